@@ -4,11 +4,17 @@ package application
 import (
 	"cloud.google.com/go/datastore"
 	"cloud.google.com/go/pubsub"
+	"database/sql"
+	"errors"
+	"github.com/asiragusa/wschat/blob"
 	"github.com/asiragusa/wschat/controller"
 	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/mail"
+	"github.com/asiragusa/wschat/memory"
 	"github.com/asiragusa/wschat/middleware"
 	"github.com/asiragusa/wschat/repository"
 	"github.com/asiragusa/wschat/services"
+	"github.com/asiragusa/wschat/sqlstore"
 	"github.com/asiragusa/wschat/validator"
 	"github.com/asiragusa/wschat/ws"
 	"github.com/facebookgo/inject"
@@ -17,16 +23,58 @@ import (
 	"github.com/kataras/iris/context"
 	"github.com/kataras/iris/core/router"
 	"github.com/kataras/iris/websocket"
+	"time"
+)
+
+const (
+	// Google cloud's datastore and pubsub backend
+	DatastoreBackend = "datastore"
+
+	// In-memory backend
+	MemoryBackend = "memory"
+
+	// SQLite storage with in-process pubsub, for single instance deployments
+	SqliteBackend = "sqlite"
 )
 
 // AppConfig contains the app configuration
 type AppConfig struct {
-	// JwtSecret is the secret for encrypting JWT Tokens
+	// Backend is the storage and pubsub backend, DatastoreBackend if empty
+	Backend string
+
+	// MemoryStore contains the data of the MemoryBackend. A new store is created if nil
+	MemoryStore *memory.Store
+
+	// DB is the database of the SqliteBackend, see sqlstore.Open
+	DB *sql.DB
+
+	// JwtSecret is the secret for encrypting JWT Tokens, used only if JwtKeys is empty
 	JwtSecret string
 
+	// JwtKeys are the RSA or EC keys of the JWT Tokens. The first one signs the new tokens, the others only validate
+	// them and can be public keys, see services.KeySet
+	JwtKeys []*services.Key
+
 	// JwtIssuer is the issuer for the JWT Token eg. http://api.example.com
 	JwtIssuer string
 
+	// BlobStorage stores the content of the attachments. Required
+	BlobStorage blob.Storage
+
+	// MailSender sends the emails, eg. the password reset tokens. Required
+	MailSender mail.Sender
+
+	// Oidc is the OpenID Connect provider the users can log in with, as an alternative to their password. The login
+	// with OpenID Connect is disabled if nil
+	Oidc *services.OidcConfig
+
+	// RequireVerifiedEmail refuses to let the users send messages until they have verified their email
+	RequireVerifiedEmail bool
+
+	// EditWindow is the duration after the creation during which a message can be edited.
+	// interactor.DefaultEditWindow if zero
+	EditWindow time.Duration
+
 	// DatastoreClient is the client for google cloud's datastore
 	DatastoreClient *datastore.Client
 
@@ -50,6 +98,9 @@ type Route struct {
 	// Can contain an Iris party or *iris.Application
 	Party router.Party
 
+	// Handlers run before the controller, after the ones of the party
+	Middleware []context.Handler
+
 	// Request handler
 	Controller Controller
 }
@@ -61,13 +112,38 @@ type Application struct {
 	routes []Route
 
 	graph []*inject.Object
+
+	// Keys signing and validating the JWT Tokens
+	keys *services.KeySet
+
+	// Deletes the orphaned pubsub subscriptions. Only available with the DatastoreBackend
+	reaper *services.Reaper
+
+	// Message repository of the DatastoreBackend, backfilling the messages stored by the previous versions
+	datastoreMessages *repository.Message
+
+	// User repository of the DatastoreBackend, backfilling the users stored by the previous versions
+	datastoreUsers *repository.User
 }
 
 // Creates a new Application
 func NewApplication(config *AppConfig) (*Application, error) {
+	if config.BlobStorage == nil {
+		return nil, errors.New("The blob storage is required")
+	}
+	if config.MailSender == nil {
+		return nil, errors.New("The mail sender is required")
+	}
+
+	keys, err := newKeySet(config)
+	if err != nil {
+		return nil, err
+	}
+
 	app := &Application{
 		config:  config,
 		irisApp: iris.New(),
+		keys:    keys,
 	}
 
 	app.getInjectObjects()
@@ -82,6 +158,15 @@ func NewApplication(config *AppConfig) (*Application, error) {
 	return app, nil
 }
 
+// Creates the key set of the JWT Tokens from the configuration
+func newKeySet(config *AppConfig) (*services.KeySet, error) {
+	if len(config.JwtKeys) == 0 {
+		return services.NewKeySet(services.NewHmacKey(config.JwtSecret))
+	}
+
+	return services.NewKeySet(config.JwtKeys[0], config.JwtKeys[1:]...)
+}
+
 // Init static routes
 func (a *Application) initStatic() {
 	a.irisApp.Get("/", func(ctx context.Context) {
@@ -94,37 +179,159 @@ func (a *Application) initStatic() {
 func (a *Application) getInjectObjects() {
 	a.injectNamed(
 		"accessTokenGenerator",
-		services.NewTokenGenerator(a.config.JwtSecret, a.config.JwtIssuer, "access"))
+		services.NewTokenGenerator(a.keys, a.config.JwtIssuer, "access"))
 
 	a.injectNamed(
 		"wsTokenGenerator",
-		services.NewTokenGenerator(a.config.JwtSecret, a.config.JwtIssuer, "ws"))
+		services.NewTokenGenerator(a.keys, a.config.JwtIssuer, "ws"))
 
-	a.inject(a.config.DatastoreClient)
-	a.inject(a.config.PubsubClient)
+	a.injectNamed(
+		"mfaTokenGenerator",
+		services.NewTokenGenerator(a.keys, a.config.JwtIssuer, services.MfaAudience))
 
+	a.inject(a.keys)
 	a.inject(clockwork.NewRealClock())
-
-	a.inject(repository.NewUserRepository())
-	a.inject(repository.NewMessageRepository())
-	a.inject(repository.NewSubscriptionRepository())
-
-	a.inject(services.NewPubsubClient())
+	a.inject(a.config.BlobStorage)
+	a.inject(a.config.MailSender)
+
+	switch a.config.Backend {
+	case MemoryBackend:
+		a.injectMemoryBackend()
+	case SqliteBackend:
+		a.injectSqliteBackend()
+	default:
+		a.injectDatastoreBackend()
+	}
 
 	a.inject(validator.NewValidator())
+	a.inject(services.NewPresenceTracker())
+	a.inject(services.NewRefreshTokenIssuer())
+	a.inject(services.NewSessionRevoker())
+	a.inject(services.NewPasswordResetIssuer())
+	a.inject(services.NewTotpAuthenticator("wschat"))
+	a.inject(services.NewLoginThrottler())
+	a.injectNamed("passwordResetThrottler", services.NewPasswordResetThrottler())
+	a.inject(services.NewEmailVerifier(a.keys, a.config.JwtIssuer))
 
 	a.inject(interactor.NewRegisterInteractor())
 	a.inject(interactor.NewLoginInteractor())
+	a.inject(interactor.NewRefreshTokenInteractor())
+	a.inject(interactor.NewLogoutInteractor())
+	a.inject(interactor.NewLogoutAllInteractor())
+	a.inject(interactor.NewListSessionsInteractor())
+	a.inject(interactor.NewDeleteSessionInteractor())
+	a.inject(interactor.NewChangePasswordInteractor())
+	a.inject(interactor.NewForgotPasswordInteractor())
+	a.inject(interactor.NewResetPasswordInteractor())
+	a.inject(interactor.NewVerifyEmailInteractor())
+	a.inject(interactor.NewResendVerificationInteractor())
+	a.inject(interactor.NewLoginMfaInteractor())
+	a.inject(interactor.NewJwksInteractor())
+	a.inject(interactor.NewEnrollTotpInteractor())
+	a.inject(interactor.NewConfirmTotpInteractor())
+	a.inject(interactor.NewDisableTotpInteractor())
 	a.inject(interactor.NewListMessagesInteractor())
+	a.inject(interactor.NewSearchMessagesInteractor())
 	a.inject(interactor.NewListUsersInteractor())
 	a.inject(interactor.NewCreateMessageInteractor())
 	a.inject(interactor.NewWsTokenInteractor())
+	a.inject(interactor.NewCreateConversationInteractor())
+	a.inject(interactor.NewListConversationsInteractor())
+	a.inject(interactor.NewAddConversationMemberInteractor())
+	a.inject(interactor.NewLeaveConversationInteractor())
+	a.inject(interactor.NewRenameConversationInteractor())
+	a.inject(interactor.NewCreateConversationMessageInteractor())
+	a.inject(interactor.NewReadMessageInteractor())
+	a.inject(interactor.NewTypingInteractor())
+	a.inject(interactor.NewDeleteMessageInteractor())
+	a.inject(interactor.NewAddReactionInteractor())
+	a.inject(interactor.NewRemoveReactionInteractor())
+	a.inject(interactor.NewUploadAttachmentInteractor())
+	a.inject(interactor.NewDownloadAttachmentInteractor())
+
+	if a.config.Oidc != nil {
+		a.inject(services.NewOidcProvider(*a.config.Oidc))
+		a.inject(interactor.NewOidcAuthorizeInteractor())
+		a.inject(interactor.NewOidcLoginInteractor())
+	}
+
+	editWindow := a.config.EditWindow
+	if editWindow == 0 {
+		editWindow = interactor.DefaultEditWindow
+	}
+	a.inject(interactor.NewEditMessageInteractor(editWindow))
+}
+
+// Injects the repositories and the pubsub client using google cloud's datastore and pubsub
+func (a *Application) injectDatastoreBackend() {
+	a.inject(a.config.DatastoreClient)
+	a.inject(a.config.PubsubClient)
+
+	a.datastoreUsers = repository.NewUserRepository()
+	a.inject(a.datastoreUsers)
+	a.datastoreMessages = repository.NewMessageRepository()
+	a.inject(a.datastoreMessages)
+	a.inject(repository.NewSubscriptionRepository())
+	a.inject(repository.NewConversationRepository())
+	a.inject(repository.NewDeliveryRepository())
+	a.inject(repository.NewAttachmentRepository())
+	a.inject(repository.NewRefreshTokenRepository())
+	a.inject(repository.NewSessionRepository())
+	a.inject(repository.NewPasswordResetRepository())
+	a.inject(repository.NewOidcFlowRepository())
+	a.inject(repository.NewLoginAttemptRepository())
+
+	a.inject(services.NewPubsubClient())
+
+	a.reaper = services.NewReaper()
+	a.inject(a.reaper)
+}
+
+// Injects the in-memory repositories and pubsub client
+func (a *Application) injectMemoryBackend() {
+	if a.config.MemoryStore == nil {
+		a.config.MemoryStore = memory.NewStore()
+	}
+	a.inject(a.config.MemoryStore)
+
+	a.inject(memory.NewUserRepository())
+	a.inject(memory.NewMessageRepository())
+	a.inject(memory.NewSubscriptionRepository())
+	a.inject(memory.NewConversationRepository())
+	a.inject(memory.NewDeliveryRepository())
+	a.inject(memory.NewAttachmentRepository())
+	a.inject(memory.NewRefreshTokenRepository())
+	a.inject(memory.NewSessionRepository())
+	a.inject(memory.NewPasswordResetRepository())
+	a.inject(memory.NewOidcFlowRepository())
+	a.inject(memory.NewLoginAttemptRepository())
+
+	a.inject(memory.NewPubsubClient())
+}
+
+// Injects the SQL repositories and the in-memory pubsub client
+func (a *Application) injectSqliteBackend() {
+	a.inject(a.config.DB)
+
+	a.inject(sqlstore.NewUserRepository())
+	a.inject(sqlstore.NewMessageRepository())
+	a.inject(sqlstore.NewSubscriptionRepository())
+	a.inject(sqlstore.NewConversationRepository())
+	a.inject(sqlstore.NewDeliveryRepository())
+	a.inject(sqlstore.NewAttachmentRepository())
+	a.inject(sqlstore.NewRefreshTokenRepository())
+	a.inject(sqlstore.NewSessionRepository())
+	a.inject(sqlstore.NewPasswordResetRepository())
+	a.inject(sqlstore.NewOidcFlowRepository())
+	a.inject(sqlstore.NewLoginAttemptRepository())
+
+	a.inject(memory.NewPubsubClient())
 }
 
 // Initializes the websocket endpoint
 func (a *Application) initWs() {
 	wsMiddleware := middleware.NewWsMiddleware()
-	wsHandler := ws.NewWsHandler()
+	wsHandler := ws.NewWsHandler(a.config.RequireVerifiedEmail)
 
 	a.inject(wsMiddleware)
 	a.inject(wsHandler)
@@ -145,12 +352,22 @@ func (a *Application) initWs() {
 
 // Initializes the routes
 func (a *Application) getRoutes() {
-	authenticatedMiddleware := middleware.NewAuthenticatedMiddleware()
+	authenticatedMiddleware := middleware.NewAuthenticatedMiddleware(a.config.RequireVerifiedEmail)
 	a.inject(authenticatedMiddleware)
 
+	// Handlers of the routes sending messages
+	verified := []context.Handler{authenticatedMiddleware.Verified}
+
 	messagesParty := a.irisApp.Party("/messages", authenticatedMiddleware.Handle)
 	usersParty := a.irisApp.Party("/users", authenticatedMiddleware.Handle)
 	wsTokenParty := a.irisApp.Party("/wsToken", authenticatedMiddleware.Handle)
+	conversationsParty := a.irisApp.Party("/conversations", authenticatedMiddleware.Handle)
+	attachmentsParty := a.irisApp.Party("/attachments", authenticatedMiddleware.Handle)
+	logoutParty := a.irisApp.Party("/logout", authenticatedMiddleware.Handle)
+	sessionsParty := a.irisApp.Party("/sessions", authenticatedMiddleware.Handle)
+	passwordParty := a.irisApp.Party("/password", authenticatedMiddleware.Handle)
+	totpParty := a.irisApp.Party("/mfa/totp", authenticatedMiddleware.Handle)
+	verifyParty := a.irisApp.Party("/verify/resend", authenticatedMiddleware.Handle)
 
 	a.routes = []Route{
 		{
@@ -165,18 +382,145 @@ func (a *Application) getRoutes() {
 			Party:      a.irisApp,
 			Controller: controller.NewLoginController(),
 		},
+		{
+			Method:     iris.MethodGet,
+			Path:       "/.well-known/jwks.json",
+			Party:      a.irisApp,
+			Controller: controller.NewJwksController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/verify",
+			Party:      a.irisApp,
+			Controller: controller.NewVerifyEmailController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/",
+			Party:      verifyParty,
+			Controller: controller.NewResendVerificationController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/login/mfa",
+			Party:      a.irisApp,
+			Controller: controller.NewLoginMfaController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/token/refresh",
+			Party:      a.irisApp,
+			Controller: controller.NewRefreshTokenController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/password/forgot",
+			Party:      a.irisApp,
+			Controller: controller.NewForgotPasswordController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/password/reset",
+			Party:      a.irisApp,
+			Controller: controller.NewResetPasswordController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/",
+			Party:      passwordParty,
+			Controller: controller.NewChangePasswordController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/",
+			Party:      totpParty,
+			Controller: controller.NewEnrollTotpController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/confirm",
+			Party:      totpParty,
+			Controller: controller.NewConfirmTotpController(),
+		},
+		{
+			Method:     iris.MethodDelete,
+			Path:       "/",
+			Party:      totpParty,
+			Controller: controller.NewDisableTotpController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/",
+			Party:      logoutParty,
+			Controller: controller.NewLogoutController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/all",
+			Party:      logoutParty,
+			Controller: controller.NewLogoutAllController(),
+		},
+		{
+			Method:     iris.MethodGet,
+			Path:       "/",
+			Party:      sessionsParty,
+			Controller: controller.NewListSessionsController(),
+		},
+		{
+			Method:     iris.MethodDelete,
+			Path:       "/{id:string}",
+			Party:      sessionsParty,
+			Controller: controller.NewDeleteSessionController(),
+		},
 		{
 			Method:     iris.MethodGet,
 			Path:       "/",
 			Party:      messagesParty,
 			Controller: controller.NewListMessagesController(),
 		},
+		{
+			Method:     iris.MethodGet,
+			Path:       "/search",
+			Party:      messagesParty,
+			Controller: controller.NewSearchMessagesController(),
+		},
 		{
 			Method:     iris.MethodPost,
 			Path:       "/",
 			Party:      messagesParty,
+			Middleware: verified,
 			Controller: controller.NewCreateMessageController(),
 		},
+		{
+			Method:     iris.MethodPatch,
+			Path:       "/{id:string}",
+			Party:      messagesParty,
+			Controller: controller.NewEditMessageController(),
+		},
+		{
+			Method:     iris.MethodDelete,
+			Path:       "/{id:string}",
+			Party:      messagesParty,
+			Controller: controller.NewDeleteMessageController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/{id:string}/read",
+			Party:      messagesParty,
+			Controller: controller.NewReadMessageController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/{id:string}/reactions",
+			Party:      messagesParty,
+			Controller: controller.NewAddReactionController(),
+		},
+		{
+			Method:     iris.MethodDelete,
+			Path:       "/{id:string}/reactions",
+			Party:      messagesParty,
+			Controller: controller.NewRemoveReactionController(),
+		},
 		{
 			Method:     iris.MethodGet,
 			Path:       "/",
@@ -189,6 +533,73 @@ func (a *Application) getRoutes() {
 			Party:      wsTokenParty,
 			Controller: controller.NewWsTokenController(),
 		},
+		{
+			Method:     iris.MethodGet,
+			Path:       "/",
+			Party:      conversationsParty,
+			Controller: controller.NewListConversationsController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/",
+			Party:      conversationsParty,
+			Controller: controller.NewCreateConversationController(),
+		},
+		{
+			Method:     iris.MethodPatch,
+			Path:       "/{id:string}",
+			Party:      conversationsParty,
+			Controller: controller.NewRenameConversationController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/{id:string}/members",
+			Party:      conversationsParty,
+			Controller: controller.NewAddConversationMemberController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/{id:string}/leave",
+			Party:      conversationsParty,
+			Controller: controller.NewLeaveConversationController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/{id:string}/messages",
+			Party:      conversationsParty,
+			Middleware: verified,
+			Controller: controller.NewCreateConversationMessageController(),
+		},
+		{
+			Method:     iris.MethodPost,
+			Path:       "/",
+			Party:      attachmentsParty,
+			Middleware: verified,
+			Controller: controller.NewUploadAttachmentController(),
+		},
+		{
+			Method:     iris.MethodGet,
+			Path:       "/{id:string}",
+			Party:      attachmentsParty,
+			Controller: controller.NewDownloadAttachmentController(),
+		},
+	}
+
+	if a.config.Oidc != nil {
+		a.routes = append(a.routes,
+			Route{
+				Method:     iris.MethodPost,
+				Path:       "/oidc/authorize",
+				Party:      a.irisApp,
+				Controller: controller.NewOidcAuthorizeController(),
+			},
+			Route{
+				Method:     iris.MethodPost,
+				Path:       "/oidc/login",
+				Party:      a.irisApp,
+				Controller: controller.NewOidcLoginController(),
+			},
+		)
 	}
 }
 
@@ -208,7 +619,8 @@ func (a *Application) init() error {
 	}
 
 	for _, route := range a.routes {
-		route.Party.Handle(route.Method, route.Path, route.Controller.Handle)
+		handlers := append(route.Middleware, route.Controller.Handle)
+		route.Party.Handle(route.Method, route.Path, handlers...)
 	}
 
 	return nil
@@ -228,3 +640,18 @@ func (a *Application) injectNamed(name string, v interface{}) {
 func (a *Application) GetRouter() *iris.Application {
 	return a.irisApp
 }
+
+// Returns the Reaper of the backend, nil if the backend doesn't need it
+func (a *Application) GetReaper() *services.Reaper {
+	return a.reaper
+}
+
+// Returns the Message repository of the DatastoreBackend, nil with the other backends
+func (a *Application) GetDatastoreMessages() *repository.Message {
+	return a.datastoreMessages
+}
+
+// Returns the User repository of the DatastoreBackend, nil with the other backends
+func (a *Application) GetDatastoreUsers() *repository.User {
+	return a.datastoreUsers
+}
@@ -1,16 +1,35 @@
 package application
 
 import (
+	"bytes"
 	"cloud.google.com/go/datastore"
 	"cloud.google.com/go/pubsub"
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"github.com/asiragusa/wschat/blob"
+	"github.com/asiragusa/wschat/mail"
+	"github.com/asiragusa/wschat/services"
+	"github.com/asiragusa/wschat/services/oidctest"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/iris-contrib/httpexpect"
 	"github.com/kataras/iris"
 	"github.com/kataras/iris/httptest"
 	"github.com/stretchr/testify/suite"
 	"golang.org/x/net/websocket"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -18,8 +37,15 @@ import (
 
 type ApplicationTestSuite struct {
 	suite.Suite
-	app *Application
-	e   *httpexpect.Expect
+	app     *Application
+	e       *httpexpect.Expect
+	blobDir string
+
+	// OpenID Connect provider the users can log in with
+	oidcProvider *oidctest.Provider
+
+	// Emails sent by the application
+	mails bytes.Buffer
 }
 
 func TestApplication(t *testing.T) {
@@ -59,17 +85,51 @@ func getPubsubClient(projectID string) (*pubsub.Client, error) {
 }
 
 func (suite *ApplicationTestSuite) SetupSuite() {
-	datastoreClient, err := getDatastoreClient("test")
+	blobDir, err := ioutil.TempDir("", "wschat")
 	suite.Require().NoError(err)
+	suite.blobDir = blobDir
 
-	pubsubClient, err := getPubsubClient("test")
+	blobStorage, err := blob.NewFilesystemStorage(blobDir)
+	suite.Require().NoError(err)
+
+	// The tokens are signed with RS256
+	rsaKey, err := rsa.GenerateKey(rand.Reader, services.MinRsaKeySize)
+	suite.Require().NoError(err)
+	jwtKey, err := services.ParseKey(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+	}))
+	suite.Require().NoError(err)
+
+	suite.oidcProvider, err = oidctest.NewProvider("wschat", "client secret")
 	suite.Require().NoError(err)
 
 	appConfig := &AppConfig{
-		JwtSecret:       "secret",
-		JwtIssuer:       "http://localhost",
-		DatastoreClient: datastoreClient,
-		PubsubClient:    pubsubClient,
+		JwtSecret:   "secret",
+		JwtKeys:     []*services.Key{jwtKey},
+		JwtIssuer:   "http://localhost",
+		BlobStorage: blobStorage,
+		MailSender:  mail.NewLogSender(&suite.mails, "noreply@localhost"),
+		Oidc: &services.OidcConfig{
+			Issuer:       suite.oidcProvider.Issuer(),
+			ClientId:     "wschat",
+			ClientSecret: "client secret",
+			RedirectUrl:  "http://localhost/?oidc=callback",
+		},
+	}
+
+	// Use the in-memory backend if the emulators are not available
+	if os.Getenv("DATASTORE_EMULATOR_HOST") == "" || os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		appConfig.Backend = MemoryBackend
+	} else {
+		datastoreClient, err := getDatastoreClient("test")
+		suite.Require().NoError(err)
+
+		pubsubClient, err := getPubsubClient("test")
+		suite.Require().NoError(err)
+
+		appConfig.DatastoreClient = datastoreClient
+		appConfig.PubsubClient = pubsubClient
 	}
 
 	app, err := NewApplication(appConfig)
@@ -86,11 +146,22 @@ func (suite *ApplicationTestSuite) SetupSuite() {
 }
 
 func (suite *ApplicationTestSuite) SetupTest() {
+	suite.mails.Reset()
+	suite.oidcProvider.Email = defaultEmail
+	suite.oidcProvider.EmailVerified = true
+
+	if suite.app.config.Backend == MemoryBackend {
+		suite.app.config.MemoryStore.Clear()
+		return
+	}
+
 	suite.wipeoutDatastoreData(suite.app.config.DatastoreClient)
 }
 
 func (suite *ApplicationTestSuite) TearDownSuite() {
 	suite.app.irisApp.Shutdown(context.Background())
+	suite.oidcProvider.Close()
+	os.RemoveAll(suite.blobDir)
 }
 
 // Add auth header for the request
@@ -210,6 +281,65 @@ func (suite *ApplicationTestSuite) TestLoginBadPassword() {
 	})
 }
 
+// Test POST /login after too many bad passwords
+func (suite *ApplicationTestSuite) TestLoginLockout() {
+	suite.validRegister()
+
+	loginRequest := map[string]string{
+		"email":    defaultEmail,
+		"password": "invalidPassword",
+	}
+	for i := 0; i < services.LoginFreeAttemptsPerEmail; i++ {
+		suite.e.POST("/login").WithJSON(loginRequest).Expect().Status(httptest.StatusUnauthorized)
+	}
+
+	// The valid password is rejected as well, until the backoff has elapsed
+	loginRequest["password"] = defaultPassword
+	expect := suite.e.POST("/login").WithJSON(loginRequest).Expect()
+	expect.Status(httptest.StatusTooManyRequests)
+	expect.Header("Retry-After").Equal("1")
+
+	json := expect.JSON().Object()
+	json.Equal(map[string]interface{}{
+		"code":       httptest.StatusTooManyRequests,
+		"message":    "Too Many Requests",
+		"retryAfter": 1,
+	})
+}
+
+// Test GET /.well-known/jwks.json, validating an access token with the published key
+func (suite *ApplicationTestSuite) TestJwks() {
+	token := suite.validRegister()
+
+	expect := suite.e.GET("/.well-known/jwks.json").Expect()
+	expect.Status(httptest.StatusOK)
+
+	keys := expect.JSON().Object().Value("keys").Array()
+	keys.Length().Equal(1)
+	jwk := keys.Element(0).Object()
+	jwk.ValueEqual("kty", "RSA")
+	jwk.ValueEqual("use", "sig")
+	jwk.ValueEqual("alg", "RS256")
+
+	// Rebuild the public key as another service would
+	n, err := base64.RawURLEncoding.DecodeString(jwk.Value("n").String().Raw())
+	suite.Require().NoError(err)
+	e, err := base64.RawURLEncoding.DecodeString(jwk.Value("e").String().Raw())
+	suite.Require().NoError(err)
+	public := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		suite.Equal(jwk.Value("kid").String().Raw(), token.Header["kid"])
+		return public, nil
+	})
+	suite.Require().NoError(err)
+	suite.True(parsed.Valid)
+	suite.Equal("RS256", parsed.Method.Alg())
+}
+
 // Test POST /login OK
 func (suite *ApplicationTestSuite) TestLoginOK() {
 	suite.validRegister()
@@ -225,6 +355,471 @@ func (suite *ApplicationTestSuite) TestLoginOK() {
 
 	json := expect.JSON().Object()
 	json.Value("accessToken").String().NotEmpty()
+	json.Value("refreshToken").String().NotEmpty()
+	json.Value("expiresIn").Equal(900)
+}
+
+// Exchanges the refresh token, expecting the given status
+func (suite *ApplicationTestSuite) refreshToken(token string, status int) *httpexpect.Object {
+	request := suite.e.POST("/token/refresh").WithJSON(map[string]string{
+		"refreshToken": token,
+	})
+
+	expect := request.Expect()
+	expect.Status(status)
+
+	return expect.JSON().Object()
+}
+
+// Test POST /token/refresh with an unknown token
+func (suite *ApplicationTestSuite) TestRefreshTokenInvalid() {
+	json := suite.refreshToken("invalid", httptest.StatusUnauthorized)
+	json.Equal(map[string]interface{}{
+		"code":    httptest.StatusUnauthorized,
+		"message": "Unauthorized",
+	})
+}
+
+// Test POST /token/refresh OK, then the reuse of the exchanged token
+func (suite *ApplicationTestSuite) TestRefreshTokenRotation() {
+	request := suite.e.POST("/register").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+	})
+	first := request.Expect().JSON().Object().Value("refreshToken").String().Raw()
+
+	json := suite.refreshToken(first, httptest.StatusOK)
+	json.Value("refreshToken").String().NotEqual(first)
+	json.Value("expiresIn").Equal(900)
+	second := json.Value("refreshToken").String().Raw()
+
+	// The new access token is valid
+	users := suite.e.GET("/users")
+	suite.authorize(users, json.Value("accessToken").String().Raw())
+	users.Expect().Status(httptest.StatusOK)
+
+	// Reusing the first token revokes the whole family, the second token included
+	suite.refreshToken(first, httptest.StatusUnauthorized)
+	suite.refreshToken(second, httptest.StatusUnauthorized)
+}
+
+// Test POST /logout: the tokens of the session can't be used anymore
+func (suite *ApplicationTestSuite) TestLogout() {
+	json := suite.e.POST("/register").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+	}).Expect().JSON().Object()
+	accessToken := json.Value("accessToken").String().Raw()
+	refreshToken := json.Value("refreshToken").String().Raw()
+
+	request := suite.e.POST("/logout")
+	suite.authorize(request, accessToken)
+	request.Expect().Status(httptest.StatusNoContent)
+
+	request = suite.e.GET("/users")
+	suite.authorize(request, accessToken)
+	request.Expect().Status(httptest.StatusUnauthorized)
+
+	suite.refreshToken(refreshToken, httptest.StatusUnauthorized)
+}
+
+// Test GET /sessions and DELETE /sessions/{id}: revoking a session invalidates its tokens only
+func (suite *ApplicationTestSuite) TestSessions() {
+	first := suite.e.POST("/register").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+		"device":   "laptop",
+	}).Expect().JSON().Object()
+	firstToken := first.Value("accessToken").String().Raw()
+
+	second := suite.e.POST("/login").WithHeader("User-Agent", "test-agent").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+		"device":   "phone",
+	}).Expect().JSON().Object()
+	secondToken := second.Value("accessToken").String().Raw()
+
+	request := suite.e.GET("/sessions")
+	suite.authorize(request, secondToken)
+	json := request.Expect().Status(httptest.StatusOK).JSON().Object()
+	json.Value("total").Equal(2)
+
+	// The most recently used session comes first
+	items := json.Value("items").Array()
+	items.Element(0).Object().Value("device").Equal("phone")
+	items.Element(0).Object().Value("userAgent").Equal("test-agent")
+	items.Element(0).Object().Value("current").Equal(true)
+	items.Element(1).Object().Value("device").Equal("laptop")
+	items.Element(1).Object().Value("current").Equal(false)
+	firstId := items.Element(1).Object().Value("id").String().Raw()
+
+	request = suite.e.DELETE("/sessions/" + firstId)
+	suite.authorize(request, secondToken)
+	request.Expect().Status(httptest.StatusNoContent)
+
+	// The tokens of the revoked session are rejected
+	request = suite.e.GET("/users")
+	suite.authorize(request, firstToken)
+	request.Expect().Status(httptest.StatusUnauthorized)
+	suite.refreshToken(first.Value("refreshToken").String().Raw(), httptest.StatusUnauthorized)
+
+	request = suite.e.DELETE("/sessions/" + firstId)
+	suite.authorize(request, secondToken)
+	request.Expect().Status(httptest.StatusNotFound)
+
+	request = suite.e.GET("/sessions")
+	suite.authorize(request, secondToken)
+	request.Expect().Status(httptest.StatusOK).JSON().Object().Value("total").Equal(1)
+}
+
+// Test DELETE /sessions/{id} with the session of another user
+func (suite *ApplicationTestSuite) TestDeleteSessionOfAnotherUser() {
+	token := suite.validRegister()
+	otherToken := suite.validRegisterWithUser("a@b.com")
+
+	request := suite.e.GET("/sessions")
+	suite.authorize(request, token)
+	id := request.Expect().JSON().Object().Value("items").Array().Element(0).Object().Value("id").String().Raw()
+
+	request = suite.e.DELETE("/sessions/" + id)
+	suite.authorize(request, otherToken)
+	request.Expect().Status(httptest.StatusNotFound)
+
+	request = suite.e.GET("/users")
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusOK)
+}
+
+// Test POST /logout/all: all the tokens of the user are invalidated
+func (suite *ApplicationTestSuite) TestLogoutAll() {
+	json := suite.e.POST("/register").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+	}).Expect().JSON().Object()
+	accessToken := json.Value("accessToken").String().Raw()
+	refreshToken := json.Value("refreshToken").String().Raw()
+
+	request := suite.e.POST("/logout/all")
+	suite.authorize(request, accessToken)
+	request.Expect().Status(httptest.StatusNoContent)
+
+	request = suite.e.GET("/users")
+	suite.authorize(request, accessToken)
+	request.Expect().Status(httptest.StatusUnauthorized)
+
+	suite.refreshToken(refreshToken, httptest.StatusUnauthorized)
+}
+
+// Test POST /password: the old password can't be used anymore and the user is signed out everywhere else
+func (suite *ApplicationTestSuite) TestChangePassword() {
+	token := suite.validRegister()
+
+	request := suite.e.POST("/password").WithJSON(map[string]string{
+		"currentPassword": "badPassword",
+		"newPassword":     "newPassword",
+	})
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusForbidden)
+
+	request = suite.e.POST("/password").WithJSON(map[string]string{
+		"currentPassword": defaultPassword,
+		"newPassword":     "newPassword",
+	})
+	suite.authorize(request, token)
+	json := request.Expect().Status(httptest.StatusOK).JSON().Object()
+	json.Value("refreshToken").String().NotEmpty()
+	newToken := json.Value("accessToken").String().Raw()
+
+	// The previous tokens are revoked, the client stays signed in with the new ones
+	request = suite.e.GET("/users")
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusUnauthorized)
+
+	request = suite.e.GET("/users")
+	suite.authorize(request, newToken)
+	request.Expect().Status(httptest.StatusOK)
+
+	suite.e.POST("/login").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+	}).Expect().Status(httptest.StatusUnauthorized)
+
+	suite.e.POST("/login").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": "newPassword",
+	}).Expect().Status(httptest.StatusOK)
+}
+
+// Matches the token in a password reset email
+var resetTokenRegexp = regexp.MustCompile(`\r\n\r\n([A-Za-z0-9_-]{43})\r\n`)
+
+// Test POST /password/forgot and POST /password/reset
+func (suite *ApplicationTestSuite) TestResetPassword() {
+	token := suite.validRegister()
+	suite.mails.Reset()
+
+	// Nothing is sent to the unknown users
+	suite.e.POST("/password/forgot").WithJSON(map[string]string{
+		"email": "unknown@test.com",
+	}).Expect().Status(httptest.StatusNoContent)
+	suite.Empty(suite.mails.String())
+
+	suite.e.POST("/password/forgot").WithJSON(map[string]string{
+		"email": defaultEmail,
+	}).Expect().Status(httptest.StatusNoContent)
+
+	matches := resetTokenRegexp.FindStringSubmatch(suite.mails.String())
+	suite.Require().Len(matches, 2)
+	suite.Contains(suite.mails.String(), "To: "+defaultEmail+"\r\n")
+
+	reset := map[string]string{
+		"token":    matches[1],
+		"password": "newPassword",
+	}
+	suite.e.POST("/password/reset").WithJSON(reset).Expect().Status(httptest.StatusNoContent)
+
+	// The token can be used only once
+	suite.e.POST("/password/reset").WithJSON(reset).Expect().Status(httptest.StatusUnauthorized)
+
+	// The user has been signed out everywhere
+	request := suite.e.GET("/users")
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusUnauthorized)
+
+	suite.e.POST("/login").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": "newPassword",
+	}).Expect().Status(httptest.StatusOK)
+}
+
+var verifyLinkRegexp = regexp.MustCompile(`\r\nhttp://localhost/\?verify=([A-Za-z0-9_.-]+)\r\n`)
+
+// Test POST /verify with the link sent on registration
+func (suite *ApplicationTestSuite) TestVerifyEmail() {
+	token := suite.validRegister()
+
+	matches := verifyLinkRegexp.FindStringSubmatch(suite.mails.String())
+	suite.Require().Len(matches, 2)
+	suite.Contains(suite.mails.String(), "To: "+defaultEmail+"\r\n")
+
+	// A new link can be asked until the email is verified
+	suite.mails.Reset()
+	request := suite.e.POST("/verify/resend")
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusNoContent)
+	suite.Regexp(verifyLinkRegexp, suite.mails.String())
+
+	suite.e.POST("/verify").WithJSON(map[string]string{
+		"token": "invalid",
+	}).Expect().Status(httptest.StatusUnauthorized)
+
+	verify := map[string]string{
+		"token": matches[1],
+	}
+	suite.e.POST("/verify").WithJSON(verify).Expect().Status(httptest.StatusNoContent)
+
+	// The link can be followed again
+	suite.e.POST("/verify").WithJSON(verify).Expect().Status(httptest.StatusNoContent)
+
+	request = suite.e.POST("/verify/resend")
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusConflict)
+}
+
+// Logs in at the OpenID Connect provider, following the redirections like the browser would do. Returns the body
+// sent to POST /oidc/login
+func (suite *ApplicationTestSuite) oidcAuthorize() map[string]string {
+	expect := suite.e.POST("/oidc/authorize").Expect()
+	expect.Status(httptest.StatusOK)
+
+	json := expect.JSON().Object()
+	json.ValueEqual("expiresIn", 600)
+
+	code, state, err := suite.oidcProvider.Login(json.Value("authorizationUrl").String().Raw())
+	suite.Require().NoError(err)
+
+	return map[string]string{
+		"code":      code,
+		"state":     state,
+		"flowToken": json.Value("flowToken").String().Raw(),
+	}
+}
+
+// Test the OpenID Connect login of a new user
+func (suite *ApplicationTestSuite) TestOidcLoginNewUser() {
+	login := suite.oidcAuthorize()
+
+	expect := suite.e.POST("/oidc/login").WithJSON(login).Expect()
+	expect.Status(httptest.StatusOK)
+	json := expect.JSON().Object()
+	json.ValueEqual("email", defaultEmail)
+	token := json.Value("accessToken").String().Raw()
+
+	// The user has been created with the verified email
+	request := suite.e.POST("/verify/resend")
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusConflict)
+
+	// But without password
+	suite.e.POST("/login").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+	}).Expect().Status(httptest.StatusUnauthorized)
+
+	// The code can't be used twice
+	suite.e.POST("/oidc/login").WithJSON(login).Expect().Status(httptest.StatusUnauthorized)
+}
+
+// Test the OpenID Connect login of an user registered with a password
+func (suite *ApplicationTestSuite) TestOidcLoginExistingUser() {
+	suite.validRegister()
+
+	// The identity isn't linked until the user has verified its email, somebody else could have registered it
+	expect := suite.e.POST("/oidc/login").WithJSON(suite.oidcAuthorize()).Expect()
+	expect.Status(httptest.StatusConflict)
+	expect.JSON().Object().Value("details").Object().ValueEqual("email", []string{"accountNotVerified"})
+
+	matches := verifyLinkRegexp.FindStringSubmatch(suite.mails.String())
+	suite.Require().Len(matches, 2)
+	suite.e.POST("/verify").WithJSON(map[string]string{
+		"token": matches[1],
+	}).Expect().Status(httptest.StatusNoContent)
+
+	expect = suite.e.POST("/oidc/login").WithJSON(suite.oidcAuthorize()).Expect()
+	expect.Status(httptest.StatusOK)
+	token := expect.JSON().Object().Value("accessToken").String().Raw()
+
+	request := suite.e.GET("/users")
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusOK).JSON().Object().ValueEqual("total", 1)
+
+	// The password still works
+	suite.e.POST("/login").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+	}).Expect().Status(httptest.StatusOK)
+}
+
+// Test the OpenID Connect login with an email the provider hasn't verified
+func (suite *ApplicationTestSuite) TestOidcLoginEmailNotVerified() {
+	suite.validRegister()
+	suite.oidcProvider.EmailVerified = false
+
+	expect := suite.e.POST("/oidc/login").WithJSON(suite.oidcAuthorize()).Expect()
+	expect.Status(httptest.StatusForbidden)
+	expect.JSON().Object().Value("details").Object().ValueEqual("email", []string{"notVerified"})
+}
+
+// Test the OpenID Connect login with a forged state
+func (suite *ApplicationTestSuite) TestOidcLoginInvalidState() {
+	login := suite.oidcAuthorize()
+	login["state"] = "forged"
+
+	suite.e.POST("/oidc/login").WithJSON(login).Expect().Status(httptest.StatusUnauthorized)
+}
+
+// Computes the current TOTP code of the base32 secret, as an authenticator app would
+func (suite *ApplicationTestSuite) totpCode(secret string) string {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	suite.Require().NoError(err)
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+// Test the enrollment of the TOTP two factor authentication and the login in two steps
+func (suite *ApplicationTestSuite) TestTotp() {
+	token := suite.validRegister()
+
+	request := suite.e.POST("/mfa/totp")
+	suite.authorize(request, token)
+	enrollment := request.Expect().Status(httptest.StatusOK).JSON().Object()
+	enrollment.Value("uri").String().Contains("otpauth://totp/")
+	secret := enrollment.Value("secret").String().Raw()
+
+	request = suite.e.POST("/mfa/totp/confirm").WithJSON(map[string]string{"code": "000000"})
+	suite.authorize(request, token)
+	if suite.totpCode(secret) != "000000" {
+		request.Expect().Status(httptest.StatusForbidden)
+	}
+
+	request = suite.e.POST("/mfa/totp/confirm").WithJSON(map[string]string{"code": suite.totpCode(secret)})
+	suite.authorize(request, token)
+	codes := request.Expect().Status(httptest.StatusOK).JSON().Object().Value("recoveryCodes").Array()
+	codes.Length().Equal(10)
+	recoveryCode := codes.First().String().Raw()
+
+	// The password alone returns only the mfa token, which can't be used as access token
+	login := map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+	}
+	json := suite.e.POST("/login").WithJSON(login).Expect().Status(httptest.StatusOK).JSON().Object()
+	json.NotContainsKey("accessToken")
+	json.Value("mfaRequired").Equal(true)
+	json.Value("expiresIn").Equal(300)
+	mfaToken := json.Value("mfaToken").String().Raw()
+
+	request = suite.e.GET("/users")
+	suite.authorize(request, mfaToken)
+	request.Expect().Status(httptest.StatusUnauthorized)
+
+	// The pending login isn't listed in the sessions
+	request = suite.e.GET("/sessions")
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusOK).JSON().Object().Value("total").Equal(1)
+
+	suite.e.POST("/login/mfa").WithJSON(map[string]string{
+		"mfaToken": token,
+		"code":     suite.totpCode(secret),
+	}).Expect().Status(httptest.StatusUnauthorized)
+
+	suite.e.POST("/login/mfa").WithJSON(map[string]string{
+		"mfaToken": mfaToken,
+		"code":     "bad-code",
+	}).Expect().Status(httptest.StatusUnauthorized)
+
+	mfa := map[string]string{
+		"mfaToken": mfaToken,
+		"code":     recoveryCode,
+	}
+	json = suite.e.POST("/login/mfa").WithJSON(mfa).Expect().Status(httptest.StatusOK).JSON().Object()
+	json.Value("refreshToken").String().NotEmpty()
+	accessToken := json.Value("accessToken").String().Raw()
+
+	request = suite.e.GET("/users")
+	suite.authorize(request, accessToken)
+	request.Expect().Status(httptest.StatusOK)
+
+	// The mfa token can't be exchanged twice
+	mfa["code"] = suite.totpCode(secret)
+	suite.e.POST("/login/mfa").WithJSON(mfa).Expect().Status(httptest.StatusUnauthorized)
+
+	// The recovery code has been used
+	mfaToken = suite.e.POST("/login").WithJSON(login).Expect().Status(httptest.StatusOK).
+		JSON().Object().Value("mfaToken").String().Raw()
+	suite.e.POST("/login/mfa").WithJSON(map[string]string{
+		"mfaToken": mfaToken,
+		"code":     recoveryCode,
+	}).Expect().Status(httptest.StatusUnauthorized)
+
+	// Disabling the two factor authentication requires a code
+	request = suite.e.DELETE("/mfa/totp").WithJSON(map[string]string{"code": recoveryCode})
+	suite.authorize(request, accessToken)
+	request.Expect().Status(httptest.StatusForbidden)
+
+	request = suite.e.DELETE("/mfa/totp").WithJSON(map[string]string{"code": suite.totpCode(secret)})
+	suite.authorize(request, accessToken)
+	request.Expect().Status(httptest.StatusNoContent)
+
+	suite.e.POST("/login").WithJSON(login).Expect().Status(httptest.StatusOK).
+		JSON().Object().Value("accessToken").String().NotEmpty()
 }
 
 // Test POST /users with bad credentials
@@ -339,6 +934,32 @@ func (suite *ApplicationTestSuite) TestListMessagesOK() {
 	message2.Value("createdAt").String().NotEmpty()
 }
 
+// Test GET /messages/search ok
+func (suite *ApplicationTestSuite) TestSearchMessagesOK() {
+	token := suite.validRegister()
+	token1 := suite.validRegisterWithUser("a@b.com")
+	token2 := suite.validRegisterWithUser("b@b.com")
+
+	suite.createMessage(token, "a@b.com")
+	suite.createMessage(token1, "b@b.com")
+	suite.createMessage(token2, defaultEmail)
+
+	request := suite.e.GET("/messages/search").WithQuery("q", "Test").WithQuery("with", "a@b.com")
+	suite.authorize(request, token)
+
+	expect := request.Expect()
+	expect.Status(httptest.StatusOK)
+
+	json := expect.JSON().Object()
+	json.Value("total").Equal(1)
+
+	message := json.Value("items").Array().Element(0).Object()
+	message.Value("from").String().Equal(defaultEmail)
+	message.Value("to").String().Equal("a@b.com")
+	message.Value("highlight").String().Equal("<mark>test</mark>")
+	message.Value("score").Equal(1)
+}
+
 // Test POST /wsToken with bad credentials
 func (suite *ApplicationTestSuite) TestCreateWsTokenUnauthorized() {
 	request := suite.e.POST("/wsToken")
@@ -368,6 +989,76 @@ func (suite *ApplicationTestSuite) TestCreateWsTokenOK() {
 	json.Value("token").String().NotEmpty()
 }
 
+// Uploads a text attachment
+func (suite *ApplicationTestSuite) uploadAttachment(token string) *httpexpect.Object {
+	request := suite.e.POST("/attachments").WithMultipart().WithFileBytes("file", "file.txt", []byte("content"))
+	suite.authorize(request, token)
+
+	expect := request.Expect()
+	expect.Status(httptest.StatusCreated)
+
+	return expect.JSON().Object()
+}
+
+// Test POST /attachments without auth
+func (suite *ApplicationTestSuite) TestUploadAttachmentUnauthorized() {
+	suite.e.POST("/attachments").
+		WithMultipart().
+		WithFileBytes("file", "file.txt", []byte("content")).
+		Expect().
+		Status(httptest.StatusUnauthorized)
+}
+
+// Test POST /attachments with a forbidden type
+func (suite *ApplicationTestSuite) TestUploadAttachmentUnprocessable() {
+	token := suite.validRegister()
+
+	request := suite.e.POST("/attachments").WithMultipart().WithFileBytes("file", "file.exe", []byte("MZ\x90\x00"))
+	suite.authorize(request, token)
+
+	request.Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+// Test the download of an attachment sent with a message
+func (suite *ApplicationTestSuite) TestAttachmentOK() {
+	token := suite.validRegister()
+	token1 := suite.validRegisterWithUser("a@b.com")
+	token2 := suite.validRegisterWithUser("b@b.com")
+
+	json := suite.uploadAttachment(token)
+	json.Value("name").Equal("file.txt")
+	json.Value("size").Equal(7)
+	id := json.Value("id").String().Raw()
+	url := json.Value("url").String().Raw()
+
+	// The attachment can't be downloaded by the other users before being sent
+	request := suite.e.GET(url)
+	suite.authorize(request, token1)
+	request.Expect().Status(httptest.StatusNotFound)
+
+	request = suite.e.POST("/messages").WithJSON(map[string]interface{}{
+		"to":          "a@b.com",
+		"message":     "test",
+		"attachments": []string{id},
+	})
+	suite.authorize(request, token)
+	expect := request.Expect()
+	expect.Status(httptest.StatusCreated)
+	expect.JSON().Object().Value("attachments").Array().Element(0).Object().Value("id").Equal(id)
+
+	// The recipient can download it
+	request = suite.e.GET(url)
+	suite.authorize(request, token1)
+	expect = request.Expect()
+	expect.Status(httptest.StatusOK)
+	expect.Body().Equal("content")
+
+	// But not the other users
+	request = suite.e.GET(url)
+	suite.authorize(request, token2)
+	request.Expect().Status(httptest.StatusNotFound)
+}
+
 // Helper method
 func (suite *ApplicationTestSuite) getWsToken() string {
 	token := suite.validRegister()
@@ -488,6 +1179,28 @@ func (suite *ApplicationTestSuite) TestReceiveWsMessage() {
 	ws.Close()
 }
 
+// Tests the websockets being closed by POST /logout/all
+func (suite *ApplicationTestSuite) TestLogoutAllClosesWs() {
+	ws := suite.getWsConn()
+
+	token := suite.e.POST("/login").WithJSON(map[string]string{
+		"email":    defaultEmail,
+		"password": defaultPassword,
+	}).Expect().JSON().Object().Value("accessToken").String().Raw()
+
+	request := suite.e.POST("/logout/all")
+	suite.authorize(request, token)
+	request.Expect().Status(httptest.StatusNoContent)
+
+	event, body := suite.readMessage(ws)
+	suite.Require().Equal("signedOut", event)
+	suite.Equal(defaultEmail, body["email"])
+
+	// The connection is closed
+	_, err := ws.Read(make([]byte, 1024))
+	suite.Error(err)
+}
+
 // Tests sending an invalid message
 func (suite *ApplicationTestSuite) TestSendWsMessageError() {
 	ws := suite.getWsConn()
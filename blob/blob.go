@@ -0,0 +1,23 @@
+// The package blob stores the content of the attachments.
+//
+// The blobs are written once and never modified, the Storage implementations only need to store and fetch them by name
+package blob
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	// Error thrown when the blob has not been found
+	NotFoundError = errors.New("Blob not found")
+
+	// Error thrown when the blob name is not valid
+	InvalidNameError = errors.New("Invalid blob name")
+)
+
+// Interface used mainly for Unit testing
+type Storage interface {
+	Put(string, io.Reader) error
+	Get(string) (io.ReadCloser, error)
+}
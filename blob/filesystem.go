@@ -0,0 +1,78 @@
+package blob
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Stores the blobs as files of a local directory
+type Filesystem struct {
+	dir string
+}
+
+// Creates the storage, creating dir if needed
+func NewFilesystemStorage(dir string) (*Filesystem, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &Filesystem{
+		dir: dir,
+	}, nil
+}
+
+// Returns the path of the blob. The names can't contain a path
+func (s Filesystem) path(name string) (string, error) {
+	if name == "" || filepath.Base(name) != name || name[0] == '.' {
+		return "", InvalidNameError
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Stores the content as the blob name. The file is written aside and then renamed, so that the partial blobs are
+// never visible
+func (s Filesystem) Put(name string, content io.Reader) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(s.dir, ".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return nil
+}
+
+// Opens the blob. The caller has to close it
+func (s Filesystem) Get(name string) (io.ReadCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, NotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
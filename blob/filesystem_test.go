@@ -0,0 +1,69 @@
+package blob
+
+import (
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var _ Storage = &Filesystem{}
+
+type FilesystemTestSuite struct {
+	suite.Suite
+	dir     string
+	storage *Filesystem
+}
+
+func TestFilesystem(t *testing.T) {
+	suite.Run(t, new(FilesystemTestSuite))
+}
+
+func (suite *FilesystemTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "blob")
+	suite.Require().NoError(err)
+	suite.dir = dir
+
+	suite.storage, err = NewFilesystemStorage(filepath.Join(dir, "blobs"))
+	suite.Require().NoError(err)
+}
+
+func (suite *FilesystemTestSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *FilesystemTestSuite) TestPutGet() {
+	err := suite.storage.Put("name", strings.NewReader("content"))
+	suite.Require().NoError(err)
+
+	r, err := suite.storage.Get("name")
+	suite.Require().NoError(err)
+	defer r.Close()
+
+	content, err := ioutil.ReadAll(r)
+	suite.Require().NoError(err)
+	suite.Equal("content", string(content))
+
+	// No temporary file is left behind
+	files, err := ioutil.ReadDir(filepath.Join(suite.dir, "blobs"))
+	suite.Require().NoError(err)
+	suite.Len(files, 1)
+}
+
+func (suite *FilesystemTestSuite) TestGetNotFound() {
+	r, err := suite.storage.Get("notExisting")
+	suite.Nil(r)
+	suite.EqualError(err, NotFoundError.Error())
+}
+
+func (suite *FilesystemTestSuite) TestInvalidName() {
+	for _, name := range []string{"", "../name", "a/b", ".tmp"} {
+		err := suite.storage.Put(name, strings.NewReader("content"))
+		suite.EqualError(err, InvalidNameError.Error(), name)
+
+		_, err = suite.storage.Get(name)
+		suite.EqualError(err, InvalidNameError.Error(), name)
+	}
+}
@@ -0,0 +1,57 @@
+package blob
+
+import (
+	"cloud.google.com/go/storage"
+	"context"
+	"io"
+)
+
+// Stores the blobs as the objects of a google cloud storage bucket
+type GCS struct {
+	client *storage.Client
+	bucket string
+}
+
+func NewGCSStorage(client *storage.Client, bucket string) *GCS {
+	return &GCS{
+		client: client,
+		bucket: bucket,
+	}
+}
+
+// Stores the content as the object name. The object is only created if the whole content has been written
+func (s GCS) Put(name string, content io.Reader) error {
+	if name == "" {
+		return InvalidNameError
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := s.client.Bucket(s.bucket).Object(name).NewWriter(ctx)
+	if _, err := io.Copy(w, content); err != nil {
+		// Cancelling the context aborts the upload
+		cancel()
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// Opens the object. The caller has to close it
+func (s GCS) Get(name string) (io.ReadCloser, error) {
+	if name == "" {
+		return nil, InvalidNameError
+	}
+
+	r, err := s.client.Bucket(s.bucket).Object(name).NewReader(context.Background())
+	if err == storage.ErrObjectNotExist {
+		return nil, NotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
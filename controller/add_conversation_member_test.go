@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type AddConversationMemberControllerTestSuite struct {
+	suite.Suite
+	controller *AddConversationMember
+	interactor *mocks.AddConversationMemberInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestAddConversationMemberController(t *testing.T) {
+	suite.Run(t, new(AddConversationMemberControllerTestSuite))
+}
+
+func (suite *AddConversationMemberControllerTestSuite) SetupSuite() {
+	suite.controller = NewAddConversationMemberController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Post("/{id:string}/members", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *AddConversationMemberControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.AddConversationMemberInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *AddConversationMemberControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *AddConversationMemberControllerTestSuite) validJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"email": "c@b.com",
+	}
+}
+
+func (suite *AddConversationMemberControllerTestSuite) requestObject() request.Request {
+	return request.AddConversationMember{
+		User:           *suite.user,
+		ConversationId: "conversationId",
+		Email:          "c@b.com",
+	}
+}
+
+func (suite *AddConversationMemberControllerTestSuite) validResponse() response.Response {
+	return response.UpdateConversation{
+		Conversation: response.Conversation{
+			Id:      "conversationId",
+			Members: []string{"a@b.com", "c@b.com"},
+		},
+	}
+}
+
+func (suite *AddConversationMemberControllerTestSuite) TestBadRequest() {
+	suite.e.POST("/conversationId/members").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *AddConversationMemberControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.POST("/conversationId/members").WithJSON(suite.validJSON()).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *AddConversationMemberControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.POST("/conversationId/members").WithJSON(suite.validJSON()).Expect().Status(response.GetCode())
+	r.JSON().Object().Value("members").Array().Contains("c@b.com")
+}
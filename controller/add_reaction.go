@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for POST /messages/{id}/reactions
+type AddReaction struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.AddReactionInteractor `inject:""`
+}
+
+func NewAddReactionController() *AddReaction {
+	return &AddReaction{}
+}
+
+func (c *AddReaction) Handle(ctx context.Context) {
+	request := request.Reaction{}
+
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+	request.MessageId = ctx.Params().Get("id")
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type AddReactionControllerTestSuite struct {
+	suite.Suite
+	controller *AddReaction
+	interactor *mocks.AddReactionInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestAddReactionController(t *testing.T) {
+	suite.Run(t, new(AddReactionControllerTestSuite))
+}
+
+func (suite *AddReactionControllerTestSuite) SetupSuite() {
+	suite.controller = NewAddReactionController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Post("/{id:string}/reactions", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *AddReactionControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.AddReactionInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *AddReactionControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *AddReactionControllerTestSuite) validJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"emoji": "👍",
+	}
+}
+
+func (suite *AddReactionControllerTestSuite) requestObject() request.Reaction {
+	return request.Reaction{
+		User:      *suite.user,
+		MessageId: "messageId",
+		Emoji:     "👍",
+	}
+}
+
+func (suite *AddReactionControllerTestSuite) TestBadRequest() {
+	suite.e.POST("/messageId/reactions").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *AddReactionControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.POST("/messageId/reactions").WithJSON(suite.validJSON()).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *AddReactionControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := response.Reactions{
+		MessageId: "messageId",
+		Reactions: []response.Reaction{
+			{Emoji: "👍", Count: 1, Users: []string{"a@b.com"}},
+		},
+	}
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.POST("/messageId/reactions").WithJSON(suite.validJSON()).Expect().Status(response.GetCode())
+	r.JSON().Object().Value("messageId").Equal("messageId")
+	r.JSON().Object().Value("reactions").Array().Length().Equal(1)
+}
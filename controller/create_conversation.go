@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for POST /conversations
+type CreateConversation struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.CreateConversationInteractor `inject:""`
+}
+
+func NewCreateConversationController() *CreateConversation {
+	return &CreateConversation{}
+}
+
+func (c *CreateConversation) Handle(ctx context.Context) {
+	request := request.CreateConversation{}
+
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
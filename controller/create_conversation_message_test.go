@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type CreateConversationMessageControllerTestSuite struct {
+	suite.Suite
+	controller *CreateConversationMessage
+	interactor *mocks.CreateConversationMessageInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestCreateConversationMessageController(t *testing.T) {
+	suite.Run(t, new(CreateConversationMessageControllerTestSuite))
+}
+
+func (suite *CreateConversationMessageControllerTestSuite) SetupSuite() {
+	suite.controller = NewCreateConversationMessageController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Post("/{id:string}/messages", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *CreateConversationMessageControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.CreateConversationMessageInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *CreateConversationMessageControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *CreateConversationMessageControllerTestSuite) validJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"message": "test",
+	}
+}
+
+func (suite *CreateConversationMessageControllerTestSuite) requestObject() request.Request {
+	return request.CreateConversationMessage{
+		From:           *suite.user,
+		ConversationId: "conversationId",
+		Message:        "test",
+	}
+}
+
+func (suite *CreateConversationMessageControllerTestSuite) validResponse() response.Response {
+	return response.CreateMessage{
+		Id:             "messageId",
+		ConversationId: "conversationId",
+		From:           "a@b.com",
+		Message:        "test",
+	}
+}
+
+func (suite *CreateConversationMessageControllerTestSuite) TestBadRequest() {
+	suite.e.POST("/conversationId/messages").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *CreateConversationMessageControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.POST("/conversationId/messages").WithJSON(suite.validJSON()).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *CreateConversationMessageControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.POST("/conversationId/messages").WithJSON(suite.validJSON()).Expect().Status(response.GetCode())
+	r.JSON().Object().Value("conversationId").Equal("conversationId")
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type CreateConversationControllerTestSuite struct {
+	suite.Suite
+	controller *CreateConversation
+	interactor *mocks.CreateConversationInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestCreateConversationController(t *testing.T) {
+	suite.Run(t, new(CreateConversationControllerTestSuite))
+}
+
+func (suite *CreateConversationControllerTestSuite) SetupSuite() {
+	suite.controller = NewCreateConversationController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Post("/", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *CreateConversationControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.CreateConversationInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *CreateConversationControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *CreateConversationControllerTestSuite) validJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"name":    "standup",
+		"members": []string{"b@b.com"},
+	}
+}
+
+func (suite *CreateConversationControllerTestSuite) requestObject() request.Request {
+	return request.CreateConversation{
+		User:    *suite.user,
+		Name:    "standup",
+		Members: []string{"b@b.com"},
+	}
+}
+
+func (suite *CreateConversationControllerTestSuite) validResponse() response.Response {
+	return response.CreateConversation{
+		Conversation: response.Conversation{
+			Id:        "conversationId",
+			Name:      "standup",
+			Members:   []string{"a@b.com", "b@b.com"},
+			CreatedBy: "a@b.com",
+		},
+	}
+}
+
+func (suite *CreateConversationControllerTestSuite) TestBadRequest() {
+	suite.e.POST("/").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *CreateConversationControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.POST("/").WithJSON(suite.validJSON()).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *CreateConversationControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.POST("/").WithJSON(suite.validJSON()).Expect().Status(response.GetCode())
+	r.JSON().Object().Value("id").Equal("conversationId")
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for DELETE /messages/{id}?scope={self|everyone}
+type DeleteMessage struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.DeleteMessageInteractor `inject:""`
+}
+
+func NewDeleteMessageController() *DeleteMessage {
+	return &DeleteMessage{}
+}
+
+func (c *DeleteMessage) Handle(ctx context.Context) {
+	request := request.DeleteMessage{
+		User:      *(ctx.Values().Get("user").(*entity.User)),
+		MessageId: ctx.Params().Get("id"),
+		Scope:     ctx.URLParam("scope"),
+	}
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type DeleteMessageControllerTestSuite struct {
+	suite.Suite
+	controller *DeleteMessage
+	interactor *mocks.DeleteMessageInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestDeleteMessageController(t *testing.T) {
+	suite.Run(t, new(DeleteMessageControllerTestSuite))
+}
+
+func (suite *DeleteMessageControllerTestSuite) SetupSuite() {
+	suite.controller = NewDeleteMessageController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Delete("/{id:string}", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *DeleteMessageControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.DeleteMessageInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *DeleteMessageControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *DeleteMessageControllerTestSuite) requestObject() request.DeleteMessage {
+	return request.DeleteMessage{
+		User:      *suite.user,
+		MessageId: "messageId",
+		Scope:     "everyone",
+	}
+}
+
+func (suite *DeleteMessageControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	request.Scope = ""
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.DELETE("/messageId").Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *DeleteMessageControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := response.DeleteMessage{
+		MessageId: "messageId",
+		Scope:     "everyone",
+	}
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.DELETE("/messageId").WithQuery("scope", "everyone").Expect().Status(response.GetCode())
+	r.JSON().Object().Value("messageId").Equal("messageId")
+	r.JSON().Object().Value("scope").Equal("everyone")
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for DELETE /sessions/{id}
+type DeleteSession struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.DeleteSessionInteractor `inject:""`
+}
+
+func NewDeleteSessionController() *DeleteSession {
+	return &DeleteSession{}
+}
+
+func (c *DeleteSession) Handle(ctx context.Context) {
+	request := request.DeleteSession{
+		User:      *(ctx.Values().Get("user").(*entity.User)),
+		SessionId: ctx.Params().Get("id"),
+	}
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type DeleteSessionControllerTestSuite struct {
+	suite.Suite
+	controller *DeleteSession
+	interactor *mocks.DeleteSessionInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestDeleteSessionController(t *testing.T) {
+	suite.Run(t, new(DeleteSessionControllerTestSuite))
+}
+
+func (suite *DeleteSessionControllerTestSuite) SetupSuite() {
+	suite.controller = NewDeleteSessionController()
+	suite.user = &entity.User{
+		Id:    "userId",
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Delete("/{id:string}", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *DeleteSessionControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.DeleteSessionInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *DeleteSessionControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *DeleteSessionControllerTestSuite) requestObject() request.DeleteSession {
+	return request.DeleteSession{
+		User:      *suite.user,
+		SessionId: "sessionId",
+	}
+}
+
+func (suite *DeleteSessionControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.DELETE("/sessionId").Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *DeleteSessionControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response.NoContentResponse{})
+
+	r := suite.e.DELETE("/sessionId").Expect().Status(httptest.StatusNoContent)
+	r.Body().Empty()
+}
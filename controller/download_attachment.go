@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"io"
+	"mime"
+)
+
+// Request handler for GET /attachments/{id}
+type DownloadAttachment struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.DownloadAttachmentInteractor `inject:""`
+}
+
+func NewDownloadAttachmentController() *DownloadAttachment {
+	return &DownloadAttachment{}
+}
+
+func (c *DownloadAttachment) Handle(ctx context.Context) {
+	request := request.DownloadAttachment{
+		User:         *(ctx.Values().Get("user").(*entity.User)),
+		AttachmentId: ctx.Params().Get("id"),
+	}
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	r := c.Interactor.Call(request)
+	res, ok := r.(response.DownloadAttachment)
+	if !ok {
+		sendResponse(ctx, r)
+		return
+	}
+	defer res.Content.Close()
+
+	// Stream the content, the browsers must not guess its type
+	ctx.Header("Content-Type", res.MimeType)
+	ctx.Header("Content-Length", fmt.Sprintf("%d", res.Size))
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": res.Name}))
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.StatusCode(iris.StatusOK)
+
+	if _, err := io.Copy(ctx.ResponseWriter(), res.Content); err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
+	}
+}
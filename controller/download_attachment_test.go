@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"bytes"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"io/ioutil"
+	"testing"
+)
+
+type DownloadAttachmentControllerTestSuite struct {
+	suite.Suite
+	controller *DownloadAttachment
+	interactor *mocks.DownloadAttachmentInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestDownloadAttachmentController(t *testing.T) {
+	suite.Run(t, new(DownloadAttachmentControllerTestSuite))
+}
+
+func (suite *DownloadAttachmentControllerTestSuite) SetupSuite() {
+	suite.controller = NewDownloadAttachmentController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Get("/{id:string}", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *DownloadAttachmentControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.DownloadAttachmentInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *DownloadAttachmentControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *DownloadAttachmentControllerTestSuite) requestObject() request.DownloadAttachment {
+	return request.DownloadAttachment{
+		User:         *suite.user,
+		AttachmentId: "attachmentId",
+	}
+}
+
+func (suite *DownloadAttachmentControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.GET("/attachmentId").Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *DownloadAttachmentControllerTestSuite) TestNotFound() {
+	request := suite.requestObject()
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response.NewError(httptest.StatusNotFound))
+	suite.e.GET("/attachmentId").Expect().Status(httptest.StatusNotFound)
+}
+
+func (suite *DownloadAttachmentControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := response.DownloadAttachment{
+		Attachment: response.Attachment{
+			Id:       "attachmentId",
+			Name:     "file.txt",
+			MimeType: "text/plain",
+			Size:     7,
+		},
+		Content: ioutil.NopCloser(bytes.NewReader([]byte("content"))),
+	}
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.GET("/attachmentId").Expect().Status(response.GetCode())
+	r.Header("Content-Type").Equal("text/plain")
+	r.Header("Content-Disposition").Equal(`attachment; filename=file.txt`)
+	r.Body().Equal("content")
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type EditMessageControllerTestSuite struct {
+	suite.Suite
+	controller *EditMessage
+	interactor *mocks.EditMessageInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestEditMessageController(t *testing.T) {
+	suite.Run(t, new(EditMessageControllerTestSuite))
+}
+
+func (suite *EditMessageControllerTestSuite) SetupSuite() {
+	suite.controller = NewEditMessageController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Patch("/{id:string}", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *EditMessageControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.EditMessageInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *EditMessageControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *EditMessageControllerTestSuite) validJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"message": "new",
+	}
+}
+
+func (suite *EditMessageControllerTestSuite) requestObject() request.Request {
+	return request.EditMessage{
+		User:      *suite.user,
+		MessageId: "messageId",
+		Message:   "new",
+	}
+}
+
+func (suite *EditMessageControllerTestSuite) validResponse() response.Response {
+	return response.EditMessage{
+		Message: response.Message{
+			Id:      "messageId",
+			Message: "new",
+		},
+	}
+}
+
+func (suite *EditMessageControllerTestSuite) TestBadRequest() {
+	suite.e.PATCH("/messageId").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *EditMessageControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.PATCH("/messageId").WithJSON(suite.validJSON()).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *EditMessageControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.PATCH("/messageId").WithJSON(suite.validJSON()).Expect().Status(response.GetCode())
+	r.JSON().Object().Value("message").Equal("new")
+}
@@ -2,14 +2,19 @@
 package controller
 
 import (
+	"fmt"
 	"github.com/asiragusa/wschat/response"
 	"github.com/kataras/iris"
 	"github.com/kataras/iris/context"
 )
 
-func sendResponse(ctx context.Context, response response.Response) {
-	ctx.StatusCode(response.GetCode())
-	if response.GetCode() != iris.StatusNoContent {
-		ctx.JSON(response)
+func sendResponse(ctx context.Context, res response.Response) {
+	if e, ok := res.(*response.Error); ok && e.RetryAfter > 0 {
+		ctx.Header("Retry-After", fmt.Sprintf("%d", e.RetryAfter))
+	}
+
+	ctx.StatusCode(res.GetCode())
+	if res.GetCode() != iris.StatusNoContent {
+		ctx.JSON(res)
 	}
 }
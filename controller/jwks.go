@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for GET /.well-known/jwks.json
+type Jwks struct {
+	// Injected via DI
+	Interactor interactor.JwksInteractor `inject:""`
+}
+
+func NewJwksController() *Jwks {
+	return &Jwks{}
+}
+
+func (c *Jwks) Handle(ctx context.Context) {
+	request := request.Jwks{}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type JwksControllerTestSuite struct {
+	suite.Suite
+	controller *Jwks
+	interactor *mocks.JwksInteractor
+	e          *httpexpect.Expect
+}
+
+func TestJwksController(t *testing.T) {
+	suite.Run(t, new(JwksControllerTestSuite))
+}
+
+func (suite *JwksControllerTestSuite) SetupSuite() {
+	suite.controller = NewJwksController()
+
+	app := iris.New()
+	app.Get("/", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *JwksControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.JwksInteractor{}
+
+	suite.controller.Interactor = suite.interactor
+}
+
+func (suite *JwksControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+}
+
+func (suite *JwksControllerTestSuite) TestHandleOk() {
+	suite.interactor.On("Call", request.Jwks{}).Return(response.Jwks{
+		Keys: []response.Jwk{{
+			Kid: "kid",
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			N:   "n",
+			E:   "AQAB",
+		}},
+	})
+
+	r := suite.e.GET("/").Expect().Status(httptest.StatusOK)
+	r.JSON().Object().Equal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": "kid",
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   "n",
+			"e":   "AQAB",
+		}},
+	})
+}
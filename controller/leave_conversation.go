@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for POST /conversations/{id}/leave
+type LeaveConversation struct {
+	// Injected via DI
+	Interactor interactor.LeaveConversationInteractor `inject:""`
+}
+
+func NewLeaveConversationController() *LeaveConversation {
+	return &LeaveConversation{}
+}
+
+func (c *LeaveConversation) Handle(ctx context.Context) {
+	request := request.ConversationMembership{}
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+	request.ConversationId = ctx.Params().Get("id")
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
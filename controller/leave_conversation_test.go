@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type LeaveConversationControllerTestSuite struct {
+	suite.Suite
+	controller *LeaveConversation
+	interactor *mocks.LeaveConversationInteractor
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestLeaveConversationController(t *testing.T) {
+	suite.Run(t, new(LeaveConversationControllerTestSuite))
+}
+
+func (suite *LeaveConversationControllerTestSuite) SetupSuite() {
+	suite.controller = NewLeaveConversationController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Post("/{id:string}/leave", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *LeaveConversationControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.LeaveConversationInteractor{}
+
+	suite.controller.Interactor = suite.interactor
+}
+
+func (suite *LeaveConversationControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+}
+
+func (suite *LeaveConversationControllerTestSuite) requestObject() request.Request {
+	return request.ConversationMembership{
+		User:           *suite.user,
+		ConversationId: "conversationId",
+	}
+}
+
+func (suite *LeaveConversationControllerTestSuite) validResponse() response.Response {
+	return response.NoContentResponse{}
+}
+
+func (suite *LeaveConversationControllerTestSuite) TestNotFound() {
+	request := suite.requestObject()
+	response := response.NewError(httptest.StatusNotFound)
+
+	suite.interactor.On("Call", request).Return(response)
+
+	suite.e.POST("/conversationId/leave").Expect().Status(httptest.StatusNotFound)
+}
+
+func (suite *LeaveConversationControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.interactor.On("Call", request).Return(response)
+
+	suite.e.POST("/conversationId/leave").Expect().Status(response.GetCode())
+}
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for GET /conversations
+type ListConversations struct {
+	// Injected via DI
+	Interactor interactor.ListConversationsInteractor `inject:""`
+}
+
+func NewListConversationsController() *ListConversations {
+	return &ListConversations{}
+}
+
+func (c *ListConversations) Handle(ctx context.Context) {
+	request := request.ListConversations{}
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
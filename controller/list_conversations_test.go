@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type ListConversationsControllerTestSuite struct {
+	suite.Suite
+	controller *ListConversations
+	interactor *mocks.ListConversationsInteractor
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestListConversationsController(t *testing.T) {
+	suite.Run(t, new(ListConversationsControllerTestSuite))
+}
+
+func (suite *ListConversationsControllerTestSuite) SetupSuite() {
+	suite.controller = NewListConversationsController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Get("/", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *ListConversationsControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.ListConversationsInteractor{}
+
+	suite.controller.Interactor = suite.interactor
+}
+
+func (suite *ListConversationsControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+}
+
+func (suite *ListConversationsControllerTestSuite) requestObject() request.Request {
+	return request.ListConversations{
+		User: *suite.user,
+	}
+}
+
+func (suite *ListConversationsControllerTestSuite) validResponse() response.Response {
+	return response.ListConversations{
+		Total: 1,
+		Items: []response.Conversation{{
+			Id:      "conversationId",
+			Name:    "standup",
+			Members: []string{"a@b.com", "b@b.com"},
+		}},
+	}
+}
+
+func (suite *ListConversationsControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.GET("/").Expect().Status(response.GetCode())
+	r.JSON().Object().Value("total").Equal(1)
+}
@@ -4,11 +4,17 @@ import (
 	"github.com/asiragusa/wschat/entity"
 	"github.com/asiragusa/wschat/interactor"
 	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
 	"github.com/kataras/iris/context"
 )
 
 // Request handler for GET /messages
 type ListMessages struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
 	// Injected via DI
 	Interactor interactor.ListMessagesInteractor `inject:""`
 }
@@ -18,8 +24,28 @@ func NewListMessagesController() *ListMessages {
 }
 
 func (c *ListMessages) Handle(ctx context.Context) {
-	request := request.ListMessages{}
+	request := request.ListMessages{
+		Before:         ctx.URLParam("before"),
+		After:          ctx.URLParam("after"),
+		With:           ctx.URLParam("with"),
+		ConversationId: ctx.URLParam("conversationId"),
+	}
+
+	if ctx.URLParamExists("limit") {
+		limit, err := ctx.URLParamInt("limit")
+		if err != nil {
+			sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+			return
+		}
+		request.Limit = limit
+	}
+
 	request.User = *(ctx.Values().Get("user").(*entity.User))
 
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
 	sendResponse(ctx, c.Interactor.Call(request))
 }
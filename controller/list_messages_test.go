@@ -10,6 +10,7 @@ import (
 	"github.com/kataras/iris/context"
 	"github.com/kataras/iris/httptest"
 	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
 	"testing"
 )
 
@@ -17,6 +18,7 @@ type ListMessagesControllerTestSuite struct {
 	suite.Suite
 	controller *ListMessages
 	interactor *mocks.ListMessagesInteractor
+	validator  *mocks.RequestValidator
 	user       *entity.User
 	e          *httpexpect.Expect
 }
@@ -42,12 +44,15 @@ func (suite *ListMessagesControllerTestSuite) SetupSuite() {
 
 func (suite *ListMessagesControllerTestSuite) SetupTest() {
 	suite.interactor = &mocks.ListMessagesInteractor{}
+	suite.validator = &mocks.RequestValidator{}
 
 	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
 }
 
 func (suite *ListMessagesControllerTestSuite) TearDownTest() {
 	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
 }
 
 func (suite *ListMessagesControllerTestSuite) requestObject() request.Request {
@@ -63,15 +68,52 @@ func (suite *ListMessagesControllerTestSuite) validResponse() response.Response
 			From: "a@b.com",
 			To:   "b@b.com",
 		}},
+		NextCursor: "next",
 	}
 }
 
+func (suite *ListMessagesControllerTestSuite) TestBadLimit() {
+	suite.e.GET("/").WithQuery("limit", "ko").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *ListMessagesControllerTestSuite) TestUnprocessableEntity() {
+	request := request.ListMessages{
+		User:  *suite.user,
+		Limit: 1000,
+	}
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.GET("/").WithQuery("limit", 1000).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
 func (suite *ListMessagesControllerTestSuite) TestHandleOk() {
 	request := suite.requestObject()
 	response := suite.validResponse()
 
+	suite.validator.On("Struct", request).Return(nil)
 	suite.interactor.On("Call", request).Return(response)
 
 	r := suite.e.GET("/").Expect().Status(response.GetCode())
 	r.JSON().Equal(response)
 }
+
+func (suite *ListMessagesControllerTestSuite) TestHandleQueryOk() {
+	request := request.ListMessages{
+		User:   *suite.user,
+		Limit:  10,
+		Before: "before",
+		With:   "b@b.com",
+	}
+	response := suite.validResponse()
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.GET("/").
+		WithQuery("limit", 10).
+		WithQuery("before", "before").
+		WithQuery("with", "b@b.com").
+		Expect().Status(response.GetCode())
+	r.JSON().Equal(response)
+}
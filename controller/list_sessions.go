@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for GET /sessions
+type ListSessions struct {
+	// Injected via DI
+	Interactor interactor.ListSessionsInteractor `inject:""`
+}
+
+func NewListSessionsController() *ListSessions {
+	return &ListSessions{}
+}
+
+func (c *ListSessions) Handle(ctx context.Context) {
+	request := request.ListSessions{
+		User:    *(ctx.Values().Get("user").(*entity.User)),
+		Session: *(ctx.Values().Get("session").(*entity.Session)),
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type ListSessionsControllerTestSuite struct {
+	suite.Suite
+	controller *ListSessions
+	interactor *mocks.ListSessionsInteractor
+	user       *entity.User
+	session    *entity.Session
+	e          *httpexpect.Expect
+}
+
+func TestListSessionsController(t *testing.T) {
+	suite.Run(t, new(ListSessionsControllerTestSuite))
+}
+
+func (suite *ListSessionsControllerTestSuite) SetupSuite() {
+	suite.controller = NewListSessionsController()
+	suite.user = &entity.User{
+		Id:    "userId",
+		Email: "a@b.com",
+	}
+	suite.session = &entity.Session{
+		Id:     "sessionId",
+		UserId: "userId",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Values().Set("session", suite.session)
+		ctx.Next()
+	})
+	app.Get("/", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *ListSessionsControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.ListSessionsInteractor{}
+
+	suite.controller.Interactor = suite.interactor
+}
+
+func (suite *ListSessionsControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+}
+
+func (suite *ListSessionsControllerTestSuite) requestObject() request.Request {
+	return request.ListSessions{
+		User:    *suite.user,
+		Session: *suite.session,
+	}
+}
+
+func (suite *ListSessionsControllerTestSuite) validResponse() response.Response {
+	return response.ListSessions{
+		Total: 1,
+		Items: []response.Session{{
+			Id:      "sessionId",
+			Device:  "phone",
+			Current: true,
+		}},
+	}
+}
+
+func (suite *ListSessionsControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.GET("/").Expect().Status(response.GetCode())
+	r.JSON().Object().Value("total").Equal(1)
+	r.JSON().Object().Value("items").Array().Element(0).Object().Value("current").Equal(true)
+}
@@ -28,6 +28,8 @@ func (c *Login) Handle(ctx context.Context) {
 		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
 		return
 	}
+	request.Ip = ctx.RemoteAddr()
+	request.UserAgent = ctx.GetHeader("User-Agent")
 
 	if err := c.Validator.Struct(request); err != nil {
 		sendResponse(ctx, c.Validator.FormatError(err))
@@ -7,6 +7,7 @@ import (
 	"github.com/iris-contrib/httpexpect"
 	"github.com/kataras/iris"
 	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"gopkg.in/go-playground/validator.v9"
 	"testing"
@@ -49,16 +50,27 @@ func (suite *LoginControllerTestSuite) validJSON() map[string]interface{} {
 	return map[string]interface{}{
 		"email":    "test@test.com",
 		"password": "validPassword",
+		"device":   "phone",
 	}
 }
 
-func (suite *LoginControllerTestSuite) requestObject() request.Request {
+func (suite *LoginControllerTestSuite) requestObject() request.Login {
 	return request.Login{
-		Email:    "test@test.com",
-		Password: "validPassword",
+		Email:     "test@test.com",
+		Password:  "validPassword",
+		Device:    "phone",
+		UserAgent: "test-agent",
 	}
 }
 
+// Matches the expected request whatever the IP address of the test client is
+func (suite *LoginControllerTestSuite) matchRequest(expected request.Login) interface{} {
+	return mock.MatchedBy(func(r request.Login) bool {
+		r.Ip = ""
+		return r == expected
+	})
+}
+
 func (suite *LoginControllerTestSuite) validResponse() response.Response {
 	return response.Login{
 		AccessToken: "accessToken",
@@ -72,18 +84,18 @@ func (suite *LoginControllerTestSuite) TestBadRequest() {
 func (suite *LoginControllerTestSuite) TestUnprocessableEntity() {
 	request := suite.requestObject()
 	err := validator.ValidationErrors{}
-	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("Struct", suite.matchRequest(request)).Return(err)
 	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
-	suite.e.POST("/").WithJSON(suite.validJSON()).Expect().Status(httptest.StatusUnprocessableEntity)
+	suite.e.POST("/").WithHeader("User-Agent", "test-agent").WithJSON(suite.validJSON()).Expect().Status(httptest.StatusUnprocessableEntity)
 }
 
 func (suite *LoginControllerTestSuite) TestHandleOk() {
 	request := suite.requestObject()
 	response := suite.validResponse()
 
-	suite.validator.On("Struct", request).Return(nil)
-	suite.interactor.On("Call", request).Return(response)
+	suite.validator.On("Struct", suite.matchRequest(request)).Return(nil)
+	suite.interactor.On("Call", suite.matchRequest(request)).Return(response)
 
-	r := suite.e.POST("/").WithJSON(suite.validJSON()).Expect().Status(response.GetCode())
+	r := suite.e.POST("/").WithHeader("User-Agent", "test-agent").WithJSON(suite.validJSON()).Expect().Status(response.GetCode())
 	r.JSON().Equal(response)
 }
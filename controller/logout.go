@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for POST /logout
+type Logout struct {
+	// Injected via DI
+	Interactor interactor.LogoutInteractor `inject:""`
+}
+
+func NewLogoutController() *Logout {
+	return &Logout{}
+}
+
+func (c *Logout) Handle(ctx context.Context) {
+	request := request.Logout{
+		User:    *(ctx.Values().Get("user").(*entity.User)),
+		Session: *(ctx.Values().Get("session").(*entity.Session)),
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
+
+// Request handler for POST /logout/all
+type LogoutAll struct {
+	// Injected via DI
+	Interactor interactor.LogoutAllInteractor `inject:""`
+}
+
+func NewLogoutAllController() *LogoutAll {
+	return &LogoutAll{}
+}
+
+func (c *LogoutAll) Handle(ctx context.Context) {
+	request := request.LogoutAll{}
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
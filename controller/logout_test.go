@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type LogoutControllerTestSuite struct {
+	suite.Suite
+	controller    *Logout
+	allController *LogoutAll
+	interactor    *mocks.LogoutInteractor
+	allInteractor *mocks.LogoutAllInteractor
+	user          *entity.User
+	session       *entity.Session
+	e             *httpexpect.Expect
+}
+
+func TestLogoutController(t *testing.T) {
+	suite.Run(t, new(LogoutControllerTestSuite))
+}
+
+func (suite *LogoutControllerTestSuite) SetupSuite() {
+	suite.controller = NewLogoutController()
+	suite.allController = NewLogoutAllController()
+	suite.user = &entity.User{
+		Id:    "userId",
+		Email: "a@b.com",
+	}
+	suite.session = &entity.Session{
+		Id:     "sessionId",
+		UserId: "userId",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Values().Set("session", suite.session)
+		ctx.Next()
+	})
+	app.Post("/", suite.controller.Handle)
+	app.Post("/all", suite.allController.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *LogoutControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.LogoutInteractor{}
+	suite.allInteractor = &mocks.LogoutAllInteractor{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.allController.Interactor = suite.allInteractor
+}
+
+func (suite *LogoutControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.allInteractor.AssertExpectations(suite.T())
+}
+
+func (suite *LogoutControllerTestSuite) TestHandleOk() {
+	request := request.Logout{
+		User:    *suite.user,
+		Session: *suite.session,
+	}
+
+	suite.interactor.On("Call", request).Return(response.NoContentResponse{})
+
+	r := suite.e.POST("/").Expect().Status(httptest.StatusNoContent)
+	r.Body().Empty()
+}
+
+func (suite *LogoutControllerTestSuite) TestHandleAllOk() {
+	request := request.LogoutAll{
+		User: *suite.user,
+	}
+
+	suite.allInteractor.On("Call", request).Return(response.NoContentResponse{})
+
+	r := suite.e.POST("/all").Expect().Status(httptest.StatusNoContent)
+	r.Body().Empty()
+}
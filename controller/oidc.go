@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for POST /oidc/authorize
+type OidcAuthorize struct {
+	// Injected via DI
+	Interactor interactor.OidcAuthorizeInteractor `inject:""`
+}
+
+func NewOidcAuthorizeController() *OidcAuthorize {
+	return &OidcAuthorize{}
+}
+
+func (c *OidcAuthorize) Handle(ctx context.Context) {
+	sendResponse(ctx, c.Interactor.Call(request.OidcAuthorize{}))
+}
+
+// Request handler for POST /oidc/login
+type OidcLogin struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.OidcLoginInteractor `inject:""`
+}
+
+func NewOidcLoginController() *OidcLogin {
+	return &OidcLogin{}
+}
+
+func (c *OidcLogin) Handle(ctx context.Context) {
+	request := request.OidcLogin{}
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	request.Ip = ctx.RemoteAddr()
+	request.UserAgent = ctx.GetHeader("User-Agent")
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type OidcControllerTestSuite struct {
+	suite.Suite
+	authorizeController *OidcAuthorize
+	loginController     *OidcLogin
+	authorizeInteractor *mocks.OidcAuthorizeInteractor
+	loginInteractor     *mocks.OidcLoginInteractor
+	validator           *mocks.RequestValidator
+	e                   *httpexpect.Expect
+}
+
+func TestOidcController(t *testing.T) {
+	suite.Run(t, new(OidcControllerTestSuite))
+}
+
+func (suite *OidcControllerTestSuite) SetupSuite() {
+	suite.authorizeController = NewOidcAuthorizeController()
+	suite.loginController = NewOidcLoginController()
+
+	app := iris.New()
+	app.Post("/oidc/authorize", suite.authorizeController.Handle)
+	app.Post("/oidc/login", suite.loginController.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *OidcControllerTestSuite) SetupTest() {
+	suite.authorizeInteractor = &mocks.OidcAuthorizeInteractor{}
+	suite.loginInteractor = &mocks.OidcLoginInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.authorizeController.Interactor = suite.authorizeInteractor
+	suite.loginController.Interactor = suite.loginInteractor
+	suite.loginController.Validator = suite.validator
+}
+
+func (suite *OidcControllerTestSuite) TearDownTest() {
+	suite.authorizeInteractor.AssertExpectations(suite.T())
+	suite.loginInteractor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *OidcControllerTestSuite) validJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"code":      "code",
+		"state":     "state",
+		"flowToken": "flowToken",
+		"device":    "phone",
+	}
+}
+
+// Matches the expected request whatever the IP address of the test client is
+func (suite *OidcControllerTestSuite) matchRequest() interface{} {
+	expected := request.OidcLogin{
+		Code:      "code",
+		State:     "state",
+		FlowToken: "flowToken",
+		Device:    "phone",
+		UserAgent: "test-agent",
+	}
+
+	return mock.MatchedBy(func(r request.OidcLogin) bool {
+		r.Ip = ""
+		return r == expected
+	})
+}
+
+func (suite *OidcControllerTestSuite) TestAuthorizeOk() {
+	res := response.OidcAuthorize{
+		AuthorizationUrl: "http://provider/authorize",
+		FlowToken:        "flowToken",
+		ExpiresIn:        600,
+	}
+	suite.authorizeInteractor.On("Call", request.OidcAuthorize{}).Return(res)
+
+	r := suite.e.POST("/oidc/authorize").Expect().Status(httptest.StatusOK)
+	r.JSON().Equal(res)
+}
+
+func (suite *OidcControllerTestSuite) TestLoginBadRequest() {
+	suite.e.POST("/oidc/login").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *OidcControllerTestSuite) TestLoginUnprocessableEntity() {
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", suite.matchRequest()).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+
+	suite.e.POST("/oidc/login").WithHeader("User-Agent", "test-agent").WithJSON(suite.validJSON()).
+		Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *OidcControllerTestSuite) TestLoginOk() {
+	res := response.Login{
+		AccessToken:  "accessToken",
+		RefreshToken: "refreshToken",
+		ExpiresIn:    900,
+	}
+	suite.validator.On("Struct", suite.matchRequest()).Return(nil)
+	suite.loginInteractor.On("Call", suite.matchRequest()).Return(res)
+
+	r := suite.e.POST("/oidc/login").WithHeader("User-Agent", "test-agent").WithJSON(suite.validJSON()).
+		Expect().Status(httptest.StatusOK)
+	r.JSON().Equal(res)
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for POST /password
+type ChangePassword struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.ChangePasswordInteractor `inject:""`
+}
+
+func NewChangePasswordController() *ChangePassword {
+	return &ChangePassword{}
+}
+
+func (c *ChangePassword) Handle(ctx context.Context) {
+	request := request.ChangePassword{}
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+	request.Session = *(ctx.Values().Get("session").(*entity.Session))
+	request.Ip = ctx.RemoteAddr()
+	request.UserAgent = ctx.GetHeader("User-Agent")
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
+
+// Request handler for POST /password/forgot
+type ForgotPassword struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.ForgotPasswordInteractor `inject:""`
+}
+
+func NewForgotPasswordController() *ForgotPassword {
+	return &ForgotPassword{}
+}
+
+func (c *ForgotPassword) Handle(ctx context.Context) {
+	request := request.ForgotPassword{}
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	request.Ip = ctx.RemoteAddr()
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
+
+// Request handler for POST /password/reset
+type ResetPassword struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.ResetPasswordInteractor `inject:""`
+}
+
+func NewResetPasswordController() *ResetPassword {
+	return &ResetPassword{}
+}
+
+func (c *ResetPassword) Handle(ctx context.Context) {
+	request := request.ResetPassword{}
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"reflect"
+	"testing"
+)
+
+type PasswordControllerTestSuite struct {
+	suite.Suite
+	changeController *ChangePassword
+	forgotController *ForgotPassword
+	resetController  *ResetPassword
+	changeInteractor *mocks.ChangePasswordInteractor
+	forgotInteractor *mocks.ForgotPasswordInteractor
+	resetInteractor  *mocks.ResetPasswordInteractor
+	validator        *mocks.RequestValidator
+	user             *entity.User
+	session          *entity.Session
+	e                *httpexpect.Expect
+}
+
+func TestPasswordController(t *testing.T) {
+	suite.Run(t, new(PasswordControllerTestSuite))
+}
+
+func (suite *PasswordControllerTestSuite) SetupSuite() {
+	suite.changeController = NewChangePasswordController()
+	suite.forgotController = NewForgotPasswordController()
+	suite.resetController = NewResetPasswordController()
+	suite.user = &entity.User{
+		Id:    "userId",
+		Email: "a@b.com",
+	}
+	suite.session = &entity.Session{
+		Id:     "sessionId",
+		UserId: "userId",
+		Device: "phone",
+	}
+
+	app := iris.New()
+	app.Post("/", func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Values().Set("session", suite.session)
+		ctx.Next()
+	}, suite.changeController.Handle)
+	app.Post("/forgot", suite.forgotController.Handle)
+	app.Post("/reset", suite.resetController.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *PasswordControllerTestSuite) SetupTest() {
+	suite.changeInteractor = &mocks.ChangePasswordInteractor{}
+	suite.forgotInteractor = &mocks.ForgotPasswordInteractor{}
+	suite.resetInteractor = &mocks.ResetPasswordInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.changeController.Interactor = suite.changeInteractor
+	suite.changeController.Validator = suite.validator
+	suite.forgotController.Interactor = suite.forgotInteractor
+	suite.forgotController.Validator = suite.validator
+	suite.resetController.Interactor = suite.resetInteractor
+	suite.resetController.Validator = suite.validator
+}
+
+func (suite *PasswordControllerTestSuite) TearDownTest() {
+	suite.changeInteractor.AssertExpectations(suite.T())
+	suite.forgotInteractor.AssertExpectations(suite.T())
+	suite.resetInteractor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+// Matches the expected change request whatever the IP address of the test client is
+func (suite *PasswordControllerTestSuite) matchChange(expected request.ChangePassword) interface{} {
+	return mock.MatchedBy(func(r request.ChangePassword) bool {
+		r.Ip = ""
+		return reflect.DeepEqual(r, expected)
+	})
+}
+
+// Matches the expected forgot request whatever the IP address of the test client is
+func (suite *PasswordControllerTestSuite) matchForgot(expected request.ForgotPassword) interface{} {
+	return mock.MatchedBy(func(r request.ForgotPassword) bool {
+		r.Ip = ""
+		return r == expected
+	})
+}
+
+func (suite *PasswordControllerTestSuite) TestBadRequest() {
+	for _, path := range []string{"/", "/forgot", "/reset"} {
+		suite.e.POST(path).WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+	}
+}
+
+func (suite *PasswordControllerTestSuite) TestChangeUnprocessableEntity() {
+	request := request.ChangePassword{
+		User:            *suite.user,
+		Session:         *suite.session,
+		CurrentPassword: "password",
+	}
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", suite.matchChange(request)).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+
+	suite.e.POST("/").WithJSON(map[string]string{
+		"currentPassword": "password",
+	}).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *PasswordControllerTestSuite) TestChangeOk() {
+	request := request.ChangePassword{
+		User:            *suite.user,
+		Session:         *suite.session,
+		CurrentPassword: "password",
+		NewPassword:     "newPassword",
+		UserAgent:       "test-agent",
+	}
+	res := response.Login{
+		AccessToken:  "accessToken",
+		RefreshToken: "refreshToken",
+		ExpiresIn:    900,
+	}
+	suite.validator.On("Struct", suite.matchChange(request)).Return(nil)
+	suite.changeInteractor.On("Call", suite.matchChange(request)).Return(res)
+
+	r := suite.e.POST("/").WithHeader("User-Agent", "test-agent").WithJSON(map[string]string{
+		"currentPassword": "password",
+		"newPassword":     "newPassword",
+	}).Expect().Status(httptest.StatusOK)
+	r.JSON().Equal(res)
+}
+
+func (suite *PasswordControllerTestSuite) TestForgotOk() {
+	request := request.ForgotPassword{
+		Email: "a@b.com",
+	}
+	suite.validator.On("Struct", suite.matchForgot(request)).Return(nil)
+	suite.forgotInteractor.On("Call", suite.matchForgot(request)).Return(response.NoContentResponse{})
+
+	r := suite.e.POST("/forgot").WithJSON(map[string]string{
+		"email": "a@b.com",
+	}).Expect().Status(httptest.StatusNoContent)
+	r.Body().Empty()
+}
+
+func (suite *PasswordControllerTestSuite) TestResetUnprocessableEntity() {
+	request := request.ResetPassword{
+		Token: "token",
+	}
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+
+	suite.e.POST("/reset").WithJSON(map[string]string{
+		"token": "token",
+	}).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *PasswordControllerTestSuite) TestResetOk() {
+	request := request.ResetPassword{
+		Token:    "token",
+		Password: "newPassword",
+	}
+	suite.validator.On("Struct", request).Return(nil)
+	suite.resetInteractor.On("Call", request).Return(response.NoContentResponse{})
+
+	r := suite.e.POST("/reset").WithJSON(map[string]string{
+		"token":    "token",
+		"password": "newPassword",
+	}).Expect().Status(httptest.StatusNoContent)
+	r.Body().Empty()
+}
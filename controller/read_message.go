@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for POST /messages/{id}/read
+type ReadMessage struct {
+	// Injected via DI
+	Interactor interactor.ReadMessageInteractor `inject:""`
+}
+
+func NewReadMessageController() *ReadMessage {
+	return &ReadMessage{}
+}
+
+func (c *ReadMessage) Handle(ctx context.Context) {
+	request := request.ReadMessage{}
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+	request.MessageId = ctx.Params().Get("id")
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
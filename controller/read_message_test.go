@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type ReadMessageControllerTestSuite struct {
+	suite.Suite
+	controller *ReadMessage
+	interactor *mocks.ReadMessageInteractor
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestReadMessageController(t *testing.T) {
+	suite.Run(t, new(ReadMessageControllerTestSuite))
+}
+
+func (suite *ReadMessageControllerTestSuite) SetupSuite() {
+	suite.controller = NewReadMessageController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Post("/{id:string}/read", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *ReadMessageControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.ReadMessageInteractor{}
+
+	suite.controller.Interactor = suite.interactor
+}
+
+func (suite *ReadMessageControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+}
+
+func (suite *ReadMessageControllerTestSuite) requestObject() request.Request {
+	return request.ReadMessage{
+		User:      *suite.user,
+		MessageId: "messageId",
+	}
+}
+
+func (suite *ReadMessageControllerTestSuite) validResponse() response.ReadMessage {
+	return response.ReadMessage{
+		MessageId: "messageId",
+		Receipt: response.Receipt{
+			User:   suite.user.Email,
+			ReadAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func (suite *ReadMessageControllerTestSuite) TestNotFound() {
+	request := suite.requestObject()
+	response := response.NewError(httptest.StatusNotFound)
+
+	suite.interactor.On("Call", request).Return(response)
+
+	suite.e.POST("/messageId/read").Expect().Status(httptest.StatusNotFound)
+}
+
+func (suite *ReadMessageControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.POST("/messageId/read").Expect().Status(response.GetCode())
+	r.JSON().Object().Value("messageId").Equal("messageId")
+	r.JSON().Object().Value("user").Equal(suite.user.Email)
+	r.JSON().Object().Value("readAt").Equal("2017-01-01T00:00:00Z")
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type RefreshTokenControllerTestSuite struct {
+	suite.Suite
+	controller *RefreshToken
+	interactor *mocks.RefreshTokenInteractor
+	validator  *mocks.RequestValidator
+	e          *httpexpect.Expect
+}
+
+func TestRefreshTokenController(t *testing.T) {
+	suite.Run(t, new(RefreshTokenControllerTestSuite))
+}
+
+func (suite *RefreshTokenControllerTestSuite) SetupSuite() {
+	suite.controller = NewRefreshTokenController()
+
+	app := iris.New()
+	app.Post("/", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *RefreshTokenControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.RefreshTokenInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *RefreshTokenControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *RefreshTokenControllerTestSuite) validJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"refreshToken": "refreshToken",
+	}
+}
+
+func (suite *RefreshTokenControllerTestSuite) requestObject() request.Request {
+	return request.RefreshToken{
+		RefreshToken: "refreshToken",
+	}
+}
+
+func (suite *RefreshTokenControllerTestSuite) validResponse() response.Response {
+	return response.RefreshToken{
+		AccessToken:  "accessToken",
+		RefreshToken: "nextRefreshToken",
+		ExpiresIn:    900,
+	}
+}
+
+func (suite *RefreshTokenControllerTestSuite) TestBadRequest() {
+	suite.e.POST("/").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *RefreshTokenControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.POST("/").WithJSON(suite.validJSON()).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *RefreshTokenControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.POST("/").WithJSON(suite.validJSON()).Expect().Status(response.GetCode())
+	r.JSON().Equal(response)
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for DELETE /messages/{id}/reactions?emoji={emoji}
+type RemoveReaction struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.RemoveReactionInteractor `inject:""`
+}
+
+func NewRemoveReactionController() *RemoveReaction {
+	return &RemoveReaction{}
+}
+
+func (c *RemoveReaction) Handle(ctx context.Context) {
+	request := request.Reaction{
+		User:      *(ctx.Values().Get("user").(*entity.User)),
+		MessageId: ctx.Params().Get("id"),
+		Emoji:     ctx.URLParam("emoji"),
+	}
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type RemoveReactionControllerTestSuite struct {
+	suite.Suite
+	controller *RemoveReaction
+	interactor *mocks.RemoveReactionInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestRemoveReactionController(t *testing.T) {
+	suite.Run(t, new(RemoveReactionControllerTestSuite))
+}
+
+func (suite *RemoveReactionControllerTestSuite) SetupSuite() {
+	suite.controller = NewRemoveReactionController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Delete("/{id:string}/reactions", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *RemoveReactionControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.RemoveReactionInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *RemoveReactionControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *RemoveReactionControllerTestSuite) requestObject() request.Reaction {
+	return request.Reaction{
+		User:      *suite.user,
+		MessageId: "messageId",
+		Emoji:     "👍",
+	}
+}
+
+func (suite *RemoveReactionControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	request.Emoji = ""
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.DELETE("/messageId/reactions").Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *RemoveReactionControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := response.Reactions{
+		MessageId: "messageId",
+		Reactions: []response.Reaction{},
+	}
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.DELETE("/messageId/reactions").WithQuery("emoji", "👍").Expect().Status(response.GetCode())
+	r.JSON().Object().Value("messageId").Equal("messageId")
+	r.JSON().Object().Value("reactions").Array().Empty()
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for PATCH /conversations/{id}
+type RenameConversation struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.RenameConversationInteractor `inject:""`
+}
+
+func NewRenameConversationController() *RenameConversation {
+	return &RenameConversation{}
+}
+
+func (c *RenameConversation) Handle(ctx context.Context) {
+	request := request.RenameConversation{}
+
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+	request.ConversationId = ctx.Params().Get("id")
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type RenameConversationControllerTestSuite struct {
+	suite.Suite
+	controller *RenameConversation
+	interactor *mocks.RenameConversationInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestRenameConversationController(t *testing.T) {
+	suite.Run(t, new(RenameConversationControllerTestSuite))
+}
+
+func (suite *RenameConversationControllerTestSuite) SetupSuite() {
+	suite.controller = NewRenameConversationController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Patch("/{id:string}", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *RenameConversationControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.RenameConversationInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *RenameConversationControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *RenameConversationControllerTestSuite) validJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"name": "retro",
+	}
+}
+
+func (suite *RenameConversationControllerTestSuite) requestObject() request.Request {
+	return request.RenameConversation{
+		User:           *suite.user,
+		ConversationId: "conversationId",
+		Name:           "retro",
+	}
+}
+
+func (suite *RenameConversationControllerTestSuite) validResponse() response.Response {
+	return response.UpdateConversation{
+		Conversation: response.Conversation{
+			Id:   "conversationId",
+			Name: "retro",
+		},
+	}
+}
+
+func (suite *RenameConversationControllerTestSuite) TestBadRequest() {
+	suite.e.PATCH("/conversationId").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *RenameConversationControllerTestSuite) TestUnprocessableEntity() {
+	request := suite.requestObject()
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.PATCH("/conversationId").WithJSON(suite.validJSON()).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *RenameConversationControllerTestSuite) TestHandleOk() {
+	request := suite.requestObject()
+	response := suite.validResponse()
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.PATCH("/conversationId").WithJSON(suite.validJSON()).Expect().Status(response.GetCode())
+	r.JSON().Object().Value("name").Equal("retro")
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"time"
+)
+
+// Request handler for GET /messages/search. since and until are RFC 3339 times
+type SearchMessages struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.SearchMessagesInteractor `inject:""`
+}
+
+func NewSearchMessagesController() *SearchMessages {
+	return &SearchMessages{}
+}
+
+func (c *SearchMessages) Handle(ctx context.Context) {
+	request := request.SearchMessages{
+		Query: ctx.URLParam("q"),
+		With:  ctx.URLParam("with"),
+	}
+
+	var err error
+	if request.Limit, err = urlParamInt(ctx, "limit"); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	if request.Offset, err = urlParamInt(ctx, "offset"); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	if request.Since, err = urlParamTime(ctx, "since"); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	if request.Until, err = urlParamTime(ctx, "until"); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
+
+// Parses an integer URL parameter. Returns 0 if the parameter is missing
+func urlParamInt(ctx context.Context, name string) (int, error) {
+	if !ctx.URLParamExists(name) {
+		return 0, nil
+	}
+	return ctx.URLParamInt(name)
+}
+
+// Parses an RFC 3339 URL parameter. Returns the zero time if the parameter is missing
+func urlParamTime(ctx context.Context, name string) (time.Time, error) {
+	if !ctx.URLParamExists(name) {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, ctx.URLParam(name))
+}
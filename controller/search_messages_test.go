@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+	"time"
+)
+
+type SearchMessagesControllerTestSuite struct {
+	suite.Suite
+	controller *SearchMessages
+	interactor *mocks.SearchMessagesInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestSearchMessagesController(t *testing.T) {
+	suite.Run(t, new(SearchMessagesControllerTestSuite))
+}
+
+func (suite *SearchMessagesControllerTestSuite) SetupSuite() {
+	suite.controller = NewSearchMessagesController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Get("/", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *SearchMessagesControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.SearchMessagesInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *SearchMessagesControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *SearchMessagesControllerTestSuite) validResponse() response.Response {
+	return response.SearchMessages{
+		Total: 1,
+		Items: []response.SearchResult{{
+			Message: response.Message{
+				From:    "a@b.com",
+				To:      "b@b.com",
+				Message: "hello",
+			},
+			Highlight: "<mark>hello</mark>",
+			Score:     1,
+		}},
+	}
+}
+
+func (suite *SearchMessagesControllerTestSuite) TestBadLimit() {
+	suite.e.GET("/").WithQuery("q", "hello").WithQuery("limit", "ko").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *SearchMessagesControllerTestSuite) TestBadOffset() {
+	suite.e.GET("/").WithQuery("q", "hello").WithQuery("offset", "ko").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *SearchMessagesControllerTestSuite) TestBadSince() {
+	suite.e.GET("/").WithQuery("q", "hello").WithQuery("since", "yesterday").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *SearchMessagesControllerTestSuite) TestBadUntil() {
+	suite.e.GET("/").WithQuery("q", "hello").WithQuery("until", "2017-01-01").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *SearchMessagesControllerTestSuite) TestUnprocessableEntity() {
+	request := request.SearchMessages{
+		User: *suite.user,
+	}
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+	suite.e.GET("/").Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *SearchMessagesControllerTestSuite) TestHandleOk() {
+	request := request.SearchMessages{
+		User:   *suite.user,
+		Query:  "hello",
+		Limit:  10,
+		Offset: 20,
+		With:   "b@b.com",
+		Since:  time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:  time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	response := suite.validResponse()
+
+	suite.validator.On("Struct", request).Return(nil)
+	suite.interactor.On("Call", request).Return(response)
+
+	r := suite.e.GET("/").
+		WithQuery("q", "hello").
+		WithQuery("limit", 10).
+		WithQuery("offset", 20).
+		WithQuery("with", "b@b.com").
+		WithQuery("since", "2017-01-01T00:00:00Z").
+		WithQuery("until", "2017-02-01T00:00:00Z").
+		Expect().Status(response.GetCode())
+	r.JSON().Equal(response)
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for POST /mfa/totp
+type EnrollTotp struct {
+	// Injected via DI
+	Interactor interactor.EnrollTotpInteractor `inject:""`
+}
+
+func NewEnrollTotpController() *EnrollTotp {
+	return &EnrollTotp{}
+}
+
+func (c *EnrollTotp) Handle(ctx context.Context) {
+	request := request.EnrollTotp{}
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
+
+// Request handler for POST /mfa/totp/confirm
+type ConfirmTotp struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.ConfirmTotpInteractor `inject:""`
+}
+
+func NewConfirmTotpController() *ConfirmTotp {
+	return &ConfirmTotp{}
+}
+
+func (c *ConfirmTotp) Handle(ctx context.Context) {
+	request := request.ConfirmTotp{}
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
+
+// Request handler for DELETE /mfa/totp
+type DisableTotp struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.DisableTotpInteractor `inject:""`
+}
+
+func NewDisableTotpController() *DisableTotp {
+	return &DisableTotp{}
+}
+
+func (c *DisableTotp) Handle(ctx context.Context) {
+	request := request.DisableTotp{}
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
+
+// Request handler for POST /login/mfa
+type LoginMfa struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.LoginMfaInteractor `inject:""`
+}
+
+func NewLoginMfaController() *LoginMfa {
+	return &LoginMfa{}
+}
+
+func (c *LoginMfa) Handle(ctx context.Context) {
+	request := request.LoginMfa{}
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	request.Ip = ctx.RemoteAddr()
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type TotpControllerTestSuite struct {
+	suite.Suite
+	enrollController   *EnrollTotp
+	confirmController  *ConfirmTotp
+	disableController  *DisableTotp
+	loginController    *LoginMfa
+	enrollInteractor   *mocks.EnrollTotpInteractor
+	confirmInteractor  *mocks.ConfirmTotpInteractor
+	disableInteractor  *mocks.DisableTotpInteractor
+	loginMfaInteractor *mocks.LoginMfaInteractor
+	validator          *mocks.RequestValidator
+	user               *entity.User
+	e                  *httpexpect.Expect
+}
+
+func TestTotpController(t *testing.T) {
+	suite.Run(t, new(TotpControllerTestSuite))
+}
+
+func (suite *TotpControllerTestSuite) SetupSuite() {
+	suite.enrollController = NewEnrollTotpController()
+	suite.confirmController = NewConfirmTotpController()
+	suite.disableController = NewDisableTotpController()
+	suite.loginController = NewLoginMfaController()
+	suite.user = &entity.User{
+		Id:    "userId",
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	setUser := func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	}
+	app.Post("/mfa/totp", setUser, suite.enrollController.Handle)
+	app.Post("/mfa/totp/confirm", setUser, suite.confirmController.Handle)
+	app.Delete("/mfa/totp", setUser, suite.disableController.Handle)
+	app.Post("/login/mfa", suite.loginController.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *TotpControllerTestSuite) SetupTest() {
+	suite.enrollInteractor = &mocks.EnrollTotpInteractor{}
+	suite.confirmInteractor = &mocks.ConfirmTotpInteractor{}
+	suite.disableInteractor = &mocks.DisableTotpInteractor{}
+	suite.loginMfaInteractor = &mocks.LoginMfaInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.enrollController.Interactor = suite.enrollInteractor
+	suite.confirmController.Interactor = suite.confirmInteractor
+	suite.confirmController.Validator = suite.validator
+	suite.disableController.Interactor = suite.disableInteractor
+	suite.disableController.Validator = suite.validator
+	suite.loginController.Interactor = suite.loginMfaInteractor
+	suite.loginController.Validator = suite.validator
+}
+
+func (suite *TotpControllerTestSuite) TearDownTest() {
+	suite.enrollInteractor.AssertExpectations(suite.T())
+	suite.confirmInteractor.AssertExpectations(suite.T())
+	suite.disableInteractor.AssertExpectations(suite.T())
+	suite.loginMfaInteractor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *TotpControllerTestSuite) TestBadRequest() {
+	suite.e.POST("/mfa/totp/confirm").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+	suite.e.DELETE("/mfa/totp").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+	suite.e.POST("/login/mfa").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *TotpControllerTestSuite) TestEnrollOk() {
+	request := request.EnrollTotp{User: *suite.user}
+	suite.enrollInteractor.On("Call", request).Return(response.EnrollTotp{Secret: "secret", Uri: "otpauth://totp/uri"})
+
+	r := suite.e.POST("/mfa/totp").Expect().Status(httptest.StatusOK)
+	r.JSON().Object().Equal(map[string]string{
+		"secret": "secret",
+		"uri":    "otpauth://totp/uri",
+	})
+}
+
+func (suite *TotpControllerTestSuite) TestConfirmUnprocessableEntity() {
+	request := request.ConfirmTotp{User: *suite.user}
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+
+	suite.e.POST("/mfa/totp/confirm").WithJSON(map[string]string{}).
+		Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *TotpControllerTestSuite) TestConfirmOk() {
+	request := request.ConfirmTotp{User: *suite.user, Code: "123456"}
+	suite.validator.On("Struct", request).Return(nil)
+	suite.confirmInteractor.On("Call", request).Return(response.ConfirmTotp{RecoveryCodes: []string{"abcd-efgh"}})
+
+	r := suite.e.POST("/mfa/totp/confirm").WithJSON(map[string]string{
+		"code": "123456",
+	}).Expect().Status(httptest.StatusOK)
+	r.JSON().Object().Value("recoveryCodes").Array().Equal([]string{"abcd-efgh"})
+}
+
+func (suite *TotpControllerTestSuite) TestDisableOk() {
+	request := request.DisableTotp{User: *suite.user, Code: "abcd-efgh"}
+	suite.validator.On("Struct", request).Return(nil)
+	suite.disableInteractor.On("Call", request).Return(response.NoContentResponse{})
+
+	r := suite.e.DELETE("/mfa/totp").WithJSON(map[string]string{
+		"code": "abcd-efgh",
+	}).Expect().Status(httptest.StatusNoContent)
+	r.Body().Empty()
+}
+
+// Matches the request regardless of the IP address of the test client
+func (suite *TotpControllerTestSuite) matchLoginMfa(expected request.LoginMfa) interface{} {
+	return mock.MatchedBy(func(r request.LoginMfa) bool {
+		r.Ip = ""
+		return r == expected
+	})
+}
+
+func (suite *TotpControllerTestSuite) TestLoginMfaUnprocessableEntity() {
+	request := request.LoginMfa{MfaToken: "mfa"}
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", suite.matchLoginMfa(request)).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+
+	suite.e.POST("/login/mfa").WithJSON(map[string]string{
+		"mfaToken": "mfa",
+	}).Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *TotpControllerTestSuite) TestLoginMfaOk() {
+	request := request.LoginMfa{MfaToken: "mfa", Code: "123456"}
+	suite.validator.On("Struct", suite.matchLoginMfa(request)).Return(nil)
+	suite.loginMfaInteractor.On("Call", suite.matchLoginMfa(request)).Return(response.Login{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    900,
+	})
+
+	r := suite.e.POST("/login/mfa").WithJSON(map[string]string{
+		"mfaToken": "mfa",
+		"code":     "123456",
+	}).Expect().Status(httptest.StatusOK)
+	r.JSON().Object().Equal(map[string]interface{}{
+		"accessToken":  "access",
+		"refreshToken": "refresh",
+		"expiresIn":    900,
+	})
+}
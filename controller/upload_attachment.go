@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"io"
+	"net/http"
+)
+
+// Maximum size of the body of an upload: the attachment, and the boundaries and headers of the multipart form
+const MaxUploadSize = validator.MaxAttachmentSize + 64<<10
+
+// Request handler for POST /attachments. The file is sent as the "file" field of a multipart form
+type UploadAttachment struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.UploadAttachmentInteractor `inject:""`
+}
+
+func NewUploadAttachmentController() *UploadAttachment {
+	return &UploadAttachment{}
+}
+
+// Counts the bytes read from the request body
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+func (c *UploadAttachment) Handle(ctx context.Context) {
+	// The form is buffered in memory and on disk while it's parsed, stop reading the bodies exceeding the limit
+	body := &countingBody{ReadCloser: http.MaxBytesReader(ctx.ResponseWriter(), ctx.Request().Body, MaxUploadSize)}
+	ctx.Request().Body = body
+
+	file, header, err := ctx.FormFile("file")
+	if err != nil && body.read >= MaxUploadSize {
+		sendResponse(ctx, response.NewError(iris.StatusRequestEntityTooLarge))
+		return
+	}
+	if err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+	defer file.Close()
+
+	// The MIME type sent by the client is not trusted, it is detected from the content instead
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusInternalServerError))
+		return
+	}
+	buffer := make([]byte, 512)
+	n, err := file.ReadAt(buffer, 0)
+	if err != nil && err != io.EOF {
+		sendResponse(ctx, response.NewError(iris.StatusInternalServerError))
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusInternalServerError))
+		return
+	}
+
+	request := request.UploadAttachment{
+		Owner:    *(ctx.Values().Get("user").(*entity.User)),
+		Name:     header.Filename,
+		MimeType: http.DetectContentType(buffer[:n]),
+		Size:     size,
+		Content:  file,
+	}
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
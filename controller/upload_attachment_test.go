@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"io/ioutil"
+	"testing"
+)
+
+type UploadAttachmentControllerTestSuite struct {
+	suite.Suite
+	controller *UploadAttachment
+	interactor *mocks.UploadAttachmentInteractor
+	validator  *mocks.RequestValidator
+	user       *entity.User
+	e          *httpexpect.Expect
+}
+
+func TestUploadAttachmentController(t *testing.T) {
+	suite.Run(t, new(UploadAttachmentControllerTestSuite))
+}
+
+func (suite *UploadAttachmentControllerTestSuite) SetupSuite() {
+	suite.controller = NewUploadAttachmentController()
+	suite.user = &entity.User{
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	})
+	app.Post("/", suite.controller.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *UploadAttachmentControllerTestSuite) SetupTest() {
+	suite.interactor = &mocks.UploadAttachmentInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.controller.Interactor = suite.interactor
+	suite.controller.Validator = suite.validator
+}
+
+func (suite *UploadAttachmentControllerTestSuite) TearDownTest() {
+	suite.interactor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+// Matches the requests built from the uploaded file.txt
+func (suite *UploadAttachmentControllerTestSuite) matchRequest() interface{} {
+	return mock.MatchedBy(func(request request.UploadAttachment) bool {
+		return request.Owner == *suite.user &&
+			request.Name == "file.txt" &&
+			request.MimeType == "text/plain; charset=utf-8" &&
+			request.Size == 7
+	})
+}
+
+func (suite *UploadAttachmentControllerTestSuite) TestBadRequest() {
+	suite.e.POST("/").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *UploadAttachmentControllerTestSuite) TestRequestEntityTooLarge() {
+	// The body is refused before the validation
+	suite.e.POST("/").
+		WithMultipart().
+		WithFileBytes("file", "file.txt", make([]byte, MaxUploadSize)).
+		Expect().
+		Status(httptest.StatusRequestEntityTooLarge)
+}
+
+func (suite *UploadAttachmentControllerTestSuite) TestUnprocessableEntity() {
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", suite.matchRequest()).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+
+	suite.e.POST("/").
+		WithMultipart().
+		WithFileBytes("file", "file.txt", []byte("content")).
+		Expect().
+		Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *UploadAttachmentControllerTestSuite) TestHandleOk() {
+	response := response.UploadAttachment{
+		Attachment: response.Attachment{
+			Id:       "attachmentId",
+			Name:     "file.txt",
+			MimeType: "text/plain; charset=utf-8",
+			Size:     7,
+			Checksum: "checksum",
+			Url:      "/attachments/attachmentId",
+		},
+	}
+
+	// The content is readable from the start
+	var content []byte
+	suite.validator.On("Struct", suite.matchRequest()).Return(nil)
+	suite.interactor.On("Call", mock.MatchedBy(func(request request.UploadAttachment) bool {
+		content, _ = ioutil.ReadAll(request.Content)
+		return true
+	})).Return(response)
+
+	r := suite.e.POST("/").
+		WithMultipart().
+		WithFileBytes("file", "file.txt", []byte("content")).
+		Expect().
+		Status(response.GetCode())
+	r.JSON().Object().Value("id").Equal("attachmentId")
+	r.JSON().Object().Value("url").Equal("/attachments/attachmentId")
+	suite.Equal("content", string(content))
+}
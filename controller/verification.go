@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/validator"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+)
+
+// Request handler for POST /verify
+type VerifyEmail struct {
+	// Injected via DI
+	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Interactor interactor.VerifyEmailInteractor `inject:""`
+}
+
+func NewVerifyEmailController() *VerifyEmail {
+	return &VerifyEmail{}
+}
+
+func (c *VerifyEmail) Handle(ctx context.Context) {
+	request := request.VerifyEmail{}
+	if err := ctx.ReadJSON(&request); err != nil {
+		sendResponse(ctx, response.NewError(iris.StatusBadRequest))
+		return
+	}
+
+	if err := c.Validator.Struct(request); err != nil {
+		sendResponse(ctx, c.Validator.FormatError(err))
+		return
+	}
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
+
+// Request handler for POST /verify/resend
+type ResendVerification struct {
+	// Injected via DI
+	Interactor interactor.ResendVerificationInteractor `inject:""`
+}
+
+func NewResendVerificationController() *ResendVerification {
+	return &ResendVerification{}
+}
+
+func (c *ResendVerification) Handle(ctx context.Context) {
+	request := request.ResendVerification{}
+	request.User = *(ctx.Values().Get("user").(*entity.User))
+
+	sendResponse(ctx, c.Interactor.Call(request))
+}
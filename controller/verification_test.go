@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/iris-contrib/httpexpect"
+	"github.com/kataras/iris"
+	"github.com/kataras/iris/context"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"testing"
+)
+
+type VerificationControllerTestSuite struct {
+	suite.Suite
+	verifyController *VerifyEmail
+	resendController *ResendVerification
+	verifyInteractor *mocks.VerifyEmailInteractor
+	resendInteractor *mocks.ResendVerificationInteractor
+	validator        *mocks.RequestValidator
+	user             *entity.User
+	e                *httpexpect.Expect
+}
+
+func TestVerificationController(t *testing.T) {
+	suite.Run(t, new(VerificationControllerTestSuite))
+}
+
+func (suite *VerificationControllerTestSuite) SetupSuite() {
+	suite.verifyController = NewVerifyEmailController()
+	suite.resendController = NewResendVerificationController()
+	suite.user = &entity.User{
+		Id:    "userId",
+		Email: "a@b.com",
+	}
+
+	app := iris.New()
+	app.Post("/verify", suite.verifyController.Handle)
+	app.Post("/verify/resend", func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	}, suite.resendController.Handle)
+	suite.e = httptest.New(suite.T(), app)
+}
+
+func (suite *VerificationControllerTestSuite) SetupTest() {
+	suite.verifyInteractor = &mocks.VerifyEmailInteractor{}
+	suite.resendInteractor = &mocks.ResendVerificationInteractor{}
+	suite.validator = &mocks.RequestValidator{}
+
+	suite.verifyController.Interactor = suite.verifyInteractor
+	suite.verifyController.Validator = suite.validator
+	suite.resendController.Interactor = suite.resendInteractor
+}
+
+func (suite *VerificationControllerTestSuite) TearDownTest() {
+	suite.verifyInteractor.AssertExpectations(suite.T())
+	suite.resendInteractor.AssertExpectations(suite.T())
+	suite.validator.AssertExpectations(suite.T())
+}
+
+func (suite *VerificationControllerTestSuite) TestVerifyBadRequest() {
+	suite.e.POST("/verify").WithText("bad request").Expect().Status(httptest.StatusBadRequest)
+}
+
+func (suite *VerificationControllerTestSuite) TestVerifyUnprocessableEntity() {
+	request := request.VerifyEmail{}
+	err := validator.ValidationErrors{}
+	suite.validator.On("Struct", request).Return(err)
+	suite.validator.On("FormatError", err).Return(response.NewError(httptest.StatusUnprocessableEntity))
+
+	suite.e.POST("/verify").WithJSON(map[string]string{}).
+		Expect().Status(httptest.StatusUnprocessableEntity)
+}
+
+func (suite *VerificationControllerTestSuite) TestVerifyOk() {
+	request := request.VerifyEmail{Token: "token"}
+	suite.validator.On("Struct", request).Return(nil)
+	suite.verifyInteractor.On("Call", request).Return(response.NoContentResponse{})
+
+	r := suite.e.POST("/verify").WithJSON(map[string]string{
+		"token": "token",
+	}).Expect().Status(httptest.StatusNoContent)
+	r.Body().Empty()
+}
+
+func (suite *VerificationControllerTestSuite) TestResendOk() {
+	request := request.ResendVerification{User: *suite.user}
+	suite.resendInteractor.On("Call", request).Return(response.NoContentResponse{})
+
+	r := suite.e.POST("/verify/resend").Expect().Status(httptest.StatusNoContent)
+	r.Body().Empty()
+}
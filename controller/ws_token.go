@@ -18,8 +18,10 @@ func NewWsTokenController() *WsToken {
 }
 
 func (c *WsToken) Handle(ctx context.Context) {
-	request := request.CreateWsToken{}
-	request.User = *(ctx.Values().Get("user").(*entity.User))
+	request := request.CreateWsToken{
+		User:    *(ctx.Values().Get("user").(*entity.User)),
+		Session: *(ctx.Values().Get("session").(*entity.Session)),
+	}
 
 	sendResponse(ctx, c.Interactor.Call(request))
 }
@@ -18,6 +18,7 @@ type WsTokenControllerTestSuite struct {
 	controller *WsToken
 	interactor *mocks.WsTokenInteractor
 	user       *entity.User
+	session    *entity.Session
 	e          *httpexpect.Expect
 }
 
@@ -30,10 +31,14 @@ func (suite *WsTokenControllerTestSuite) SetupSuite() {
 	suite.user = &entity.User{
 		Email: "a@b.com",
 	}
+	suite.session = &entity.Session{
+		Id: "sessionId",
+	}
 
 	app := iris.New()
 	app.Use(func(ctx context.Context) {
 		ctx.Values().Set("user", suite.user)
+		ctx.Values().Set("session", suite.session)
 		ctx.Next()
 	})
 	app.Post("/", suite.controller.Handle)
@@ -52,7 +57,8 @@ func (suite *WsTokenControllerTestSuite) TearDownTest() {
 
 func (suite *WsTokenControllerTestSuite) requestObject() request.Request {
 	return request.CreateWsToken{
-		User: *suite.user,
+		User:    *suite.user,
+		Session: *suite.session,
 	}
 }
 
@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// Uploaded file. It can be attached to a single message
+type Attachment struct {
+	// Attachment Id, also used as the name of the blob holding the content
+	Id string `json:"id"`
+
+	// User having uploaded the file
+	Owner string `json:"owner"`
+
+	// Message the file is attached to. Empty until the message is sent
+	MessageId string `json:"messageId"`
+
+	// File name
+	Name string `json:"name"`
+
+	// MIME type, detected from the content
+	MimeType string `json:"mimeType"`
+
+	// Size in bytes
+	Size int64 `json:"size"`
+
+	// Hex encoded SHA-256 of the content
+	Checksum string `json:"checksum"`
+
+	// Created at
+	CreatedAt time.Time `json:"createdAt"`
+}
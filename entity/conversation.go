@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// Group conversation (room)
+type Conversation struct {
+	// Conversation Id
+	Id string `json:"id"`
+
+	// Conversation name
+	Name string `json:"name"`
+
+	// Emails of the users belonging to the conversation, used for searching
+	Members []string `json:"members"`
+
+	// Email of the user who created the conversation
+	CreatedBy string `json:"createdBy"`
+
+	// Created at
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Returns true if email is a member of the conversation
+func (c Conversation) HasMember(email string) bool {
+	for _, member := range c.Members {
+		if member == email {
+			return true
+		}
+	}
+	return false
+}
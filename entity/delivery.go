@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// The type Delivery records a message delivered to an user.
+//
+// Seq increases monotonically for every user and allows to replay the messages missed while disconnected.
+// Used by services/pubsub_client
+type Delivery struct {
+	// Delivery Id, composed by the receiver and the sequence number
+	Id string
+
+	// Receiver
+	To string
+
+	// Sequence number, unique per receiver
+	Seq int64
+
+	// Delivered message
+	MessageId string
+
+	// Created At
+	CreatedAt time.Time
+}
+
+// Last sequence number allocated to an user
+type Sequence struct {
+	// The user the sequence belongs to
+	Id string
+
+	// Last allocated value
+	Value int64
+}
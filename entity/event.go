@@ -0,0 +1,29 @@
+package entity
+
+import "encoding/json"
+
+// Event pushed to the websockets of the recipients, eg. a read receipt. Events are not stored
+type Event struct {
+	// Event name, emitted as is on the websockets
+	Type string `json:"type"`
+
+	// Recipient emails
+	To []string `json:"to"`
+
+	// Event body, encoded as JSON
+	Data json.RawMessage `json:"data"`
+}
+
+// Creates a new event, encoding data as JSON
+func NewEvent(eventType string, to []string, data interface{}) (Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Type: eventType,
+		To:   to,
+		Data: encoded,
+	}, nil
+}
@@ -0,0 +1,16 @@
+package entity
+
+// Identity of an user logged in at an OpenID Connect provider, from its ID token
+type Identity struct {
+	// Issuer of the ID token, ie. the provider
+	Issuer string
+
+	// Identifier of the user at the provider
+	Subject string
+
+	// Email of the user
+	Email string
+
+	// True if the provider has verified the email
+	EmailVerified bool
+}
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// Failed login attempts of an email or of a client IP, used to slow down the brute force attacks
+type LoginAttempts struct {
+	// Throttled key, eg. email:a@b.com or ip:127.0.0.1
+	Key string
+
+	// Number of consecutive failed attempts
+	Failures int
+
+	// Time of the last failed attempt
+	LastFailureAt time.Time
+}
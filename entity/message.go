@@ -1,7 +1,13 @@
 // The entity package contains all the entities of google cloud datastore
 package entity
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// Maximum length, in characters, of the snippet quoting a replied message
+const SnippetLength = 100
 
 // Sent message struct
 type Message struct {
@@ -11,15 +17,234 @@ type Message struct {
 	// Users belonging to the message, used for searching
 	Users []string
 
+	// Conversation the message has been sent to. Empty for direct messages
+	ConversationId string `json:"conversationId,omitempty"`
+
 	// Sender
 	From string `json:"from"`
 
-	// Receiver
+	// Receiver, empty for conversation messages
 	To string `json:"to"`
 
 	// Message text
 	Message string `json:"message"`
 
+	// Distinct words of the text, indexed for the search. Set by Index
+	Terms []string `json:"-"`
+
 	// Created at
 	CreatedAt time.Time `json:"createdAt"`
+
+	// Message replied to. Its MessageId is empty if the message is not a reply
+	ReplyTo Reply `json:"replyTo"`
+
+	// Attached files
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Read receipts, one per recipient having read the message
+	Reads []Receipt `json:"reads,omitempty"`
+
+	// Last edit time. Zero if the message has never been edited
+	EditedAt time.Time `json:"editedAt"`
+
+	// Prior texts of the message, from the oldest
+	Revisions []Revision `json:"revisions,omitempty"`
+
+	// Reactions, one per emoji, in the order they have been first used
+	Reactions []Reaction `json:"reactions,omitempty"`
+
+	// Time the message has been deleted for everyone at. Zero if it hasn't been deleted
+	DeletedAt time.Time `json:"deletedAt"`
+
+	// Sequence number of the delivery to the receiver. Not stored, it is set when the message is published
+	Seq int64 `json:"seq,omitempty" datastore:"-"`
+}
+
+// Read receipt of a message
+type Receipt struct {
+	// User having read the message
+	User string `json:"user"`
+
+	// Read at
+	ReadAt time.Time `json:"readAt"`
+}
+
+// Message replied to, quoted as it was when the reply has been sent
+type Reply struct {
+	// Replied message ID
+	MessageId string `json:"messageId"`
+
+	// Sender of the replied message
+	From string `json:"from"`
+
+	// Beginning of the replied message text, at most SnippetLength characters
+	Snippet string `json:"snippet"`
+}
+
+// Users having reacted to a message with the same emoji
+type Reaction struct {
+	// Emoji
+	Emoji string `json:"emoji"`
+
+	// Number of users
+	Count int `json:"count"`
+
+	// Users having reacted, from the first
+	Users []string `json:"users"`
+}
+
+// Prior text of an edited message
+type Revision struct {
+	// Message text
+	Message string `json:"message"`
+
+	// Time the text has been written at
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Returns the quote of the message to store in its replies
+func (m Message) Quote() Reply {
+	snippet := strings.TrimSpace(m.Message)
+	if runes := []rune(snippet); len(runes) > SnippetLength {
+		snippet = strings.TrimSpace(string(runes[:SnippetLength-1])) + "…"
+	}
+
+	return Reply{
+		MessageId: m.Id,
+		From:      m.From,
+		Snippet:   snippet,
+	}
+}
+
+// Replaces the text of the message, keeping the current one as a revision. The new text is indexed
+func (m *Message) Edit(text string, at time.Time) {
+	createdAt := m.CreatedAt
+	if !m.EditedAt.IsZero() {
+		createdAt = m.EditedAt
+	}
+
+	m.Revisions = append(m.Revisions, Revision{
+		Message:   m.Message,
+		CreatedAt: createdAt,
+	})
+	m.Message = text
+	m.EditedAt = at
+	m.Index()
+}
+
+// Deletes the message for everyone, leaving a tombstone without its texts, reactions and attachments.
+// Deleting a deleted message is a no-op
+func (m *Message) Delete(at time.Time) {
+	if m.Deleted() {
+		return
+	}
+
+	m.Message = ""
+	m.Revisions = nil
+	m.Reactions = nil
+	m.Attachments = nil
+	m.DeletedAt = at
+	m.Index()
+}
+
+// Returns true if the message has been deleted for everyone
+func (m Message) Deleted() bool {
+	return !m.DeletedAt.IsZero()
+}
+
+// Removes the user from the message, hiding it from the user's history
+func (m *Message) DeleteFor(email string) {
+	users := []string{}
+	for _, user := range m.Users {
+		if user != email {
+			users = append(users, user)
+		}
+	}
+	m.Users = users
+}
+
+// Adds the reaction of the user with the emoji. Adding it twice is a no-op
+func (m *Message) AddReaction(email, emoji string) {
+	for i := range m.Reactions {
+		reaction := &m.Reactions[i]
+		if reaction.Emoji != emoji {
+			continue
+		}
+
+		for _, user := range reaction.Users {
+			if user == email {
+				return
+			}
+		}
+		reaction.Users = append(reaction.Users, email)
+		reaction.Count = len(reaction.Users)
+		return
+	}
+
+	m.Reactions = append(m.Reactions, Reaction{
+		Emoji: emoji,
+		Count: 1,
+		Users: []string{email},
+	})
+}
+
+// Removes the reaction of the user with the emoji, if any. The emojis left without users are removed
+func (m *Message) RemoveReaction(email, emoji string) {
+	reactions := []Reaction{}
+	for _, reaction := range m.Reactions {
+		if reaction.Emoji == emoji {
+			users := []string{}
+			for _, user := range reaction.Users {
+				if user != email {
+					users = append(users, user)
+				}
+			}
+			reaction.Users = users
+			reaction.Count = len(users)
+		}
+
+		if reaction.Count > 0 {
+			reactions = append(reactions, reaction)
+		}
+	}
+
+	if len(reactions) == 0 {
+		reactions = nil
+	}
+	m.Reactions = reactions
+}
+
+// Returns the read receipt of the user, nil if the user hasn't read the message
+func (m Message) ReadBy(email string) *Receipt {
+	for i := range m.Reads {
+		if m.Reads[i].User == email {
+			return &m.Reads[i]
+		}
+	}
+	return nil
+}
+
+// Returns true if email belongs to the message
+func (m Message) HasUser(email string) bool {
+	for _, user := range m.Users {
+		if user == email {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the users the message has to be delivered to
+func (m Message) Recipients() []string {
+	if m.ConversationId == "" {
+		return []string{m.To}
+	}
+
+	recipients := []string{}
+	for _, user := range m.Users {
+		if user != m.From {
+			recipients = append(recipients, user)
+		}
+	}
+	return recipients
 }
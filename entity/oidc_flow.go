@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// Server side state of an OpenID Connect login, from the redirection to the provider until the callback. The state and
+// the flow token are stored hashed, the nonce and the PKCE verifier never leave the server
+type OidcFlow struct {
+	// Hex encoded SHA-256 of the state sent to the provider
+	Id string
+
+	// Hex encoded SHA-256 of the flow token, kept by the client that has started the flow
+	FlowToken string
+
+	// Nonce expected in the ID token
+	Nonce string
+
+	// PKCE verifier sent with the authorization code
+	Verifier string
+
+	// Created at
+	CreatedAt time.Time
+
+	// The flow can't be completed after this time
+	ExpiresAt time.Time
+}
@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// Server side state of a password reset token. The token itself is only sent to the user by email, it is stored hashed
+type PasswordReset struct {
+	// Hex encoded SHA-256 of the token
+	Id string
+
+	// Id of the user the token has been issued to
+	UserId string
+
+	// Secret of the user when the token has been issued. Changing the user's secret invalidates the token
+	Secret string
+
+	// Created at
+	CreatedAt time.Time
+
+	// The token can't be used after this time
+	ExpiresAt time.Time
+}
@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// Presence statuses
+const (
+	// The user has a live connection and has been active recently
+	PresenceOnline = "online"
+
+	// The user has a live connection but hasn't been active recently
+	PresenceAway = "away"
+
+	// The user has no live connection
+	PresenceOffline = "offline"
+)
+
+// Presence of an user, computed from its live connections. Not stored
+type Presence struct {
+	// User email
+	Email string `json:"email"`
+
+	// One of PresenceOnline, PresenceAway and PresenceOffline
+	Status string `json:"status"`
+
+	// Last time the user has been seen connected and active. Zero if the user has never connected
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+// Server side state of a refresh token. The token itself is only known by the client, it is stored hashed
+type RefreshToken struct {
+	// Hex encoded SHA-256 of the token
+	Id string
+
+	// Id of the first token of the family. The tokens obtained by rotating a token belong to its family
+	Family string
+
+	// Id of the user the token has been issued to
+	UserId string
+
+	// Secret of the user when the token has been issued. Changing the user's secret invalidates the token
+	Secret string
+
+	// Created at
+	CreatedAt time.Time
+
+	// The token can't be used after this time
+	ExpiresAt time.Time
+
+	// Time the token has been exchanged at. Zero if the token hasn't been used yet
+	UsedAt time.Time
+}
+
+// Returns true if the token has already been exchanged
+func (t RefreshToken) Used() bool {
+	return !t.UsedAt.IsZero()
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Word of a text, normalized for the search
+type Token struct {
+	// Normalized word
+	Term string
+
+	// Byte offsets of the word in the text
+	Start, End int
+}
+
+// Splits a text in words. The words are sequences of letters and digits, and are compared case insensitively
+func Tokenize(text string) []Token {
+	var tokens []Token
+
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		}
+		if !word && start >= 0 {
+			tokens = append(tokens, Token{Term: strings.ToLower(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Term: strings.ToLower(text[start:]), Start: start, End: len(text)})
+	}
+
+	return tokens
+}
+
+// Returns the distinct terms of a text, in order of appearance
+func Terms(text string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, token := range Tokenize(text) {
+		if !seen[token.Term] {
+			seen[token.Term] = true
+			terms = append(terms, token.Term)
+		}
+	}
+	return terms
+}
+
+// Indexes the current text of the message
+func (m *Message) Index() {
+	m.Terms = Terms(m.Message)
+}
+
+// Returns the relevance of the message for the terms: the number of occurrences of the terms in its text.
+// Returns 0 unless the text contains all of them
+func (m Message) Score(terms []string) int {
+	counts := map[string]int{}
+	for _, token := range Tokenize(m.Message) {
+		counts[token.Term]++
+	}
+
+	score := 0
+	for _, term := range terms {
+		if counts[term] == 0 {
+			return 0
+		}
+		score += counts[term]
+	}
+	return score
+}
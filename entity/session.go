@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// Login session of an user on a device. Every token is issued for a session, and deleting the session revokes them
+type Session struct {
+	// Session ID, used as the ID of the tokens issued for the session
+	Id string
+
+	// Id of the user
+	UserId string
+
+	// Secret of the user when the session has been opened. Changing the user's secret invalidates the session
+	Secret string
+
+	// Device name given by the client at login
+	Device string
+
+	// IP address the session has been opened from
+	Ip string
+
+	// User agent of the client that opened the session
+	UserAgent string
+
+	// Created at
+	CreatedAt time.Time
+
+	// Last time a token of the session has been used
+	LastUsedAt time.Time
+
+	// True until the second factor of the login has been verified. Only the mfa token is issued for a pending session
+	Pending bool
+}
+
+// Body of the sessionRevoked event, pushed to the user when one of its sessions is revoked
+type SessionRevoked struct {
+	// Id of the revoked session
+	Id string `json:"id"`
+}
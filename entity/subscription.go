@@ -4,15 +4,18 @@ import "time"
 
 // The type Subscription indexes all the pubsub subscriptions.
 //
-// It allows fast searching for the subscriptions belonging to a given user.
+// Every server instance holds a subscription to the shared topic, receiving the messages of all the users.
 // Used by services/pubsub_client
 type Subscription struct {
 	// Subscription Id
 	Id string
 
-	// User subscribed
+	// User subscribed. Empty for the subscriptions of the server instances
 	To string
 
 	// Created At
 	CreatedAt time.Time
+
+	// Updated periodically by the owner of the subscription. Stale subscriptions are deleted by services.Reaper
+	LastSeenAt time.Time
 }
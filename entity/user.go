@@ -10,7 +10,7 @@ type User struct {
 	// User email
 	Email string
 
-	// User password
+	// User password. Empty for the users created by the OpenID Connect login, until they reset it
 	Password string
 
 	// Secret, used for the JWT Token ID. Changing this invalidates the tokens
@@ -18,4 +18,29 @@ type User struct {
 
 	// Created At
 	CreatedAt time.Time
+
+	// Last time the user has been seen connected and active. Used to compute the presence
+	LastSeenAt time.Time
+
+	// Base32 TOTP secret. Empty if the two factor authentication is disabled
+	TotpSecret string
+
+	// Base32 TOTP secret being enrolled, until it's confirmed with a first code
+	PendingTotpSecret string
+
+	// SHA-256 hashes of the unused recovery codes
+	RecoveryCodes []string
+
+	// Time step of the last TOTP code accepted. The codes of this step and of the previous ones are refused, so that
+	// an intercepted code can't be replayed
+	TotpCounter int64
+
+	// True once the user has followed the verification link sent to its email, or logged in with an OpenID Connect
+	// provider that has verified it
+	EmailVerified bool
+}
+
+// Returns true if the user has enabled the two factor authentication
+func (u User) TotpEnabled() bool {
+	return u.TotpSecret != ""
 }
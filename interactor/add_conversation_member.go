@@ -0,0 +1,65 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type AddConversationMemberInteractor interface {
+	Call(request.AddConversationMember) response.Response
+}
+
+// Adds an user to a conversation. Only members can add users, knowing the ID of a conversation isn't enough to join it
+type AddConversationMember struct {
+	// Injected via DI
+	ConversationRepository repository.ConversationRepository `inject:""`
+
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+}
+
+func NewAddConversationMemberInteractor() *AddConversationMember {
+	return &AddConversationMember{}
+}
+
+func (i AddConversationMember) Call(request request.AddConversationMember) response.Response {
+	// Find the conversation or return an error
+	conversation, err := i.ConversationRepository.GetById(request.ConversationId)
+	if err == repository.ConversationNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Only members can add users
+	if !conversation.HasMember(request.User.Email) {
+		return response.NewError(iris.StatusForbidden)
+	}
+
+	// Find the user to add or return an error
+	_, err = i.UserRepository.GetUserByEmail(request.Email)
+	if err == repository.UserNotFoundError {
+		error := response.NewError(iris.StatusUnprocessableEntity)
+		error.AddDetail("email", "notExists")
+		return error
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	conversation, err = i.ConversationRepository.AddMember(conversation.Id, request.Email)
+	if err == repository.ConversationNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.UpdateConversation{
+		Conversation: newConversationResponse(*conversation),
+	}
+}
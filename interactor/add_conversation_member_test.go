@@ -0,0 +1,144 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type AddConversationMemberInteractorTestSuite struct {
+	suite.Suite
+	interactor             *AddConversationMember
+	conversationRepository *mocks.ConversationRepository
+	userRepository         *mocks.UserRepository
+}
+
+func TestAddConversationMemberInteractor(t *testing.T) {
+	suite.Run(t, new(AddConversationMemberInteractorTestSuite))
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewAddConversationMemberInteractor()
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) SetupTest() {
+	suite.conversationRepository = &mocks.ConversationRepository{}
+	suite.userRepository = &mocks.UserRepository{}
+	suite.interactor.ConversationRepository = suite.conversationRepository
+	suite.interactor.UserRepository = suite.userRepository
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) TearDownTest() {
+	suite.conversationRepository.AssertExpectations(suite.T())
+	suite.userRepository.AssertExpectations(suite.T())
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) getValidRequest() request.AddConversationMember {
+	return request.AddConversationMember{
+		User: entity.User{
+			Email: "a@b.com",
+		},
+		ConversationId: "conversationId",
+		Email:          "c@b.com",
+	}
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) getConversation() *entity.Conversation {
+	return &entity.Conversation{
+		Id:      "conversationId",
+		Members: []string{"b@b.com", "a@b.com"},
+	}
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) TestNotFound() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(nil, repository.ConversationNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) TestGetByIdAnyError() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) TestNotMember() {
+	// Knowing the ID of the conversation isn't enough to join it
+	request := suite.getValidRequest()
+	request.Email = request.User.Email
+	conversation := suite.getConversation()
+	conversation.Members = []string{"b@b.com"}
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(conversation, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) TestUserNotExists() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(suite.getConversation(), nil)
+	suite.userRepository.On("GetUserByEmail", request.Email).Return(nil, repository.UserNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("email", "notExists")
+	suite.Equal(expected, r)
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) TestGetUserAnyError() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(suite.getConversation(), nil)
+	suite.userRepository.On("GetUserByEmail", request.Email).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) TestAddMemberAnyError() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(suite.getConversation(), nil)
+	suite.userRepository.On("GetUserByEmail", request.Email).Return(&entity.User{Email: request.Email}, nil)
+	suite.conversationRepository.On("AddMember", request.ConversationId, request.Email).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *AddConversationMemberInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	conversation := entity.Conversation{
+		Id:      request.ConversationId,
+		Members: []string{"b@b.com", "a@b.com", request.Email},
+	}
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(suite.getConversation(), nil)
+	suite.userRepository.On("GetUserByEmail", request.Email).Return(&entity.User{Email: request.Email}, nil)
+	suite.conversationRepository.On("AddMember", request.ConversationId, request.Email).Return(&conversation, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.UpdateConversation{
+		Conversation: response.Conversation{
+			Id:      conversation.Id,
+			Members: conversation.Members,
+		},
+	}
+	suite.Equal(expected, r)
+}
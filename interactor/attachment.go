@@ -0,0 +1,125 @@
+package interactor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/asiragusa/wschat/blob"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris"
+	"io"
+)
+
+// Interface used mainly for Unit testing
+type UploadAttachmentInteractor interface {
+	Call(request.UploadAttachment) response.Response
+}
+
+// Interface used mainly for Unit testing
+type DownloadAttachmentInteractor interface {
+	Call(request.DownloadAttachment) response.Response
+}
+
+// Stores an uploaded file, to be sent later with a message
+type UploadAttachment struct {
+	// Injected via DI
+	AttachmentRepository repository.AttachmentRepository `inject:""`
+
+	// Injected via DI
+	Storage blob.Storage `inject:""`
+}
+
+func NewUploadAttachmentInteractor() *UploadAttachment {
+	return &UploadAttachment{}
+}
+
+func (i UploadAttachment) Call(request request.UploadAttachment) response.Response {
+	// Compute the checksum, then rewind the content to store it
+	hash := sha256.New()
+	if _, err := io.Copy(hash, request.Content); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	if _, err := request.Content.Seek(0, io.SeekStart); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	attachment, err := i.AttachmentRepository.Create(request.Owner.Email, request.Name, request.MimeType, request.Size, checksum)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Don't leave behind an attachment without content
+	if err := i.Storage.Put(attachment.Id, request.Content); err != nil {
+		if err := i.AttachmentRepository.Delete(attachment.Id); err != nil {
+			// TODO: do proper logging
+			fmt.Println(err.Error())
+		}
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.UploadAttachment{
+		Attachment: newAttachmentResponse(*attachment),
+	}
+}
+
+// Fetches an attachment and its content. Only the users of the message can download it, or its owner as long as it
+// hasn't been sent
+type DownloadAttachment struct {
+	// Injected via DI
+	AttachmentRepository repository.AttachmentRepository `inject:""`
+
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+
+	// Injected via DI
+	Storage blob.Storage `inject:""`
+}
+
+func NewDownloadAttachmentInteractor() *DownloadAttachment {
+	return &DownloadAttachment{}
+}
+
+func (i DownloadAttachment) Call(request request.DownloadAttachment) response.Response {
+	attachment, err := i.AttachmentRepository.GetById(request.AttachmentId)
+	if err == repository.AttachmentNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if attachment.MessageId == "" {
+		if attachment.Owner != request.User.Email {
+			return response.NewError(iris.StatusNotFound)
+		}
+	} else {
+		message, err := i.MessageRepository.GetById(attachment.MessageId)
+		if err == repository.MessageNotFoundError {
+			return response.NewError(iris.StatusNotFound)
+		}
+		if err != nil {
+			return response.NewError(iris.StatusInternalServerError)
+		}
+
+		// The attachments are dropped with the messages deleted for everyone
+		if !message.HasUser(request.User.Email) || message.Deleted() {
+			return response.NewError(iris.StatusNotFound)
+		}
+	}
+
+	content, err := i.Storage.Get(attachment.Id)
+	if err == blob.NotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.DownloadAttachment{
+		Attachment: newAttachmentResponse(*attachment),
+		Content:    content,
+	}
+}
@@ -0,0 +1,281 @@
+package interactor
+
+import (
+	"bytes"
+	"github.com/asiragusa/wschat/blob"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// SHA-256 of "content"
+const contentChecksum = "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"
+
+type AttachmentInteractorTestSuite struct {
+	suite.Suite
+	upload               *UploadAttachment
+	download             *DownloadAttachment
+	attachmentRepository *mocks.AttachmentRepository
+	messageRepository    *mocks.MessageRepository
+	storage              *mocks.Storage
+}
+
+func TestAttachmentInteractor(t *testing.T) {
+	suite.Run(t, new(AttachmentInteractorTestSuite))
+}
+
+func (suite *AttachmentInteractorTestSuite) SetupSuite() {
+	suite.upload = NewUploadAttachmentInteractor()
+	suite.download = NewDownloadAttachmentInteractor()
+}
+
+func (suite *AttachmentInteractorTestSuite) SetupTest() {
+	suite.attachmentRepository = &mocks.AttachmentRepository{}
+	suite.messageRepository = &mocks.MessageRepository{}
+	suite.storage = &mocks.Storage{}
+
+	suite.upload.AttachmentRepository = suite.attachmentRepository
+	suite.upload.Storage = suite.storage
+
+	suite.download.AttachmentRepository = suite.attachmentRepository
+	suite.download.MessageRepository = suite.messageRepository
+	suite.download.Storage = suite.storage
+}
+
+func (suite *AttachmentInteractorTestSuite) TearDownTest() {
+	suite.attachmentRepository.AssertExpectations(suite.T())
+	suite.messageRepository.AssertExpectations(suite.T())
+	suite.storage.AssertExpectations(suite.T())
+}
+
+func (suite *AttachmentInteractorTestSuite) getUploadRequest() request.UploadAttachment {
+	return request.UploadAttachment{
+		Owner: entity.User{
+			Email: "a@b.com",
+		},
+		Name:     "file.txt",
+		MimeType: "text/plain",
+		Size:     7,
+		Content:  bytes.NewReader([]byte("content")),
+	}
+}
+
+func (suite *AttachmentInteractorTestSuite) getAttachment() *entity.Attachment {
+	return &entity.Attachment{
+		Id:        "attachmentId",
+		Owner:     "a@b.com",
+		Name:      "file.txt",
+		MimeType:  "text/plain",
+		Size:      7,
+		Checksum:  contentChecksum,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (suite *AttachmentInteractorTestSuite) getAttachmentResponse() response.Attachment {
+	return response.Attachment{
+		Id:       "attachmentId",
+		Name:     "file.txt",
+		MimeType: "text/plain",
+		Size:     7,
+		Checksum: contentChecksum,
+		Url:      "/attachments/attachmentId",
+	}
+}
+
+func (suite *AttachmentInteractorTestSuite) TestUploadCreateAnError() {
+	request := suite.getUploadRequest()
+	suite.attachmentRepository.On("Create", "a@b.com", "file.txt", "text/plain", int64(7), contentChecksum).Return(nil, assert.AnError)
+
+	r := suite.upload.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestUploadPutAnError() {
+	request := suite.getUploadRequest()
+	suite.attachmentRepository.On("Create", "a@b.com", "file.txt", "text/plain", int64(7), contentChecksum).Return(suite.getAttachment(), nil)
+	suite.storage.On("Put", "attachmentId", request.Content).Return(assert.AnError)
+
+	// The attachment without content is deleted
+	suite.attachmentRepository.On("Delete", "attachmentId").Return(nil)
+
+	r := suite.upload.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestUploadOK() {
+	request := suite.getUploadRequest()
+	suite.attachmentRepository.On("Create", "a@b.com", "file.txt", "text/plain", int64(7), contentChecksum).Return(suite.getAttachment(), nil)
+
+	// The whole content is stored, after having been hashed
+	var stored []byte
+	suite.storage.On("Put", "attachmentId", mock.MatchedBy(func(content io.Reader) bool {
+		stored, _ = ioutil.ReadAll(content)
+		return true
+	})).Return(nil)
+
+	r := suite.upload.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.UploadAttachment{
+		Attachment: suite.getAttachmentResponse(),
+	}, r)
+	suite.Equal("content", string(stored))
+}
+
+func (suite *AttachmentInteractorTestSuite) getDownloadRequest() request.DownloadAttachment {
+	return request.DownloadAttachment{
+		User: entity.User{
+			Email: "b@b.com",
+		},
+		AttachmentId: "attachmentId",
+	}
+}
+
+func (suite *AttachmentInteractorTestSuite) getMessage() *entity.Message {
+	return &entity.Message{
+		Id:    "messageId",
+		From:  "a@b.com",
+		To:    "b@b.com",
+		Users: []string{"a@b.com", "b@b.com"},
+	}
+}
+
+func (suite *AttachmentInteractorTestSuite) getSent() *entity.Attachment {
+	attachment := suite.getAttachment()
+	attachment.MessageId = "messageId"
+	return attachment
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadNotFound() {
+	request := suite.getDownloadRequest()
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(nil, repository.AttachmentNotFoundError)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadAnError() {
+	request := suite.getDownloadRequest()
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(nil, assert.AnError)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadNotSentNotOwner() {
+	request := suite.getDownloadRequest()
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getAttachment(), nil)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadNotSentOwner() {
+	request := suite.getDownloadRequest()
+	request.User.Email = "a@b.com"
+	content := ioutil.NopCloser(bytes.NewReader([]byte("content")))
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getAttachment(), nil)
+	suite.storage.On("Get", "attachmentId").Return(content, nil)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.DownloadAttachment{
+		Attachment: suite.getAttachmentResponse(),
+		Content:    content,
+	}, r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadMessageNotFound() {
+	request := suite.getDownloadRequest()
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getSent(), nil)
+	suite.messageRepository.On("GetById", "messageId").Return(nil, repository.MessageNotFoundError)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadMessageAnError() {
+	request := suite.getDownloadRequest()
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getSent(), nil)
+	suite.messageRepository.On("GetById", "messageId").Return(nil, assert.AnError)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadNotParticipant() {
+	request := suite.getDownloadRequest()
+	request.User.Email = "c@b.com"
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getSent(), nil)
+	suite.messageRepository.On("GetById", "messageId").Return(suite.getMessage(), nil)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadMessageDeleted() {
+	request := suite.getDownloadRequest()
+	message := suite.getMessage()
+	message.Delete(time.Now())
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getSent(), nil)
+	suite.messageRepository.On("GetById", "messageId").Return(message, nil)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadBlobNotFound() {
+	request := suite.getDownloadRequest()
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getSent(), nil)
+	suite.messageRepository.On("GetById", "messageId").Return(suite.getMessage(), nil)
+	suite.storage.On("Get", "attachmentId").Return(nil, blob.NotFoundError)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadBlobAnError() {
+	request := suite.getDownloadRequest()
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getSent(), nil)
+	suite.messageRepository.On("GetById", "messageId").Return(suite.getMessage(), nil)
+	suite.storage.On("Get", "attachmentId").Return(nil, assert.AnError)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *AttachmentInteractorTestSuite) TestDownloadOK() {
+	request := suite.getDownloadRequest()
+	content := ioutil.NopCloser(bytes.NewReader([]byte("content")))
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getSent(), nil)
+	suite.messageRepository.On("GetById", "messageId").Return(suite.getMessage(), nil)
+	suite.storage.On("Get", "attachmentId").Return(content, nil)
+
+	r := suite.download.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.DownloadAttachment{
+		Attachment: suite.getAttachmentResponse(),
+		Content:    content,
+	}, r)
+}
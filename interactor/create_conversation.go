@@ -0,0 +1,51 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type CreateConversationInteractor interface {
+	Call(request.CreateConversation) response.Response
+}
+
+// Creates a new group conversation
+type CreateConversation struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	ConversationRepository repository.ConversationRepository `inject:""`
+}
+
+func NewCreateConversationInteractor() *CreateConversation {
+	return &CreateConversation{}
+}
+
+func (i CreateConversation) Call(request request.CreateConversation) response.Response {
+	// Check that all the members exist
+	for _, member := range request.Members {
+		_, err := i.UserRepository.GetUserByEmail(member)
+		if err == repository.UserNotFoundError {
+			error := response.NewError(iris.StatusUnprocessableEntity)
+			error.AddDetail("members", "notExists")
+			return error
+		}
+		if err != nil {
+			return response.NewError(iris.StatusInternalServerError)
+		}
+	}
+
+	// Create the conversation
+	conversation, err := i.ConversationRepository.Create(request.Name, request.User.Email, request.Members)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.CreateConversation{
+		Conversation: newConversationResponse(*conversation),
+	}
+}
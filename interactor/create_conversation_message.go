@@ -0,0 +1,67 @@
+package interactor
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type CreateConversationMessageInteractor interface {
+	Call(request.CreateConversationMessage) response.Response
+}
+
+// Interactor used to send a message to a group conversation
+type CreateConversationMessage struct {
+	// Injected via DI
+	ConversationRepository repository.ConversationRepository `inject:""`
+
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+}
+
+func NewCreateConversationMessageInteractor() *CreateConversationMessage {
+	return &CreateConversationMessage{}
+}
+
+func (i CreateConversationMessage) Call(request request.CreateConversationMessage) response.Response {
+	// Find the conversation or return an error
+	conversation, err := i.ConversationRepository.GetById(request.ConversationId)
+	if err == repository.ConversationNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Only members can send messages to the conversation
+	if !conversation.HasMember(request.From.Email) {
+		return response.NewError(iris.StatusForbidden)
+	}
+
+	// Create the new message
+	message, err := i.MessageRepository.CreateInConversation(request.From.Email, conversation.Id, conversation.Members, request.Message)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Dispatch the message to the members
+	if err := i.PubsubClient.Publish(*message); err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
+	}
+
+	return response.CreateMessage{
+		Id:             message.Id,
+		ConversationId: message.ConversationId,
+		From:           message.From,
+		Message:        message.Message,
+		CreatedAt:      message.CreatedAt,
+	}
+}
@@ -0,0 +1,130 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type CreateConversationMessageInteractorTestSuite struct {
+	suite.Suite
+	interactor             *CreateConversationMessage
+	conversationRepository *mocks.ConversationRepository
+	messageRepository      *mocks.MessageRepository
+	pubsubClient           *mocks.PubsubClient
+}
+
+func TestCreateConversationMessageInteractor(t *testing.T) {
+	suite.Run(t, new(CreateConversationMessageInteractorTestSuite))
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewCreateConversationMessageInteractor()
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) SetupTest() {
+	suite.conversationRepository = &mocks.ConversationRepository{}
+	suite.messageRepository = &mocks.MessageRepository{}
+	suite.pubsubClient = &mocks.PubsubClient{}
+
+	suite.interactor.ConversationRepository = suite.conversationRepository
+	suite.interactor.MessageRepository = suite.messageRepository
+	suite.interactor.PubsubClient = suite.pubsubClient
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) TearDownTest() {
+	suite.conversationRepository.AssertExpectations(suite.T())
+	suite.messageRepository.AssertExpectations(suite.T())
+	suite.pubsubClient.AssertExpectations(suite.T())
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) getValidRequest() request.CreateConversationMessage {
+	return request.CreateConversationMessage{
+		From: entity.User{
+			Email: "a@b.com",
+		},
+		ConversationId: "conversationId",
+		Message:        "test",
+	}
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) conversation() *entity.Conversation {
+	return &entity.Conversation{
+		Id:      "conversationId",
+		Members: []string{"a@b.com", "b@b.com", "c@b.com"},
+	}
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) TestNotFound() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(nil, repository.ConversationNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) TestGetAnError() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) TestNotMember() {
+	request := suite.getValidRequest()
+	request.From.Email = "d@b.com"
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(suite.conversation(), nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) TestMessageRepositoryAnError() {
+	request := suite.getValidRequest()
+	conversation := suite.conversation()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(conversation, nil)
+	suite.messageRepository.On("CreateInConversation", request.From.Email, conversation.Id, conversation.Members, request.Message).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *CreateConversationMessageInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	conversation := suite.conversation()
+	message := entity.Message{
+		Id:             "messageId",
+		ConversationId: conversation.Id,
+		Users:          conversation.Members,
+		From:           request.From.Email,
+		Message:        request.Message,
+		CreatedAt:      time.Now(),
+	}
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(conversation, nil)
+	suite.messageRepository.On("CreateInConversation", request.From.Email, conversation.Id, conversation.Members, request.Message).Return(&message, nil)
+	suite.pubsubClient.On("Publish", message).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.CreateMessage{
+		Id:             message.Id,
+		ConversationId: message.ConversationId,
+		From:           message.From,
+		Message:        message.Message,
+		CreatedAt:      message.CreatedAt,
+	}
+	suite.Equal(expected, r)
+}
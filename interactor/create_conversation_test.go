@@ -0,0 +1,110 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type CreateConversationInteractorTestSuite struct {
+	suite.Suite
+	interactor             *CreateConversation
+	userRepository         *mocks.UserRepository
+	conversationRepository *mocks.ConversationRepository
+}
+
+func TestCreateConversationInteractor(t *testing.T) {
+	suite.Run(t, new(CreateConversationInteractorTestSuite))
+}
+
+func (suite *CreateConversationInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewCreateConversationInteractor()
+}
+
+func (suite *CreateConversationInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.conversationRepository = &mocks.ConversationRepository{}
+
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.ConversationRepository = suite.conversationRepository
+}
+
+func (suite *CreateConversationInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.conversationRepository.AssertExpectations(suite.T())
+}
+
+func (suite *CreateConversationInteractorTestSuite) getValidRequest() request.CreateConversation {
+	return request.CreateConversation{
+		User: entity.User{
+			Email: "a@b.com",
+		},
+		Name:    "standup",
+		Members: []string{"b@b.com"},
+	}
+}
+
+func (suite *CreateConversationInteractorTestSuite) TestMemberNotFound() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("GetUserByEmail", "b@b.com").Return(nil, repository.UserNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("members", "notExists")
+	suite.Equal(expected, r)
+}
+
+func (suite *CreateConversationInteractorTestSuite) TestGetMemberAnError() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("GetUserByEmail", "b@b.com").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *CreateConversationInteractorTestSuite) TestRepositoryAnError() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("GetUserByEmail", "b@b.com").Return(&entity.User{Email: "b@b.com"}, nil)
+	suite.conversationRepository.On("Create", request.Name, request.User.Email, request.Members).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *CreateConversationInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	conversation := entity.Conversation{
+		Id:        "conversationId",
+		Name:      request.Name,
+		Members:   []string{"a@b.com", "b@b.com"},
+		CreatedBy: "a@b.com",
+		CreatedAt: time.Now(),
+	}
+	suite.userRepository.On("GetUserByEmail", "b@b.com").Return(&entity.User{Email: "b@b.com"}, nil)
+	suite.conversationRepository.On("Create", request.Name, request.User.Email, request.Members).Return(&conversation, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.CreateConversation{
+		Conversation: response.Conversation{
+			Id:        conversation.Id,
+			Name:      conversation.Name,
+			Members:   conversation.Members,
+			CreatedBy: conversation.CreatedBy,
+			CreatedAt: conversation.CreatedAt,
+		},
+	}
+	suite.Equal(expected, r)
+}
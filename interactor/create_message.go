@@ -5,6 +5,7 @@ package interactor
 
 import (
 	"fmt"
+	"github.com/asiragusa/wschat/entity"
 	"github.com/asiragusa/wschat/repository"
 	"github.com/asiragusa/wschat/request"
 	"github.com/asiragusa/wschat/response"
@@ -25,6 +26,9 @@ type CreateMessage struct {
 	// Injected via DI
 	MessageRepository repository.MessageRepository `inject:""`
 
+	// Injected via DI
+	AttachmentRepository repository.AttachmentRepository `inject:""`
+
 	// Injected via DI
 	PubsubClient services.PubsubClient `inject:""`
 }
@@ -45,23 +49,128 @@ func (i CreateMessage) Call(request request.CreateMessage) response.Response {
 		return response.NewError(iris.StatusInternalServerError)
 	}
 
+	attachments, res := i.getAttachments(request.Attachments, request.From.Email)
+	if res != nil {
+		return res
+	}
+
 	// Create the new message
-	message, err := i.MessageRepository.Create(request.From.Email, to.Email, request.Message)
+	var message *entity.Message
+	if request.ReplyTo != "" {
+		replyTo, res := i.getReplied(request.ReplyTo, request.From.Email, to.Email)
+		if res != nil {
+			return res
+		}
+		message, err = i.MessageRepository.CreateReply(request.From.Email, to.Email, request.Message, replyTo.Quote())
+	} else {
+		message, err = i.MessageRepository.Create(request.From.Email, to.Email, request.Message)
+	}
 	if err != nil {
 		return response.NewError(iris.StatusInternalServerError)
 	}
 
+	// Link the attachments before dispatching the message, undoing everything if any of them can't be
+	if len(attachments) > 0 {
+		attached, err := i.attach(message.Id, attachments)
+		if err != nil {
+			i.rollback(message.Id, attachments)
+		}
+		if err == repository.AttachmentAttachedError || err == repository.AttachmentNotFoundError {
+			error := response.NewError(iris.StatusUnprocessableEntity)
+			error.AddDetail("attachments", "notExists")
+			return error
+		}
+		if err != nil {
+			return response.NewError(iris.StatusInternalServerError)
+		}
+		message = attached
+	}
+
 	// Dispatch the message to the pubsub clients
 	if err := i.PubsubClient.Publish(*message); err != nil {
 		// TODO: do proper logging
 		fmt.Println(err.Error())
 	}
 
-	return response.CreateMessage{
+	created := response.CreateMessage{
 		Id:        message.Id,
 		From:      request.From.Email,
 		To:        to.Email,
 		Message:   message.Message,
 		CreatedAt: message.CreatedAt,
+		ReplyTo:   NewQuoteResponse(message.ReplyTo),
+	}
+	if len(message.Attachments) > 0 {
+		created.Attachments = NewAttachmentsResponse(message.Attachments)
+	}
+
+	return created
+}
+
+// Fetches the message replied to, which must be a direct message between from and to that hasn't been deleted.
+// Returns an error response otherwise
+func (i CreateMessage) getReplied(id, from, to string) (*entity.Message, response.Response) {
+	message, err := i.MessageRepository.GetById(id)
+	if err != nil && err != repository.MessageNotFoundError {
+		return nil, response.NewError(iris.StatusInternalServerError)
+	}
+
+	if err != nil || message.ConversationId != "" || message.Deleted() ||
+		!message.HasUser(from) || !message.HasUser(to) {
+		error := response.NewError(iris.StatusUnprocessableEntity)
+		error.AddDetail("replyTo", "notExists")
+		return nil, error
+	}
+
+	return message, nil
+}
+
+// Fetches the attachments to send, which must have been uploaded by from and not be attached to another message yet.
+// Returns an error response otherwise
+func (i CreateMessage) getAttachments(ids []string, from string) ([]entity.Attachment, response.Response) {
+	var attachments []entity.Attachment
+	for _, id := range ids {
+		attachment, err := i.AttachmentRepository.GetById(id)
+		if err != nil && err != repository.AttachmentNotFoundError {
+			return nil, response.NewError(iris.StatusInternalServerError)
+		}
+
+		if err != nil || attachment.Owner != from || attachment.MessageId != "" {
+			error := response.NewError(iris.StatusUnprocessableEntity)
+			error.AddDetail("attachments", "notExists")
+			return nil, error
+		}
+
+		attachments = append(attachments, *attachment)
+	}
+
+	return attachments, nil
+}
+
+// Links the attachments to the message, so that they can be downloaded by its users
+func (i CreateMessage) attach(id string, attachments []entity.Attachment) (*entity.Message, error) {
+	for j, attachment := range attachments {
+		attached, err := i.AttachmentRepository.Attach(attachment.Id, id)
+		if err != nil {
+			return nil, err
+		}
+		attachments[j] = *attached
+	}
+
+	return i.MessageRepository.Attach(id, attachments)
+}
+
+// Deletes the message and releases the attachments linked to it, so that they can be sent again
+func (i CreateMessage) rollback(id string, attachments []entity.Attachment) {
+	for _, attachment := range attachments {
+		if err := i.AttachmentRepository.Detach(attachment.Id, id); err != nil {
+			// TODO: do proper logging
+			fmt.Println(err.Error())
+		}
+	}
+
+	if err := i.MessageRepository.Delete(id); err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
 	}
 }
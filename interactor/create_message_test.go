@@ -9,16 +9,18 @@ import (
 	"github.com/kataras/iris/httptest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"strings"
 	"testing"
 	"time"
 )
 
 type CreateMessageInteractorTestSuite struct {
 	suite.Suite
-	interactor        *CreateMessage
-	userRepository    *mocks.UserRepository
-	messageRepository *mocks.MessageRepository
-	pubsubClient      *mocks.PubsubClient
+	interactor           *CreateMessage
+	userRepository       *mocks.UserRepository
+	messageRepository    *mocks.MessageRepository
+	attachmentRepository *mocks.AttachmentRepository
+	pubsubClient         *mocks.PubsubClient
 }
 
 func TestCreateMessageInteractor(t *testing.T) {
@@ -32,16 +34,19 @@ func (suite *CreateMessageInteractorTestSuite) SetupSuite() {
 func (suite *CreateMessageInteractorTestSuite) SetupTest() {
 	suite.userRepository = &mocks.UserRepository{}
 	suite.messageRepository = &mocks.MessageRepository{}
+	suite.attachmentRepository = &mocks.AttachmentRepository{}
 	suite.pubsubClient = &mocks.PubsubClient{}
 
 	suite.interactor.UserRepository = suite.userRepository
 	suite.interactor.MessageRepository = suite.messageRepository
+	suite.interactor.AttachmentRepository = suite.attachmentRepository
 	suite.interactor.PubsubClient = suite.pubsubClient
 }
 
 func (suite *CreateMessageInteractorTestSuite) TearDownTest() {
 	suite.userRepository.AssertExpectations(suite.T())
 	suite.messageRepository.AssertExpectations(suite.T())
+	suite.attachmentRepository.AssertExpectations(suite.T())
 	suite.pubsubClient.AssertExpectations(suite.T())
 }
 
@@ -152,3 +157,326 @@ func (suite *CreateMessageInteractorTestSuite) TestOK() {
 	}
 	suite.Equal(expected, r)
 }
+
+func (suite *CreateMessageInteractorTestSuite) getReplied() *entity.Message {
+	return &entity.Message{
+		Id:      "repliedId",
+		From:    "b@b.com",
+		To:      "a@b.com",
+		Users:   []string{"b@b.com", "a@b.com"},
+		Message: "replied",
+	}
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestReplyToNotFound() {
+	request := suite.getValidRequest()
+	request.ReplyTo = "repliedId"
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.messageRepository.On("GetById", request.ReplyTo).Return(nil, repository.MessageNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("replyTo", "notExists")
+	suite.Equal(expected, r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestReplyToAnError() {
+	request := suite.getValidRequest()
+	request.ReplyTo = "repliedId"
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.messageRepository.On("GetById", request.ReplyTo).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestReplyToOtherUsers() {
+	request := suite.getValidRequest()
+	request.ReplyTo = "repliedId"
+
+	replied := suite.getReplied()
+	replied.To = "c@b.com"
+	replied.Users = []string{"b@b.com", "c@b.com"}
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.messageRepository.On("GetById", request.ReplyTo).Return(replied, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("replyTo", "notExists")
+	suite.Equal(expected, r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestReplyOK() {
+	request := suite.getValidRequest()
+	request.ReplyTo = "repliedId"
+
+	replied := suite.getReplied()
+	message := entity.Message{
+		Id:        "messageId",
+		From:      request.From.Email,
+		To:        request.To,
+		Message:   request.Message,
+		CreatedAt: time.Now(),
+		ReplyTo:   replied.Quote(),
+	}
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.messageRepository.On("GetById", request.ReplyTo).Return(replied, nil)
+	suite.messageRepository.On("CreateReply", request.From.Email, request.To, request.Message, entity.Reply{
+		MessageId: "repliedId",
+		From:      "b@b.com",
+		Snippet:   "replied",
+	}).Return(&message, nil)
+	suite.pubsubClient.On("Publish", message).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.CreateMessage{
+		Id:        message.Id,
+		From:      message.From,
+		To:        message.To,
+		Message:   message.Message,
+		CreatedAt: message.CreatedAt,
+		ReplyTo: &response.Quote{
+			MessageId: "repliedId",
+			From:      "b@b.com",
+			Snippet:   "replied",
+		},
+	}
+	suite.Equal(expected, r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestReplySnippet() {
+	request := suite.getValidRequest()
+	request.ReplyTo = "repliedId"
+
+	// The long messages are truncated in the quote
+	replied := suite.getReplied()
+	replied.Message = strings.Repeat("é", entity.SnippetLength+1)
+	message := entity.Message{
+		Id:      "messageId",
+		ReplyTo: replied.Quote(),
+	}
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.messageRepository.On("GetById", request.ReplyTo).Return(replied, nil)
+	suite.messageRepository.On("CreateReply", request.From.Email, request.To, request.Message, entity.Reply{
+		MessageId: "repliedId",
+		From:      "b@b.com",
+		Snippet:   strings.Repeat("é", entity.SnippetLength-1) + "…",
+	}).Return(&message, nil)
+	suite.pubsubClient.On("Publish", message).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(httptest.StatusCreated, r.GetCode())
+}
+
+func (suite *CreateMessageInteractorTestSuite) getAttachment() *entity.Attachment {
+	return &entity.Attachment{
+		Id:       "attachmentId",
+		Owner:    "a@b.com",
+		Name:     "file.png",
+		MimeType: "image/png",
+		Size:     42,
+		Checksum: "checksum",
+	}
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestAttachmentNotFound() {
+	request := suite.getValidRequest()
+	request.Attachments = []string{"attachmentId"}
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(nil, repository.AttachmentNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("attachments", "notExists")
+	suite.Equal(expected, r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestAttachmentAnError() {
+	request := suite.getValidRequest()
+	request.Attachments = []string{"attachmentId"}
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestAttachmentNotOwned() {
+	request := suite.getValidRequest()
+	request.Attachments = []string{"attachmentId"}
+
+	attachment := suite.getAttachment()
+	attachment.Owner = "b@b.com"
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(attachment, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("attachments", "notExists")
+	suite.Equal(expected, r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestAttachmentAlreadySent() {
+	request := suite.getValidRequest()
+	request.Attachments = []string{"attachmentId"}
+
+	attachment := suite.getAttachment()
+	attachment.MessageId = "otherId"
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(attachment, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("attachments", "notExists")
+	suite.Equal(expected, r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestAttachAnError() {
+	request := suite.getValidRequest()
+	request.Attachments = []string{"attachmentId"}
+	message := entity.Message{
+		Id: "messageId",
+	}
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getAttachment(), nil)
+	suite.messageRepository.On("Create", request.From.Email, request.To, request.Message).Return(&message, nil)
+	suite.attachmentRepository.On("Attach", "attachmentId", "messageId").Return(nil, assert.AnError)
+
+	// The message is deleted and the attachment released, without being dispatched
+	suite.attachmentRepository.On("Detach", "attachmentId", "messageId").Return(nil)
+	suite.messageRepository.On("Delete", "messageId").Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestAttachAttachedError() {
+	request := suite.getValidRequest()
+	request.Attachments = []string{"attachmentId", "otherId"}
+	message := entity.Message{
+		Id: "messageId",
+	}
+	other := suite.getAttachment()
+	other.Id = "otherId"
+	attached := suite.getAttachment()
+	attached.MessageId = "messageId"
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getAttachment(), nil)
+	suite.attachmentRepository.On("GetById", "otherId").Return(other, nil)
+	suite.messageRepository.On("Create", request.From.Email, request.To, request.Message).Return(&message, nil)
+
+	// The second attachment has been sent with another message in the meantime
+	suite.attachmentRepository.On("Attach", "attachmentId", "messageId").Return(attached, nil)
+	suite.attachmentRepository.On("Attach", "otherId", "messageId").Return(nil, repository.AttachmentAttachedError)
+
+	// Only the attachments linked to this message are released
+	suite.attachmentRepository.On("Detach", "attachmentId", "messageId").Return(nil)
+	suite.attachmentRepository.On("Detach", "otherId", "messageId").Return(nil)
+	suite.messageRepository.On("Delete", "messageId").Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("attachments", "notExists")
+	suite.Equal(expected, r)
+}
+
+func (suite *CreateMessageInteractorTestSuite) TestAttachmentsOK() {
+	request := suite.getValidRequest()
+	request.Attachments = []string{"attachmentId"}
+
+	created := entity.Message{
+		Id:        "messageId",
+		From:      request.From.Email,
+		To:        request.To,
+		Message:   request.Message,
+		CreatedAt: time.Now(),
+	}
+
+	attached := suite.getAttachment()
+	attached.MessageId = "messageId"
+
+	message := created
+	message.Attachments = []entity.Attachment{*attached}
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{
+		Email: request.To,
+	}, nil)
+	suite.attachmentRepository.On("GetById", "attachmentId").Return(suite.getAttachment(), nil)
+	suite.messageRepository.On("Create", request.From.Email, request.To, request.Message).Return(&created, nil)
+	suite.attachmentRepository.On("Attach", "attachmentId", "messageId").Return(attached, nil)
+	suite.messageRepository.On("Attach", "messageId", []entity.Attachment{*attached}).Return(&message, nil)
+	suite.pubsubClient.On("Publish", message).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.CreateMessage{
+		Id:        message.Id,
+		From:      message.From,
+		To:        message.To,
+		Message:   message.Message,
+		CreatedAt: message.CreatedAt,
+		Attachments: []response.Attachment{
+			{
+				Id:       "attachmentId",
+				Name:     "file.png",
+				MimeType: "image/png",
+				Size:     42,
+				Checksum: "checksum",
+				Url:      "/attachments/attachmentId",
+			},
+		},
+	}
+	suite.Equal(expected, r)
+}
@@ -0,0 +1,89 @@
+package interactor
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+const (
+	// Hides the message from the history of the user
+	DeleteScopeSelf = "self"
+
+	// Deletes the message for all its users
+	DeleteScopeEveryone = "everyone"
+)
+
+// Interface used mainly for Unit testing
+type DeleteMessageInteractor interface {
+	Call(request.DeleteMessage) response.Response
+}
+
+// Deletes a message for the user or for everyone and notifies the users concerned
+type DeleteMessage struct {
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+}
+
+func NewDeleteMessageInteractor() *DeleteMessage {
+	return &DeleteMessage{}
+}
+
+func (i DeleteMessage) Call(request request.DeleteMessage) response.Response {
+	message, err := i.MessageRepository.GetById(request.MessageId)
+	if err == repository.MessageNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Only the users of the message can see it
+	if !message.HasUser(request.User.Email) {
+		return response.NewError(iris.StatusNotFound)
+	}
+
+	// The other connections of the user are notified, and all the users if the message is deleted for everyone
+	to := []string{request.User.Email}
+	if request.Scope == DeleteScopeEveryone {
+		// Only the author can delete the message for everyone
+		if message.From != request.User.Email {
+			return response.NewError(iris.StatusForbidden)
+		}
+
+		to = message.Users
+		message, err = i.MessageRepository.DeleteForEveryone(message.Id)
+	} else {
+		message, err = i.MessageRepository.DeleteFor(message.Id, request.User.Email)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	res := response.DeleteMessage{
+		MessageId: message.Id,
+		Scope:     request.Scope,
+	}
+	if request.Scope == DeleteScopeEveryone {
+		deletedAt := message.DeletedAt
+		res.DeletedAt = &deletedAt
+	}
+
+	event, err := entity.NewEvent("deleted", to, res)
+	if err == nil {
+		err = i.PubsubClient.PublishEvent(event)
+	}
+	if err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
+	}
+
+	return res
+}
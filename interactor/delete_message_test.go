@@ -0,0 +1,175 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type DeleteMessageInteractorTestSuite struct {
+	suite.Suite
+	interactor        *DeleteMessage
+	messageRepository *mocks.MessageRepository
+	pubsub            *mocks.PubsubClient
+}
+
+func TestDeleteMessageInteractor(t *testing.T) {
+	suite.Run(t, new(DeleteMessageInteractorTestSuite))
+}
+
+func (suite *DeleteMessageInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewDeleteMessageInteractor()
+}
+
+func (suite *DeleteMessageInteractorTestSuite) SetupTest() {
+	suite.messageRepository = &mocks.MessageRepository{}
+	suite.pubsub = &mocks.PubsubClient{}
+
+	suite.interactor.MessageRepository = suite.messageRepository
+	suite.interactor.PubsubClient = suite.pubsub
+}
+
+func (suite *DeleteMessageInteractorTestSuite) TearDownTest() {
+	suite.messageRepository.AssertExpectations(suite.T())
+	suite.pubsub.AssertExpectations(suite.T())
+}
+
+func (suite *DeleteMessageInteractorTestSuite) getValidRequest(scope string) request.DeleteMessage {
+	return request.DeleteMessage{
+		User: entity.User{
+			Email: "a@b.com",
+		},
+		MessageId: "messageId",
+		Scope:     scope,
+	}
+}
+
+func (suite *DeleteMessageInteractorTestSuite) getMessage() *entity.Message {
+	return &entity.Message{
+		Id:        "messageId",
+		From:      "a@b.com",
+		To:        "b@b.com",
+		Users:     []string{"a@b.com", "b@b.com"},
+		Message:   "txt",
+		CreatedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// Captures the published event
+func (suite *DeleteMessageInteractorTestSuite) expectEvent(published *entity.Event) {
+	suite.pubsub.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		*published = event
+		return true
+	})).Return(nil)
+}
+
+func (suite *DeleteMessageInteractorTestSuite) TestNotFound() {
+	request := suite.getValidRequest(DeleteScopeSelf)
+	suite.messageRepository.On("GetById", request.MessageId).Return(nil, repository.MessageNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *DeleteMessageInteractorTestSuite) TestGetAnError() {
+	request := suite.getValidRequest(DeleteScopeSelf)
+	suite.messageRepository.On("GetById", request.MessageId).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *DeleteMessageInteractorTestSuite) TestNotParticipant() {
+	request := suite.getValidRequest(DeleteScopeSelf)
+	request.User.Email = "c@b.com"
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *DeleteMessageInteractorTestSuite) TestEveryoneNotAuthor() {
+	request := suite.getValidRequest(DeleteScopeEveryone)
+	request.User.Email = "b@b.com"
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *DeleteMessageInteractorTestSuite) TestDeleteAnError() {
+	request := suite.getValidRequest(DeleteScopeEveryone)
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+	suite.messageRepository.On("DeleteForEveryone", request.MessageId).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *DeleteMessageInteractorTestSuite) TestSelfOK() {
+	// The recipient can delete the message for itself
+	request := suite.getValidRequest(DeleteScopeSelf)
+	request.User.Email = "b@b.com"
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+
+	deleted := suite.getMessage()
+	deleted.DeleteFor("b@b.com")
+	suite.messageRepository.On("DeleteFor", request.MessageId, "b@b.com").Return(deleted, nil)
+
+	var published entity.Event
+	suite.expectEvent(&published)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.DeleteMessage{
+		MessageId: "messageId",
+		Scope:     DeleteScopeSelf,
+	}, r)
+
+	// Only the other connections of the user are notified
+	suite.Equal("deleted", published.Type)
+	suite.Equal([]string{"b@b.com"}, published.To)
+	suite.JSONEq(`{"messageId": "messageId", "scope": "self"}`, string(published.Data))
+}
+
+func (suite *DeleteMessageInteractorTestSuite) TestEveryoneOK() {
+	request := suite.getValidRequest(DeleteScopeEveryone)
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+
+	deletedAt := time.Date(2017, 1, 1, 0, 1, 0, 0, time.UTC)
+	deleted := suite.getMessage()
+	deleted.Delete(deletedAt)
+	suite.messageRepository.On("DeleteForEveryone", request.MessageId).Return(deleted, nil)
+
+	var published entity.Event
+	suite.expectEvent(&published)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.DeleteMessage{
+		MessageId: "messageId",
+		Scope:     DeleteScopeEveryone,
+		DeletedAt: &deletedAt,
+	}, r)
+
+	suite.Equal("deleted", published.Type)
+	suite.Equal([]string{"a@b.com", "b@b.com"}, published.To)
+	suite.JSONEq(`{
+		"messageId": "messageId",
+		"scope": "everyone",
+		"deletedAt": "2017-01-01T00:01:00Z"
+	}`, string(published.Data))
+}
@@ -0,0 +1,48 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type DeleteSessionInteractor interface {
+	Call(request.DeleteSession) response.Response
+}
+
+// Revokes one of the sessions of the user. Its tokens stop working and its websockets are closed
+type DeleteSession struct {
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+
+	// Injected via DI
+	SessionRevoker services.SessionRevoker `inject:""`
+}
+
+func NewDeleteSessionInteractor() *DeleteSession {
+	return &DeleteSession{}
+}
+
+func (i DeleteSession) Call(request request.DeleteSession) response.Response {
+	session, err := i.SessionRepository.GetById(request.SessionId)
+	if err == repository.SessionNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// The sessions of the other users and the stale ones are not visible
+	if session.UserId != request.User.Id || session.Secret != request.User.Secret {
+		return response.NewError(iris.StatusNotFound)
+	}
+
+	if err := i.SessionRevoker.Revoke(request.User, session.Id); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.NoContentResponse{}
+}
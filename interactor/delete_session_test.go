@@ -0,0 +1,106 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type DeleteSessionInteractorTestSuite struct {
+	suite.Suite
+	interactor        *DeleteSession
+	sessionRepository *mocks.SessionRepository
+	revoker           *mocks.SessionRevoker
+}
+
+func TestDeleteSessionInteractor(t *testing.T) {
+	suite.Run(t, new(DeleteSessionInteractorTestSuite))
+}
+
+func (suite *DeleteSessionInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewDeleteSessionInteractor()
+}
+
+func (suite *DeleteSessionInteractorTestSuite) SetupTest() {
+	suite.sessionRepository = &mocks.SessionRepository{}
+	suite.revoker = &mocks.SessionRevoker{}
+
+	suite.interactor.SessionRepository = suite.sessionRepository
+	suite.interactor.SessionRevoker = suite.revoker
+}
+
+func (suite *DeleteSessionInteractorTestSuite) TearDownTest() {
+	suite.sessionRepository.AssertExpectations(suite.T())
+	suite.revoker.AssertExpectations(suite.T())
+}
+
+func (suite *DeleteSessionInteractorTestSuite) getValidRequest() request.DeleteSession {
+	return request.DeleteSession{
+		User:      entity.User{Id: "userId", Email: "a@b.com", Secret: "secret"},
+		SessionId: "sessionId",
+	}
+}
+
+func (suite *DeleteSessionInteractorTestSuite) session() *entity.Session {
+	return &entity.Session{Id: "sessionId", UserId: "userId", Secret: "secret"}
+}
+
+func (suite *DeleteSessionInteractorTestSuite) TestNotFound() {
+	request := suite.getValidRequest()
+	suite.sessionRepository.On("GetById", "sessionId").Return(nil, repository.SessionNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *DeleteSessionInteractorTestSuite) TestRepositoryAnyError() {
+	request := suite.getValidRequest()
+	suite.sessionRepository.On("GetById", "sessionId").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *DeleteSessionInteractorTestSuite) TestOtherUser() {
+	request := suite.getValidRequest()
+	session := suite.session()
+	session.UserId = "otherId"
+	suite.sessionRepository.On("GetById", "sessionId").Return(session, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *DeleteSessionInteractorTestSuite) TestStaleSession() {
+	request := suite.getValidRequest()
+	session := suite.session()
+	session.Secret = "oldSecret"
+	suite.sessionRepository.On("GetById", "sessionId").Return(session, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *DeleteSessionInteractorTestSuite) TestRevokeAnError() {
+	request := suite.getValidRequest()
+	suite.sessionRepository.On("GetById", "sessionId").Return(suite.session(), nil)
+	suite.revoker.On("Revoke", request.User, "sessionId").Return(assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *DeleteSessionInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.sessionRepository.On("GetById", "sessionId").Return(suite.session(), nil)
+	suite.revoker.On("Revoke", request.User, "sessionId").Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NoContentResponse{}, r)
+}
@@ -0,0 +1,89 @@
+package interactor
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/jonboulle/clockwork"
+	"github.com/kataras/iris"
+	"time"
+)
+
+// Default duration after the creation during which a message can be edited
+const DefaultEditWindow = 15 * time.Minute
+
+// Interface used mainly for Unit testing
+type EditMessageInteractor interface {
+	Call(request.EditMessage) response.Response
+}
+
+// Replaces the text of a message and notifies its users
+type EditMessage struct {
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+
+	// Duration after the creation during which the message can be edited
+	Window time.Duration
+}
+
+func NewEditMessageInteractor(window time.Duration) *EditMessage {
+	return &EditMessage{
+		Window: window,
+	}
+}
+
+func (i EditMessage) Call(request request.EditMessage) response.Response {
+	message, err := i.MessageRepository.GetById(request.MessageId)
+	if err == repository.MessageNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Only the users of the message can see it, and nothing is left to edit in a deleted message
+	if !message.HasUser(request.User.Email) || message.Deleted() {
+		return response.NewError(iris.StatusNotFound)
+	}
+
+	// Only the author can edit the message
+	if message.From != request.User.Email {
+		return response.NewError(iris.StatusForbidden)
+	}
+
+	if i.Clock.Now().Sub(message.CreatedAt) > i.Window {
+		err := response.NewError(iris.StatusUnprocessableEntity)
+		err.AddDetail("messageId", "editWindowExpired")
+		return err
+	}
+
+	message, err = i.MessageRepository.Edit(message.Id, request.Message)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	res := response.EditMessage{
+		Message: newMessageResponse(*message),
+	}
+
+	// Notify the users of the message, including the author's other connections
+	event, err := entity.NewEvent("edited", message.Users, res)
+	if err == nil {
+		err = i.PubsubClient.PublishEvent(event)
+	}
+	if err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
+	}
+
+	return res
+}
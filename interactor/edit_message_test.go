@@ -0,0 +1,182 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/jonboulle/clockwork"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type EditMessageInteractorTestSuite struct {
+	suite.Suite
+	interactor        *EditMessage
+	messageRepository *mocks.MessageRepository
+	pubsub            *mocks.PubsubClient
+	clock             clockwork.FakeClock
+}
+
+func TestEditMessageInteractor(t *testing.T) {
+	suite.Run(t, new(EditMessageInteractorTestSuite))
+}
+
+func (suite *EditMessageInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewEditMessageInteractor(DefaultEditWindow)
+}
+
+func (suite *EditMessageInteractorTestSuite) SetupTest() {
+	suite.messageRepository = &mocks.MessageRepository{}
+	suite.pubsub = &mocks.PubsubClient{}
+	suite.clock = clockwork.NewFakeClockAt(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	suite.interactor.MessageRepository = suite.messageRepository
+	suite.interactor.PubsubClient = suite.pubsub
+	suite.interactor.Clock = suite.clock
+}
+
+func (suite *EditMessageInteractorTestSuite) TearDownTest() {
+	suite.messageRepository.AssertExpectations(suite.T())
+	suite.pubsub.AssertExpectations(suite.T())
+}
+
+func (suite *EditMessageInteractorTestSuite) getValidRequest() request.EditMessage {
+	return request.EditMessage{
+		User: entity.User{
+			Email: "a@b.com",
+		},
+		MessageId: "messageId",
+		Message:   "new",
+	}
+}
+
+func (suite *EditMessageInteractorTestSuite) getMessage() *entity.Message {
+	return &entity.Message{
+		Id:        "messageId",
+		From:      "a@b.com",
+		To:        "b@b.com",
+		Users:     []string{"a@b.com", "b@b.com"},
+		Message:   "old",
+		CreatedAt: suite.clock.Now(),
+	}
+}
+
+func (suite *EditMessageInteractorTestSuite) TestNotFound() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(nil, repository.MessageNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *EditMessageInteractorTestSuite) TestGetAnError() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *EditMessageInteractorTestSuite) TestNotParticipant() {
+	request := suite.getValidRequest()
+	request.User.Email = "c@b.com"
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *EditMessageInteractorTestSuite) TestDeleted() {
+	request := suite.getValidRequest()
+	message := suite.getMessage()
+	message.Delete(suite.clock.Now())
+	suite.messageRepository.On("GetById", request.MessageId).Return(message, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *EditMessageInteractorTestSuite) TestNotAuthor() {
+	request := suite.getValidRequest()
+	request.User.Email = "b@b.com"
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *EditMessageInteractorTestSuite) TestWindowExpired() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+	suite.clock.Advance(DefaultEditWindow + time.Second)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("messageId", "editWindowExpired")
+	suite.Equal(expected, r)
+}
+
+func (suite *EditMessageInteractorTestSuite) TestEditAnError() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+	suite.messageRepository.On("Edit", request.MessageId, request.Message).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *EditMessageInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+
+	edited := suite.getMessage()
+	suite.clock.Advance(time.Minute)
+	edited.Edit(request.Message, suite.clock.Now())
+	suite.messageRepository.On("Edit", request.MessageId, request.Message).Return(edited, nil)
+
+	var published entity.Event
+	suite.pubsub.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		published = event
+		return true
+	})).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	editedAt := suite.clock.Now()
+	suite.Equal(response.EditMessage{
+		Message: response.Message{
+			Id:        "messageId",
+			From:      "a@b.com",
+			To:        "b@b.com",
+			Message:   "new",
+			CreatedAt: edited.CreatedAt,
+			EditedAt:  &editedAt,
+		},
+	}, r)
+
+	suite.Equal("edited", published.Type)
+	suite.Equal([]string{"a@b.com", "b@b.com"}, published.To)
+	suite.JSONEq(`{
+		"id": "messageId",
+		"from": "a@b.com",
+		"to": "b@b.com",
+		"message": "new",
+		"createdAt": "2017-01-01T00:00:00Z",
+		"editedAt": "2017-01-01T00:01:00Z"
+	}`, string(published.Data))
+}
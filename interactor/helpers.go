@@ -0,0 +1,132 @@
+package interactor
+
+import (
+	"bytes"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/response"
+	"html"
+)
+
+// Converts a message entity to its response representation
+func newMessageResponse(message entity.Message) response.Message {
+	res := response.Message{
+		Id:             message.Id,
+		ConversationId: message.ConversationId,
+		From:           message.From,
+		To:             message.To,
+		Message:        message.Message,
+		CreatedAt:      message.CreatedAt,
+	}
+
+	res.ReplyTo = NewQuoteResponse(message.ReplyTo)
+
+	if !message.EditedAt.IsZero() {
+		editedAt := message.EditedAt
+		res.EditedAt = &editedAt
+	}
+
+	if message.Deleted() {
+		deletedAt := message.DeletedAt
+		res.DeletedAt = &deletedAt
+	}
+
+	for _, receipt := range message.Reads {
+		res.Reads = append(res.Reads, response.Receipt{
+			User:   receipt.User,
+			ReadAt: receipt.ReadAt,
+		})
+	}
+
+	if len(message.Reactions) > 0 {
+		res.Reactions = newReactionsResponse(message.Reactions)
+	}
+
+	if len(message.Attachments) > 0 {
+		res.Attachments = NewAttachmentsResponse(message.Attachments)
+	}
+
+	return res
+}
+
+// Converts the message replied to to its response representation. Returns nil if the message is not a reply
+func NewQuoteResponse(reply entity.Reply) *response.Quote {
+	if reply.MessageId == "" {
+		return nil
+	}
+
+	return &response.Quote{
+		MessageId: reply.MessageId,
+		From:      reply.From,
+		Snippet:   reply.Snippet,
+	}
+}
+
+// Converts the reactions to a message to their response representation
+func newReactionsResponse(reactions []entity.Reaction) []response.Reaction {
+	res := []response.Reaction{}
+	for _, reaction := range reactions {
+		res = append(res, response.Reaction{
+			Emoji: reaction.Emoji,
+			Count: reaction.Count,
+			Users: reaction.Users,
+		})
+	}
+	return res
+}
+
+// Converts an attachment entity to its response representation
+func newAttachmentResponse(attachment entity.Attachment) response.Attachment {
+	return response.Attachment{
+		Id:       attachment.Id,
+		Name:     attachment.Name,
+		MimeType: attachment.MimeType,
+		Size:     attachment.Size,
+		Checksum: attachment.Checksum,
+		Url:      response.AttachmentUrl(attachment.Id),
+	}
+}
+
+// Converts the attachments of a message to their response representation
+func NewAttachmentsResponse(attachments []entity.Attachment) []response.Attachment {
+	res := []response.Attachment{}
+	for _, attachment := range attachments {
+		res = append(res, newAttachmentResponse(attachment))
+	}
+	return res
+}
+
+// Escapes the text for HTML and wraps the words matching the terms in <mark> tags
+func highlight(text string, terms []string) string {
+	matches := map[string]bool{}
+	for _, term := range terms {
+		matches[term] = true
+	}
+
+	var buffer bytes.Buffer
+	last := 0
+	for _, token := range entity.Tokenize(text) {
+		if !matches[token.Term] {
+			continue
+		}
+
+		buffer.WriteString(html.EscapeString(text[last:token.Start]))
+		buffer.WriteString("<mark>")
+		buffer.WriteString(html.EscapeString(text[token.Start:token.End]))
+		buffer.WriteString("</mark>")
+		last = token.End
+	}
+	buffer.WriteString(html.EscapeString(text[last:]))
+
+	return buffer.String()
+}
+
+// Converts a conversation entity to its response representation
+func newConversationResponse(conversation entity.Conversation) response.Conversation {
+	return response.Conversation{
+		Id:        conversation.Id,
+		Name:      conversation.Name,
+		Members:   conversation.Members,
+		CreatedBy: conversation.CreatedBy,
+		CreatedAt: conversation.CreatedAt,
+	}
+}
@@ -0,0 +1,45 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+)
+
+// Interface used mainly for Unit testing
+type JwksInteractor interface {
+	Call(request.Jwks) response.Response
+}
+
+// Publishes the public keys validating the JWT tokens, so that other services can validate the access tokens
+type Jwks struct {
+	// Injected via DI
+	Keys *services.KeySet `inject:""`
+}
+
+func NewJwksInteractor() *Jwks {
+	return &Jwks{}
+}
+
+func (i Jwks) Call(request request.Jwks) response.Response {
+	res := response.Jwks{
+		Keys: []response.Jwk{},
+	}
+
+	for _, key := range i.Keys.PublicKeys() {
+		jwk := key.Jwk()
+		res.Keys = append(res.Keys, response.Jwk{
+			Kid: key.Id,
+			Kty: jwk.Kty,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+			Crv: jwk.Crv,
+			X:   jwk.X,
+			Y:   jwk.Y,
+			N:   jwk.N,
+			E:   jwk.E,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,73 @@
+package interactor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type JwksInteractorTestSuite struct {
+	suite.Suite
+	interactor *Jwks
+}
+
+func TestJwksInteractor(t *testing.T) {
+	suite.Run(t, new(JwksInteractorTestSuite))
+}
+
+func (suite *JwksInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewJwksInteractor()
+}
+
+// Returns a new ES256 key
+func (suite *JwksInteractorTestSuite) newKey() *services.Key {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().NoError(err)
+
+	der, err := x509.MarshalECPrivateKey(private)
+	suite.Require().NoError(err)
+
+	key, err := services.ParseKey(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	suite.Require().NoError(err)
+	return key
+}
+
+func (suite *JwksInteractorTestSuite) TestHmacOnly() {
+	keys, err := services.NewKeySet(services.NewHmacKey("secret"))
+	suite.Require().NoError(err)
+	suite.interactor.Keys = keys
+
+	r := suite.interactor.Call(request.Jwks{})
+	suite.Equal(response.Jwks{Keys: []response.Jwk{}}, r)
+}
+
+func (suite *JwksInteractorTestSuite) TestOK() {
+	signing := suite.newKey()
+	old := suite.newKey()
+	keys, err := services.NewKeySet(signing, old)
+	suite.Require().NoError(err)
+	suite.interactor.Keys = keys
+
+	r := suite.interactor.Call(request.Jwks{})
+	suite.Require().IsType(response.Jwks{}, r)
+
+	jwks := r.(response.Jwks)
+	suite.Require().Len(jwks.Keys, 2)
+	suite.Equal(response.Jwk{
+		Kid: signing.Id,
+		Kty: "EC",
+		Use: "sig",
+		Alg: "ES256",
+		Crv: "P-256",
+		X:   signing.Jwk().X,
+		Y:   signing.Jwk().Y,
+	}, jwks.Keys[0])
+	suite.Equal(old.Id, jwks.Keys[1].Kid)
+}
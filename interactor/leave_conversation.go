@@ -0,0 +1,45 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type LeaveConversationInteractor interface {
+	Call(request.ConversationMembership) response.Response
+}
+
+// Removes the current user from a conversation
+type LeaveConversation struct {
+	// Injected via DI
+	ConversationRepository repository.ConversationRepository `inject:""`
+}
+
+func NewLeaveConversationInteractor() *LeaveConversation {
+	return &LeaveConversation{}
+}
+
+func (i LeaveConversation) Call(request request.ConversationMembership) response.Response {
+	// Find the conversation or return an error
+	conversation, err := i.ConversationRepository.GetById(request.ConversationId)
+	if err == repository.ConversationNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Only members can leave a conversation
+	if !conversation.HasMember(request.User.Email) {
+		return response.NewError(iris.StatusForbidden)
+	}
+
+	if _, err := i.ConversationRepository.RemoveMember(conversation.Id, request.User.Email); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.NoContentResponse{}
+}
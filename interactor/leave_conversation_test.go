@@ -0,0 +1,101 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type LeaveConversationInteractorTestSuite struct {
+	suite.Suite
+	interactor             *LeaveConversation
+	conversationRepository *mocks.ConversationRepository
+}
+
+func TestLeaveConversationInteractor(t *testing.T) {
+	suite.Run(t, new(LeaveConversationInteractorTestSuite))
+}
+
+func (suite *LeaveConversationInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewLeaveConversationInteractor()
+}
+
+func (suite *LeaveConversationInteractorTestSuite) SetupTest() {
+	suite.conversationRepository = &mocks.ConversationRepository{}
+	suite.interactor.ConversationRepository = suite.conversationRepository
+}
+
+func (suite *LeaveConversationInteractorTestSuite) TearDownTest() {
+	suite.conversationRepository.AssertExpectations(suite.T())
+}
+
+func (suite *LeaveConversationInteractorTestSuite) getValidRequest() request.ConversationMembership {
+	return request.ConversationMembership{
+		User: entity.User{
+			Email: "a@b.com",
+		},
+		ConversationId: "conversationId",
+	}
+}
+
+func (suite *LeaveConversationInteractorTestSuite) TestNotFound() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(nil, repository.ConversationNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *LeaveConversationInteractorTestSuite) TestGetAnError() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LeaveConversationInteractorTestSuite) TestNotMember() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(&entity.Conversation{
+		Id:      request.ConversationId,
+		Members: []string{"b@b.com"},
+	}, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *LeaveConversationInteractorTestSuite) TestRemoveAnError() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(&entity.Conversation{
+		Id:      request.ConversationId,
+		Members: []string{request.User.Email},
+	}, nil)
+	suite.conversationRepository.On("RemoveMember", request.ConversationId, request.User.Email).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LeaveConversationInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(&entity.Conversation{
+		Id:      request.ConversationId,
+		Members: []string{request.User.Email},
+	}, nil)
+	suite.conversationRepository.On("RemoveMember", request.ConversationId, request.User.Email).Return(&entity.Conversation{}, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NoContentResponse{}, r)
+}
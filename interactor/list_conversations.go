@@ -0,0 +1,42 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type ListConversationsInteractor interface {
+	Call(request.ListConversations) response.Response
+}
+
+// Lists the conversations the user is a member of
+type ListConversations struct {
+	// Injected via DI
+	ConversationRepository repository.ConversationRepository `inject:""`
+}
+
+func NewListConversationsInteractor() *ListConversations {
+	return &ListConversations{}
+}
+
+func (i ListConversations) Call(request request.ListConversations) response.Response {
+	// Find the conversations
+	conversations, err := i.ConversationRepository.AllWithMember(request.User.Email)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	res := response.ListConversations{
+		Total: len(conversations),
+		Items: []response.Conversation{},
+	}
+
+	for _, conversation := range conversations {
+		res.Items = append(res.Items, newConversationResponse(conversation))
+	}
+
+	return res
+}
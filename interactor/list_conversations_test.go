@@ -0,0 +1,73 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type ListConversationsInteractorTestSuite struct {
+	suite.Suite
+	interactor             *ListConversations
+	conversationRepository *mocks.ConversationRepository
+}
+
+func TestListConversationsInteractor(t *testing.T) {
+	suite.Run(t, new(ListConversationsInteractorTestSuite))
+}
+
+func (suite *ListConversationsInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewListConversationsInteractor()
+}
+
+func (suite *ListConversationsInteractorTestSuite) SetupTest() {
+	suite.conversationRepository = &mocks.ConversationRepository{}
+	suite.interactor.ConversationRepository = suite.conversationRepository
+}
+
+func (suite *ListConversationsInteractorTestSuite) TearDownTest() {
+	suite.conversationRepository.AssertExpectations(suite.T())
+}
+
+func (suite *ListConversationsInteractorTestSuite) getValidRequest() request.ListConversations {
+	return request.ListConversations{
+		User: entity.User{
+			Email: "a@b.com",
+		},
+	}
+}
+
+func (suite *ListConversationsInteractorTestSuite) TestRepositoryAnyError() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("AllWithMember", request.User.Email).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ListConversationsInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	conversations := []entity.Conversation{
+		{Name: "c1"},
+		{Name: "c2"},
+	}
+	suite.conversationRepository.On("AllWithMember", request.User.Email).Return(conversations, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.ListConversations{
+		Total: 2,
+		Items: []response.Conversation{
+			{Name: "c1"},
+			{Name: "c2"},
+		},
+	}
+	suite.Equal(expected, r)
+}
@@ -7,12 +7,15 @@ import (
 	"github.com/kataras/iris"
 )
 
+// Number of messages returned when the request doesn't specify a limit
+const defaultMessagesLimit = 50
+
 // Interface used mainly for Unit testing
 type ListMessagesInteractor interface {
 	Call(request.ListMessages) response.Response
 }
 
-// ListMessages returns all the messages for the given user
+// ListMessages returns a page of the messages for the given user
 type ListMessages struct {
 	// Injected via DI
 	MessageRepository repository.MessageRepository `inject:""`
@@ -23,25 +26,53 @@ func NewListMessagesInteractor() *ListMessages {
 }
 
 func (i ListMessages) Call(request request.ListMessages) response.Response {
+	// before and after, with and conversationId are mutually exclusive
+	if request.Before != "" && request.After != "" {
+		err := response.NewError(iris.StatusUnprocessableEntity)
+		err.AddDetail("after", "excluded")
+		return err
+	}
+	if request.With != "" && request.ConversationId != "" {
+		err := response.NewError(iris.StatusUnprocessableEntity)
+		err.AddDetail("conversationId", "excluded")
+		return err
+	}
+
+	options := repository.MessagePageOptions{
+		With:           request.With,
+		ConversationId: request.ConversationId,
+		Limit:          request.Limit,
+		Before:         request.Before,
+		After:          request.After,
+	}
+	if options.Limit == 0 {
+		options.Limit = defaultMessagesLimit
+	}
+
 	// Find messages
-	messages, err := i.MessageRepository.AllWithUser(request.User.Email)
+	page, err := i.MessageRepository.PageWithUser(request.User.Email, options)
+	if err == repository.InvalidCursorError {
+		err := response.NewError(iris.StatusUnprocessableEntity)
+		if request.After != "" {
+			err.AddDetail("after", "invalid")
+		} else {
+			err.AddDetail("before", "invalid")
+		}
+		return err
+	}
 	if err != nil {
 		return response.NewError(iris.StatusInternalServerError)
 	}
 
 	res := response.ListMessages{
-		Total: len(messages),
-		Items: []response.Message{},
-	}
-
-	for _, message := range messages {
-		res.Items = append(res.Items, response.Message{
-			Id:        message.Id,
-			From:      message.From,
-			To:        message.To,
-			Message:   message.Message,
-			CreatedAt: message.CreatedAt,
-		})
+		Total:      len(page.Messages),
+		Items:      []response.Message{},
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+
+	for _, message := range page.Messages {
+		res.Items = append(res.Items, newMessageResponse(message))
 	}
 	return res
 }
@@ -3,6 +3,7 @@ package interactor
 import (
 	"github.com/asiragusa/wschat/entity"
 	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
 	"github.com/asiragusa/wschat/request"
 	"github.com/asiragusa/wschat/response"
 	"github.com/kataras/iris/httptest"
@@ -43,10 +44,57 @@ func (suite *ListMessagesInteractorTestSuite) getValidRequest() request.ListMess
 	}
 }
 
+func (suite *ListMessagesInteractorTestSuite) TestBeforeAndAfter() {
+	request := suite.getValidRequest()
+	request.Before = "before"
+	request.After = "after"
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("after", "excluded")
+	suite.Equal(expected, r)
+}
+
+func (suite *ListMessagesInteractorTestSuite) TestWithAndConversationId() {
+	request := suite.getValidRequest()
+	request.With = "a@b.com"
+	request.ConversationId = "conversationId"
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("conversationId", "excluded")
+	suite.Equal(expected, r)
+}
+
+func (suite *ListMessagesInteractorTestSuite) TestInvalidCursor() {
+	request := suite.getValidRequest()
+	request.After = "invalid"
+	options := repository.MessagePageOptions{
+		Limit: defaultMessagesLimit,
+		After: "invalid",
+	}
+
+	suite.messageRepository.On("PageWithUser", request.User.Email, options).Return(nil, repository.InvalidCursorError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("after", "invalid")
+	suite.Equal(expected, r)
+}
+
 func (suite *ListMessagesInteractorTestSuite) TestRepositoryAnyError() {
 	request := suite.getValidRequest()
+	options := repository.MessagePageOptions{
+		Limit: defaultMessagesLimit,
+	}
 
-	suite.messageRepository.On("AllWithUser", request.User.Email).Return(nil, assert.AnError)
+	suite.messageRepository.On("PageWithUser", request.User.Email, options).Return(nil, assert.AnError)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
@@ -55,12 +103,29 @@ func (suite *ListMessagesInteractorTestSuite) TestRepositoryAnyError() {
 
 func (suite *ListMessagesInteractorTestSuite) TestOK() {
 	request := suite.getValidRequest()
-	messages := []entity.Message{
-		{From: "a@b.com"},
-		{From: "b@b.com"},
+	request.Limit = 2
+	request.Before = "before"
+	request.With = "a@b.com"
+	options := repository.MessagePageOptions{
+		With:   "a@b.com",
+		Limit:  2,
+		Before: "before",
+	}
+	page := &repository.MessagePage{
+		Messages: []entity.Message{
+			{From: "a@b.com"},
+			{
+				From: "test@test.com",
+				Reactions: []entity.Reaction{
+					{Emoji: "👍", Count: 1, Users: []string{"a@b.com"}},
+				},
+			},
+		},
+		NextCursor: "next",
+		PrevCursor: "before",
 	}
 
-	suite.messageRepository.On("AllWithUser", request.User.Email).Return(messages, nil)
+	suite.messageRepository.On("PageWithUser", request.User.Email, options).Return(page, nil)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
@@ -69,8 +134,15 @@ func (suite *ListMessagesInteractorTestSuite) TestOK() {
 		Total: 2,
 		Items: []response.Message{
 			{From: "a@b.com"},
-			{From: "b@b.com"},
+			{
+				From: "test@test.com",
+				Reactions: []response.Reaction{
+					{Emoji: "👍", Count: 1, Users: []string{"a@b.com"}},
+				},
+			},
 		},
+		NextCursor: "next",
+		PrevCursor: "before",
 	}
 	suite.Equal(expected, r)
 }
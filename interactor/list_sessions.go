@@ -0,0 +1,59 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type ListSessionsInteractor interface {
+	Call(request.ListSessions) response.Response
+}
+
+// Lists the open sessions of the user, most recently used first
+type ListSessions struct {
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+}
+
+func NewListSessionsInteractor() *ListSessions {
+	return &ListSessions{}
+}
+
+func (i ListSessions) Call(request request.ListSessions) response.Response {
+	sessions, err := i.SessionRepository.AllOf(request.User.Id)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	res := response.ListSessions{
+		Items: []response.Session{},
+	}
+
+	for _, session := range sessions {
+		// Sessions opened before the user signed out everywhere can't be used anymore
+		if session.Secret != request.User.Secret {
+			continue
+		}
+
+		// Logins still waiting for the second factor aren't sessions yet
+		if session.Pending {
+			continue
+		}
+
+		res.Items = append(res.Items, response.Session{
+			Id:         session.Id,
+			Device:     session.Device,
+			Ip:         session.Ip,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.Id == request.Session.Id,
+		})
+	}
+	res.Total = len(res.Items)
+
+	return res
+}
@@ -0,0 +1,80 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type ListSessionsInteractorTestSuite struct {
+	suite.Suite
+	interactor        *ListSessions
+	sessionRepository *mocks.SessionRepository
+}
+
+func TestListSessionsInteractor(t *testing.T) {
+	suite.Run(t, new(ListSessionsInteractorTestSuite))
+}
+
+func (suite *ListSessionsInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewListSessionsInteractor()
+}
+
+func (suite *ListSessionsInteractorTestSuite) SetupTest() {
+	suite.sessionRepository = &mocks.SessionRepository{}
+	suite.interactor.SessionRepository = suite.sessionRepository
+}
+
+func (suite *ListSessionsInteractorTestSuite) TearDownTest() {
+	suite.sessionRepository.AssertExpectations(suite.T())
+}
+
+func (suite *ListSessionsInteractorTestSuite) getValidRequest() request.ListSessions {
+	return request.ListSessions{
+		User:    entity.User{Id: "userId", Email: "a@b.com", Secret: "secret"},
+		Session: entity.Session{Id: "current", UserId: "userId", Secret: "secret"},
+	}
+}
+
+func (suite *ListSessionsInteractorTestSuite) TestRepositoryAnyError() {
+	request := suite.getValidRequest()
+	suite.sessionRepository.On("AllOf", "userId").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ListSessionsInteractorTestSuite) TestEmpty() {
+	request := suite.getValidRequest()
+	suite.sessionRepository.On("AllOf", "userId").Return([]entity.Session{}, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.ListSessions{Total: 0, Items: []response.Session{}}, r)
+}
+
+func (suite *ListSessionsInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	now := time.Now()
+	sessions := []entity.Session{
+		{Id: "other", UserId: "userId", Secret: "secret", Device: "phone", Ip: "1.2.3.4", UserAgent: "ua", CreatedAt: now, LastUsedAt: now},
+		{Id: "stale", UserId: "userId", Secret: "oldSecret", CreatedAt: now, LastUsedAt: now},
+		{Id: "pending", UserId: "userId", Secret: "secret", CreatedAt: now, LastUsedAt: now, Pending: true},
+		{Id: "current", UserId: "userId", Secret: "secret", CreatedAt: now, LastUsedAt: now},
+	}
+	suite.sessionRepository.On("AllOf", "userId").Return(sessions, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.ListSessions{
+		Total: 2,
+		Items: []response.Session{
+			{Id: "other", Device: "phone", Ip: "1.2.3.4", UserAgent: "ua", CreatedAt: now, LastUsedAt: now},
+			{Id: "current", CreatedAt: now, LastUsedAt: now, Current: true},
+		},
+	}, r)
+}
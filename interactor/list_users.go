@@ -4,6 +4,7 @@ import (
 	"github.com/asiragusa/wschat/repository"
 	"github.com/asiragusa/wschat/request"
 	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
 	"github.com/kataras/iris"
 )
 
@@ -12,10 +13,13 @@ type ListUsersInteractor interface {
 	Call(request.ListUsers) response.Response
 }
 
-// Lists all the registered users
+// Lists all the registered users with their presence
 type ListUsers struct {
 	// Injected via DI
 	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	PresenceTracker services.PresenceTracker `inject:""`
 }
 
 func NewListUsersInteractor() *ListUsers {
@@ -34,10 +38,20 @@ func (i ListUsers) Call(request request.ListUsers) response.Response {
 		Items: []response.User{},
 	}
 
-	for _, user := range users {
-		res.Items = append(res.Items, response.User{
-			Email: user.Email,
-		})
+	presences, err := i.PresenceTracker.GetAll(users)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	for _, presence := range presences {
+		item := response.User{
+			Email:  presence.Email,
+			Status: presence.Status,
+		}
+		if !presence.LastSeenAt.IsZero() {
+			item.LastSeenAt = &presence.LastSeenAt
+		}
+		res.Items = append(res.Items, item)
 	}
 	return res
 }
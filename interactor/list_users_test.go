@@ -9,12 +9,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"testing"
+	"time"
 )
 
 type ListUsersInteractorTestSuite struct {
 	suite.Suite
-	interactor     *ListUsers
-	userRepository *mocks.UserRepository
+	interactor      *ListUsers
+	userRepository  *mocks.UserRepository
+	presenceTracker *mocks.PresenceTracker
 }
 
 func TestListUsersInteractor(t *testing.T) {
@@ -27,12 +29,15 @@ func (suite *ListUsersInteractorTestSuite) SetupSuite() {
 
 func (suite *ListUsersInteractorTestSuite) SetupTest() {
 	suite.userRepository = &mocks.UserRepository{}
+	suite.presenceTracker = &mocks.PresenceTracker{}
 
 	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.PresenceTracker = suite.presenceTracker
 }
 
 func (suite *ListUsersInteractorTestSuite) TearDownTest() {
 	suite.userRepository.AssertExpectations(suite.T())
+	suite.presenceTracker.AssertExpectations(suite.T())
 }
 
 func (suite *ListUsersInteractorTestSuite) getValidRequest() request.ListUsers {
@@ -49,14 +54,33 @@ func (suite *ListUsersInteractorTestSuite) TestRepositoryAnyError() {
 	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
 }
 
-func (suite *ListUsersInteractorTestSuite) TestOK() {
+func (suite *ListUsersInteractorTestSuite) TestPresenceAnError() {
 	request := suite.getValidRequest()
 	users := []entity.User{
 		{Email: "a@b.com"},
+	}
+
+	suite.userRepository.On("All").Return(users, nil)
+	suite.presenceTracker.On("GetAll", users).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ListUsersInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	lastSeenAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	users := []entity.User{
+		{Email: "a@b.com", LastSeenAt: lastSeenAt},
 		{Email: "b@b.com"},
 	}
 
 	suite.userRepository.On("All").Return(users, nil)
+	suite.presenceTracker.On("GetAll", users).Return([]entity.Presence{
+		{Email: "a@b.com", Status: entity.PresenceOnline, LastSeenAt: lastSeenAt},
+		{Email: "b@b.com", Status: entity.PresenceOffline},
+	}, nil)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
@@ -64,8 +88,8 @@ func (suite *ListUsersInteractorTestSuite) TestOK() {
 	expected := response.ListUsers{
 		Total: 2,
 		Items: []response.User{
-			{Email: "a@b.com"},
-			{Email: "b@b.com"},
+			{Email: "a@b.com", Status: entity.PresenceOnline, LastSeenAt: &lastSeenAt},
+			{Email: "b@b.com", Status: entity.PresenceOffline},
 		},
 	}
 	suite.Equal(expected, r)
@@ -6,7 +6,6 @@ import (
 	"github.com/asiragusa/wschat/response"
 	"github.com/asiragusa/wschat/services"
 	"github.com/kataras/iris"
-	"time"
 )
 
 // Interface used mainly for Unit testing
@@ -14,13 +13,26 @@ type LoginInteractor interface {
 	Call(request.Login) response.Response
 }
 
-// Logs in an user. Returns the access token for authenticating the following requests
+// Logs in an user. Returns the access token for authenticating the following requests and the refresh token.
+// If the user has enabled the two factor authentication, returns instead the mfa token, exchanged at LoginMfa
 type Login struct {
 	// Injected via DI
 	UserRepository repository.UserRepository `inject:""`
 
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+
 	// Injected via DI
 	AccessTokenGenerator services.TokenGenerator `inject:"accessTokenGenerator"`
+
+	// Injected via DI
+	MfaTokenGenerator services.TokenGenerator `inject:"mfaTokenGenerator"`
+
+	// Injected via DI
+	RefreshTokenIssuer services.RefreshTokenIssuer `inject:""`
+
+	// Injected via DI
+	LoginThrottler services.LoginThrottler `inject:""`
 }
 
 func NewLoginInteractor() *Login {
@@ -28,9 +40,21 @@ func NewLoginInteractor() *Login {
 }
 
 func (i Login) Call(request request.Login) response.Response {
+	// Refuse the attempt while the email or the client IP is locked out by the previous failures
+	wait, err := i.LoginThrottler.Check(request.Email, request.Ip)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	if wait > 0 {
+		return response.NewTooManyRequestsError(wait)
+	}
+
 	// Find the user in the DB
 	user, err := i.UserRepository.Login(request.Email, request.Password)
 	if err == repository.UserBadUsernameOrPasswordError {
+		if err := i.LoginThrottler.Fail(request.Email, request.Ip); err != nil {
+			return response.NewError(iris.StatusInternalServerError)
+		}
 		return response.NewError(iris.StatusUnauthorized)
 	}
 
@@ -38,13 +62,46 @@ func (i Login) Call(request request.Login) response.Response {
 		return response.NewError(iris.StatusInternalServerError)
 	}
 
+	// Open a new session on the client's device. It stays pending until the second factor is verified
+	pending := user.TotpEnabled()
+	session, err := i.SessionRepository.Create(user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, pending)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if pending {
+		mfaToken, err := i.MfaTokenGenerator.GenerateToken(*session, services.MfaTokenDuration)
+		if err != nil {
+			return response.NewError(iris.StatusInternalServerError)
+		}
+
+		return response.MfaRequired{
+			MfaRequired: true,
+			MfaToken:    mfaToken,
+			ExpiresIn:   int(services.MfaTokenDuration.Seconds()),
+		}
+	}
+
+	// The failures are forgotten only once the login is complete, including the second factor
+	if err := i.LoginThrottler.Succeed(user.Email); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
 	// Generate the access token
-	token, err := i.AccessTokenGenerator.GenerateToken(*user, time.Hour*24*30)
+	token, err := i.AccessTokenGenerator.GenerateToken(*session, services.AccessTokenDuration)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Issue the refresh token, used to get the following access tokens
+	refreshToken, err := i.RefreshTokenIssuer.Issue(*session)
 	if err != nil {
 		return response.NewError(iris.StatusInternalServerError)
 	}
 
 	return response.Login{
-		AccessToken: token,
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(services.AccessTokenDuration.Seconds()),
 	}
 }
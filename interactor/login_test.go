@@ -6,6 +6,7 @@ import (
 	"github.com/asiragusa/wschat/repository"
 	"github.com/asiragusa/wschat/request"
 	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
 	"github.com/kataras/iris/httptest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -18,7 +19,12 @@ type LoginInteractorTestSuite struct {
 	interactor     *Login
 	userRepository *mocks.UserRepository
 
+	sessionRepository *mocks.SessionRepository
+
 	accessTokenGenerator *mocks.TokenGenerator
+	mfaTokenGenerator    *mocks.TokenGenerator
+	refreshTokenIssuer   *mocks.RefreshTokenIssuer
+	loginThrottler       *mocks.LoginThrottler
 }
 
 func TestLoginInteractor(t *testing.T) {
@@ -31,29 +37,46 @@ func (suite *LoginInteractorTestSuite) SetupSuite() {
 
 func (suite *LoginInteractorTestSuite) SetupTest() {
 	suite.userRepository = &mocks.UserRepository{}
+	suite.sessionRepository = &mocks.SessionRepository{}
 
 	suite.accessTokenGenerator = &mocks.TokenGenerator{}
+	suite.mfaTokenGenerator = &mocks.TokenGenerator{}
+	suite.refreshTokenIssuer = &mocks.RefreshTokenIssuer{}
+	suite.loginThrottler = &mocks.LoginThrottler{}
 
 	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.SessionRepository = suite.sessionRepository
 	suite.interactor.AccessTokenGenerator = suite.accessTokenGenerator
+	suite.interactor.MfaTokenGenerator = suite.mfaTokenGenerator
+	suite.interactor.RefreshTokenIssuer = suite.refreshTokenIssuer
+	suite.interactor.LoginThrottler = suite.loginThrottler
 }
 
 func (suite *LoginInteractorTestSuite) TearDownTest() {
 	suite.userRepository.AssertExpectations(suite.T())
+	suite.sessionRepository.AssertExpectations(suite.T())
 	suite.accessTokenGenerator.AssertExpectations(suite.T())
+	suite.mfaTokenGenerator.AssertExpectations(suite.T())
+	suite.refreshTokenIssuer.AssertExpectations(suite.T())
+	suite.loginThrottler.AssertExpectations(suite.T())
 }
 
 func (suite *LoginInteractorTestSuite) getValidRequest() request.Login {
 	return request.Login{
-		Email:    "a@b.com",
-		Password: "validPassword",
+		Email:     "a@b.com",
+		Password:  "validPassword",
+		Device:    "laptop",
+		Ip:        "127.0.0.1",
+		UserAgent: "agent",
 	}
 }
 
 func (suite *LoginInteractorTestSuite) TestRepositoryBadUsernameOrPassword() {
 	request := suite.getValidRequest()
 
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), nil)
 	suite.userRepository.On("Login", request.Email, request.Password).Return(nil, repository.UserBadUsernameOrPasswordError)
+	suite.loginThrottler.On("Fail", request.Email, request.Ip).Return(nil)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
@@ -63,6 +86,7 @@ func (suite *LoginInteractorTestSuite) TestRepositoryBadUsernameOrPassword() {
 func (suite *LoginInteractorTestSuite) TestRepositoryAnyError() {
 	request := suite.getValidRequest()
 
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), nil)
 	suite.userRepository.On("Login", request.Email, request.Password).Return(nil, assert.AnError)
 
 	r := suite.interactor.Call(request)
@@ -72,10 +96,14 @@ func (suite *LoginInteractorTestSuite) TestRepositoryAnyError() {
 
 func (suite *LoginInteractorTestSuite) TestAccessTokenGeneratorAnyError() {
 	request := suite.getValidRequest()
-	user := entity.User{Email: request.Email}
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret"}
+	session := suite.session()
 
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), nil)
 	suite.userRepository.On("Login", request.Email, request.Password).Return(&user, nil)
-	suite.accessTokenGenerator.On("GenerateToken", user, time.Hour*24*30).Return("", assert.AnError)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false).Return(&session, nil)
+	suite.loginThrottler.On("Succeed", request.Email).Return(nil)
+	suite.accessTokenGenerator.On("GenerateToken", session, services.AccessTokenDuration).Return("", assert.AnError)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
@@ -84,16 +112,136 @@ func (suite *LoginInteractorTestSuite) TestAccessTokenGeneratorAnyError() {
 
 func (suite *LoginInteractorTestSuite) TestOK() {
 	request := suite.getValidRequest()
-	user := entity.User{Email: request.Email}
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret"}
+	session := suite.session()
 
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), nil)
 	suite.userRepository.On("Login", request.Email, request.Password).Return(&user, nil)
-	suite.accessTokenGenerator.On("GenerateToken", user, time.Hour*24*30).Return("access", nil)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false).Return(&session, nil)
+	suite.loginThrottler.On("Succeed", request.Email).Return(nil)
+	suite.accessTokenGenerator.On("GenerateToken", session, services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", session).Return("refresh", nil)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
 
 	expected := response.Login{
-		AccessToken: "access",
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    900,
+	}
+	suite.Equal(expected, r)
+}
+
+func (suite *LoginInteractorTestSuite) TestRefreshTokenIssuerAnyError() {
+	request := suite.getValidRequest()
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret"}
+	session := suite.session()
+
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", request.Email, request.Password).Return(&user, nil)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false).Return(&session, nil)
+	suite.loginThrottler.On("Succeed", request.Email).Return(nil)
+	suite.accessTokenGenerator.On("GenerateToken", session, services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", session).Return("", assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+// Returns the session opened by the request
+func (suite *LoginInteractorTestSuite) session() entity.Session {
+	return entity.Session{
+		Id:        "sessionId",
+		UserId:    "userId",
+		Secret:    "secret",
+		Device:    "laptop",
+		Ip:        "127.0.0.1",
+		UserAgent: "agent",
+	}
+}
+
+func (suite *LoginInteractorTestSuite) TestSessionRepositoryAnyError() {
+	request := suite.getValidRequest()
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret"}
+
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", request.Email, request.Password).Return(&user, nil)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LoginInteractorTestSuite) TestMfaRequired() {
+	request := suite.getValidRequest()
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret", TotpSecret: "totpSecret"}
+	session := suite.session()
+	session.Pending = true
+
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", request.Email, request.Password).Return(&user, nil)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, true).Return(&session, nil)
+	suite.mfaTokenGenerator.On("GenerateToken", session, services.MfaTokenDuration).Return("mfa", nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.MfaRequired{
+		MfaRequired: true,
+		MfaToken:    "mfa",
+		ExpiresIn:   300,
 	}
 	suite.Equal(expected, r)
 }
+
+func (suite *LoginInteractorTestSuite) TestMfaTokenGeneratorAnyError() {
+	request := suite.getValidRequest()
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret", TotpSecret: "totpSecret"}
+	session := suite.session()
+	session.Pending = true
+
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", request.Email, request.Password).Return(&user, nil)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, true).Return(&session, nil)
+	suite.mfaTokenGenerator.On("GenerateToken", session, services.MfaTokenDuration).Return("", assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LoginInteractorTestSuite) TestThrottled() {
+	request := suite.getValidRequest()
+
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(1500*time.Millisecond, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewTooManyRequestsError(1500*time.Millisecond), r)
+	suite.Equal(2, r.(*response.Error).RetryAfter)
+}
+
+func (suite *LoginInteractorTestSuite) TestThrottlerAnyError() {
+	request := suite.getValidRequest()
+
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LoginInteractorTestSuite) TestThrottlerFailAnyError() {
+	request := suite.getValidRequest()
+
+	suite.loginThrottler.On("Check", request.Email, request.Ip).Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", request.Email, request.Password).Return(nil, repository.UserBadUsernameOrPasswordError)
+	suite.loginThrottler.On("Fail", request.Email, request.Ip).Return(assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
@@ -0,0 +1,80 @@
+package interactor
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Name of the event closing the websockets of an user signed out everywhere
+const SignedOutEvent = "signedOut"
+
+// Interface used mainly for Unit testing
+type LogoutInteractor interface {
+	Call(request.Logout) response.Response
+}
+
+// Closes the current session: its access and refresh tokens are revoked
+type Logout struct {
+	// Injected via DI
+	SessionRevoker services.SessionRevoker `inject:""`
+}
+
+func NewLogoutInteractor() *Logout {
+	return &Logout{}
+}
+
+func (i Logout) Call(request request.Logout) response.Response {
+	if err := i.SessionRevoker.Revoke(request.User, request.Session.Id); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.NoContentResponse{}
+}
+
+// Interface used mainly for Unit testing
+type LogoutAllInteractor interface {
+	Call(request.LogoutAll) response.Response
+}
+
+// Signs the user out everywhere by rotating its secret, which invalidates all the tokens issued to the user.
+// The websockets of the user are closed
+type LogoutAll struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+}
+
+func NewLogoutAllInteractor() *LogoutAll {
+	return &LogoutAll{}
+}
+
+func (i LogoutAll) Call(request request.LogoutAll) response.Response {
+	if _, err := i.UserRepository.RotateSecret(request.User.Id); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	publishSignedOut(i.PubsubClient, request.User.Email)
+
+	return response.NoContentResponse{}
+}
+
+// Closes the websockets of the user, on every instance, after its secret has been rotated
+func publishSignedOut(client services.PubsubClient, email string) {
+	event, err := entity.NewEvent(SignedOutEvent, []string{email}, response.SignedOut{
+		Email: email,
+	})
+	if err == nil {
+		err = client.PublishEvent(event)
+	}
+	if err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
+	}
+}
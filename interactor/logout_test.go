@@ -0,0 +1,129 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type LogoutInteractorTestSuite struct {
+	suite.Suite
+	interactor *Logout
+	revoker    *mocks.SessionRevoker
+}
+
+func TestLogoutInteractor(t *testing.T) {
+	suite.Run(t, new(LogoutInteractorTestSuite))
+}
+
+func (suite *LogoutInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewLogoutInteractor()
+}
+
+func (suite *LogoutInteractorTestSuite) SetupTest() {
+	suite.revoker = &mocks.SessionRevoker{}
+	suite.interactor.SessionRevoker = suite.revoker
+}
+
+func (suite *LogoutInteractorTestSuite) TearDownTest() {
+	suite.revoker.AssertExpectations(suite.T())
+}
+
+func (suite *LogoutInteractorTestSuite) getValidRequest() request.Logout {
+	return request.Logout{
+		User:    entity.User{Id: "userId", Email: "a@b.com"},
+		Session: entity.Session{Id: "sessionId", UserId: "userId"},
+	}
+}
+
+func (suite *LogoutInteractorTestSuite) TestAnError() {
+	request := suite.getValidRequest()
+	suite.revoker.On("Revoke", request.User, "sessionId").Return(assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LogoutInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.revoker.On("Revoke", request.User, "sessionId").Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NoContentResponse{}, r)
+}
+
+type LogoutAllInteractorTestSuite struct {
+	suite.Suite
+	interactor     *LogoutAll
+	userRepository *mocks.UserRepository
+	pubsub         *mocks.PubsubClient
+}
+
+func TestLogoutAllInteractor(t *testing.T) {
+	suite.Run(t, new(LogoutAllInteractorTestSuite))
+}
+
+func (suite *LogoutAllInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewLogoutAllInteractor()
+}
+
+func (suite *LogoutAllInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.pubsub = &mocks.PubsubClient{}
+
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.PubsubClient = suite.pubsub
+}
+
+func (suite *LogoutAllInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.pubsub.AssertExpectations(suite.T())
+}
+
+func (suite *LogoutAllInteractorTestSuite) getValidRequest() request.LogoutAll {
+	return request.LogoutAll{
+		User: entity.User{Id: "userId", Email: "a@b.com"},
+	}
+}
+
+func (suite *LogoutAllInteractorTestSuite) TestRotateAnError() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("RotateSecret", "userId").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LogoutAllInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("RotateSecret", "userId").Return(&entity.User{Id: "userId", Secret: "new"}, nil)
+
+	var published entity.Event
+	suite.pubsub.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		published = event
+		return true
+	})).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NoContentResponse{}, r)
+
+	suite.Equal(SignedOutEvent, published.Type)
+	suite.Equal([]string{"a@b.com"}, published.To)
+	suite.JSONEq(`{"email": "a@b.com"}`, string(published.Data))
+}
+
+func (suite *LogoutAllInteractorTestSuite) TestPublishAnError() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("RotateSecret", "userId").Return(&entity.User{Id: "userId", Secret: "new"}, nil)
+	suite.pubsub.On("PublishEvent", mock.Anything).Return(assert.AnError)
+
+	// The secret is rotated anyway, the error is only logged
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NoContentResponse{}, r)
+}
@@ -0,0 +1,149 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type OidcAuthorizeInteractor interface {
+	Call(request.OidcAuthorize) response.Response
+}
+
+// Starts the OpenID Connect login. Returns the URL of the provider the user is redirected to, and the flow token
+// the client sends back to OidcLogin
+type OidcAuthorize struct {
+	// Injected via DI
+	OidcProvider services.OidcProvider `inject:""`
+}
+
+func NewOidcAuthorizeInteractor() *OidcAuthorize {
+	return &OidcAuthorize{}
+}
+
+func (i OidcAuthorize) Call(request request.OidcAuthorize) response.Response {
+	authorizationUrl, flowToken, err := i.OidcProvider.Authorize()
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.OidcAuthorize{
+		AuthorizationUrl: authorizationUrl,
+		FlowToken:        flowToken,
+		ExpiresIn:        int(services.OidcFlowDuration.Seconds()),
+	}
+}
+
+// Interface used mainly for Unit testing
+type OidcLoginInteractor interface {
+	Call(request.OidcLogin) response.Response
+}
+
+// Completes the OpenID Connect login. The user is found by the email verified by the provider, and created without
+// password if it doesn't exist yet. An existing user must have verified its email too. Then, like Login, returns the
+// access token and the refresh token, or the mfa token if the user has enabled the two factor authentication
+type OidcLogin struct {
+	// Injected via DI
+	OidcProvider services.OidcProvider `inject:""`
+
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+
+	// Injected via DI
+	AccessTokenGenerator services.TokenGenerator `inject:"accessTokenGenerator"`
+
+	// Injected via DI
+	MfaTokenGenerator services.TokenGenerator `inject:"mfaTokenGenerator"`
+
+	// Injected via DI
+	RefreshTokenIssuer services.RefreshTokenIssuer `inject:""`
+}
+
+func NewOidcLoginInteractor() *OidcLogin {
+	return &OidcLogin{}
+}
+
+func (i OidcLogin) Call(request request.OidcLogin) response.Response {
+	identity, err := i.OidcProvider.Exchange(request.Code, request.State, request.FlowToken)
+	switch err {
+	case nil:
+	case services.InvalidOidcFlowError, services.InvalidOidcCodeError, services.InvalidIdTokenError:
+		return response.NewError(iris.StatusUnauthorized)
+	default:
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// An unverified email could belong to somebody else
+	if !identity.EmailVerified {
+		e := response.NewError(iris.StatusForbidden)
+		e.AddDetail("email", "notVerified")
+		return e
+	}
+
+	// Link the identity to the user with the same email, or create it
+	user, err := i.UserRepository.GetUserByEmail(identity.Email)
+	if err == repository.UserNotFoundError {
+		user, err = i.UserRepository.CreateExternalUser(identity.Email)
+	}
+	if err == repository.UserAlreadyExistsError {
+		// The user has been created by a concurrent request
+		return response.NewError(iris.StatusConflict)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Anybody can register an email without owning it, the identity is linked only once the owner of the existing
+	// user has proven it by following its verification link
+	if !user.EmailVerified {
+		e := response.NewError(iris.StatusConflict)
+		e.AddDetail("email", "accountNotVerified")
+		return e
+	}
+
+	// Open a new session on the client's device. It stays pending until the second factor is verified
+	pending := user.TotpEnabled()
+	session, err := i.SessionRepository.Create(user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, pending)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if pending {
+		mfaToken, err := i.MfaTokenGenerator.GenerateToken(*session, services.MfaTokenDuration)
+		if err != nil {
+			return response.NewError(iris.StatusInternalServerError)
+		}
+
+		return response.MfaRequired{
+			MfaRequired: true,
+			MfaToken:    mfaToken,
+			ExpiresIn:   int(services.MfaTokenDuration.Seconds()),
+			Email:       user.Email,
+		}
+	}
+
+	// Generate the access token
+	token, err := i.AccessTokenGenerator.GenerateToken(*session, services.AccessTokenDuration)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Issue the refresh token, used to get the following access tokens
+	refreshToken, err := i.RefreshTokenIssuer.Issue(*session)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.Login{
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(services.AccessTokenDuration.Seconds()),
+		Email:        user.Email,
+	}
+}
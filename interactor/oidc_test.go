@@ -0,0 +1,291 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type OidcAuthorizeInteractorTestSuite struct {
+	suite.Suite
+	interactor   *OidcAuthorize
+	oidcProvider *mocks.OidcProvider
+}
+
+func TestOidcAuthorizeInteractor(t *testing.T) {
+	suite.Run(t, new(OidcAuthorizeInteractorTestSuite))
+}
+
+func (suite *OidcAuthorizeInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewOidcAuthorizeInteractor()
+}
+
+func (suite *OidcAuthorizeInteractorTestSuite) SetupTest() {
+	suite.oidcProvider = &mocks.OidcProvider{}
+	suite.interactor.OidcProvider = suite.oidcProvider
+}
+
+func (suite *OidcAuthorizeInteractorTestSuite) TearDownTest() {
+	suite.oidcProvider.AssertExpectations(suite.T())
+}
+
+func (suite *OidcAuthorizeInteractorTestSuite) TestOK() {
+	suite.oidcProvider.On("Authorize").Return("http://provider/authorize", "flowToken", nil)
+
+	r := suite.interactor.Call(request.OidcAuthorize{})
+	suite.Equal(response.OidcAuthorize{
+		AuthorizationUrl: "http://provider/authorize",
+		FlowToken:        "flowToken",
+		ExpiresIn:        600,
+	}, r)
+}
+
+func (suite *OidcAuthorizeInteractorTestSuite) TestProviderAnyError() {
+	suite.oidcProvider.On("Authorize").Return("", "", assert.AnError)
+
+	r := suite.interactor.Call(request.OidcAuthorize{})
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+type OidcLoginInteractorTestSuite struct {
+	suite.Suite
+	interactor *OidcLogin
+
+	oidcProvider         *mocks.OidcProvider
+	userRepository       *mocks.UserRepository
+	sessionRepository    *mocks.SessionRepository
+	accessTokenGenerator *mocks.TokenGenerator
+	mfaTokenGenerator    *mocks.TokenGenerator
+	refreshTokenIssuer   *mocks.RefreshTokenIssuer
+
+	identity *entity.Identity
+	user     *entity.User
+	session  *entity.Session
+}
+
+func TestOidcLoginInteractor(t *testing.T) {
+	suite.Run(t, new(OidcLoginInteractorTestSuite))
+}
+
+func (suite *OidcLoginInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewOidcLoginInteractor()
+}
+
+func (suite *OidcLoginInteractorTestSuite) SetupTest() {
+	suite.oidcProvider = &mocks.OidcProvider{}
+	suite.userRepository = &mocks.UserRepository{}
+	suite.sessionRepository = &mocks.SessionRepository{}
+	suite.accessTokenGenerator = &mocks.TokenGenerator{}
+	suite.mfaTokenGenerator = &mocks.TokenGenerator{}
+	suite.refreshTokenIssuer = &mocks.RefreshTokenIssuer{}
+
+	suite.interactor.OidcProvider = suite.oidcProvider
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.SessionRepository = suite.sessionRepository
+	suite.interactor.AccessTokenGenerator = suite.accessTokenGenerator
+	suite.interactor.MfaTokenGenerator = suite.mfaTokenGenerator
+	suite.interactor.RefreshTokenIssuer = suite.refreshTokenIssuer
+
+	suite.identity = &entity.Identity{
+		Issuer:        "http://provider",
+		Subject:       "subject",
+		Email:         "a@b.com",
+		EmailVerified: true,
+	}
+	suite.user = &entity.User{Id: "userId", Email: "a@b.com", Secret: "secret", EmailVerified: true}
+	suite.session = &entity.Session{
+		Id:        "sessionId",
+		UserId:    "userId",
+		Secret:    "secret",
+		Device:    "laptop",
+		Ip:        "127.0.0.1",
+		UserAgent: "agent",
+	}
+}
+
+func (suite *OidcLoginInteractorTestSuite) TearDownTest() {
+	suite.oidcProvider.AssertExpectations(suite.T())
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.sessionRepository.AssertExpectations(suite.T())
+	suite.accessTokenGenerator.AssertExpectations(suite.T())
+	suite.mfaTokenGenerator.AssertExpectations(suite.T())
+	suite.refreshTokenIssuer.AssertExpectations(suite.T())
+}
+
+func (suite *OidcLoginInteractorTestSuite) getValidRequest() request.OidcLogin {
+	return request.OidcLogin{
+		Code:      "code",
+		State:     "state",
+		FlowToken: "flowToken",
+		Device:    "laptop",
+		Ip:        "127.0.0.1",
+		UserAgent: "agent",
+	}
+}
+
+// Mocks the exchange of the code, returning the identity of the suite
+func (suite *OidcLoginInteractorTestSuite) exchange() {
+	suite.oidcProvider.On("Exchange", "code", "state", "flowToken").Return(suite.identity, nil)
+}
+
+// Mocks the opening of the session and the generation of the tokens
+func (suite *OidcLoginInteractorTestSuite) login() {
+	suite.sessionRepository.On("Create", "userId", "secret", "laptop", "127.0.0.1", "agent", false).Return(suite.session, nil)
+	suite.accessTokenGenerator.On("GenerateToken", *suite.session, services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", *suite.session).Return("refresh", nil)
+}
+
+func (suite *OidcLoginInteractorTestSuite) expectedLogin() response.Login {
+	return response.Login{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    900,
+		Email:        "a@b.com",
+	}
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestInvalidExchange() {
+	for _, err := range []error{
+		services.InvalidOidcFlowError,
+		services.InvalidOidcCodeError,
+		services.InvalidIdTokenError,
+	} {
+		suite.SetupTest()
+		suite.oidcProvider.On("Exchange", "code", "state", "flowToken").Return(nil, err)
+
+		r := suite.interactor.Call(suite.getValidRequest())
+		suite.Equal(response.NewError(httptest.StatusUnauthorized), r)
+	}
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestProviderAnyError() {
+	suite.oidcProvider.On("Exchange", "code", "state", "flowToken").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestEmailNotVerified() {
+	suite.identity.EmailVerified = false
+	suite.exchange()
+
+	expected := response.NewError(httptest.StatusForbidden)
+	expected.AddDetail("email", "notVerified")
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(expected, r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestExistingUser() {
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(suite.user, nil)
+	suite.login()
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(suite.expectedLogin(), r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestExistingUserNotVerified() {
+	// An unverified local user registered with the same email, possibly by somebody else
+	suite.user.EmailVerified = false
+
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(suite.user, nil)
+
+	expected := response.NewError(httptest.StatusConflict)
+	expected.AddDetail("email", "accountNotVerified")
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(expected, r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestNewUser() {
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(nil, repository.UserNotFoundError)
+	suite.userRepository.On("CreateExternalUser", "a@b.com").Return(suite.user, nil)
+	suite.login()
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(suite.expectedLogin(), r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestNewUserConcurrentlyCreated() {
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(nil, repository.UserNotFoundError)
+	suite.userRepository.On("CreateExternalUser", "a@b.com").Return(nil, repository.UserAlreadyExistsError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusConflict), r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestCreateExternalUserAnyError() {
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(nil, repository.UserNotFoundError)
+	suite.userRepository.On("CreateExternalUser", "a@b.com").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestGetUserByEmailAnyError() {
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestMfaRequired() {
+	suite.user.TotpSecret = "totpSecret"
+	suite.session.Pending = true
+
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(suite.user, nil)
+	suite.sessionRepository.On("Create", "userId", "secret", "laptop", "127.0.0.1", "agent", true).Return(suite.session, nil)
+	suite.mfaTokenGenerator.On("GenerateToken", *suite.session, services.MfaTokenDuration).Return("mfa", nil)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.MfaRequired{
+		MfaRequired: true,
+		MfaToken:    "mfa",
+		ExpiresIn:   300,
+		Email:       "a@b.com",
+	}, r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestSessionRepositoryAnyError() {
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(suite.user, nil)
+	suite.sessionRepository.On("Create", "userId", "secret", "laptop", "127.0.0.1", "agent", false).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestAccessTokenGeneratorAnyError() {
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(suite.user, nil)
+	suite.sessionRepository.On("Create", "userId", "secret", "laptop", "127.0.0.1", "agent", false).Return(suite.session, nil)
+	suite.accessTokenGenerator.On("GenerateToken", *suite.session, services.AccessTokenDuration).Return("", assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *OidcLoginInteractorTestSuite) TestRefreshTokenIssuerAnyError() {
+	suite.exchange()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(suite.user, nil)
+	suite.sessionRepository.On("Create", "userId", "secret", "laptop", "127.0.0.1", "agent", false).Return(suite.session, nil)
+	suite.accessTokenGenerator.On("GenerateToken", *suite.session, services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", *suite.session).Return("", assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
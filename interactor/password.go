@@ -0,0 +1,226 @@
+package interactor
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/mail"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Subject of the password reset emails
+const passwordResetSubject = "Reset your password"
+
+// Interface used mainly for Unit testing
+type ChangePasswordInteractor interface {
+	Call(request.ChangePassword) response.Response
+}
+
+// Changes the password of the authenticated user, given its current password. The secret of the user is rotated,
+// signing it out everywhere, and the current session is replaced by a new one whose tokens are returned
+type ChangePassword struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+
+	// Injected via DI
+	AccessTokenGenerator services.TokenGenerator `inject:"accessTokenGenerator"`
+
+	// Injected via DI
+	RefreshTokenIssuer services.RefreshTokenIssuer `inject:""`
+
+	// Injected via DI
+	LoginThrottler services.LoginThrottler `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+}
+
+func NewChangePasswordInteractor() *ChangePassword {
+	return &ChangePassword{}
+}
+
+func (i ChangePassword) Call(request request.ChangePassword) response.Response {
+	// The current password is throttled like the logins, a stolen access token mustn't allow to guess it
+	wait, err := i.LoginThrottler.Check(request.User.Email, request.Ip)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	if wait > 0 {
+		return response.NewTooManyRequestsError(wait)
+	}
+
+	// Check the current password
+	_, err = i.UserRepository.Login(request.User.Email, request.CurrentPassword)
+	if err == repository.UserBadUsernameOrPasswordError {
+		if err := i.LoginThrottler.Fail(request.User.Email, request.Ip); err != nil {
+			return response.NewError(iris.StatusInternalServerError)
+		}
+		return response.NewError(iris.StatusForbidden)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if err := i.LoginThrottler.Succeed(request.User.Email); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if _, err := i.UserRepository.UpdatePassword(request.User.Id, request.NewPassword); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Whoever knew the old password may have opened sessions: all of them are revoked
+	user, err := i.UserRepository.RotateSecret(request.User.Id)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	publishSignedOut(i.PubsubClient, user.Email)
+
+	// The client stays signed in on its device with a new session
+	session, err := i.SessionRepository.Create(user.Id, user.Secret, request.Session.Device, request.Ip,
+		request.UserAgent, false)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	if err := i.SessionRepository.Delete(request.Session.Id); err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
+	}
+
+	token, err := i.AccessTokenGenerator.GenerateToken(*session, services.AccessTokenDuration)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	refreshToken, err := i.RefreshTokenIssuer.Issue(*session)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.Login{
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(services.AccessTokenDuration.Seconds()),
+	}
+}
+
+// Interface used mainly for Unit testing
+type ForgotPasswordInteractor interface {
+	Call(request.ForgotPassword) response.Response
+}
+
+// Sends a password reset token to the user by email.
+// The response is the same whether the user exists or not, so that the registered emails can't be discovered
+type ForgotPassword struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	PasswordResetIssuer services.PasswordResetIssuer `inject:""`
+
+	// Injected via DI
+	MailSender mail.Sender `inject:""`
+
+	// Injected via DI
+	PasswordResetThrottler services.LoginThrottler `inject:"passwordResetThrottler"`
+}
+
+func NewForgotPasswordInteractor() *ForgotPassword {
+	return &ForgotPassword{}
+}
+
+func (i ForgotPassword) Call(request request.ForgotPassword) response.Response {
+	// Every request counts against the email and the client IP, so that they can't be used to flood the mailboxes
+	wait, err := i.PasswordResetThrottler.Check(request.Email, request.Ip)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	if wait > 0 {
+		return response.NewTooManyRequestsError(wait)
+	}
+	if err := i.PasswordResetThrottler.Fail(request.Email, request.Ip); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	user, err := i.UserRepository.GetUserByEmail(request.Email)
+	if err == repository.UserNotFoundError {
+		return response.NoContentResponse{}
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	token, err := i.PasswordResetIssuer.Issue(*user)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	err = i.MailSender.Send(mail.Message{
+		To:      user.Email,
+		Subject: passwordResetSubject,
+		Body:    passwordResetBody(token),
+	})
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.NoContentResponse{}
+}
+
+// Returns the body of the password reset email
+func passwordResetBody(token string) string {
+	return fmt.Sprintf(
+		"Use the following token to reset your password. It can be used only once, within %d minutes.\r\n\r\n"+
+			"%s\r\n\r\n"+
+			"If you didn't ask to reset your password, you can ignore this email.\r\n",
+		int(services.PasswordResetDuration.Minutes()), token,
+	)
+}
+
+// Interface used mainly for Unit testing
+type ResetPasswordInteractor interface {
+	Call(request.ResetPassword) response.Response
+}
+
+// Sets a new password with a token sent by ForgotPassword. The secret of the user is rotated, signing it out everywhere
+type ResetPassword struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	PasswordResetIssuer services.PasswordResetIssuer `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+}
+
+func NewResetPasswordInteractor() *ResetPassword {
+	return &ResetPassword{}
+}
+
+func (i ResetPassword) Call(request request.ResetPassword) response.Response {
+	user, err := i.PasswordResetIssuer.Consume(request.Token)
+	if err == services.InvalidPasswordResetTokenError {
+		return response.NewError(iris.StatusUnauthorized)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if _, err := i.UserRepository.UpdatePassword(user.Id, request.Password); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Whoever knew the old password may have opened sessions: all of them are revoked
+	if _, err := i.UserRepository.RotateSecret(user.Id); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	publishSignedOut(i.PubsubClient, user.Email)
+
+	return response.NoContentResponse{}
+}
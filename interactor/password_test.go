@@ -0,0 +1,437 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mail"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type ChangePasswordInteractorTestSuite struct {
+	suite.Suite
+	interactor           *ChangePassword
+	userRepository       *mocks.UserRepository
+	sessionRepository    *mocks.SessionRepository
+	accessTokenGenerator *mocks.TokenGenerator
+	refreshTokenIssuer   *mocks.RefreshTokenIssuer
+	throttler            *mocks.LoginThrottler
+	pubsub               *mocks.PubsubClient
+	rotated              *entity.User
+	session              *entity.Session
+}
+
+func TestChangePasswordInteractor(t *testing.T) {
+	suite.Run(t, new(ChangePasswordInteractorTestSuite))
+}
+
+func (suite *ChangePasswordInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewChangePasswordInteractor()
+	suite.rotated = &entity.User{Id: "userId", Email: "a@b.com", Secret: "rotated"}
+	suite.session = &entity.Session{Id: "newSessionId", UserId: "userId", Secret: "rotated", Device: "phone"}
+}
+
+func (suite *ChangePasswordInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.sessionRepository = &mocks.SessionRepository{}
+	suite.accessTokenGenerator = &mocks.TokenGenerator{}
+	suite.refreshTokenIssuer = &mocks.RefreshTokenIssuer{}
+	suite.throttler = &mocks.LoginThrottler{}
+	suite.pubsub = &mocks.PubsubClient{}
+
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.SessionRepository = suite.sessionRepository
+	suite.interactor.AccessTokenGenerator = suite.accessTokenGenerator
+	suite.interactor.RefreshTokenIssuer = suite.refreshTokenIssuer
+	suite.interactor.LoginThrottler = suite.throttler
+	suite.interactor.PubsubClient = suite.pubsub
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.sessionRepository.AssertExpectations(suite.T())
+	suite.accessTokenGenerator.AssertExpectations(suite.T())
+	suite.refreshTokenIssuer.AssertExpectations(suite.T())
+	suite.throttler.AssertExpectations(suite.T())
+	suite.pubsub.AssertExpectations(suite.T())
+}
+
+func (suite *ChangePasswordInteractorTestSuite) getValidRequest() request.ChangePassword {
+	return request.ChangePassword{
+		User:            entity.User{Id: "userId", Email: "a@b.com", Secret: "secret"},
+		Session:         entity.Session{Id: "sessionId", UserId: "userId", Secret: "secret", Device: "phone"},
+		CurrentPassword: "password",
+		NewPassword:     "newPassword",
+		Ip:              "127.0.0.1",
+		UserAgent:       "agent",
+	}
+}
+
+// Expects the password to be checked and changed, and the secret of the user to be rotated
+func (suite *ChangePasswordInteractorTestSuite) expectChanged(request request.ChangePassword) {
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", "a@b.com", "password").Return(&request.User, nil)
+	suite.throttler.On("Succeed", "a@b.com").Return(nil)
+	suite.userRepository.On("UpdatePassword", "userId", "newPassword").Return(&request.User, nil)
+	suite.userRepository.On("RotateSecret", "userId").Return(suite.rotated, nil)
+	suite.pubsub.On("PublishEvent", mock.AnythingOfType("entity.Event")).Return(nil)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestThrottled() {
+	request := suite.getValidRequest()
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Minute, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewTooManyRequestsError(time.Minute), r)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestCheckAnError() {
+	request := suite.getValidRequest()
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestBadPassword() {
+	request := suite.getValidRequest()
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", "a@b.com", "password").Return(nil, repository.UserBadUsernameOrPasswordError)
+
+	// The failure is recorded
+	suite.throttler.On("Fail", "a@b.com", "127.0.0.1").Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestLoginAnError() {
+	request := suite.getValidRequest()
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", "a@b.com", "password").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestUpdateAnError() {
+	request := suite.getValidRequest()
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", "a@b.com", "password").Return(&request.User, nil)
+	suite.throttler.On("Succeed", "a@b.com").Return(nil)
+	suite.userRepository.On("UpdatePassword", "userId", "newPassword").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestRotateAnError() {
+	request := suite.getValidRequest()
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", "a@b.com", "password").Return(&request.User, nil)
+	suite.throttler.On("Succeed", "a@b.com").Return(nil)
+	suite.userRepository.On("UpdatePassword", "userId", "newPassword").Return(&request.User, nil)
+	suite.userRepository.On("RotateSecret", "userId").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestCreateSessionAnError() {
+	request := suite.getValidRequest()
+	suite.expectChanged(request)
+	suite.sessionRepository.On("Create", "userId", "rotated", "phone", "127.0.0.1", "agent", false).
+		Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestGenerateTokenAnError() {
+	request := suite.getValidRequest()
+	suite.expectChanged(request)
+	suite.sessionRepository.On("Create", "userId", "rotated", "phone", "127.0.0.1", "agent", false).
+		Return(suite.session, nil)
+	suite.sessionRepository.On("Delete", "sessionId").Return(nil)
+	suite.accessTokenGenerator.On("GenerateToken", *suite.session, services.AccessTokenDuration).
+		Return("", assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestIssueAnError() {
+	request := suite.getValidRequest()
+	suite.expectChanged(request)
+	suite.sessionRepository.On("Create", "userId", "rotated", "phone", "127.0.0.1", "agent", false).
+		Return(suite.session, nil)
+	suite.sessionRepository.On("Delete", "sessionId").Return(nil)
+	suite.accessTokenGenerator.On("GenerateToken", *suite.session, services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", *suite.session).Return("", assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ChangePasswordInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.userRepository.On("Login", "a@b.com", "password").Return(&request.User, nil)
+	suite.throttler.On("Succeed", "a@b.com").Return(nil)
+	suite.userRepository.On("UpdatePassword", "userId", "newPassword").Return(&request.User, nil)
+
+	// The user is signed out everywhere
+	suite.userRepository.On("RotateSecret", "userId").Return(suite.rotated, nil)
+	var published entity.Event
+	suite.pubsub.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		published = event
+		return true
+	})).Return(nil)
+
+	// And the current session is replaced by a new one, signed with the rotated secret
+	suite.sessionRepository.On("Create", "userId", "rotated", "phone", "127.0.0.1", "agent", false).
+		Return(suite.session, nil)
+	suite.sessionRepository.On("Delete", "sessionId").Return(nil)
+	suite.accessTokenGenerator.On("GenerateToken", *suite.session, services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", *suite.session).Return("refresh", nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.Login{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    int(services.AccessTokenDuration.Seconds()),
+	}, r)
+
+	suite.Equal(SignedOutEvent, published.Type)
+	suite.Equal([]string{"a@b.com"}, published.To)
+}
+
+type ForgotPasswordInteractorTestSuite struct {
+	suite.Suite
+	interactor     *ForgotPassword
+	userRepository *mocks.UserRepository
+	issuer         *mocks.PasswordResetIssuer
+	sender         *mocks.Sender
+	throttler      *mocks.LoginThrottler
+	user           *entity.User
+}
+
+func TestForgotPasswordInteractor(t *testing.T) {
+	suite.Run(t, new(ForgotPasswordInteractorTestSuite))
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewForgotPasswordInteractor()
+	suite.user = &entity.User{Id: "userId", Email: "a@b.com"}
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.issuer = &mocks.PasswordResetIssuer{}
+	suite.sender = &mocks.Sender{}
+	suite.throttler = &mocks.LoginThrottler{}
+
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.PasswordResetIssuer = suite.issuer
+	suite.interactor.MailSender = suite.sender
+	suite.interactor.PasswordResetThrottler = suite.throttler
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.issuer.AssertExpectations(suite.T())
+	suite.sender.AssertExpectations(suite.T())
+	suite.throttler.AssertExpectations(suite.T())
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) getValidRequest() request.ForgotPassword {
+	// The request passes the throttle, which counts it
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.throttler.On("Fail", "a@b.com", "127.0.0.1").Return(nil)
+
+	return request.ForgotPassword{
+		Email: "a@b.com",
+		Ip:    "127.0.0.1",
+	}
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) TestThrottled() {
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Minute, nil)
+
+	r := suite.interactor.Call(request.ForgotPassword{Email: "a@b.com", Ip: "127.0.0.1"})
+	suite.Equal(response.NewTooManyRequestsError(time.Minute), r)
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) TestCheckAnError() {
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), assert.AnError)
+
+	r := suite.interactor.Call(request.ForgotPassword{Email: "a@b.com", Ip: "127.0.0.1"})
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) TestFailAnError() {
+	suite.throttler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.throttler.On("Fail", "a@b.com", "127.0.0.1").Return(assert.AnError)
+
+	r := suite.interactor.Call(request.ForgotPassword{Email: "a@b.com", Ip: "127.0.0.1"})
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) TestUnknownUser() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(nil, repository.UserNotFoundError)
+
+	// Nothing is sent, but the response doesn't tell it
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NoContentResponse{}, r)
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) TestUserAnError() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) TestIssueAnError() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(suite.user, nil)
+	suite.issuer.On("Issue", *suite.user).Return("", assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) TestSendAnError() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(suite.user, nil)
+	suite.issuer.On("Issue", *suite.user).Return("token", nil)
+	suite.sender.On("Send", mock.Anything).Return(assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ForgotPasswordInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.userRepository.On("GetUserByEmail", "a@b.com").Return(suite.user, nil)
+	suite.issuer.On("Issue", *suite.user).Return("token", nil)
+
+	var sent mail.Message
+	suite.sender.On("Send", mock.MatchedBy(func(message mail.Message) bool {
+		sent = message
+		return true
+	})).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NoContentResponse{}, r)
+
+	suite.Equal("a@b.com", sent.To)
+	suite.Equal(passwordResetSubject, sent.Subject)
+	suite.Contains(sent.Body, "\r\n\r\ntoken\r\n\r\n")
+}
+
+type ResetPasswordInteractorTestSuite struct {
+	suite.Suite
+	interactor     *ResetPassword
+	userRepository *mocks.UserRepository
+	issuer         *mocks.PasswordResetIssuer
+	pubsub         *mocks.PubsubClient
+	user           *entity.User
+}
+
+func TestResetPasswordInteractor(t *testing.T) {
+	suite.Run(t, new(ResetPasswordInteractorTestSuite))
+}
+
+func (suite *ResetPasswordInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewResetPasswordInteractor()
+	suite.user = &entity.User{Id: "userId", Email: "a@b.com"}
+}
+
+func (suite *ResetPasswordInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.issuer = &mocks.PasswordResetIssuer{}
+	suite.pubsub = &mocks.PubsubClient{}
+
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.PasswordResetIssuer = suite.issuer
+	suite.interactor.PubsubClient = suite.pubsub
+}
+
+func (suite *ResetPasswordInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.issuer.AssertExpectations(suite.T())
+	suite.pubsub.AssertExpectations(suite.T())
+}
+
+func (suite *ResetPasswordInteractorTestSuite) getValidRequest() request.ResetPassword {
+	return request.ResetPassword{
+		Token:    "token",
+		Password: "newPassword",
+	}
+}
+
+func (suite *ResetPasswordInteractorTestSuite) TestInvalidToken() {
+	request := suite.getValidRequest()
+	suite.issuer.On("Consume", "token").Return(nil, services.InvalidPasswordResetTokenError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusUnauthorized), r)
+}
+
+func (suite *ResetPasswordInteractorTestSuite) TestConsumeAnError() {
+	request := suite.getValidRequest()
+	suite.issuer.On("Consume", "token").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ResetPasswordInteractorTestSuite) TestUpdateAnError() {
+	request := suite.getValidRequest()
+	suite.issuer.On("Consume", "token").Return(suite.user, nil)
+	suite.userRepository.On("UpdatePassword", "userId", "newPassword").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ResetPasswordInteractorTestSuite) TestRotateAnError() {
+	request := suite.getValidRequest()
+	suite.issuer.On("Consume", "token").Return(suite.user, nil)
+	suite.userRepository.On("UpdatePassword", "userId", "newPassword").Return(suite.user, nil)
+	suite.userRepository.On("RotateSecret", "userId").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ResetPasswordInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.issuer.On("Consume", "token").Return(suite.user, nil)
+	suite.userRepository.On("UpdatePassword", "userId", "newPassword").Return(suite.user, nil)
+	suite.userRepository.On("RotateSecret", "userId").Return(suite.user, nil)
+
+	var published entity.Event
+	suite.pubsub.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		published = event
+		return true
+	})).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NoContentResponse{}, r)
+
+	// The websockets of the user are closed
+	suite.Equal(SignedOutEvent, published.Type)
+	suite.Equal([]string{"a@b.com"}, published.To)
+}
@@ -0,0 +1,97 @@
+package interactor
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type AddReactionInteractor interface {
+	Call(request.Reaction) response.Response
+}
+
+// Interface used mainly for Unit testing
+type RemoveReactionInteractor interface {
+	Call(request.Reaction) response.Response
+}
+
+// Adds the reaction of the user to a message and notifies its users
+type AddReaction struct {
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+}
+
+func NewAddReactionInteractor() *AddReaction {
+	return &AddReaction{}
+}
+
+func (i AddReaction) Call(request request.Reaction) response.Response {
+	return react(i.MessageRepository, i.PubsubClient, request, i.MessageRepository.AddReaction)
+}
+
+// Removes the reaction of the user from a message and notifies its users
+type RemoveReaction struct {
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+}
+
+func NewRemoveReactionInteractor() *RemoveReaction {
+	return &RemoveReaction{}
+}
+
+func (i RemoveReaction) Call(request request.Reaction) response.Response {
+	return react(i.MessageRepository, i.PubsubClient, request, i.MessageRepository.RemoveReaction)
+}
+
+// Applies the reaction of the user with update, then sends a reaction event to the users of the message
+func react(
+	messageRepository repository.MessageRepository,
+	pubsubClient services.PubsubClient,
+	request request.Reaction,
+	update func(id, email, emoji string) (*entity.Message, error),
+) response.Response {
+	message, err := messageRepository.GetById(request.MessageId)
+	if err == repository.MessageNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Only the users of the message can see it, and the deleted messages can't be reacted to
+	if !message.HasUser(request.User.Email) || message.Deleted() {
+		return response.NewError(iris.StatusNotFound)
+	}
+
+	message, err = update(message.Id, request.User.Email, request.Emoji)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	res := response.Reactions{
+		MessageId: message.Id,
+		Reactions: newReactionsResponse(message.Reactions),
+	}
+
+	event, err := entity.NewEvent("reaction", message.Users, res)
+	if err == nil {
+		err = pubsubClient.PublishEvent(event)
+	}
+	if err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
+	}
+
+	return res
+}
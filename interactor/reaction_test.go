@@ -0,0 +1,174 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type ReactionInteractorTestSuite struct {
+	suite.Suite
+	add               *AddReaction
+	remove            *RemoveReaction
+	messageRepository *mocks.MessageRepository
+	pubsub            *mocks.PubsubClient
+}
+
+func TestReactionInteractor(t *testing.T) {
+	suite.Run(t, new(ReactionInteractorTestSuite))
+}
+
+func (suite *ReactionInteractorTestSuite) SetupSuite() {
+	suite.add = NewAddReactionInteractor()
+	suite.remove = NewRemoveReactionInteractor()
+}
+
+func (suite *ReactionInteractorTestSuite) SetupTest() {
+	suite.messageRepository = &mocks.MessageRepository{}
+	suite.pubsub = &mocks.PubsubClient{}
+
+	suite.add.MessageRepository = suite.messageRepository
+	suite.add.PubsubClient = suite.pubsub
+	suite.remove.MessageRepository = suite.messageRepository
+	suite.remove.PubsubClient = suite.pubsub
+}
+
+func (suite *ReactionInteractorTestSuite) TearDownTest() {
+	suite.messageRepository.AssertExpectations(suite.T())
+	suite.pubsub.AssertExpectations(suite.T())
+}
+
+func (suite *ReactionInteractorTestSuite) getValidRequest() request.Reaction {
+	return request.Reaction{
+		User: entity.User{
+			Email: "b@b.com",
+		},
+		MessageId: "messageId",
+		Emoji:     "👍",
+	}
+}
+
+func (suite *ReactionInteractorTestSuite) getMessage() *entity.Message {
+	return &entity.Message{
+		Id:        "messageId",
+		From:      "a@b.com",
+		To:        "b@b.com",
+		Users:     []string{"a@b.com", "b@b.com"},
+		Message:   "txt",
+		CreatedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (suite *ReactionInteractorTestSuite) TestNotFound() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(nil, repository.MessageNotFoundError)
+
+	r := suite.add.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *ReactionInteractorTestSuite) TestGetAnError() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(nil, assert.AnError)
+
+	r := suite.add.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ReactionInteractorTestSuite) TestNotParticipant() {
+	request := suite.getValidRequest()
+	request.User.Email = "c@b.com"
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+
+	r := suite.add.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *ReactionInteractorTestSuite) TestDeleted() {
+	request := suite.getValidRequest()
+	message := suite.getMessage()
+	message.Delete(message.CreatedAt)
+	suite.messageRepository.On("GetById", request.MessageId).Return(message, nil)
+
+	r := suite.add.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *ReactionInteractorTestSuite) TestAddAnError() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+	suite.messageRepository.On("AddReaction", request.MessageId, "b@b.com", "👍").Return(nil, assert.AnError)
+
+	r := suite.add.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ReactionInteractorTestSuite) TestAddOK() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+
+	reacted := suite.getMessage()
+	reacted.AddReaction("a@b.com", "👍")
+	reacted.AddReaction("b@b.com", "👍")
+	suite.messageRepository.On("AddReaction", request.MessageId, "b@b.com", "👍").Return(reacted, nil)
+
+	var published entity.Event
+	suite.pubsub.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		published = event
+		return true
+	})).Return(nil)
+
+	r := suite.add.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.Reactions{
+		MessageId: "messageId",
+		Reactions: []response.Reaction{
+			{Emoji: "👍", Count: 2, Users: []string{"a@b.com", "b@b.com"}},
+		},
+	}, r)
+
+	// Both the users are notified
+	suite.Equal("reaction", published.Type)
+	suite.Equal([]string{"a@b.com", "b@b.com"}, published.To)
+	suite.JSONEq(`{
+		"messageId": "messageId",
+		"reactions": [{"emoji": "👍", "count": 2, "users": ["a@b.com", "b@b.com"]}]
+	}`, string(published.Data))
+}
+
+func (suite *ReactionInteractorTestSuite) TestRemoveOK() {
+	request := suite.getValidRequest()
+	message := suite.getMessage()
+	message.AddReaction("b@b.com", "👍")
+	suite.messageRepository.On("GetById", request.MessageId).Return(message, nil)
+	suite.messageRepository.On("RemoveReaction", request.MessageId, "b@b.com", "👍").Return(suite.getMessage(), nil)
+
+	var published entity.Event
+	suite.pubsub.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		published = event
+		return true
+	})).Return(nil)
+
+	r := suite.remove.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.Reactions{
+		MessageId: "messageId",
+		Reactions: []response.Reaction{},
+	}, r)
+
+	suite.Equal("reaction", published.Type)
+	suite.JSONEq(`{"messageId": "messageId", "reactions": []}`, string(published.Data))
+}
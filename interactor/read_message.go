@@ -0,0 +1,90 @@
+package interactor
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type ReadMessageInteractor interface {
+	Call(request.ReadMessage) response.Response
+}
+
+// Marks a direct message as read by its recipient and notifies the sender
+type ReadMessage struct {
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+}
+
+func NewReadMessageInteractor() *ReadMessage {
+	return &ReadMessage{}
+}
+
+func (i ReadMessage) Call(request request.ReadMessage) response.Response {
+	message, err := i.MessageRepository.GetById(request.MessageId)
+	if err == repository.MessageNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Only the users of the message can see it, the ones having deleted it for themselves are not anymore
+	if !message.HasUser(request.User.Email) {
+		return response.NewError(iris.StatusNotFound)
+	}
+
+	// Read receipts are only supported for direct messages
+	if message.ConversationId != "" {
+		err := response.NewError(iris.StatusUnprocessableEntity)
+		err.AddDetail("messageId", "notDirect")
+		return err
+	}
+
+	// Only the recipient can read the message
+	if message.To != request.User.Email {
+		return response.NewError(iris.StatusForbidden)
+	}
+
+	// The sender has already been notified
+	if receipt := message.ReadBy(request.User.Email); receipt != nil {
+		return newReadMessageResponse(message.Id, *receipt)
+	}
+
+	message, err = i.MessageRepository.MarkRead(message.Id, request.User.Email)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	res := newReadMessageResponse(message.Id, *message.ReadBy(request.User.Email))
+
+	// Notify the sender
+	event, err := entity.NewEvent("read", []string{message.From}, res)
+	if err == nil {
+		err = i.PubsubClient.PublishEvent(event)
+	}
+	if err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
+	}
+
+	return res
+}
+
+func newReadMessageResponse(messageId string, receipt entity.Receipt) response.ReadMessage {
+	return response.ReadMessage{
+		MessageId: messageId,
+		Receipt: response.Receipt{
+			User:   receipt.User,
+			ReadAt: receipt.ReadAt,
+		},
+	}
+}
@@ -0,0 +1,196 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type ReadMessageInteractorTestSuite struct {
+	suite.Suite
+	interactor        *ReadMessage
+	messageRepository *mocks.MessageRepository
+	pubsub            *mocks.PubsubClient
+	readAt            time.Time
+}
+
+func TestReadMessageInteractor(t *testing.T) {
+	suite.Run(t, new(ReadMessageInteractorTestSuite))
+}
+
+func (suite *ReadMessageInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewReadMessageInteractor()
+	suite.readAt = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (suite *ReadMessageInteractorTestSuite) SetupTest() {
+	suite.messageRepository = &mocks.MessageRepository{}
+	suite.pubsub = &mocks.PubsubClient{}
+	suite.interactor.MessageRepository = suite.messageRepository
+	suite.interactor.PubsubClient = suite.pubsub
+}
+
+func (suite *ReadMessageInteractorTestSuite) TearDownTest() {
+	suite.messageRepository.AssertExpectations(suite.T())
+	suite.pubsub.AssertExpectations(suite.T())
+}
+
+func (suite *ReadMessageInteractorTestSuite) getValidRequest() request.ReadMessage {
+	return request.ReadMessage{
+		User: entity.User{
+			Email: "a@b.com",
+		},
+		MessageId: "messageId",
+	}
+}
+
+func (suite *ReadMessageInteractorTestSuite) getMessage() *entity.Message {
+	return &entity.Message{
+		Id:    "messageId",
+		From:  "b@b.com",
+		To:    "a@b.com",
+		Users: []string{"b@b.com", "a@b.com"},
+	}
+}
+
+func (suite *ReadMessageInteractorTestSuite) validResponse() response.ReadMessage {
+	return response.ReadMessage{
+		MessageId: "messageId",
+		Receipt: response.Receipt{
+			User:   "a@b.com",
+			ReadAt: suite.readAt,
+		},
+	}
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestNotFound() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(nil, repository.MessageNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestGetAnError() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestNotParticipant() {
+	request := suite.getValidRequest()
+	message := suite.getMessage()
+	message.To = "c@b.com"
+	message.Users = []string{"b@b.com", "c@b.com"}
+	suite.messageRepository.On("GetById", request.MessageId).Return(message, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestDeletedForSelf() {
+	request := suite.getValidRequest()
+	message := suite.getMessage()
+	// The recipient has removed the message from its history
+	message.Users = []string{"b@b.com"}
+	suite.messageRepository.On("GetById", request.MessageId).Return(message, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestConversationMessage() {
+	request := suite.getValidRequest()
+	message := suite.getMessage()
+	message.ConversationId = "conversationId"
+	suite.messageRepository.On("GetById", request.MessageId).Return(message, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("messageId", "notDirect")
+	suite.Equal(expected, r)
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestSender() {
+	request := suite.getValidRequest()
+	message := suite.getMessage()
+	message.From, message.To = message.To, message.From
+	suite.messageRepository.On("GetById", request.MessageId).Return(message, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestAlreadyRead() {
+	request := suite.getValidRequest()
+	message := suite.getMessage()
+	message.Reads = []entity.Receipt{{User: "a@b.com", ReadAt: suite.readAt}}
+	suite.messageRepository.On("GetById", request.MessageId).Return(message, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(suite.validResponse(), r)
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestMarkReadAnError() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+	suite.messageRepository.On("MarkRead", request.MessageId, request.User.Email).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestPublishAnError() {
+	request := suite.getValidRequest()
+	read := suite.getMessage()
+	read.Reads = []entity.Receipt{{User: "a@b.com", ReadAt: suite.readAt}}
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+	suite.messageRepository.On("MarkRead", request.MessageId, request.User.Email).Return(read, nil)
+	suite.pubsub.On("PublishEvent", mock.AnythingOfType("entity.Event")).Return(assert.AnError)
+
+	// The receipt is stored, the error is ignored
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(suite.validResponse(), r)
+}
+
+func (suite *ReadMessageInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	read := suite.getMessage()
+	read.Reads = []entity.Receipt{{User: "a@b.com", ReadAt: suite.readAt}}
+	suite.messageRepository.On("GetById", request.MessageId).Return(suite.getMessage(), nil)
+	suite.messageRepository.On("MarkRead", request.MessageId, request.User.Email).Return(read, nil)
+
+	var published entity.Event
+	suite.pubsub.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		published = event
+		return true
+	})).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(suite.validResponse(), r)
+
+	suite.Equal("read", published.Type)
+	suite.Equal([]string{"b@b.com"}, published.To)
+	suite.JSONEq(`{"messageId": "messageId", "user": "a@b.com", "readAt": "2017-01-01T00:00:00Z"}`, string(published.Data))
+}
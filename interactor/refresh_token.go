@@ -0,0 +1,50 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type RefreshTokenInteractor interface {
+	Call(request.RefreshToken) response.Response
+}
+
+// Exchanges a refresh token for a new access token and a new refresh token
+type RefreshToken struct {
+	// Injected via DI
+	AccessTokenGenerator services.TokenGenerator `inject:"accessTokenGenerator"`
+
+	// Injected via DI
+	RefreshTokenIssuer services.RefreshTokenIssuer `inject:""`
+}
+
+func NewRefreshTokenInteractor() *RefreshToken {
+	return &RefreshToken{}
+}
+
+func (i RefreshToken) Call(request request.RefreshToken) response.Response {
+	// Rotate the refresh token. A reused token revokes its session, so the client has to login again
+	session, refreshToken, err := i.RefreshTokenIssuer.Rotate(request.RefreshToken)
+	if err == services.InvalidRefreshTokenError || err == services.RefreshTokenReusedError {
+		return response.NewError(iris.StatusUnauthorized)
+	}
+
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Generate the access token
+	token, err := i.AccessTokenGenerator.GenerateToken(*session, services.AccessTokenDuration)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.RefreshToken{
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(services.AccessTokenDuration.Seconds()),
+	}
+}
@@ -0,0 +1,105 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type RefreshTokenInteractorTestSuite struct {
+	suite.Suite
+	interactor *RefreshToken
+
+	accessTokenGenerator *mocks.TokenGenerator
+	refreshTokenIssuer   *mocks.RefreshTokenIssuer
+}
+
+func TestRefreshTokenInteractor(t *testing.T) {
+	suite.Run(t, new(RefreshTokenInteractorTestSuite))
+}
+
+func (suite *RefreshTokenInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewRefreshTokenInteractor()
+}
+
+func (suite *RefreshTokenInteractorTestSuite) SetupTest() {
+	suite.accessTokenGenerator = &mocks.TokenGenerator{}
+	suite.refreshTokenIssuer = &mocks.RefreshTokenIssuer{}
+
+	suite.interactor.AccessTokenGenerator = suite.accessTokenGenerator
+	suite.interactor.RefreshTokenIssuer = suite.refreshTokenIssuer
+}
+
+func (suite *RefreshTokenInteractorTestSuite) TearDownTest() {
+	suite.accessTokenGenerator.AssertExpectations(suite.T())
+	suite.refreshTokenIssuer.AssertExpectations(suite.T())
+}
+
+func (suite *RefreshTokenInteractorTestSuite) getValidRequest() request.RefreshToken {
+	return request.RefreshToken{
+		RefreshToken: "refresh",
+	}
+}
+
+func (suite *RefreshTokenInteractorTestSuite) TestInvalid() {
+	request := suite.getValidRequest()
+	suite.refreshTokenIssuer.On("Rotate", request.RefreshToken).Return(nil, "", services.InvalidRefreshTokenError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusUnauthorized), r)
+}
+
+func (suite *RefreshTokenInteractorTestSuite) TestReused() {
+	request := suite.getValidRequest()
+	suite.refreshTokenIssuer.On("Rotate", request.RefreshToken).Return(nil, "", services.RefreshTokenReusedError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusUnauthorized), r)
+}
+
+func (suite *RefreshTokenInteractorTestSuite) TestRotateAnyError() {
+	request := suite.getValidRequest()
+	suite.refreshTokenIssuer.On("Rotate", request.RefreshToken).Return(nil, "", assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *RefreshTokenInteractorTestSuite) TestAccessTokenGeneratorAnyError() {
+	request := suite.getValidRequest()
+	session := entity.Session{Id: "sessionId", UserId: "userId"}
+
+	suite.refreshTokenIssuer.On("Rotate", request.RefreshToken).Return(&session, "next", nil)
+	suite.accessTokenGenerator.On("GenerateToken", session, services.AccessTokenDuration).Return("", assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *RefreshTokenInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	session := entity.Session{Id: "sessionId", UserId: "userId"}
+
+	suite.refreshTokenIssuer.On("Rotate", request.RefreshToken).Return(&session, "next", nil)
+	suite.accessTokenGenerator.On("GenerateToken", session, services.AccessTokenDuration).Return("access", nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.RefreshToken{
+		AccessToken:  "access",
+		RefreshToken: "next",
+		ExpiresIn:    900,
+	}
+	suite.Equal(expected, r)
+}
@@ -1,12 +1,13 @@
 package interactor
 
 import (
+	"fmt"
+	"github.com/asiragusa/wschat/mail"
 	"github.com/asiragusa/wschat/repository"
 	"github.com/asiragusa/wschat/request"
 	"github.com/asiragusa/wschat/response"
 	"github.com/asiragusa/wschat/services"
 	"github.com/kataras/iris"
-	"time"
 )
 
 // Interface used mainly for Unit testing
@@ -14,13 +15,25 @@ type RegisterInteractor interface {
 	Call(request.Register) response.Response
 }
 
-// Registers a new user. Returns the Access Token
+// Registers a new user and sends it the email verification link. Returns the Access Token and the Refresh Token
 type Register struct {
 	// Injected via DI
 	UserRepository repository.UserRepository `inject:""`
 
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+
 	// Injected via DI
 	AccessTokenGenerator services.TokenGenerator `inject:"accessTokenGenerator"`
+
+	// Injected via DI
+	RefreshTokenIssuer services.RefreshTokenIssuer `inject:""`
+
+	// Injected via DI
+	EmailVerifier services.EmailVerifier `inject:""`
+
+	// Injected via DI
+	MailSender mail.Sender `inject:""`
 }
 
 func NewRegisterInteractor() *Register {
@@ -40,13 +53,33 @@ func (i Register) Call(request request.Register) response.Response {
 		return response.NewError(iris.StatusInternalServerError)
 	}
 
+	// The user is created anyway: the link can be sent again with POST /verify/resend
+	if err := sendVerificationEmail(i.EmailVerifier, i.MailSender, *user); err != nil {
+		// TODO: properly log the error
+		fmt.Println(err.Error())
+	}
+
+	// Open a new session on the client's device
+	session, err := i.SessionRepository.Create(user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
 	// Generate the Access Token
-	token, err := i.AccessTokenGenerator.GenerateToken(*user, time.Hour*24*30)
+	token, err := i.AccessTokenGenerator.GenerateToken(*session, services.AccessTokenDuration)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Issue the refresh token, used to get the following access tokens
+	refreshToken, err := i.RefreshTokenIssuer.Issue(*session)
 	if err != nil {
 		return response.NewError(iris.StatusInternalServerError)
 	}
 
 	return response.Register{
-		AccessToken: token,
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(services.AccessTokenDuration.Seconds()),
 	}
 }
@@ -2,15 +2,17 @@ package interactor
 
 import (
 	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mail"
 	"github.com/asiragusa/wschat/mocks"
 	"github.com/asiragusa/wschat/repository"
 	"github.com/asiragusa/wschat/request"
 	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
 	"github.com/kataras/iris/httptest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"testing"
-	"time"
 )
 
 type RegisterInteractorTestSuite struct {
@@ -18,7 +20,12 @@ type RegisterInteractorTestSuite struct {
 	interactor     *Register
 	userRepository *mocks.UserRepository
 
+	sessionRepository *mocks.SessionRepository
+
 	accessTokenGenerator *mocks.TokenGenerator
+	refreshTokenIssuer   *mocks.RefreshTokenIssuer
+	emailVerifier        *mocks.EmailVerifier
+	sender               *mocks.Sender
 }
 
 func TestRegisterInteractor(t *testing.T) {
@@ -31,22 +38,37 @@ func (suite *RegisterInteractorTestSuite) SetupSuite() {
 
 func (suite *RegisterInteractorTestSuite) SetupTest() {
 	suite.userRepository = &mocks.UserRepository{}
+	suite.sessionRepository = &mocks.SessionRepository{}
 
 	suite.accessTokenGenerator = &mocks.TokenGenerator{}
+	suite.refreshTokenIssuer = &mocks.RefreshTokenIssuer{}
+	suite.emailVerifier = &mocks.EmailVerifier{}
+	suite.sender = &mocks.Sender{}
 
 	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.SessionRepository = suite.sessionRepository
 	suite.interactor.AccessTokenGenerator = suite.accessTokenGenerator
+	suite.interactor.RefreshTokenIssuer = suite.refreshTokenIssuer
+	suite.interactor.EmailVerifier = suite.emailVerifier
+	suite.interactor.MailSender = suite.sender
 }
 
 func (suite *RegisterInteractorTestSuite) TearDownTest() {
 	suite.userRepository.AssertExpectations(suite.T())
+	suite.sessionRepository.AssertExpectations(suite.T())
 	suite.accessTokenGenerator.AssertExpectations(suite.T())
+	suite.refreshTokenIssuer.AssertExpectations(suite.T())
+	suite.emailVerifier.AssertExpectations(suite.T())
+	suite.sender.AssertExpectations(suite.T())
 }
 
 func (suite *RegisterInteractorTestSuite) getValidRequest() request.Register {
 	return request.Register{
-		Email:    "a@b.com",
-		Password: "validPassword",
+		Email:     "a@b.com",
+		Password:  "validPassword",
+		Device:    "laptop",
+		Ip:        "127.0.0.1",
+		UserAgent: "agent",
 	}
 }
 
@@ -76,10 +98,13 @@ func (suite *RegisterInteractorTestSuite) TestRepositoryAnyError() {
 
 func (suite *RegisterInteractorTestSuite) TestAccessTokenGeneratorAnyError() {
 	request := suite.getValidRequest()
-	user := entity.User{Email: request.Email}
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret"}
+	session := suite.session()
 
 	suite.userRepository.On("CreateUser", request.Email, request.Password).Return(&user, nil)
-	suite.accessTokenGenerator.On("GenerateToken", user, time.Hour*24*30).Return("", assert.AnError)
+	suite.expectVerificationEmail(user)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false).Return(&session, nil)
+	suite.accessTokenGenerator.On("GenerateToken", session, services.AccessTokenDuration).Return("", assert.AnError)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
@@ -88,16 +113,92 @@ func (suite *RegisterInteractorTestSuite) TestAccessTokenGeneratorAnyError() {
 
 func (suite *RegisterInteractorTestSuite) TestOK() {
 	request := suite.getValidRequest()
-	user := entity.User{Email: request.Email}
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret"}
+	session := suite.session()
 
 	suite.userRepository.On("CreateUser", request.Email, request.Password).Return(&user, nil)
-	suite.accessTokenGenerator.On("GenerateToken", user, time.Hour*24*30).Return("access", nil)
+	suite.expectVerificationEmail(user)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false).Return(&session, nil)
+	suite.accessTokenGenerator.On("GenerateToken", session, services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", session).Return("refresh", nil)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
 
 	expected := response.Register{
-		AccessToken: "access",
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    900,
 	}
 	suite.Equal(expected, r)
 }
+
+func (suite *RegisterInteractorTestSuite) TestRefreshTokenIssuerAnyError() {
+	request := suite.getValidRequest()
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret"}
+	session := suite.session()
+
+	suite.userRepository.On("CreateUser", request.Email, request.Password).Return(&user, nil)
+	suite.expectVerificationEmail(user)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false).Return(&session, nil)
+	suite.accessTokenGenerator.On("GenerateToken", session, services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", session).Return("", assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+// Expects the verification link to be sent to the user
+func (suite *RegisterInteractorTestSuite) expectVerificationEmail(user entity.User) {
+	suite.emailVerifier.On("Link", user).Return("http://localhost/?verify=token", nil)
+	suite.sender.On("Send", mock.MatchedBy(func(message mail.Message) bool {
+		return message.To == user.Email && message.Subject == emailVerificationSubject
+	})).Return(nil)
+}
+
+func (suite *RegisterInteractorTestSuite) TestSendVerificationAnyError() {
+	request := suite.getValidRequest()
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret"}
+	session := suite.session()
+
+	// The user is registered even if the link can't be sent
+	suite.userRepository.On("CreateUser", request.Email, request.Password).Return(&user, nil)
+	suite.emailVerifier.On("Link", user).Return("http://localhost/?verify=token", nil)
+	suite.sender.On("Send", mock.Anything).Return(assert.AnError)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false).Return(&session, nil)
+	suite.accessTokenGenerator.On("GenerateToken", session, services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", session).Return("refresh", nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.Register{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    900,
+	}, r)
+}
+
+// Returns the session opened by the request
+func (suite *RegisterInteractorTestSuite) session() entity.Session {
+	return entity.Session{
+		Id:        "sessionId",
+		UserId:    "userId",
+		Secret:    "secret",
+		Device:    "laptop",
+		Ip:        "127.0.0.1",
+		UserAgent: "agent",
+	}
+}
+
+func (suite *RegisterInteractorTestSuite) TestSessionRepositoryAnyError() {
+	request := suite.getValidRequest()
+	user := entity.User{Id: "userId", Email: request.Email, Secret: "secret"}
+
+	suite.userRepository.On("CreateUser", request.Email, request.Password).Return(&user, nil)
+	suite.expectVerificationEmail(user)
+	suite.sessionRepository.On("Create", user.Id, user.Secret, request.Device, request.Ip, request.UserAgent, false).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
@@ -0,0 +1,47 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type RenameConversationInteractor interface {
+	Call(request.RenameConversation) response.Response
+}
+
+// Renames a conversation. Only members can rename it
+type RenameConversation struct {
+	// Injected via DI
+	ConversationRepository repository.ConversationRepository `inject:""`
+}
+
+func NewRenameConversationInteractor() *RenameConversation {
+	return &RenameConversation{}
+}
+
+func (i RenameConversation) Call(request request.RenameConversation) response.Response {
+	// Find the conversation or return an error
+	conversation, err := i.ConversationRepository.GetById(request.ConversationId)
+	if err == repository.ConversationNotFoundError {
+		return response.NewError(iris.StatusNotFound)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if !conversation.HasMember(request.User.Email) {
+		return response.NewError(iris.StatusForbidden)
+	}
+
+	conversation, err = i.ConversationRepository.Rename(conversation.Id, request.Name)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.UpdateConversation{
+		Conversation: newConversationResponse(*conversation),
+	}
+}
@@ -0,0 +1,106 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type RenameConversationInteractorTestSuite struct {
+	suite.Suite
+	interactor             *RenameConversation
+	conversationRepository *mocks.ConversationRepository
+}
+
+func TestRenameConversationInteractor(t *testing.T) {
+	suite.Run(t, new(RenameConversationInteractorTestSuite))
+}
+
+func (suite *RenameConversationInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewRenameConversationInteractor()
+}
+
+func (suite *RenameConversationInteractorTestSuite) SetupTest() {
+	suite.conversationRepository = &mocks.ConversationRepository{}
+	suite.interactor.ConversationRepository = suite.conversationRepository
+}
+
+func (suite *RenameConversationInteractorTestSuite) TearDownTest() {
+	suite.conversationRepository.AssertExpectations(suite.T())
+}
+
+func (suite *RenameConversationInteractorTestSuite) getValidRequest() request.RenameConversation {
+	return request.RenameConversation{
+		User: entity.User{
+			Email: "a@b.com",
+		},
+		ConversationId: "conversationId",
+		Name:           "retro",
+	}
+}
+
+func (suite *RenameConversationInteractorTestSuite) TestNotFound() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(nil, repository.ConversationNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusNotFound), r)
+}
+
+func (suite *RenameConversationInteractorTestSuite) TestNotMember() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(&entity.Conversation{
+		Id:      request.ConversationId,
+		Members: []string{"b@b.com"},
+	}, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *RenameConversationInteractorTestSuite) TestRenameAnError() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(&entity.Conversation{
+		Id:      request.ConversationId,
+		Members: []string{request.User.Email},
+	}, nil)
+	suite.conversationRepository.On("Rename", request.ConversationId, request.Name).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *RenameConversationInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.conversationRepository.On("GetById", request.ConversationId).Return(&entity.Conversation{
+		Id:      request.ConversationId,
+		Name:    "standup",
+		Members: []string{request.User.Email},
+	}, nil)
+	suite.conversationRepository.On("Rename", request.ConversationId, request.Name).Return(&entity.Conversation{
+		Id:      request.ConversationId,
+		Name:    request.Name,
+		Members: []string{request.User.Email},
+	}, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.UpdateConversation{
+		Conversation: response.Conversation{
+			Id:      request.ConversationId,
+			Name:    request.Name,
+			Members: []string{request.User.Email},
+		},
+	}
+	suite.Equal(expected, r)
+}
@@ -0,0 +1,74 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris"
+)
+
+// Number of messages returned when the search doesn't specify a limit
+const defaultSearchLimit = 20
+
+// Interface used mainly for Unit testing
+type SearchMessagesInteractor interface {
+	Call(request.SearchMessages) response.Response
+}
+
+// SearchMessages finds the messages of the given user containing all the words of the query
+type SearchMessages struct {
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+}
+
+func NewSearchMessagesInteractor() *SearchMessages {
+	return &SearchMessages{}
+}
+
+func (i SearchMessages) Call(request request.SearchMessages) response.Response {
+	// The query must contain at least a word, eg. not only punctuation
+	terms := entity.Terms(request.Query)
+	if len(terms) == 0 {
+		err := response.NewError(iris.StatusUnprocessableEntity)
+		err.AddDetail("q", "invalid")
+		return err
+	}
+
+	if !request.Since.IsZero() && !request.Until.IsZero() && !request.Until.After(request.Since) {
+		err := response.NewError(iris.StatusUnprocessableEntity)
+		err.AddDetail("until", "invalid")
+		return err
+	}
+
+	options := repository.SearchOptions{
+		Terms:  terms,
+		With:   request.With,
+		Since:  request.Since,
+		Until:  request.Until,
+		Limit:  request.Limit,
+		Offset: request.Offset,
+	}
+	if options.Limit == 0 {
+		options.Limit = defaultSearchLimit
+	}
+
+	results, err := i.MessageRepository.Search(request.User.Email, options)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	res := response.SearchMessages{
+		Total: len(results),
+		Items: []response.SearchResult{},
+	}
+
+	for _, result := range results {
+		res.Items = append(res.Items, response.SearchResult{
+			Message:   newMessageResponse(result.Message),
+			Highlight: highlight(result.Message.Message, terms),
+			Score:     result.Score,
+		})
+	}
+	return res
+}
@@ -0,0 +1,133 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type SearchMessagesInteractorTestSuite struct {
+	suite.Suite
+	interactor        *SearchMessages
+	messageRepository *mocks.MessageRepository
+}
+
+func TestSearchMessagesInteractor(t *testing.T) {
+	suite.Run(t, new(SearchMessagesInteractorTestSuite))
+}
+
+func (suite *SearchMessagesInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewSearchMessagesInteractor()
+}
+
+func (suite *SearchMessagesInteractorTestSuite) SetupTest() {
+	suite.messageRepository = &mocks.MessageRepository{}
+	suite.interactor.MessageRepository = suite.messageRepository
+}
+
+func (suite *SearchMessagesInteractorTestSuite) TearDownTest() {
+	suite.messageRepository.AssertExpectations(suite.T())
+}
+
+func (suite *SearchMessagesInteractorTestSuite) getValidRequest() request.SearchMessages {
+	return request.SearchMessages{
+		User: entity.User{
+			Email: "a@b.com",
+		},
+		Query: "Hello, World!",
+	}
+}
+
+func (suite *SearchMessagesInteractorTestSuite) TestNoTerms() {
+	request := suite.getValidRequest()
+	request.Query = "?!"
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("q", "invalid")
+	suite.Equal(expected, r)
+}
+
+func (suite *SearchMessagesInteractorTestSuite) TestInvalidRange() {
+	request := suite.getValidRequest()
+	request.Since = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	request.Until = request.Since
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("until", "invalid")
+	suite.Equal(expected, r)
+}
+
+func (suite *SearchMessagesInteractorTestSuite) TestAnError() {
+	request := suite.getValidRequest()
+	suite.messageRepository.On("Search", "a@b.com", repository.SearchOptions{
+		Terms: []string{"hello", "world"},
+		Limit: defaultSearchLimit,
+	}).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *SearchMessagesInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	request.With = "b@b.com"
+	request.Since = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	request.Until = time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)
+	request.Limit = 10
+	request.Offset = 20
+
+	createdAt := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	suite.messageRepository.On("Search", "a@b.com", repository.SearchOptions{
+		Terms:  []string{"hello", "world"},
+		With:   "b@b.com",
+		Since:  request.Since,
+		Until:  request.Until,
+		Limit:  10,
+		Offset: 20,
+	}).Return([]repository.SearchResult{
+		{
+			Message: entity.Message{
+				Id:        "messageId",
+				From:      "b@b.com",
+				To:        "a@b.com",
+				Message:   "<b>Hello</b> world, hello!",
+				CreatedAt: createdAt,
+			},
+			Score: 3,
+		},
+	}, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	suite.Equal(response.SearchMessages{
+		Total: 1,
+		Items: []response.SearchResult{
+			{
+				Message: response.Message{
+					Id:        "messageId",
+					From:      "b@b.com",
+					To:        "a@b.com",
+					Message:   "<b>Hello</b> world, hello!",
+					CreatedAt: createdAt,
+				},
+				Highlight: "&lt;b&gt;<mark>Hello</mark>&lt;/b&gt; <mark>world</mark>, <mark>hello</mark>!",
+				Score:     3,
+			},
+		},
+	}, r)
+}
@@ -0,0 +1,227 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Interface used mainly for Unit testing
+type EnrollTotpInteractor interface {
+	Call(request.EnrollTotp) response.Response
+}
+
+// Starts the enrollment of the two factor authentication. Returns the TOTP secret to add to the authenticator app
+type EnrollTotp struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	TotpAuthenticator services.TotpAuthenticator `inject:""`
+}
+
+func NewEnrollTotpInteractor() *EnrollTotp {
+	return &EnrollTotp{}
+}
+
+func (i EnrollTotp) Call(request request.EnrollTotp) response.Response {
+	// The secret can be replaced only by disabling the two factor authentication, which requires a code
+	if request.User.TotpEnabled() {
+		return response.NewError(iris.StatusConflict)
+	}
+
+	secret, err := i.TotpAuthenticator.NewSecret()
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if _, err := i.UserRepository.SetPendingTotpSecret(request.User.Id, secret); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.EnrollTotp{
+		Secret: secret,
+		Uri:    i.TotpAuthenticator.ProvisioningUri(secret, request.User.Email),
+	}
+}
+
+// Interface used mainly for Unit testing
+type ConfirmTotpInteractor interface {
+	Call(request.ConfirmTotp) response.Response
+}
+
+// Enables the two factor authentication, given a first code of the enrolled secret. Returns the recovery codes
+type ConfirmTotp struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	TotpAuthenticator services.TotpAuthenticator `inject:""`
+}
+
+func NewConfirmTotpInteractor() *ConfirmTotp {
+	return &ConfirmTotp{}
+}
+
+func (i ConfirmTotp) Call(request request.ConfirmTotp) response.Response {
+	secret := request.User.PendingTotpSecret
+	if request.User.TotpEnabled() || secret == "" {
+		return response.NewError(iris.StatusConflict)
+	}
+
+	counter, ok := i.TotpAuthenticator.ValidateCode(secret, request.Code)
+	if !ok {
+		return response.NewError(iris.StatusForbidden)
+	}
+
+	// The confirming code can't be replayed to login
+	err := i.UserRepository.UseTotpCounter(request.User.Id, counter)
+	if err == repository.UserTotpCodeUsedError {
+		return response.NewError(iris.StatusForbidden)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	codes, hashes, err := i.TotpAuthenticator.NewRecoveryCodes()
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if _, err := i.UserRepository.EnableTotp(request.User.Id, secret, hashes); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.ConfirmTotp{
+		RecoveryCodes: codes,
+	}
+}
+
+// Interface used mainly for Unit testing
+type DisableTotpInteractor interface {
+	Call(request.DisableTotp) response.Response
+}
+
+// Disables the two factor authentication, given a TOTP code or a recovery code
+type DisableTotp struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	TotpAuthenticator services.TotpAuthenticator `inject:""`
+}
+
+func NewDisableTotpInteractor() *DisableTotp {
+	return &DisableTotp{}
+}
+
+func (i DisableTotp) Call(request request.DisableTotp) response.Response {
+	if !request.User.TotpEnabled() {
+		return response.NewError(iris.StatusConflict)
+	}
+
+	ok, err := i.TotpAuthenticator.Verify(request.User, request.Code)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	if !ok {
+		return response.NewError(iris.StatusForbidden)
+	}
+
+	if _, err := i.UserRepository.DisableTotp(request.User.Id); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.NoContentResponse{}
+}
+
+// Interface used mainly for Unit testing
+type LoginMfaInteractor interface {
+	Call(request.LoginMfa) response.Response
+}
+
+// Second step of the login of an user with the two factor authentication enabled.
+// Exchanges the mfa token and a valid code for the access token and the refresh token
+type LoginMfa struct {
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+
+	// Injected via DI
+	MfaTokenGenerator services.TokenGenerator `inject:"mfaTokenGenerator"`
+
+	// Injected via DI
+	AccessTokenGenerator services.TokenGenerator `inject:"accessTokenGenerator"`
+
+	// Injected via DI
+	RefreshTokenIssuer services.RefreshTokenIssuer `inject:""`
+
+	// Injected via DI
+	TotpAuthenticator services.TotpAuthenticator `inject:""`
+
+	// Injected via DI
+	LoginThrottler services.LoginThrottler `inject:""`
+}
+
+func NewLoginMfaInteractor() *LoginMfa {
+	return &LoginMfa{}
+}
+
+func (i LoginMfa) Call(request request.LoginMfa) response.Response {
+	// The mfa token is valid only while its session is pending
+	user, session, err := i.MfaTokenGenerator.ValidateToken(request.MfaToken)
+	if err == services.InvalidTokenError {
+		return response.NewError(iris.StatusUnauthorized)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// The codes are throttled like the passwords
+	wait, err := i.LoginThrottler.Check(user.Email, request.Ip)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	if wait > 0 {
+		return response.NewTooManyRequestsError(wait)
+	}
+
+	ok, err := i.TotpAuthenticator.Verify(*user, request.Code)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	if !ok {
+		if err := i.LoginThrottler.Fail(user.Email, request.Ip); err != nil {
+			return response.NewError(iris.StatusInternalServerError)
+		}
+		return response.NewError(iris.StatusUnauthorized)
+	}
+
+	if err := i.LoginThrottler.Succeed(user.Email); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	if err := i.SessionRepository.Activate(session.Id); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+	session.Pending = false
+
+	// Generate the access token
+	token, err := i.AccessTokenGenerator.GenerateToken(*session, services.AccessTokenDuration)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// Issue the refresh token, used to get the following access tokens
+	refreshToken, err := i.RefreshTokenIssuer.Issue(*session)
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.Login{
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(services.AccessTokenDuration.Seconds()),
+	}
+}
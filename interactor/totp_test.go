@@ -0,0 +1,420 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type EnrollTotpInteractorTestSuite struct {
+	suite.Suite
+	interactor        *EnrollTotp
+	userRepository    *mocks.UserRepository
+	totpAuthenticator *mocks.TotpAuthenticator
+}
+
+func TestEnrollTotpInteractor(t *testing.T) {
+	suite.Run(t, new(EnrollTotpInteractorTestSuite))
+}
+
+func (suite *EnrollTotpInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewEnrollTotpInteractor()
+}
+
+func (suite *EnrollTotpInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.totpAuthenticator = &mocks.TotpAuthenticator{}
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.TotpAuthenticator = suite.totpAuthenticator
+}
+
+func (suite *EnrollTotpInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.totpAuthenticator.AssertExpectations(suite.T())
+}
+
+func (suite *EnrollTotpInteractorTestSuite) getValidRequest() request.EnrollTotp {
+	return request.EnrollTotp{
+		User: entity.User{Id: "userId", Email: "a@b.com"},
+	}
+}
+
+func (suite *EnrollTotpInteractorTestSuite) TestAlreadyEnabled() {
+	request := suite.getValidRequest()
+	request.User.TotpSecret = "secret"
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusConflict), r)
+}
+
+func (suite *EnrollTotpInteractorTestSuite) TestNewSecretAnyError() {
+	suite.totpAuthenticator.On("NewSecret").Return("", assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *EnrollTotpInteractorTestSuite) TestRepositoryAnyError() {
+	suite.totpAuthenticator.On("NewSecret").Return("secret", nil)
+	suite.userRepository.On("SetPendingTotpSecret", "userId", "secret").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *EnrollTotpInteractorTestSuite) TestOK() {
+	suite.totpAuthenticator.On("NewSecret").Return("secret", nil)
+	suite.userRepository.On("SetPendingTotpSecret", "userId", "secret").Return(&entity.User{}, nil)
+	suite.totpAuthenticator.On("ProvisioningUri", "secret", "a@b.com").Return("otpauth://totp/uri")
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.EnrollTotp{Secret: "secret", Uri: "otpauth://totp/uri"}, r)
+}
+
+type ConfirmTotpInteractorTestSuite struct {
+	suite.Suite
+	interactor        *ConfirmTotp
+	userRepository    *mocks.UserRepository
+	totpAuthenticator *mocks.TotpAuthenticator
+}
+
+func TestConfirmTotpInteractor(t *testing.T) {
+	suite.Run(t, new(ConfirmTotpInteractorTestSuite))
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewConfirmTotpInteractor()
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.totpAuthenticator = &mocks.TotpAuthenticator{}
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.TotpAuthenticator = suite.totpAuthenticator
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.totpAuthenticator.AssertExpectations(suite.T())
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) getValidRequest() request.ConfirmTotp {
+	return request.ConfirmTotp{
+		User: entity.User{Id: "userId", Email: "a@b.com", PendingTotpSecret: "pending"},
+		Code: "123456",
+	}
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) TestNotEnrolled() {
+	request := suite.getValidRequest()
+	request.User.PendingTotpSecret = ""
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusConflict), r)
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) TestAlreadyEnabled() {
+	request := suite.getValidRequest()
+	request.User.TotpSecret = "secret"
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusConflict), r)
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) TestInvalidCode() {
+	suite.totpAuthenticator.On("ValidateCode", "pending", "123456").Return(int64(0), false)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) TestCodeUsed() {
+	suite.totpAuthenticator.On("ValidateCode", "pending", "123456").Return(int64(42), true)
+	suite.userRepository.On("UseTotpCounter", "userId", int64(42)).Return(repository.UserTotpCodeUsedError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) TestUseCounterAnyError() {
+	suite.totpAuthenticator.On("ValidateCode", "pending", "123456").Return(int64(42), true)
+	suite.userRepository.On("UseTotpCounter", "userId", int64(42)).Return(assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) TestRecoveryCodesAnyError() {
+	suite.totpAuthenticator.On("ValidateCode", "pending", "123456").Return(int64(42), true)
+	suite.userRepository.On("UseTotpCounter", "userId", int64(42)).Return(nil)
+	suite.totpAuthenticator.On("NewRecoveryCodes").Return(nil, nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) TestRepositoryAnyError() {
+	suite.totpAuthenticator.On("ValidateCode", "pending", "123456").Return(int64(42), true)
+	suite.userRepository.On("UseTotpCounter", "userId", int64(42)).Return(nil)
+	suite.totpAuthenticator.On("NewRecoveryCodes").Return([]string{"code"}, []string{"hash"}, nil)
+	suite.userRepository.On("EnableTotp", "userId", "pending", []string{"hash"}).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ConfirmTotpInteractorTestSuite) TestOK() {
+	suite.totpAuthenticator.On("ValidateCode", "pending", "123456").Return(int64(42), true)
+	suite.userRepository.On("UseTotpCounter", "userId", int64(42)).Return(nil)
+	suite.totpAuthenticator.On("NewRecoveryCodes").Return([]string{"code"}, []string{"hash"}, nil)
+	suite.userRepository.On("EnableTotp", "userId", "pending", []string{"hash"}).Return(&entity.User{}, nil)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.ConfirmTotp{RecoveryCodes: []string{"code"}}, r)
+}
+
+type DisableTotpInteractorTestSuite struct {
+	suite.Suite
+	interactor        *DisableTotp
+	userRepository    *mocks.UserRepository
+	totpAuthenticator *mocks.TotpAuthenticator
+}
+
+func TestDisableTotpInteractor(t *testing.T) {
+	suite.Run(t, new(DisableTotpInteractorTestSuite))
+}
+
+func (suite *DisableTotpInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewDisableTotpInteractor()
+}
+
+func (suite *DisableTotpInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.totpAuthenticator = &mocks.TotpAuthenticator{}
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.TotpAuthenticator = suite.totpAuthenticator
+}
+
+func (suite *DisableTotpInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.totpAuthenticator.AssertExpectations(suite.T())
+}
+
+func (suite *DisableTotpInteractorTestSuite) getValidRequest() request.DisableTotp {
+	return request.DisableTotp{
+		User: entity.User{Id: "userId", Email: "a@b.com", TotpSecret: "secret"},
+		Code: "123456",
+	}
+}
+
+func (suite *DisableTotpInteractorTestSuite) TestNotEnabled() {
+	request := suite.getValidRequest()
+	request.User.TotpSecret = ""
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusConflict), r)
+}
+
+func (suite *DisableTotpInteractorTestSuite) TestInvalidCode() {
+	request := suite.getValidRequest()
+	suite.totpAuthenticator.On("Verify", request.User, "123456").Return(false, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusForbidden), r)
+}
+
+func (suite *DisableTotpInteractorTestSuite) TestVerifyAnyError() {
+	request := suite.getValidRequest()
+	suite.totpAuthenticator.On("Verify", request.User, "123456").Return(false, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *DisableTotpInteractorTestSuite) TestRepositoryAnyError() {
+	request := suite.getValidRequest()
+	suite.totpAuthenticator.On("Verify", request.User, "123456").Return(true, nil)
+	suite.userRepository.On("DisableTotp", "userId").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *DisableTotpInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.totpAuthenticator.On("Verify", request.User, "123456").Return(true, nil)
+	suite.userRepository.On("DisableTotp", "userId").Return(&entity.User{}, nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NoContentResponse{}, r)
+}
+
+type LoginMfaInteractorTestSuite struct {
+	suite.Suite
+	interactor           *LoginMfa
+	sessionRepository    *mocks.SessionRepository
+	mfaTokenGenerator    *mocks.TokenGenerator
+	accessTokenGenerator *mocks.TokenGenerator
+	refreshTokenIssuer   *mocks.RefreshTokenIssuer
+	totpAuthenticator    *mocks.TotpAuthenticator
+	loginThrottler       *mocks.LoginThrottler
+	user                 entity.User
+}
+
+func TestLoginMfaInteractor(t *testing.T) {
+	suite.Run(t, new(LoginMfaInteractorTestSuite))
+}
+
+func (suite *LoginMfaInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewLoginMfaInteractor()
+	suite.user = entity.User{Id: "userId", Email: "a@b.com", Secret: "secret", TotpSecret: "totpSecret"}
+}
+
+func (suite *LoginMfaInteractorTestSuite) SetupTest() {
+	suite.sessionRepository = &mocks.SessionRepository{}
+	suite.mfaTokenGenerator = &mocks.TokenGenerator{}
+	suite.accessTokenGenerator = &mocks.TokenGenerator{}
+	suite.refreshTokenIssuer = &mocks.RefreshTokenIssuer{}
+	suite.totpAuthenticator = &mocks.TotpAuthenticator{}
+	suite.loginThrottler = &mocks.LoginThrottler{}
+
+	suite.interactor.SessionRepository = suite.sessionRepository
+	suite.interactor.MfaTokenGenerator = suite.mfaTokenGenerator
+	suite.interactor.AccessTokenGenerator = suite.accessTokenGenerator
+	suite.interactor.RefreshTokenIssuer = suite.refreshTokenIssuer
+	suite.interactor.TotpAuthenticator = suite.totpAuthenticator
+	suite.interactor.LoginThrottler = suite.loginThrottler
+}
+
+func (suite *LoginMfaInteractorTestSuite) TearDownTest() {
+	suite.sessionRepository.AssertExpectations(suite.T())
+	suite.mfaTokenGenerator.AssertExpectations(suite.T())
+	suite.accessTokenGenerator.AssertExpectations(suite.T())
+	suite.refreshTokenIssuer.AssertExpectations(suite.T())
+	suite.totpAuthenticator.AssertExpectations(suite.T())
+	suite.loginThrottler.AssertExpectations(suite.T())
+}
+
+func (suite *LoginMfaInteractorTestSuite) getValidRequest() request.LoginMfa {
+	return request.LoginMfa{
+		MfaToken: "mfa",
+		Code:     "123456",
+		Ip:       "127.0.0.1",
+	}
+}
+
+// Returns the pending session of the mfa token
+func (suite *LoginMfaInteractorTestSuite) session() *entity.Session {
+	return &entity.Session{Id: "sessionId", UserId: "userId", Secret: "secret", Pending: true}
+}
+
+// Returns the session once activated
+func (suite *LoginMfaInteractorTestSuite) activeSession() entity.Session {
+	session := suite.session()
+	session.Pending = false
+	return *session
+}
+
+func (suite *LoginMfaInteractorTestSuite) TestInvalidToken() {
+	suite.mfaTokenGenerator.On("ValidateToken", "mfa").Return(nil, nil, services.InvalidTokenError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusUnauthorized), r)
+}
+
+func (suite *LoginMfaInteractorTestSuite) TestValidateTokenAnyError() {
+	suite.mfaTokenGenerator.On("ValidateToken", "mfa").Return(nil, nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LoginMfaInteractorTestSuite) TestThrottled() {
+	suite.mfaTokenGenerator.On("ValidateToken", "mfa").Return(&suite.user, suite.session(), nil)
+	suite.loginThrottler.On("Check", "a@b.com", "127.0.0.1").Return(time.Minute, nil)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewTooManyRequestsError(time.Minute), r)
+}
+
+func (suite *LoginMfaInteractorTestSuite) TestInvalidCode() {
+	suite.mfaTokenGenerator.On("ValidateToken", "mfa").Return(&suite.user, suite.session(), nil)
+	suite.loginThrottler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.totpAuthenticator.On("Verify", suite.user, "123456").Return(false, nil)
+	suite.loginThrottler.On("Fail", "a@b.com", "127.0.0.1").Return(nil)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusUnauthorized), r)
+}
+
+func (suite *LoginMfaInteractorTestSuite) TestVerifyAnyError() {
+	suite.mfaTokenGenerator.On("ValidateToken", "mfa").Return(&suite.user, suite.session(), nil)
+	suite.loginThrottler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.totpAuthenticator.On("Verify", suite.user, "123456").Return(false, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LoginMfaInteractorTestSuite) TestActivateAnyError() {
+	suite.mfaTokenGenerator.On("ValidateToken", "mfa").Return(&suite.user, suite.session(), nil)
+	suite.loginThrottler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.totpAuthenticator.On("Verify", suite.user, "123456").Return(true, nil)
+	suite.loginThrottler.On("Succeed", "a@b.com").Return(nil)
+	suite.sessionRepository.On("Activate", "sessionId").Return(assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LoginMfaInteractorTestSuite) TestAccessTokenGeneratorAnyError() {
+	suite.mfaTokenGenerator.On("ValidateToken", "mfa").Return(&suite.user, suite.session(), nil)
+	suite.loginThrottler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.totpAuthenticator.On("Verify", suite.user, "123456").Return(true, nil)
+	suite.loginThrottler.On("Succeed", "a@b.com").Return(nil)
+	suite.sessionRepository.On("Activate", "sessionId").Return(nil)
+	suite.accessTokenGenerator.On("GenerateToken", suite.activeSession(), services.AccessTokenDuration).Return("", assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LoginMfaInteractorTestSuite) TestRefreshTokenIssuerAnyError() {
+	suite.mfaTokenGenerator.On("ValidateToken", "mfa").Return(&suite.user, suite.session(), nil)
+	suite.loginThrottler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.totpAuthenticator.On("Verify", suite.user, "123456").Return(true, nil)
+	suite.loginThrottler.On("Succeed", "a@b.com").Return(nil)
+	suite.sessionRepository.On("Activate", "sessionId").Return(nil)
+	suite.accessTokenGenerator.On("GenerateToken", suite.activeSession(), services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", suite.activeSession()).Return("", assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *LoginMfaInteractorTestSuite) TestOK() {
+	suite.mfaTokenGenerator.On("ValidateToken", "mfa").Return(&suite.user, suite.session(), nil)
+	suite.loginThrottler.On("Check", "a@b.com", "127.0.0.1").Return(time.Duration(0), nil)
+	suite.totpAuthenticator.On("Verify", suite.user, "123456").Return(true, nil)
+	suite.loginThrottler.On("Succeed", "a@b.com").Return(nil)
+	suite.sessionRepository.On("Activate", "sessionId").Return(nil)
+	suite.accessTokenGenerator.On("GenerateToken", suite.activeSession(), services.AccessTokenDuration).Return("access", nil)
+	suite.refreshTokenIssuer.On("Issue", suite.activeSession()).Return("refresh", nil)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+
+	expected := response.Login{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    900,
+	}
+	suite.Equal(expected, r)
+}
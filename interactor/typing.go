@@ -0,0 +1,69 @@
+package interactor
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/jonboulle/clockwork"
+	"github.com/kataras/iris"
+	"time"
+)
+
+// Time after which a typing indicator expires if no new one is received
+const TypingExpiry = 5 * time.Second
+
+// Interface used mainly for Unit testing
+type TypingInteractor interface {
+	Call(request.Typing) response.Response
+}
+
+// Notifies the recipient that the user is typing. Nothing is stored
+type Typing struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient services.PubsubClient `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewTypingInteractor() *Typing {
+	return &Typing{}
+}
+
+func (i Typing) Call(request request.Typing) response.Response {
+	// Find the destination user or return an error
+	to, err := i.UserRepository.GetUserByEmail(request.To)
+	if err == repository.UserNotFoundError {
+		error := response.NewError(iris.StatusUnprocessableEntity)
+		error.AddDetail("to", "notExists")
+		return error
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	res := response.Typing{
+		From:      request.From.Email,
+		To:        to.Email,
+		ExpiresAt: i.Clock.Now().Add(TypingExpiry),
+	}
+
+	// Notify the recipient
+	event, err := entity.NewEvent("typing", []string{to.Email}, res)
+	if err == nil {
+		err = i.PubsubClient.PublishEvent(event)
+	}
+	if err != nil {
+		// TODO: do proper logging
+		fmt.Println(err.Error())
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return res
+}
@@ -0,0 +1,118 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/jonboulle/clockwork"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type TypingInteractorTestSuite struct {
+	suite.Suite
+	interactor     *Typing
+	userRepository *mocks.UserRepository
+	pubsubClient   *mocks.PubsubClient
+	clock          clockwork.FakeClock
+}
+
+func TestTypingInteractor(t *testing.T) {
+	suite.Run(t, new(TypingInteractorTestSuite))
+}
+
+func (suite *TypingInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewTypingInteractor()
+}
+
+func (suite *TypingInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.pubsubClient = &mocks.PubsubClient{}
+	suite.clock = clockwork.NewFakeClockAt(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.PubsubClient = suite.pubsubClient
+	suite.interactor.Clock = suite.clock
+}
+
+func (suite *TypingInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.pubsubClient.AssertExpectations(suite.T())
+}
+
+func (suite *TypingInteractorTestSuite) getValidRequest() request.Typing {
+	return request.Typing{
+		From: entity.User{
+			Email: "a@b.com",
+		},
+		To: "b@b.com",
+	}
+}
+
+func (suite *TypingInteractorTestSuite) TestToNotFound() {
+	request := suite.getValidRequest()
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(nil, repository.UserNotFoundError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("to", "notExists")
+
+	suite.Equal(expected, r)
+}
+
+func (suite *TypingInteractorTestSuite) TestGetToAnError() {
+	request := suite.getValidRequest()
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *TypingInteractorTestSuite) TestPublishAnError() {
+	request := suite.getValidRequest()
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{Email: request.To}, nil)
+	suite.pubsubClient.On("PublishEvent", mock.AnythingOfType("entity.Event")).Return(assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *TypingInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+
+	suite.userRepository.On("GetUserByEmail", request.To).Return(&entity.User{Email: request.To}, nil)
+
+	var published entity.Event
+	suite.pubsubClient.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		published = event
+		return true
+	})).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Require().NotNil(r)
+
+	suite.Equal(response.Typing{
+		From:      "a@b.com",
+		To:        "b@b.com",
+		ExpiresAt: suite.clock.Now().Add(TypingExpiry),
+	}, r)
+
+	suite.Equal("typing", published.Type)
+	suite.Equal([]string{"b@b.com"}, published.To)
+	suite.JSONEq(`{"from": "a@b.com", "to": "b@b.com", "expiresAt": "2017-01-01T00:00:05Z"}`, string(published.Data))
+}
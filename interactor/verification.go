@@ -0,0 +1,108 @@
+package interactor
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mail"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris"
+)
+
+// Subject of the email verification emails
+const emailVerificationSubject = "Verify your email"
+
+// Sends the verification link to the email of the user
+func sendVerificationEmail(verifier services.EmailVerifier, sender mail.Sender, user entity.User) error {
+	link, err := verifier.Link(user)
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(mail.Message{
+		To:      user.Email,
+		Subject: emailVerificationSubject,
+		Body:    emailVerificationBody(link),
+	})
+}
+
+// Returns the body of the email verification email
+func emailVerificationBody(link string) string {
+	return fmt.Sprintf(
+		"Open the following link to verify your email. It expires in %d hours.\r\n\r\n"+
+			"%s\r\n\r\n"+
+			"If you didn't sign up, you can ignore this email.\r\n",
+		int(services.EmailVerificationDuration.Hours()), link,
+	)
+}
+
+// Interface used mainly for Unit testing
+type VerifyEmailInteractor interface {
+	Call(request.VerifyEmail) response.Response
+}
+
+// Marks the email of the user as verified, given the token of the link sent by email
+type VerifyEmail struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	EmailVerifier services.EmailVerifier `inject:""`
+}
+
+func NewVerifyEmailInteractor() *VerifyEmail {
+	return &VerifyEmail{}
+}
+
+func (i VerifyEmail) Call(request request.VerifyEmail) response.Response {
+	user, err := i.EmailVerifier.Verify(request.Token)
+	if err == services.InvalidEmailVerificationTokenError {
+		return response.NewError(iris.StatusUnauthorized)
+	}
+	if err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	// The link can be followed more than once
+	if user.EmailVerified {
+		return response.NoContentResponse{}
+	}
+
+	if _, err := i.UserRepository.SetEmailVerified(user.Id); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.NoContentResponse{}
+}
+
+// Interface used mainly for Unit testing
+type ResendVerificationInteractor interface {
+	Call(request.ResendVerification) response.Response
+}
+
+// Sends a new verification link to the current user, eg. when the first one has expired
+type ResendVerification struct {
+	// Injected via DI
+	EmailVerifier services.EmailVerifier `inject:""`
+
+	// Injected via DI
+	MailSender mail.Sender `inject:""`
+}
+
+func NewResendVerificationInteractor() *ResendVerification {
+	return &ResendVerification{}
+}
+
+func (i ResendVerification) Call(request request.ResendVerification) response.Response {
+	if request.User.EmailVerified {
+		return response.NewError(iris.StatusConflict)
+	}
+
+	if err := sendVerificationEmail(i.EmailVerifier, i.MailSender, request.User); err != nil {
+		return response.NewError(iris.StatusInternalServerError)
+	}
+
+	return response.NoContentResponse{}
+}
@@ -0,0 +1,166 @@
+package interactor
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mail"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/request"
+	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/kataras/iris/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type VerifyEmailInteractorTestSuite struct {
+	suite.Suite
+	interactor     *VerifyEmail
+	userRepository *mocks.UserRepository
+	emailVerifier  *mocks.EmailVerifier
+	user           *entity.User
+}
+
+func TestVerifyEmailInteractor(t *testing.T) {
+	suite.Run(t, new(VerifyEmailInteractorTestSuite))
+}
+
+func (suite *VerifyEmailInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewVerifyEmailInteractor()
+}
+
+func (suite *VerifyEmailInteractorTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.emailVerifier = &mocks.EmailVerifier{}
+	suite.user = &entity.User{Id: "userId", Email: "a@b.com"}
+
+	suite.interactor.UserRepository = suite.userRepository
+	suite.interactor.EmailVerifier = suite.emailVerifier
+}
+
+func (suite *VerifyEmailInteractorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.emailVerifier.AssertExpectations(suite.T())
+}
+
+func (suite *VerifyEmailInteractorTestSuite) getValidRequest() request.VerifyEmail {
+	return request.VerifyEmail{
+		Token: "token",
+	}
+}
+
+func (suite *VerifyEmailInteractorTestSuite) TestInvalidToken() {
+	suite.emailVerifier.On("Verify", "token").Return(nil, services.InvalidEmailVerificationTokenError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusUnauthorized), r)
+}
+
+func (suite *VerifyEmailInteractorTestSuite) TestVerifierAnyError() {
+	suite.emailVerifier.On("Verify", "token").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *VerifyEmailInteractorTestSuite) TestAlreadyVerified() {
+	suite.user.EmailVerified = true
+	suite.emailVerifier.On("Verify", "token").Return(suite.user, nil)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NoContentResponse{}, r)
+}
+
+func (suite *VerifyEmailInteractorTestSuite) TestRepositoryAnyError() {
+	suite.emailVerifier.On("Verify", "token").Return(suite.user, nil)
+	suite.userRepository.On("SetEmailVerified", "userId").Return(nil, assert.AnError)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *VerifyEmailInteractorTestSuite) TestOK() {
+	suite.emailVerifier.On("Verify", "token").Return(suite.user, nil)
+	suite.userRepository.On("SetEmailVerified", "userId").Return(&entity.User{}, nil)
+
+	r := suite.interactor.Call(suite.getValidRequest())
+	suite.Equal(response.NoContentResponse{}, r)
+}
+
+type ResendVerificationInteractorTestSuite struct {
+	suite.Suite
+	interactor    *ResendVerification
+	emailVerifier *mocks.EmailVerifier
+	sender        *mocks.Sender
+}
+
+func TestResendVerificationInteractor(t *testing.T) {
+	suite.Run(t, new(ResendVerificationInteractorTestSuite))
+}
+
+func (suite *ResendVerificationInteractorTestSuite) SetupSuite() {
+	suite.interactor = NewResendVerificationInteractor()
+}
+
+func (suite *ResendVerificationInteractorTestSuite) SetupTest() {
+	suite.emailVerifier = &mocks.EmailVerifier{}
+	suite.sender = &mocks.Sender{}
+
+	suite.interactor.EmailVerifier = suite.emailVerifier
+	suite.interactor.MailSender = suite.sender
+}
+
+func (suite *ResendVerificationInteractorTestSuite) TearDownTest() {
+	suite.emailVerifier.AssertExpectations(suite.T())
+	suite.sender.AssertExpectations(suite.T())
+}
+
+func (suite *ResendVerificationInteractorTestSuite) getValidRequest() request.ResendVerification {
+	return request.ResendVerification{
+		User: entity.User{Id: "userId", Email: "a@b.com"},
+	}
+}
+
+func (suite *ResendVerificationInteractorTestSuite) TestAlreadyVerified() {
+	request := suite.getValidRequest()
+	request.User.EmailVerified = true
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusConflict), r)
+}
+
+func (suite *ResendVerificationInteractorTestSuite) TestLinkAnyError() {
+	request := suite.getValidRequest()
+	suite.emailVerifier.On("Link", request.User).Return("", assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ResendVerificationInteractorTestSuite) TestSendAnyError() {
+	request := suite.getValidRequest()
+	suite.emailVerifier.On("Link", request.User).Return("http://localhost/?verify=token", nil)
+	suite.sender.On("Send", mock.Anything).Return(assert.AnError)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NewError(httptest.StatusInternalServerError), r)
+}
+
+func (suite *ResendVerificationInteractorTestSuite) TestOK() {
+	request := suite.getValidRequest()
+	suite.emailVerifier.On("Link", request.User).Return("http://localhost/?verify=token", nil)
+
+	var sent mail.Message
+	suite.sender.On("Send", mock.MatchedBy(func(message mail.Message) bool {
+		sent = message
+		return true
+	})).Return(nil)
+
+	r := suite.interactor.Call(request)
+	suite.Equal(response.NoContentResponse{}, r)
+
+	suite.Equal("a@b.com", sent.To)
+	suite.Equal(emailVerificationSubject, sent.Subject)
+	suite.Contains(sent.Body, "\r\n\r\nhttp://localhost/?verify=token\r\n\r\n")
+}
@@ -24,8 +24,8 @@ func NewWsTokenInteractor() *WsToken {
 }
 
 func (i WsToken) Call(request request.CreateWsToken) response.Response {
-	// Generates a new token for the given (already validated) user
-	token, err := i.WsTokenGenerator.GenerateToken(request.User, time.Second*30)
+	// Generates a new token for the session of the given (already validated) user
+	token, err := i.WsTokenGenerator.GenerateToken(request.Session, time.Second*30)
 	if err != nil {
 		return response.NewError(iris.StatusInternalServerError)
 	}
@@ -41,13 +41,16 @@ func (suite *WsTokenInteractorTestSuite) getValidRequest() request.CreateWsToken
 		User: entity.User{
 			Email: "test@test.com",
 		},
+		Session: entity.Session{
+			Id: "sessionId",
+		},
 	}
 }
 
 func (suite *WsTokenInteractorTestSuite) TestGeneratorAnyError() {
 	request := suite.getValidRequest()
 
-	suite.generator.On("GenerateToken", request.User, time.Second*30).Return("", assert.AnError)
+	suite.generator.On("GenerateToken", request.Session, time.Second*30).Return("", assert.AnError)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
@@ -57,7 +60,7 @@ func (suite *WsTokenInteractorTestSuite) TestGeneratorAnyError() {
 func (suite *WsTokenInteractorTestSuite) TestOK() {
 	request := suite.getValidRequest()
 
-	suite.generator.On("GenerateToken", request.User, time.Second*30).Return("token", nil)
+	suite.generator.On("GenerateToken", request.Session, time.Second*30).Return("token", nil)
 
 	r := suite.interactor.Call(request)
 	suite.Require().NotNil(r)
@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Writes the emails to a file or to the standard output instead of sending them. Used for local development
+type Log struct {
+	lock sync.Mutex
+	w    io.Writer
+	from string
+}
+
+func NewLogSender(w io.Writer, from string) *Log {
+	return &Log{
+		w:    w,
+		from: from,
+	}
+}
+
+// Writes the message, followed by a separator line
+func (s *Log) Send(message Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, err := s.w.Write(format(s.from, message, time.Now())); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprint(s.w, "\r\n----\r\n")
+	return err
+}
@@ -0,0 +1,48 @@
+// The package mail sends the emails of the application, eg. the password reset tokens.
+//
+// The Sender implementations only need to deliver plain text messages to a single recipient
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Plain text email
+type Message struct {
+	// Recipient address
+	To string
+
+	// Subject line
+	Subject string
+
+	// Plain text body
+	Body string
+}
+
+// Interface used mainly for Unit testing
+type Sender interface {
+	Send(Message) error
+}
+
+// Formats the message as a RFC 5322 email, with the given sender
+func format(from string, message Message, date time.Time) []byte {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", header(from))
+	fmt.Fprintf(&buffer, "To: %s\r\n", header(message.To))
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", header(message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("\r\n")
+	buffer.WriteString(message.Body)
+
+	return buffer.Bytes()
+}
+
+// Removes the line breaks from a header value, so that no other header can be injected
+func header(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,94 @@
+package mail
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var _ Sender = &Log{}
+var _ Sender = &SMTP{}
+var _ Sender = &Outbox{}
+
+type MailTestSuite struct {
+	suite.Suite
+}
+
+func TestMail(t *testing.T) {
+	suite.Run(t, new(MailTestSuite))
+}
+
+func (suite *MailTestSuite) TestFormat() {
+	date := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	message := Message{
+		To:      "a@b.com",
+		Subject: "Subject\r\nBcc: c@d.com",
+		Body:    "Body",
+	}
+
+	formatted := string(format("noreply@b.com", message, date))
+	suite.Equal(strings.Join([]string{
+		"From: noreply@b.com",
+		"To: a@b.com",
+		"Subject: SubjectBcc: c@d.com",
+		"Date: Sun, 01 Jan 2017 00:00:00 +0000",
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		"Body",
+	}, "\r\n"), formatted)
+}
+
+func (suite *MailTestSuite) TestLogSend() {
+	var buffer bytes.Buffer
+	sender := NewLogSender(&buffer, "noreply@b.com")
+
+	err := sender.Send(Message{To: "a@b.com", Subject: "First", Body: "Body"})
+	suite.Require().NoError(err)
+	err = sender.Send(Message{To: "a@b.com", Subject: "Second", Body: "Body"})
+	suite.Require().NoError(err)
+
+	messages := strings.Split(buffer.String(), "\r\n----\r\n")
+	suite.Require().Len(messages, 3)
+	suite.Contains(messages[0], "Subject: First\r\n")
+	suite.Contains(messages[1], "Subject: Second\r\n")
+	suite.Empty(messages[2])
+}
+
+func (suite *MailTestSuite) TestOutboxSend() {
+	dir, err := ioutil.TempDir("", "outbox")
+	suite.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	sender, err := NewOutboxSender(filepath.Join(dir, "mails"), "noreply@b.com")
+	suite.Require().NoError(err)
+
+	err = sender.Send(Message{To: "a@b.com", Subject: "First", Body: "Body"})
+	suite.Require().NoError(err)
+	err = sender.Send(Message{To: "a@b.com", Subject: "Second", Body: "Body"})
+	suite.Require().NoError(err)
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "mails"))
+	suite.Require().NoError(err)
+	suite.Require().Len(files, 2)
+
+	first, err := ioutil.ReadFile(filepath.Join(dir, "mails", files[0].Name()))
+	suite.Require().NoError(err)
+	suite.Contains(string(first), "Subject: First\r\n")
+	suite.True(strings.HasSuffix(files[0].Name(), ".eml"))
+
+	second, err := ioutil.ReadFile(filepath.Join(dir, "mails", files[1].Name()))
+	suite.Require().NoError(err)
+	suite.Contains(string(second), "Subject: Second\r\n")
+}
+
+func (suite *MailTestSuite) TestNewSMTPSenderBadAddr() {
+	sender, err := NewSMTPSender("localhost", "noreply@b.com", "", "")
+	suite.Nil(sender)
+	suite.Error(err)
+}
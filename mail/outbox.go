@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Writes every email to its own .eml file in a directory instead of sending it. Used for local development, the
+// files can be opened with any mail client
+type Outbox struct {
+	lock  sync.Mutex
+	dir   string
+	from  string
+	count int
+}
+
+// Creates the sender, creating dir if it doesn't exist
+func NewOutboxSender(dir, from string) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &Outbox{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+// Writes the message to a new file, named after the current time so that the files are listed in order
+func (s *Outbox) Send(message Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	s.count++
+	name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405.000000000"), s.count)
+
+	return ioutil.WriteFile(filepath.Join(s.dir, name), format(s.from, message, now), 0600)
+}
@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// Sends the emails through a SMTP server
+type SMTP struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// Creates the sender. addr is the host:port of the server. The PLAIN authentication is used if username is not empty
+func NewSMTPSender(addr, from, username, password string) (*SMTP, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SMTP{
+		addr: addr,
+		from: from,
+	}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return s, nil
+}
+
+// Sends the message, using STARTTLS if the server supports it
+func (s SMTP) Send(message Message) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{message.To}, format(s.from, message, time.Now()))
+}
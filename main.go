@@ -3,19 +3,44 @@ package main
 import (
 	"cloud.google.com/go/datastore"
 	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/storage"
 	"context"
 	"fmt"
 	"github.com/asiragusa/wschat/application"
+	"github.com/asiragusa/wschat/blob"
+	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/mail"
+	"github.com/asiragusa/wschat/services"
+	"github.com/asiragusa/wschat/sqlstore"
 	"github.com/kataras/iris"
 	"github.com/kataras/iris/middleware/recover"
 	"github.com/urfave/cli"
 	"os"
+	"time"
 )
 
 func main() {
 	app := cli.NewApp()
 
 	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "backend",
+			Value:  application.DatastoreBackend,
+			Usage:  "Storage and pubsub backend: datastore, memory or sqlite",
+			EnvVar: "BACKEND",
+		},
+		cli.StringFlag{
+			Name:   "sqlitePath",
+			Value:  "wschat.db",
+			Usage:  "Path of the SQLite database, used by the sqlite backend",
+			EnvVar: "SQLITE_PATH",
+		},
+		cli.StringFlag{
+			Name:   "addr",
+			Value:  ":80",
+			Usage:  "Listen address",
+			EnvVar: "ADDR",
+		},
 		cli.StringFlag{
 			Name:   "projectID",
 			Value:  "test",
@@ -25,15 +50,134 @@ func main() {
 		cli.StringFlag{
 			Name:   "jwtSecret",
 			Value:  "default",
-			Usage:  "JWT secret",
+			Usage:  "JWT secret, used to sign the tokens with HS256 if no jwtKey is given",
 			EnvVar: "JWT_SECRET",
 		},
+		cli.StringSliceFlag{
+			Name: "jwtKey",
+			Usage: "PEM file of a RSA or EC key signing the tokens with RS256 or ES256. Can be repeated: the first key " +
+				"signs the new tokens, the others only validate them and can be public keys",
+			EnvVar: "JWT_KEYS",
+		},
 		cli.StringFlag{
 			Name:   "jwtIssuer",
 			Value:  "http://localhost",
 			Usage:  "Jwt issuer eg. http://myapp.com",
 			EnvVar: "JWT_ISSUER",
 		},
+		cli.StringFlag{
+			Name:   "blobStorage",
+			Value:  "filesystem",
+			Usage:  "Storage of the attachments: filesystem or gcs",
+			EnvVar: "BLOB_STORAGE",
+		},
+		cli.StringFlag{
+			Name:   "blobDir",
+			Value:  "attachments",
+			Usage:  "Directory of the attachments, used by the filesystem blob storage",
+			EnvVar: "BLOB_DIR",
+		},
+		cli.StringFlag{
+			Name:   "gcsBucket",
+			Usage:  "Google cloud storage bucket of the attachments, used by the gcs blob storage",
+			EnvVar: "GCS_BUCKET",
+		},
+		cli.StringFlag{
+			Name:   "mailSender",
+			Value:  "log",
+			Usage:  "Sender of the emails: log, outbox or smtp",
+			EnvVar: "MAIL_SENDER",
+		},
+		cli.StringFlag{
+			Name:   "mailFrom",
+			Value:  "noreply@localhost",
+			Usage:  "Sender address of the emails",
+			EnvVar: "MAIL_FROM",
+		},
+		cli.StringFlag{
+			Name:   "mailLog",
+			Usage:  "File the emails are appended to, used by the log mail sender. The standard output if empty",
+			EnvVar: "MAIL_LOG",
+		},
+		cli.StringFlag{
+			Name:   "mailOutbox",
+			Value:  "outbox",
+			Usage:  "Directory the emails are written to, one file each, used by the outbox mail sender",
+			EnvVar: "MAIL_OUTBOX",
+		},
+		cli.StringFlag{
+			Name:   "smtpAddr",
+			Value:  "localhost:25",
+			Usage:  "SMTP server host:port, used by the smtp mail sender",
+			EnvVar: "SMTP_ADDR",
+		},
+		cli.StringFlag{
+			Name:   "smtpUsername",
+			Usage:  "SMTP username, used by the smtp mail sender. No authentication if empty",
+			EnvVar: "SMTP_USERNAME",
+		},
+		cli.StringFlag{
+			Name:   "smtpPassword",
+			Usage:  "SMTP password, used by the smtp mail sender",
+			EnvVar: "SMTP_PASSWORD",
+		},
+		cli.BoolFlag{
+			Name:   "requireVerifiedEmail",
+			Usage:  "Refuse to let the users send messages until they have verified their email",
+			EnvVar: "REQUIRE_VERIFIED_EMAIL",
+		},
+		cli.StringFlag{
+			Name:   "oidcIssuer",
+			Usage:  "Issuer of the OpenID Connect provider eg. https://accounts.example.com. The login with OpenID Connect is disabled if empty",
+			EnvVar: "OIDC_ISSUER",
+		},
+		cli.StringFlag{
+			Name:   "oidcClientId",
+			Usage:  "Client ID registered at the OpenID Connect provider",
+			EnvVar: "OIDC_CLIENT_ID",
+		},
+		cli.StringFlag{
+			Name:   "oidcClientSecret",
+			Usage:  "Client secret registered at the OpenID Connect provider. Empty for a public client",
+			EnvVar: "OIDC_CLIENT_SECRET",
+		},
+		cli.StringFlag{
+			Name:   "oidcRedirectUrl",
+			Usage:  "Redirect URL registered at the OpenID Connect provider. jwtIssuer + /?oidc=callback if empty",
+			EnvVar: "OIDC_REDIRECT_URL",
+		},
+		cli.DurationFlag{
+			Name:   "editWindow",
+			Value:  interactor.DefaultEditWindow,
+			Usage:  "Duration after the creation during which a message can be edited",
+			EnvVar: "EDIT_WINDOW",
+		},
+	}
+
+	app.Commands = []cli.Command{
+		{
+			Name:  "sweep",
+			Usage: "Delete the pubsub subscriptions left behind by the dead server instances",
+			Flags: []cli.Flag{
+				cli.DurationFlag{
+					Name:  "staleAfter",
+					Value: services.DefaultStaleAfter,
+					Usage: "Delete the subscriptions not seen for this duration",
+				},
+			},
+			Action: cliSweep,
+		},
+		{
+			Name:  "backfill",
+			Usage: "Update the data derived from the messages and the users stored by the previous versions",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "verifiedBefore",
+					Usage: "Mark as verified the emails of the users created before this RFC 3339 time",
+				},
+			},
+			Action: cliBackfill,
+		},
 	}
 
 	app.Action = cliMain
@@ -60,24 +204,131 @@ func getPubsubClient(projectID string) (*pubsub.Client, error) {
 	return client, nil
 }
 
-func cliMain(c *cli.Context) error {
-	datastoreClient, err := getDatastoreClient(c.String("projectID"))
+// Creates the storage of the attachments from the global flags
+func getBlobStorage(c *cli.Context) (blob.Storage, error) {
+	switch c.String("blobStorage") {
+	case "filesystem":
+		return blob.NewFilesystemStorage(c.String("blobDir"))
+	case "gcs":
+		client, err := storage.NewClient(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return blob.NewGCSStorage(client, c.String("gcsBucket")), nil
+	default:
+		return nil, fmt.Errorf("Unknown blob storage %s", c.String("blobStorage"))
+	}
+}
+
+// Creates the sender of the emails from the global flags
+func getMailSender(c *cli.Context) (mail.Sender, error) {
+	switch c.String("mailSender") {
+	case "log":
+		if c.String("mailLog") == "" {
+			return mail.NewLogSender(os.Stdout, c.String("mailFrom")), nil
+		}
+		file, err := os.OpenFile(c.String("mailLog"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return mail.NewLogSender(file, c.String("mailFrom")), nil
+	case "outbox":
+		return mail.NewOutboxSender(c.String("mailOutbox"), c.String("mailFrom"))
+	case "smtp":
+		return mail.NewSMTPSender(c.String("smtpAddr"), c.String("mailFrom"), c.String("smtpUsername"), c.String("smtpPassword"))
+	default:
+		return nil, fmt.Errorf("Unknown mail sender %s", c.String("mailSender"))
+	}
+}
+
+// Loads the keys of the JWT tokens from the global flags
+func getJwtKeys(c *cli.Context) ([]*services.Key, error) {
+	keys := []*services.Key{}
+	for _, path := range c.StringSlice("jwtKey") {
+		key, err := services.LoadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Creates the application from the global flags. Exits on error
+func newApplication(c *cli.Context) *application.Application {
+	appConfig := &application.AppConfig{
+		Backend:              c.String("backend"),
+		JwtSecret:            c.String("jwtSecret"),
+		JwtIssuer:            c.String("jwtIssuer"),
+		RequireVerifiedEmail: c.Bool("requireVerifiedEmail"),
+		EditWindow:           c.Duration("editWindow"),
+	}
+
+	jwtKeys, err := getJwtKeys(c)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	if len(jwtKeys) == 0 && appConfig.JwtSecret == "default" {
+		fmt.Fprintln(os.Stderr, "Warning: the tokens are signed with the default JWT secret, set --jwtKey or --jwtSecret")
+	}
+	appConfig.JwtKeys = jwtKeys
+
+	if c.String("oidcIssuer") != "" {
+		appConfig.Oidc = &services.OidcConfig{
+			Issuer:       c.String("oidcIssuer"),
+			ClientId:     c.String("oidcClientId"),
+			ClientSecret: c.String("oidcClientSecret"),
+			RedirectUrl:  c.String("oidcRedirectUrl"),
+		}
+		if appConfig.Oidc.RedirectUrl == "" {
+			appConfig.Oidc.RedirectUrl = appConfig.JwtIssuer + "/?oidc=callback"
+		}
+	}
 
-	pubsubClient, err := getPubsubClient(c.String("projectID"))
+	blobStorage, err := getBlobStorage(c)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	appConfig.BlobStorage = blobStorage
 
-	appConfig := &application.AppConfig{
-		JwtSecret:       c.String("jwtSecret"),
-		JwtIssuer:       c.String("jwtIssuer"),
-		DatastoreClient: datastoreClient,
-		PubsubClient:    pubsubClient,
+	mailSender, err := getMailSender(c)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	appConfig.MailSender = mailSender
+
+	switch appConfig.Backend {
+	case application.MemoryBackend:
+	case application.SqliteBackend:
+		db, err := sqlstore.Open(c.String("sqlitePath"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		appConfig.DB = db
+	case application.DatastoreBackend:
+		datastoreClient, err := getDatastoreClient(c.String("projectID"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		pubsubClient, err := getPubsubClient(c.String("projectID"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		appConfig.DatastoreClient = datastoreClient
+		appConfig.PubsubClient = pubsubClient
+	default:
+		fmt.Fprintln(os.Stderr, "Unknown backend", appConfig.Backend)
+		os.Exit(1)
 	}
 
 	app, err := application.NewApplication(appConfig)
@@ -86,9 +337,79 @@ func cliMain(c *cli.Context) error {
 		os.Exit(1)
 	}
 
+	return app
+}
+
+func cliMain(c *cli.Context) error {
+	app := newApplication(c)
+
+	// Delete the subscriptions left behind by the dead instances
+	if reaper := app.GetReaper(); reaper != nil {
+		go reaper.Run(context.Background(), services.ReapInterval)
+	}
+
 	router := app.GetRouter()
 	router.Use(recover.New())
-	router.Run(iris.Addr(":80"))
+	router.Run(iris.Addr(c.String("addr")))
+
+	return nil
+}
+
+func cliSweep(c *cli.Context) error {
+	app := newApplication(c.Parent())
+
+	reaper := app.GetReaper()
+	if reaper == nil {
+		fmt.Fprintln(os.Stderr, "The sweep command needs the datastore backend")
+		os.Exit(1)
+	}
+	reaper.StaleAfter = c.Duration("staleAfter")
+
+	deleted, err := reaper.Sweep()
+	fmt.Println("Deleted", deleted, "stale subscriptions")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+func cliBackfill(c *cli.Context) error {
+	app := newApplication(c.Parent())
+
+	var before time.Time
+	if c.String("verifiedBefore") != "" {
+		var err error
+		if before, err = time.Parse(time.RFC3339, c.String("verifiedBefore")); err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid verifiedBefore time:", err)
+			os.Exit(1)
+		}
+	}
+
+	messages := app.GetDatastoreMessages()
+	if messages == nil {
+		fmt.Fprintln(os.Stderr, "The backfill command needs the datastore backend")
+		os.Exit(1)
+	}
+
+	count, err := messages.Backfill()
+	fmt.Println("Backfilled", count, "messages")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if before.IsZero() {
+		return nil
+	}
+
+	count, err = app.GetDatastoreUsers().Backfill(before)
+	fmt.Println("Verified the emails of", count, "users")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	return nil
 }
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+)
+
+// In-memory implementation of repository.AttachmentRepository
+type Attachment struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewAttachmentRepository() *Attachment {
+	return &Attachment{}
+}
+
+// Fetch an attachment by ID
+func (r Attachment) GetById(id string) (*entity.Attachment, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	attachment, ok := r.Store.attachments[id]
+	if !ok {
+		return nil, repository.AttachmentNotFoundError
+	}
+
+	return &attachment, nil
+}
+
+// Creates a new attachment, not attached to any message
+func (r Attachment) Create(owner, name, mimeType string, size int64, checksum string) (*entity.Attachment, error) {
+	attachment := entity.Attachment{
+		Id:        uuid.NewV4().String(),
+		Owner:     owner,
+		Name:      name,
+		MimeType:  mimeType,
+		Size:      size,
+		Checksum:  checksum,
+		CreatedAt: r.Clock.Now(),
+	}
+
+	r.Store.Lock()
+	r.Store.attachments[attachment.Id] = attachment
+	r.Store.Unlock()
+
+	return &attachment, nil
+}
+
+// Attaches the attachment to the message. Attaching it again to the same message is a no-op
+func (r Attachment) Attach(id, messageId string) (*entity.Attachment, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	attachment, ok := r.Store.attachments[id]
+	if !ok {
+		return nil, repository.AttachmentNotFoundError
+	}
+
+	if attachment.MessageId != "" && attachment.MessageId != messageId {
+		return nil, repository.AttachmentAttachedError
+	}
+	attachment.MessageId = messageId
+	r.Store.attachments[id] = attachment
+
+	return &attachment, nil
+}
+
+// Detaches the attachment from the message, so that it can be sent again. It is a no-op if the attachment is not
+// attached to the message
+func (r Attachment) Detach(id, messageId string) error {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	attachment, ok := r.Store.attachments[id]
+	if !ok {
+		return repository.AttachmentNotFoundError
+	}
+
+	if attachment.MessageId == messageId {
+		attachment.MessageId = ""
+		r.Store.attachments[id] = attachment
+	}
+
+	return nil
+}
+
+// Deletes the attachment. Deleting a missing attachment is a no-op
+func (r Attachment) Delete(id string) error {
+	r.Store.Lock()
+	delete(r.Store.attachments, id)
+	r.Store.Unlock()
+
+	return nil
+}
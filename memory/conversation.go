@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+	"sort"
+)
+
+// In-memory implementation of repository.ConversationRepository
+type Conversation struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewConversationRepository() *Conversation {
+	return &Conversation{}
+}
+
+// Returns a copy of the conversation
+func copyConversation(conversation entity.Conversation) entity.Conversation {
+	conversation.Members = copyStrings(conversation.Members)
+	return conversation
+}
+
+// Fetch a conversation by ID
+func (r Conversation) GetById(id string) (*entity.Conversation, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	conversation, ok := r.Store.conversations[id]
+	if !ok {
+		return nil, repository.ConversationNotFoundError
+	}
+
+	conversation = copyConversation(conversation)
+	return &conversation, nil
+}
+
+// Fetch all the conversations the user is a member of, sorted by CreatedAt
+func (r Conversation) AllWithMember(email string) ([]entity.Conversation, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	conversations := []entity.Conversation{}
+	for _, conversation := range r.Store.conversations {
+		if conversation.HasMember(email) {
+			conversations = append(conversations, copyConversation(conversation))
+		}
+	}
+
+	sort.Slice(conversations, func(i, j int) bool {
+		return conversations[i].CreatedAt.Before(conversations[j].CreatedAt)
+	})
+
+	return conversations, nil
+}
+
+// Creates a new conversation. The creator is always added to the members
+func (r Conversation) Create(name, createdBy string, members []string) (*entity.Conversation, error) {
+	conversation := entity.Conversation{
+		Id:        uuid.NewV4().String(),
+		Name:      name,
+		Members:   uniqueMembers(append([]string{createdBy}, members...)),
+		CreatedBy: createdBy,
+		CreatedAt: r.Clock.Now(),
+	}
+
+	r.Store.Lock()
+	r.Store.conversations[conversation.Id] = copyConversation(conversation)
+	r.Store.Unlock()
+
+	return &conversation, nil
+}
+
+// Adds a member to the conversation. Adding an existing member is a no-op
+func (r Conversation) AddMember(id, email string) (*entity.Conversation, error) {
+	return r.update(id, func(conversation *entity.Conversation) {
+		conversation.Members = uniqueMembers(append(conversation.Members, email))
+	})
+}
+
+// Removes a member from the conversation. Removing a non member is a no-op
+func (r Conversation) RemoveMember(id, email string) (*entity.Conversation, error) {
+	return r.update(id, func(conversation *entity.Conversation) {
+		members := []string{}
+		for _, member := range conversation.Members {
+			if member != email {
+				members = append(members, member)
+			}
+		}
+		conversation.Members = members
+	})
+}
+
+// Renames the conversation
+func (r Conversation) Rename(id, name string) (*entity.Conversation, error) {
+	return r.update(id, func(conversation *entity.Conversation) {
+		conversation.Name = name
+	})
+}
+
+// Applies fn to the conversation and stores the result
+func (r Conversation) update(id string, fn func(*entity.Conversation)) (*entity.Conversation, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	conversation, ok := r.Store.conversations[id]
+	if !ok {
+		return nil, repository.ConversationNotFoundError
+	}
+
+	conversation = copyConversation(conversation)
+	fn(&conversation)
+	r.Store.conversations[id] = copyConversation(conversation)
+
+	return &conversation, nil
+}
+
+// Removes the duplicates from the members list, preserving the order
+func uniqueMembers(members []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, member := range members {
+		if seen[member] {
+			continue
+		}
+		seen[member] = true
+		unique = append(unique, member)
+	}
+	return unique
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+)
+
+// In-memory implementation of repository.DeliveryRepository
+type Delivery struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewDeliveryRepository() *Delivery {
+	return &Delivery{}
+}
+
+// Returns at most limit deliveries to the "to" user having a sequence number greater than seq, sorted by Seq
+func (r Delivery) AllAfter(to string, seq int64, limit int) ([]entity.Delivery, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	// The deliveries are appended, so they are already sorted by Seq
+	deliveries := []entity.Delivery{}
+	for _, delivery := range r.Store.deliveries[to] {
+		if len(deliveries) == limit {
+			break
+		}
+		if delivery.Seq > seq {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	return deliveries, nil
+}
+
+// Records the delivery of a message to the "to" user, allocating the next sequence number of the user
+func (r Delivery) Create(to, messageId string) (*entity.Delivery, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	delivery := entity.Delivery{
+		To:        to,
+		Seq:       int64(len(r.Store.deliveries[to]) + 1),
+		MessageId: messageId,
+		CreatedAt: r.Clock.Now(),
+	}
+	delivery.Id = fmt.Sprintf("%s/%d", to, delivery.Seq)
+
+	r.Store.deliveries[to] = append(r.Store.deliveries[to], delivery)
+
+	return &delivery, nil
+}
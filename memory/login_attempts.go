@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+// In-memory implementation of repository.LoginAttemptRepository
+type LoginAttempts struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewLoginAttemptRepository() *LoginAttempts {
+	return &LoginAttempts{}
+}
+
+// Fetches the failed login attempts of the key
+func (r LoginAttempts) Get(key string) (*entity.LoginAttempts, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	attempts, ok := r.Store.loginAttempts[key]
+	if !ok {
+		return nil, repository.LoginAttemptsNotFoundError
+	}
+
+	return &attempts, nil
+}
+
+// Counts a failed login attempt of the key. The count restarts if the last failure happened before since
+func (r LoginAttempts) RecordFailure(key string, since time.Time) (*entity.LoginAttempts, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	attempts, ok := r.Store.loginAttempts[key]
+	if !ok || attempts.LastFailureAt.Before(since) {
+		attempts = entity.LoginAttempts{Key: key}
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = r.Clock.Now()
+	r.Store.loginAttempts[key] = attempts
+
+	return &attempts, nil
+}
+
+// Forgets the failed login attempts of the key
+func (r LoginAttempts) Reset(key string) error {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	delete(r.Store.loginAttempts, key)
+	return nil
+}
@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/repository/repositorytest"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestLoginAttemptRepository(t *testing.T) {
+	suite.Run(t, &repositorytest.LoginAttemptSuite{
+		New: func(t *testing.T, clock clockwork.Clock) repository.LoginAttemptRepository {
+			r := NewLoginAttemptRepository()
+			r.Store = NewStore()
+			r.Clock = clock
+			return r
+		},
+	})
+}
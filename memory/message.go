@@ -0,0 +1,437 @@
+package memory
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// In-memory implementation of repository.MessageRepository
+type Message struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewMessageRepository() *Message {
+	return &Message{}
+}
+
+// Returns a copy of the message
+func copyMessage(message entity.Message) entity.Message {
+	message.Users = copyStrings(message.Users)
+	message.Terms = copyStrings(message.Terms)
+	if message.Reads != nil {
+		message.Reads = append([]entity.Receipt{}, message.Reads...)
+	}
+	if message.Revisions != nil {
+		message.Revisions = append([]entity.Revision{}, message.Revisions...)
+	}
+	if message.Attachments != nil {
+		message.Attachments = append([]entity.Attachment{}, message.Attachments...)
+	}
+	if message.Reactions != nil {
+		reactions := make([]entity.Reaction, len(message.Reactions))
+		for i, reaction := range message.Reactions {
+			reaction.Users = copyStrings(reaction.Users)
+			reactions[i] = reaction
+		}
+		message.Reactions = reactions
+	}
+	return message
+}
+
+// Fetch a message by ID
+func (r Message) GetById(id string) (*entity.Message, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	message, ok := r.Store.messages[id]
+	if !ok {
+		return nil, repository.MessageNotFoundError
+	}
+
+	message = copyMessage(message)
+	return &message, nil
+}
+
+// Fetch the messages by ID, in the same order. The messages not found are skipped
+func (r Message) GetByIds(ids []string) ([]entity.Message, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	messages := []entity.Message{}
+	for _, id := range ids {
+		if message, ok := r.Store.messages[id]; ok {
+			messages = append(messages, copyMessage(message))
+		}
+	}
+
+	return messages, nil
+}
+
+// Returns the messages matching the filter, from the newest to the oldest
+func (r Message) filter(fn func(entity.Message) bool) []entity.Message {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	messages := []entity.Message{}
+	for _, message := range r.Store.messages {
+		if fn(message) {
+			messages = append(messages, copyMessage(message))
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messageAfter(messages[i], messages[j])
+	})
+
+	return messages
+}
+
+// Returns true if a is newer than b. The Id is used to sort the messages created at the same time
+func messageAfter(a, b entity.Message) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.Id > b.Id
+	}
+	return a.CreatedAt.After(b.CreatedAt)
+}
+
+// Returns true if email belongs to the message users
+func hasUser(message entity.Message, email string) bool {
+	for _, user := range message.Users {
+		if user == email {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch all messages belonging to an user
+func (r Message) AllWithUser(email string) ([]entity.Message, error) {
+	messages := r.filter(func(message entity.Message) bool {
+		return hasUser(message, email)
+	})
+
+	// Sort by CreatedAt
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+// Encodes the position following a message in the list sorted from the newest to the oldest
+func encodeCursor(message entity.Message) string {
+	cursor := fmt.Sprintf("%d/%s", message.CreatedAt.UnixNano(), message.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+// Decodes a cursor created by encodeCursor. The returned message only contains Id and CreatedAt
+func decodeCursor(cursor string) (entity.Message, error) {
+	var message entity.Message
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return message, repository.InvalidCursorError
+	}
+
+	parts := strings.SplitN(string(decoded), "/", 2)
+	if len(parts) != 2 {
+		return message, repository.InvalidCursorError
+	}
+
+	nano, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return message, repository.InvalidCursorError
+	}
+
+	message.Id = parts[1]
+	message.CreatedAt = time.Unix(0, nano)
+	return message, nil
+}
+
+// Fetch a page of the messages belonging to an user, with the same semantics of repository.Message.PageWithUser
+func (r Message) PageWithUser(email string, options repository.MessagePageOptions) (*repository.MessagePage, error) {
+	var before, after entity.Message
+	var err error
+	if options.Before != "" {
+		if before, err = decodeCursor(options.Before); err != nil {
+			return nil, err
+		}
+	}
+	if options.After != "" {
+		if after, err = decodeCursor(options.After); err != nil {
+			return nil, err
+		}
+	}
+
+	messages := r.filter(func(message entity.Message) bool {
+		if !hasUser(message, email) {
+			return false
+		}
+		if options.With != "" && (message.ConversationId != "" || !hasUser(message, options.With)) {
+			return false
+		}
+		if options.ConversationId != "" && message.ConversationId != options.ConversationId {
+			return false
+		}
+		return true
+	})
+
+	page := &repository.MessagePage{}
+
+	if options.After != "" {
+		// Keep the messages newer than the cursor, which includes the message the cursor follows
+		end := 0
+		for end < len(messages) && !messageAfter(after, messages[end]) {
+			end++
+		}
+		messages = messages[:end]
+
+		page.NextCursor = options.After
+		if len(messages) > options.Limit {
+			first := len(messages) - options.Limit
+			page.PrevCursor = encodeCursor(messages[first-1])
+			messages = messages[first:]
+		}
+	} else {
+		if options.Before != "" {
+			// Skip the messages up to the one the cursor follows
+			start := 0
+			for start < len(messages) && !messageAfter(before, messages[start]) {
+				start++
+			}
+			messages = messages[start:]
+
+			page.PrevCursor = options.Before
+		}
+
+		if len(messages) > options.Limit {
+			messages = messages[:options.Limit]
+			page.NextCursor = encodeCursor(messages[len(messages)-1])
+		}
+	}
+
+	// The messages have been sorted from the newest, return them in chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	page.Messages = messages
+
+	return page, nil
+}
+
+// Search the messages belonging to an user containing all the terms, the most relevant first. Only the
+// repository.MaxSearchCandidates newest matches are ranked
+func (r Message) Search(email string, options repository.SearchOptions) ([]repository.SearchResult, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	// The messages missing the other terms have a zero score and are dropped by the ranking
+	messages := []entity.Message{}
+	if len(options.Terms) > 0 {
+		for id := range r.Store.terms[options.Terms[0]] {
+			if message := r.Store.messages[id]; hasUser(message, email) {
+				messages = append(messages, copyMessage(message))
+			}
+		}
+	}
+
+	return repository.Rank(repository.Candidates(messages, options), options), nil
+}
+
+// Returns the emails of the users having exchanged direct messages with the user, sorted
+func (r Message) Contacts(email string) ([]string, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	contacts := []string{}
+	for contact := range r.Store.contacts[email] {
+		contacts = append(contacts, contact)
+	}
+	sort.Strings(contacts)
+
+	return contacts, nil
+}
+
+// Records the users of a direct message as contacts of each other. The Store must be locked
+func (r Message) addContacts(message entity.Message) {
+	if message.ConversationId != "" || message.From == message.To {
+		return
+	}
+
+	for owner, contact := range map[string]string{message.From: message.To, message.To: message.From} {
+		if r.Store.contacts[owner] == nil {
+			r.Store.contacts[owner] = map[string]bool{}
+		}
+		r.Store.contacts[owner][contact] = true
+	}
+}
+
+// Replaces the terms of the prior version of the message with its current ones in the search index.
+// The Store must be locked
+func (r Message) index(prior, message entity.Message) {
+	for _, term := range prior.Terms {
+		delete(r.Store.terms[term], prior.Id)
+		if len(r.Store.terms[term]) == 0 {
+			delete(r.Store.terms, term)
+		}
+	}
+
+	for _, term := range message.Terms {
+		if r.Store.terms[term] == nil {
+			r.Store.terms[term] = map[string]bool{}
+		}
+		r.Store.terms[term][message.Id] = true
+	}
+}
+
+// Stores a new message
+func (r Message) create(message entity.Message) (*entity.Message, error) {
+	message.Id = uuid.NewV4().String()
+	message.CreatedAt = r.Clock.Now()
+	message.Index()
+
+	r.Store.Lock()
+	r.Store.messages[message.Id] = copyMessage(message)
+	r.index(entity.Message{}, message)
+	r.addContacts(message)
+	r.Store.Unlock()
+
+	return &message, nil
+}
+
+// Creates a new message
+func (r Message) Create(from, to, message string) (*entity.Message, error) {
+	return r.create(entity.Message{
+		From:    from,
+		To:      to,
+		Message: message,
+		Users:   []string{from, to},
+	})
+}
+
+// Creates a new message replying to another one
+func (r Message) CreateReply(from, to, message string, replyTo entity.Reply) (*entity.Message, error) {
+	return r.create(entity.Message{
+		From:    from,
+		To:      to,
+		Message: message,
+		Users:   []string{from, to},
+		ReplyTo: replyTo,
+	})
+}
+
+// Creates a new message in a conversation. members are the conversation members at the time of sending
+func (r Message) CreateInConversation(from, conversationId string, members []string, message string) (*entity.Message, error) {
+	return r.create(entity.Message{
+		ConversationId: conversationId,
+		From:           from,
+		Message:        message,
+		Users:          copyStrings(members),
+	})
+}
+
+// Marks the message as read by the user, at the current time. Marking an already read message is a no-op
+func (r Message) MarkRead(id, email string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		if message.ReadBy(email) == nil {
+			message.Reads = append(message.Reads, entity.Receipt{
+				User:   email,
+				ReadAt: r.Clock.Now(),
+			})
+		}
+		return nil
+	})
+}
+
+// Replaces the text of the message, keeping the prior one as a revision
+func (r Message) Edit(id, text string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.Edit(text, r.Clock.Now())
+		return nil
+	})
+}
+
+// Adds the attachments to the message
+func (r Message) Attach(id string, attachments []entity.Attachment) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.Attachments = append(message.Attachments, attachments...)
+		return nil
+	})
+}
+
+// Adds the reaction of the user with the emoji. Adding it twice is a no-op
+func (r Message) AddReaction(id, email, emoji string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.AddReaction(email, emoji)
+		return nil
+	})
+}
+
+// Removes the reaction of the user with the emoji, if any
+func (r Message) RemoveReaction(id, email, emoji string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.RemoveReaction(email, emoji)
+		return nil
+	})
+}
+
+// Removes the user from the message, hiding it from the user's history
+func (r Message) DeleteFor(id, email string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.DeleteFor(email)
+		return nil
+	})
+}
+
+// Deletes the message for everyone, leaving a tombstone in the history of its users
+func (r Message) DeleteForEveryone(id string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.Delete(r.Clock.Now())
+		return nil
+	})
+}
+
+// Deletes the message, undoing its creation before it is published. Deleting a missing message is a no-op
+func (r Message) Delete(id string) error {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	if stored, ok := r.Store.messages[id]; ok {
+		delete(r.Store.messages, id)
+		r.index(stored, entity.Message{})
+	}
+
+	return nil
+}
+
+// Applies fn to a copy of the message and stores it if fn doesn't return an error
+func (r Message) update(id string, fn func(*entity.Message) error) (*entity.Message, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	stored, ok := r.Store.messages[id]
+	if !ok {
+		return nil, repository.MessageNotFoundError
+	}
+
+	message := copyMessage(stored)
+	if err := fn(&message); err != nil {
+		return nil, err
+	}
+	r.Store.messages[id] = copyMessage(message)
+	r.index(stored, message)
+
+	return &message, nil
+}
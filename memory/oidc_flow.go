@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+// In-memory implementation of repository.OidcFlowRepository
+type OidcFlow struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewOidcFlowRepository() *OidcFlow {
+	return &OidcFlow{}
+}
+
+// Stores a new OpenID Connect flow
+func (r OidcFlow) Create(id, flowToken, nonce, verifier string, expiresAt time.Time) (*entity.OidcFlow, error) {
+	flow := entity.OidcFlow{
+		Id:        id,
+		FlowToken: flowToken,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		CreatedAt: r.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	r.Store.Lock()
+	r.Store.oidcFlows[id] = flow
+	r.Store.Unlock()
+
+	return &flow, nil
+}
+
+// Deletes the OpenID Connect flow and returns it, so that it can be completed only once
+func (r OidcFlow) Consume(id string) (*entity.OidcFlow, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	flow, ok := r.Store.oidcFlows[id]
+	if !ok {
+		return nil, repository.OidcFlowNotFoundError
+	}
+	delete(r.Store.oidcFlows, id)
+
+	return &flow, nil
+}
@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/repository/repositorytest"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestOidcFlowRepository(t *testing.T) {
+	suite.Run(t, &repositorytest.OidcFlowSuite{
+		New: func(t *testing.T, clock clockwork.Clock) repository.OidcFlowRepository {
+			r := NewOidcFlowRepository()
+			r.Store = NewStore()
+			r.Clock = clock
+			return r
+		},
+	})
+}
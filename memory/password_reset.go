@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+// In-memory implementation of repository.PasswordResetRepository
+type PasswordReset struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewPasswordResetRepository() *PasswordReset {
+	return &PasswordReset{}
+}
+
+// Stores a new password reset token of the user
+func (r PasswordReset) Create(id, userId, secret string, expiresAt time.Time) (*entity.PasswordReset, error) {
+	reset := entity.PasswordReset{
+		Id:        id,
+		UserId:    userId,
+		Secret:    secret,
+		CreatedAt: r.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	r.Store.Lock()
+	r.Store.passwordResets[id] = reset
+	r.Store.Unlock()
+
+	return &reset, nil
+}
+
+// Deletes the password reset token and returns it, so that it can be used only once
+func (r PasswordReset) Consume(id string) (*entity.PasswordReset, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	reset, ok := r.Store.passwordResets[id]
+	if !ok {
+		return nil, repository.PasswordResetNotFoundError
+	}
+	delete(r.Store.passwordResets, id)
+
+	return &reset, nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/services"
+)
+
+// In-memory implementation of services.PubsubClient.
+//
+// The messages are delivered to the subscribers of the same process
+type Pubsub struct {
+	// Injected via DI
+	DeliveryRepository repository.DeliveryRepository `inject:""`
+
+	registry *services.Registry
+}
+
+func NewPubsubClient() *Pubsub {
+	return &Pubsub{
+		registry: services.NewRegistry(),
+	}
+}
+
+// Publish a message to all its recipients, recording the deliveries as services.Pubsub does
+func (p *Pubsub) Publish(message entity.Message) error {
+	for _, to := range message.Recipients() {
+		delivery, err := p.DeliveryRepository.Create(to, message.Id)
+		if err != nil {
+			return err
+		}
+		message.Seq = delivery.Seq
+
+		p.registry.Dispatch(to, copyMessage(message))
+	}
+
+	return nil
+}
+
+// Publish an event to its recipients
+func (p *Pubsub) PublishEvent(event entity.Event) error {
+	for _, to := range event.To {
+		p.registry.DispatchEvent(to, event)
+	}
+
+	return nil
+}
+
+// Subscribe to the messages and the events sent to the to user. The onMessage and onEvent functions are called when
+// a new message or event is received.
+//
+// Returns an error if something went wrong and the cancel function, used to delete the subscription
+func (p *Pubsub) Subscribe(to string, onMessage func(entity.Message), onEvent func(entity.Event)) (error, func()) {
+	return nil, p.registry.Add(to, onMessage, onEvent)
+}
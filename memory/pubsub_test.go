@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+var _ services.PubsubClient = NewPubsubClient()
+
+type PubsubTestSuite struct {
+	suite.Suite
+	client             *Pubsub
+	deliveryRepository *mocks.DeliveryRepository
+}
+
+func TestPubsub(t *testing.T) {
+	suite.Run(t, new(PubsubTestSuite))
+}
+
+func (suite *PubsubTestSuite) SetupTest() {
+	suite.deliveryRepository = &mocks.DeliveryRepository{}
+
+	suite.client = NewPubsubClient()
+	suite.client.DeliveryRepository = suite.deliveryRepository
+}
+
+func (suite *PubsubTestSuite) TearDownTest() {
+	suite.deliveryRepository.AssertExpectations(suite.T())
+}
+
+func (suite *PubsubTestSuite) TestPublishDeliveryError() {
+	suite.deliveryRepository.On("Create", "to1", "id1").Return(nil, assert.AnError)
+
+	err := suite.client.Publish(entity.Message{Id: "id1", To: "to1"})
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *PubsubTestSuite) TestPublishSubscribe() {
+	var received []entity.Message
+	receive := func(message entity.Message) {
+		received = append(received, message)
+	}
+
+	err, cancel1 := suite.client.Subscribe("to1", receive, nil)
+	suite.Require().NoError(err)
+	err, cancel2 := suite.client.Subscribe("to1", receive, nil)
+	suite.Require().NoError(err)
+
+	suite.deliveryRepository.On("Create", "to1", "id1").Return(&entity.Delivery{Seq: 1}, nil)
+	suite.deliveryRepository.On("Create", "to2", "id2").Return(&entity.Delivery{Seq: 1}, nil)
+	suite.deliveryRepository.On("Create", "to1", "id3").Return(&entity.Delivery{Seq: 2}, nil)
+
+	suite.Require().NoError(suite.client.Publish(entity.Message{Id: "id1", To: "to1"}))
+	suite.Require().NoError(suite.client.Publish(entity.Message{Id: "id2", To: "to2"}))
+
+	suite.Require().Len(received, 2)
+	suite.Equal("id1", received[0].Id)
+	suite.Equal(int64(1), received[0].Seq)
+
+	cancel1()
+	suite.Require().NoError(suite.client.Publish(entity.Message{Id: "id3", To: "to1"}))
+	suite.Require().Len(received, 3)
+	suite.Equal("id3", received[2].Id)
+	suite.Equal(int64(2), received[2].Seq)
+
+	cancel2()
+	suite.Equal(0, suite.client.registry.Len())
+}
+
+func (suite *PubsubTestSuite) TestPublishEvent() {
+	var received []entity.Event
+	receive := func(event entity.Event) {
+		received = append(received, event)
+	}
+
+	err, cancel1 := suite.client.Subscribe("to1", nil, receive)
+	suite.Require().NoError(err)
+	defer cancel1()
+	err, cancel2 := suite.client.Subscribe("to2", nil, receive)
+	suite.Require().NoError(err)
+	defer cancel2()
+
+	event, err := entity.NewEvent("read", []string{"to1", "to3"}, map[string]string{"messageId": "id1"})
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.client.PublishEvent(event))
+
+	suite.Require().Len(received, 1)
+	suite.Equal("read", received[0].Type)
+	suite.JSONEq(`{"messageId": "id1"}`, string(received[0].Data))
+}
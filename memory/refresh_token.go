@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+// In-memory implementation of repository.RefreshTokenRepository
+type RefreshToken struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewRefreshTokenRepository() *RefreshToken {
+	return &RefreshToken{}
+}
+
+// Fetch a refresh token by ID
+func (r RefreshToken) GetById(id string) (*entity.RefreshToken, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	token, ok := r.Store.refreshTokens[id]
+	if !ok {
+		return nil, repository.RefreshTokenNotFoundError
+	}
+
+	return &token, nil
+}
+
+// Stores a new refresh token of the user. The token starts a new family if family is empty
+func (r RefreshToken) Create(id, family, userId, secret string, expiresAt time.Time) (*entity.RefreshToken, error) {
+	if family == "" {
+		family = id
+	}
+
+	token := entity.RefreshToken{
+		Id:        id,
+		Family:    family,
+		UserId:    userId,
+		Secret:    secret,
+		CreatedAt: r.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	r.Store.Lock()
+	r.Store.refreshTokens[id] = token
+	r.Store.Unlock()
+
+	return &token, nil
+}
+
+// Marks the refresh token as used, at the current time. Returns RefreshTokenUsedError if it has already been used
+func (r RefreshToken) Use(id string) (*entity.RefreshToken, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	token, ok := r.Store.refreshTokens[id]
+	if !ok {
+		return nil, repository.RefreshTokenNotFoundError
+	}
+
+	if token.Used() {
+		return nil, repository.RefreshTokenUsedError
+	}
+	token.UsedAt = r.Clock.Now()
+	r.Store.refreshTokens[id] = token
+
+	return &token, nil
+}
+
+// Deletes all the tokens of the family
+func (r RefreshToken) DeleteFamily(family string) error {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	for id, token := range r.Store.refreshTokens {
+		if token.Family == family {
+			delete(r.Store.refreshTokens, id)
+		}
+	}
+
+	return nil
+}
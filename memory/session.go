@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+	"sort"
+)
+
+// In-memory implementation of repository.SessionRepository
+type Session struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewSessionRepository() *Session {
+	return &Session{}
+}
+
+// Fetch a session by ID
+func (r Session) GetById(id string) (*entity.Session, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	session, ok := r.Store.sessions[id]
+	if !ok {
+		return nil, repository.SessionNotFoundError
+	}
+
+	return &session, nil
+}
+
+// Returns all the sessions of the user, the most recently used first
+func (r Session) AllOf(userId string) ([]entity.Session, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	sessions := []entity.Session{}
+	for _, session := range r.Store.sessions {
+		if session.UserId == userId {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+// Opens a new session of the user. A pending session waits for the second factor of the login
+func (r Session) Create(userId, secret, device, ip, userAgent string, pending bool) (*entity.Session, error) {
+	now := r.Clock.Now()
+	session := entity.Session{
+		Id:         uuid.NewV4().String(),
+		UserId:     userId,
+		Secret:     secret,
+		Device:     device,
+		Ip:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		Pending:    pending,
+	}
+
+	r.Store.Lock()
+	r.Store.sessions[session.Id] = session
+	r.Store.Unlock()
+
+	return &session, nil
+}
+
+// Sets the LastUsedAt of the session to now
+func (r Session) Touch(id string) error {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	session, ok := r.Store.sessions[id]
+	if !ok {
+		return repository.SessionNotFoundError
+	}
+	session.LastUsedAt = r.Clock.Now()
+	r.Store.sessions[id] = session
+
+	return nil
+}
+
+// Marks a pending session as active, once the second factor of the login has been verified
+func (r Session) Activate(id string) error {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	session, ok := r.Store.sessions[id]
+	if !ok {
+		return repository.SessionNotFoundError
+	}
+	session.Pending = false
+	r.Store.sessions[id] = session
+
+	return nil
+}
+
+// Deletes a session, by ID
+func (r Session) Delete(id string) error {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	delete(r.Store.sessions, id)
+	return nil
+}
@@ -0,0 +1,73 @@
+// The package memory contains an in-memory implementation of the repositories and of the pubsub client.
+//
+// It is used to run the application without google cloud's datastore and pubsub, eg. for development and testing.
+// The data is lost when the process exits
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"sync"
+)
+
+// The Store contains the data shared by all the in-memory repositories
+type Store struct {
+	sync.Mutex
+
+	users         map[string]entity.User
+	messages      map[string]entity.Message
+	subscriptions map[string]entity.Subscription
+	conversations map[string]entity.Conversation
+	deliveries    map[string][]entity.Delivery
+	attachments   map[string]entity.Attachment
+	refreshTokens map[string]entity.RefreshToken
+	sessions      map[string]entity.Session
+
+	// Password reset tokens, by the hash of the token
+	passwordResets map[string]entity.PasswordReset
+
+	// OpenID Connect flows, by the hash of the state
+	oidcFlows map[string]entity.OidcFlow
+
+	// Failed login attempts, by throttled key
+	loginAttempts map[string]entity.LoginAttempts
+
+	// Search index of the messages: the IDs of the messages containing each term
+	terms map[string]map[string]bool
+
+	// Users having exchanged direct messages, by user
+	contacts map[string]map[string]bool
+}
+
+func NewStore() *Store {
+	s := &Store{}
+	s.Clear()
+	return s
+}
+
+// Removes all the data
+func (s *Store) Clear() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.users = map[string]entity.User{}
+	s.messages = map[string]entity.Message{}
+	s.subscriptions = map[string]entity.Subscription{}
+	s.conversations = map[string]entity.Conversation{}
+	s.deliveries = map[string][]entity.Delivery{}
+	s.attachments = map[string]entity.Attachment{}
+	s.refreshTokens = map[string]entity.RefreshToken{}
+	s.sessions = map[string]entity.Session{}
+	s.passwordResets = map[string]entity.PasswordReset{}
+	s.oidcFlows = map[string]entity.OidcFlow{}
+	s.loginAttempts = map[string]entity.LoginAttempts{}
+	s.terms = map[string]map[string]bool{}
+	s.contacts = map[string]map[string]bool{}
+}
+
+// Returns a copy of a string slice, so that the stored entities can't be modified by the callers
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type StoreTestSuite struct {
+	suite.Suite
+	store          *Store
+	userRepository *User
+}
+
+func TestStore(t *testing.T) {
+	suite.Run(t, new(StoreTestSuite))
+}
+
+func (suite *StoreTestSuite) SetupTest() {
+	suite.store = NewStore()
+	suite.userRepository = NewUserRepository()
+	suite.userRepository.Store = suite.store
+	suite.userRepository.Clock = clockwork.NewFakeClock()
+}
+
+func (suite *StoreTestSuite) TestClear() {
+	_, err := suite.userRepository.CreateUser("a@b.com", "password")
+	suite.Require().NoError(err)
+
+	suite.store.Clear()
+
+	users, err := suite.userRepository.All()
+	suite.NoError(err)
+	suite.Len(users, 0)
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"sort"
+	"time"
+)
+
+// In-memory implementation of repository.SubscriptionRepository
+type Subscription struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewSubscriptionRepository() *Subscription {
+	return &Subscription{}
+}
+
+// Returns all the subscriptions belonging the "to" user
+func (r Subscription) AllTo(to string) ([]entity.Subscription, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	subscriptions := []entity.Subscription{}
+	for _, subscription := range r.Store.subscriptions {
+		if subscription.To == to {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].Id < subscriptions[j].Id
+	})
+
+	return subscriptions, nil
+}
+
+// Returns all the subscriptions not seen since before, sorted by LastSeenAt
+func (r Subscription) AllStale(before time.Time) ([]entity.Subscription, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	subscriptions := []entity.Subscription{}
+	for _, subscription := range r.Store.subscriptions {
+		if subscription.LastSeenAt.Before(before) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].LastSeenAt.Before(subscriptions[j].LastSeenAt)
+	})
+
+	return subscriptions, nil
+}
+
+// Returns all the subscriptions seen after since, sorted by Id
+func (r Subscription) AllLive(since time.Time) ([]entity.Subscription, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	subscriptions := []entity.Subscription{}
+	for _, subscription := range r.Store.subscriptions {
+		if subscription.LastSeenAt.After(since) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].Id < subscriptions[j].Id
+	})
+
+	return subscriptions, nil
+}
+
+// Creates a new subscription for the to user.
+// The caller is responsible for the uniqueness of the ID (eg. using an UUID generator)
+func (r Subscription) Create(id, to string) (*entity.Subscription, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	if _, ok := r.Store.subscriptions[id]; ok {
+		return nil, repository.SubscriptionAlreadyExistsError
+	}
+
+	now := r.Clock.Now()
+	subscription := entity.Subscription{
+		Id:         id,
+		To:         to,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	r.Store.subscriptions[id] = subscription
+
+	return &subscription, nil
+}
+
+// Sets the LastSeenAt of the subscription to now
+func (r Subscription) Touch(id string) error {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	subscription, ok := r.Store.subscriptions[id]
+	if !ok {
+		return repository.SubscriptionNotFoundError
+	}
+	subscription.LastSeenAt = r.Clock.Now()
+	r.Store.subscriptions[id] = subscription
+
+	return nil
+}
+
+// Deletes a subscription, by ID
+func (r Subscription) Delete(id string) error {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	delete(r.Store.subscriptions, id)
+	return nil
+}
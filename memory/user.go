@@ -0,0 +1,255 @@
+package memory
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+	"golang.org/x/crypto/bcrypt"
+	"sort"
+)
+
+// In-memory implementation of repository.UserRepository
+type User struct {
+	// Injected via DI
+	Store *Store `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewUserRepository() *User {
+	return &User{}
+}
+
+// Fetches an user by ID
+func (r User) GetUserById(id string) (*entity.User, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	user, ok := r.Store.users[id]
+	if !ok {
+		return nil, repository.UserNotFoundError
+	}
+
+	return &user, nil
+}
+
+// Fetches an user by Email
+func (r User) GetUserByEmail(email string) (*entity.User, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	return r.getUserByEmail(email)
+}
+
+// Fetches an user by Email. The store must be locked by the caller
+func (r User) getUserByEmail(email string) (*entity.User, error) {
+	for _, user := range r.Store.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+
+	return nil, repository.UserNotFoundError
+}
+
+// Creates a new user, given its email and password
+func (r User) CreateUser(email string, password string) (*entity.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	if _, err := r.getUserByEmail(email); err == nil {
+		return nil, repository.UserAlreadyExistsError
+	}
+
+	user := entity.User{
+		Id:        uuid.NewV4().String(),
+		Email:     email,
+		Password:  string(hash),
+		Secret:    uuid.NewV4().String(),
+		CreatedAt: r.Clock.Now(),
+	}
+	r.Store.users[user.Id] = user
+
+	return &user, nil
+}
+
+// Creates a new user without password, whose email has been verified by an identity provider.
+// The user can't log in with a password until it resets it
+func (r User) CreateExternalUser(email string) (*entity.User, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	if _, err := r.getUserByEmail(email); err == nil {
+		return nil, repository.UserAlreadyExistsError
+	}
+
+	user := entity.User{
+		Id:            uuid.NewV4().String(),
+		Email:         email,
+		Secret:        uuid.NewV4().String(),
+		CreatedAt:     r.Clock.Now(),
+		EmailVerified: true,
+	}
+	r.Store.users[user.Id] = user
+
+	return &user, nil
+}
+
+// Logs in an user by email and password
+func (r User) Login(email, password string) (*entity.User, error) {
+	user, err := r.GetUserByEmail(email)
+	if err == repository.UserNotFoundError {
+		return nil, repository.UserBadUsernameOrPasswordError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if err != nil {
+		return nil, repository.UserBadUsernameOrPasswordError
+	}
+
+	return user, nil
+}
+
+// Fetches all the users, sorted by email
+func (r User) All() ([]entity.User, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	users := []entity.User{}
+	for _, user := range r.Store.users {
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Email < users[j].Email
+	})
+
+	return users, nil
+}
+
+// Sets the LastSeenAt of the user to now
+func (r User) Touch(id string) error {
+	_, err := r.update(id, func(user *entity.User) error {
+		user.LastSeenAt = r.Clock.Now()
+		return nil
+	})
+	return err
+}
+
+// Replaces the secret of the user with a new random one, invalidating all the tokens issued to the user
+func (r User) RotateSecret(id string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.Secret = uuid.NewV4().String()
+		return nil
+	})
+}
+
+// Replaces the password of the user, hashing the new one with bcrypt
+func (r User) UpdatePassword(id string, password string) (*entity.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.update(id, func(user *entity.User) error {
+		user.Password = string(hash)
+		return nil
+	})
+}
+
+// Stores the TOTP secret being enrolled, until it's confirmed by EnableTotp
+func (r User) SetPendingTotpSecret(id string, secret string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.PendingTotpSecret = secret
+		return nil
+	})
+}
+
+// Enables the two factor authentication with the given TOTP secret and the hashes of the recovery codes
+func (r User) EnableTotp(id string, secret string, recoveryCodes []string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.TotpSecret = secret
+		user.PendingTotpSecret = ""
+		user.RecoveryCodes = recoveryCodes
+		return nil
+	})
+}
+
+// Disables the two factor authentication, removing the TOTP secret, the recovery codes and the last time step used
+func (r User) DisableTotp(id string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.TotpSecret = ""
+		user.PendingTotpSecret = ""
+		user.RecoveryCodes = nil
+		user.TotpCounter = 0
+		return nil
+	})
+}
+
+// Records the time step of an accepted TOTP code. Returns UserTotpCodeUsedError if it isn't newer than the last one
+func (r User) UseTotpCounter(id string, counter int64) error {
+	_, err := r.update(id, func(user *entity.User) error {
+		if counter <= user.TotpCounter {
+			return repository.UserTotpCodeUsedError
+		}
+
+		user.TotpCounter = counter
+		return nil
+	})
+	return err
+}
+
+// Removes the recovery code with the given hash, so it can't be used twice
+func (r User) UseRecoveryCode(id string, hash string) error {
+	_, err := r.update(id, func(user *entity.User) error {
+		codes := []string{}
+		for _, code := range user.RecoveryCodes {
+			if code != hash {
+				codes = append(codes, code)
+			}
+		}
+		if len(codes) == len(user.RecoveryCodes) {
+			return repository.UserRecoveryCodeNotFoundError
+		}
+
+		user.RecoveryCodes = codes
+		return nil
+	})
+	return err
+}
+
+// Marks the email of the user as verified
+func (r User) SetEmailVerified(id string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.EmailVerified = true
+		return nil
+	})
+}
+
+// Applies fn to the user and stores it
+func (r User) update(id string, fn func(*entity.User) error) (*entity.User, error) {
+	r.Store.Lock()
+	defer r.Store.Unlock()
+
+	user, ok := r.Store.users[id]
+	if !ok {
+		return nil, repository.UserNotFoundError
+	}
+
+	if err := fn(&user); err != nil {
+		return nil, err
+	}
+	r.Store.users[id] = user
+
+	return &user, nil
+}
@@ -2,6 +2,7 @@
 package middleware
 
 import (
+	"github.com/asiragusa/wschat/entity"
 	"github.com/asiragusa/wschat/response"
 	"github.com/asiragusa/wschat/services"
 	"github.com/kataras/iris"
@@ -13,10 +14,16 @@ import (
 type Authenticated struct {
 	// Injected via DI
 	AccessTokenGenerator services.TokenGenerator `inject:"accessTokenGenerator"`
+
+	// If true, Verified refuses the users that haven't verified their email yet
+	requireVerifiedEmail bool
 }
 
-func NewAuthenticatedMiddleware() *Authenticated {
-	return &Authenticated{}
+// Creates the middleware. requireVerifiedEmail enables the Verified handler
+func NewAuthenticatedMiddleware(requireVerifiedEmail bool) *Authenticated {
+	return &Authenticated{
+		requireVerifiedEmail: requireVerifiedEmail,
+	}
 }
 
 // Parses the header. Only Bearer authorization is allowed
@@ -40,7 +47,7 @@ func parseAuthorizationHeader(authorization string) string {
 func (m *Authenticated) Handle(ctx context.Context) {
 	token := parseAuthorizationHeader(ctx.GetHeader("Authorization"))
 
-	user, err := m.AccessTokenGenerator.ValidateToken(token)
+	user, session, err := m.AccessTokenGenerator.ValidateToken(token)
 
 	// If the token is not valid send 401
 	if err == services.InvalidTokenError {
@@ -60,7 +67,25 @@ func (m *Authenticated) Handle(ctx context.Context) {
 		return
 	}
 
-	// Set the user in the iris context
+	// Set the user and its session in the iris context
 	ctx.Values().Set("user", user)
+	ctx.Values().Set("session", session)
+	ctx.Next()
+}
+
+// Middleware handler for the routes sending messages, run after Handle.
+// Sends 403 if the email verification is required and the user hasn't verified its email yet
+func (m *Authenticated) Verified(ctx context.Context) {
+	user := ctx.Values().Get("user").(*entity.User)
+
+	if m.requireVerifiedEmail && !user.EmailVerified {
+		error := response.NewError(iris.StatusForbidden)
+		error.AddDetail("email", "notVerified")
+		ctx.StatusCode(error.GetCode())
+		ctx.JSON(error)
+		ctx.StopExecution()
+		return
+	}
+
 	ctx.Next()
 }
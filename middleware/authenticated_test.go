@@ -25,7 +25,7 @@ func TestAuthenticatedMiddleware(t *testing.T) {
 }
 
 func (suite *AuthenticatedMiddlewareTestSuite) SetupSuite() {
-	suite.middleware = NewAuthenticatedMiddleware()
+	suite.middleware = NewAuthenticatedMiddleware(false)
 
 	app := iris.New()
 	app.Use(suite.middleware.Handle)
@@ -34,6 +34,10 @@ func (suite *AuthenticatedMiddlewareTestSuite) SetupSuite() {
 		suite.Require().NotNil(user)
 		suite.Equal("test", user.Email)
 
+		session := ctx.Values().Get("session").(*entity.Session)
+		suite.Require().NotNil(session)
+		suite.Equal("sessionId", session.Id)
+
 		ctx.StatusCode(httptest.StatusOK)
 		ctx.JSON(map[string]bool{
 			"ok": true,
@@ -62,7 +66,7 @@ func (suite *AuthenticatedMiddlewareTestSuite) TestBadHeader() {
 		"Bearer A B",
 	}
 
-	suite.generator.On("ValidateToken", "").Times(len(headers)).Return(nil, services.InvalidTokenError)
+	suite.generator.On("ValidateToken", "").Times(len(headers)).Return(nil, nil, services.InvalidTokenError)
 
 	for _, auth := range headers {
 		suite.e.GET("/").WithHeader("Authorization", auth).Expect().Status(httptest.StatusUnauthorized).
@@ -76,7 +80,7 @@ func (suite *AuthenticatedMiddlewareTestSuite) TestBadHeader() {
 func (suite *AuthenticatedMiddlewareTestSuite) TestBadAuth() {
 	auth := "Bearer invalid"
 
-	suite.generator.On("ValidateToken", "invalid").Return(nil, services.InvalidTokenError)
+	suite.generator.On("ValidateToken", "invalid").Return(nil, nil, services.InvalidTokenError)
 	suite.e.GET("/").WithHeader("Authorization", auth).Expect().Status(httptest.StatusUnauthorized).
 		JSON().Object().Equal(map[string]interface{}{
 		"code":    httptest.StatusUnauthorized,
@@ -87,7 +91,7 @@ func (suite *AuthenticatedMiddlewareTestSuite) TestBadAuth() {
 func (suite *AuthenticatedMiddlewareTestSuite) TestGeneratorAnError() {
 	auth := "Bearer valid"
 
-	suite.generator.On("ValidateToken", "valid").Return(nil, assert.AnError)
+	suite.generator.On("ValidateToken", "valid").Return(nil, nil, assert.AnError)
 	suite.e.GET("/").WithHeader("Authorization", auth).Expect().Status(httptest.StatusInternalServerError).
 		JSON().Object().Equal(map[string]interface{}{
 		"code":    httptest.StatusInternalServerError,
@@ -100,6 +104,56 @@ func (suite *AuthenticatedMiddlewareTestSuite) TestHandleOk() {
 
 	suite.generator.On("ValidateToken", "valid").Return(&entity.User{
 		Email: "test",
+	}, &entity.Session{
+		Id: "sessionId",
 	}, nil)
 	suite.e.GET("/").WithHeader("Authorization", auth).Expect().Status(httptest.StatusOK)
 }
+
+type VerifiedMiddlewareTestSuite struct {
+	suite.Suite
+	user *entity.User
+}
+
+func TestVerifiedMiddleware(t *testing.T) {
+	suite.Run(t, new(VerifiedMiddlewareTestSuite))
+}
+
+func (suite *VerifiedMiddlewareTestSuite) SetupTest() {
+	suite.user = &entity.User{Email: "test"}
+}
+
+// Returns a router protected by the Verified handler
+func (suite *VerifiedMiddlewareTestSuite) expect(requireVerifiedEmail bool) *httpexpect.Expect {
+	middleware := NewAuthenticatedMiddleware(requireVerifiedEmail)
+
+	app := iris.New()
+	app.Use(func(ctx context.Context) {
+		ctx.Values().Set("user", suite.user)
+		ctx.Next()
+	}, middleware.Verified)
+	app.Get("/", func(ctx context.Context) {
+		ctx.StatusCode(httptest.StatusOK)
+	})
+	return httptest.New(suite.T(), app)
+}
+
+func (suite *VerifiedMiddlewareTestSuite) TestNotRequired() {
+	suite.expect(false).GET("/").Expect().Status(httptest.StatusOK)
+}
+
+func (suite *VerifiedMiddlewareTestSuite) TestNotVerified() {
+	suite.expect(true).GET("/").Expect().Status(httptest.StatusForbidden).
+		JSON().Object().Equal(map[string]interface{}{
+		"code":    httptest.StatusForbidden,
+		"message": "Forbidden",
+		"details": map[string]interface{}{
+			"email": []string{"notVerified"},
+		},
+	})
+}
+
+func (suite *VerifiedMiddlewareTestSuite) TestVerified() {
+	suite.user.EmailVerified = true
+	suite.expect(true).GET("/").Expect().Status(httptest.StatusOK)
+}
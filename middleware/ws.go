@@ -23,7 +23,7 @@ func (m *Ws) Handle(ctx context.Context) {
 	token := ctx.URLParam("token")
 
 	// Validate the token
-	user, err := m.WsTokenGenerator.ValidateToken(token)
+	user, session, err := m.WsTokenGenerator.ValidateToken(token)
 
 	// Invalid token
 	if err == services.InvalidTokenError {
@@ -43,7 +43,8 @@ func (m *Ws) Handle(ctx context.Context) {
 		return
 	}
 
-	// Set the user in the iris context
+	// Set the user and its session in the iris context
 	ctx.Values().Set("user", user)
+	ctx.Values().Set("session", session)
 	ctx.Next()
 }
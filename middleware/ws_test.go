@@ -34,6 +34,10 @@ func (suite *WsMiddlewareTestSuite) SetupSuite() {
 		suite.Require().NotNil(user)
 		suite.Equal("test", user.Email)
 
+		session := ctx.Values().Get("session").(*entity.Session)
+		suite.Require().NotNil(session)
+		suite.Equal("sessionId", session.Id)
+
 		ctx.StatusCode(httptest.StatusOK)
 		ctx.JSON(map[string]bool{
 			"ok": true,
@@ -53,7 +57,7 @@ func (suite *WsMiddlewareTestSuite) TearDownTest() {
 }
 
 func (suite *WsMiddlewareTestSuite) TestNoParam() {
-	suite.generator.On("ValidateToken", "").Return(nil, services.InvalidTokenError)
+	suite.generator.On("ValidateToken", "").Return(nil, nil, services.InvalidTokenError)
 
 	suite.e.GET("/").Expect().Status(httptest.StatusUnauthorized).
 		JSON().Object().Equal(map[string]interface{}{
@@ -65,7 +69,7 @@ func (suite *WsMiddlewareTestSuite) TestNoParam() {
 func (suite *WsMiddlewareTestSuite) TestBadAuth() {
 	token := "invalid"
 
-	suite.generator.On("ValidateToken", "invalid").Return(nil, services.InvalidTokenError)
+	suite.generator.On("ValidateToken", "invalid").Return(nil, nil, services.InvalidTokenError)
 	suite.e.GET("/").WithQuery("token", token).Expect().Status(httptest.StatusUnauthorized).
 		JSON().Object().Equal(map[string]interface{}{
 		"code":    httptest.StatusUnauthorized,
@@ -76,7 +80,7 @@ func (suite *WsMiddlewareTestSuite) TestBadAuth() {
 func (suite *WsMiddlewareTestSuite) TestGeneratorAnError() {
 	token := "valid"
 
-	suite.generator.On("ValidateToken", "valid").Return(nil, assert.AnError)
+	suite.generator.On("ValidateToken", "valid").Return(nil, nil, assert.AnError)
 	suite.e.GET("/").WithQuery("token", token).Expect().Status(httptest.StatusInternalServerError).
 		JSON().Object().Equal(map[string]interface{}{
 		"code":    httptest.StatusInternalServerError,
@@ -89,6 +93,8 @@ func (suite *WsMiddlewareTestSuite) TestOk() {
 
 	suite.generator.On("ValidateToken", "valid").Return(&entity.User{
 		Email: "test",
+	}, &entity.Session{
+		Id: "sessionId",
 	}, nil)
 	suite.e.GET("/").WithQuery("token", token).Expect().Status(httptest.StatusOK)
 }
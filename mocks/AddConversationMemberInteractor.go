@@ -0,0 +1,27 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+import request "github.com/asiragusa/wschat/request"
+import response "github.com/asiragusa/wschat/response"
+
+// AddConversationMemberInteractor is an autogenerated mock type for the AddConversationMemberInteractor type
+type AddConversationMemberInteractor struct {
+	mock.Mock
+}
+
+// Call provides a mock function with given fields: _a0
+func (_m *AddConversationMemberInteractor) Call(_a0 request.AddConversationMember) response.Response {
+	ret := _m.Called(_a0)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(request.AddConversationMember) response.Response); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(response.Response)
+		}
+	}
+
+	return r0
+}
@@ -0,0 +1,107 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+// AttachmentRepository is an autogenerated mock type for the AttachmentRepository type
+type AttachmentRepository struct {
+	mock.Mock
+}
+
+// Attach provides a mock function with given fields: _a0, _a1
+func (_m *AttachmentRepository) Attach(_a0 string, _a1 string) (*entity.Attachment, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.Attachment
+	if rf, ok := ret.Get(0).(func(string, string) *entity.Attachment); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Attachment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
+func (_m *AttachmentRepository) Create(_a0 string, _a1 string, _a2 string, _a3 int64, _a4 string) (*entity.Attachment, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
+
+	var r0 *entity.Attachment
+	if rf, ok := ret.Get(0).(func(string, string, string, int64, string) *entity.Attachment); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Attachment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, int64, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: _a0
+func (_m *AttachmentRepository) Delete(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Detach provides a mock function with given fields: _a0, _a1
+func (_m *AttachmentRepository) Detach(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetById provides a mock function with given fields: _a0
+func (_m *AttachmentRepository) GetById(_a0 string) (*entity.Attachment, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.Attachment
+	if rf, ok := ret.Get(0).(func(string) *entity.Attachment); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Attachment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,148 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+// ConversationRepository is an autogenerated mock type for the ConversationRepository type
+type ConversationRepository struct {
+	mock.Mock
+}
+
+// AddMember provides a mock function with given fields: _a0, _a1
+func (_m *ConversationRepository) AddMember(_a0 string, _a1 string) (*entity.Conversation, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.Conversation
+	if rf, ok := ret.Get(0).(func(string, string) *entity.Conversation); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AllWithMember provides a mock function with given fields: _a0
+func (_m *ConversationRepository) AllWithMember(_a0 string) ([]entity.Conversation, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []entity.Conversation
+	if rf, ok := ret.Get(0).(func(string) []entity.Conversation); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: _a0, _a1, _a2
+func (_m *ConversationRepository) Create(_a0 string, _a1 string, _a2 []string) (*entity.Conversation, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *entity.Conversation
+	if rf, ok := ret.Get(0).(func(string, string, []string) *entity.Conversation); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, []string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetById provides a mock function with given fields: _a0
+func (_m *ConversationRepository) GetById(_a0 string) (*entity.Conversation, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.Conversation
+	if rf, ok := ret.Get(0).(func(string) *entity.Conversation); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveMember provides a mock function with given fields: _a0, _a1
+func (_m *ConversationRepository) RemoveMember(_a0 string, _a1 string) (*entity.Conversation, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.Conversation
+	if rf, ok := ret.Get(0).(func(string, string) *entity.Conversation); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Rename provides a mock function with given fields: _a0, _a1
+func (_m *ConversationRepository) Rename(_a0 string, _a1 string) (*entity.Conversation, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.Conversation
+	if rf, ok := ret.Get(0).(func(string, string) *entity.Conversation); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,56 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+// DeliveryRepository is an autogenerated mock type for the DeliveryRepository type
+type DeliveryRepository struct {
+	mock.Mock
+}
+
+// AllAfter provides a mock function with given fields: _a0, _a1, _a2
+func (_m *DeliveryRepository) AllAfter(_a0 string, _a1 int64, _a2 int) ([]entity.Delivery, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []entity.Delivery
+	if rf, ok := ret.Get(0).(func(string, int64, int) []entity.Delivery); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Delivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int64, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: _a0, _a1
+func (_m *DeliveryRepository) Create(_a0 string, _a1 string) (*entity.Delivery, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.Delivery
+	if rf, ok := ret.Get(0).(func(string, string) *entity.Delivery); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Delivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,72 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+import time "time"
+
+// LoginAttemptRepository is an autogenerated mock type for the LoginAttemptRepository type
+type LoginAttemptRepository struct {
+	mock.Mock
+}
+
+// Get provides a mock function with given fields: _a0
+func (_m *LoginAttemptRepository) Get(_a0 string) (*entity.LoginAttempts, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.LoginAttempts
+	if rf, ok := ret.Get(0).(func(string) *entity.LoginAttempts); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.LoginAttempts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordFailure provides a mock function with given fields: _a0, _a1
+func (_m *LoginAttemptRepository) RecordFailure(_a0 string, _a1 time.Time) (*entity.LoginAttempts, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.LoginAttempts
+	if rf, ok := ret.Get(0).(func(string, time.Time) *entity.LoginAttempts); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.LoginAttempts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reset provides a mock function with given fields: _a0
+func (_m *LoginAttemptRepository) Reset(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,60 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+import time "time"
+
+// LoginThrottler is an autogenerated mock type for the LoginThrottler type
+type LoginThrottler struct {
+	mock.Mock
+}
+
+// Check provides a mock function with given fields: _a0, _a1
+func (_m *LoginThrottler) Check(_a0 string, _a1 string) (time.Duration, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func(string, string) time.Duration); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Fail provides a mock function with given fields: _a0, _a1
+func (_m *LoginThrottler) Fail(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Succeed provides a mock function with given fields: _a0
+func (_m *LoginThrottler) Succeed(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -3,12 +3,36 @@ package mocks
 
 import entity "github.com/asiragusa/wschat/entity"
 import mock "github.com/stretchr/testify/mock"
+import repository "github.com/asiragusa/wschat/repository"
 
 // MessageRepository is an autogenerated mock type for the MessageRepository type
 type MessageRepository struct {
 	mock.Mock
 }
 
+// AddReaction provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MessageRepository) AddReaction(_a0 string, _a1 string, _a2 string) (*entity.Message, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *entity.Message
+	if rf, ok := ret.Get(0).(func(string, string, string) *entity.Message); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AllWithUser provides a mock function with given fields: _a0
 func (_m *MessageRepository) AllWithUser(_a0 string) ([]entity.Message, error) {
 	ret := _m.Called(_a0)
@@ -32,6 +56,52 @@ func (_m *MessageRepository) AllWithUser(_a0 string) ([]entity.Message, error) {
 	return r0, r1
 }
 
+// Attach provides a mock function with given fields: _a0, _a1
+func (_m *MessageRepository) Attach(_a0 string, _a1 []entity.Attachment) (*entity.Message, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.Message
+	if rf, ok := ret.Get(0).(func(string, []entity.Attachment) *entity.Message); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []entity.Attachment) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Contacts provides a mock function with given fields: _a0
+func (_m *MessageRepository) Contacts(_a0 string) ([]string, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: _a0, _a1, _a2
 func (_m *MessageRepository) Create(_a0 string, _a1 string, _a2 string) (*entity.Message, error) {
 	ret := _m.Called(_a0, _a1, _a2)
@@ -55,6 +125,135 @@ func (_m *MessageRepository) Create(_a0 string, _a1 string, _a2 string) (*entity
 	return r0, r1
 }
 
+// CreateInConversation provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *MessageRepository) CreateInConversation(_a0 string, _a1 string, _a2 []string, _a3 string) (*entity.Message, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *entity.Message
+	if rf, ok := ret.Get(0).(func(string, string, []string, string) *entity.Message); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, []string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateReply provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *MessageRepository) CreateReply(_a0 string, _a1 string, _a2 string, _a3 entity.Reply) (*entity.Message, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *entity.Message
+	if rf, ok := ret.Get(0).(func(string, string, string, entity.Reply) *entity.Message); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, entity.Reply) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: _a0
+func (_m *MessageRepository) Delete(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteFor provides a mock function with given fields: _a0, _a1
+func (_m *MessageRepository) DeleteFor(_a0 string, _a1 string) (*entity.Message, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.Message
+	if rf, ok := ret.Get(0).(func(string, string) *entity.Message); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteForEveryone provides a mock function with given fields: _a0
+func (_m *MessageRepository) DeleteForEveryone(_a0 string) (*entity.Message, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.Message
+	if rf, ok := ret.Get(0).(func(string) *entity.Message); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Edit provides a mock function with given fields: _a0, _a1
+func (_m *MessageRepository) Edit(_a0 string, _a1 string) (*entity.Message, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.Message
+	if rf, ok := ret.Get(0).(func(string, string) *entity.Message); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetById provides a mock function with given fields: _a0
 func (_m *MessageRepository) GetById(_a0 string) (*entity.Message, error) {
 	ret := _m.Called(_a0)
@@ -77,3 +276,118 @@ func (_m *MessageRepository) GetById(_a0 string) (*entity.Message, error) {
 
 	return r0, r1
 }
+
+// GetByIds provides a mock function with given fields: _a0
+func (_m *MessageRepository) GetByIds(_a0 []string) ([]entity.Message, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []entity.Message
+	if rf, ok := ret.Get(0).(func([]string) []entity.Message); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRead provides a mock function with given fields: _a0, _a1
+func (_m *MessageRepository) MarkRead(_a0 string, _a1 string) (*entity.Message, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.Message
+	if rf, ok := ret.Get(0).(func(string, string) *entity.Message); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PageWithUser provides a mock function with given fields: _a0, _a1
+func (_m *MessageRepository) PageWithUser(_a0 string, _a1 repository.MessagePageOptions) (*repository.MessagePage, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *repository.MessagePage
+	if rf, ok := ret.Get(0).(func(string, repository.MessagePageOptions) *repository.MessagePage); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*repository.MessagePage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, repository.MessagePageOptions) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveReaction provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MessageRepository) RemoveReaction(_a0 string, _a1 string, _a2 string) (*entity.Message, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *entity.Message
+	if rf, ok := ret.Get(0).(func(string, string, string) *entity.Message); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: _a0, _a1
+func (_m *MessageRepository) Search(_a0 string, _a1 repository.SearchOptions) ([]repository.SearchResult, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []repository.SearchResult
+	if rf, ok := ret.Get(0).(func(string, repository.SearchOptions) []repository.SearchResult); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]repository.SearchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, repository.SearchOptions) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,58 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+import time "time"
+
+// OidcFlowRepository is an autogenerated mock type for the OidcFlowRepository type
+type OidcFlowRepository struct {
+	mock.Mock
+}
+
+// Consume provides a mock function with given fields: _a0
+func (_m *OidcFlowRepository) Consume(_a0 string) (*entity.OidcFlow, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.OidcFlow
+	if rf, ok := ret.Get(0).(func(string) *entity.OidcFlow); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.OidcFlow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
+func (_m *OidcFlowRepository) Create(_a0 string, _a1 string, _a2 string, _a3 string, _a4 time.Time) (*entity.OidcFlow, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
+
+	var r0 *entity.OidcFlow
+	if rf, ok := ret.Get(0).(func(string, string, string, string, time.Time) *entity.OidcFlow); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.OidcFlow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, string, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
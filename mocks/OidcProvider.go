@@ -0,0 +1,61 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+// OidcProvider is an autogenerated mock type for the OidcProvider type
+type OidcProvider struct {
+	mock.Mock
+}
+
+// Authorize provides a mock function with given fields:
+func (_m *OidcProvider) Authorize() (string, string, error) {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func() string); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func() error); ok {
+		r2 = rf()
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Exchange provides a mock function with given fields: _a0, _a1, _a2
+func (_m *OidcProvider) Exchange(_a0 string, _a1 string, _a2 string) (*entity.Identity, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *entity.Identity
+	if rf, ok := ret.Get(0).(func(string, string, string) *entity.Identity); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Identity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,54 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+// PasswordResetIssuer is an autogenerated mock type for the PasswordResetIssuer type
+type PasswordResetIssuer struct {
+	mock.Mock
+}
+
+// Consume provides a mock function with given fields: _a0
+func (_m *PasswordResetIssuer) Consume(_a0 string) (*entity.User, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(string) *entity.User); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Issue provides a mock function with given fields: _a0
+func (_m *PasswordResetIssuer) Issue(_a0 entity.User) (string, error) {
+	ret := _m.Called(_a0)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(entity.User) string); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(entity.User) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,58 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+import time "time"
+
+// PasswordResetRepository is an autogenerated mock type for the PasswordResetRepository type
+type PasswordResetRepository struct {
+	mock.Mock
+}
+
+// Consume provides a mock function with given fields: _a0
+func (_m *PasswordResetRepository) Consume(_a0 string) (*entity.PasswordReset, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.PasswordReset
+	if rf, ok := ret.Get(0).(func(string) *entity.PasswordReset); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.PasswordReset)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PasswordResetRepository) Create(_a0 string, _a1 string, _a2 string, _a3 time.Time) (*entity.PasswordReset, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 *entity.PasswordReset
+	if rf, ok := ret.Get(0).(func(string, string, string, time.Time) *entity.PasswordReset); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.PasswordReset)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,91 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+// PresenceTracker is an autogenerated mock type for the PresenceTracker type
+type PresenceTracker struct {
+	mock.Mock
+}
+
+// Connect provides a mock function with given fields: _a0
+func (_m *PresenceTracker) Connect(_a0 entity.User) (error, func()) {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(entity.User) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	var r1 func()
+	if rf, ok := ret.Get(1).(func(entity.User) func()); ok {
+		r1 = rf(_a0)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func())
+		}
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: _a0
+func (_m *PresenceTracker) Get(_a0 entity.User) (entity.Presence, error) {
+	ret := _m.Called(_a0)
+
+	var r0 entity.Presence
+	if rf, ok := ret.Get(0).(func(entity.User) entity.Presence); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(entity.Presence)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(entity.User) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with given fields: _a0
+func (_m *PresenceTracker) GetAll(_a0 []entity.User) ([]entity.Presence, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []entity.Presence
+	if rf, ok := ret.Get(0).(func([]entity.User) []entity.Presence); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Presence)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]entity.User) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Touch provides a mock function with given fields: _a0
+func (_m *PresenceTracker) Touch(_a0 entity.User) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(entity.User) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,61 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+// RefreshTokenIssuer is an autogenerated mock type for the RefreshTokenIssuer type
+type RefreshTokenIssuer struct {
+	mock.Mock
+}
+
+// Issue provides a mock function with given fields: _a0
+func (_m *RefreshTokenIssuer) Issue(_a0 entity.Session) (string, error) {
+	ret := _m.Called(_a0)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(entity.Session) string); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(entity.Session) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Rotate provides a mock function with given fields: _a0
+func (_m *RefreshTokenIssuer) Rotate(_a0 string) (*entity.Session, string, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.Session
+	if rf, ok := ret.Get(0).(func(string) *entity.Session); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Session)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string) string); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(_a0)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -0,0 +1,95 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import entity "github.com/asiragusa/wschat/entity"
+import mock "github.com/stretchr/testify/mock"
+
+import time "time"
+
+// RefreshTokenRepository is an autogenerated mock type for the RefreshTokenRepository type
+type RefreshTokenRepository struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
+func (_m *RefreshTokenRepository) Create(_a0 string, _a1 string, _a2 string, _a3 string, _a4 time.Time) (*entity.RefreshToken, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
+
+	var r0 *entity.RefreshToken
+	if rf, ok := ret.Get(0).(func(string, string, string, string, time.Time) *entity.RefreshToken); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RefreshToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, string, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteFamily provides a mock function with given fields: _a0
+func (_m *RefreshTokenRepository) DeleteFamily(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetById provides a mock function with given fields: _a0
+func (_m *RefreshTokenRepository) GetById(_a0 string) (*entity.RefreshToken, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.RefreshToken
+	if rf, ok := ret.Get(0).(func(string) *entity.RefreshToken); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RefreshToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Use provides a mock function with given fields: _a0
+func (_m *RefreshTokenRepository) Use(_a0 string) (*entity.RefreshToken, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.RefreshToken
+	if rf, ok := ret.Get(0).(func(string) *entity.RefreshToken); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.RefreshToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,24 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import mail "github.com/asiragusa/wschat/mail"
+import mock "github.com/stretchr/testify/mock"
+
+// Sender is an autogenerated mock type for the Sender type
+type Sender struct {
+	mock.Mock
+}
+
+// Send provides a mock function with given fields: _a0
+func (_m *Sender) Send(_a0 mail.Message) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(mail.Message) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -4,11 +4,59 @@ package mocks
 import entity "github.com/asiragusa/wschat/entity"
 import mock "github.com/stretchr/testify/mock"
 
+import time "time"
+
 // SubscriptionRepository is an autogenerated mock type for the SubscriptionRepository type
 type SubscriptionRepository struct {
 	mock.Mock
 }
 
+// AllLive provides a mock function with given fields: _a0
+func (_m *SubscriptionRepository) AllLive(_a0 time.Time) ([]entity.Subscription, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []entity.Subscription
+	if rf, ok := ret.Get(0).(func(time.Time) []entity.Subscription); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Subscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AllStale provides a mock function with given fields: _a0
+func (_m *SubscriptionRepository) AllStale(_a0 time.Time) ([]entity.Subscription, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []entity.Subscription
+	if rf, ok := ret.Get(0).(func(time.Time) []entity.Subscription); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Subscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AllTo provides a mock function with given fields: _a0
 func (_m *SubscriptionRepository) AllTo(_a0 string) ([]entity.Subscription, error) {
 	ret := _m.Called(_a0)
@@ -68,3 +116,17 @@ func (_m *SubscriptionRepository) Delete(_a0 string) error {
 
 	return r0
 }
+
+// Touch provides a mock function with given fields: _a0
+func (_m *SubscriptionRepository) Touch(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -12,18 +12,18 @@ type TokenGenerator struct {
 }
 
 // GenerateToken provides a mock function with given fields: _a0, _a1
-func (_m *TokenGenerator) GenerateToken(_a0 entity.User, _a1 time.Duration) (string, error) {
+func (_m *TokenGenerator) GenerateToken(_a0 entity.Session, _a1 time.Duration) (string, error) {
 	ret := _m.Called(_a0, _a1)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(entity.User, time.Duration) string); ok {
+	if rf, ok := ret.Get(0).(func(entity.Session, time.Duration) string); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(entity.User, time.Duration) error); ok {
+	if rf, ok := ret.Get(1).(func(entity.Session, time.Duration) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -33,7 +33,7 @@ func (_m *TokenGenerator) GenerateToken(_a0 entity.User, _a1 time.Duration) (str
 }
 
 // ValidateToken provides a mock function with given fields: _a0
-func (_m *TokenGenerator) ValidateToken(_a0 string) (*entity.User, error) {
+func (_m *TokenGenerator) ValidateToken(_a0 string) (*entity.User, *entity.Session, error) {
 	ret := _m.Called(_a0)
 
 	var r0 *entity.User
@@ -45,12 +45,21 @@ func (_m *TokenGenerator) ValidateToken(_a0 string) (*entity.User, error) {
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
+	var r1 *entity.Session
+	if rf, ok := ret.Get(1).(func(string) *entity.Session); ok {
 		r1 = rf(_a0)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*entity.Session)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(_a0)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
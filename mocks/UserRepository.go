@@ -32,6 +32,29 @@ func (_m *UserRepository) All() ([]entity.User, error) {
 	return r0, r1
 }
 
+// CreateExternalUser provides a mock function with given fields: _a0
+func (_m *UserRepository) CreateExternalUser(_a0 string) (*entity.User, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(string) *entity.User); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateUser provides a mock function with given fields: _a0, _a1
 func (_m *UserRepository) CreateUser(_a0 string, _a1 string) (*entity.User, error) {
 	ret := _m.Called(_a0, _a1)
@@ -55,6 +78,52 @@ func (_m *UserRepository) CreateUser(_a0 string, _a1 string) (*entity.User, erro
 	return r0, r1
 }
 
+// DisableTotp provides a mock function with given fields: _a0
+func (_m *UserRepository) DisableTotp(_a0 string) (*entity.User, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(string) *entity.User); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnableTotp provides a mock function with given fields: _a0, _a1, _a2
+func (_m *UserRepository) EnableTotp(_a0 string, _a1 string, _a2 []string) (*entity.User, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(string, string, []string) *entity.User); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, []string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserByEmail provides a mock function with given fields: _a0
 func (_m *UserRepository) GetUserByEmail(_a0 string) (*entity.User, error) {
 	ret := _m.Called(_a0)
@@ -123,3 +192,137 @@ func (_m *UserRepository) Login(_a0 string, _a1 string) (*entity.User, error) {
 
 	return r0, r1
 }
+
+// RotateSecret provides a mock function with given fields: _a0
+func (_m *UserRepository) RotateSecret(_a0 string) (*entity.User, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(string) *entity.User); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetEmailVerified provides a mock function with given fields: _a0
+func (_m *UserRepository) SetEmailVerified(_a0 string) (*entity.User, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(string) *entity.User); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPendingTotpSecret provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) SetPendingTotpSecret(_a0 string, _a1 string) (*entity.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(string, string) *entity.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Touch provides a mock function with given fields: _a0
+func (_m *UserRepository) Touch(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) UpdatePassword(_a0 string, _a1 string) (*entity.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *entity.User
+	if rf, ok := ret.Get(0).(func(string, string) *entity.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UseRecoveryCode provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) UseRecoveryCode(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseTotpCounter provides a mock function with given fields: _a0, _a1
+func (_m *UserRepository) UseTotpCounter(_a0 string, _a1 int64) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -112,6 +112,7 @@ $(function () {
         jQuery.ajax({
             url: "/messages",
             type: "GET",
+            data: {limit: 100},
             dataType: "json",
             headers: {
                 Authorization: "Bearer " + accessToken
@@ -1,6 +1,7 @@
 $(function () {
     var loginModal = $("#loginModal");
     var logoutButton = $("#logoutBtn");
+    var logoutAllButton = $("#logoutAllBtn");
     var signupEmail = $("#signupInputEmail");
     var signupPassword = $("#signupInputPassword");
     var loginEmail = $("#loginInputEmail");
@@ -8,23 +9,105 @@ $(function () {
 
     var user;
 
-    var accessToken = localStorage.getItem("accessToken");
-    if (accessToken) {
-        // Use the current access token
-        logoutButton.removeClass("hidden");
-        loginModal.trigger("accessToken", [accessToken, localStorage.getItem("user")]);
+    // Verification link sent by email
+    var verifyToken = new URLSearchParams(window.location.search).get("verify");
+    if (verifyToken) {
+        window.history.replaceState(null, "", "/");
+        jQuery.ajax({
+            url: "/verify",
+            type: "POST",
+            data: JSON.stringify({
+                token: verifyToken
+            }),
+            contentType: "application/json; charset=utf-8"
+        }).done(function () {
+            alert("Your email has been verified");
+        }).fail(onFail);
+    }
+
+    // Redirection of the OpenID Connect provider, after the login
+    var params = new URLSearchParams(window.location.search);
+    if (params.get("oidc") == "callback") {
+        window.history.replaceState(null, "", "/");
+        var flowToken = sessionStorage.getItem("oidcFlowToken");
+        sessionStorage.removeItem("oidcFlowToken");
+
+        if (params.get("error")) {
+            alert("Error: " + params.get("error"));
+            showLogin();
+        } else {
+            jQuery.ajax({
+                url: "/oidc/login",
+                type: "POST",
+                data: JSON.stringify({
+                    code: params.get("code"),
+                    state: params.get("state"),
+                    flowToken: flowToken
+                }),
+                contentType: "application/json; charset=utf-8",
+                dataType: "json"
+            }).done(function (data) {
+                user = data.email;
+                onLogin(data);
+            }).fail(function (jqXHR) {
+                onFail(jqXHR);
+                showLogin();
+            });
+        }
+        return;
+    }
+
+    var refreshToken = localStorage.getItem("refreshToken");
+    if (refreshToken) {
+        // The access token is short lived, get a new one with the refresh token
+        user = localStorage.getItem("user");
+        jQuery.ajax({
+            url: "/token/refresh",
+            type: "POST",
+            data: JSON.stringify({
+                refreshToken: refreshToken
+            }),
+            contentType: "application/json; charset=utf-8",
+            dataType: "json"
+        }).done(onSuccess).fail(showLogin);
     } else {
-        // Show the login modal
+        showLogin();
+    }
+
+    // Show the login modal
+    function showLogin() {
         logoutButton.addClass("hidden");
+        logoutAllButton.addClass("hidden");
+        localStorage.removeItem("accessToken");
+        localStorage.removeItem("refreshToken");
         loginModal.modal();
     }
 
+    // Closes the session on the server, then forgets the tokens
+    function logout(url, data) {
+        jQuery.ajax({
+            url: url,
+            type: "POST",
+            data: JSON.stringify(data),
+            contentType: "application/json; charset=utf-8",
+            headers: {
+                Authorization: "Bearer " + localStorage.getItem("accessToken")
+            }
+        }).always(function () {
+            localStorage.removeItem("accessToken");
+            localStorage.removeItem("refreshToken");
+            window.location.reload();
+        });
+    }
+
     // Logout button handler
     logoutButton.on("click", function () {
-        logoutButton.addClass("hidden");
-        localStorage.removeItem("accessToken");
-        window.location.reload();
-        loginModal.modal();
+        logout("/logout", {});
+    });
+
+    // Logout everywhere button handler
+    logoutAllButton.on("click", function () {
+        logout("/logout/all", {});
     });
 
     // XHR fail handler
@@ -36,7 +119,9 @@ $(function () {
     // XHR success handler, common to login & register
     function onSuccess(data) {
         logoutButton.removeClass("hidden");
+        logoutAllButton.removeClass("hidden");
         localStorage.setItem("accessToken", data.accessToken);
+        localStorage.setItem("refreshToken", data.refreshToken);
         localStorage.setItem("user", user);
         loginModal.trigger("accessToken", [data.accessToken, user]);
         loginModal.modal("hide")
@@ -52,6 +137,19 @@ $(function () {
         }
     });
 
+    // Single sign-on button handler, redirects to the OpenID Connect provider. The flow token is kept until the
+    // provider redirects back
+    $("#oidcLoginButton").on("click", function () {
+        jQuery.ajax({
+            url: "/oidc/authorize",
+            type: "POST",
+            dataType: "json"
+        }).done(function (data) {
+            sessionStorage.setItem("oidcFlowToken", data.flowToken);
+            window.location = data.authorizationUrl;
+        }).fail(onFail);
+    });
+
     // Signup
     function signup() {
         user = signupEmail.val();
@@ -79,6 +177,29 @@ $(function () {
             }),
             contentType: "application/json; charset=utf-8",
             dataType: "json"
+        }).done(onLogin).fail(onFail);
+    }
+
+    // Login success handler, asks for the second factor if the user has enabled it
+    function onLogin(data) {
+        if (!data.mfaRequired) {
+            onSuccess(data);
+            return;
+        }
+
+        var code = prompt("Authentication code or recovery code");
+        if (!code) {
+            return;
+        }
+        jQuery.ajax({
+            url: "/login/mfa",
+            type: "POST",
+            data: JSON.stringify({
+                mfaToken: data.mfaToken,
+                code: code
+            }),
+            contentType: "application/json; charset=utf-8",
+            dataType: "json"
         }).done(onSuccess).fail(onFail);
     }
 });
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"cloud.google.com/go/datastore"
+	"context"
+	"errors"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+)
+
+var (
+	// Error thrown when the attachment has not been found
+	AttachmentNotFoundError = errors.New("Attachment not found")
+
+	// Error thrown when the attachment is already attached to another message
+	AttachmentAttachedError = errors.New("Attachment already attached")
+)
+
+// Interface used mainly for Unit testing
+type AttachmentRepository interface {
+	GetById(string) (*entity.Attachment, error)
+	Create(string, string, string, int64, string) (*entity.Attachment, error)
+	Attach(string, string) (*entity.Attachment, error)
+	Detach(string, string) error
+	Delete(string) error
+}
+
+// Attachment Repository
+type Attachment struct {
+	// Injected via DI
+	Client *datastore.Client `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+	kind  string
+}
+
+func NewAttachmentRepository() *Attachment {
+	return &Attachment{
+		kind: "Attachment",
+	}
+}
+
+// Fetch an attachment by ID
+func (r Attachment) GetById(id string) (*entity.Attachment, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+
+	entity := &entity.Attachment{}
+	err := r.Client.Get(ctx, key, entity)
+	if err == datastore.ErrNoSuchEntity {
+		return nil, AttachmentNotFoundError
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// Creates a new attachment, not attached to any message
+func (r Attachment) Create(owner, name, mimeType string, size int64, checksum string) (*entity.Attachment, error) {
+	entity := &entity.Attachment{
+		Id:        uuid.NewV4().String(),
+		Owner:     owner,
+		Name:      name,
+		MimeType:  mimeType,
+		Size:      size,
+		Checksum:  checksum,
+		CreatedAt: r.Clock.Now(),
+	}
+
+	key := datastore.NameKey(r.kind, entity.Id, nil)
+
+	ctx := context.Background()
+	if _, err := r.Client.Put(ctx, key, entity); err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// Attaches the attachment to the message. Attaching it again to the same message is a no-op
+func (r Attachment) Attach(id, messageId string) (*entity.Attachment, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	var attachment entity.Attachment
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		err := tx.Get(key, &attachment)
+		if err == datastore.ErrNoSuchEntity {
+			return AttachmentNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		if attachment.MessageId != "" && attachment.MessageId != messageId {
+			return AttachmentAttachedError
+		}
+		attachment.MessageId = messageId
+
+		_, err = tx.Put(key, &attachment)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// Detaches the attachment from the message, so that it can be sent again. It is a no-op if the attachment is not
+// attached to the message
+func (r Attachment) Detach(id, messageId string) error {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var attachment entity.Attachment
+
+		err := tx.Get(key, &attachment)
+		if err == datastore.ErrNoSuchEntity {
+			return AttachmentNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		if attachment.MessageId != messageId {
+			return nil
+		}
+		attachment.MessageId = ""
+
+		_, err = tx.Put(key, &attachment)
+		return err
+	})
+
+	return err
+}
+
+// Deletes the attachment. Deleting a missing attachment is a no-op
+func (r Attachment) Delete(id string) error {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	return r.Client.Delete(ctx, key)
+}
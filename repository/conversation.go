@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"cloud.google.com/go/datastore"
+	"context"
+	"errors"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+)
+
+var (
+	// Error thrown when the conversation has not been found
+	ConversationNotFoundError = errors.New("Conversation not found")
+)
+
+// Interface used mainly for Unit testing
+type ConversationRepository interface {
+	GetById(string) (*entity.Conversation, error)
+	AllWithMember(string) ([]entity.Conversation, error)
+	Create(string, string, []string) (*entity.Conversation, error)
+	AddMember(string, string) (*entity.Conversation, error)
+	RemoveMember(string, string) (*entity.Conversation, error)
+	Rename(string, string) (*entity.Conversation, error)
+}
+
+// Conversation Repository
+type Conversation struct {
+	// Injected via DI
+	Client *datastore.Client `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+	kind  string
+}
+
+func NewConversationRepository() *Conversation {
+	return &Conversation{
+		kind: "Conversation",
+	}
+}
+
+// Fetch a conversation by ID
+func (r Conversation) GetById(id string) (*entity.Conversation, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+
+	entity := &entity.Conversation{}
+	err := r.Client.Get(ctx, key, entity)
+	if err == datastore.ErrNoSuchEntity {
+		return nil, ConversationNotFoundError
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// Fetch all the conversations the user is a member of
+func (r Conversation) AllWithMember(email string) ([]entity.Conversation, error) {
+	query := datastore.NewQuery(r.kind).Filter("Members =", email).Order("CreatedAt")
+
+	entities := []entity.Conversation{}
+	ctx := context.Background()
+	_, err := r.Client.GetAll(ctx, query, &entities)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+// Creates a new conversation. The creator is always added to the members
+func (r Conversation) Create(name, createdBy string, members []string) (*entity.Conversation, error) {
+	entity := &entity.Conversation{
+		Id:        uuid.NewV4().String(),
+		Name:      name,
+		Members:   uniqueMembers(append([]string{createdBy}, members...)),
+		CreatedBy: createdBy,
+		CreatedAt: r.Clock.Now(),
+	}
+
+	key := datastore.NameKey(r.kind, entity.Id, nil)
+
+	ctx := context.Background()
+	if _, err := r.Client.Put(ctx, key, entity); err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// Adds a member to the conversation. Adding an existing member is a no-op
+func (r Conversation) AddMember(id, email string) (*entity.Conversation, error) {
+	return r.update(id, func(conversation *entity.Conversation) {
+		conversation.Members = uniqueMembers(append(conversation.Members, email))
+	})
+}
+
+// Removes a member from the conversation. Removing a non member is a no-op
+func (r Conversation) RemoveMember(id, email string) (*entity.Conversation, error) {
+	return r.update(id, func(conversation *entity.Conversation) {
+		members := []string{}
+		for _, member := range conversation.Members {
+			if member != email {
+				members = append(members, member)
+			}
+		}
+		conversation.Members = members
+	})
+}
+
+// Renames the conversation
+func (r Conversation) Rename(id, name string) (*entity.Conversation, error) {
+	return r.update(id, func(conversation *entity.Conversation) {
+		conversation.Name = name
+	})
+}
+
+// Applies fn to the conversation in a transaction and stores the result
+func (r Conversation) update(id string, fn func(*entity.Conversation)) (*entity.Conversation, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	var conversation entity.Conversation
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		err := tx.Get(key, &conversation)
+		if err == datastore.ErrNoSuchEntity {
+			return ConversationNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		fn(&conversation)
+
+		_, err = tx.Put(key, &conversation)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &conversation, nil
+}
+
+// Removes the duplicates from the members list, preserving the order
+func uniqueMembers(members []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+
+	for _, member := range members {
+		if seen[member] {
+			continue
+		}
+		seen[member] = true
+		unique = append(unique, member)
+	}
+
+	return unique
+}
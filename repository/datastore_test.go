@@ -0,0 +1,38 @@
+package repository_test
+
+import (
+	"cloud.google.com/go/datastore"
+	"context"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+// Skips the test if the datastore emulator is not available
+func skipWithoutEmulator(t *testing.T) {
+	if os.Getenv("DATASTORE_EMULATOR_HOST") == "" {
+		t.Skip("DATASTORE_EMULATOR_HOST is not set")
+	}
+}
+
+func getDatastoreClient(projectID string) (*datastore.Client, error) {
+	ctx := context.Background()
+
+	client, err := datastore.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Deletes all the entities of the datastore
+func cleanDb(t *testing.T, client *datastore.Client) {
+	query := datastore.NewQuery("").KeysOnly()
+	ctx := context.Background()
+
+	keys, err := client.GetAll(ctx, query, nil)
+	require.NoError(t, err)
+
+	err = client.DeleteMulti(ctx, keys)
+	require.NoError(t, err)
+}
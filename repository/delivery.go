@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"cloud.google.com/go/datastore"
+	"context"
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+)
+
+// Interface used mainly for Unit testing
+type DeliveryRepository interface {
+	AllAfter(string, int64, int) ([]entity.Delivery, error)
+	Create(string, string) (*entity.Delivery, error)
+}
+
+// Delivery repository, used by services.PubsubClient
+type Delivery struct {
+	// Injected via DI
+	Client *datastore.Client `inject:""`
+
+	// Injected via DI
+	Clock        clockwork.Clock `inject:""`
+	kind         string
+	sequenceKind string
+}
+
+func NewDeliveryRepository() *Delivery {
+	return &Delivery{
+		kind:         "Delivery",
+		sequenceKind: "Sequence",
+	}
+}
+
+// Returns at most limit deliveries to the "to" user having a sequence number greater than seq, sorted by Seq
+func (r Delivery) AllAfter(to string, seq int64, limit int) ([]entity.Delivery, error) {
+	query := datastore.NewQuery(r.kind).Filter("To =", to).Filter("Seq >", seq).Order("Seq").Limit(limit)
+
+	entities := []entity.Delivery{}
+	ctx := context.Background()
+	_, err := r.Client.GetAll(ctx, query, &entities)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+// Records the delivery of a message to the "to" user, allocating the next sequence number of the user
+func (r Delivery) Create(to, messageId string) (*entity.Delivery, error) {
+	delivery := &entity.Delivery{
+		To:        to,
+		MessageId: messageId,
+		CreatedAt: r.Clock.Now(),
+	}
+
+	sequenceKey := datastore.NameKey(r.sequenceKind, to, nil)
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		sequence := entity.Sequence{
+			Id: to,
+		}
+
+		err := tx.Get(sequenceKey, &sequence)
+		if err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+
+		sequence.Value++
+		if _, err := tx.Put(sequenceKey, &sequence); err != nil {
+			return err
+		}
+
+		delivery.Seq = sequence.Value
+		delivery.Id = fmt.Sprintf("%s/%d", to, delivery.Seq)
+
+		_, err = tx.Put(datastore.NameKey(r.kind, delivery.Id, nil), delivery)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
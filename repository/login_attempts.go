@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"cloud.google.com/go/datastore"
+	"context"
+	"errors"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+var (
+	// Error thrown when no failed login attempt has been recorded for the key
+	LoginAttemptsNotFoundError = errors.New("Login attempts not found")
+)
+
+// Interface used mainly for Unit testing
+type LoginAttemptRepository interface {
+	Get(string) (*entity.LoginAttempts, error)
+	RecordFailure(string, time.Time) (*entity.LoginAttempts, error)
+	Reset(string) error
+}
+
+// Login Attempt Repository
+type LoginAttempts struct {
+	// Injected via DI
+	Client *datastore.Client `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+	kind  string
+}
+
+func NewLoginAttemptRepository() *LoginAttempts {
+	return &LoginAttempts{
+		kind: "LoginAttempts",
+	}
+}
+
+// Fetches the failed login attempts of the key
+func (r LoginAttempts) Get(key string) (*entity.LoginAttempts, error) {
+	k := datastore.NameKey(r.kind, key, nil)
+
+	ctx := context.Background()
+
+	entity := &entity.LoginAttempts{}
+	err := r.Client.Get(ctx, k, entity)
+	if err == datastore.ErrNoSuchEntity {
+		return nil, LoginAttemptsNotFoundError
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// Counts a failed login attempt of the key. The count restarts if the last failure happened before since
+func (r LoginAttempts) RecordFailure(key string, since time.Time) (*entity.LoginAttempts, error) {
+	k := datastore.NameKey(r.kind, key, nil)
+
+	var attempts entity.LoginAttempts
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		err := tx.Get(k, &attempts)
+		if err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+
+		if err == datastore.ErrNoSuchEntity || attempts.LastFailureAt.Before(since) {
+			attempts = entity.LoginAttempts{Key: key}
+		}
+		attempts.Failures++
+		attempts.LastFailureAt = r.Clock.Now()
+
+		_, err = tx.Put(k, &attempts)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempts, nil
+}
+
+// Forgets the failed login attempts of the key
+func (r LoginAttempts) Reset(key string) error {
+	k := datastore.NameKey(r.kind, key, nil)
+
+	ctx := context.Background()
+	return r.Client.Delete(ctx, k)
+}
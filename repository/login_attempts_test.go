@@ -0,0 +1,28 @@
+package repository_test
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/repository/repositorytest"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestLoginAttemptRepository(t *testing.T) {
+	skipWithoutEmulator(t)
+
+	client, err := getDatastoreClient("test")
+	require.NoError(t, err)
+
+	suite.Run(t, &repositorytest.LoginAttemptSuite{
+		New: func(t *testing.T, clock clockwork.Clock) repository.LoginAttemptRepository {
+			cleanDb(t, client)
+
+			r := repository.NewLoginAttemptRepository()
+			r.Client = client
+			r.Clock = clock
+			return r
+		},
+	})
+}
@@ -3,22 +3,74 @@ package repository
 import (
 	"cloud.google.com/go/datastore"
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"github.com/asiragusa/wschat/entity"
 	"github.com/jonboulle/clockwork"
 	"github.com/satori/go.uuid"
+	"google.golang.org/api/iterator"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
 	// Error thrown when the message has not been found
 	MessageNotFoundError = errors.New("Not found")
+
+	// Error thrown when a pagination cursor can't be decoded
+	InvalidCursorError = errors.New("Invalid cursor")
 )
 
+// Options used to fetch a page of messages
+type MessagePageOptions struct {
+	// Only return the direct messages exchanged with this user, if not empty
+	With string
+
+	// Only return the messages of this conversation, if not empty
+	ConversationId string
+
+	// Maximum number of messages to return
+	Limit int
+
+	// Return the messages older than this cursor, if not empty
+	Before string
+
+	// Return the messages newer than this cursor, if not empty
+	After string
+}
+
+// A page of messages, sorted by CreatedAt
+type MessagePage struct {
+	Messages []entity.Message
+
+	// Cursor to be used as Before to fetch the older messages. Empty if there are none
+	NextCursor string
+
+	// Cursor to be used as After to fetch the newer messages. Empty if the page contains the newest message
+	PrevCursor string
+}
+
 // Interface used mainly for Unit testing
 type MessageRepository interface {
 	GetById(string) (*entity.Message, error)
+	GetByIds([]string) ([]entity.Message, error)
 	AllWithUser(string) ([]entity.Message, error)
+	PageWithUser(string, MessagePageOptions) (*MessagePage, error)
+	Search(string, SearchOptions) ([]SearchResult, error)
+	Contacts(string) ([]string, error)
 	Create(string, string, string) (*entity.Message, error)
+	CreateReply(string, string, string, entity.Reply) (*entity.Message, error)
+	CreateInConversation(string, string, []string, string) (*entity.Message, error)
+	MarkRead(string, string) (*entity.Message, error)
+	Edit(string, string) (*entity.Message, error)
+	DeleteFor(string, string) (*entity.Message, error)
+	DeleteForEveryone(string) (*entity.Message, error)
+	Delete(string) error
+	Attach(string, []entity.Attachment) (*entity.Message, error)
+	AddReaction(string, string, string) (*entity.Message, error)
+	RemoveReaction(string, string, string) (*entity.Message, error)
 }
 
 // Message Repository
@@ -27,13 +79,21 @@ type Message struct {
 	Client *datastore.Client `inject:""`
 
 	// Injected via DI
-	Clock clockwork.Clock `inject:""`
-	kind  string
+	Clock       clockwork.Clock `inject:""`
+	kind        string
+	contactKind string
+}
+
+// Users having exchanged direct messages, stored once for each of them
+type contact struct {
+	Owner   string
+	Contact string
 }
 
 func NewMessageRepository() *Message {
 	return &Message{
-		kind: "Message",
+		kind:        "Message",
+		contactKind: "Contact",
 	}
 }
 
@@ -56,6 +116,40 @@ func (r Message) GetById(id string) (*entity.Message, error) {
 	return entity, nil
 }
 
+// Fetch the messages by ID, in the same order. The messages not found are skipped
+func (r Message) GetByIds(ids []string) ([]entity.Message, error) {
+	keys := make([]*datastore.Key, len(ids))
+	for i, id := range ids {
+		keys[i] = datastore.NameKey(r.kind, id, nil)
+	}
+
+	ctx := context.Background()
+
+	entities := make([]entity.Message, len(ids))
+	err := r.Client.GetMulti(ctx, keys, entities)
+	if err == nil {
+		return entities, nil
+	}
+
+	multiErr, ok := err.(datastore.MultiError)
+	if !ok {
+		return nil, err
+	}
+
+	found := []entity.Message{}
+	for i, err := range multiErr {
+		if err == datastore.ErrNoSuchEntity {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, entities[i])
+	}
+
+	return found, nil
+}
+
 // Fetch all messages belonging to an user
 func (r Message) AllWithUser(email string) ([]entity.Message, error) {
 	query := datastore.NewQuery(r.kind).Filter("Users =", email).Order("CreatedAt")
@@ -72,23 +166,429 @@ func (r Message) AllWithUser(email string) ([]entity.Message, error) {
 
 }
 
+// Position of a message in the history, used as pagination cursor. The Id sorts the messages created at the same time
+type messageCursor struct {
+	createdAt time.Time
+	id        string
+}
+
+// Encodes the position of the message
+func encodeCursor(message entity.Message) string {
+	cursor := fmt.Sprintf("%d/%s", message.CreatedAt.UnixNano(), message.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+// Decodes a cursor created by encodeCursor
+func decodeCursor(encoded string) (*messageCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, InvalidCursorError
+	}
+
+	parts := strings.SplitN(string(decoded), "/", 2)
+	if len(parts) != 2 {
+		return nil, InvalidCursorError
+	}
+
+	nano, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, InvalidCursorError
+	}
+
+	return &messageCursor{time.Unix(0, nano), parts[1]}, nil
+}
+
+// Fetch a page of the messages belonging to an user.
+// NextCursor points to the oldest message of the page, Before returns the messages strictly older than it.
+// PrevCursor points to the message following the page, After returns the page starting from it
+func (r Message) PageWithUser(email string, options MessagePageOptions) (*MessagePage, error) {
+	query := datastore.NewQuery(r.kind).Filter("Users =", email)
+	if options.With != "" {
+		query = query.Filter("Users =", options.With).Filter("ConversationId =", "")
+	}
+	if options.ConversationId != "" {
+		query = query.Filter("ConversationId =", options.ConversationId)
+	}
+
+	page := &MessagePage{
+		Messages: []entity.Message{},
+	}
+
+	var err error
+	if options.After != "" {
+		err = r.pageAfter(query, options, page)
+	} else {
+		err = r.pageBefore(query, options, page)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Fetches up to options.Limit messages older than options.Before, or the newest ones if it's empty
+func (r Message) pageBefore(query *datastore.Query, options MessagePageOptions, page *MessagePage) error {
+	skip := func(entity.Message) bool { return false }
+	if options.Before != "" {
+		before, err := decodeCursor(options.Before)
+		if err != nil {
+			return err
+		}
+		query = query.Filter("CreatedAt <=", before.createdAt)
+
+		// The messages created at the same time as the cursor are sorted by Id
+		skip = func(message entity.Message) bool {
+			return message.CreatedAt.Equal(before.createdAt) && message.Id >= before.id
+		}
+
+		// Messages newer than the cursor have been returned by a previous page
+		page.PrevCursor = options.Before
+	}
+
+	// Fetch one more message to know if there is a next page
+	messages, err := r.fetch(query.Order("-CreatedAt").Order("-__key__"), options.Limit+1, skip)
+	if err != nil {
+		return err
+	}
+
+	if len(messages) > options.Limit {
+		messages = messages[:options.Limit]
+		page.NextCursor = encodeCursor(messages[len(messages)-1])
+	}
+
+	// The messages have been fetched from the newest, return them in chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	page.Messages = messages
+
+	return nil
+}
+
+// Fetches the options.Limit messages starting from options.After, walking the history from the oldest
+func (r Message) pageAfter(query *datastore.Query, options MessagePageOptions, page *MessagePage) error {
+	after, err := decodeCursor(options.After)
+	if err != nil {
+		return err
+	}
+
+	// The messages created at the same time as the cursor are sorted by Id
+	skip := func(message entity.Message) bool {
+		return message.CreatedAt.Equal(after.createdAt) && message.Id < after.id
+	}
+
+	// Fetch one more message to know if there is a newer page
+	query = query.Filter("CreatedAt >=", after.createdAt).Order("CreatedAt").Order("__key__")
+	messages, err := r.fetch(query, options.Limit+1, skip)
+	if err != nil {
+		return err
+	}
+
+	// The messages older than the page are fetched with the same cursor
+	page.NextCursor = options.After
+	if len(messages) > options.Limit {
+		page.PrevCursor = encodeCursor(messages[options.Limit])
+		messages = messages[:options.Limit]
+	}
+	page.Messages = messages
+
+	return nil
+}
+
+// Runs the query until limit messages not matched by skip have been read. Only the messages sharing the timestamp
+// of the cursor are skipped, the query is run again from where it stopped to replace them
+func (r Message) fetch(query *datastore.Query, limit int, skip func(entity.Message) bool) ([]entity.Message, error) {
+	messages := []entity.Message{}
+
+	for want := limit; want > 0; want = limit - len(messages) {
+		it := r.Client.Run(context.Background(), query.Limit(want))
+		for read := 0; read < want; read++ {
+			var message entity.Message
+			_, err := it.Next(&message)
+			if err == iterator.Done {
+				// No more messages
+				return messages, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if !skip(message) {
+				messages = append(messages, message)
+			}
+		}
+
+		cursor, err := it.Cursor()
+		if err != nil {
+			return nil, err
+		}
+		query = query.Start(cursor)
+	}
+
+	return messages, nil
+}
+
+// Search the messages belonging to an user containing all the terms, the most relevant first.
+// The messages are found with the Terms list property, only the MaxSearchCandidates newest matches are ranked
+func (r Message) Search(email string, options SearchOptions) ([]SearchResult, error) {
+	if len(options.Terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	query := datastore.NewQuery(r.kind).Filter("Users =", email)
+	for _, term := range options.Terms {
+		query = query.Filter("Terms =", term)
+	}
+	if options.With != "" {
+		query = query.Filter("Users =", options.With).Filter("ConversationId =", "")
+	}
+	if !options.Since.IsZero() {
+		query = query.Filter("CreatedAt >=", options.Since)
+	}
+	if !options.Until.IsZero() {
+		query = query.Filter("CreatedAt <", options.Until)
+	}
+	query = query.Order("-CreatedAt").Order("-__key__").Limit(MaxSearchCandidates)
+
+	entities := []entity.Message{}
+	if _, err := r.Client.GetAll(context.Background(), query, &entities); err != nil {
+		return nil, err
+	}
+
+	return Rank(entities, options), nil
+}
+
+// Returns the emails of the users having exchanged direct messages with the user, sorted
+func (r Message) Contacts(email string) ([]string, error) {
+	query := datastore.NewQuery(r.contactKind).Filter("Owner =", email).Order("Contact")
+
+	entities := []contact{}
+	if _, err := r.Client.GetAll(context.Background(), query, &entities); err != nil {
+		return nil, err
+	}
+
+	contacts := []string{}
+	for _, contact := range entities {
+		contacts = append(contacts, contact.Contact)
+	}
+
+	return contacts, nil
+}
+
+// Records the users of a direct message as contacts of each other. Recording them again is a no-op
+func (r Message) addContacts(message *entity.Message) error {
+	if message.ConversationId != "" || message.From == message.To {
+		return nil
+	}
+
+	keys := []*datastore.Key{
+		datastore.NameKey(r.contactKind, message.From+"/"+message.To, nil),
+		datastore.NameKey(r.contactKind, message.To+"/"+message.From, nil),
+	}
+	contacts := []contact{
+		{Owner: message.From, Contact: message.To},
+		{Owner: message.To, Contact: message.From},
+	}
+
+	_, err := r.Client.PutMulti(context.Background(), keys, contacts)
+	return err
+}
+
+// Records the contacts and indexes the terms of the messages stored before they were introduced. Returns the number
+// of messages walked
+func (r Message) Backfill() (int, error) {
+	query := datastore.NewQuery(r.kind)
+
+	count := 0
+	it := r.Client.Run(context.Background(), query)
+	for {
+		var message entity.Message
+		key, err := it.Next(&message)
+		if err == iterator.Done {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if err := r.addContacts(&message); err != nil {
+			return count, err
+		}
+
+		// Only the messages missing their terms are stored again
+		indexed := message.Terms
+		message.Index()
+		if !sameTerms(indexed, message.Terms) {
+			if _, err := r.Client.Put(context.Background(), key, &message); err != nil {
+				return count, err
+			}
+		}
+		count++
+	}
+}
+
+// Returns true if the lists of terms are equal
+func sameTerms(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Stores a new message
+func (r Message) create(message *entity.Message) (*entity.Message, error) {
+	message.Id = uuid.NewV4().String()
+	message.CreatedAt = r.Clock.Now()
+	message.Index()
+
+	// The contacts are recorded first, they are harmless if storing the message fails
+	if err := r.addContacts(message); err != nil {
+		return nil, err
+	}
+
+	key := datastore.NameKey(r.kind, message.Id, nil)
+
+	ctx := context.Background()
+	if _, err := r.Client.Put(ctx, key, message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
 // Creates a new message
 func (r Message) Create(from, to, message string) (*entity.Message, error) {
-	entity := &entity.Message{
-		Id:        uuid.NewV4().String(),
-		From:      from,
-		To:        to,
-		Message:   message,
-		Users:     []string{from, to},
-		CreatedAt: r.Clock.Now(),
-	}
+	return r.create(&entity.Message{
+		From:    from,
+		To:      to,
+		Message: message,
+		Users:   []string{from, to},
+	})
+}
 
-	key := datastore.NameKey(r.kind, entity.Id, nil)
+// Creates a new message replying to another one
+func (r Message) CreateReply(from, to, message string, replyTo entity.Reply) (*entity.Message, error) {
+	return r.create(&entity.Message{
+		From:    from,
+		To:      to,
+		Message: message,
+		Users:   []string{from, to},
+		ReplyTo: replyTo,
+	})
+}
+
+// Creates a new message in a conversation. members are the conversation members at the time of sending
+func (r Message) CreateInConversation(from, conversationId string, members []string, message string) (*entity.Message, error) {
+	return r.create(&entity.Message{
+		ConversationId: conversationId,
+		From:           from,
+		Message:        message,
+		Users:          members,
+	})
+}
+
+// Marks the message as read by the user, at the current time. Marking an already read message is a no-op
+func (r Message) MarkRead(id, email string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		if message.ReadBy(email) == nil {
+			message.Reads = append(message.Reads, entity.Receipt{
+				User:   email,
+				ReadAt: r.Clock.Now(),
+			})
+		}
+		return nil
+	})
+}
+
+// Replaces the text of the message, keeping the prior one as a revision
+func (r Message) Edit(id, text string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.Edit(text, r.Clock.Now())
+		return nil
+	})
+}
+
+// Adds the attachments to the message
+func (r Message) Attach(id string, attachments []entity.Attachment) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.Attachments = append(message.Attachments, attachments...)
+		return nil
+	})
+}
+
+// Adds the reaction of the user with the emoji. Adding it twice is a no-op
+func (r Message) AddReaction(id, email, emoji string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.AddReaction(email, emoji)
+		return nil
+	})
+}
+
+// Removes the reaction of the user with the emoji, if any
+func (r Message) RemoveReaction(id, email, emoji string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.RemoveReaction(email, emoji)
+		return nil
+	})
+}
+
+// Removes the user from the message, hiding it from the user's history
+func (r Message) DeleteFor(id, email string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.DeleteFor(email)
+		return nil
+	})
+}
+
+// Deletes the message for everyone, leaving a tombstone in the history of its users
+func (r Message) DeleteForEveryone(id string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.Delete(r.Clock.Now())
+		return nil
+	})
+}
+
+// Deletes the message, undoing its creation before it is published. Deleting a missing message is a no-op
+func (r Message) Delete(id string) error {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	return r.Client.Delete(ctx, key)
+}
+
+// Applies fn to the message in a transaction
+func (r Message) update(id string, fn func(*entity.Message) error) (*entity.Message, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	var message entity.Message
 
 	ctx := context.Background()
-	if _, err := r.Client.Put(ctx, key, entity); err != nil {
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		err := tx.Get(key, &message)
+		if err == datastore.ErrNoSuchEntity {
+			return MessageNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := fn(&message); err != nil {
+			return err
+		}
+
+		_, err = tx.Put(key, &message)
+		return err
+	})
+
+	if err != nil {
 		return nil, err
 	}
 
-	return entity, nil
+	return &message, nil
 }
@@ -1,22 +1,43 @@
-package repository
+package repository_test
 
 import (
 	"cloud.google.com/go/datastore"
 	"context"
 	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/repository/repositorytest"
 	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"testing"
-	"time"
 )
 
+func TestMessageRepository(t *testing.T) {
+	skipWithoutEmulator(t)
+
+	client, err := getDatastoreClient("test")
+	require.NoError(t, err)
+
+	suite.Run(t, &repositorytest.MessageSuite{
+		New: func(t *testing.T, clock clockwork.Clock) repository.MessageRepository {
+			cleanDb(t, client)
+
+			r := repository.NewMessageRepository()
+			r.Client = client
+			r.Clock = clock
+			return r
+		},
+	})
+}
+
+// Tests of the backfill of the messages stored by the previous versions, specific to the datastore
 type MessageRepositoryTestSuite struct {
 	suite.Suite
-	repository *Message
-	clock      clockwork.FakeClock
+	repository *repository.Message
 }
 
-func TestMessageRepository(t *testing.T) {
+func TestDatastoreMessageRepository(t *testing.T) {
+	skipWithoutEmulator(t)
 	suite.Run(t, new(MessageRepositoryTestSuite))
 }
 
@@ -24,78 +45,62 @@ func (suite *MessageRepositoryTestSuite) SetupSuite() {
 	client, err := getDatastoreClient("test")
 	suite.Require().NoError(err)
 
-	suite.repository = NewMessageRepository()
+	suite.repository = repository.NewMessageRepository()
 	suite.repository.Client = client
+	suite.repository.Clock = clockwork.NewFakeClock()
 }
 
-func (suite *MessageRepositoryTestSuite) cleanDb() {
-	query := datastore.NewQuery("").KeysOnly()
-	ctx := context.Background()
+func (suite *MessageRepositoryTestSuite) SetupTest() {
+	cleanDb(suite.T(), suite.repository.Client)
+}
 
-	keys, err := suite.repository.Client.GetAll(ctx, query, nil)
-	suite.Require().NoError(err)
+func (suite *MessageRepositoryTestSuite) search(email string, options repository.SearchOptions) []string {
+	if options.Limit == 0 {
+		options.Limit = 10
+	}
 
-	err = suite.repository.Client.DeleteMulti(ctx, keys)
+	results, err := suite.repository.Search(email, options)
 	suite.Require().NoError(err)
-}
-
-func (suite *MessageRepositoryTestSuite) SetupTest() {
-	suite.cleanDb()
 
-	suite.clock = clockwork.NewFakeClockAt(time.Now())
-	suite.repository.Clock = suite.clock
+	ids := []string{}
+	for _, result := range results {
+		ids = append(ids, result.Message.Id)
+	}
+	return ids
 }
 
-func (suite *MessageRepositoryTestSuite) createMessage(from, to, message string) *entity.Message {
-	entity, err := suite.repository.Create(from, to, message)
+func (suite *MessageRepositoryTestSuite) TestBackfillContacts() {
+	// A message stored before the contacts were introduced
+	message := entity.Message{Id: "old", From: "a", To: "b", Message: "txt", Users: []string{"a", "b"}}
+	key := datastore.NameKey("Message", message.Id, nil)
+	_, err := suite.repository.Client.Put(context.Background(), key, &message)
 	suite.Require().NoError(err)
-	suite.Require().NotNil(entity)
-
-	return entity
-}
 
-func (suite *MessageRepositoryTestSuite) TestGetByIdNotExisting() {
-	user, err := suite.repository.GetById("notExisting")
-	suite.Nil(user)
-	suite.EqualError(err, MessageNotFoundError.Error())
-}
+	contacts, err := suite.repository.Contacts("a")
+	suite.Require().NoError(err)
+	suite.Empty(contacts)
 
-func (suite *MessageRepositoryTestSuite) TestGetByIdOK() {
-	m := suite.createMessage("a", "b", "txt")
+	count, err := suite.repository.Backfill()
+	suite.Require().NoError(err)
+	suite.Equal(1, count)
 
-	message, err := suite.repository.GetById(m.Id)
-	suite.NoError(err)
-	suite.Require().NotNil(message)
-	suite.Equal("a", message.From)
-	suite.Equal("b", message.To)
-	suite.Equal("txt", message.Message)
+	contacts, err = suite.repository.Contacts("a")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"b"}, contacts)
 }
 
-func (suite *MessageRepositoryTestSuite) TestCreateOK() {
-	message := suite.createMessage("a", "b", "txt")
+func (suite *MessageRepositoryTestSuite) TestBackfillTerms() {
+	// A message stored before the search was introduced
+	message := entity.Message{Id: "old", ConversationId: "c", From: "a", Message: "Hello", Users: []string{"a", "c"}}
+	key := datastore.NameKey("Message", message.Id, nil)
+	_, err := suite.repository.Client.Put(context.Background(), key, &message)
+	suite.Require().NoError(err)
 
-	suite.Equal(message.From, "a")
-	suite.Equal(message.To, "b")
-	suite.Equal(message.CreatedAt, suite.repository.Clock.Now())
-	suite.Equal([]string{"a", "b"}, message.Users)
-	suite.NotEmpty(message.Id)
-}
+	suite.Empty(suite.search("a", repository.SearchOptions{Terms: []string{"hello"}}))
+
+	count, err := suite.repository.Backfill()
+	suite.Require().NoError(err)
+	suite.Equal(1, count)
 
-func (suite *MessageRepositoryTestSuite) TestAllWithUserOk() {
-	suite.createMessage("a", "b", "txt1")
-	suite.clock.Advance(time.Microsecond)
-	suite.createMessage("a", "c", "txt2")
-	suite.clock.Advance(time.Microsecond)
-	suite.createMessage("b", "c", "txt3")
-	suite.clock.Advance(time.Microsecond)
-	suite.createMessage("a", "b", "txt4")
-
-	messages, err := suite.repository.AllWithUser("a")
-	suite.NoError(err)
-	suite.Require().NotNil(messages)
-	suite.Require().Len(messages, 3)
-
-	suite.Equal("txt1", messages[0].Message)
-	suite.Equal("txt2", messages[1].Message)
-	suite.Equal("txt4", messages[2].Message)
+	suite.Equal([]string{"old"}, suite.search("a", repository.SearchOptions{Terms: []string{"hello"}}))
 }
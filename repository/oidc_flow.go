@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"cloud.google.com/go/datastore"
+	"context"
+	"errors"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+var (
+	// Error thrown when the OpenID Connect flow has not been found
+	OidcFlowNotFoundError = errors.New("OpenID Connect flow not found")
+)
+
+// Interface used mainly for Unit testing
+type OidcFlowRepository interface {
+	Create(string, string, string, string, time.Time) (*entity.OidcFlow, error)
+	Consume(string) (*entity.OidcFlow, error)
+}
+
+// OpenID Connect Flow Repository
+type OidcFlow struct {
+	// Injected via DI
+	Client *datastore.Client `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+	kind  string
+}
+
+func NewOidcFlowRepository() *OidcFlow {
+	return &OidcFlow{
+		kind: "OidcFlow",
+	}
+}
+
+// Stores a new OpenID Connect flow
+func (r OidcFlow) Create(id, flowToken, nonce, verifier string, expiresAt time.Time) (*entity.OidcFlow, error) {
+	flow := &entity.OidcFlow{
+		Id:        id,
+		FlowToken: flowToken,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		CreatedAt: r.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	if _, err := r.Client.Put(ctx, key, flow); err != nil {
+		return nil, err
+	}
+
+	return flow, nil
+}
+
+// Deletes the OpenID Connect flow and returns it, so that it can be completed only once
+func (r OidcFlow) Consume(id string) (*entity.OidcFlow, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	var flow entity.OidcFlow
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		err := tx.Get(key, &flow)
+		if err == datastore.ErrNoSuchEntity {
+			return OidcFlowNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(key)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &flow, nil
+}
@@ -0,0 +1,28 @@
+package repository_test
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/repository/repositorytest"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestOidcFlowRepository(t *testing.T) {
+	skipWithoutEmulator(t)
+
+	client, err := getDatastoreClient("test")
+	require.NoError(t, err)
+
+	suite.Run(t, &repositorytest.OidcFlowSuite{
+		New: func(t *testing.T, clock clockwork.Clock) repository.OidcFlowRepository {
+			cleanDb(t, client)
+
+			r := repository.NewOidcFlowRepository()
+			r.Client = client
+			r.Clock = clock
+			return r
+		},
+	})
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"cloud.google.com/go/datastore"
+	"context"
+	"errors"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+var (
+	// Error thrown when the password reset token has not been found
+	PasswordResetNotFoundError = errors.New("Password reset not found")
+)
+
+// Interface used mainly for Unit testing
+type PasswordResetRepository interface {
+	Create(string, string, string, time.Time) (*entity.PasswordReset, error)
+	Consume(string) (*entity.PasswordReset, error)
+}
+
+// Password Reset Repository
+type PasswordReset struct {
+	// Injected via DI
+	Client *datastore.Client `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+	kind  string
+}
+
+func NewPasswordResetRepository() *PasswordReset {
+	return &PasswordReset{
+		kind: "PasswordReset",
+	}
+}
+
+// Stores a new password reset token of the user
+func (r PasswordReset) Create(id, userId, secret string, expiresAt time.Time) (*entity.PasswordReset, error) {
+	entity := &entity.PasswordReset{
+		Id:        id,
+		UserId:    userId,
+		Secret:    secret,
+		CreatedAt: r.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	if _, err := r.Client.Put(ctx, key, entity); err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// Deletes the password reset token and returns it, so that it can be used only once
+func (r PasswordReset) Consume(id string) (*entity.PasswordReset, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	var reset entity.PasswordReset
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		err := tx.Get(key, &reset)
+		if err == datastore.ErrNoSuchEntity {
+			return PasswordResetNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(key)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reset, nil
+}
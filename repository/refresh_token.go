@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"cloud.google.com/go/datastore"
+	"context"
+	"errors"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+var (
+	// Error thrown when the refresh token has not been found
+	RefreshTokenNotFoundError = errors.New("Refresh token not found")
+
+	// Error thrown when the refresh token has already been exchanged
+	RefreshTokenUsedError = errors.New("Refresh token already used")
+)
+
+// Interface used mainly for Unit testing
+type RefreshTokenRepository interface {
+	GetById(string) (*entity.RefreshToken, error)
+	Create(string, string, string, string, time.Time) (*entity.RefreshToken, error)
+	Use(string) (*entity.RefreshToken, error)
+	DeleteFamily(string) error
+}
+
+// Refresh Token Repository
+type RefreshToken struct {
+	// Injected via DI
+	Client *datastore.Client `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+	kind  string
+}
+
+func NewRefreshTokenRepository() *RefreshToken {
+	return &RefreshToken{
+		kind: "RefreshToken",
+	}
+}
+
+// Fetch a refresh token by ID
+func (r RefreshToken) GetById(id string) (*entity.RefreshToken, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+
+	entity := &entity.RefreshToken{}
+	err := r.Client.Get(ctx, key, entity)
+	if err == datastore.ErrNoSuchEntity {
+		return nil, RefreshTokenNotFoundError
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// Stores a new refresh token of the user. The token starts a new family if family is empty
+func (r RefreshToken) Create(id, family, userId, secret string, expiresAt time.Time) (*entity.RefreshToken, error) {
+	if family == "" {
+		family = id
+	}
+
+	entity := &entity.RefreshToken{
+		Id:        id,
+		Family:    family,
+		UserId:    userId,
+		Secret:    secret,
+		CreatedAt: r.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	if _, err := r.Client.Put(ctx, key, entity); err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// Marks the refresh token as used, at the current time. Returns RefreshTokenUsedError if it has already been used
+func (r RefreshToken) Use(id string) (*entity.RefreshToken, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	var token entity.RefreshToken
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		err := tx.Get(key, &token)
+		if err == datastore.ErrNoSuchEntity {
+			return RefreshTokenNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		if token.Used() {
+			return RefreshTokenUsedError
+		}
+		token.UsedAt = r.Clock.Now()
+
+		_, err = tx.Put(key, &token)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Deletes all the tokens of the family
+func (r RefreshToken) DeleteFamily(family string) error {
+	query := datastore.NewQuery(r.kind).Filter("Family =", family).KeysOnly()
+
+	ctx := context.Background()
+	keys, err := r.Client.GetAll(ctx, query, nil)
+	if err != nil {
+		return err
+	}
+
+	return r.Client.DeleteMulti(ctx, keys)
+}
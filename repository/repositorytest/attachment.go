@@ -0,0 +1,117 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+// Conformance suite of repository.AttachmentRepository
+type AttachmentSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.AttachmentRepository
+
+	repository repository.AttachmentRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *AttachmentSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *AttachmentSuite) TestGetByIdNotExisting() {
+	attachment, err := suite.repository.GetById("notExisting")
+	suite.Nil(attachment)
+	suite.EqualError(err, repository.AttachmentNotFoundError.Error())
+}
+
+func (suite *AttachmentSuite) TestCreateOK() {
+	attachment, err := suite.repository.Create("a", "file.png", "image/png", 42, "checksum")
+	suite.Require().NoError(err)
+
+	suite.NotEmpty(attachment.Id)
+	suite.Equal("a", attachment.Owner)
+	suite.Empty(attachment.MessageId)
+	suite.Equal("file.png", attachment.Name)
+	suite.Equal("image/png", attachment.MimeType)
+	suite.Equal(int64(42), attachment.Size)
+	suite.Equal("checksum", attachment.Checksum)
+
+	found, err := suite.repository.GetById(attachment.Id)
+	suite.Require().NoError(err)
+	suite.Equal(attachment.Id, found.Id)
+	suite.True(attachment.CreatedAt.Equal(found.CreatedAt))
+}
+
+func (suite *AttachmentSuite) TestAttachNotExisting() {
+	attachment, err := suite.repository.Attach("notExisting", "messageId")
+	suite.Nil(attachment)
+	suite.EqualError(err, repository.AttachmentNotFoundError.Error())
+}
+
+func (suite *AttachmentSuite) TestAttachOK() {
+	attachment, err := suite.repository.Create("a", "file.png", "image/png", 42, "checksum")
+	suite.Require().NoError(err)
+
+	attached, err := suite.repository.Attach(attachment.Id, "messageId")
+	suite.Require().NoError(err)
+	suite.Equal("messageId", attached.MessageId)
+
+	// Attaching it again to the same message is a no-op
+	_, err = suite.repository.Attach(attachment.Id, "messageId")
+	suite.Require().NoError(err)
+
+	// But it can't be attached to another one
+	attached, err = suite.repository.Attach(attachment.Id, "otherId")
+	suite.Nil(attached)
+	suite.EqualError(err, repository.AttachmentAttachedError.Error())
+
+	found, err := suite.repository.GetById(attachment.Id)
+	suite.Require().NoError(err)
+	suite.Equal("messageId", found.MessageId)
+}
+
+func (suite *AttachmentSuite) TestDetachNotExisting() {
+	suite.EqualError(suite.repository.Detach("notExisting", "messageId"), repository.AttachmentNotFoundError.Error())
+}
+
+func (suite *AttachmentSuite) TestDetachOK() {
+	attachment, err := suite.repository.Create("a", "file.png", "image/png", 42, "checksum")
+	suite.Require().NoError(err)
+	_, err = suite.repository.Attach(attachment.Id, "messageId")
+	suite.Require().NoError(err)
+
+	// Detaching it from another message is a no-op
+	suite.Require().NoError(suite.repository.Detach(attachment.Id, "otherId"))
+	found, err := suite.repository.GetById(attachment.Id)
+	suite.Require().NoError(err)
+	suite.Equal("messageId", found.MessageId)
+
+	suite.Require().NoError(suite.repository.Detach(attachment.Id, "messageId"))
+	found, err = suite.repository.GetById(attachment.Id)
+	suite.Require().NoError(err)
+	suite.Empty(found.MessageId)
+
+	// It can be attached to another message
+	attached, err := suite.repository.Attach(attachment.Id, "otherId")
+	suite.Require().NoError(err)
+	suite.Equal("otherId", attached.MessageId)
+}
+
+func (suite *AttachmentSuite) TestDeleteOK() {
+	attachment, err := suite.repository.Create("a", "file.png", "image/png", 42, "checksum")
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.repository.Delete(attachment.Id))
+
+	found, err := suite.repository.GetById(attachment.Id)
+	suite.Nil(found)
+	suite.EqualError(err, repository.AttachmentNotFoundError.Error())
+
+	// Deleting it again is a no-op
+	suite.NoError(suite.repository.Delete(attachment.Id))
+}
@@ -0,0 +1,117 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Conformance suite of repository.ConversationRepository
+type ConversationSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.ConversationRepository
+
+	repository repository.ConversationRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *ConversationSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *ConversationSuite) createConversation(name, createdBy string, members ...string) *entity.Conversation {
+	entity, err := suite.repository.Create(name, createdBy, members)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(entity)
+
+	return entity
+}
+
+func (suite *ConversationSuite) TestGetByIdNotExisting() {
+	conversation, err := suite.repository.GetById("notExisting")
+	suite.Nil(conversation)
+	suite.EqualError(err, repository.ConversationNotFoundError.Error())
+}
+
+func (suite *ConversationSuite) TestGetByIdOK() {
+	c := suite.createConversation("standup", "a", "b")
+
+	conversation, err := suite.repository.GetById(c.Id)
+	suite.NoError(err)
+	suite.Require().NotNil(conversation)
+	suite.Equal("standup", conversation.Name)
+	suite.Equal([]string{"a", "b"}, conversation.Members)
+}
+
+func (suite *ConversationSuite) TestCreateOK() {
+	conversation := suite.createConversation("standup", "a", "b", "a", "c", "b")
+
+	suite.NotEmpty(conversation.Id)
+	suite.Equal("standup", conversation.Name)
+	suite.Equal("a", conversation.CreatedBy)
+	suite.Equal([]string{"a", "b", "c"}, conversation.Members)
+	suite.Equal(suite.clock.Now(), conversation.CreatedAt)
+}
+
+func (suite *ConversationSuite) TestAllWithMemberOK() {
+	suite.createConversation("c1", "a", "b")
+	suite.clock.Advance(time.Microsecond)
+	suite.createConversation("c2", "b", "c")
+	suite.clock.Advance(time.Microsecond)
+	suite.createConversation("c3", "c", "a")
+
+	conversations, err := suite.repository.AllWithMember("a")
+	suite.NoError(err)
+	suite.Require().Len(conversations, 2)
+	suite.Equal("c1", conversations[0].Name)
+	suite.Equal("c3", conversations[1].Name)
+}
+
+func (suite *ConversationSuite) TestAddMemberNotExisting() {
+	conversation, err := suite.repository.AddMember("notExisting", "a")
+	suite.Nil(conversation)
+	suite.EqualError(err, repository.ConversationNotFoundError.Error())
+}
+
+func (suite *ConversationSuite) TestAddMemberOK() {
+	c := suite.createConversation("standup", "a")
+
+	conversation, err := suite.repository.AddMember(c.Id, "b")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"a", "b"}, conversation.Members)
+
+	conversation, err = suite.repository.AddMember(c.Id, "b")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"a", "b"}, conversation.Members)
+}
+
+func (suite *ConversationSuite) TestRemoveMemberOK() {
+	c := suite.createConversation("standup", "a", "b", "c")
+
+	conversation, err := suite.repository.RemoveMember(c.Id, "b")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"a", "c"}, conversation.Members)
+
+	stored, err := suite.repository.GetById(c.Id)
+	suite.Require().NoError(err)
+	suite.Equal([]string{"a", "c"}, stored.Members)
+}
+
+func (suite *ConversationSuite) TestRenameOK() {
+	c := suite.createConversation("standup", "a")
+
+	conversation, err := suite.repository.Rename(c.Id, "retro")
+	suite.Require().NoError(err)
+	suite.Equal("retro", conversation.Name)
+
+	found, err := suite.repository.GetById(c.Id)
+	suite.Require().NoError(err)
+	suite.Equal("retro", found.Name)
+	suite.Equal([]string{"a"}, found.Members)
+}
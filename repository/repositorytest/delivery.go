@@ -0,0 +1,66 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+// Conformance suite of repository.DeliveryRepository
+type DeliverySuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.DeliveryRepository
+
+	repository repository.DeliveryRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *DeliverySuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *DeliverySuite) TestCreateOK() {
+	delivery, err := suite.repository.Create("a", "message1")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(delivery)
+
+	suite.Equal("a", delivery.To)
+	suite.Equal("message1", delivery.MessageId)
+	suite.Equal(int64(1), delivery.Seq)
+	suite.Equal(suite.clock.Now(), delivery.CreatedAt)
+}
+
+func (suite *DeliverySuite) TestCreateIncrementsPerUser() {
+	for i := int64(1); i <= 3; i++ {
+		delivery, err := suite.repository.Create("a", "message")
+		suite.Require().NoError(err)
+		suite.Equal(i, delivery.Seq)
+	}
+
+	delivery, err := suite.repository.Create("b", "message")
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), delivery.Seq)
+}
+
+func (suite *DeliverySuite) TestAllAfterOK() {
+	for _, id := range []string{"message1", "message2", "message3", "message4"} {
+		_, err := suite.repository.Create("a", id)
+		suite.Require().NoError(err)
+	}
+	_, err := suite.repository.Create("b", "message5")
+	suite.Require().NoError(err)
+
+	deliveries, err := suite.repository.AllAfter("a", 1, 2)
+	suite.Require().NoError(err)
+	suite.Require().Len(deliveries, 2)
+	suite.Equal("message2", deliveries[0].MessageId)
+	suite.Equal("message3", deliveries[1].MessageId)
+
+	deliveries, err = suite.repository.AllAfter("a", 4, 2)
+	suite.Require().NoError(err)
+	suite.Len(deliveries, 0)
+}
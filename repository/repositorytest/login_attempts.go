@@ -0,0 +1,77 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Conformance suite of repository.LoginAttemptRepository
+type LoginAttemptSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.LoginAttemptRepository
+
+	repository repository.LoginAttemptRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *LoginAttemptSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *LoginAttemptSuite) TestGetNotExisting() {
+	attempts, err := suite.repository.Get("notExisting")
+	suite.Nil(attempts)
+	suite.EqualError(err, repository.LoginAttemptsNotFoundError.Error())
+}
+
+func (suite *LoginAttemptSuite) TestRecordFailure() {
+	since := suite.clock.Now().Add(-time.Hour)
+	attempts, err := suite.repository.RecordFailure("email:a@b.com", since)
+	suite.Require().NoError(err)
+	suite.Equal("email:a@b.com", attempts.Key)
+	suite.Equal(1, attempts.Failures)
+	suite.True(suite.clock.Now().Equal(attempts.LastFailureAt))
+
+	suite.clock.Advance(time.Minute)
+	attempts, err = suite.repository.RecordFailure("email:a@b.com", since)
+	suite.Require().NoError(err)
+	suite.Equal(2, attempts.Failures)
+	suite.True(suite.clock.Now().Equal(attempts.LastFailureAt))
+
+	found, err := suite.repository.Get("email:a@b.com")
+	suite.Require().NoError(err)
+	suite.Equal(2, found.Failures)
+	suite.True(attempts.LastFailureAt.Equal(found.LastFailureAt))
+
+	// The other keys are counted separately
+	attempts, err = suite.repository.RecordFailure("ip:127.0.0.1", since)
+	suite.Require().NoError(err)
+	suite.Equal(1, attempts.Failures)
+}
+
+func (suite *LoginAttemptSuite) TestRecordFailureRestartsCount() {
+	_, err := suite.repository.RecordFailure("email:a@b.com", suite.clock.Now().Add(-time.Hour))
+	suite.Require().NoError(err)
+
+	// The previous failure is older than since
+	suite.clock.Advance(time.Hour)
+	attempts, err := suite.repository.RecordFailure("email:a@b.com", suite.clock.Now().Add(-time.Minute))
+	suite.Require().NoError(err)
+	suite.Equal(1, attempts.Failures)
+}
+
+func (suite *LoginAttemptSuite) TestReset() {
+	_, err := suite.repository.RecordFailure("email:a@b.com", suite.clock.Now())
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.repository.Reset("email:a@b.com"))
+
+	_, err = suite.repository.Get("email:a@b.com")
+	suite.EqualError(err, repository.LoginAttemptsNotFoundError.Error())
+}
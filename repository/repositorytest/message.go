@@ -0,0 +1,607 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Conformance suite of repository.MessageRepository
+type MessageSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.MessageRepository
+
+	repository repository.MessageRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *MessageSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+// Creates a message a microsecond after the previous one, so that the messages are sorted the same way by every backend
+func (suite *MessageSuite) createMessage(from, to, message string) *entity.Message {
+	suite.clock.Advance(time.Microsecond)
+
+	entity, err := suite.repository.Create(from, to, message)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(entity)
+
+	return entity
+}
+
+func (suite *MessageSuite) TestGetByIdNotExisting() {
+	user, err := suite.repository.GetById("notExisting")
+	suite.Nil(user)
+	suite.EqualError(err, repository.MessageNotFoundError.Error())
+}
+
+func (suite *MessageSuite) TestGetByIdOK() {
+	m := suite.createMessage("a", "b", "txt")
+
+	message, err := suite.repository.GetById(m.Id)
+	suite.NoError(err)
+	suite.Require().NotNil(message)
+	suite.Equal("a", message.From)
+	suite.Equal("b", message.To)
+	suite.Equal("txt", message.Message)
+	suite.Equal([]string{"a", "b"}, message.Users)
+}
+
+func (suite *MessageSuite) TestGetByIdsOK() {
+	m1 := suite.createMessage("a", "b", "txt1")
+	m2 := suite.createMessage("a", "b", "txt2")
+
+	messages, err := suite.repository.GetByIds([]string{m2.Id, "notExisting", m1.Id})
+	suite.Require().NoError(err)
+	suite.Require().Len(messages, 2)
+	suite.Equal("txt2", messages[0].Message)
+	suite.Equal("txt1", messages[1].Message)
+}
+
+func (suite *MessageSuite) TestCreateOK() {
+	message := suite.createMessage("a", "b", "txt")
+
+	suite.Equal(message.From, "a")
+	suite.Equal(message.To, "b")
+	suite.Equal(message.CreatedAt, suite.clock.Now())
+	suite.Equal([]string{"a", "b"}, message.Users)
+	suite.NotEmpty(message.Id)
+}
+
+func (suite *MessageSuite) TestAllWithUserOk() {
+	suite.createMessage("a", "b", "txt1")
+	suite.clock.Advance(time.Microsecond)
+	suite.createMessage("a", "c", "txt2")
+	suite.clock.Advance(time.Microsecond)
+	suite.createMessage("b", "c", "txt3")
+	suite.clock.Advance(time.Microsecond)
+	suite.createMessage("a", "b", "txt4")
+
+	messages, err := suite.repository.AllWithUser("a")
+	suite.NoError(err)
+	suite.Require().NotNil(messages)
+	suite.Require().Len(messages, 3)
+
+	suite.Equal("txt1", messages[0].Message)
+	suite.Equal("txt2", messages[1].Message)
+	suite.Equal("txt4", messages[2].Message)
+}
+
+func (suite *MessageSuite) TestCreateReplyOK() {
+	m := suite.createMessage("a", "b", "txt1")
+
+	message, err := suite.repository.CreateReply("b", "a", "txt2", m.Quote())
+	suite.Require().NoError(err)
+	suite.Equal(entity.Reply{MessageId: m.Id, From: "a", Snippet: "txt1"}, message.ReplyTo)
+
+	found, err := suite.repository.GetById(message.Id)
+	suite.Require().NoError(err)
+	suite.Equal(message.ReplyTo, found.ReplyTo)
+}
+
+func (suite *MessageSuite) TestCreateInConversationOK() {
+	members := []string{"a", "b", "c"}
+	message, err := suite.repository.CreateInConversation("a", "conversationId", members, "txt")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(message)
+
+	suite.Equal("a", message.From)
+	suite.Empty(message.To)
+	suite.Equal("conversationId", message.ConversationId)
+	suite.Equal(members, message.Users)
+	suite.Equal(message.CreatedAt, suite.clock.Now())
+	suite.NotEmpty(message.Id)
+
+	messages, err := suite.repository.AllWithUser("c")
+	suite.NoError(err)
+	suite.Require().Len(messages, 1)
+	suite.Equal("conversationId", messages[0].ConversationId)
+}
+
+func (suite *MessageSuite) TestPageWithUserOk() {
+	for _, txt := range []string{"txt1", "txt2", "txt3", "txt4", "txt5"} {
+		suite.createMessage("a", "b", txt)
+		suite.clock.Advance(time.Microsecond)
+	}
+	suite.createMessage("a", "c", "other")
+
+	options := repository.MessagePageOptions{
+		With:  "b",
+		Limit: 2,
+	}
+	page, err := suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Require().Len(page.Messages, 2)
+	suite.Equal("txt4", page.Messages[0].Message)
+	suite.Equal("txt5", page.Messages[1].Message)
+	suite.Empty(page.PrevCursor)
+	suite.Require().NotEmpty(page.NextCursor)
+
+	options.Before = page.NextCursor
+	page, err = suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Require().Len(page.Messages, 2)
+	suite.Equal("txt2", page.Messages[0].Message)
+	suite.Equal("txt3", page.Messages[1].Message)
+	suite.Equal(options.Before, page.PrevCursor)
+	suite.Require().NotEmpty(page.NextCursor)
+
+	before := page.NextCursor
+	options.Before = before
+	page, err = suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Require().Len(page.Messages, 1)
+	suite.Equal("txt1", page.Messages[0].Message)
+	suite.Empty(page.NextCursor)
+
+	// Walk back to the newest messages
+	options.Before = ""
+	options.After = before
+	page, err = suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Require().Len(page.Messages, 2)
+	suite.Equal("txt2", page.Messages[0].Message)
+	suite.Equal("txt3", page.Messages[1].Message)
+	suite.Equal(before, page.NextCursor)
+	suite.Require().NotEmpty(page.PrevCursor)
+
+	options.After = page.PrevCursor
+	page, err = suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Require().Len(page.Messages, 2)
+	suite.Equal("txt4", page.Messages[0].Message)
+	suite.Equal("txt5", page.Messages[1].Message)
+	suite.Empty(page.PrevCursor)
+}
+
+// The boundaries of the pages, in both directions, with messages created at the same time
+func (suite *MessageSuite) TestPageWithUserBoundaries() {
+	// The messages created at the same time are sorted by Id
+	ids := []string{}
+	for _, txt := range []string{"txt1", "txt2", "txt3", "txt4"} {
+		message, err := suite.repository.Create("a", "b", txt)
+		suite.Require().NoError(err)
+		ids = append(ids, message.Id)
+	}
+	sort.Strings(ids)
+
+	pageIds := func(page *repository.MessagePage) []string {
+		found := []string{}
+		for _, message := range page.Messages {
+			found = append(found, message.Id)
+		}
+		return found
+	}
+
+	options := repository.MessagePageOptions{With: "b", Limit: 2}
+	newest, err := suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Equal(ids[2:], pageIds(newest))
+	suite.Empty(newest.PrevCursor)
+	suite.Require().NotEmpty(newest.NextCursor)
+
+	// The last page is full, there are no older messages
+	options.Before = newest.NextCursor
+	oldest, err := suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Equal(ids[:2], pageIds(oldest))
+	suite.Equal(newest.NextCursor, oldest.PrevCursor)
+	suite.Empty(oldest.NextCursor)
+
+	// The newer messages start from the message of the cursor
+	options.Before = ""
+	options.After = newest.NextCursor
+	page, err := suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Equal(ids[2:], pageIds(page))
+	suite.Equal(newest.NextCursor, page.NextCursor)
+	suite.Empty(page.PrevCursor)
+
+	// One message at a time
+	options.Limit = 1
+	page, err = suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Equal(ids[2:3], pageIds(page))
+	suite.Require().NotEmpty(page.PrevCursor)
+
+	options.After = page.PrevCursor
+	page, err = suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Equal(ids[3:], pageIds(page))
+	suite.Empty(page.PrevCursor)
+
+	options.After = ""
+	options.Before = page.NextCursor
+	page, err = suite.repository.PageWithUser("a", options)
+	suite.Require().NoError(err)
+	suite.Equal(ids[2:3], pageIds(page))
+	suite.NotEmpty(page.NextCursor)
+}
+
+func (suite *MessageSuite) TestPageWithUserConversation() {
+	suite.createMessage("a", "b", "direct")
+	_, err := suite.repository.CreateInConversation("b", "conversationId", []string{"a", "b"}, "group")
+	suite.Require().NoError(err)
+
+	page, err := suite.repository.PageWithUser("a", repository.MessagePageOptions{ConversationId: "conversationId", Limit: 10})
+	suite.Require().NoError(err)
+	suite.Require().Len(page.Messages, 1)
+	suite.Equal("group", page.Messages[0].Message)
+
+	page, err = suite.repository.PageWithUser("a", repository.MessagePageOptions{With: "b", Limit: 10})
+	suite.Require().NoError(err)
+	suite.Require().Len(page.Messages, 1)
+	suite.Equal("direct", page.Messages[0].Message)
+}
+
+func (suite *MessageSuite) TestPageWithUserInvalidCursor() {
+	page, err := suite.repository.PageWithUser("a", repository.MessagePageOptions{Before: "invalid", Limit: 10})
+	suite.Nil(page)
+	suite.EqualError(err, repository.InvalidCursorError.Error())
+}
+
+func (suite *MessageSuite) TestContacts() {
+	suite.createMessage("a", "b", "txt1")
+	suite.createMessage("c", "a", "txt2")
+	suite.createMessage("b", "a", "txt3")
+	_, err := suite.repository.CreateInConversation("a", "conversationId", []string{"a", "d"}, "group")
+	suite.Require().NoError(err)
+
+	contacts, err := suite.repository.Contacts("a")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"b", "c"}, contacts)
+
+	contacts, err = suite.repository.Contacts("b")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"a"}, contacts)
+
+	contacts, err = suite.repository.Contacts("d")
+	suite.Require().NoError(err)
+	suite.Empty(contacts)
+}
+
+func (suite *MessageSuite) TestMarkReadNotExisting() {
+	message, err := suite.repository.MarkRead("notExisting", "b")
+	suite.Nil(message)
+	suite.EqualError(err, repository.MessageNotFoundError.Error())
+}
+
+func (suite *MessageSuite) TestMarkReadOK() {
+	m := suite.createMessage("a", "b", "txt")
+	readAt := suite.clock.Now()
+
+	message, err := suite.repository.MarkRead(m.Id, "b")
+	suite.Require().NoError(err)
+	suite.Require().Len(message.Reads, 1)
+	suite.Equal("b", message.Reads[0].User)
+	suite.True(readAt.Equal(message.Reads[0].ReadAt))
+
+	// Marking it again doesn't change the read time
+	suite.clock.Advance(time.Minute)
+	message, err = suite.repository.MarkRead(m.Id, "b")
+	suite.Require().NoError(err)
+	suite.Require().Len(message.Reads, 1)
+	suite.True(readAt.Equal(message.Reads[0].ReadAt))
+
+	found, err := suite.repository.GetById(m.Id)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(found.ReadBy("b"))
+	suite.True(readAt.Equal(found.ReadBy("b").ReadAt))
+	suite.Nil(found.ReadBy("a"))
+}
+
+func (suite *MessageSuite) TestEditNotExisting() {
+	message, err := suite.repository.Edit("notExisting", "txt")
+	suite.Nil(message)
+	suite.EqualError(err, repository.MessageNotFoundError.Error())
+}
+
+func (suite *MessageSuite) TestEditOK() {
+	m := suite.createMessage("a", "b", "txt1")
+
+	suite.clock.Advance(time.Minute)
+	message, err := suite.repository.Edit(m.Id, "txt2")
+	suite.Require().NoError(err)
+	suite.Equal("txt2", message.Message)
+	suite.True(suite.clock.Now().Equal(message.EditedAt))
+
+	editedAt := suite.clock.Now()
+	suite.clock.Advance(time.Minute)
+	_, err = suite.repository.Edit(m.Id, "txt3")
+	suite.Require().NoError(err)
+
+	found, err := suite.repository.GetById(m.Id)
+	suite.Require().NoError(err)
+	suite.Equal("txt3", found.Message)
+	suite.True(suite.clock.Now().Equal(found.EditedAt))
+	suite.Require().Len(found.Revisions, 2)
+	suite.Equal("txt1", found.Revisions[0].Message)
+	suite.True(m.CreatedAt.Equal(found.Revisions[0].CreatedAt))
+	suite.Equal("txt2", found.Revisions[1].Message)
+	suite.True(editedAt.Equal(found.Revisions[1].CreatedAt))
+}
+
+func (suite *MessageSuite) TestDeleteForNotExisting() {
+	message, err := suite.repository.DeleteFor("notExisting", "a")
+	suite.Nil(message)
+	suite.EqualError(err, repository.MessageNotFoundError.Error())
+}
+
+func (suite *MessageSuite) TestDeleteForOK() {
+	m := suite.createMessage("a", "b", "txt")
+
+	message, err := suite.repository.DeleteFor(m.Id, "a")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"b"}, message.Users)
+	suite.Equal("txt", message.Message)
+
+	// The message is hidden from the history of the user only
+	messages, err := suite.repository.AllWithUser("a")
+	suite.Require().NoError(err)
+	suite.Len(messages, 0)
+
+	messages, err = suite.repository.AllWithUser("b")
+	suite.Require().NoError(err)
+	suite.Require().Len(messages, 1)
+	suite.Equal("txt", messages[0].Message)
+}
+
+func (suite *MessageSuite) TestDeleteForEveryoneNotExisting() {
+	message, err := suite.repository.DeleteForEveryone("notExisting")
+	suite.Nil(message)
+	suite.EqualError(err, repository.MessageNotFoundError.Error())
+}
+
+func (suite *MessageSuite) TestDeleteOK() {
+	m := suite.createMessage("a", "b", "hello")
+
+	suite.Require().NoError(suite.repository.Delete(m.Id))
+
+	message, err := suite.repository.GetById(m.Id)
+	suite.Nil(message)
+	suite.EqualError(err, repository.MessageNotFoundError.Error())
+
+	messages, err := suite.repository.AllWithUser("a")
+	suite.Require().NoError(err)
+	suite.Empty(messages)
+
+	results, err := suite.repository.Search("a", repository.SearchOptions{Terms: []string{"hello"}})
+	suite.Require().NoError(err)
+	suite.Empty(results)
+
+	// Deleting it again is a no-op
+	suite.NoError(suite.repository.Delete(m.Id))
+}
+
+func (suite *MessageSuite) TestDeleteForEveryoneOK() {
+	m := suite.createMessage("a", "b", "txt1")
+	_, err := suite.repository.Edit(m.Id, "txt2")
+	suite.Require().NoError(err)
+
+	suite.clock.Advance(time.Minute)
+	message, err := suite.repository.DeleteForEveryone(m.Id)
+	suite.Require().NoError(err)
+	suite.True(message.Deleted())
+	suite.True(suite.clock.Now().Equal(message.DeletedAt))
+
+	// The users keep a tombstone without the texts
+	messages, err := suite.repository.AllWithUser("b")
+	suite.Require().NoError(err)
+	suite.Require().Len(messages, 1)
+	suite.Equal(m.Id, messages[0].Id)
+	suite.Equal("", messages[0].Message)
+	suite.Nil(messages[0].Revisions)
+	suite.True(suite.clock.Now().Equal(messages[0].DeletedAt))
+}
+
+func (suite *MessageSuite) TestAddReactionNotExisting() {
+	message, err := suite.repository.AddReaction("notExisting", "a", "👍")
+	suite.Nil(message)
+	suite.EqualError(err, repository.MessageNotFoundError.Error())
+}
+
+func (suite *MessageSuite) TestReactionsOK() {
+	m := suite.createMessage("a", "b", "txt")
+
+	_, err := suite.repository.AddReaction(m.Id, "a", "👍")
+	suite.Require().NoError(err)
+	_, err = suite.repository.AddReaction(m.Id, "b", "👍")
+	suite.Require().NoError(err)
+	_, err = suite.repository.AddReaction(m.Id, "b", "👍")
+	suite.Require().NoError(err)
+	message, err := suite.repository.AddReaction(m.Id, "b", "🎉")
+	suite.Require().NoError(err)
+
+	expected := []entity.Reaction{
+		{Emoji: "👍", Count: 2, Users: []string{"a", "b"}},
+		{Emoji: "🎉", Count: 1, Users: []string{"b"}},
+	}
+	suite.Equal(expected, message.Reactions)
+
+	found, err := suite.repository.GetById(m.Id)
+	suite.Require().NoError(err)
+	suite.Equal(expected, found.Reactions)
+
+	// The emojis left without users are removed
+	message, err = suite.repository.RemoveReaction(m.Id, "b", "🎉")
+	suite.Require().NoError(err)
+	message, err = suite.repository.RemoveReaction(m.Id, "a", "👍")
+	suite.Require().NoError(err)
+	suite.Equal([]entity.Reaction{{Emoji: "👍", Count: 1, Users: []string{"b"}}}, message.Reactions)
+
+	found, err = suite.repository.GetById(m.Id)
+	suite.Require().NoError(err)
+	suite.Equal(message.Reactions, found.Reactions)
+}
+
+func (suite *MessageSuite) TestAttachNotExisting() {
+	message, err := suite.repository.Attach("notExisting", []entity.Attachment{{Id: "id"}})
+	suite.Nil(message)
+	suite.EqualError(err, repository.MessageNotFoundError.Error())
+}
+
+func (suite *MessageSuite) TestAttachOK() {
+	m := suite.createMessage("a", "b", "txt")
+	attachments := []entity.Attachment{
+		{Id: "id1", Name: "file1.png", MimeType: "image/png", Size: 1, Checksum: "c1"},
+		{Id: "id2", Name: "file2.pdf", MimeType: "application/pdf", Size: 2, Checksum: "c2"},
+	}
+
+	message, err := suite.repository.Attach(m.Id, attachments)
+	suite.Require().NoError(err)
+	suite.Equal(attachments, message.Attachments)
+
+	found, err := suite.repository.GetById(m.Id)
+	suite.Require().NoError(err)
+	suite.Equal(attachments, found.Attachments)
+}
+
+// Returns the IDs of the messages found, in order
+func (suite *MessageSuite) search(email string, options repository.SearchOptions) []string {
+	if options.Limit == 0 {
+		options.Limit = 10
+	}
+
+	results, err := suite.repository.Search(email, options)
+	suite.Require().NoError(err)
+
+	ids := []string{}
+	for _, result := range results {
+		ids = append(ids, result.Message.Id)
+	}
+	return ids
+}
+
+func (suite *MessageSuite) TestSearch() {
+	m1 := suite.createMessage("a", "b", "Hello world")
+	m2 := suite.createMessage("b", "a", "hello, HELLO there")
+	suite.createMessage("a", "b", "world")
+	m4 := suite.createMessage("a", "c", "hello")
+	suite.createMessage("b", "c", "hello")
+
+	// The most relevant first, then the newest
+	suite.Equal([]string{m2.Id, m4.Id, m1.Id}, suite.search("a", repository.SearchOptions{
+		Terms: []string{"hello"},
+	}))
+
+	results, err := suite.repository.Search("a", repository.SearchOptions{
+		Terms: []string{"hello"},
+		Limit: 1,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	suite.Equal(m2.Id, results[0].Message.Id)
+	suite.Equal(2, results[0].Score)
+
+	// All the terms must match
+	suite.Equal([]string{m1.Id}, suite.search("a", repository.SearchOptions{
+		Terms: []string{"hello", "world"},
+	}))
+	suite.Empty(suite.search("a", repository.SearchOptions{
+		Terms: []string{"hello", "nothing"},
+	}))
+	suite.Empty(suite.search("a", repository.SearchOptions{}))
+}
+
+func (suite *MessageSuite) TestSearchFilters() {
+	m1 := suite.createMessage("a", "b", "hello")
+	m2 := suite.createMessage("a", "c", "hello")
+	m3 := suite.createMessage("a", "b", "hello")
+
+	suite.Equal([]string{m3.Id, m1.Id}, suite.search("a", repository.SearchOptions{
+		Terms: []string{"hello"},
+		With:  "b",
+	}))
+
+	suite.Equal([]string{m3.Id, m2.Id}, suite.search("a", repository.SearchOptions{
+		Terms: []string{"hello"},
+		Since: m2.CreatedAt,
+	}))
+
+	suite.Equal([]string{m2.Id, m1.Id}, suite.search("a", repository.SearchOptions{
+		Terms: []string{"hello"},
+		Until: m3.CreatedAt,
+	}))
+}
+
+func (suite *MessageSuite) TestSearchPages() {
+	m1 := suite.createMessage("a", "b", "hello")
+	m2 := suite.createMessage("a", "b", "hello hello")
+	m3 := suite.createMessage("a", "b", "hello")
+
+	suite.Equal([]string{m2.Id, m3.Id}, suite.search("a", repository.SearchOptions{
+		Terms: []string{"hello"},
+		Limit: 2,
+	}))
+	suite.Equal([]string{m3.Id, m1.Id}, suite.search("a", repository.SearchOptions{
+		Terms:  []string{"hello"},
+		Offset: 1,
+	}))
+	suite.Empty(suite.search("a", repository.SearchOptions{
+		Terms:  []string{"hello"},
+		Offset: 3,
+	}))
+}
+
+func (suite *MessageSuite) TestSearchCandidates() {
+	old := suite.createMessage("a", "b", "hello hello")
+	var messages []*entity.Message
+	for i := 0; i < repository.MaxSearchCandidates; i++ {
+		messages = append(messages, suite.createMessage("a", "b", "hello"))
+	}
+
+	// Only the newest matches are ranked, even if an older one is more relevant
+	ids := suite.search("a", repository.SearchOptions{Terms: []string{"hello"}, Limit: 1})
+	suite.Equal([]string{messages[len(messages)-1].Id}, ids)
+
+	// The older messages are found by narrowing the search
+	ids = suite.search("a", repository.SearchOptions{Terms: []string{"hello"}, Until: messages[0].CreatedAt})
+	suite.Equal([]string{old.Id}, ids)
+}
+
+func (suite *MessageSuite) TestSearchIndexUpdated() {
+	m := suite.createMessage("a", "b", "hello")
+
+	_, err := suite.repository.Edit(m.Id, "goodbye")
+	suite.Require().NoError(err)
+	suite.Empty(suite.search("a", repository.SearchOptions{Terms: []string{"hello"}}))
+	suite.Equal([]string{m.Id}, suite.search("a", repository.SearchOptions{Terms: []string{"goodbye"}}))
+
+	// The messages deleted for a user are not found by the user
+	_, err = suite.repository.DeleteFor(m.Id, "a")
+	suite.Require().NoError(err)
+	suite.Empty(suite.search("a", repository.SearchOptions{Terms: []string{"goodbye"}}))
+	suite.Equal([]string{m.Id}, suite.search("b", repository.SearchOptions{Terms: []string{"goodbye"}}))
+
+	_, err = suite.repository.DeleteForEveryone(m.Id)
+	suite.Require().NoError(err)
+	suite.Empty(suite.search("b", repository.SearchOptions{Terms: []string{"goodbye"}}))
+}
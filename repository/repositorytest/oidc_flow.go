@@ -0,0 +1,58 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Conformance suite of repository.OidcFlowRepository
+type OidcFlowSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.OidcFlowRepository
+
+	repository repository.OidcFlowRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *OidcFlowSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *OidcFlowSuite) TestConsumeNotExisting() {
+	flow, err := suite.repository.Consume("notExisting")
+	suite.Nil(flow)
+	suite.EqualError(err, repository.OidcFlowNotFoundError.Error())
+}
+
+func (suite *OidcFlowSuite) TestCreateConsumeOK() {
+	expiresAt := suite.clock.Now().Add(time.Hour)
+	flow, err := suite.repository.Create("id", "flowToken", "nonce", "verifier", expiresAt)
+	suite.Require().NoError(err)
+
+	suite.Equal("id", flow.Id)
+	suite.Equal("flowToken", flow.FlowToken)
+	suite.Equal("nonce", flow.Nonce)
+	suite.Equal("verifier", flow.Verifier)
+	suite.True(suite.clock.Now().Equal(flow.CreatedAt))
+	suite.True(expiresAt.Equal(flow.ExpiresAt))
+
+	consumed, err := suite.repository.Consume("id")
+	suite.Require().NoError(err)
+	suite.Equal("id", consumed.Id)
+	suite.Equal("flowToken", consumed.FlowToken)
+	suite.Equal("nonce", consumed.Nonce)
+	suite.Equal("verifier", consumed.Verifier)
+	suite.True(flow.CreatedAt.Equal(consumed.CreatedAt))
+	suite.True(expiresAt.Equal(consumed.ExpiresAt))
+
+	// The flow can be completed only once
+	consumed, err = suite.repository.Consume("id")
+	suite.Nil(consumed)
+	suite.EqualError(err, repository.OidcFlowNotFoundError.Error())
+}
@@ -0,0 +1,56 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Conformance suite of repository.PasswordResetRepository
+type PasswordResetSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.PasswordResetRepository
+
+	repository repository.PasswordResetRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *PasswordResetSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *PasswordResetSuite) TestConsumeNotExisting() {
+	reset, err := suite.repository.Consume("notExisting")
+	suite.Nil(reset)
+	suite.EqualError(err, repository.PasswordResetNotFoundError.Error())
+}
+
+func (suite *PasswordResetSuite) TestCreateConsumeOK() {
+	expiresAt := suite.clock.Now().Add(time.Hour)
+	reset, err := suite.repository.Create("id", "userId", "secret", expiresAt)
+	suite.Require().NoError(err)
+
+	suite.Equal("id", reset.Id)
+	suite.Equal("userId", reset.UserId)
+	suite.Equal("secret", reset.Secret)
+	suite.True(suite.clock.Now().Equal(reset.CreatedAt))
+	suite.True(expiresAt.Equal(reset.ExpiresAt))
+
+	consumed, err := suite.repository.Consume("id")
+	suite.Require().NoError(err)
+	suite.Equal("id", consumed.Id)
+	suite.Equal("userId", consumed.UserId)
+	suite.Equal("secret", consumed.Secret)
+	suite.True(reset.CreatedAt.Equal(consumed.CreatedAt))
+	suite.True(expiresAt.Equal(consumed.ExpiresAt))
+
+	// The token can be used only once
+	consumed, err = suite.repository.Consume("id")
+	suite.Nil(consumed)
+	suite.EqualError(err, repository.PasswordResetNotFoundError.Error())
+}
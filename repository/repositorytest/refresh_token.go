@@ -0,0 +1,103 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Conformance suite of repository.RefreshTokenRepository
+type RefreshTokenSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.RefreshTokenRepository
+
+	repository repository.RefreshTokenRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *RefreshTokenSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *RefreshTokenSuite) TestGetByIdNotExisting() {
+	token, err := suite.repository.GetById("notExisting")
+	suite.Nil(token)
+	suite.EqualError(err, repository.RefreshTokenNotFoundError.Error())
+}
+
+func (suite *RefreshTokenSuite) TestCreateOK() {
+	expiresAt := suite.clock.Now().Add(time.Hour)
+	token, err := suite.repository.Create("id", "", "userId", "secret", expiresAt)
+	suite.Require().NoError(err)
+
+	suite.Equal("id", token.Id)
+	suite.Equal("id", token.Family)
+	suite.Equal("userId", token.UserId)
+	suite.Equal("secret", token.Secret)
+	suite.False(token.Used())
+
+	found, err := suite.repository.GetById("id")
+	suite.Require().NoError(err)
+	suite.Equal("id", found.Family)
+	suite.Equal("userId", found.UserId)
+	suite.Equal("secret", found.Secret)
+	suite.True(token.CreatedAt.Equal(found.CreatedAt))
+	suite.True(expiresAt.Equal(found.ExpiresAt))
+	suite.False(found.Used())
+}
+
+func (suite *RefreshTokenSuite) TestCreateInFamily() {
+	token, err := suite.repository.Create("id2", "id", "userId", "secret", suite.clock.Now())
+	suite.Require().NoError(err)
+	suite.Equal("id", token.Family)
+}
+
+func (suite *RefreshTokenSuite) TestUseNotExisting() {
+	token, err := suite.repository.Use("notExisting")
+	suite.Nil(token)
+	suite.EqualError(err, repository.RefreshTokenNotFoundError.Error())
+}
+
+func (suite *RefreshTokenSuite) TestUseOK() {
+	_, err := suite.repository.Create("id", "", "userId", "secret", suite.clock.Now().Add(time.Hour))
+	suite.Require().NoError(err)
+
+	suite.clock.Advance(time.Minute)
+	token, err := suite.repository.Use("id")
+	suite.Require().NoError(err)
+	suite.True(token.Used())
+	suite.True(suite.clock.Now().Equal(token.UsedAt))
+
+	found, err := suite.repository.GetById("id")
+	suite.Require().NoError(err)
+	suite.True(suite.clock.Now().Equal(found.UsedAt))
+
+	// A token can be used only once
+	token, err = suite.repository.Use("id")
+	suite.Nil(token)
+	suite.EqualError(err, repository.RefreshTokenUsedError.Error())
+}
+
+func (suite *RefreshTokenSuite) TestDeleteFamily() {
+	expiresAt := suite.clock.Now().Add(time.Hour)
+	_, err := suite.repository.Create("a1", "", "userId", "secret", expiresAt)
+	suite.Require().NoError(err)
+	_, err = suite.repository.Create("a2", "a1", "userId", "secret", expiresAt)
+	suite.Require().NoError(err)
+	_, err = suite.repository.Create("b1", "", "userId", "secret", expiresAt)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.repository.DeleteFamily("a1"))
+
+	_, err = suite.repository.GetById("a1")
+	suite.EqualError(err, repository.RefreshTokenNotFoundError.Error())
+	_, err = suite.repository.GetById("a2")
+	suite.EqualError(err, repository.RefreshTokenNotFoundError.Error())
+	_, err = suite.repository.GetById("b1")
+	suite.NoError(err)
+}
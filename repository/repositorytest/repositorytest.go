@@ -0,0 +1,12 @@
+// This package contains the conformance suites of the repositories.
+// Every backend runs them with the constructors of its own repositories, so that they all behave the same way
+package repositorytest
+
+import (
+	"github.com/jonboulle/clockwork"
+)
+
+// Returns the clock of a test. Its time is rounded to the microsecond, the precision of the datastore
+func newClock() clockwork.FakeClock {
+	return clockwork.NewFakeClock()
+}
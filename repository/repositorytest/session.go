@@ -0,0 +1,122 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Conformance suite of repository.SessionRepository
+type SessionSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.SessionRepository
+
+	repository repository.SessionRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *SessionSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *SessionSuite) TestGetByIdNotExisting() {
+	session, err := suite.repository.GetById("notExisting")
+	suite.Nil(session)
+	suite.EqualError(err, repository.SessionNotFoundError.Error())
+}
+
+func (suite *SessionSuite) TestCreateOK() {
+	session, err := suite.repository.Create("userId", "secret", "laptop", "127.0.0.1", "agent", false)
+	suite.Require().NoError(err)
+
+	suite.NotEmpty(session.Id)
+	suite.Equal("userId", session.UserId)
+	suite.Equal("secret", session.Secret)
+	suite.Equal("laptop", session.Device)
+	suite.Equal("127.0.0.1", session.Ip)
+	suite.Equal("agent", session.UserAgent)
+	suite.True(suite.clock.Now().Equal(session.CreatedAt))
+	suite.True(suite.clock.Now().Equal(session.LastUsedAt))
+
+	found, err := suite.repository.GetById(session.Id)
+	suite.Require().NoError(err)
+	suite.Equal(session.Id, found.Id)
+	suite.Equal("userId", found.UserId)
+	suite.Equal("secret", found.Secret)
+	suite.Equal("laptop", found.Device)
+	suite.Equal("127.0.0.1", found.Ip)
+	suite.Equal("agent", found.UserAgent)
+	suite.True(session.CreatedAt.Equal(found.CreatedAt))
+	suite.True(session.LastUsedAt.Equal(found.LastUsedAt))
+}
+
+func (suite *SessionSuite) TestAllOf() {
+	first, err := suite.repository.Create("userId", "secret", "laptop", "127.0.0.1", "agent", false)
+	suite.Require().NoError(err)
+	suite.clock.Advance(time.Minute)
+	second, err := suite.repository.Create("userId", "secret", "phone", "127.0.0.1", "agent", false)
+	suite.Require().NoError(err)
+	_, err = suite.repository.Create("otherId", "secret", "laptop", "127.0.0.1", "agent", false)
+	suite.Require().NoError(err)
+
+	sessions, err := suite.repository.AllOf("userId")
+	suite.Require().NoError(err)
+	suite.Require().Len(sessions, 2)
+	suite.Equal(second.Id, sessions[0].Id)
+	suite.Equal(first.Id, sessions[1].Id)
+
+	// The most recently used first
+	suite.clock.Advance(time.Minute)
+	suite.Require().NoError(suite.repository.Touch(first.Id))
+
+	sessions, err = suite.repository.AllOf("userId")
+	suite.Require().NoError(err)
+	suite.Require().Len(sessions, 2)
+	suite.Equal(first.Id, sessions[0].Id)
+	suite.True(suite.clock.Now().Equal(sessions[0].LastUsedAt))
+
+	sessions, err = suite.repository.AllOf("notExisting")
+	suite.Require().NoError(err)
+	suite.Empty(sessions)
+}
+
+func (suite *SessionSuite) TestTouchNotExisting() {
+	err := suite.repository.Touch("notExisting")
+	suite.EqualError(err, repository.SessionNotFoundError.Error())
+}
+
+func (suite *SessionSuite) TestActivate() {
+	session, err := suite.repository.Create("userId", "secret", "laptop", "127.0.0.1", "agent", true)
+	suite.Require().NoError(err)
+	suite.True(session.Pending)
+
+	found, err := suite.repository.GetById(session.Id)
+	suite.Require().NoError(err)
+	suite.True(found.Pending)
+
+	suite.Require().NoError(suite.repository.Activate(session.Id))
+
+	found, err = suite.repository.GetById(session.Id)
+	suite.Require().NoError(err)
+	suite.False(found.Pending)
+}
+
+func (suite *SessionSuite) TestActivateNotExisting() {
+	err := suite.repository.Activate("notExisting")
+	suite.EqualError(err, repository.SessionNotFoundError.Error())
+}
+
+func (suite *SessionSuite) TestDelete() {
+	session, err := suite.repository.Create("userId", "secret", "laptop", "127.0.0.1", "agent", false)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.repository.Delete(session.Id))
+
+	_, err = suite.repository.GetById(session.Id)
+	suite.EqualError(err, repository.SessionNotFoundError.Error())
+}
@@ -0,0 +1,136 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Conformance suite of repository.SubscriptionRepository
+type SubscriptionSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.SubscriptionRepository
+
+	repository repository.SubscriptionRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *SubscriptionSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *SubscriptionSuite) createSubscription(id, to string) *entity.Subscription {
+	entity, err := suite.repository.Create(id, to)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(entity)
+
+	return entity
+}
+
+func (suite *SubscriptionSuite) TestCreateSameId() {
+	suite.createSubscription("id", "to")
+	entity, err := suite.repository.Create("id", "whatever")
+
+	suite.Nil(entity)
+	suite.EqualError(err, repository.SubscriptionAlreadyExistsError.Error())
+
+}
+
+func (suite *SubscriptionSuite) TestCreateOK() {
+	Subscription := suite.createSubscription("id", "to")
+
+	suite.Equal(Subscription.Id, "id")
+	suite.Equal(Subscription.To, "to")
+	suite.Equal(Subscription.CreatedAt, suite.clock.Now())
+	suite.Equal(Subscription.LastSeenAt, suite.clock.Now())
+}
+
+func (suite *SubscriptionSuite) TestAllToOk() {
+	suite.createSubscription("id1", "a")
+	suite.createSubscription("id2", "a")
+	suite.createSubscription("id3", "b")
+
+	Subscriptions, err := suite.repository.AllTo("a")
+	suite.NoError(err)
+	suite.Require().NotNil(Subscriptions)
+	suite.Require().Len(Subscriptions, 2)
+}
+
+func (suite *SubscriptionSuite) TestDeleteOk() {
+	suite.createSubscription("id1", "a")
+	err := suite.repository.Delete("id1")
+	suite.NoError(err)
+}
+
+func (suite *SubscriptionSuite) TestAllToAndDelete() {
+	suite.createSubscription("id2", "a")
+	suite.createSubscription("id1", "a")
+	suite.createSubscription("id3", "b")
+
+	subscriptions, err := suite.repository.AllTo("a")
+	suite.NoError(err)
+	suite.Require().Len(subscriptions, 2)
+	suite.Equal("id1", subscriptions[0].Id)
+	suite.Equal("id2", subscriptions[1].Id)
+
+	suite.NoError(suite.repository.Delete("id1"))
+
+	subscriptions, err = suite.repository.AllTo("a")
+	suite.NoError(err)
+	suite.Require().Len(subscriptions, 1)
+	suite.Equal("id2", subscriptions[0].Id)
+}
+
+func (suite *SubscriptionSuite) TestTouchNotExisting() {
+	suite.EqualError(suite.repository.Touch("notExisting"), repository.SubscriptionNotFoundError.Error())
+}
+
+func (suite *SubscriptionSuite) TestTouchAndAllStale() {
+	created := suite.clock.Now()
+	suite.createSubscription("id1", "")
+	suite.createSubscription("id2", "")
+	suite.createSubscription("id3", "")
+
+	suite.clock.Advance(time.Minute)
+	suite.Require().NoError(suite.repository.Touch("id3"))
+	suite.clock.Advance(time.Minute)
+	suite.Require().NoError(suite.repository.Touch("id1"))
+
+	subscriptions, err := suite.repository.AllStale(created)
+	suite.NoError(err)
+	suite.Len(subscriptions, 0)
+
+	subscriptions, err = suite.repository.AllStale(suite.clock.Now())
+	suite.NoError(err)
+	suite.Require().Len(subscriptions, 2)
+	suite.Equal("id2", subscriptions[0].Id)
+	suite.True(created.Equal(subscriptions[0].LastSeenAt))
+	suite.Equal("id3", subscriptions[1].Id)
+	suite.True(created.Add(time.Minute).Equal(subscriptions[1].LastSeenAt))
+}
+
+func (suite *SubscriptionSuite) TestAllLive() {
+	created := suite.clock.Now()
+	suite.createSubscription("id1", "a")
+	suite.createSubscription("id2", "")
+	suite.createSubscription("id3", "b")
+
+	suite.clock.Advance(time.Minute)
+	suite.Require().NoError(suite.repository.Touch("id3"))
+
+	subscriptions, err := suite.repository.AllLive(created)
+	suite.NoError(err)
+	suite.Require().Len(subscriptions, 1)
+	suite.Equal("id3", subscriptions[0].Id)
+	suite.Equal("b", subscriptions[0].To)
+
+	subscriptions, err = suite.repository.AllLive(created.Add(-time.Second))
+	suite.NoError(err)
+	suite.Len(subscriptions, 3)
+}
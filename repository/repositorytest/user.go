@@ -0,0 +1,328 @@
+package repositorytest
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+// Conformance suite of repository.UserRepository
+type UserSuite struct {
+	suite.Suite
+
+	// Returns an empty repository using clock
+	New func(t *testing.T, clock clockwork.Clock) repository.UserRepository
+
+	repository repository.UserRepository
+	clock      clockwork.FakeClock
+}
+
+func (suite *UserSuite) SetupTest() {
+	suite.clock = newClock()
+	suite.repository = suite.New(suite.T(), suite.clock)
+}
+
+func (suite *UserSuite) createUser(email, password string) *entity.User {
+	user, err := suite.repository.CreateUser(email, password)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(user)
+
+	return user
+}
+
+var (
+	email    = "test@test.com"
+	password = "testPassword"
+)
+
+func (suite *UserSuite) TestGetUserByIdNotExisting() {
+	user, err := suite.repository.GetUserById("notExisting")
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserNotFoundError.Error())
+}
+
+func (suite *UserSuite) TestGetUserByIdOK() {
+	u := suite.createUser(email, password)
+
+	user, err := suite.repository.GetUserById(u.Id)
+	suite.NoError(err)
+	suite.Require().NotNil(user)
+	suite.Equal(email, user.Email)
+}
+
+func (suite *UserSuite) TestGetUserByEmailNotExisting() {
+	user, err := suite.repository.GetUserByEmail(email)
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserNotFoundError.Error())
+}
+
+func (suite *UserSuite) TestGetUserByEmailOK() {
+	suite.createUser(email, password)
+
+	user, err := suite.repository.GetUserByEmail(email)
+	suite.NoError(err)
+	suite.Require().NotNil(user)
+	suite.Equal(email, user.Email)
+}
+
+func (suite *UserSuite) TestCreateExistingUser() {
+	suite.createUser(email, password)
+
+	user, err := suite.repository.CreateUser(email, password)
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserAlreadyExistsError.Error())
+}
+
+func (suite *UserSuite) TestCreateUserOK() {
+	user := suite.createUser(email, password)
+
+	suite.Equal(user.Email, email)
+	suite.Equal(user.CreatedAt, suite.clock.Now())
+	suite.NotEmpty(user.Password)
+	suite.NotEqual(password, user.Password)
+	suite.NotEmpty(user.Secret)
+
+	found, err := suite.repository.GetUserById(user.Id)
+	suite.Require().NoError(err)
+	suite.Equal(user.Email, found.Email)
+	suite.Equal(user.Password, found.Password)
+	suite.Equal(user.Secret, found.Secret)
+	suite.True(user.CreatedAt.Equal(found.CreatedAt))
+}
+
+func (suite *UserSuite) TestLoginNotExistingUser() {
+	user, err := suite.repository.Login(email, password)
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserBadUsernameOrPasswordError.Error())
+}
+
+func (suite *UserSuite) TestLoginBadPassword() {
+	suite.createUser(email, password)
+
+	user, err := suite.repository.Login(email, "badPassword")
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserBadUsernameOrPasswordError.Error())
+}
+
+func (suite *UserSuite) TestLoginOk() {
+	suite.createUser(email, password)
+
+	user, err := suite.repository.Login(email, password)
+	suite.NoError(err)
+	suite.Require().NotNil(user)
+
+	suite.Equal(email, user.Email)
+}
+
+func (suite *UserSuite) TestAllOk() {
+	email1 := "b@b.com"
+	suite.createUser(email1, password)
+
+	email2 := "a@b.com"
+	suite.createUser(email2, password)
+
+	users, err := suite.repository.All()
+	suite.NoError(err)
+	suite.Require().NotNil(users)
+	suite.Require().Len(users, 2)
+
+	suite.Equal(email2, users[0].Email)
+	suite.Equal(email1, users[1].Email)
+}
+
+func (suite *UserSuite) TestTouchNotExisting() {
+	err := suite.repository.Touch("notExisting")
+	suite.EqualError(err, repository.UserNotFoundError.Error())
+}
+
+func (suite *UserSuite) TestTouchOk() {
+	user := suite.createUser(email, password)
+	suite.True(user.LastSeenAt.IsZero())
+
+	suite.clock.Advance(time.Minute)
+	suite.Require().NoError(suite.repository.Touch(user.Id))
+
+	found, err := suite.repository.GetUserById(user.Id)
+	suite.Require().NoError(err)
+	suite.True(suite.clock.Now().Equal(found.LastSeenAt))
+}
+
+func (suite *UserSuite) TestRotateSecretNotExisting() {
+	user, err := suite.repository.RotateSecret("notExisting")
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserNotFoundError.Error())
+}
+
+func (suite *UserSuite) TestRotateSecretOk() {
+	user := suite.createUser(email, password)
+
+	rotated, err := suite.repository.RotateSecret(user.Id)
+	suite.Require().NoError(err)
+	suite.NotEmpty(rotated.Secret)
+	suite.NotEqual(user.Secret, rotated.Secret)
+
+	found, err := suite.repository.GetUserById(user.Id)
+	suite.Require().NoError(err)
+	suite.Equal(rotated.Secret, found.Secret)
+	suite.Equal(user.Password, found.Password)
+}
+
+func (suite *UserSuite) TestUpdatePasswordNotExisting() {
+	user, err := suite.repository.UpdatePassword("notExisting", "newPassword")
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserNotFoundError.Error())
+}
+
+func (suite *UserSuite) TestUpdatePasswordOk() {
+	user := suite.createUser(email, password)
+
+	updated, err := suite.repository.UpdatePassword(user.Id, "newPassword")
+	suite.Require().NoError(err)
+	suite.NotEqual(user.Password, updated.Password)
+	suite.Equal(user.Secret, updated.Secret)
+
+	_, err = suite.repository.Login(email, password)
+	suite.EqualError(err, repository.UserBadUsernameOrPasswordError.Error())
+
+	found, err := suite.repository.Login(email, "newPassword")
+	suite.Require().NoError(err)
+	suite.Equal(updated.Password, found.Password)
+}
+
+func (suite *UserSuite) TestEnableTotp() {
+	user := suite.createUser(email, password)
+	suite.False(user.TotpEnabled())
+
+	updated, err := suite.repository.SetPendingTotpSecret(user.Id, "pending")
+	suite.Require().NoError(err)
+	suite.Equal("pending", updated.PendingTotpSecret)
+	suite.False(updated.TotpEnabled())
+
+	updated, err = suite.repository.EnableTotp(user.Id, "pending", []string{"hash1", "hash2"})
+	suite.Require().NoError(err)
+	suite.Equal("pending", updated.TotpSecret)
+	suite.Empty(updated.PendingTotpSecret)
+
+	found, err := suite.repository.GetUserById(user.Id)
+	suite.Require().NoError(err)
+	suite.True(found.TotpEnabled())
+	suite.Equal("pending", found.TotpSecret)
+	suite.Empty(found.PendingTotpSecret)
+	suite.Equal([]string{"hash1", "hash2"}, found.RecoveryCodes)
+
+	_, err = suite.repository.DisableTotp(user.Id)
+	suite.Require().NoError(err)
+
+	found, err = suite.repository.GetUserById(user.Id)
+	suite.Require().NoError(err)
+	suite.False(found.TotpEnabled())
+	suite.Empty(found.RecoveryCodes)
+}
+
+func (suite *UserSuite) TestEnableTotpNotExisting() {
+	user, err := suite.repository.EnableTotp("notExisting", "secret", []string{"hash"})
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserNotFoundError.Error())
+}
+
+func (suite *UserSuite) TestUseRecoveryCode() {
+	user := suite.createUser(email, password)
+	_, err := suite.repository.EnableTotp(user.Id, "secret", []string{"hash1", "hash2", "hash3"})
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.repository.UseRecoveryCode(user.Id, "hash2"))
+
+	// Every code can be used only once
+	err = suite.repository.UseRecoveryCode(user.Id, "hash2")
+	suite.EqualError(err, repository.UserRecoveryCodeNotFoundError.Error())
+
+	err = suite.repository.UseRecoveryCode(user.Id, "notExisting")
+	suite.EqualError(err, repository.UserRecoveryCodeNotFoundError.Error())
+
+	found, err := suite.repository.GetUserById(user.Id)
+	suite.Require().NoError(err)
+	suite.Equal([]string{"hash1", "hash3"}, found.RecoveryCodes)
+}
+
+func (suite *UserSuite) TestUseRecoveryCodeNotExisting() {
+	err := suite.repository.UseRecoveryCode("notExisting", "hash")
+	suite.EqualError(err, repository.UserNotFoundError.Error())
+}
+
+func (suite *UserSuite) TestUseTotpCounter() {
+	user, err := suite.repository.CreateUser("a@b.com", "password")
+	suite.Require().NoError(err)
+	_, err = suite.repository.EnableTotp(user.Id, "secret", []string{"hash"})
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.repository.UseTotpCounter(user.Id, 42))
+
+	// The codes of the same time step and of the previous ones can't be replayed
+	err = suite.repository.UseTotpCounter(user.Id, 42)
+	suite.EqualError(err, repository.UserTotpCodeUsedError.Error())
+
+	err = suite.repository.UseTotpCounter(user.Id, 41)
+	suite.EqualError(err, repository.UserTotpCodeUsedError.Error())
+
+	suite.Require().NoError(suite.repository.UseTotpCounter(user.Id, 43))
+
+	found, err := suite.repository.GetUserById(user.Id)
+	suite.Require().NoError(err)
+	suite.Equal(int64(43), found.TotpCounter)
+
+	// The counter is reset with the TOTP secret
+	_, err = suite.repository.DisableTotp(user.Id)
+	suite.Require().NoError(err)
+
+	found, err = suite.repository.GetUserById(user.Id)
+	suite.Require().NoError(err)
+	suite.Zero(found.TotpCounter)
+}
+
+func (suite *UserSuite) TestUseTotpCounterNotExisting() {
+	err := suite.repository.UseTotpCounter("notExisting", 42)
+	suite.EqualError(err, repository.UserNotFoundError.Error())
+}
+
+func (suite *UserSuite) TestCreateExternalUser() {
+	user, err := suite.repository.CreateExternalUser(email)
+	suite.Require().NoError(err)
+	suite.Equal(email, user.Email)
+	suite.Equal(suite.clock.Now(), user.CreatedAt)
+	suite.NotEmpty(user.Secret)
+	suite.Empty(user.Password)
+	suite.True(user.EmailVerified)
+
+	// It can't log in with a password
+	_, err = suite.repository.Login(email, "")
+	suite.EqualError(err, repository.UserBadUsernameOrPasswordError.Error())
+}
+
+func (suite *UserSuite) TestCreateExternalUserExisting() {
+	suite.createUser(email, password)
+
+	user, err := suite.repository.CreateExternalUser(email)
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserAlreadyExistsError.Error())
+}
+
+func (suite *UserSuite) TestSetEmailVerified() {
+	user := suite.createUser(email, password)
+	suite.False(user.EmailVerified)
+
+	_, err := suite.repository.SetEmailVerified(user.Id)
+	suite.Require().NoError(err)
+
+	found, err := suite.repository.GetUserById(user.Id)
+	suite.Require().NoError(err)
+	suite.True(found.EmailVerified)
+}
+
+func (suite *UserSuite) TestSetEmailVerifiedNotExisting() {
+	user, err := suite.repository.SetEmailVerified("notExisting")
+	suite.Nil(user)
+	suite.EqualError(err, repository.UserNotFoundError.Error())
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"sort"
+	"time"
+)
+
+// Maximum number of messages containing the terms considered by a search, the newest ones. The older messages are
+// only found by narrowing the search, eg. with Until
+const MaxSearchCandidates = 1000
+
+// Options used to search the messages
+type SearchOptions struct {
+	// Terms to search, see entity.Terms. The messages must contain all of them
+	Terms []string
+
+	// Only return the direct messages exchanged with this user, if not empty
+	With string
+
+	// Only return the messages created at or after this time, if not zero
+	Since time.Time
+
+	// Only return the messages created before this time, if not zero
+	Until time.Time
+
+	// Maximum number of messages to return
+	Limit int
+
+	// Number of the most relevant messages to skip, used to page through the results
+	Offset int
+}
+
+// Returns true if the message satisfies the filters of the options, regardless of the terms
+func (o SearchOptions) matches(message entity.Message) bool {
+	if o.With != "" && (message.ConversationId != "" || !message.HasUser(o.With)) {
+		return false
+	}
+	if !o.Since.IsZero() && message.CreatedAt.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && !message.CreatedAt.Before(o.Until) {
+		return false
+	}
+	return true
+}
+
+// Message matching a search
+type SearchResult struct {
+	Message entity.Message
+
+	// Relevance of the message, see entity.Message.Score
+	Score int
+}
+
+// Filters the messages found by the index of a backend with the options and keeps the MaxSearchCandidates newest
+// ones, for the backends which can't do it while querying
+func Candidates(messages []entity.Message, options SearchOptions) []entity.Message {
+	candidates := []entity.Message{}
+	for _, message := range messages {
+		if options.matches(message) && message.Score(options.Terms) > 0 {
+			candidates = append(candidates, message)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return newer(candidates[i], candidates[j])
+	})
+
+	if len(candidates) > MaxSearchCandidates {
+		candidates = candidates[:MaxSearchCandidates]
+	}
+
+	return candidates
+}
+
+// Filters the candidates found by a backend with the options, and returns the page of the most relevant ones.
+// The messages with the same score are sorted from the newest
+func Rank(messages []entity.Message, options SearchOptions) []SearchResult {
+	results := []SearchResult{}
+	for _, message := range messages {
+		if !options.matches(message) {
+			continue
+		}
+
+		if score := message.Score(options.Terms); score > 0 {
+			results = append(results, SearchResult{
+				Message: message,
+				Score:   score,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return newer(results[i].Message, results[j].Message)
+	})
+
+	if options.Offset >= len(results) {
+		return []SearchResult{}
+	}
+	results = results[options.Offset:]
+
+	if len(results) > options.Limit {
+		results = results[:options.Limit]
+	}
+
+	return results
+}
+
+// Returns true if a has been created after b, or at the same time with a greater id
+func newer(a, b entity.Message) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.Id > b.Id
+	}
+	return a.CreatedAt.After(b.CreatedAt)
+}
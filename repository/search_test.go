@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type SearchTestSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+func TestSearch(t *testing.T) {
+	suite.Run(t, new(SearchTestSuite))
+}
+
+func (suite *SearchTestSuite) SetupTest() {
+	suite.now = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Returns a direct message from a to b, created ago
+func (suite *SearchTestSuite) message(id, text string, ago time.Duration) entity.Message {
+	return entity.Message{
+		Id:        id,
+		From:      "a",
+		To:        "b",
+		Message:   text,
+		Users:     []string{"a", "b"},
+		CreatedAt: suite.now.Add(-ago),
+	}
+}
+
+// Returns the IDs of the messages, in order
+func ids(messages []entity.Message) []string {
+	ids := []string{}
+	for _, message := range messages {
+		ids = append(ids, message.Id)
+	}
+	return ids
+}
+
+func (suite *SearchTestSuite) TestScore() {
+	message := suite.message("m", "Hello, hello world!", 0)
+
+	suite.Equal(2, message.Score([]string{"hello"}))
+	suite.Equal(3, message.Score([]string{"hello", "world"}))
+	suite.Equal(0, message.Score([]string{"hello", "nothing"}))
+	suite.Equal(0, message.Score([]string{"hell"}))
+}
+
+func (suite *SearchTestSuite) TestRank() {
+	messages := []entity.Message{
+		suite.message("m1", "hello", 3*time.Second),
+		suite.message("m2", "hello hello", 2*time.Second),
+		suite.message("m3", "goodbye", time.Second),
+		suite.message("m4", "hello", time.Second),
+		suite.message("m5", "hello", time.Second),
+	}
+
+	// The most relevant first, then the newest. The ties are broken by id
+	results := Rank(messages, SearchOptions{Terms: []string{"hello"}, Limit: 10})
+	suite.Equal([]SearchResult{
+		{Message: messages[1], Score: 2},
+		{Message: messages[4], Score: 1},
+		{Message: messages[3], Score: 1},
+		{Message: messages[0], Score: 1},
+	}, results)
+
+	results = Rank(messages, SearchOptions{Terms: []string{"hello"}, Limit: 2, Offset: 1})
+	suite.Equal([]SearchResult{
+		{Message: messages[4], Score: 1},
+		{Message: messages[3], Score: 1},
+	}, results)
+
+	suite.Empty(Rank(messages, SearchOptions{Terms: []string{"hello"}, Limit: 2, Offset: 4}))
+	suite.Empty(Rank(messages, SearchOptions{Terms: []string{"nothing"}, Limit: 10}))
+}
+
+func (suite *SearchTestSuite) TestRankFilters() {
+	conversation := suite.message("m1", "hello", 0)
+	conversation.ConversationId = "c"
+	other := suite.message("m2", "hello", time.Minute)
+	other.To = "c"
+	other.Users = []string{"a", "c"}
+	messages := []entity.Message{
+		conversation,
+		other,
+		suite.message("m3", "hello", time.Hour),
+	}
+
+	results := Rank(messages, SearchOptions{Terms: []string{"hello"}, Limit: 10, With: "b"})
+	suite.Require().Len(results, 1)
+	suite.Equal("m3", results[0].Message.Id)
+
+	results = Rank(messages, SearchOptions{Terms: []string{"hello"}, Limit: 10, Since: suite.now.Add(-time.Minute)})
+	suite.Require().Len(results, 2)
+	suite.Equal("m1", results[0].Message.Id)
+	suite.Equal("m2", results[1].Message.Id)
+
+	results = Rank(messages, SearchOptions{Terms: []string{"hello"}, Limit: 10, Until: suite.now.Add(-time.Minute)})
+	suite.Require().Len(results, 1)
+	suite.Equal("m3", results[0].Message.Id)
+}
+
+func (suite *SearchTestSuite) TestCandidates() {
+	messages := []entity.Message{
+		suite.message("old", "hello hello", time.Hour),
+		suite.message("other", "goodbye", 0),
+	}
+	for i := 0; i < MaxSearchCandidates; i++ {
+		messages = append(messages, suite.message(fmt.Sprintf("m%04d", i), "hello", time.Duration(i)))
+	}
+
+	// The newest matches are kept
+	candidates := Candidates(messages, SearchOptions{Terms: []string{"hello"}})
+	suite.Require().Len(candidates, MaxSearchCandidates)
+	suite.Equal("m0000", candidates[0].Id)
+	suite.Equal(fmt.Sprintf("m%04d", MaxSearchCandidates-1), candidates[len(candidates)-1].Id)
+	suite.NotContains(ids(candidates), "old")
+
+	// The filters are applied before the cap
+	candidates = Candidates(messages, SearchOptions{Terms: []string{"hello"}, Until: suite.now.Add(-time.Minute)})
+	suite.Equal([]string{"old"}, ids(candidates))
+}
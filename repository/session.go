@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"cloud.google.com/go/datastore"
+	"context"
+	"errors"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+	"sort"
+)
+
+var (
+	// Error thrown when the session has not been found
+	SessionNotFoundError = errors.New("Session not found")
+)
+
+// Interface used mainly for Unit testing
+type SessionRepository interface {
+	GetById(string) (*entity.Session, error)
+	AllOf(string) ([]entity.Session, error)
+	Create(string, string, string, string, string, bool) (*entity.Session, error)
+	Touch(string) error
+	Activate(string) error
+	Delete(string) error
+}
+
+// Session Repository
+type Session struct {
+	// Injected via DI
+	Client *datastore.Client `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+	kind  string
+}
+
+func NewSessionRepository() *Session {
+	return &Session{
+		kind: "Session",
+	}
+}
+
+// Fetch a session by ID
+func (r Session) GetById(id string) (*entity.Session, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+
+	entity := &entity.Session{}
+	err := r.Client.Get(ctx, key, entity)
+	if err == datastore.ErrNoSuchEntity {
+		return nil, SessionNotFoundError
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// Returns all the sessions of the user, the most recently used first
+func (r Session) AllOf(userId string) ([]entity.Session, error) {
+	query := datastore.NewQuery(r.kind).Filter("UserId =", userId)
+
+	sessions := []entity.Session{}
+	ctx := context.Background()
+	if _, err := r.Client.GetAll(ctx, query, &sessions); err != nil {
+		return nil, err
+	}
+
+	// Sorted here to avoid the need of a composite index
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+// Opens a new session of the user. A pending session waits for the second factor of the login
+func (r Session) Create(userId, secret, device, ip, userAgent string, pending bool) (*entity.Session, error) {
+	now := r.Clock.Now()
+	session := &entity.Session{
+		Id:         uuid.NewV4().String(),
+		UserId:     userId,
+		Secret:     secret,
+		Device:     device,
+		Ip:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		Pending:    pending,
+	}
+
+	key := datastore.NameKey(r.kind, session.Id, nil)
+
+	ctx := context.Background()
+	if _, err := r.Client.Put(ctx, key, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Sets the LastUsedAt of the session to now
+func (r Session) Touch(id string) error {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var session entity.Session
+
+		err := tx.Get(key, &session)
+		if err == datastore.ErrNoSuchEntity {
+			return SessionNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		session.LastUsedAt = r.Clock.Now()
+		_, err = tx.Put(key, &session)
+		return err
+	})
+
+	return err
+}
+
+// Marks a pending session as active, once the second factor of the login has been verified
+func (r Session) Activate(id string) error {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var session entity.Session
+
+		err := tx.Get(key, &session)
+		if err == datastore.ErrNoSuchEntity {
+			return SessionNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		session.Pending = false
+		_, err = tx.Put(key, &session)
+		return err
+	})
+
+	return err
+}
+
+// Deletes a session, by ID
+func (r Session) Delete(id string) error {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	return r.Client.Delete(ctx, key)
+}
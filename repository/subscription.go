@@ -6,17 +6,25 @@ import (
 	"errors"
 	"github.com/asiragusa/wschat/entity"
 	"github.com/jonboulle/clockwork"
+	"sort"
+	"time"
 )
 
 var (
 	// Error thrown if a subscription already exists
 	SubscriptionAlreadyExistsError = errors.New("Already exists")
+
+	// Error thrown when the subscription has not been found
+	SubscriptionNotFoundError = errors.New("Not found")
 )
 
 // Interface used mainly for Unit testing
 type SubscriptionRepository interface {
 	AllTo(string) ([]entity.Subscription, error)
+	AllStale(time.Time) ([]entity.Subscription, error)
+	AllLive(time.Time) ([]entity.Subscription, error)
 	Create(string, string) (*entity.Subscription, error)
+	Touch(string) error
 	Delete(string) error
 }
 
@@ -49,13 +57,54 @@ func (r Subscription) AllTo(to string) ([]entity.Subscription, error) {
 
 }
 
+// Returns all the subscriptions not seen since before, sorted by LastSeenAt
+func (r Subscription) AllStale(before time.Time) ([]entity.Subscription, error) {
+	// A subscription is always created before being seen. Filtering on CreatedAt includes the subscriptions created
+	// before LastSeenAt was introduced, which don't have the property
+	query := datastore.NewQuery(r.kind).Filter("CreatedAt <", before)
+
+	entities := []entity.Subscription{}
+	ctx := context.Background()
+	if _, err := r.Client.GetAll(ctx, query, &entities); err != nil {
+		return nil, err
+	}
+
+	stale := []entity.Subscription{}
+	for _, subscription := range entities {
+		if subscription.LastSeenAt.Before(before) {
+			stale = append(stale, subscription)
+		}
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].LastSeenAt.Before(stale[j].LastSeenAt)
+	})
+
+	return stale, nil
+}
+
+// Returns all the subscriptions seen after since
+func (r Subscription) AllLive(since time.Time) ([]entity.Subscription, error) {
+	query := datastore.NewQuery(r.kind).Filter("LastSeenAt >", since)
+
+	entities := []entity.Subscription{}
+	ctx := context.Background()
+	if _, err := r.Client.GetAll(ctx, query, &entities); err != nil {
+		return nil, err
+	}
+
+	return entities, nil
+}
+
 // Creates a new subscription for the to user.
 // The caller is responsible for the uniqueness of the ID (eg. using an UUID generator)
 func (r Subscription) Create(id, to string) (*entity.Subscription, error) {
+	now := r.Clock.Now()
 	subscription := &entity.Subscription{
-		Id:        id,
-		To:        to,
-		CreatedAt: r.Clock.Now(),
+		Id:         id,
+		To:         to,
+		CreatedAt:  now,
+		LastSeenAt: now,
 	}
 
 	key := datastore.NameKey(r.kind, subscription.Id, nil)
@@ -84,6 +133,30 @@ func (r Subscription) Create(id, to string) (*entity.Subscription, error) {
 	return subscription, nil
 }
 
+// Sets the LastSeenAt of the subscription to now
+func (r Subscription) Touch(id string) error {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var subscription entity.Subscription
+
+		err := tx.Get(key, &subscription)
+		if err == datastore.ErrNoSuchEntity {
+			return SubscriptionNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		subscription.LastSeenAt = r.Clock.Now()
+		_, err = tx.Put(key, &subscription)
+		return err
+	})
+
+	return err
+}
+
 // Deletes a subscription, by ID
 func (r Subscription) Delete(id string) error {
 	key := datastore.NameKey(r.kind, id, nil)
@@ -9,6 +9,7 @@ import (
 	"github.com/satori/go.uuid"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/api/iterator"
+	"time"
 )
 
 var (
@@ -20,6 +21,10 @@ var (
 	UserAlreadyExistsError = errors.New("User already exists")
 	// Bad username or password
 	UserBadUsernameOrPasswordError = errors.New("Bad username or password")
+	// The recovery code does not exist or has already been used
+	UserRecoveryCodeNotFoundError = errors.New("Recovery code not found")
+	// The TOTP code has already been used, or a newer one has
+	UserTotpCodeUsedError = errors.New("TOTP code already used")
 )
 
 // Interface used mainly for Unit testing
@@ -27,8 +32,18 @@ type UserRepository interface {
 	GetUserById(string) (*entity.User, error)
 	GetUserByEmail(string) (*entity.User, error)
 	CreateUser(string, string) (*entity.User, error)
+	CreateExternalUser(string) (*entity.User, error)
 	Login(string, string) (*entity.User, error)
 	All() ([]entity.User, error)
+	Touch(string) error
+	RotateSecret(string) (*entity.User, error)
+	UpdatePassword(string, string) (*entity.User, error)
+	SetPendingTotpSecret(string, string) (*entity.User, error)
+	EnableTotp(string, string, []string) (*entity.User, error)
+	DisableTotp(string) (*entity.User, error)
+	UseRecoveryCode(string, string) error
+	UseTotpCounter(string, int64) error
+	SetEmailVerified(string) (*entity.User, error)
 }
 
 // User Repository
@@ -131,6 +146,37 @@ func (r User) CreateUser(email string, password string) (*entity.User, error) {
 	return user, nil
 }
 
+// Creates a new user without password, whose email has been verified by an identity provider.
+// The user can't log in with a password until it resets it
+func (r User) CreateExternalUser(email string) (*entity.User, error) {
+	_, err := r.GetUserByEmail(email)
+	if err == nil {
+		return nil, UserAlreadyExistsError
+	}
+	if err != UserNotFoundError {
+		return nil, err
+	}
+
+	user := &entity.User{
+		Id:            uuid.NewV4().String(),
+		Email:         email,
+		Secret:        uuid.NewV4().String(),
+		CreatedAt:     r.Clock.Now(),
+		EmailVerified: true,
+	}
+
+	key := datastore.NameKey(r.kind, user.Id, nil)
+
+	ctx := context.Background()
+	_, err = r.Client.Put(ctx, key, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 // Logs in an user by email and password
 func (r User) Login(email, password string) (*entity.User, error) {
 	user, err := r.GetUserByEmail(email)
@@ -163,3 +209,160 @@ func (r User) All() ([]entity.User, error) {
 
 	return users, nil
 }
+
+// Sets the LastSeenAt of the user to now
+func (r User) Touch(id string) error {
+	_, err := r.update(id, func(user *entity.User) error {
+		user.LastSeenAt = r.Clock.Now()
+		return nil
+	})
+	return err
+}
+
+// Replaces the secret of the user with a new random one, invalidating all the tokens issued to the user
+func (r User) RotateSecret(id string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.Secret = uuid.NewV4().String()
+		return nil
+	})
+}
+
+// Replaces the password of the user, hashing the new one with bcrypt
+func (r User) UpdatePassword(id string, password string) (*entity.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.update(id, func(user *entity.User) error {
+		user.Password = string(hash)
+		return nil
+	})
+}
+
+// Stores the TOTP secret being enrolled, until it's confirmed by EnableTotp
+func (r User) SetPendingTotpSecret(id string, secret string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.PendingTotpSecret = secret
+		return nil
+	})
+}
+
+// Enables the two factor authentication with the given TOTP secret and the hashes of the recovery codes
+func (r User) EnableTotp(id string, secret string, recoveryCodes []string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.TotpSecret = secret
+		user.PendingTotpSecret = ""
+		user.RecoveryCodes = recoveryCodes
+		return nil
+	})
+}
+
+// Disables the two factor authentication, removing the TOTP secret, the recovery codes and the last time step used
+func (r User) DisableTotp(id string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.TotpSecret = ""
+		user.PendingTotpSecret = ""
+		user.RecoveryCodes = nil
+		user.TotpCounter = 0
+		return nil
+	})
+}
+
+// Records the time step of an accepted TOTP code. Returns UserTotpCodeUsedError if it isn't newer than the last one
+func (r User) UseTotpCounter(id string, counter int64) error {
+	_, err := r.update(id, func(user *entity.User) error {
+		if counter <= user.TotpCounter {
+			return UserTotpCodeUsedError
+		}
+
+		user.TotpCounter = counter
+		return nil
+	})
+	return err
+}
+
+// Removes the recovery code with the given hash, so it can't be used twice
+func (r User) UseRecoveryCode(id string, hash string) error {
+	_, err := r.update(id, func(user *entity.User) error {
+		codes := []string{}
+		for _, code := range user.RecoveryCodes {
+			if code != hash {
+				codes = append(codes, code)
+			}
+		}
+		if len(codes) == len(user.RecoveryCodes) {
+			return UserRecoveryCodeNotFoundError
+		}
+
+		user.RecoveryCodes = codes
+		return nil
+	})
+	return err
+}
+
+// Marks the email of the user as verified
+func (r User) SetEmailVerified(id string) (*entity.User, error) {
+	return r.update(id, func(user *entity.User) error {
+		user.EmailVerified = true
+		return nil
+	})
+}
+
+// Marks as verified the emails of the users created before the given time, registered before the email verification
+// was introduced. Returns the number of users updated
+func (r User) Backfill(before time.Time) (int, error) {
+	query := datastore.NewQuery(r.kind).Filter("CreatedAt <", before)
+
+	count := 0
+	it := r.Client.Run(context.Background(), query)
+	for {
+		var user entity.User
+		key, err := it.Next(&user)
+		if err == iterator.Done {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if user.EmailVerified {
+			continue
+		}
+		if _, err := r.SetEmailVerified(key.Name); err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// Applies fn to the user in a transaction
+func (r User) update(id string, fn func(*entity.User) error) (*entity.User, error) {
+	key := datastore.NameKey(r.kind, id, nil)
+
+	var user entity.User
+
+	ctx := context.Background()
+	_, err := r.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		err := tx.Get(key, &user)
+		if err == datastore.ErrNoSuchEntity {
+			return UserNotFoundError
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := fn(&user); err != nil {
+			return err
+		}
+
+		_, err = tx.Put(key, &user)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -1,59 +1,61 @@
-package repository
+package repository_test
 
 import (
 	"cloud.google.com/go/datastore"
 	"context"
 	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/repository/repositorytest"
 	"github.com/jonboulle/clockwork"
 	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"testing"
 	"time"
 )
 
+func TestUserRepository(t *testing.T) {
+	skipWithoutEmulator(t)
+
+	client, err := getDatastoreClient("test")
+	require.NoError(t, err)
+
+	suite.Run(t, &repositorytest.UserSuite{
+		New: func(t *testing.T, clock clockwork.Clock) repository.UserRepository {
+			cleanDb(t, client)
+
+			r := repository.NewUserRepository()
+			r.Client = client
+			r.Clock = clock
+			return r
+		},
+	})
+}
+
+// Tests of the behaviours specific to the datastore, which the other backends can't reproduce
 type UserRepositoryTestSuite struct {
 	suite.Suite
-	userRepository *User
+	userRepository *repository.User
 	clock          clockwork.FakeClock
 }
 
-func TestUserRepository(t *testing.T) {
+func TestDatastoreUserRepository(t *testing.T) {
+	skipWithoutEmulator(t)
 	suite.Run(t, new(UserRepositoryTestSuite))
 }
 
-func getDatastoreClient(projectID string) (*datastore.Client, error) {
-	ctx := context.Background()
-
-	client, err := datastore.NewClient(ctx, projectID)
-	if err != nil {
-		return nil, err
-	}
-	return client, nil
-}
-
 func (suite *UserRepositoryTestSuite) SetupSuite() {
 	client, err := getDatastoreClient("test")
 	suite.Require().NoError(err)
 
-	suite.userRepository = NewUserRepository()
+	suite.userRepository = repository.NewUserRepository()
 	suite.userRepository.Client = client
 }
 
-func (suite *UserRepositoryTestSuite) cleanDb() {
-	query := datastore.NewQuery("").KeysOnly()
-	ctx := context.Background()
-
-	keys, err := suite.userRepository.Client.GetAll(ctx, query, nil)
-	suite.Require().NoError(err)
-
-	err = suite.userRepository.Client.DeleteMulti(ctx, keys)
-	suite.Require().NoError(err)
-}
-
 func (suite *UserRepositoryTestSuite) SetupTest() {
-	suite.cleanDb()
+	cleanDb(suite.T(), suite.userRepository.Client)
 
-	suite.clock = clockwork.NewFakeClockAt(time.Now())
+	suite.clock = clockwork.NewFakeClock()
 	suite.userRepository.Clock = suite.clock
 }
 
@@ -88,98 +90,37 @@ var (
 	password = "testPassword"
 )
 
-func (suite *UserRepositoryTestSuite) TestGetUserByIdNotExisting() {
-	user, err := suite.userRepository.GetUserById("notExisting")
-	suite.Nil(user)
-	suite.EqualError(err, UserNotFoundError.Error())
-}
-
-func (suite *UserRepositoryTestSuite) TestGetUserByIdOK() {
-	u := suite.createUser(email, password)
-
-	user, err := suite.userRepository.GetUserById(u.Id)
-	suite.NoError(err)
-	suite.Require().NotNil(user)
-	suite.Equal(email, user.Email)
-}
-
-func (suite *UserRepositoryTestSuite) TestGetUserByEmailNotExisting() {
-	user, err := suite.userRepository.GetUserByEmail(email)
-	suite.Nil(user)
-	suite.EqualError(err, UserNotFoundError.Error())
-}
-
 func (suite *UserRepositoryTestSuite) TestGetUserByEmailDuplicate() {
 	suite.createUser(email, password)
 	suite.createUserRaw(email, password)
 
 	user, err := suite.userRepository.GetUserByEmail(email)
 	suite.Nil(user)
-	suite.EqualError(err, UserDuplicateError.Error())
+	suite.EqualError(err, repository.UserDuplicateError.Error())
 }
 
-func (suite *UserRepositoryTestSuite) TestGetUserByEmailOK() {
-	suite.createUser(email, password)
-
-	user, err := suite.userRepository.GetUserByEmail(email)
-	suite.NoError(err)
-	suite.Require().NotNil(user)
-	suite.Equal(email, user.Email)
-}
-
-func (suite *UserRepositoryTestSuite) TestCreateExistingUser() {
-	suite.createUser(email, password)
-
-	user, err := suite.userRepository.CreateUser(email, password)
-	suite.Nil(user)
-	suite.EqualError(err, UserAlreadyExistsError.Error())
-}
-
-func (suite *UserRepositoryTestSuite) TestCreateUserOK() {
-	user := suite.createUser(email, password)
-
-	suite.Equal(user.Email, email)
-	suite.Equal(user.CreatedAt, suite.userRepository.Clock.Now())
-	suite.NotEmpty(user.Password)
-	suite.NotEmpty(user.Secret)
-}
-
-func (suite *UserRepositoryTestSuite) TestLoginNotExistingUser() {
-	user, err := suite.userRepository.Login(email, password)
-	suite.Nil(user)
-	suite.EqualError(err, UserBadUsernameOrPasswordError.Error())
-}
-
-func (suite *UserRepositoryTestSuite) TestLoginBadPassword() {
-	suite.createUser(email, password)
-
-	user, err := suite.userRepository.Login(email, "badPassword")
-	suite.Nil(user)
-	suite.EqualError(err, UserBadUsernameOrPasswordError.Error())
-}
-
-func (suite *UserRepositoryTestSuite) TestLoginOk() {
-	suite.createUser(email, password)
-
-	user, err := suite.userRepository.Login(email, password)
-	suite.NoError(err)
-	suite.Require().NotNil(user)
+func (suite *UserRepositoryTestSuite) TestBackfill() {
+	// A user registered before the email verification was introduced
+	old := suite.createUser(email, password)
+	verified := suite.createUser("verified@b.com", password)
+	_, err := suite.userRepository.SetEmailVerified(verified.Id)
+	suite.Require().NoError(err)
 
-	suite.Equal(email, user.Email)
-}
+	suite.clock.Advance(time.Hour)
+	upgradedAt := suite.clock.Now()
 
-func (suite *UserRepositoryTestSuite) TestAllOk() {
-	email1 := "b@b.com"
-	suite.createUser(email1, password)
+	suite.clock.Advance(time.Hour)
+	recent := suite.createUser("recent@b.com", password)
 
-	email2 := "a@b.com"
-	suite.createUser(email2, password)
+	count, err := suite.userRepository.Backfill(upgradedAt)
+	suite.Require().NoError(err)
+	suite.Equal(1, count)
 
-	users, err := suite.userRepository.All()
-	suite.NoError(err)
-	suite.Require().NotNil(users)
-	suite.Require().Len(users, 2)
+	found, err := suite.userRepository.GetUserById(old.Id)
+	suite.Require().NoError(err)
+	suite.True(found.EmailVerified)
 
-	suite.Equal(email2, users[0].Email)
-	suite.Equal(email1, users[1].Email)
+	found, err = suite.userRepository.GetUserById(recent.Id)
+	suite.Require().NoError(err)
+	suite.False(found.EmailVerified)
 }
@@ -1,7 +1,11 @@
 // The package request defines all the requests accepted by the HTTP and WS endpoints
 package request
 
-import "github.com/asiragusa/wschat/entity"
+import (
+	"github.com/asiragusa/wschat/entity"
+	"io"
+	"time"
+)
 
 type (
 	// Base interface
@@ -14,6 +18,15 @@ type (
 
 		// User password
 		Password string `json:"password" validate:"required,min=6"`
+
+		// Name of the device, shown in the list of the sessions
+		Device string `json:"device" validate:"max=100"`
+
+		// This field is assigned by the request handler. IP address of the client
+		Ip string `json:"-"`
+
+		// This field is assigned by the request handler. User agent of the client
+		UserAgent string `json:"-"`
 	}
 
 	// Used by POST /login
@@ -23,6 +36,166 @@ type (
 
 		// User password
 		Password string `json:"password" validate:"required"`
+
+		// Name of the device, shown in the list of the sessions
+		Device string `json:"device" validate:"max=100"`
+
+		// This field is assigned by the request handler. IP address of the client
+		Ip string `json:"-"`
+
+		// This field is assigned by the request handler. User agent of the client
+		UserAgent string `json:"-"`
+	}
+
+	// Used by POST /token/refresh
+	RefreshToken struct {
+		// Refresh token returned by the login, the registration or the previous refresh
+		RefreshToken string `json:"refreshToken" validate:"required"`
+	}
+
+	// Used by POST /logout
+	Logout struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// This field is assigned by the request handler. It represents the session of the access token
+		Session entity.Session `json:"-"`
+	}
+
+	// Used by POST /logout/all
+	LogoutAll struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+	}
+
+	// Used by GET /sessions
+	ListSessions struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// This field is assigned by the request handler. It represents the session of the access token
+		Session entity.Session `json:"-"`
+	}
+
+	// Used by DELETE /sessions/{id}
+	DeleteSession struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// Session revoked. Assigned from the URL by the HTTP request handler
+		SessionId string `json:"sessionId" validate:"required"`
+	}
+
+	// Used by POST /password
+	ChangePassword struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// This field is assigned by the request handler. It represents the session of the access token
+		Session entity.Session `json:"-"`
+
+		// Current password of the user
+		CurrentPassword string `json:"currentPassword" validate:"required"`
+
+		// New password
+		NewPassword string `json:"newPassword" validate:"required,min=6"`
+
+		// This field is assigned by the request handler. IP address of the client
+		Ip string `json:"-"`
+
+		// This field is assigned by the request handler. User agent of the client
+		UserAgent string `json:"-"`
+	}
+
+	// Used by POST /password/forgot
+	ForgotPassword struct {
+		// Email of the user
+		Email string `json:"email" validate:"required,email"`
+
+		// This field is assigned by the request handler. IP address of the client
+		Ip string `json:"-"`
+	}
+
+	// Used by POST /password/reset
+	ResetPassword struct {
+		// Token received by email
+		Token string `json:"token" validate:"required"`
+
+		// New password
+		Password string `json:"password" validate:"required,min=6"`
+	}
+
+	// Used by POST /login/mfa
+	LoginMfa struct {
+		// Mfa token returned by POST /login
+		MfaToken string `json:"mfaToken" validate:"required"`
+
+		// TOTP code or recovery code
+		Code string `json:"code" validate:"required"`
+
+		// This field is assigned by the request handler. IP address of the client
+		Ip string `json:"-"`
+	}
+
+	// Used by POST /mfa/totp
+	EnrollTotp struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+	}
+
+	// Used by POST /mfa/totp/confirm
+	ConfirmTotp struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// First TOTP code generated with the enrolled secret
+		Code string `json:"code" validate:"required"`
+	}
+
+	// Used by DELETE /mfa/totp
+	DisableTotp struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// TOTP code or recovery code
+		Code string `json:"code" validate:"required"`
+	}
+
+	// Used by POST /oidc/authorize
+	OidcAuthorize struct {
+	}
+
+	// Used by POST /oidc/login
+	OidcLogin struct {
+		// Authorization code received by the redirect URL
+		Code string `json:"code" validate:"required"`
+
+		// State received by the redirect URL
+		State string `json:"state" validate:"required"`
+
+		// Flow token returned by POST /oidc/authorize
+		FlowToken string `json:"flowToken" validate:"required"`
+
+		// Name of the device, shown in the list of the sessions
+		Device string `json:"device" validate:"max=100"`
+
+		// This field is assigned by the request handler. IP address of the client
+		Ip string `json:"-"`
+
+		// This field is assigned by the request handler. User agent of the client
+		UserAgent string `json:"-"`
+	}
+
+	// Used by POST /verify
+	VerifyEmail struct {
+		// Token of the verification link received by email
+		Token string `json:"token" validate:"required"`
+	}
+
+	// Used by POST /verify/resend
+	ResendVerification struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
 	}
 
 	// Used by POST /message and WS
@@ -35,21 +208,217 @@ type (
 
 		// Message text
 		Message string `json:"message" validate:"required"`
+
+		// ID of the message replied to, optional
+		ReplyTo string `json:"replyTo"`
+
+		// IDs of the uploaded attachments to send with the message, optional
+		Attachments []string `json:"attachments" validate:"max=10,dive,required"`
 	}
 
 	// Used by GET /messages
 	ListMessages struct {
 		// This field is assigned by the request handler. It represents the current authorized user
-		User entity.User
+		User entity.User `json:"-"`
+
+		// Maximum number of messages to return. Defaults to 50
+		Limit int `json:"limit" validate:"min=0,max=100"`
+
+		// Cursor returned as nextCursor, fetches the older messages
+		Before string `json:"before"`
+
+		// Cursor returned as prevCursor, fetches the newer messages
+		After string `json:"after"`
+
+		// Only return the direct messages exchanged with this user
+		With string `json:"with" validate:"omitempty,email"`
+
+		// Only return the messages of this conversation
+		ConversationId string `json:"conversationId"`
+	}
+
+	// Used by GET /messages/search
+	SearchMessages struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// Words to search, the messages must contain all of them
+		Query string `json:"q" validate:"required,max=200"`
+
+		// Maximum number of messages to return. Defaults to 20
+		Limit int `json:"limit" validate:"min=0,max=100"`
+
+		// Number of the most relevant messages to skip, to page through the results
+		Offset int `json:"offset" validate:"min=0"`
+
+		// Only search the direct messages exchanged with this user
+		With string `json:"with" validate:"omitempty,email"`
+
+		// Only search the messages sent at or after this time, if not zero
+		Since time.Time `json:"since"`
+
+		// Only search the messages sent before this time, if not zero
+		Until time.Time `json:"until"`
+	}
+
+	// Used by POST /messages/{id}/read and WS
+	ReadMessage struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// Message read. Assigned from the URL by the HTTP request handler
+		MessageId string `json:"messageId" validate:"required"`
+	}
+
+	// Used by PATCH /messages/{id} and WS
+	EditMessage struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// Message edited. Assigned from the URL by the HTTP request handler
+		MessageId string `json:"messageId" validate:"required"`
+
+		// New message text
+		Message string `json:"message" validate:"required"`
+	}
+
+	// Used by DELETE /messages/{id} and WS
+	DeleteMessage struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// Message deleted. Assigned from the URL by the HTTP request handler
+		MessageId string `json:"messageId" validate:"required"`
+
+		// Either self, to hide the message from the user's history, or everyone, to delete it for all its users
+		Scope string `json:"scope" validate:"required,eq=self|eq=everyone"`
+	}
+
+	// Used by POST and DELETE /messages/{id}/reactions and WS
+	Reaction struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// Message reacted to. Assigned from the URL by the HTTP request handler
+		MessageId string `json:"messageId" validate:"required"`
+
+		// Emoji of the reaction, a single emoji
+		Emoji string `json:"emoji" validate:"required,max=32,emoji"`
+	}
+
+	// Used by WS to notify that the user is typing
+	Typing struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		From entity.User `json:"-"`
+
+		// User receiving the indicator
+		To string `json:"to" validate:"required"`
 	}
 
 	// Used by GET /users
 	ListUsers struct {
 	}
 
+	// Used by GET /.well-known/jwks.json
+	Jwks struct {
+	}
+
 	// Used by POST /wsToken
 	CreateWsToken struct {
 		// This field is assigned by the request handler. It represents the current authorized user
 		User entity.User
+
+		// This field is assigned by the request handler. The websocket is opened in the session of the access token
+		Session entity.Session
+	}
+
+	// Used by POST /conversations
+	CreateConversation struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// Conversation name
+		Name string `json:"name" validate:"required,max=100"`
+
+		// Emails of the members. The current user is always added
+		Members []string `json:"members" validate:"dive,required"`
+	}
+
+	// Used by GET /conversations
+	ListConversations struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User
+	}
+
+	// Used by POST /conversations/{id}/leave
+	ConversationMembership struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User
+
+		// This field is assigned by the request handler from the URL
+		ConversationId string
+	}
+
+	// Used by PATCH /conversations/{id}
+	RenameConversation struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// This field is assigned by the request handler from the URL
+		ConversationId string `json:"-"`
+
+		// New conversation name
+		Name string `json:"name" validate:"required,max=100"`
+	}
+
+	// Used by POST /conversations/{id}/members
+	AddConversationMember struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User `json:"-"`
+
+		// This field is assigned by the request handler from the URL
+		ConversationId string `json:"-"`
+
+		// Email of the user to add
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	// Used by POST /conversations/{id}/messages and WS
+	CreateConversationMessage struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		From entity.User `json:"-"`
+
+		// Conversation the message is sent to. Assigned from the URL by the HTTP request handler
+		ConversationId string `json:"conversationId" validate:"required"`
+
+		// Message text
+		Message string `json:"message" validate:"required"`
+	}
+
+	// Used by POST /attachments
+	UploadAttachment struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		Owner entity.User `json:"-"`
+
+		// File name
+		Name string `json:"name" validate:"required,max=255"`
+
+		// MIME type, detected from the content
+		MimeType string `json:"mimeType" validate:"required,attachmentType"`
+
+		// Size in bytes
+		Size int64 `json:"size" validate:"min=1,attachmentSize"`
+
+		// File content
+		Content io.ReadSeeker `json:"-"`
+	}
+
+	// Used by GET /attachments/{id}
+	DownloadAttachment struct {
+		// This field is assigned by the request handler. It represents the current authorized user
+		User entity.User
+
+		// This field is assigned by the request handler from the URL
+		AttachmentId string `validate:"required"`
 	}
 )
@@ -4,7 +4,9 @@ import (
 	"github.com/asiragusa/wschat/validator"
 	"github.com/stretchr/testify/suite"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 type RequestsTestSuite struct {
@@ -64,6 +66,11 @@ func (suite *RequestsTestSuite) TestRegisterInvalid() {
 			Email:    "a@b.com",
 			Password: "aaaaa",
 		},
+		{
+			Email:    "a@b.com",
+			Password: "validPassword",
+			Device:   strings.Repeat("a", 101),
+		},
 	})
 }
 
@@ -85,6 +92,11 @@ func (suite *RequestsTestSuite) TestLoginInvalid() {
 		{
 			Password: "validPassword",
 		},
+		{
+			Email:    "a@b.com",
+			Password: "validPassword",
+			Device:   strings.Repeat("a", 101),
+		},
 	})
 }
 
@@ -95,6 +107,185 @@ func (suite *RequestsTestSuite) TestLoginValid() {
 	})
 }
 
+func (suite *RequestsTestSuite) TestRefreshTokenInvalid() {
+	suite.mustNotValidate([]*RefreshToken{
+		{
+		// Empty Request
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestRefreshTokenValid() {
+	suite.mustValidateOne(RefreshToken{
+		RefreshToken: "token",
+	})
+}
+
+func (suite *RequestsTestSuite) TestDeleteSessionInvalid() {
+	suite.mustNotValidate([]*DeleteSession{
+		{
+		// Empty Request
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestDeleteSessionValid() {
+	suite.mustValidateOne(DeleteSession{
+		SessionId: "sessionId",
+	})
+}
+
+func (suite *RequestsTestSuite) TestChangePasswordInvalid() {
+	suite.mustNotValidate([]*ChangePassword{
+		{
+		// Empty Request
+		},
+		{
+			CurrentPassword: "password",
+		},
+		{
+			NewPassword: "newPassword",
+		},
+		{
+			CurrentPassword: "password",
+			NewPassword:     "short",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestChangePasswordValid() {
+	suite.mustValidateOne(ChangePassword{
+		CurrentPassword: "password",
+		NewPassword:     "aaaaaa",
+	})
+}
+
+func (suite *RequestsTestSuite) TestForgotPasswordInvalid() {
+	suite.mustNotValidate([]*ForgotPassword{
+		{
+		// Empty Request
+		},
+		{
+			Email: "notAnEmail",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestForgotPasswordValid() {
+	suite.mustValidateOne(ForgotPassword{
+		Email: "a@b.com",
+	})
+}
+
+func (suite *RequestsTestSuite) TestResetPasswordInvalid() {
+	suite.mustNotValidate([]*ResetPassword{
+		{
+		// Empty Request
+		},
+		{
+			Token: "token",
+		},
+		{
+			Password: "newPassword",
+		},
+		{
+			Token:    "token",
+			Password: "short",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestResetPasswordValid() {
+	suite.mustValidateOne(ResetPassword{
+		Token:    "token",
+		Password: "aaaaaa",
+	})
+}
+
+func (suite *RequestsTestSuite) TestLoginMfaInvalid() {
+	suite.mustNotValidate([]*LoginMfa{
+		{
+		// Empty Request
+		},
+		{
+			MfaToken: "token",
+		},
+		{
+			Code: "123456",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestLoginMfaValid() {
+	suite.mustValidateOne(LoginMfa{
+		MfaToken: "token",
+		Code:     "123456",
+	})
+}
+
+func (suite *RequestsTestSuite) TestOidcLoginInvalid() {
+	suite.mustNotValidate([]*OidcLogin{
+		{
+		// Empty Request
+		},
+		{
+			State:     "state",
+			FlowToken: "flowToken",
+		},
+		{
+			Code:      "code",
+			FlowToken: "flowToken",
+		},
+		{
+			Code:  "code",
+			State: "state",
+		},
+		{
+			Code:      "code",
+			State:     "state",
+			FlowToken: "flowToken",
+			Device:    strings.Repeat("a", 101),
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestOidcLoginValid() {
+	suite.mustValidateOne(OidcLogin{
+		Code:      "code",
+		State:     "state",
+		FlowToken: "flowToken",
+		Device:    "Laptop",
+	})
+}
+
+func (suite *RequestsTestSuite) TestConfirmTotpInvalid() {
+	suite.mustNotValidate([]*ConfirmTotp{
+		{
+		// Empty Request
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestConfirmTotpValid() {
+	suite.mustValidateOne(ConfirmTotp{
+		Code: "123456",
+	})
+}
+
+func (suite *RequestsTestSuite) TestDisableTotpInvalid() {
+	suite.mustNotValidate([]*DisableTotp{
+		{
+		// Empty Request
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestDisableTotpValid() {
+	suite.mustValidateOne(DisableTotp{
+		Code: "abcd-efgh",
+	})
+}
+
 func (suite *RequestsTestSuite) TestCreateMessageInvalid() {
 	suite.mustNotValidate([]*CreateMessage{
 		{
@@ -114,4 +305,215 @@ func (suite *RequestsTestSuite) TestCreateMessageValid() {
 		To:      "a",
 		Message: "a",
 	})
+	suite.mustValidateOne(CreateMessage{
+		To:          "a",
+		Message:     "a",
+		Attachments: []string{"id"},
+	})
+}
+
+func (suite *RequestsTestSuite) TestCreateMessageInvalidAttachments() {
+	suite.mustNotValidate([]*CreateMessage{
+		{
+			To:          "a",
+			Message:     "a",
+			Attachments: []string{""},
+		},
+		{
+			To:          "a",
+			Message:     "a",
+			Attachments: make([]string, 11),
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestListMessagesInvalid() {
+	suite.mustNotValidate([]*ListMessages{
+		{
+			Limit: -1,
+		},
+		{
+			Limit: 101,
+		},
+		{
+			With: "a",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestListMessagesValid() {
+	suite.mustValidate([]*ListMessages{
+		{
+		// Empty Request
+		},
+		{
+			Limit:  100,
+			Before: "cursor",
+			With:   "a@b.com",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestSearchMessagesInvalid() {
+	suite.mustNotValidate([]*SearchMessages{
+		{
+		// Empty Request
+		},
+		{
+			Query: "a",
+			Limit: 101,
+		},
+		{
+			Query:  "a",
+			Offset: -1,
+		},
+		{
+			Query: "a",
+			With:  "a",
+		},
+		{
+			Query: strings.Repeat("a", 201),
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestSearchMessagesValid() {
+	suite.mustValidate([]*SearchMessages{
+		{
+			Query: "a",
+		},
+		{
+			Query:  "a",
+			Limit:  100,
+			Offset: 100,
+			With:   "a@b.com",
+			Since:  time.Now().Add(-time.Hour),
+			Until:  time.Now(),
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestDeleteMessageInvalid() {
+	suite.mustNotValidate([]*DeleteMessage{
+		{
+		// Empty Request
+		},
+		{
+			MessageId: "a",
+		},
+		{
+			MessageId: "a",
+			Scope:     "all",
+		},
+		{
+			Scope: "self",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestDeleteMessageValid() {
+	suite.mustValidate([]*DeleteMessage{
+		{
+			MessageId: "a",
+			Scope:     "self",
+		},
+		{
+			MessageId: "a",
+			Scope:     "everyone",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestReactionInvalid() {
+	suite.mustNotValidate([]*Reaction{
+		{
+		// Empty Request
+		},
+		{
+			Emoji: "👍",
+		},
+		{
+			MessageId: "a",
+		},
+		{
+			MessageId: "a",
+			Emoji:     "lol",
+		},
+		{
+			MessageId: "a",
+			Emoji:     "<script>",
+		},
+		{
+			MessageId: "a",
+			Emoji:     "👍👍",
+		},
+		{
+			MessageId: "a",
+			Emoji:     "👍\n",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestReactionValid() {
+	suite.mustValidate([]*Reaction{
+		{
+			MessageId: "a",
+			Emoji:     "👍",
+		},
+		{
+			MessageId: "a",
+			Emoji:     "👍🏽",
+		},
+		{
+			MessageId: "a",
+			Emoji:     "🇮🇹",
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestUploadAttachmentInvalid() {
+	suite.mustNotValidate([]*UploadAttachment{
+		{
+		// Empty Request
+		},
+		{
+			MimeType: "image/png",
+			Size:     1,
+		},
+		{
+			Name:     "file.exe",
+			MimeType: "application/octet-stream",
+			Size:     1,
+		},
+		{
+			Name:     "file.png",
+			MimeType: "image/png",
+			Size:     validator.MaxAttachmentSize + 1,
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestUploadAttachmentValid() {
+	suite.mustValidate([]*UploadAttachment{
+		{
+			Name:     "file.png",
+			MimeType: "image/png",
+			Size:     validator.MaxAttachmentSize,
+		},
+		{
+			Name:     "file.txt",
+			MimeType: "text/plain; charset=utf-8",
+			Size:     1,
+		},
+	})
+}
+
+func (suite *RequestsTestSuite) TestDownloadAttachmentInvalid() {
+	suite.mustNotValidateOne(DownloadAttachment{})
+}
+
+func (suite *RequestsTestSuite) TestDownloadAttachmentValid() {
+	suite.mustValidateOne(DownloadAttachment{
+		AttachmentId: "a",
+	})
 }
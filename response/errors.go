@@ -1,14 +1,21 @@
 package response
 
-import "github.com/kataras/iris"
+import (
+	"github.com/kataras/iris"
+	"math"
+	"time"
+)
 
 var messages = map[int]string{
-	iris.StatusBadRequest:          "Bad Request",
-	iris.StatusUnauthorized:        "Unauthorized",
-	iris.StatusForbidden:           "Forbidden",
-	iris.StatusNotFound:            "Not Found",
-	iris.StatusUnprocessableEntity: "Unprocessable Entity",
-	iris.StatusInternalServerError: "Internal Server Error",
+	iris.StatusBadRequest:            "Bad Request",
+	iris.StatusUnauthorized:          "Unauthorized",
+	iris.StatusForbidden:             "Forbidden",
+	iris.StatusNotFound:              "Not Found",
+	iris.StatusConflict:              "Conflict",
+	iris.StatusRequestEntityTooLarge: "Request Entity Too Large",
+	iris.StatusUnprocessableEntity:   "Unprocessable Entity",
+	iris.StatusTooManyRequests:       "Too Many Requests",
+	iris.StatusInternalServerError:   "Internal Server Error",
 }
 
 // Error response type
@@ -16,6 +23,9 @@ type Error struct {
 	Code    int                 `json:"code"`
 	Message string              `json:"message"`
 	Details map[string][]string `json:"details,omitempty"`
+
+	// Seconds to wait before retrying the request, sent in the Retry-After header too. Zero if not set
+	RetryAfter int `json:"retryAfter,omitempty"`
 }
 
 // Returns the error code
@@ -34,5 +44,13 @@ func (e *Error) AddDetail(field, detail string) {
 // Creates a new Error Response with code `code`
 func NewError(code int) *Error {
 	// If code is not found, message is an empty string, which is acceptable
-	return &Error{code, messages[code], make(map[string][]string)}
+	return &Error{code, messages[code], make(map[string][]string), 0}
+}
+
+// Creates a new 429 Error Response, telling the client to wait retryAfter before retrying
+func NewTooManyRequestsError(retryAfter time.Duration) *Error {
+	e := NewError(iris.StatusTooManyRequests)
+	// Rounded up, so that the client doesn't retry too early
+	e.RetryAfter = int(math.Ceil(retryAfter.Seconds()))
+	return e
 }
@@ -3,6 +3,7 @@ package response
 
 import (
 	"github.com/kataras/iris"
+	"io"
 	"time"
 )
 
@@ -26,6 +27,10 @@ type (
 		CreatedResponse
 		// Access token
 		AccessToken string `json:"accessToken"`
+		// Refresh token, exchanged at POST /token/refresh for a new access token
+		RefreshToken string `json:"refreshToken"`
+		// Validity of the access token, in seconds
+		ExpiresIn int `json:"expiresIn"`
 	}
 
 	// Used by POST /login endpoint
@@ -35,15 +40,244 @@ type (
 
 		// Access token
 		AccessToken string `json:"accessToken"`
+
+		// Refresh token, exchanged at POST /token/refresh for a new access token
+		RefreshToken string `json:"refreshToken"`
+
+		// Validity of the access token, in seconds
+		ExpiresIn int `json:"expiresIn"`
+
+		// Email of the user, only returned by POST /oidc/login as the client doesn't know it
+		Email string `json:"email,omitempty"`
+	}
+
+	// Used by POST /login endpoint, when the user has enabled the two factor authentication
+	MfaRequired struct {
+		// Returns 200
+		OKResponse
+
+		// Always true, tells the client to ask for the second factor
+		MfaRequired bool `json:"mfaRequired"`
+
+		// Token exchanged at POST /login/mfa, with the second factor, for the access token
+		MfaToken string `json:"mfaToken"`
+
+		// Validity of the mfa token, in seconds
+		ExpiresIn int `json:"expiresIn"`
+
+		// Email of the user, only returned by POST /oidc/login as the client doesn't know it
+		Email string `json:"email,omitempty"`
+	}
+
+	// Used by POST /oidc/authorize endpoint
+	OidcAuthorize struct {
+		// Returns 200
+		OKResponse
+
+		// URL of the OpenID Connect provider the user is redirected to
+		AuthorizationUrl string `json:"authorizationUrl"`
+
+		// Token sent back to POST /oidc/login with the code and the state received by the redirect URL
+		FlowToken string `json:"flowToken"`
+
+		// Validity of the flow token, in seconds
+		ExpiresIn int `json:"expiresIn"`
+	}
+
+	// Used by POST /mfa/totp endpoint
+	EnrollTotp struct {
+		// Returns 200
+		OKResponse
+
+		// Base32 TOTP secret, for the authenticator apps that can't scan the URI
+		Secret string `json:"secret"`
+
+		// otpauth:// provisioning URI, usually shown as a QR code
+		Uri string `json:"uri"`
+	}
+
+	// Used by POST /mfa/totp/confirm endpoint
+	ConfirmTotp struct {
+		// Returns 200
+		OKResponse
+
+		// One time recovery codes, returned only once
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+
+	// Pushed with the signedOut event to the websockets of an user signed out everywhere, before closing them
+	SignedOut struct {
+		// Email of the user
+		Email string `json:"email"`
+	}
+
+	// Used by POST /token/refresh endpoint
+	RefreshToken struct {
+		// Returns 200
+		OKResponse
+
+		// Access token
+		AccessToken string `json:"accessToken"`
+
+		// New refresh token, the one exchanged can't be used anymore
+		RefreshToken string `json:"refreshToken"`
+
+		// Validity of the access token, in seconds
+		ExpiresIn int `json:"expiresIn"`
 	}
 
 	// Contains a message
 	Message struct {
-		Id        string    `json:"id"`
-		From      string    `json:"from"`
-		To        string    `json:"to"`
-		Message   string    `json:"message"`
-		CreatedAt time.Time `json:"createdAt"`
+		Id             string       `json:"id"`
+		ConversationId string       `json:"conversationId,omitempty"`
+		From           string       `json:"from"`
+		To             string       `json:"to"`
+		Message        string       `json:"message"`
+		CreatedAt      time.Time    `json:"createdAt"`
+		ReplyTo        *Quote       `json:"replyTo,omitempty"`
+		EditedAt       *time.Time   `json:"editedAt,omitempty"`
+		DeletedAt      *time.Time   `json:"deletedAt,omitempty"`
+		Reads          []Receipt    `json:"reads,omitempty"`
+		Reactions      []Reaction   `json:"reactions,omitempty"`
+		Attachments    []Attachment `json:"attachments,omitempty"`
+	}
+
+	// Contains an attachment
+	Attachment struct {
+		// Attachment ID
+		Id string `json:"id"`
+
+		// File name
+		Name string `json:"name"`
+
+		// MIME type
+		MimeType string `json:"mimeType"`
+
+		// Size in bytes
+		Size int64 `json:"size"`
+
+		// SHA-256 checksum of the content, hex encoded
+		Checksum string `json:"checksum"`
+
+		// Download URL, requires the access token
+		Url string `json:"url"`
+	}
+
+	// Used by POST /attachments
+	UploadAttachment struct {
+		// Returns 201
+		CreatedResponse
+
+		// The uploaded attachment
+		Attachment
+	}
+
+	// Used by GET /attachments/{id}. The content is streamed by the request handler, who has to close it
+	DownloadAttachment struct {
+		// Returns 200
+		OKResponse
+
+		// The downloaded attachment
+		Attachment
+
+		// File content
+		Content io.ReadCloser `json:"-"`
+	}
+
+	// Message replied to
+	Quote struct {
+		// Replied message ID
+		MessageId string `json:"messageId"`
+
+		// Sender of the replied message
+		From string `json:"from"`
+
+		// Beginning of the replied message text
+		Snippet string `json:"snippet"`
+	}
+
+	// Users having reacted to a message with the same emoji
+	Reaction struct {
+		// Emoji
+		Emoji string `json:"emoji"`
+
+		// Number of users
+		Count int `json:"count"`
+
+		// Users having reacted, from the first
+		Users []string `json:"users"`
+	}
+
+	// Read receipt of a message
+	Receipt struct {
+		// User having read the message
+		User string `json:"user"`
+
+		// Read at
+		ReadAt time.Time `json:"readAt"`
+	}
+
+	// Used by POST /messages/{id}/read, WS and the read event
+	ReadMessage struct {
+		// Returns 200
+		OKResponse
+
+		// Message ID
+		MessageId string `json:"messageId"`
+
+		// The read receipt
+		Receipt
+	}
+
+	// Used by PATCH /messages/{id}, WS and the edited event
+	EditMessage struct {
+		// Returns 200
+		OKResponse
+
+		// The edited message
+		Message
+	}
+
+	// Used by DELETE /messages/{id}, WS and the deleted event
+	DeleteMessage struct {
+		// Returns 200
+		OKResponse
+
+		// Message ID
+		MessageId string `json:"messageId"`
+
+		// Either self or everyone
+		Scope string `json:"scope"`
+
+		// Deletion time, only set when the message has been deleted for everyone
+		DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	}
+
+	// Used by POST and DELETE /messages/{id}/reactions, WS and the reaction event
+	Reactions struct {
+		// Returns 200
+		OKResponse
+
+		// Message ID
+		MessageId string `json:"messageId"`
+
+		// All the reactions to the message
+		Reactions []Reaction `json:"reactions"`
+	}
+
+	// Used by WS and the typing event
+	Typing struct {
+		// Returns 200
+		OKResponse
+
+		// User typing
+		From string `json:"from"`
+
+		// Recipient of the indicator
+		To string `json:"to"`
+
+		// The indicator has to be hidden after this time, unless a new one is received
+		ExpiresAt time.Time `json:"expiresAt"`
 	}
 
 	// Used by GET /messages endpoint
@@ -56,6 +290,36 @@ type (
 
 		// Array of found messages
 		Items []Message `json:"items"`
+
+		// Cursor to fetch the older messages, passed as before. Empty if there are none
+		NextCursor string `json:"nextCursor,omitempty"`
+
+		// Cursor to fetch the newer messages, passed as after. Empty if there are none
+		PrevCursor string `json:"prevCursor,omitempty"`
+	}
+
+	// Message matching a search
+	SearchResult struct {
+		// The message found
+		Message
+
+		// Text of the message, HTML escaped, with the matched words wrapped in <mark> tags
+		Highlight string `json:"highlight"`
+
+		// Relevance of the message, the higher the better
+		Score int `json:"score"`
+	}
+
+	// Used by GET /messages/search endpoint
+	SearchMessages struct {
+		// Returns 200
+		OKResponse
+
+		// Total items
+		Total int `json:"total"`
+
+		// Array of found messages, the most relevant first
+		Items []SearchResult `json:"items"`
 	}
 
 	// Used by POST /messages endpoint and WS
@@ -66,6 +330,9 @@ type (
 		// Message ID
 		Id string `json:"id"`
 
+		// Conversation ID, empty for direct messages
+		ConversationId string `json:"conversationId,omitempty"`
+
 		// Message from email
 		From string `json:"from"`
 
@@ -77,12 +344,27 @@ type (
 
 		// Created At
 		CreatedAt time.Time `json:"createdAt"`
+
+		// Message replied to, only set for the replies
+		ReplyTo *Quote `json:"replyTo,omitempty"`
+
+		// Attached files
+		Attachments []Attachment `json:"attachments,omitempty"`
+
+		// Sequence number of the delivery, only set for the received messages
+		Seq int64 `json:"seq,omitempty"`
 	}
 
 	// Used by GET /users endpoint
 	User struct {
 		// User email
 		Email string `json:"email"`
+
+		// Presence status: online, away or offline
+		Status string `json:"status"`
+
+		// Last time the user has been seen connected and active. Omitted if the user has never connected
+		LastSeenAt *time.Time `json:"lastSeenAt,omitempty"`
 	}
 
 	// Used by GET /users endpoint
@@ -96,6 +378,39 @@ type (
 		Items []User `json:"items"`
 	}
 
+	// Used by GET /.well-known/jwks.json endpoint
+	Jwks struct {
+		// Returns 200
+		OKResponse
+
+		// Public keys validating the tokens, the one signing the new tokens first
+		Keys []Jwk `json:"keys"`
+	}
+
+	// JSON Web Key, as defined by RFC 7517
+	Jwk struct {
+		// Key ID, matching the kid header of the tokens
+		Kid string `json:"kid"`
+
+		// Key type, RSA or EC
+		Kty string `json:"kty"`
+
+		// Always sig
+		Use string `json:"use"`
+
+		// Signing algorithm eg. RS256
+		Alg string `json:"alg"`
+
+		// Curve and coordinates of the EC keys
+		Crv string `json:"crv,omitempty"`
+		X   string `json:"x,omitempty"`
+		Y   string `json:"y,omitempty"`
+
+		// Modulus and exponent of the RSA keys
+		N string `json:"n,omitempty"`
+		E string `json:"e,omitempty"`
+	}
+
 	// Used by POST /wsToken
 	CreateWsToken struct {
 		// Returns 201
@@ -104,6 +419,90 @@ type (
 		// WS token
 		Token string `json:"token"`
 	}
+
+	// Contains a conversation
+	Conversation struct {
+		// Conversation ID
+		Id string `json:"id"`
+
+		// Conversation name
+		Name string `json:"name"`
+
+		// Member emails
+		Members []string `json:"members"`
+
+		// Creator email
+		CreatedBy string `json:"createdBy"`
+
+		// Created At
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	// Used by POST /conversations
+	CreateConversation struct {
+		// Returns 201
+		CreatedResponse
+
+		// The created conversation
+		Conversation
+	}
+
+	// Used by PATCH /conversations/{id} and POST /conversations/{id}/members
+	UpdateConversation struct {
+		// Returns 200
+		OKResponse
+
+		// The updated conversation
+		Conversation
+	}
+
+	// Used by GET /conversations
+	ListConversations struct {
+		// Returns 200
+		OKResponse
+
+		// Total items
+		Total int `json:"total"`
+
+		// Conversation list
+		Items []Conversation `json:"items"`
+	}
+
+	// Used by GET /sessions
+	Session struct {
+		// Session Id
+		Id string `json:"id"`
+
+		// Device name sent at login, if any
+		Device string `json:"device,omitempty"`
+
+		// IP address the session was opened from
+		Ip string `json:"ip,omitempty"`
+
+		// User agent the session was opened with
+		UserAgent string `json:"userAgent,omitempty"`
+
+		// Login time
+		CreatedAt time.Time `json:"createdAt"`
+
+		// Last time a token of the session has been used
+		LastUsedAt time.Time `json:"lastUsedAt"`
+
+		// True for the session the request has been made with
+		Current bool `json:"current"`
+	}
+
+	// Used by GET /sessions
+	ListSessions struct {
+		// Returns 200
+		OKResponse
+
+		// Total items
+		Total int `json:"total"`
+
+		// Session list
+		Items []Session `json:"items"`
+	}
 )
 
 // Return 200
@@ -120,3 +519,8 @@ func (r CreatedResponse) GetCode() int {
 func (r NoContentResponse) GetCode() int {
 	return iris.StatusNoContent
 }
+
+// Returns the download URL of an attachment
+func AttachmentUrl(id string) string {
+	return "/attachments/" + id
+}
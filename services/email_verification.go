@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/jonboulle/clockwork"
+	"net/url"
+	"time"
+)
+
+const (
+	// Validity of the email verification links
+	EmailVerificationDuration = 24 * time.Hour
+
+	// Audience of the email verification tokens
+	EmailVerificationAudience = "verify"
+)
+
+// Error thrown when the email verification token is invalid, expired or issued for another email
+var InvalidEmailVerificationTokenError = errors.New("Invalid email verification token")
+
+// Interface used mainly for Unit testing
+type EmailVerifier interface {
+	Link(entity.User) (string, error)
+	Verify(string) (*entity.User, error)
+}
+
+// Claims of the email verification tokens. The email binds the token to the address it has been sent to
+type emailVerificationClaims struct {
+	Email string `json:"email"`
+	jwt.StandardClaims
+}
+
+// Issues and validates the signed email verification links.
+//
+// The links are stateless: the token is a JWT, signed like the access tokens, that points to the home page
+type EmailVerification struct {
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	keys   *KeySet
+	issuer string
+}
+
+// Creates a new EmailVerification
+// keys are the keys used to sign and validate the tokens
+// issuer is the base URL of the links eg. http://localhost
+func NewEmailVerifier(keys *KeySet, issuer string) *EmailVerification {
+	return &EmailVerification{
+		keys:   keys,
+		issuer: issuer,
+	}
+}
+
+// Returns the verification link of the email of the user, valid for EmailVerificationDuration
+func (v EmailVerification) Link(user entity.User) (string, error) {
+	claims := &emailVerificationClaims{
+		Email: user.Email,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   user.Id,
+			Audience:  EmailVerificationAudience,
+			ExpiresAt: v.Clock.Now().Add(EmailVerificationDuration).Unix(),
+			Issuer:    v.issuer,
+		},
+	}
+
+	token, err := v.keys.Sign(claims)
+	if err != nil {
+		return "", err
+	}
+
+	return v.issuer + "/?verify=" + url.QueryEscape(token), nil
+}
+
+// Validates the token of a verification link. Returns the user the link has been sent to
+func (v EmailVerification) Verify(signed string) (*entity.User, error) {
+	token, err := v.keys.Parse(signed, &emailVerificationClaims{})
+	if err != nil {
+		return nil, InvalidEmailVerificationTokenError
+	}
+
+	claims, ok := token.Claims.(*emailVerificationClaims)
+	if !ok || claims.Audience != EmailVerificationAudience || claims.Issuer != v.issuer {
+		return nil, InvalidEmailVerificationTokenError
+	}
+
+	user, err := v.UserRepository.GetUserById(claims.Subject)
+	if err == repository.UserNotFoundError {
+		return nil, InvalidEmailVerificationTokenError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Email != claims.Email {
+		return nil, InvalidEmailVerificationTokenError
+	}
+
+	return user, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type EmailVerificationTestSuite struct {
+	suite.Suite
+	verifier       *EmailVerification
+	userRepository *mocks.UserRepository
+	user           entity.User
+}
+
+func TestEmailVerification(t *testing.T) {
+	suite.Run(t, new(EmailVerificationTestSuite))
+}
+
+func (suite *EmailVerificationTestSuite) SetupTest() {
+	suite.userRepository = &mocks.UserRepository{}
+	suite.user = entity.User{Id: "userId", Email: "a@b.com"}
+
+	keys, err := NewKeySet(NewHmacKey("secret"))
+	suite.Require().NoError(err)
+
+	suite.verifier = NewEmailVerifier(keys, "http://localhost")
+	// The expiration of the tokens is checked against the real time
+	suite.verifier.Clock = clockwork.NewFakeClockAt(time.Now())
+	suite.verifier.UserRepository = suite.userRepository
+}
+
+func (suite *EmailVerificationTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+}
+
+// Returns the token of the link sent to the user
+func (suite *EmailVerificationTestSuite) token() string {
+	link, err := suite.verifier.Link(suite.user)
+	suite.Require().NoError(err)
+	suite.Require().True(strings.HasPrefix(link, "http://localhost/?verify="))
+
+	parsed, err := url.Parse(link)
+	suite.Require().NoError(err)
+
+	return parsed.Query().Get("verify")
+}
+
+func (suite *EmailVerificationTestSuite) TestVerifyOK() {
+	token := suite.token()
+	suite.userRepository.On("GetUserById", "userId").Return(&suite.user, nil)
+
+	user, err := suite.verifier.Verify(token)
+	suite.NoError(err)
+	suite.Equal(&suite.user, user)
+}
+
+func (suite *EmailVerificationTestSuite) TestVerifyInvalid() {
+	user, err := suite.verifier.Verify("invalid")
+	suite.Nil(user)
+	suite.Equal(InvalidEmailVerificationTokenError, err)
+}
+
+func (suite *EmailVerificationTestSuite) TestVerifyExpired() {
+	suite.verifier.Clock = clockwork.NewFakeClockAt(time.Now().Add(-EmailVerificationDuration - time.Minute))
+	token := suite.token()
+
+	user, err := suite.verifier.Verify(token)
+	suite.Nil(user)
+	suite.Equal(InvalidEmailVerificationTokenError, err)
+}
+
+func (suite *EmailVerificationTestSuite) TestVerifyOtherAudience() {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{
+		Subject:  "userId",
+		Audience: "access",
+		Issuer:   "http://localhost",
+	})
+	signed, err := token.SignedString([]byte("secret"))
+	suite.Require().NoError(err)
+
+	user, err := suite.verifier.Verify(signed)
+	suite.Nil(user)
+	suite.Equal(InvalidEmailVerificationTokenError, err)
+}
+
+func (suite *EmailVerificationTestSuite) TestVerifyUserNotFound() {
+	token := suite.token()
+	suite.userRepository.On("GetUserById", "userId").Return(nil, repository.UserNotFoundError)
+
+	user, err := suite.verifier.Verify(token)
+	suite.Nil(user)
+	suite.Equal(InvalidEmailVerificationTokenError, err)
+}
+
+func (suite *EmailVerificationTestSuite) TestVerifyOtherEmail() {
+	token := suite.token()
+	suite.userRepository.On("GetUserById", "userId").Return(&entity.User{Id: "userId", Email: "c@d.com"}, nil)
+
+	user, err := suite.verifier.Verify(token)
+	suite.Nil(user)
+	suite.Equal(InvalidEmailVerificationTokenError, err)
+}
+
+func (suite *EmailVerificationTestSuite) TestVerifyAnyError() {
+	token := suite.token()
+	suite.userRepository.On("GetUserById", "userId").Return(nil, assert.AnError)
+
+	user, err := suite.verifier.Verify(token)
+	suite.Nil(user)
+	suite.Equal(assert.AnError, err)
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"io/ioutil"
+	"math"
+	"math/big"
+)
+
+// Minimum size of the RSA keys, in bits
+const MinRsaKeySize = 2048
+
+// Key used to sign or validate the JWT tokens
+type Key struct {
+	// Key ID, sent in the kid header of the tokens. Empty for the HMAC secret
+	Id string
+
+	// Signing method of the tokens, eg. RS256
+	Method jwt.SigningMethod
+
+	// []byte for HMAC, *rsa.PrivateKey or *ecdsa.PrivateKey. Nil if the key can only validate the tokens
+	private interface{}
+
+	// []byte for HMAC, *rsa.PublicKey or *ecdsa.PublicKey
+	public interface{}
+}
+
+// Public members of a RSA or EC key, as defined by RFC 7518
+type Jwk struct {
+	// Key type, RSA or EC
+	Kty string
+
+	// Curve of the EC keys
+	Crv string
+
+	// Coordinates of the EC keys
+	X string
+	Y string
+
+	// Modulus and exponent of the RSA keys
+	N string
+	E string
+}
+
+// Creates the HS256 key of a shared secret. Its tokens have no kid, and it's never published
+func NewHmacKey(secret string) *Key {
+	return &Key{
+		Method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+}
+
+// Parses a PEM encoded RSA or EC key. Private keys can sign the tokens, public keys can only validate them.
+// RSA keys sign with RS256, EC keys with ES256, ES384 or ES512 depending on the curve
+func ParseKey(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("No PEM data found")
+	}
+
+	var private, public interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("Unsupported PEM block %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		public = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		public = &k.PublicKey
+	}
+
+	key := &Key{
+		private: private,
+		public:  public,
+	}
+
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < MinRsaKeySize {
+			return nil, fmt.Errorf("The RSA keys must be at least %d bits long", MinRsaKeySize)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		switch k.Curve.Params().Name {
+		case "P-256":
+			key.Method = jwt.SigningMethodES256
+		case "P-384":
+			key.Method = jwt.SigningMethodES384
+		case "P-521":
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, errors.New("Unsupported elliptic curve")
+		}
+	default:
+		return nil, errors.New("Unsupported key type")
+	}
+
+	// The kid is the thumbprint of the key, so that it doesn't change when the key is reloaded
+	key.Id, err = thumbprint(key.Jwk())
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Parses the public RSA or EC key published as a JWK, eg. by an OpenID Connect provider. The key can only validate
+// the tokens
+func ParseJwk(jwk Jwk) (*Key, error) {
+	var public interface{}
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeJwkInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJwkInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > math.MaxInt32 {
+			return nil, errors.New("Invalid RSA exponent")
+		}
+		public = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("Unsupported elliptic curve")
+		}
+		x, err := decodeJwkInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJwkInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("Invalid EC point")
+		}
+		public = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	default:
+		return nil, errors.New("Unsupported key type")
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// Reads and parses the PEM file at path, see ParseKey
+func LoadKeyFile(path string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ParseKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+	return key, nil
+}
+
+// Returns true if the key can sign the tokens
+func (k Key) CanSign() bool {
+	return k.private != nil
+}
+
+// Returns true if the key can be published, ie. it's not a shared secret
+func (k Key) IsPublic() bool {
+	_, ok := k.public.([]byte)
+	return !ok
+}
+
+// Returns the public members of the key. Empty for the HMAC keys
+func (k Key) Jwk() Jwk {
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		return Jwk{
+			Kty: "RSA",
+			N:   encodeJwkInt(public.N.Bytes()),
+			E:   encodeJwkInt(big.NewInt(int64(public.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		// The coordinates are padded to the size of the curve
+		size := (public.Curve.Params().BitSize + 7) / 8
+		return Jwk{
+			Kty: "EC",
+			Crv: public.Curve.Params().Name,
+			X:   encodeJwkInt(padLeft(public.X.Bytes(), size)),
+			Y:   encodeJwkInt(padLeft(public.Y.Bytes(), size)),
+		}
+	}
+
+	return Jwk{}
+}
+
+// Base64url encoding without padding, used by the JWKs
+func encodeJwkInt(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decodes a base64url encoded integer of a JWK
+func decodeJwkInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("Invalid JWK member")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Pads b with leading zeros up to size bytes
+func padLeft(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+// Computes the RFC 7638 thumbprint of the key
+func thumbprint(jwk Jwk) (string, error) {
+	var members string
+	switch jwk.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, jwk.Crv, jwk.X, jwk.Y)
+	default:
+		return "", errors.New("Unsupported key type")
+	}
+
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// Keys of the JWT tokens. The signing key signs the new tokens, while all the keys validate them.
+//
+// To rotate the keys, add a new signing key and keep the previous one in the validating keys until the tokens it
+// signed have expired
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	ids     []string
+}
+
+// Creates the key set. The signing key must be a private key, the validating ones can be public keys
+func NewKeySet(signing *Key, validating ...*Key) (*KeySet, error) {
+	if signing == nil || !signing.CanSign() {
+		return nil, errors.New("The signing key must be a private key")
+	}
+
+	s := &KeySet{
+		signing: signing,
+		keys:    map[string]*Key{},
+	}
+	for _, key := range append([]*Key{signing}, validating...) {
+		if _, ok := s.keys[key.Id]; ok {
+			return nil, fmt.Errorf("Duplicate key %s", key.Id)
+		}
+		s.keys[key.Id] = key
+		s.ids = append(s.ids, key.Id)
+	}
+
+	return s, nil
+}
+
+// Signs a new token with the signing key
+func (s KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	if s.signing.Id != "" {
+		token.Header["kid"] = s.signing.Id
+	}
+
+	return token.SignedString(s.signing.private)
+}
+
+// Parses and validates the token with the key identified by its kid into claims
+func (s KeySet) Parse(signed string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("Unknown key %s", kid)
+		}
+
+		// The algorithm is the one of the key, never the one chosen by the token
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("Unexpected signing method %s", token.Method.Alg())
+		}
+
+		return key.public, nil
+	})
+}
+
+// Returns the keys that can be published, in order: the signing key first
+func (s KeySet) PublicKeys() []Key {
+	keys := []Key{}
+	for _, id := range s.ids {
+		if key := s.keys[id]; key.IsPublic() {
+			keys = append(keys, *key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+type KeysTestSuite struct {
+	suite.Suite
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func TestKeys(t *testing.T) {
+	suite.Run(t, new(KeysTestSuite))
+}
+
+func (suite *KeysTestSuite) SetupSuite() {
+	var err error
+	suite.rsaKey, err = rsa.GenerateKey(rand.Reader, MinRsaKeySize)
+	suite.Require().NoError(err)
+
+	suite.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().NoError(err)
+}
+
+// PEM encodes the DER bytes in a block of type typ
+func (suite *KeysTestSuite) encode(typ string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+// Returns the PEM of the public key
+func (suite *KeysTestSuite) publicPem(public interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	suite.Require().NoError(err)
+	return suite.encode("PUBLIC KEY", der)
+}
+
+func (suite *KeysTestSuite) parse(data []byte) *Key {
+	key, err := ParseKey(data)
+	suite.Require().NoError(err)
+	return key
+}
+
+// Returns valid claims, expiring in one minute
+func (suite *KeysTestSuite) claims() *jwt.StandardClaims {
+	return &jwt.StandardClaims{
+		Subject:   "userId",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func (suite *KeysTestSuite) TestParseRsa() {
+	pkcs1 := suite.parse(suite.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(suite.rsaKey)))
+	suite.Equal(jwt.SigningMethodRS256, pkcs1.Method)
+	suite.True(pkcs1.CanSign())
+	suite.True(pkcs1.IsPublic())
+	suite.NotEmpty(pkcs1.Id)
+
+	der, err := x509.MarshalPKCS8PrivateKey(suite.rsaKey)
+	suite.Require().NoError(err)
+	pkcs8 := suite.parse(suite.encode("PRIVATE KEY", der))
+
+	public := suite.parse(suite.publicPem(&suite.rsaKey.PublicKey))
+	suite.False(public.CanSign())
+
+	// The kid only depends on the public key
+	suite.Equal(pkcs1.Id, pkcs8.Id)
+	suite.Equal(pkcs1.Id, public.Id)
+}
+
+func (suite *KeysTestSuite) TestParseEc() {
+	der, err := x509.MarshalECPrivateKey(suite.ecKey)
+	suite.Require().NoError(err)
+	private := suite.parse(suite.encode("EC PRIVATE KEY", der))
+	suite.Equal(jwt.SigningMethodES256, private.Method)
+	suite.True(private.CanSign())
+
+	public := suite.parse(suite.publicPem(&suite.ecKey.PublicKey))
+	suite.False(public.CanSign())
+	suite.Equal(private.Id, public.Id)
+
+	jwk := private.Jwk()
+	suite.Equal("EC", jwk.Kty)
+	suite.Equal("P-256", jwk.Crv)
+	// 32 bytes coordinates, base64url encoded without padding
+	suite.Len(jwk.X, 43)
+	suite.Len(jwk.Y, 43)
+}
+
+func (suite *KeysTestSuite) TestParseErrors() {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	suite.Require().NoError(err)
+
+	for _, data := range [][]byte{
+		[]byte("not a pem"),
+		suite.encode("CERTIFICATE", []byte("certificate")),
+		suite.encode("RSA PRIVATE KEY", []byte("invalid")),
+		suite.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(small)),
+	} {
+		key, err := ParseKey(data)
+		suite.Nil(key)
+		suite.Error(err)
+	}
+}
+
+// Thumbprint example of RFC 7638, section 3.1
+func (suite *KeysTestSuite) TestThumbprint() {
+	id, err := thumbprint(Jwk{
+		Kty: "RSA",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknj" +
+			"hMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6" +
+			"qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8a" +
+			"wapJzKnqDKgw",
+		E: "AQAB",
+	})
+	suite.NoError(err)
+	suite.Equal("NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", id)
+}
+
+func (suite *KeysTestSuite) TestLoadKeyFile() {
+	file, err := ioutil.TempFile("", "key")
+	suite.Require().NoError(err)
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(suite.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(suite.rsaKey)))
+	suite.Require().NoError(err)
+	file.Close()
+
+	key, err := LoadKeyFile(file.Name())
+	suite.Require().NoError(err)
+	suite.Equal(jwt.SigningMethodRS256, key.Method)
+
+	_, err = LoadKeyFile(file.Name() + ".notExisting")
+	suite.Error(err)
+}
+
+func (suite *KeysTestSuite) TestNewKeySetErrors() {
+	public := suite.parse(suite.publicPem(&suite.rsaKey.PublicKey))
+	_, err := NewKeySet(public)
+	suite.Error(err)
+
+	private := suite.parse(suite.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(suite.rsaKey)))
+	_, err = NewKeySet(private, public)
+	suite.Error(err)
+}
+
+func (suite *KeysTestSuite) TestSignAndParse() {
+	rsaKey := suite.parse(suite.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(suite.rsaKey)))
+	keys, err := NewKeySet(rsaKey)
+	suite.Require().NoError(err)
+
+	signed, err := keys.Sign(suite.claims())
+	suite.Require().NoError(err)
+
+	token, err := keys.Parse(signed, &jwt.StandardClaims{})
+	suite.Require().NoError(err)
+	suite.Equal(rsaKey.Id, token.Header["kid"])
+	suite.Equal("RS256", token.Header["alg"])
+	suite.Equal("userId", token.Claims.(*jwt.StandardClaims).Subject)
+}
+
+func (suite *KeysTestSuite) TestRotation() {
+	der, err := x509.MarshalECPrivateKey(suite.ecKey)
+	suite.Require().NoError(err)
+	oldKey := suite.parse(suite.encode("EC PRIVATE KEY", der))
+	newKey := suite.parse(suite.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(suite.rsaKey)))
+
+	oldKeys, err := NewKeySet(oldKey)
+	suite.Require().NoError(err)
+	signed, err := oldKeys.Sign(suite.claims())
+	suite.Require().NoError(err)
+
+	// The old key keeps validating its tokens, even with only its public part
+	oldPublic := suite.parse(suite.publicPem(&suite.ecKey.PublicKey))
+	keys, err := NewKeySet(newKey, oldPublic)
+	suite.Require().NoError(err)
+	_, err = keys.Parse(signed, &jwt.StandardClaims{})
+	suite.NoError(err)
+
+	// Until it's removed
+	keys, err = NewKeySet(newKey)
+	suite.Require().NoError(err)
+	_, err = keys.Parse(signed, &jwt.StandardClaims{})
+	suite.Error(err)
+}
+
+func (suite *KeysTestSuite) TestParseHmac() {
+	rsaKey := suite.parse(suite.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(suite.rsaKey)))
+	keys, err := NewKeySet(rsaKey)
+	suite.Require().NoError(err)
+
+	// A token signed with a shared secret, without kid, isn't accepted without the HMAC key
+	hmacKeys, err := NewKeySet(NewHmacKey("secret"))
+	suite.Require().NoError(err)
+	signed, err := hmacKeys.Sign(suite.claims())
+	suite.Require().NoError(err)
+
+	_, err = keys.Parse(signed, &jwt.StandardClaims{})
+	suite.Error(err)
+
+	_, err = hmacKeys.Parse(signed, &jwt.StandardClaims{})
+	suite.NoError(err)
+}
+
+func (suite *KeysTestSuite) TestParseOtherAlgorithm() {
+	rsaKey := suite.parse(suite.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(suite.rsaKey)))
+	keys, err := NewKeySet(rsaKey)
+	suite.Require().NoError(err)
+
+	// HS256 signed with the public key as secret, with the kid of the RSA key
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, suite.claims())
+	token.Header["kid"] = rsaKey.Id
+	signed, err := token.SignedString(x509.MarshalPKCS1PublicKey(&suite.rsaKey.PublicKey))
+	suite.Require().NoError(err)
+
+	_, err = keys.Parse(signed, &jwt.StandardClaims{})
+	suite.Error(err)
+}
+
+func (suite *KeysTestSuite) TestPublicKeys() {
+	rsaKey := suite.parse(suite.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(suite.rsaKey)))
+	ecKey := suite.parse(suite.publicPem(&suite.ecKey.PublicKey))
+	hmacKey := NewHmacKey("secret")
+
+	keys, err := NewKeySet(rsaKey, hmacKey, ecKey)
+	suite.Require().NoError(err)
+
+	// The shared secret is never published
+	public := keys.PublicKeys()
+	suite.Require().Len(public, 2)
+	suite.Equal(rsaKey.Id, public[0].Id)
+	suite.Equal(ecKey.Id, public[1].Id)
+	suite.Equal("RSA", public[0].Jwk().Kty)
+	suite.Equal("AQAB", public[0].Jwk().E)
+}
+
+func (suite *KeysTestSuite) TestParseJwk() {
+	rsaKey := suite.parse(suite.publicPem(&suite.rsaKey.PublicKey))
+	parsed, err := ParseJwk(rsaKey.Jwk())
+	suite.Require().NoError(err)
+	suite.Equal(rsaKey.Id, parsed.Id)
+	suite.Equal(jwt.SigningMethodRS256, parsed.Method)
+	suite.False(parsed.CanSign())
+
+	ecKey := suite.parse(suite.publicPem(&suite.ecKey.PublicKey))
+	parsed, err = ParseJwk(ecKey.Jwk())
+	suite.Require().NoError(err)
+	suite.Equal(ecKey.Id, parsed.Id)
+	suite.Equal(jwt.SigningMethodES256, parsed.Method)
+
+	// A point that isn't on the curve
+	jwk := ecKey.Jwk()
+	jwk.X, jwk.Y = jwk.Y, jwk.X
+
+	for _, jwk := range []Jwk{
+		jwk,
+		{Kty: "oct"},
+		{Kty: "RSA", N: "invalid!", E: "AQAB"},
+		{Kty: "RSA", N: rsaKey.Jwk().N},
+		{Kty: "EC", Crv: "P-192", X: ecKey.Jwk().X, Y: ecKey.Jwk().Y},
+	} {
+		key, err := ParseJwk(jwk)
+		suite.Nil(key)
+		suite.Error(err)
+	}
+}
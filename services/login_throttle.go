@@ -0,0 +1,133 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"strings"
+	"time"
+)
+
+const (
+	// Failed logins of an email allowed before the backoff starts
+	LoginFreeAttemptsPerEmail = 5
+
+	// Failed logins from a client IP allowed before the backoff starts, higher as an IP can be shared by many users
+	LoginFreeAttemptsPerIp = 20
+
+	// Wait imposed after the first failure past the free ones, doubled at every further failure
+	LoginBackoffBase = time.Second
+
+	// Maximum wait between two login attempts
+	LoginLockoutDuration = 15 * time.Minute
+
+	// The failures are forgotten when no login fails for this long
+	LoginAttemptsWindow = 24 * time.Hour
+)
+
+// Interface used mainly for Unit testing
+type LoginThrottler interface {
+	Check(string, string) (time.Duration, error)
+	Fail(string, string) error
+	Succeed(string) error
+}
+
+// Slows down the brute force attacks on the logins, counting the failed attempts per email and per client IP.
+// Past the free attempts, every failure doubles the wait before the next attempt, up to LoginLockoutDuration
+type LoginThrottle struct {
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+
+	// Injected via DI
+	LoginAttemptRepository repository.LoginAttemptRepository `inject:""`
+
+	// Prefix of the throttled keys, keeping apart the attempts of the different actions
+	Prefix string
+}
+
+func NewLoginThrottler() *LoginThrottle {
+	return &LoginThrottle{}
+}
+
+// Throttles the password reset requests, apart from the logins so that they can't be used to lock an user out
+func NewPasswordResetThrottler() *LoginThrottle {
+	return &LoginThrottle{
+		Prefix: "reset:",
+	}
+}
+
+// Returns how long the client has to wait before trying to login with the email, zero if it can try right away
+func (t LoginThrottle) Check(email, ip string) (time.Duration, error) {
+	var wait time.Duration
+	for key, free := range t.keys(email, ip) {
+		attempts, err := t.LoginAttemptRepository.Get(key)
+		if err == repository.LoginAttemptsNotFoundError {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if attempts.LastFailureAt.Before(t.Clock.Now().Add(-LoginAttemptsWindow)) {
+			continue
+		}
+
+		until := attempts.LastFailureAt.Add(loginBackoff(attempts.Failures, free))
+		if remaining := until.Sub(t.Clock.Now()); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	return wait, nil
+}
+
+// Records a failed login with the email from the client IP
+func (t LoginThrottle) Fail(email, ip string) error {
+	since := t.Clock.Now().Add(-LoginAttemptsWindow)
+	for key := range t.keys(email, ip) {
+		if _, err := t.LoginAttemptRepository.RecordFailure(key, since); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Forgets the failures of the email after a successful login.
+// The failures of the IP are kept, as an attacker could own one of the accounts it tries
+func (t LoginThrottle) Succeed(email string) error {
+	return t.LoginAttemptRepository.Reset(t.Prefix + emailKey(email))
+}
+
+// Returns the throttled keys with the free attempts of each
+func (t LoginThrottle) keys(email, ip string) map[string]int {
+	keys := map[string]int{
+		t.Prefix + emailKey(email): LoginFreeAttemptsPerEmail,
+	}
+	if ip != "" {
+		keys[t.Prefix+"ip:"+ip] = LoginFreeAttemptsPerIp
+	}
+
+	return keys
+}
+
+// Returns the throttled key of the email
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// Returns the wait imposed after the given number of failures
+func loginBackoff(failures, free int) time.Duration {
+	if failures < free {
+		return 0
+	}
+
+	backoff := LoginBackoffBase
+	for i := free; i < failures; i++ {
+		backoff *= 2
+		if backoff >= LoginLockoutDuration {
+			return LoginLockoutDuration
+		}
+	}
+
+	return backoff
+}
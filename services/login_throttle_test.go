@@ -0,0 +1,142 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type LoginThrottleTestSuite struct {
+	suite.Suite
+	throttle           *LoginThrottle
+	clock              clockwork.FakeClock
+	attemptsRepository *mocks.LoginAttemptRepository
+}
+
+func TestLoginThrottle(t *testing.T) {
+	suite.Run(t, new(LoginThrottleTestSuite))
+}
+
+func (suite *LoginThrottleTestSuite) SetupTest() {
+	suite.clock = clockwork.NewFakeClockAt(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	suite.attemptsRepository = &mocks.LoginAttemptRepository{}
+
+	suite.throttle = NewLoginThrottler()
+	suite.throttle.Clock = suite.clock
+	suite.throttle.LoginAttemptRepository = suite.attemptsRepository
+}
+
+func (suite *LoginThrottleTestSuite) TearDownTest() {
+	suite.attemptsRepository.AssertExpectations(suite.T())
+}
+
+// Returns the attempts of the key, the last one failed ago
+func (suite *LoginThrottleTestSuite) attempts(key string, failures int, ago time.Duration) *entity.LoginAttempts {
+	return &entity.LoginAttempts{
+		Key:           key,
+		Failures:      failures,
+		LastFailureAt: suite.clock.Now().Add(-ago),
+	}
+}
+
+func (suite *LoginThrottleTestSuite) TestLoginBackoff() {
+	suite.Equal(time.Duration(0), loginBackoff(0, 5))
+	suite.Equal(time.Duration(0), loginBackoff(4, 5))
+	suite.Equal(time.Second, loginBackoff(5, 5))
+	suite.Equal(2*time.Second, loginBackoff(6, 5))
+	suite.Equal(512*time.Second, loginBackoff(14, 5))
+	suite.Equal(LoginLockoutDuration, loginBackoff(15, 5))
+	suite.Equal(LoginLockoutDuration, loginBackoff(1000, 5))
+}
+
+func (suite *LoginThrottleTestSuite) TestCheckNoFailures() {
+	suite.attemptsRepository.On("Get", "email:a@b.com").Return(nil, repository.LoginAttemptsNotFoundError)
+	suite.attemptsRepository.On("Get", "ip:127.0.0.1").Return(nil, repository.LoginAttemptsNotFoundError)
+
+	wait, err := suite.throttle.Check(" A@b.com", "127.0.0.1")
+	suite.NoError(err)
+	suite.Equal(time.Duration(0), wait)
+}
+
+func (suite *LoginThrottleTestSuite) TestCheckEmailLocked() {
+	suite.attemptsRepository.On("Get", "email:a@b.com").
+		Return(suite.attempts("email:a@b.com", LoginFreeAttemptsPerEmail+2, time.Second), nil)
+	suite.attemptsRepository.On("Get", "ip:127.0.0.1").
+		Return(suite.attempts("ip:127.0.0.1", LoginFreeAttemptsPerEmail+2, time.Second), nil)
+
+	wait, err := suite.throttle.Check("a@b.com", "127.0.0.1")
+	suite.NoError(err)
+	suite.Equal(3*time.Second, wait)
+}
+
+func (suite *LoginThrottleTestSuite) TestCheckIpLocked() {
+	suite.attemptsRepository.On("Get", "email:a@b.com").Return(nil, repository.LoginAttemptsNotFoundError)
+	suite.attemptsRepository.On("Get", "ip:127.0.0.1").
+		Return(suite.attempts("ip:127.0.0.1", 100, time.Minute), nil)
+
+	wait, err := suite.throttle.Check("a@b.com", "127.0.0.1")
+	suite.NoError(err)
+	suite.Equal(LoginLockoutDuration-time.Minute, wait)
+}
+
+func (suite *LoginThrottleTestSuite) TestCheckBackoffElapsed() {
+	suite.attemptsRepository.On("Get", "email:a@b.com").
+		Return(suite.attempts("email:a@b.com", 100, LoginLockoutDuration), nil)
+
+	wait, err := suite.throttle.Check("a@b.com", "")
+	suite.NoError(err)
+	suite.Equal(time.Duration(0), wait)
+}
+
+func (suite *LoginThrottleTestSuite) TestCheckAnyError() {
+	suite.attemptsRepository.On("Get", "email:a@b.com").Return(nil, assert.AnError)
+
+	_, err := suite.throttle.Check("a@b.com", "")
+	suite.Equal(assert.AnError, err)
+}
+
+func (suite *LoginThrottleTestSuite) TestFail() {
+	since := suite.clock.Now().Add(-LoginAttemptsWindow)
+	suite.attemptsRepository.On("RecordFailure", "email:a@b.com", since).Return(&entity.LoginAttempts{}, nil)
+	suite.attemptsRepository.On("RecordFailure", "ip:127.0.0.1", since).Return(&entity.LoginAttempts{}, nil)
+
+	suite.NoError(suite.throttle.Fail("a@b.com", "127.0.0.1"))
+}
+
+func (suite *LoginThrottleTestSuite) TestFailAnyError() {
+	since := suite.clock.Now().Add(-LoginAttemptsWindow)
+	suite.attemptsRepository.On("RecordFailure", "email:a@b.com", since).Return(nil, assert.AnError)
+
+	suite.Equal(assert.AnError, suite.throttle.Fail("a@b.com", ""))
+}
+
+func (suite *LoginThrottleTestSuite) TestSucceed() {
+	suite.attemptsRepository.On("Reset", "email:a@b.com").Return(nil)
+
+	suite.NoError(suite.throttle.Succeed("A@b.com"))
+}
+
+func (suite *LoginThrottleTestSuite) TestPasswordResetThrottler() {
+	suite.throttle = NewPasswordResetThrottler()
+	suite.throttle.Clock = suite.clock
+	suite.throttle.LoginAttemptRepository = suite.attemptsRepository
+
+	// The attempts are kept apart from the logins
+	since := suite.clock.Now().Add(-LoginAttemptsWindow)
+	suite.attemptsRepository.On("RecordFailure", "reset:email:a@b.com", since).Return(&entity.LoginAttempts{}, nil)
+	suite.attemptsRepository.On("RecordFailure", "reset:ip:127.0.0.1", since).Return(&entity.LoginAttempts{}, nil)
+	suite.attemptsRepository.On("Get", "reset:email:a@b.com").
+		Return(suite.attempts("reset:email:a@b.com", LoginFreeAttemptsPerEmail, 0), nil)
+	suite.attemptsRepository.On("Get", "reset:ip:127.0.0.1").Return(nil, repository.LoginAttemptsNotFoundError)
+
+	suite.Require().NoError(suite.throttle.Fail("a@b.com", "127.0.0.1"))
+
+	wait, err := suite.throttle.Check("a@b.com", "127.0.0.1")
+	suite.NoError(err)
+	suite.Equal(LoginBackoffBase, wait)
+}
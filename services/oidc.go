@@ -0,0 +1,467 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/jonboulle/clockwork"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Validity of the flows, ie. the time the user has to log in at the provider
+	OidcFlowDuration = 10 * time.Minute
+
+	// Minimum interval between two downloads of the provider's keys triggered by an unknown kid
+	OidcKeysRefreshInterval = time.Minute
+
+	// Scopes requested to the provider
+	oidcScopes = "openid email"
+
+	// Maximum size of the provider's responses
+	oidcMaxResponseSize = 1 << 20
+)
+
+var (
+	// Error thrown when the flow is unknown, expired or already completed, or when the flow token doesn't match it
+	InvalidOidcFlowError = errors.New("Invalid OpenID Connect flow")
+
+	// Error thrown when the provider refuses the authorization code
+	InvalidOidcCodeError = errors.New("Invalid OpenID Connect authorization code")
+
+	// Error thrown when the ID token returned by the provider is invalid
+	InvalidIdTokenError = errors.New("Invalid ID token")
+
+	// Error thrown when the ID token is signed with a key the provider doesn't publish
+	unknownKeyError = errors.New("Unknown key")
+)
+
+// Configuration of the OpenID Connect provider
+type OidcConfig struct {
+	// Issuer of the provider eg. https://accounts.example.com. The configuration is discovered at
+	// Issuer/.well-known/openid-configuration
+	Issuer string
+
+	// Client ID and secret registered at the provider. The secret can be empty for public clients
+	ClientId     string
+	ClientSecret string
+
+	// URL the provider redirects to after the login, registered at the provider eg. http://localhost/?oidc=callback
+	RedirectUrl string
+}
+
+// Interface used mainly for Unit testing
+type OidcProvider interface {
+	Authorize() (string, string, error)
+	Exchange(string, string, string) (*entity.Identity, error)
+}
+
+// Members of the discovery document used by the client
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+// Key published by the provider
+type oidcJwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Response of the token endpoint
+type oidcTokenResponse struct {
+	IdToken string `json:"id_token"`
+	Error   string `json:"error"`
+}
+
+// OpenID Connect client, implementing the authorization code flow with PKCE.
+//
+// The nonce and the PKCE verifier are kept on the server until the callback, in a flow found by the state and deleted
+// when the flow is completed, so that it can be completed only once. The flow token, a random token returned to the
+// client with the authorization URL, binds the flow to that client: it sends it back with the code and the state
+// received by the redirect URL
+type Oidc struct {
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+
+	// Injected via DI
+	OidcFlowRepository repository.OidcFlowRepository `inject:""`
+
+	// HTTP client used to contact the provider
+	Client *http.Client
+
+	config OidcConfig
+
+	// Discovery document and keys of the provider, downloaded on first use
+	lock          sync.Mutex
+	discovery     *oidcDiscovery
+	providerKeys  map[string]*Key
+	keysFetchedAt time.Time
+}
+
+// Creates a new OpenID Connect client, given the configuration of the provider
+func NewOidcProvider(config OidcConfig) *Oidc {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	return &Oidc{
+		Client: &http.Client{Timeout: 10 * time.Second},
+		config: config,
+	}
+}
+
+// Starts a new flow. Returns the authorization URL of the provider, where the user is redirected to, and the flow
+// token, kept by the client until the callback
+func (o *Oidc) Authorize() (string, string, error) {
+	discovery, err := o.getDiscovery()
+	if err != nil {
+		return "", "", err
+	}
+
+	// The state protects the client from the forged callbacks, the nonce binds the ID token to the flow and the
+	// verifier binds the code to the flow, see RFC 7636
+	random := make([]string, 4)
+	for n := range random {
+		if random[n], err = randomToken(); err != nil {
+			return "", "", err
+		}
+	}
+	state, nonce, verifier, flowToken := random[0], random[1], random[2], random[3]
+
+	// Only the hashes of the state and of the flow token are stored, they are enough to find and check the flow
+	expiresAt := o.Clock.Now().Add(OidcFlowDuration)
+	if _, err := o.OidcFlowRepository.Create(hashToken(state), hashToken(flowToken), nonce, verifier, expiresAt); err != nil {
+		return "", "", err
+	}
+
+	authorizationUrl, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The endpoint can already have query parameters
+	query := authorizationUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", o.config.ClientId)
+	query.Set("redirect_uri", o.config.RedirectUrl)
+	query.Set("scope", oidcScopes)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	authorizationUrl.RawQuery = query.Encode()
+
+	return authorizationUrl.String(), flowToken, nil
+}
+
+// Completes the flow: consumes the flow of the state, checks it against the flow token, exchanges the code for the
+// ID token and returns the identity it contains. The flow can't be completed anymore, even on error
+func (o *Oidc) Exchange(code, state, flowToken string) (*entity.Identity, error) {
+	flow, err := o.OidcFlowRepository.Consume(hashToken(state))
+	if err == repository.OidcFlowNotFoundError {
+		return nil, InvalidOidcFlowError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !o.Clock.Now().Before(flow.ExpiresAt) {
+		return nil, InvalidOidcFlowError
+	}
+
+	if subtle.ConstantTimeCompare([]byte(flow.FlowToken), []byte(hashToken(flowToken))) != 1 {
+		return nil, InvalidOidcFlowError
+	}
+
+	discovery, err := o.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := o.requestIdToken(discovery.TokenEndpoint, code, flow.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.validateIdToken(discovery.Issuer, idToken, flow.Nonce)
+}
+
+// Exchanges the code at the token endpoint. Returns the ID token
+func (o *Oidc) requestIdToken(endpoint, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", o.config.RedirectUrl)
+	form.Set("code_verifier", verifier)
+	if o.config.ClientSecret == "" {
+		form.Set("client_id", o.config.ClientId)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if o.config.ClientSecret != "" {
+		// The credentials are form encoded before being used for the basic authentication, see RFC 6749 section 2.3.1
+		req.SetBasicAuth(url.QueryEscape(o.config.ClientId), url.QueryEscape(o.config.ClientSecret))
+	}
+
+	res, err := o.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body := oidcTokenResponse{}
+	if err := json.NewDecoder(io.LimitReader(res.Body, oidcMaxResponseSize)).Decode(&body); err != nil {
+		return "", fmt.Errorf("Invalid response of the token endpoint: %s", err.Error())
+	}
+
+	// The provider refuses expired, reused or forged codes, and the codes issued with another verifier
+	if res.StatusCode == http.StatusBadRequest && body.Error == "invalid_grant" {
+		return "", InvalidOidcCodeError
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("The token endpoint returned %d %s", res.StatusCode, body.Error)
+	}
+	if body.IdToken == "" {
+		return "", errors.New("The token endpoint didn't return an ID token")
+	}
+
+	return body.IdToken, nil
+}
+
+// Validates the signature and the claims of the ID token, see OpenID Connect Core section 3.1.3.7
+func (o *Oidc) validateIdToken(issuer, signed, nonce string) (*entity.Identity, error) {
+	// Set when the keys of the provider can't be downloaded, which doesn't make the token invalid
+	var keysErr error
+
+	token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := o.getProviderKey(kid)
+		if err == unknownKeyError {
+			return nil, err
+		}
+		if err != nil {
+			keysErr = err
+			return nil, err
+		}
+
+		// The algorithm is the one of the key, never the one chosen by the token
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("Unexpected signing method %s", token.Method.Alg())
+		}
+
+		return key.public, nil
+	})
+	if keysErr != nil {
+		return nil, keysErr
+	}
+	if err != nil {
+		return nil, InvalidIdTokenError
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, InvalidIdTokenError
+	}
+
+	// The expiration is checked by jwt.Parse, but only if present
+	if _, ok := claims["exp"]; !ok {
+		return nil, InvalidIdTokenError
+	}
+
+	if iss, _ := claims["iss"].(string); iss != issuer {
+		return nil, InvalidIdTokenError
+	}
+
+	if !o.validAudience(claims) {
+		return nil, InvalidIdTokenError
+	}
+
+	if claimNonce, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(claimNonce), []byte(nonce)) != 1 {
+		return nil, InvalidIdTokenError
+	}
+
+	identity := &entity.Identity{Issuer: issuer}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	if identity.Subject == "" || identity.Email == "" {
+		return nil, InvalidIdTokenError
+	}
+
+	// Some providers send the boolean as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	return identity, nil
+}
+
+// Returns true if the ID token has been issued to this client. With several audiences, the authorized party must
+// be this client too
+func (o *Oidc) validAudience(claims jwt.MapClaims) bool {
+	var audiences []string
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+
+	found := false
+	for _, aud := range audiences {
+		if aud == o.config.ClientId {
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+
+	azp, hasAzp := claims["azp"].(string)
+	if len(audiences) > 1 || hasAzp {
+		return azp == o.config.ClientId
+	}
+
+	return true
+}
+
+// Returns the discovery document of the provider, downloading it on first use
+func (o *Oidc) getDiscovery() (*oidcDiscovery, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.discovery != nil {
+		return o.discovery, nil
+	}
+
+	discovery := &oidcDiscovery{}
+	if err := o.getJson(o.config.Issuer+"/.well-known/openid-configuration", discovery); err != nil {
+		return nil, err
+	}
+
+	// Prevents a provider from impersonating another one, see OpenID Connect Discovery section 4.3
+	if discovery.Issuer != o.config.Issuer {
+		return nil, fmt.Errorf("The discovery document is for the issuer %s", discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksUri == "" {
+		return nil, errors.New("Incomplete discovery document")
+	}
+
+	o.discovery = discovery
+	return discovery, nil
+}
+
+// Returns the key of the provider identified by kid. The keys are downloaded again when the kid is unknown, as the
+// provider has probably rotated them, at most every OidcKeysRefreshInterval
+func (o *Oidc) getProviderKey(kid string) (*Key, error) {
+	discovery, err := o.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if key, ok := o.providerKeys[kid]; ok {
+		return key, nil
+	}
+
+	if o.providerKeys != nil && o.Clock.Now().Sub(o.keysFetchedAt) < OidcKeysRefreshInterval {
+		return nil, unknownKeyError
+	}
+
+	body := struct {
+		Keys []oidcJwk `json:"keys"`
+	}{}
+	if err := o.getJson(discovery.JwksUri, &body); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*Key{}
+	for _, jwk := range body.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := ParseJwk(Jwk{Kty: jwk.Kty, Crv: jwk.Crv, X: jwk.X, Y: jwk.Y, N: jwk.N, E: jwk.E})
+		if err != nil {
+			// The keys that can't be used are ignored
+			continue
+		}
+
+		// The RSA keys can be published for a stronger hash than the default one
+		if jwk.Alg != "" && jwk.Alg != key.Method.Alg() {
+			method, ok := jwt.GetSigningMethod(jwk.Alg).(*jwt.SigningMethodRSA)
+			if !ok || key.Method.Alg() != jwt.SigningMethodRS256.Alg() {
+				continue
+			}
+			key.Method = method
+		}
+
+		// The tokens reference the keys with the kid chosen by the provider
+		key.Id = jwk.Kid
+		keys[key.Id] = key
+	}
+
+	o.providerKeys = keys
+	o.keysFetchedAt = o.Clock.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, unknownKeyError
+	}
+	return key, nil
+}
+
+// Downloads the JSON document at u into v
+func (o *Oidc) getJson(u string, v interface{}) error {
+	res, err := o.Client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, io.LimitReader(res.Body, oidcMaxResponseSize))
+		return fmt.Errorf("%s returned %d", u, res.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(res.Body, oidcMaxResponseSize)).Decode(v)
+}
+
+// Computes the S256 PKCE challenge of the verifier, see RFC 7636 section 4.2
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/services/oidctest"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type OidcTestSuite struct {
+	suite.Suite
+	provider *oidctest.Provider
+	oidc     *Oidc
+	clock    clockwork.FakeClock
+	flows    *oidcFlowStore
+}
+
+// Keeps the flows like the repositories do, the memory package can't be imported by the services
+type oidcFlowStore struct {
+	flows map[string]entity.OidcFlow
+
+	// Error returned by Consume, when set
+	err error
+}
+
+func (s *oidcFlowStore) Create(id, flowToken, nonce, verifier string, expiresAt time.Time) (*entity.OidcFlow, error) {
+	flow := entity.OidcFlow{Id: id, FlowToken: flowToken, Nonce: nonce, Verifier: verifier, ExpiresAt: expiresAt}
+	s.flows[id] = flow
+	return &flow, nil
+}
+
+func (s *oidcFlowStore) Consume(id string) (*entity.OidcFlow, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	flow, ok := s.flows[id]
+	if !ok {
+		return nil, repository.OidcFlowNotFoundError
+	}
+	delete(s.flows, id)
+
+	return &flow, nil
+}
+
+func TestOidc(t *testing.T) {
+	suite.Run(t, new(OidcTestSuite))
+}
+
+func (suite *OidcTestSuite) SetupSuite() {
+	var err error
+	suite.provider, err = oidctest.NewProvider("clientId", "client secret")
+	suite.Require().NoError(err)
+}
+
+func (suite *OidcTestSuite) TearDownSuite() {
+	suite.provider.Close()
+}
+
+func (suite *OidcTestSuite) SetupTest() {
+	suite.provider.ClientSecret = "client secret"
+	suite.provider.Email = "user@example.com"
+	suite.provider.EmailVerified = true
+	suite.provider.Claims = nil
+
+	suite.oidc = suite.newOidc(OidcConfig{
+		Issuer:       suite.provider.Issuer() + "/",
+		ClientId:     "clientId",
+		ClientSecret: "client secret",
+		RedirectUrl:  "http://localhost/?oidc=callback",
+	})
+}
+
+func (suite *OidcTestSuite) newOidc(config OidcConfig) *Oidc {
+	suite.clock = clockwork.NewFakeClockAt(time.Now())
+
+	suite.flows = &oidcFlowStore{flows: map[string]entity.OidcFlow{}}
+
+	oidc := NewOidcProvider(config)
+	// The expiration of the ID tokens is checked against the real time
+	oidc.Clock = suite.clock
+	oidc.OidcFlowRepository = suite.flows
+	return oidc
+}
+
+// Starts a new flow and logs in at the provider. Returns the flow token, the code and the state
+func (suite *OidcTestSuite) login() (string, string, string) {
+	authorizationUrl, flowToken, err := suite.oidc.Authorize()
+	suite.Require().NoError(err)
+
+	code, state, err := suite.provider.Login(authorizationUrl)
+	suite.Require().NoError(err)
+
+	return flowToken, code, state
+}
+
+// Runs the whole flow
+func (suite *OidcTestSuite) exchange() (*entity.Identity, error) {
+	flowToken, code, state := suite.login()
+	return suite.oidc.Exchange(code, state, flowToken)
+}
+
+func (suite *OidcTestSuite) TestAuthorize() {
+	authorizationUrl, flowToken, err := suite.oidc.Authorize()
+	suite.Require().NoError(err)
+
+	parsed, err := url.Parse(authorizationUrl)
+	suite.Require().NoError(err)
+	suite.Equal(suite.provider.Issuer()+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+
+	query := parsed.Query()
+	suite.Equal("code", query.Get("response_type"))
+	suite.Equal("clientId", query.Get("client_id"))
+	suite.Equal("http://localhost/?oidc=callback", query.Get("redirect_uri"))
+	suite.Equal("openid email", query.Get("scope"))
+	suite.Equal("S256", query.Get("code_challenge_method"))
+
+	// The secrets of the flow are kept on the server, found by the hash of the state
+	flow, ok := suite.flows.flows[hashToken(query.Get("state"))]
+	suite.Require().True(ok)
+	suite.Equal(hashToken(flowToken), flow.FlowToken)
+	suite.Equal(flow.Nonce, query.Get("nonce"))
+	suite.Equal(codeChallenge(flow.Verifier), query.Get("code_challenge"))
+	suite.True(suite.clock.Now().Add(OidcFlowDuration).Equal(flow.ExpiresAt))
+
+	// The flow token is an opaque random token
+	suite.Len(flowToken, 43)
+	suite.NotContains(flowToken, flow.Verifier)
+
+	// Every flow has its own secrets
+	_, other, err := suite.oidc.Authorize()
+	suite.Require().NoError(err)
+	suite.NotEqual(flowToken, other)
+	suite.Len(suite.flows.flows, 2)
+}
+
+// Example of RFC 7636, appendix B
+func (suite *OidcTestSuite) TestCodeChallenge() {
+	suite.Equal("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", codeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func (suite *OidcTestSuite) TestExchangeOK() {
+	identity, err := suite.exchange()
+	suite.Require().NoError(err)
+	suite.Equal(&entity.Identity{
+		Issuer:        suite.provider.Issuer(),
+		Subject:       "subject",
+		Email:         "user@example.com",
+		EmailVerified: true,
+	}, identity)
+}
+
+func (suite *OidcTestSuite) TestExchangePublicClient() {
+	suite.provider.ClientSecret = ""
+	suite.oidc = suite.newOidc(OidcConfig{
+		Issuer:      suite.provider.Issuer(),
+		ClientId:    "clientId",
+		RedirectUrl: "http://localhost/?oidc=callback",
+	})
+
+	_, err := suite.exchange()
+	suite.NoError(err)
+}
+
+func (suite *OidcTestSuite) TestExchangeEmailNotVerified() {
+	suite.provider.EmailVerified = false
+
+	identity, err := suite.exchange()
+	suite.Require().NoError(err)
+	suite.False(identity.EmailVerified)
+}
+
+func (suite *OidcTestSuite) TestExchangeEmailVerifiedString() {
+	suite.provider.Claims = map[string]interface{}{"email_verified": "true"}
+
+	identity, err := suite.exchange()
+	suite.Require().NoError(err)
+	suite.True(identity.EmailVerified)
+}
+
+func (suite *OidcTestSuite) TestExchangeInvalidFlow() {
+	flowToken, code, state := suite.login()
+
+	identity, err := suite.oidc.Exchange(code, "otherState", flowToken)
+	suite.Nil(identity)
+	suite.Equal(InvalidOidcFlowError, err)
+
+	// The flow token binds the flow to the client having started it
+	identity, err = suite.oidc.Exchange(code, state, "otherFlowToken")
+	suite.Nil(identity)
+	suite.Equal(InvalidOidcFlowError, err)
+
+	// The flow has been consumed by the failed attempt
+	identity, err = suite.oidc.Exchange(code, state, flowToken)
+	suite.Nil(identity)
+	suite.Equal(InvalidOidcFlowError, err)
+}
+
+func (suite *OidcTestSuite) TestExchangeExpiredFlow() {
+	flowToken, code, state := suite.login()
+	suite.clock.Advance(OidcFlowDuration)
+
+	identity, err := suite.oidc.Exchange(code, state, flowToken)
+	suite.Nil(identity)
+	suite.Equal(InvalidOidcFlowError, err)
+}
+
+func (suite *OidcTestSuite) TestExchangeFlowReused() {
+	flowToken, code, state := suite.login()
+
+	_, err := suite.oidc.Exchange(code, state, flowToken)
+	suite.Require().NoError(err)
+	suite.Empty(suite.flows.flows)
+
+	// The flow can be completed only once, even with a new code
+	_, code, _ = suite.login()
+	identity, err := suite.oidc.Exchange(code, state, flowToken)
+	suite.Nil(identity)
+	suite.Equal(InvalidOidcFlowError, err)
+}
+
+func (suite *OidcTestSuite) TestExchangeRepositoryAnyError() {
+	flowToken, code, state := suite.login()
+	suite.flows.err = assert.AnError
+
+	identity, err := suite.oidc.Exchange(code, state, flowToken)
+	suite.Nil(identity)
+	suite.Equal(assert.AnError, err)
+}
+
+func (suite *OidcTestSuite) TestExchangeOtherVerifier() {
+	// A code stolen from another flow can't be used without its verifier
+	_, code, _ := suite.login()
+	flowToken, _, state := suite.login()
+
+	identity, err := suite.oidc.Exchange(code, state, flowToken)
+	suite.Nil(identity)
+	suite.Equal(InvalidOidcCodeError, err)
+}
+
+func (suite *OidcTestSuite) TestExchangeBadClientSecret() {
+	suite.provider.ClientSecret = "other secret"
+
+	identity, err := suite.exchange()
+	suite.Nil(identity)
+	suite.Error(err)
+	suite.NotEqual(InvalidOidcCodeError, err)
+}
+
+func (suite *OidcTestSuite) TestExchangeInvalidIdToken() {
+	for _, claims := range []map[string]interface{}{
+		{"iss": "http://other"},
+		{"aud": "otherClient"},
+		{"aud": []string{"clientId", "otherClient"}},
+		{"aud": []string{"clientId", "otherClient"}, "azp": "otherClient"},
+		{"nonce": "otherNonce"},
+		{"nonce": nil},
+		{"sub": nil},
+		{"email": nil},
+		{"exp": nil},
+		{"exp": time.Now().Add(-time.Minute).Unix()},
+	} {
+		suite.provider.Claims = claims
+
+		identity, err := suite.exchange()
+		suite.Nil(identity, "%v", claims)
+		suite.Equal(InvalidIdTokenError, err, "%v", claims)
+	}
+}
+
+func (suite *OidcTestSuite) TestExchangeSeveralAudiences() {
+	suite.provider.Claims = map[string]interface{}{
+		"aud": []string{"clientId", "otherClient"},
+		"azp": "clientId",
+	}
+
+	_, err := suite.exchange()
+	suite.NoError(err)
+}
+
+func (suite *OidcTestSuite) TestKeyRotation() {
+	_, err := suite.exchange()
+	suite.Require().NoError(err)
+	requests := suite.provider.JwksRequests()
+
+	// The keys are cached
+	_, err = suite.exchange()
+	suite.Require().NoError(err)
+	suite.Equal(requests, suite.provider.JwksRequests())
+
+	// The keys rotated by the provider are not downloaded again before OidcKeysRefreshInterval
+	suite.Require().NoError(suite.provider.Rotate())
+
+	identity, err := suite.exchange()
+	suite.Nil(identity)
+	suite.Equal(InvalidIdTokenError, err)
+	suite.Equal(requests, suite.provider.JwksRequests())
+
+	// Then the new key is downloaded on first use
+	suite.clock.Advance(OidcKeysRefreshInterval)
+	_, err = suite.exchange()
+	suite.NoError(err)
+	suite.Equal(requests+1, suite.provider.JwksRequests())
+
+	_, err = suite.exchange()
+	suite.NoError(err)
+	suite.Equal(requests+1, suite.provider.JwksRequests())
+}
+
+func (suite *OidcTestSuite) TestDiscoveryOtherIssuer() {
+	// Same server, but the discovery document is for another issuer
+	suite.oidc = suite.newOidc(OidcConfig{
+		Issuer:   strings.Replace(suite.provider.Issuer(), "127.0.0.1", "localhost", 1),
+		ClientId: "clientId",
+	})
+
+	_, _, err := suite.oidc.Authorize()
+	suite.Error(err)
+}
+
+func (suite *OidcTestSuite) TestProviderDown() {
+	server := httptest.NewServer(nil)
+	server.Close()
+
+	suite.oidc = suite.newOidc(OidcConfig{
+		Issuer:   server.URL,
+		ClientId: "clientId",
+	})
+
+	_, _, err := suite.oidc.Authorize()
+	suite.Error(err)
+}
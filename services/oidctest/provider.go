@@ -0,0 +1,321 @@
+// Package oidctest provides a local OpenID Connect provider for the tests, implementing the authorization code flow
+// with PKCE
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Validity of the ID tokens
+const IdTokenDuration = 5 * time.Minute
+
+// Authorization code issued to the client, exchanged once at the token endpoint
+type authorization struct {
+	redirectUri string
+	challenge   string
+	claims      jwt.MapClaims
+}
+
+// The provider logs in the user described by its exported fields as soon as the client redirects it to the
+// authorization endpoint, then redirects it back to the client with the code
+type Provider struct {
+	// Server of the provider, its URL is the issuer
+	Server *httptest.Server
+
+	// Client registered at the provider. The secret is empty for a public client
+	ClientId     string
+	ClientSecret string
+
+	// User logged in by the following authorizations
+	Subject       string
+	Email         string
+	EmailVerified bool
+
+	// Claims of the following ID tokens, overriding the default ones. A nil value removes the claim
+	Claims map[string]interface{}
+
+	lock         sync.Mutex
+	key          *rsa.PrivateKey
+	kid          string
+	codes        map[string]authorization
+	jwksRequests int
+}
+
+// Creates and starts the provider with a new signing key
+func NewProvider(clientId, clientSecret string) (*Provider, error) {
+	p := &Provider{
+		ClientId:      clientId,
+		ClientSecret:  clientSecret,
+		Subject:       "subject",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		codes:         map[string]authorization{},
+	}
+
+	if err := p.Rotate(); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.handleDiscovery)
+	mux.HandleFunc("/authorize", p.handleAuthorize)
+	mux.HandleFunc("/token", p.handleToken)
+	mux.HandleFunc("/jwks", p.handleJwks)
+	p.Server = httptest.NewServer(mux)
+
+	return p, nil
+}
+
+// Returns the issuer of the provider
+func (p *Provider) Issuer() string {
+	return p.Server.URL
+}
+
+// Stops the provider
+func (p *Provider) Close() {
+	p.Server.Close()
+}
+
+// Replaces the signing key with a new one. The previous key isn't published anymore
+func (p *Provider) Rotate() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.key = key
+	p.kid = randomString()
+
+	return nil
+}
+
+// Returns the number of downloads of the keys
+func (p *Provider) JwksRequests() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.jwksRequests
+}
+
+// Signs the claims with the current key of the provider. Used to forge the ID tokens
+func (p *Provider) Sign(claims jwt.Claims) (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+	return token.SignedString(p.key)
+}
+
+// Follows the authorization URL like a browser would do. Returns the code and the state sent to the redirect URL
+func (p *Provider) Login(authorizationUrl string) (code, state string, err error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get(authorizationUrl)
+	if err != nil {
+		return "", "", err
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusFound {
+		return "", "", errors.New("Authorization refused: " + res.Status)
+	}
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+func (p *Provider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer(),
+		"authorization_endpoint":                p.Issuer() + "/authorize",
+		"token_endpoint":                        p.Issuer() + "/token",
+		"jwks_uri":                              p.Issuer() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// Logs in the user and redirects it to the client
+func (p *Provider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != p.ClientId || query.Get("response_type") != "code" ||
+		query.Get("redirect_uri") == "" || query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" || !strings.Contains(" "+query.Get("scope")+" ", " openid ") {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            p.Issuer(),
+		"sub":            p.Subject,
+		"aud":            p.ClientId,
+		"exp":            now.Add(IdTokenDuration).Unix(),
+		"iat":            now.Unix(),
+		"email":          p.Email,
+		"email_verified": p.EmailVerified,
+	}
+	if nonce := query.Get("nonce"); nonce != "" {
+		claims["nonce"] = nonce
+	}
+	for name, value := range p.Claims {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+
+	code := randomString()
+	p.lock.Lock()
+	p.codes[code] = authorization{
+		redirectUri: query.Get("redirect_uri"),
+		challenge:   query.Get("code_challenge"),
+		claims:      claims,
+	}
+	p.lock.Unlock()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// Exchanges a code for the ID token
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	if !p.authenticate(r) {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	// The codes can be used only once
+	p.lock.Lock()
+	code, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.lock.Unlock()
+
+	if !ok || code.redirectUri != r.PostForm.Get("redirect_uri") ||
+		code.challenge != challenge(r.PostForm.Get("code_verifier")) {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := p.Sign(code.claims)
+	if err != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   int(IdTokenDuration.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// Authenticates the client with the basic authentication, or with the client_id if it's a public client
+func (p *Provider) authenticate(r *http.Request) bool {
+	if p.ClientSecret == "" {
+		return r.PostForm.Get("client_id") == p.ClientId
+	}
+
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	id, err := url.QueryUnescape(id)
+	if err != nil {
+		return false
+	}
+	secret, err = url.QueryUnescape(secret)
+	if err != nil {
+		return false
+	}
+
+	return id == p.ClientId && subtle.ConstantTimeCompare([]byte(secret), []byte(p.ClientSecret)) == 1
+}
+
+// Publishes the current key
+func (p *Provider) handleJwks(w http.ResponseWriter, r *http.Request) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.jwksRequests++
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": p.kid,
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Returns a random, URL safe string
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Computes the S256 PKCE challenge of the verifier
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
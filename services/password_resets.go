@@ -0,0 +1,85 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+// Validity of the password reset tokens
+const PasswordResetDuration = time.Hour
+
+// Error thrown when the password reset token is unknown, expired or already used
+var InvalidPasswordResetTokenError = errors.New("Invalid password reset token")
+
+// Interface used mainly for Unit testing
+type PasswordResetIssuer interface {
+	Issue(entity.User) (string, error)
+	Consume(string) (*entity.User, error)
+}
+
+// Issues opaque, single use password reset tokens.
+//
+// Only the SHA-256 of the tokens is stored. A token is revoked when the secret of its user changes, so resetting the
+// password invalidates the other tokens of the user
+type PasswordResets struct {
+	// Injected via DI
+	PasswordResetRepository repository.PasswordResetRepository `inject:""`
+
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewPasswordResetIssuer() *PasswordResets {
+	return &PasswordResets{}
+}
+
+// Generates and stores a new password reset token of the user
+func (r PasswordResets) Issue(user entity.User) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := r.Clock.Now().Add(PasswordResetDuration)
+	if _, err := r.PasswordResetRepository.Create(hashToken(token), user.Id, user.Secret, expiresAt); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Consumes the token and returns the user it has been issued to. The token can't be used anymore, even on error
+func (r PasswordResets) Consume(token string) (*entity.User, error) {
+	stored, err := r.PasswordResetRepository.Consume(hashToken(token))
+	if err == repository.PasswordResetNotFoundError {
+		return nil, InvalidPasswordResetTokenError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.Clock.Now().Before(stored.ExpiresAt) {
+		return nil, InvalidPasswordResetTokenError
+	}
+
+	user, err := r.UserRepository.GetUserById(stored.UserId)
+	if err == repository.UserNotFoundError {
+		return nil, InvalidPasswordResetTokenError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(user.Secret), []byte(stored.Secret)) != 1 {
+		return nil, InvalidPasswordResetTokenError
+	}
+
+	return user, nil
+}
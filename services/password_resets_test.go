@@ -0,0 +1,140 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type PasswordResetsTestSuite struct {
+	suite.Suite
+	issuer          *PasswordResets
+	clock           clockwork.FakeClock
+	resetRepository *mocks.PasswordResetRepository
+	userRepository  *mocks.UserRepository
+	user            entity.User
+}
+
+func TestPasswordResets(t *testing.T) {
+	suite.Run(t, new(PasswordResetsTestSuite))
+}
+
+func (suite *PasswordResetsTestSuite) SetupTest() {
+	suite.clock = clockwork.NewFakeClockAt(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	suite.resetRepository = &mocks.PasswordResetRepository{}
+	suite.userRepository = &mocks.UserRepository{}
+
+	suite.issuer = NewPasswordResetIssuer()
+	suite.issuer.PasswordResetRepository = suite.resetRepository
+	suite.issuer.UserRepository = suite.userRepository
+	suite.issuer.Clock = suite.clock
+
+	suite.user = entity.User{
+		Id:     "userId",
+		Email:  "a@b.com",
+		Secret: "secret",
+	}
+}
+
+func (suite *PasswordResetsTestSuite) TearDownTest() {
+	suite.resetRepository.AssertExpectations(suite.T())
+	suite.userRepository.AssertExpectations(suite.T())
+}
+
+// Returns the stored state of the token
+func (suite *PasswordResetsTestSuite) stored(token string) *entity.PasswordReset {
+	return &entity.PasswordReset{
+		Id:        hashToken(token),
+		UserId:    suite.user.Id,
+		Secret:    suite.user.Secret,
+		CreatedAt: suite.clock.Now(),
+		ExpiresAt: suite.clock.Now().Add(PasswordResetDuration),
+	}
+}
+
+func (suite *PasswordResetsTestSuite) TestIssueOK() {
+	var id string
+	expiresAt := suite.clock.Now().Add(PasswordResetDuration)
+	suite.resetRepository.On("Create", mock.MatchedBy(func(hash string) bool {
+		id = hash
+		return true
+	}), suite.user.Id, suite.user.Secret, expiresAt).Return(&entity.PasswordReset{}, nil)
+
+	token, err := suite.issuer.Issue(suite.user)
+	suite.Require().NoError(err)
+	suite.Len(token, 43)
+
+	// Only the hash of the token is stored
+	suite.Equal(hashToken(token), id)
+	suite.NotEqual(token, id)
+}
+
+func (suite *PasswordResetsTestSuite) TestIssueAnError() {
+	suite.resetRepository.On("Create", mock.Anything, suite.user.Id, suite.user.Secret, mock.Anything).Return(nil, assert.AnError)
+
+	_, err := suite.issuer.Issue(suite.user)
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *PasswordResetsTestSuite) TestConsumeNotFound() {
+	suite.resetRepository.On("Consume", hashToken("token")).Return(nil, repository.PasswordResetNotFoundError)
+
+	_, err := suite.issuer.Consume("token")
+	suite.EqualError(err, InvalidPasswordResetTokenError.Error())
+}
+
+func (suite *PasswordResetsTestSuite) TestConsumeAnError() {
+	suite.resetRepository.On("Consume", hashToken("token")).Return(nil, assert.AnError)
+
+	_, err := suite.issuer.Consume("token")
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *PasswordResetsTestSuite) TestConsumeExpired() {
+	suite.resetRepository.On("Consume", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.clock.Advance(PasswordResetDuration)
+
+	_, err := suite.issuer.Consume("token")
+	suite.EqualError(err, InvalidPasswordResetTokenError.Error())
+}
+
+func (suite *PasswordResetsTestSuite) TestConsumeUserNotFound() {
+	suite.resetRepository.On("Consume", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(nil, repository.UserNotFoundError)
+
+	_, err := suite.issuer.Consume("token")
+	suite.EqualError(err, InvalidPasswordResetTokenError.Error())
+}
+
+func (suite *PasswordResetsTestSuite) TestConsumeUserAnError() {
+	suite.resetRepository.On("Consume", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(nil, assert.AnError)
+
+	_, err := suite.issuer.Consume("token")
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *PasswordResetsTestSuite) TestConsumeSecretChanged() {
+	user := suite.user
+	user.Secret = "newSecret"
+	suite.resetRepository.On("Consume", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(&user, nil)
+
+	_, err := suite.issuer.Consume("token")
+	suite.EqualError(err, InvalidPasswordResetTokenError.Error())
+}
+
+func (suite *PasswordResetsTestSuite) TestConsumeOK() {
+	suite.resetRepository.On("Consume", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(&suite.user, nil)
+
+	user, err := suite.issuer.Consume("token")
+	suite.Require().NoError(err)
+	suite.Equal(&suite.user, user)
+}
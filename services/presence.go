@@ -0,0 +1,253 @@
+package services
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+	"sort"
+	"time"
+)
+
+const (
+	// Users connected but not active for this duration are away
+	AwayAfter = 5 * time.Minute
+
+	// Connections not seen for this duration are considered closed, eg. because their server instance died
+	ConnectionTimeout = 2 * HeartbeatInterval
+)
+
+// Interface used mainly for Unit testing
+type PresenceTracker interface {
+	Connect(entity.User) (error, func())
+	Touch(entity.User) error
+	Get(entity.User) (entity.Presence, error)
+	GetAll([]entity.User) ([]entity.Presence, error)
+}
+
+// Tracks the presence of the users.
+//
+// Every websocket connection is stored as a subscription to its user, kept alive with a heartbeat like the
+// subscriptions of the server instances, so that the connections left behind by a dead instance are deleted by the
+// Reaper. The activity of the users is tracked with their LastSeenAt
+type Presence struct {
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+
+	// Injected via DI
+	ConversationRepository repository.ConversationRepository `inject:""`
+
+	// Injected via DI
+	SubscriptionRepository repository.SubscriptionRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient PubsubClient `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewPresenceTracker() *Presence {
+	return &Presence{}
+}
+
+// Registers a new connection of the user. The contacts of the user and the members of its conversations are notified
+// with a presence event if it is the first one.
+//
+// Returns an error if something went wrong and the function to call on disconnection
+func (p *Presence) Connect(user entity.User) (error, func()) {
+	// Get a random connection id
+	id := "C" + uuid.NewV4().String()
+
+	if _, err := p.SubscriptionRepository.Create(id, user.Email); err != nil {
+		return err, nil
+	}
+
+	if err := p.Touch(user); err != nil {
+		// TODO: properly log the error
+		fmt.Println(err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go p.heartbeat(ctx, id)
+
+	if count, err := p.connections(user.Email); err != nil {
+		// TODO: properly log the error
+		fmt.Println(err.Error())
+	} else if count == 1 {
+		p.publish(user.Email, entity.PresenceOnline)
+	}
+
+	return nil, func() {
+		cancel()
+
+		if err := p.SubscriptionRepository.Delete(id); err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+		}
+
+		if err := p.Touch(user); err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+		}
+
+		if count, err := p.connections(user.Email); err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+		} else if count == 0 {
+			p.publish(user.Email, entity.PresenceOffline)
+		}
+	}
+}
+
+// Records an activity of the user
+func (p *Presence) Touch(user entity.User) error {
+	return p.UserRepository.Touch(user.Id)
+}
+
+// Returns the presence of the user
+func (p *Presence) Get(user entity.User) (entity.Presence, error) {
+	count, err := p.connections(user.Email)
+	if err != nil {
+		return p.presence(user, 0), err
+	}
+
+	return p.presence(user, count), nil
+}
+
+// Returns the presence of the users, in the same order. The live connections of all the users are fetched at once
+func (p *Presence) GetAll(users []entity.User) ([]entity.Presence, error) {
+	subscriptions, err := p.SubscriptionRepository.AllLive(p.Clock.Now().Add(-ConnectionTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, subscription := range subscriptions {
+		counts[subscription.To]++
+	}
+
+	presences := make([]entity.Presence, len(users))
+	for i, user := range users {
+		presences[i] = p.presence(user, counts[user.Email])
+	}
+
+	return presences, nil
+}
+
+// Returns the presence of the user having count live connections
+func (p *Presence) presence(user entity.User, count int) entity.Presence {
+	presence := entity.Presence{
+		Email:      user.Email,
+		Status:     entity.PresenceOffline,
+		LastSeenAt: user.LastSeenAt,
+	}
+
+	if count > 0 {
+		if p.Clock.Now().Sub(user.LastSeenAt) < AwayAfter {
+			presence.Status = entity.PresenceOnline
+		} else {
+			presence.Status = entity.PresenceAway
+		}
+	}
+
+	return presence
+}
+
+// Returns the number of live connections of the user
+func (p *Presence) connections(email string) (int, error) {
+	subscriptions, err := p.SubscriptionRepository.AllTo(email)
+	if err != nil {
+		return 0, err
+	}
+
+	after := p.Clock.Now().Add(-ConnectionTimeout)
+
+	count := 0
+	for _, subscription := range subscriptions {
+		if subscription.LastSeenAt.After(after) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Sends a presence event with the status of the user to its contacts and to the members of its conversations
+func (p *Presence) publish(email, status string) {
+	to, err := p.audience(email)
+	if err != nil {
+		// TODO: properly log the error
+		fmt.Println(err.Error())
+		return
+	}
+	if len(to) == 0 {
+		return
+	}
+
+	event, err := entity.NewEvent("presence", to, entity.Presence{
+		Email:      email,
+		Status:     status,
+		LastSeenAt: p.Clock.Now(),
+	})
+	if err == nil {
+		err = p.PubsubClient.PublishEvent(event)
+	}
+	if err != nil {
+		// TODO: properly log the error
+		fmt.Println(err.Error())
+	}
+}
+
+// Returns the users sharing a direct message or a conversation with the user, sorted
+func (p *Presence) audience(email string) ([]string, error) {
+	contacts, err := p.MessageRepository.Contacts(email)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations, err := p.ConversationRepository.AllWithMember(email)
+	if err != nil {
+		return nil, err
+	}
+
+	users := map[string]bool{}
+	for _, contact := range contacts {
+		users[contact] = true
+	}
+	for _, conversation := range conversations {
+		for _, member := range conversation.Members {
+			users[member] = true
+		}
+	}
+	delete(users, email)
+
+	to := []string{}
+	for user := range users {
+		to = append(to, user)
+	}
+	sort.Strings(to)
+
+	return to, nil
+}
+
+// Updates the LastSeenAt of the connection every HeartbeatInterval, until ctx is done
+func (p *Presence) heartbeat(ctx context.Context, id string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.Clock.After(HeartbeatInterval):
+		}
+
+		if err := p.SubscriptionRepository.Touch(id); err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+	"time"
+)
+
+type PresenceTestSuite struct {
+	suite.Suite
+	presence       *Presence
+	clock          clockwork.FakeClock
+	userRepository *mocks.UserRepository
+	msgRepository  *mocks.MessageRepository
+	convRepository *mocks.ConversationRepository
+	subsRepository *mocks.SubscriptionRepository
+	pubsubClient   *mocks.PubsubClient
+	user           entity.User
+}
+
+func TestPresence(t *testing.T) {
+	suite.Run(t, new(PresenceTestSuite))
+}
+
+func (suite *PresenceTestSuite) SetupTest() {
+	suite.clock = clockwork.NewFakeClockAt(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	suite.userRepository = &mocks.UserRepository{}
+	suite.msgRepository = &mocks.MessageRepository{}
+	suite.convRepository = &mocks.ConversationRepository{}
+	suite.subsRepository = &mocks.SubscriptionRepository{}
+	suite.pubsubClient = &mocks.PubsubClient{}
+
+	suite.presence = NewPresenceTracker()
+	suite.presence.UserRepository = suite.userRepository
+	suite.presence.MessageRepository = suite.msgRepository
+	suite.presence.ConversationRepository = suite.convRepository
+	suite.presence.SubscriptionRepository = suite.subsRepository
+	suite.presence.PubsubClient = suite.pubsubClient
+	suite.presence.Clock = suite.clock
+
+	suite.user = entity.User{
+		Id:    "id",
+		Email: "a@b.com",
+	}
+}
+
+func (suite *PresenceTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.msgRepository.AssertExpectations(suite.T())
+	suite.convRepository.AssertExpectations(suite.T())
+	suite.subsRepository.AssertExpectations(suite.T())
+	suite.pubsubClient.AssertExpectations(suite.T())
+}
+
+// Returns a connection of the user, seen ago
+func (suite *PresenceTestSuite) connection(ago time.Duration) entity.Subscription {
+	return entity.Subscription{
+		Id:         "C1",
+		To:         suite.user.Email,
+		LastSeenAt: suite.clock.Now().Add(-ago),
+	}
+}
+
+func (suite *PresenceTestSuite) TestGetOffline() {
+	suite.user.LastSeenAt = suite.clock.Now().Add(-time.Hour)
+
+	// The stale connections are ignored
+	suite.subsRepository.On("AllTo", suite.user.Email).Return([]entity.Subscription{
+		suite.connection(ConnectionTimeout),
+	}, nil)
+
+	presence, err := suite.presence.Get(suite.user)
+	suite.Require().NoError(err)
+	suite.Equal(entity.Presence{
+		Email:      suite.user.Email,
+		Status:     entity.PresenceOffline,
+		LastSeenAt: suite.user.LastSeenAt,
+	}, presence)
+}
+
+func (suite *PresenceTestSuite) TestGetOnline() {
+	suite.user.LastSeenAt = suite.clock.Now().Add(-time.Minute)
+	suite.subsRepository.On("AllTo", suite.user.Email).Return([]entity.Subscription{
+		suite.connection(time.Second),
+	}, nil)
+
+	presence, err := suite.presence.Get(suite.user)
+	suite.Require().NoError(err)
+	suite.Equal(entity.PresenceOnline, presence.Status)
+}
+
+func (suite *PresenceTestSuite) TestGetAway() {
+	suite.user.LastSeenAt = suite.clock.Now().Add(-AwayAfter)
+	suite.subsRepository.On("AllTo", suite.user.Email).Return([]entity.Subscription{
+		suite.connection(time.Second),
+	}, nil)
+
+	presence, err := suite.presence.Get(suite.user)
+	suite.Require().NoError(err)
+	suite.Equal(entity.PresenceAway, presence.Status)
+}
+
+func (suite *PresenceTestSuite) TestGetAnError() {
+	suite.subsRepository.On("AllTo", suite.user.Email).Return(nil, assert.AnError)
+
+	_, err := suite.presence.Get(suite.user)
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *PresenceTestSuite) TestGetAll() {
+	other := entity.User{Id: "id2", Email: "b@b.com", LastSeenAt: suite.clock.Now()}
+	away := entity.User{Id: "id3", Email: "c@b.com", LastSeenAt: suite.clock.Now().Add(-AwayAfter)}
+	suite.user.LastSeenAt = suite.clock.Now()
+
+	// The live connections of all the users are fetched with a single query
+	suite.subsRepository.On("AllLive", suite.clock.Now().Add(-ConnectionTimeout)).Return([]entity.Subscription{
+		{Id: "C1", To: "b@b.com"},
+		{Id: "C2", To: "b@b.com"},
+		{Id: "C3", To: "c@b.com"},
+		{Id: "instance", To: ""},
+	}, nil)
+
+	presences, err := suite.presence.GetAll([]entity.User{suite.user, other, away})
+	suite.Require().NoError(err)
+	suite.Equal([]entity.Presence{
+		{Email: "a@b.com", Status: entity.PresenceOffline, LastSeenAt: suite.user.LastSeenAt},
+		{Email: "b@b.com", Status: entity.PresenceOnline, LastSeenAt: other.LastSeenAt},
+		{Email: "c@b.com", Status: entity.PresenceAway, LastSeenAt: away.LastSeenAt},
+	}, presences)
+}
+
+func (suite *PresenceTestSuite) TestGetAllAnError() {
+	suite.subsRepository.On("AllLive", suite.clock.Now().Add(-ConnectionTimeout)).Return(nil, assert.AnError)
+
+	presences, err := suite.presence.GetAll([]entity.User{suite.user})
+	suite.Nil(presences)
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *PresenceTestSuite) TestConnectAnError() {
+	suite.subsRepository.On("Create", mock.AnythingOfType("string"), suite.user.Email).Return(nil, assert.AnError)
+
+	err, disconnect := suite.presence.Connect(suite.user)
+	suite.EqualError(err, assert.AnError.Error())
+	suite.Nil(disconnect)
+}
+
+func (suite *PresenceTestSuite) TestConnectDisconnect() {
+	var id string
+	suite.subsRepository.On("Create", mock.MatchedBy(func(name string) bool {
+		id = name
+		return strings.HasPrefix(name, "C")
+	}), suite.user.Email).Return(&entity.Subscription{}, nil)
+	suite.userRepository.On("Touch", suite.user.Id).Twice().Return(nil)
+	suite.subsRepository.On("AllTo", suite.user.Email).Once().Return([]entity.Subscription{
+		suite.connection(0),
+	}, nil)
+	suite.msgRepository.On("Contacts", suite.user.Email).Return([]string{"c@b.com"}, nil)
+	suite.convRepository.On("AllWithMember", suite.user.Email).Return([]entity.Conversation{
+		{Id: "conversationId", Members: []string{suite.user.Email, "b@b.com", "c@b.com"}},
+	}, nil)
+
+	var events []entity.Event
+	suite.pubsubClient.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		events = append(events, event)
+		return true
+	})).Return(nil)
+
+	// The contacts and the members of the conversations are notified of the first connection
+	err, disconnect := suite.presence.Connect(suite.user)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(disconnect)
+	suite.Require().Len(events, 1)
+	suite.Equal("presence", events[0].Type)
+	suite.Equal([]string{"b@b.com", "c@b.com"}, events[0].To)
+	suite.JSONEq(`{"email": "a@b.com", "status": "online", "lastSeenAt": "2017-01-01T00:00:00Z"}`, string(events[0].Data))
+
+	// And of the last disconnection
+	suite.subsRepository.On("Delete", id).Return(nil)
+	suite.subsRepository.On("AllTo", suite.user.Email).Once().Return([]entity.Subscription{}, nil)
+
+	disconnect()
+	suite.Require().Len(events, 2)
+	suite.JSONEq(`{"email": "a@b.com", "status": "offline", "lastSeenAt": "2017-01-01T00:00:00Z"}`, string(events[1].Data))
+}
+
+func (suite *PresenceTestSuite) TestConnectNoContacts() {
+	suite.subsRepository.On("Create", mock.AnythingOfType("string"), suite.user.Email).Return(&entity.Subscription{}, nil)
+	suite.userRepository.On("Touch", suite.user.Id).Return(nil)
+	suite.subsRepository.On("AllTo", suite.user.Email).Return([]entity.Subscription{
+		suite.connection(0),
+	}, nil)
+	suite.msgRepository.On("Contacts", suite.user.Email).Return([]string{}, nil)
+	suite.convRepository.On("AllWithMember", suite.user.Email).Return([]entity.Conversation{}, nil)
+
+	// Nobody is notified
+	err, disconnect := suite.presence.Connect(suite.user)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(disconnect)
+}
+
+func (suite *PresenceTestSuite) TestConnectNotFirst() {
+	suite.subsRepository.On("Create", mock.AnythingOfType("string"), suite.user.Email).Return(&entity.Subscription{}, nil)
+	suite.userRepository.On("Touch", suite.user.Id).Return(nil)
+	suite.subsRepository.On("AllTo", suite.user.Email).Return([]entity.Subscription{
+		suite.connection(0),
+		suite.connection(time.Second),
+	}, nil)
+
+	err, disconnect := suite.presence.Connect(suite.user)
+	suite.Require().NoError(err)
+
+	// Nobody is notified while another connection is live
+	suite.subsRepository.On("Delete", mock.AnythingOfType("string")).Return(nil)
+	disconnect()
+}
+
+func (suite *PresenceTestSuite) TestHeartbeat() {
+	suite.subsRepository.On("Create", mock.AnythingOfType("string"), suite.user.Email).Return(&entity.Subscription{}, nil)
+	suite.userRepository.On("Touch", suite.user.Id).Return(nil)
+	suite.subsRepository.On("AllTo", suite.user.Email).Return([]entity.Subscription{
+		suite.connection(0),
+		suite.connection(time.Second),
+	}, nil)
+
+	touched := make(chan bool, 1)
+	suite.subsRepository.On("Touch", mock.AnythingOfType("string")).Run(func(mock.Arguments) {
+		touched <- true
+	}).Return(nil)
+
+	err, disconnect := suite.presence.Connect(suite.user)
+	suite.Require().NoError(err)
+
+	suite.clock.BlockUntil(1)
+	suite.clock.Advance(HeartbeatInterval)
+
+	select {
+	case <-touched:
+	case <-time.After(time.Second):
+		suite.Fail("the connection has not been touched")
+	}
+
+	suite.subsRepository.On("Delete", mock.AnythingOfType("string")).Return(nil)
+	disconnect()
+}
@@ -6,186 +6,292 @@ import (
 	"fmt"
 	"github.com/asiragusa/wschat/entity"
 	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
 	"github.com/satori/go.uuid"
 	"golang.org/x/net/context"
+	"sync"
 	"time"
 )
 
+const (
+	// Name of the topic shared by all the server instances
+	TopicName = "messages"
+
+	// Interval between two updates of the LastSeenAt of the instance subscription
+	HeartbeatInterval = 30 * time.Second
+)
+
 // Interface used mainly for Unit testing
 type PubsubClient interface {
 	Publish(entity.Message) error
-	Subscribe(string, func(message entity.Message)) (error, func())
+	PublishEvent(entity.Event) error
+	Subscribe(string, func(message entity.Message), func(event entity.Event)) (error, func())
 }
 
-// Pubsub client
+// Data published on the topic: either a message with the sequence number of each recipient or an event
+type envelope struct {
+	Message *entity.Message  `json:"message,omitempty"`
+	Seqs    map[string]int64 `json:"seqs,omitempty"`
+	Event   *entity.Event    `json:"event,omitempty"`
+}
+
+// Pubsub client.
+//
+// Every message is published once on the shared topic. Each server instance holds a single subscription to the
+// topic, created on the first call to Subscribe, and routes the received messages to its local subscribers
 type Pubsub struct {
 	// Injected via DI
 	Client *pubsub.Client `inject:""`
 
 	// Injected via DI
 	SubscriptionRepository repository.SubscriptionRepository `inject:""`
+
+	// Injected via DI
+	DeliveryRepository repository.DeliveryRepository `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+
+	lock         sync.Mutex
+	topic        *pubsub.Topic
+	subscription *pubsub.Subscription
+	cancel       func()
+	registry     *Registry
 }
 
 func NewPubsubClient() *Pubsub {
-	return &Pubsub{}
+	return &Pubsub{
+		registry: NewRegistry(),
+	}
 }
 
-// Creates a new subscription
-func (p Pubsub) createSubscription(to string) (*pubsub.Subscription, error) {
-	// Get a random subscription name
-	name := "T" + uuid.NewV4().String()
+// Returns the shared topic, creating it if needed. Must be called with the lock held
+func (p *Pubsub) getTopic() (*pubsub.Topic, error) {
+	if p.topic != nil {
+		return p.topic, nil
+	}
 
-	// First create the topic
 	ctx := context.Background()
-	topic, err := p.Client.CreateTopic(ctx, name)
+	topic := p.Client.Topic(TopicName)
+	ok, err := topic.Exists(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer topic.Stop()
 
-	// Create the sub
+	if !ok {
+		topic, err = p.Client.CreateTopic(ctx, TopicName)
+		if err != nil {
+			// The topic may have been created by another instance in the meantime
+			topic = p.Client.Topic(TopicName)
+			if ok, _ := topic.Exists(ctx); !ok {
+				return nil, err
+			}
+		}
+	}
+
+	p.topic = topic
+	return topic, nil
+}
+
+// Creates the subscription of this instance and starts receiving the messages, if not already done.
+// Must be called with the lock held
+func (p *Pubsub) start() error {
+	if p.subscription != nil {
+		return nil
+	}
+
+	topic, err := p.getTopic()
+	if err != nil {
+		return err
+	}
+
+	// Get a random subscription name
+	name := "S" + uuid.NewV4().String()
+
+	ctx := context.Background()
 	subscription, err := p.Client.CreateSubscription(ctx, name, pubsub.SubscriptionConfig{
 		Topic:       topic,
 		AckDeadline: 10 * time.Second,
 	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Store the subscription in the db
-	_, err = p.SubscriptionRepository.Create(name, to)
-	if err != nil {
+	// Store the subscription in the db. The subscription of an instance doesn't belong to a single user
+	if _, err := p.SubscriptionRepository.Create(name, ""); err != nil {
 		p.deleteSubscription(subscription)
-		return nil, err
+		return err
 	}
 
-	return subscription, nil
+	ctx, cancel := context.WithCancel(ctx)
+	p.subscription = subscription
+	p.cancel = cancel
+
+	// Receive the messages in a new goroutine
+	go func() {
+		err := subscription.Receive(ctx, p.receive)
+		if err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+		}
+	}()
+
+	// Keep the subscription alive, so that it's not deleted by the Reaper
+	go p.heartbeat(ctx, name)
+
+	return nil
 }
 
-// Deletes a subscription
-func (p Pubsub) deleteSubscription(subscription *pubsub.Subscription) {
-	// First remove it from the db
-	if err := p.SubscriptionRepository.Delete(subscription.ID()); err != nil {
-		// TODO: properly log the error
-		fmt.Println(err.Error())
+// Updates the LastSeenAt of the subscription every HeartbeatInterval, until ctx is done
+func (p *Pubsub) heartbeat(ctx context.Context, id string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.Clock.After(HeartbeatInterval):
+		}
+
+		if err := p.SubscriptionRepository.Touch(id); err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+		}
 	}
+}
 
-	ctx := context.Background()
-	config, err := subscription.Config(ctx)
-	if err != nil {
+// Routes a message or an event received from the subscription to the local subscribers of its recipients
+func (p *Pubsub) receive(ctx context.Context, m *pubsub.Message) {
+	var data envelope
+	if err := json.Unmarshal(m.Data, &data); err != nil {
 		// TODO: properly log the error
 		fmt.Println(err.Error())
 
-		err = subscription.Delete(ctx)
-		// TODO: properly log the error
-		fmt.Println(err.Error())
+		// Don't acknowledge de message
+		m.Nack()
 		return
 	}
 
-	if err := subscription.Delete(ctx); err != nil {
+	if data.Event != nil {
+		for _, to := range data.Event.To {
+			p.registry.DispatchEvent(to, *data.Event)
+		}
+	}
+
+	if data.Message != nil {
+		for to, seq := range data.Seqs {
+			message := *data.Message
+			message.Seq = seq
+			p.registry.Dispatch(to, message)
+		}
+	}
+
+	// Every instance receives all the messages, they are acknowledged even if nobody is connected here
+	m.Ack()
+}
+
+// Deletes a subscription, from the db and from pubsub
+func (p *Pubsub) deleteSubscription(subscription *pubsub.Subscription) {
+	// First remove it from the db
+	if err := p.SubscriptionRepository.Delete(subscription.ID()); err != nil {
 		// TODO: properly log the error
 		fmt.Println(err.Error())
 	}
 
-	topic := config.Topic
-	if err := topic.Delete(ctx); err != nil {
+	if err := subscription.Delete(context.Background()); err != nil {
 		// TODO: properly log the error
 		fmt.Println(err.Error())
 	}
-
-	topic.Stop()
 }
 
-// Get all the topics belonging to a given user
-func (p Pubsub) getTopics(to string) ([]*pubsub.Topic, error) {
-	topicList, err := p.SubscriptionRepository.AllTo(to)
-	if err != nil {
-		return nil, err
+// Publish a message to all its recipients: message.To for direct messages, every conversation member but
+// the sender otherwise.
+//
+// Every delivery is recorded with the next sequence number of the recipient, then the message is published once,
+// along with the sequence numbers. If a recipient is not connected, it will fetch the message on reconnection
+func (p *Pubsub) Publish(message entity.Message) error {
+	data := envelope{
+		Message: &message,
+		Seqs:    map[string]int64{},
 	}
 
-	var topics []*pubsub.Topic
-	ctx := context.Background()
-
-	for _, t := range topicList {
-		topic := p.Client.Topic(t.Id)
-		ok, err := topic.Exists(ctx)
+	for _, to := range message.Recipients() {
+		// Record the delivery first, so that it can be replayed if the receiver is not connected
+		delivery, err := p.DeliveryRepository.Create(to, message.Id)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		data.Seqs[to] = delivery.Seq
+	}
 
-		if ok {
-			topics = append(topics, topic)
-		}
+	if len(data.Seqs) == 0 {
+		return nil
 	}
 
-	return topics, nil
+	return p.publish(data)
 }
 
-// Publish a message. The field message.To is used to identify the receivers
-func (p Pubsub) Publish(message entity.Message) error {
-	json, err := json.Marshal(&message)
+// Publish an event to its recipients. Events are not recorded, the recipients not connected won't receive them
+func (p *Pubsub) PublishEvent(event entity.Event) error {
+	if len(event.To) == 0 {
+		return nil
+	}
+
+	return p.publish(envelope{
+		Event: &event,
+	})
+}
+
+// Publish the data on the shared topic
+func (p *Pubsub) publish(data envelope) error {
+	json, err := json.Marshal(&data)
 	if err != nil {
 		return err
 	}
 
-	// Get all the topics belonging to the mesage receiver
-	topics, err := p.getTopics(message.To)
+	p.lock.Lock()
+	topic, err := p.getTopic()
+	p.lock.Unlock()
 	if err != nil {
 		return err
 	}
 
-	// Publish the message on every open topic. If the receiver is not connected, no message will be published
-	for _, topic := range topics {
-		topic.Publish(context.Background(), &pubsub.Message{
-			Data: json,
-		})
-	}
-	return nil
+	ctx := context.Background()
+	_, err = topic.Publish(ctx, &pubsub.Message{
+		Data: json,
+	}).Get(ctx)
+
+	return err
 }
 
-// Subscribe to the messages sent to the to user. The cb function is called when a new message is received.
+// Subscribe to the messages and the events sent to the to user. The onMessage and onEvent functions are called when
+// a new message or event is received.
 //
-// Returns an error if something went wrong and the cancel function, used to delete the subscription
-func (p Pubsub) Subscribe(to string, cb func(message entity.Message)) (error, func()) {
-	// Creates the subscription
-	subscription, err := p.createSubscription(to)
+// Returns an error if something went wrong and the cancel function, used to remove the subscriber
+func (p *Pubsub) Subscribe(to string, onMessage func(entity.Message), onEvent func(entity.Event)) (error, func()) {
+	p.lock.Lock()
+	err := p.start()
+	p.lock.Unlock()
 	if err != nil {
 		return err, nil
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-
-	// Receive the messages in a new goroutine
-	go func() {
-		err := subscription.Receive(ctx, func(context context.Context, m *pubsub.Message) {
-			// Decode the received message
-			var message entity.Message
-			err := json.Unmarshal(m.Data, &message)
-
-			if err != nil {
-				// TODO: properly log the error
-				fmt.Println(err.Error())
-
-				// Don't acknowledge de message
-				m.Nack()
-				return
-			}
+	return nil, p.registry.Add(to, onMessage, onEvent)
+}
 
-			// Call th cb function, ignoring errors from the receiver function
-			cb(message)
+// Stops receiving the messages and deletes the subscription of this instance
+func (p *Pubsub) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
 
-			// Acknowledge the message
-			m.Ack()
-		})
+	if p.subscription == nil {
+		return
+	}
 
-		if err != nil {
-			// TODO: properly log the error
-			fmt.Println(err.Error())
-		}
-	}()
+	p.cancel()
+	p.deleteSubscription(p.subscription)
+	p.subscription = nil
 
-	return nil, func() {
-		cancel()
-		p.deleteSubscription(subscription)
+	if p.topic != nil {
+		p.topic.Stop()
+		p.topic = nil
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"golang.org/x/net/context"
 	"google.golang.org/api/iterator"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -17,12 +18,17 @@ import (
 
 type PubsubClientTestSuite struct {
 	suite.Suite
-	client         *Pubsub
-	clock          clockwork.FakeClock
-	subsRepository *mocks.SubscriptionRepository
+	pubsubClient       *pubsub.Client
+	client             *Pubsub
+	clock              clockwork.FakeClock
+	subsRepository     *mocks.SubscriptionRepository
+	deliveryRepository *mocks.DeliveryRepository
 }
 
 func TestPubsubClient(t *testing.T) {
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("PUBSUB_EMULATOR_HOST is not set")
+	}
 	suite.Run(t, new(PubsubClientTestSuite))
 }
 
@@ -40,13 +46,12 @@ func (suite *PubsubClientTestSuite) SetupSuite() {
 	client, err := getPubsubClient("test")
 	suite.Require().NoError(err)
 
-	suite.client = NewPubsubClient()
-	suite.client.Client = client
+	suite.pubsubClient = client
 }
 
 func (suite *PubsubClientTestSuite) cleanSubs() {
 	ctx := context.Background()
-	it := suite.client.Client.Subscriptions(ctx)
+	it := suite.pubsubClient.Subscriptions(ctx)
 	for {
 		sub, err := it.Next()
 		if err == iterator.Done {
@@ -59,7 +64,7 @@ func (suite *PubsubClientTestSuite) cleanSubs() {
 }
 func (suite *PubsubClientTestSuite) cleanPubs() {
 	ctx := context.Background()
-	it := suite.client.Client.Topics(ctx)
+	it := suite.pubsubClient.Topics(ctx)
 	for {
 		topic, err := it.Next()
 		if err == iterator.Done {
@@ -75,12 +80,22 @@ func (suite *PubsubClientTestSuite) SetupTest() {
 	suite.cleanSubs()
 	suite.cleanPubs()
 
+	suite.clock = clockwork.NewFakeClock()
+
+	suite.client = NewPubsubClient()
+	suite.client.Client = suite.pubsubClient
+	suite.client.Clock = suite.clock
+
 	suite.subsRepository = &mocks.SubscriptionRepository{}
 	suite.client.SubscriptionRepository = suite.subsRepository
+
+	suite.deliveryRepository = &mocks.DeliveryRepository{}
+	suite.client.DeliveryRepository = suite.deliveryRepository
 }
 
 func (suite *PubsubClientTestSuite) TearDownTest() {
 	suite.subsRepository.AssertExpectations(suite.T())
+	suite.deliveryRepository.AssertExpectations(suite.T())
 }
 
 func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
@@ -105,116 +120,95 @@ func (m *MockReceiver) Receive(message string) {
 	m.Called(message)
 }
 
-func (suite *PubsubClientTestSuite) TestGetTopicsRepoError() {
-	suite.subsRepository.On("AllTo", "to1").Return(nil, assert.AnError)
-	topics, err := suite.client.getTopics("to1")
-	suite.Nil(topics)
-	suite.Require().NotNil(err)
-	suite.EqualError(err, assert.AnError.Error())
-}
-
-func (suite *PubsubClientTestSuite) TestPublishRepoError() {
-	suite.subsRepository.On("AllTo", "to1").Return(nil, assert.AnError)
+func (suite *PubsubClientTestSuite) TestPublishDeliveryError() {
+	suite.deliveryRepository.On("Create", "to1", "id1").Return(nil, assert.AnError)
 	err := suite.client.Publish(entity.Message{
+		Id: "id1",
 		To: "to1",
 	})
 	suite.Require().NotNil(err)
 	suite.EqualError(err, assert.AnError.Error())
 }
 
-func (suite *PubsubClientTestSuite) TestGetTopicsNotExisting() {
-	suite.subsRepository.On("AllTo", "to1").Return([]entity.Subscription{
-		{
-			Id: "id1",
-			To: "to1",
-		},
-	}, nil)
-
-	topics, err := suite.client.getTopics("to1")
-	suite.Require().NoError(err)
-
-	suite.Len(topics, 0)
-}
-
-func (suite *PubsubClientTestSuite) TestGetTopicsOK() {
-	subscriptions := []entity.Subscription{
-		{
-			Id: "id1",
-			To: "to1",
-		},
-		{
-			Id: "id2",
-			To: "to1",
-		},
-		{
-			Id: "id3",
-			To: "to1",
-		},
-	}
-	suite.subsRepository.On("AllTo", "to1").Return(subscriptions, nil)
-
-	ctx := context.Background()
-
-	topic1, err := suite.client.Client.CreateTopic(ctx, "id1")
-	suite.Require().NoError(err)
-	defer topic1.Stop()
-
-	topic2, err := suite.client.Client.CreateTopic(ctx, "id2")
+func (suite *PubsubClientTestSuite) TestPublishCreatesTopic() {
+	suite.deliveryRepository.On("Create", "to1", "id1").Return(&entity.Delivery{Seq: 1}, nil)
+	err := suite.client.Publish(entity.Message{
+		Id: "id1",
+		To: "to1",
+	})
 	suite.Require().NoError(err)
-	defer topic2.Stop()
 
-	topics, err := suite.client.getTopics("to1")
+	ok, err := suite.pubsubClient.Topic(TopicName).Exists(context.Background())
 	suite.Require().NoError(err)
-
-	defer func() {
-		for _, t := range topics {
-			t.Stop()
-		}
-	}()
-
-	suite.Require().Len(topics, 2)
-	suite.Equal(topic1.ID(), topics[0].ID())
-	suite.Equal(topic2.ID(), topics[1].ID())
+	suite.True(ok)
 }
 
-func (suite *PubsubClientTestSuite) TestCreateSubscriptionRepoError() {
-	to := "test@test.com"
-
-	suite.subsRepository.On("Create", mock.AnythingOfType("string"), to).Return(nil, assert.AnError)
+func (suite *PubsubClientTestSuite) TestSubscribeRepoError() {
+	suite.subsRepository.On("Create", mock.AnythingOfType("string"), "").Return(nil, assert.AnError)
 	suite.subsRepository.On("Delete", mock.AnythingOfType("string")).Return(nil)
-	subscription, err := suite.client.createSubscription(to)
 
+	err, cancel := suite.client.Subscribe("to1", func(entity.Message) {}, func(entity.Event) {})
 	suite.Require().NotNil(err)
 	suite.EqualError(err, assert.AnError.Error())
-	suite.Nil(subscription)
-
+	suite.Nil(cancel)
+	suite.Nil(suite.client.subscription)
 }
 
-func (suite *PubsubClientTestSuite) createSubscription(to string) *pubsub.Subscription {
-	subEntity := &entity.Subscription{}
-	suite.subsRepository.On("Create", mock.AnythingOfType("string"), to).Return(subEntity, nil)
+func (suite *PubsubClientTestSuite) TestSubscribeCreatesASingleSubscription() {
+	var subId string
+	suite.subsRepository.On("Create", mock.MatchedBy(func(id string) bool {
+		subId = id
+		return true
+	}), "").Once().Return(&entity.Subscription{}, nil)
+
+	err, cancel1 := suite.client.Subscribe("to1", func(entity.Message) {}, func(entity.Event) {})
+	suite.Require().NoError(err)
+	defer cancel1()
+
+	err, cancel2 := suite.client.Subscribe("to2", func(entity.Message) {}, func(entity.Event) {})
+	suite.Require().NoError(err)
+	defer cancel2()
 
-	subscription, err := suite.client.createSubscription(to)
+	ok, err := suite.pubsubClient.Subscription(subId).Exists(context.Background())
 	suite.Require().NoError(err)
+	suite.True(ok)
+
+	suite.subsRepository.On("Delete", subId).Return(nil)
+	suite.client.Close()
 
-	return subscription
+	ok, err = suite.pubsubClient.Subscription(subId).Exists(context.Background())
+	suite.Require().NoError(err)
+	suite.False(ok)
 }
 
-func (suite *PubsubClientTestSuite) TestCreateSubscriptionOK() {
-	subscription := suite.createSubscription("to1")
+func (suite *PubsubClientTestSuite) TestSubscribeHeartbeat() {
+	var subId string
+	suite.subsRepository.On("Create", mock.MatchedBy(func(id string) bool {
+		subId = id
+		return true
+	}), "").Once().Return(&entity.Subscription{}, nil)
 
-	ok, err := subscription.Exists(context.Background())
+	err, cancel := suite.client.Subscribe("to1", func(entity.Message) {}, func(entity.Event) {})
 	suite.Require().NoError(err)
+	defer cancel()
 
-	suite.True(ok)
-}
+	touched := make(chan string)
+	suite.subsRepository.On("Touch", subId).Run(func(args mock.Arguments) {
+		touched <- args.String(0)
+	}).Return(nil)
 
-func (suite *PubsubClientTestSuite) TestDeleteSubscriptionOK() {
-	subscription := suite.createSubscription("to1")
+	suite.clock.BlockUntil(1)
+	suite.clock.Advance(HeartbeatInterval)
 
-	suite.subsRepository.On("Delete", subscription.ID()).Return(nil)
+	select {
+	case id := <-touched:
+		suite.Equal(subId, id)
+	case <-time.After(time.Second):
+		suite.Fail("the subscription has not been touched")
+	}
 
-	suite.client.deleteSubscription(subscription)
+	suite.subsRepository.On("Delete", subId).Return(nil)
+	suite.client.Close()
 }
 
 func (suite *PubsubClientTestSuite) TestPublishSubscribe() {
@@ -242,45 +236,52 @@ func (suite *PubsubClientTestSuite) TestPublishSubscribe() {
 			Message:   "test3",
 			CreatedAt: now,
 		},
+		{
+			Id:             "test4",
+			ConversationId: "conversationId",
+			From:           "from1",
+			Users:          []string{"from1", to},
+			Message:        "test4",
+			CreatedAt:      now,
+		},
 	}
 
 	var receiver MockReceiver
 
 	var wg sync.WaitGroup
-	wg.Add(4) //subscriptions * len messages to a@b.com
+	wg.Add(6) // local subscribers * len messages to a@b.com
 
 	receiveFn := func(message entity.Message) {
 		defer wg.Done()
+		suite.NotZero(message.Seq)
 		receiver.Receive(message.Id)
 	}
 
-	var subIds []string
-
-	subEntity := &entity.Subscription{}
+	var subId string
 	suite.subsRepository.On("Create", mock.MatchedBy(func(id string) bool {
-		subIds = append(subIds, id)
+		subId = id
 		return true
-	}), to).Twice().Return(subEntity, nil)
+	}), "").Once().Return(&entity.Subscription{}, nil)
 
-	err, cancel1 := suite.client.Subscribe(to, receiveFn)
+	events := make(chan entity.Event, 2)
+	eventFn := func(event entity.Event) {
+		events <- event
+	}
+
+	err, cancel1 := suite.client.Subscribe(to, receiveFn, eventFn)
 	suite.Require().NoError(err)
 
-	err, cancel2 := suite.client.Subscribe(to, receiveFn)
+	err, cancel2 := suite.client.Subscribe(to, receiveFn, eventFn)
 	suite.Require().NoError(err)
 
 	receiver.On("Receive", "test1").Twice()
 	receiver.On("Receive", "test2").Twice()
+	receiver.On("Receive", "test4").Twice()
 
-	suite.subsRepository.On("AllTo", to).Return([]entity.Subscription{
-		{
-			Id: subIds[0],
-		},
-		{
-			Id: subIds[1],
-		},
-	}, nil)
-
-	suite.subsRepository.On("AllTo", "b@b.com").Return([]entity.Subscription{}, nil)
+	suite.deliveryRepository.On("Create", to, "test1").Return(&entity.Delivery{Seq: 1}, nil)
+	suite.deliveryRepository.On("Create", to, "test2").Return(&entity.Delivery{Seq: 2}, nil)
+	suite.deliveryRepository.On("Create", "b@b.com", "test3").Return(&entity.Delivery{Seq: 1}, nil)
+	suite.deliveryRepository.On("Create", to, "test4").Return(&entity.Delivery{Seq: 3}, nil)
 
 	for _, message := range messages {
 		err := suite.client.Publish(message)
@@ -290,11 +291,26 @@ func (suite *PubsubClientTestSuite) TestPublishSubscribe() {
 	timeout := waitTimeout(&wg, time.Second)
 	suite.Require().False(timeout)
 
-	suite.subsRepository.On("Delete", subIds[0]).Return(nil)
-	cancel1()
+	event, err := entity.NewEvent("read", []string{to, "b@b.com"}, map[string]string{"messageId": "test1"})
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.client.PublishEvent(event))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case received := <-events:
+			suite.Equal("read", received.Type)
+			suite.JSONEq(`{"messageId": "test1"}`, string(received.Data))
+		case <-time.After(time.Second):
+			suite.Fail("the event has not been received")
+		}
+	}
 
-	suite.subsRepository.On("Delete", subIds[1]).Return(nil)
+	cancel1()
 	cancel2()
+	suite.Equal(0, suite.client.registry.Len())
+
+	suite.subsRepository.On("Delete", subId).Return(nil)
+	suite.client.Close()
 
 	receiver.AssertExpectations(suite.T())
 }
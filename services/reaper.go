@@ -0,0 +1,113 @@
+package services
+
+import (
+	"cloud.google.com/go/pubsub"
+	"fmt"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const (
+	// Subscriptions not seen for this duration are deleted by the Reaper
+	DefaultStaleAfter = 5 * time.Minute
+
+	// Interval between two sweeps of the Reaper
+	ReapInterval = time.Minute
+)
+
+// The Reaper deletes the subscriptions left behind by the server instances that died without deleting their own
+type Reaper struct {
+	// Injected via DI
+	Client *pubsub.Client `inject:""`
+
+	// Injected via DI
+	SubscriptionRepository repository.SubscriptionRepository `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+
+	// Subscriptions not seen for this duration are considered stale
+	StaleAfter time.Duration
+}
+
+func NewReaper() *Reaper {
+	return &Reaper{
+		StaleAfter: DefaultStaleAfter,
+	}
+}
+
+// Deletes the stale subscriptions. The subscriptions failing to be deleted are skipped and retried on the next sweep.
+//
+// Returns the number of subscriptions deleted and the last error encountered
+func (r *Reaper) Sweep() (int, error) {
+	stale, err := r.SubscriptionRepository.AllStale(r.Clock.Now().Add(-r.StaleAfter))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, subscription := range stale {
+		if e := r.deleteSubscription(subscription.Id); e != nil {
+			err = e
+			continue
+		}
+		deleted++
+	}
+
+	return deleted, err
+}
+
+// Deletes a subscription from pubsub, then from the db
+func (r *Reaper) deleteSubscription(id string) error {
+	ctx := context.Background()
+
+	subscription := r.Client.Subscription(id)
+	ok, err := subscription.Exists(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		config, err := subscription.Config(ctx)
+		if err != nil {
+			return err
+		}
+
+		// The subscriptions created before the shared topic had a topic of their own
+		if topic := config.Topic; topic != nil && topic.ID() != TopicName {
+			ok, err := topic.Exists(ctx)
+			if err != nil {
+				return err
+			}
+			if ok {
+				if err := topic.Delete(ctx); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := subscription.Delete(ctx); err != nil {
+			return err
+		}
+	}
+
+	return r.SubscriptionRepository.Delete(id)
+}
+
+// Sweeps every interval, until ctx is done
+func (r *Reaper) Run(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.Clock.After(interval):
+		}
+
+		if _, err := r.Sweep(); err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"cloud.google.com/go/pubsub"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+	"os"
+	"testing"
+	"time"
+)
+
+type ReaperTestSuite struct {
+	suite.Suite
+	pubsubClient   *pubsub.Client
+	reaper         *Reaper
+	clock          clockwork.FakeClock
+	subsRepository *mocks.SubscriptionRepository
+}
+
+func TestReaper(t *testing.T) {
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("PUBSUB_EMULATOR_HOST is not set")
+	}
+	suite.Run(t, new(ReaperTestSuite))
+}
+
+func (suite *ReaperTestSuite) SetupSuite() {
+	client, err := getPubsubClient("test")
+	suite.Require().NoError(err)
+
+	suite.pubsubClient = client
+}
+
+func (suite *ReaperTestSuite) SetupTest() {
+	suite.clock = clockwork.NewFakeClock()
+	suite.subsRepository = &mocks.SubscriptionRepository{}
+
+	suite.reaper = NewReaper()
+	suite.reaper.Client = suite.pubsubClient
+	suite.reaper.SubscriptionRepository = suite.subsRepository
+	suite.reaper.Clock = suite.clock
+}
+
+func (suite *ReaperTestSuite) TearDownTest() {
+	suite.subsRepository.AssertExpectations(suite.T())
+}
+
+// Creates a subscription with its own topic, as done before the shared topic was introduced
+func (suite *ReaperTestSuite) createLegacySubscription(name string) (*pubsub.Topic, *pubsub.Subscription) {
+	ctx := context.Background()
+
+	topic, err := suite.pubsubClient.CreateTopic(ctx, name)
+	suite.Require().NoError(err)
+	defer topic.Stop()
+
+	subscription, err := suite.pubsubClient.CreateSubscription(ctx, name, pubsub.SubscriptionConfig{
+		Topic:       topic,
+		AckDeadline: 10 * time.Second,
+	})
+	suite.Require().NoError(err)
+
+	return topic, subscription
+}
+
+func (suite *ReaperTestSuite) TestSweepRepoError() {
+	suite.subsRepository.On("AllStale", suite.clock.Now().Add(-DefaultStaleAfter)).Return(nil, assert.AnError)
+
+	deleted, err := suite.reaper.Sweep()
+	suite.Equal(0, deleted)
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *ReaperTestSuite) TestSweepOK() {
+	topic, subscription := suite.createLegacySubscription("Tlegacy")
+
+	suite.subsRepository.On("AllStale", suite.clock.Now().Add(-DefaultStaleAfter)).Return([]entity.Subscription{
+		{Id: subscription.ID()},
+		{Id: "notExisting"},
+	}, nil)
+	suite.subsRepository.On("Delete", subscription.ID()).Return(nil)
+	suite.subsRepository.On("Delete", "notExisting").Return(nil)
+
+	deleted, err := suite.reaper.Sweep()
+	suite.NoError(err)
+	suite.Equal(2, deleted)
+
+	ctx := context.Background()
+	ok, err := subscription.Exists(ctx)
+	suite.Require().NoError(err)
+	suite.False(ok)
+
+	ok, err = topic.Exists(ctx)
+	suite.Require().NoError(err)
+	suite.False(ok)
+}
+
+func (suite *ReaperTestSuite) TestSweepDeleteError() {
+	suite.subsRepository.On("AllStale", mock.Anything).Return([]entity.Subscription{
+		{Id: "id1"},
+		{Id: "id2"},
+	}, nil)
+	suite.subsRepository.On("Delete", "id1").Return(assert.AnError)
+	suite.subsRepository.On("Delete", "id2").Return(nil)
+
+	deleted, err := suite.reaper.Sweep()
+	suite.EqualError(err, assert.AnError.Error())
+	suite.Equal(1, deleted)
+}
+
+func (suite *ReaperTestSuite) TestRun() {
+	swept := make(chan bool)
+	suite.subsRepository.On("AllStale", mock.Anything).Run(func(mock.Arguments) {
+		swept <- true
+	}).Return([]entity.Subscription{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		suite.reaper.Run(ctx, ReapInterval)
+		done <- true
+	}()
+
+	suite.clock.BlockUntil(1)
+	suite.clock.Advance(ReapInterval)
+
+	select {
+	case <-swept:
+	case <-time.After(time.Second):
+		suite.Fail("the reaper didn't sweep")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.Fail("the reaper didn't stop")
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+const (
+	// Validity of the access tokens
+	AccessTokenDuration = 15 * time.Minute
+
+	// Validity of the refresh tokens
+	RefreshTokenDuration = 30 * 24 * time.Hour
+)
+
+var (
+	// Error thrown when the refresh token is unknown, expired or revoked
+	InvalidRefreshTokenError = errors.New("Invalid refresh token")
+
+	// Error thrown when an already used refresh token is presented again. Its whole family is revoked
+	RefreshTokenReusedError = errors.New("Refresh token reused")
+)
+
+// Interface used mainly for Unit testing
+type RefreshTokenIssuer interface {
+	Issue(entity.Session) (string, error)
+	Rotate(string) (*entity.Session, string, error)
+}
+
+// Issues opaque, single use refresh tokens.
+//
+// Only the SHA-256 of the tokens is stored. The tokens of a session form a family, identified by the session ID.
+// Every exchange of a token marks it as used and issues a new one in the same family: if a used token is presented
+// again it has been stolen, either by the client or by the attacker, so the whole session is revoked
+type RefreshTokens struct {
+	// Injected via DI
+	RefreshTokenRepository repository.RefreshTokenRepository `inject:""`
+
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+
+	// Injected via DI
+	SessionRevoker SessionRevoker `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewRefreshTokenIssuer() *RefreshTokens {
+	return &RefreshTokens{}
+}
+
+// Issues the first refresh token of the session
+func (r RefreshTokens) Issue(session entity.Session) (string, error) {
+	return r.issue(session)
+}
+
+// Exchanges the refresh token for a new one of the same session. Returns the session and the new token
+func (r RefreshTokens) Rotate(token string) (*entity.Session, string, error) {
+	id := hashToken(token)
+
+	stored, err := r.RefreshTokenRepository.GetById(id)
+	if err == repository.RefreshTokenNotFoundError {
+		return nil, "", InvalidRefreshTokenError
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := r.UserRepository.GetUserById(stored.UserId)
+	if err == repository.UserNotFoundError {
+		return nil, "", InvalidRefreshTokenError
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if stored.Used() {
+		return nil, "", r.reused(*user, stored.Family)
+	}
+
+	if !r.Clock.Now().Before(stored.ExpiresAt) {
+		return nil, "", InvalidRefreshTokenError
+	}
+
+	// The token is revoked when the user's secret changes
+	if subtle.ConstantTimeCompare([]byte(user.Secret), []byte(stored.Secret)) != 1 {
+		return nil, "", InvalidRefreshTokenError
+	}
+
+	session, err := r.SessionRepository.GetById(stored.Family)
+	if err == repository.SessionNotFoundError {
+		return nil, "", InvalidRefreshTokenError
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Another request may have used the token in the meantime
+	_, err = r.RefreshTokenRepository.Use(id)
+	if err == repository.RefreshTokenUsedError {
+		return nil, "", r.reused(*user, stored.Family)
+	}
+	if err == repository.RefreshTokenNotFoundError {
+		return nil, "", InvalidRefreshTokenError
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	next, err := r.issue(*session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return session, next, nil
+}
+
+// Generates and stores a new refresh token of the session
+func (r RefreshTokens) issue(session entity.Session) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := r.Clock.Now().Add(RefreshTokenDuration)
+	_, err = r.RefreshTokenRepository.Create(hashToken(token), session.Id, session.UserId, session.Secret, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Revokes the session after the reuse of one of its tokens
+func (r RefreshTokens) reused(user entity.User, sessionId string) error {
+	if err := r.SessionRevoker.Revoke(user, sessionId); err != nil {
+		// TODO: properly log the error
+		fmt.Println(err.Error())
+	}
+
+	return RefreshTokenReusedError
+}
+
+// Generates a random, URL safe token of 256 bits
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Returns the ID under which the token is stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type RefreshTokensTestSuite struct {
+	suite.Suite
+	issuer            *RefreshTokens
+	clock             clockwork.FakeClock
+	tokenRepository   *mocks.RefreshTokenRepository
+	userRepository    *mocks.UserRepository
+	sessionRepository *mocks.SessionRepository
+	revoker           *mocks.SessionRevoker
+	user              entity.User
+	session           entity.Session
+}
+
+func TestRefreshTokens(t *testing.T) {
+	suite.Run(t, new(RefreshTokensTestSuite))
+}
+
+func (suite *RefreshTokensTestSuite) SetupTest() {
+	suite.clock = clockwork.NewFakeClockAt(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	suite.tokenRepository = &mocks.RefreshTokenRepository{}
+	suite.userRepository = &mocks.UserRepository{}
+	suite.sessionRepository = &mocks.SessionRepository{}
+	suite.revoker = &mocks.SessionRevoker{}
+
+	suite.issuer = NewRefreshTokenIssuer()
+	suite.issuer.RefreshTokenRepository = suite.tokenRepository
+	suite.issuer.UserRepository = suite.userRepository
+	suite.issuer.SessionRepository = suite.sessionRepository
+	suite.issuer.SessionRevoker = suite.revoker
+	suite.issuer.Clock = suite.clock
+
+	suite.user = entity.User{
+		Id:     "userId",
+		Email:  "a@b.com",
+		Secret: "secret",
+	}
+	suite.session = entity.Session{
+		Id:     "sessionId",
+		UserId: "userId",
+		Secret: "secret",
+	}
+}
+
+func (suite *RefreshTokensTestSuite) TearDownTest() {
+	suite.tokenRepository.AssertExpectations(suite.T())
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.sessionRepository.AssertExpectations(suite.T())
+	suite.revoker.AssertExpectations(suite.T())
+}
+
+// Returns the stored state of the token
+func (suite *RefreshTokensTestSuite) stored(token string) *entity.RefreshToken {
+	return &entity.RefreshToken{
+		Id:        hashToken(token),
+		Family:    suite.session.Id,
+		UserId:    suite.user.Id,
+		Secret:    suite.user.Secret,
+		CreatedAt: suite.clock.Now(),
+		ExpiresAt: suite.clock.Now().Add(RefreshTokenDuration),
+	}
+}
+
+func (suite *RefreshTokensTestSuite) TestIssueOK() {
+	var id string
+	expiresAt := suite.clock.Now().Add(RefreshTokenDuration)
+	suite.tokenRepository.On("Create", mock.MatchedBy(func(hash string) bool {
+		id = hash
+		return true
+	}), "sessionId", suite.user.Id, suite.user.Secret, expiresAt).Return(&entity.RefreshToken{}, nil)
+
+	token, err := suite.issuer.Issue(suite.session)
+	suite.Require().NoError(err)
+	suite.Len(token, 43)
+
+	// Only the hash of the token is stored
+	suite.Equal(hashToken(token), id)
+	suite.NotEqual(token, id)
+}
+
+func (suite *RefreshTokensTestSuite) TestIssueAnError() {
+	suite.tokenRepository.On("Create", mock.Anything, "sessionId", suite.user.Id, suite.user.Secret, mock.Anything).Return(nil, assert.AnError)
+
+	_, err := suite.issuer.Issue(suite.session)
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *RefreshTokensTestSuite) TestRotateNotFound() {
+	suite.tokenRepository.On("GetById", hashToken("token")).Return(nil, repository.RefreshTokenNotFoundError)
+
+	_, _, err := suite.issuer.Rotate("token")
+	suite.EqualError(err, InvalidRefreshTokenError.Error())
+}
+
+func (suite *RefreshTokensTestSuite) TestRotateAnError() {
+	suite.tokenRepository.On("GetById", hashToken("token")).Return(nil, assert.AnError)
+
+	_, _, err := suite.issuer.Rotate("token")
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *RefreshTokensTestSuite) TestRotateUserNotFound() {
+	suite.tokenRepository.On("GetById", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(nil, repository.UserNotFoundError)
+
+	_, _, err := suite.issuer.Rotate("token")
+	suite.EqualError(err, InvalidRefreshTokenError.Error())
+}
+
+func (suite *RefreshTokensTestSuite) TestRotateExpired() {
+	suite.tokenRepository.On("GetById", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(&suite.user, nil)
+	suite.clock.Advance(RefreshTokenDuration)
+
+	_, _, err := suite.issuer.Rotate("token")
+	suite.EqualError(err, InvalidRefreshTokenError.Error())
+}
+
+func (suite *RefreshTokensTestSuite) TestRotateSecretChanged() {
+	suite.tokenRepository.On("GetById", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.user.Secret = "changed"
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(&suite.user, nil)
+
+	_, _, err := suite.issuer.Rotate("token")
+	suite.EqualError(err, InvalidRefreshTokenError.Error())
+}
+
+func (suite *RefreshTokensTestSuite) TestRotateSessionRevoked() {
+	suite.tokenRepository.On("GetById", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(&suite.user, nil)
+	suite.sessionRepository.On("GetById", "sessionId").Return(nil, repository.SessionNotFoundError)
+
+	_, _, err := suite.issuer.Rotate("token")
+	suite.EqualError(err, InvalidRefreshTokenError.Error())
+}
+
+func (suite *RefreshTokensTestSuite) TestRotateReused() {
+	stored := suite.stored("token")
+	stored.UsedAt = suite.clock.Now()
+	suite.tokenRepository.On("GetById", hashToken("token")).Return(stored, nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(&suite.user, nil)
+	suite.revoker.On("Revoke", suite.user, "sessionId").Return(nil)
+
+	_, _, err := suite.issuer.Rotate("token")
+	suite.EqualError(err, RefreshTokenReusedError.Error())
+}
+
+func (suite *RefreshTokensTestSuite) TestRotateConcurrentlyUsed() {
+	suite.tokenRepository.On("GetById", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(&suite.user, nil)
+	suite.sessionRepository.On("GetById", "sessionId").Return(&suite.session, nil)
+	suite.tokenRepository.On("Use", hashToken("token")).Return(nil, repository.RefreshTokenUsedError)
+	suite.revoker.On("Revoke", suite.user, "sessionId").Return(nil)
+
+	_, _, err := suite.issuer.Rotate("token")
+	suite.EqualError(err, RefreshTokenReusedError.Error())
+}
+
+func (suite *RefreshTokensTestSuite) TestRotateOK() {
+	suite.tokenRepository.On("GetById", hashToken("token")).Return(suite.stored("token"), nil)
+	suite.userRepository.On("GetUserById", suite.user.Id).Return(&suite.user, nil)
+	suite.sessionRepository.On("GetById", "sessionId").Return(&suite.session, nil)
+	suite.tokenRepository.On("Use", hashToken("token")).Return(&entity.RefreshToken{}, nil)
+
+	var id string
+	suite.tokenRepository.On("Create", mock.MatchedBy(func(hash string) bool {
+		id = hash
+		return true
+	}), "sessionId", suite.user.Id, suite.user.Secret, suite.clock.Now().Add(RefreshTokenDuration)).Return(&entity.RefreshToken{}, nil)
+
+	session, token, err := suite.issuer.Rotate("token")
+	suite.Require().NoError(err)
+	suite.Equal(&suite.session, session)
+	suite.NotEqual("token", token)
+	suite.Equal(hashToken(token), id)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"sync"
+)
+
+// Callbacks of a local subscriber
+type subscriber struct {
+	onMessage func(entity.Message)
+	onEvent   func(entity.Event)
+}
+
+// The Registry routes the messages and the events to the local subscribers, by user email
+type Registry struct {
+	lock        sync.Mutex
+	lastId      int
+	subscribers map[string]map[int]subscriber
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		subscribers: map[string]map[int]subscriber{},
+	}
+}
+
+// Adds a subscriber for the "to" user. Returns the function removing it
+func (r *Registry) Add(to string, onMessage func(entity.Message), onEvent func(entity.Event)) func() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.lastId++
+	id := r.lastId
+
+	if r.subscribers[to] == nil {
+		r.subscribers[to] = map[int]subscriber{}
+	}
+	r.subscribers[to][id] = subscriber{onMessage, onEvent}
+
+	return func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		delete(r.subscribers[to], id)
+		if len(r.subscribers[to]) == 0 {
+			delete(r.subscribers, to)
+		}
+	}
+}
+
+// Returns the number of users having at least a subscriber
+func (r *Registry) Len() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return len(r.subscribers)
+}
+
+// Returns the subscribers of the "to" user. They are called outside of the lock, so that they can add or remove
+// subscribers
+func (r *Registry) get(to string) []subscriber {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var subscribers []subscriber
+	for _, s := range r.subscribers[to] {
+		subscribers = append(subscribers, s)
+	}
+
+	return subscribers
+}
+
+// Calls the subscribers of the "to" user with the message
+func (r *Registry) Dispatch(to string, message entity.Message) {
+	for _, s := range r.get(to) {
+		s.onMessage(message)
+	}
+}
+
+// Calls the subscribers of the "to" user with the event
+func (r *Registry) DispatchEvent(to string, event entity.Event) {
+	for _, s := range r.get(to) {
+		s.onEvent(event)
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type RegistryTestSuite struct {
+	suite.Suite
+	registry *Registry
+}
+
+func TestRegistry(t *testing.T) {
+	suite.Run(t, new(RegistryTestSuite))
+}
+
+func (suite *RegistryTestSuite) SetupTest() {
+	suite.registry = NewRegistry()
+}
+
+func (suite *RegistryTestSuite) TestDispatch() {
+	var received []string
+	remove1 := suite.registry.Add("to1", func(message entity.Message) {
+		received = append(received, "1:"+message.Id)
+	}, func(event entity.Event) {
+		received = append(received, "1:"+event.Type)
+	})
+	remove2 := suite.registry.Add("to1", func(message entity.Message) {
+		received = append(received, "2:"+message.Id)
+	}, func(event entity.Event) {
+		received = append(received, "2:"+event.Type)
+	})
+	suite.Equal(1, suite.registry.Len())
+
+	suite.registry.Dispatch("to1", entity.Message{Id: "id1"})
+	suite.ElementsMatch([]string{"1:id1", "2:id1"}, received)
+
+	received = nil
+	suite.registry.DispatchEvent("to1", entity.Event{Type: "read"})
+	suite.ElementsMatch([]string{"1:read", "2:read"}, received)
+
+	received = nil
+	suite.registry.Dispatch("to2", entity.Message{Id: "id2"})
+	suite.Empty(received)
+
+	remove1()
+	suite.registry.Dispatch("to1", entity.Message{Id: "id3"})
+	suite.Equal([]string{"2:id3"}, received)
+
+	remove2()
+	suite.Equal(0, suite.registry.Len())
+}
+
+func (suite *RegistryTestSuite) TestRemoveFromSubscriber() {
+	calls := 0
+	var remove func()
+	remove = suite.registry.Add("to1", func(message entity.Message) {
+		calls++
+		remove()
+	}, nil)
+
+	suite.registry.Dispatch("to1", entity.Message{})
+	suite.registry.Dispatch("to1", entity.Message{})
+	suite.Equal(1, calls)
+}
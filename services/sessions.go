@@ -0,0 +1,56 @@
+package services
+
+import (
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+)
+
+// Name of the event closing the websockets of a revoked session
+const SessionRevokedEvent = "sessionRevoked"
+
+// Interface used mainly for Unit testing
+type SessionRevoker interface {
+	Revoke(entity.User, string) error
+}
+
+// Revokes the sessions of the users
+type Sessions struct {
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+
+	// Injected via DI
+	RefreshTokenRepository repository.RefreshTokenRepository `inject:""`
+
+	// Injected via DI
+	PubsubClient PubsubClient `inject:""`
+}
+
+func NewSessionRevoker() *Sessions {
+	return &Sessions{}
+}
+
+// Revokes the session of the user: the access and refresh tokens of the session can't be used anymore and its
+// websockets are closed with a sessionRevoked event
+func (s Sessions) Revoke(user entity.User, id string) error {
+	if err := s.SessionRepository.Delete(id); err != nil {
+		return err
+	}
+
+	if err := s.RefreshTokenRepository.DeleteFamily(id); err != nil {
+		return err
+	}
+
+	event, err := entity.NewEvent(SessionRevokedEvent, []string{user.Email}, entity.SessionRevoked{
+		Id: id,
+	})
+	if err == nil {
+		err = s.PubsubClient.PublishEvent(event)
+	}
+	if err != nil {
+		// TODO: properly log the error
+		fmt.Println(err.Error())
+	}
+
+	return nil
+}
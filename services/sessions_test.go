@@ -0,0 +1,77 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+type SessionsTestSuite struct {
+	suite.Suite
+	sessions          *Sessions
+	sessionRepository *mocks.SessionRepository
+	tokenRepository   *mocks.RefreshTokenRepository
+	pubsubClient      *mocks.PubsubClient
+	user              entity.User
+}
+
+func TestSessions(t *testing.T) {
+	suite.Run(t, new(SessionsTestSuite))
+}
+
+func (suite *SessionsTestSuite) SetupTest() {
+	suite.sessionRepository = &mocks.SessionRepository{}
+	suite.tokenRepository = &mocks.RefreshTokenRepository{}
+	suite.pubsubClient = &mocks.PubsubClient{}
+
+	suite.sessions = NewSessionRevoker()
+	suite.sessions.SessionRepository = suite.sessionRepository
+	suite.sessions.RefreshTokenRepository = suite.tokenRepository
+	suite.sessions.PubsubClient = suite.pubsubClient
+
+	suite.user = entity.User{
+		Id:    "userId",
+		Email: "a@b.com",
+	}
+}
+
+func (suite *SessionsTestSuite) TearDownTest() {
+	suite.sessionRepository.AssertExpectations(suite.T())
+	suite.tokenRepository.AssertExpectations(suite.T())
+	suite.pubsubClient.AssertExpectations(suite.T())
+}
+
+func (suite *SessionsTestSuite) TestRevokeDeleteAnError() {
+	suite.sessionRepository.On("Delete", "sessionId").Return(assert.AnError)
+
+	err := suite.sessions.Revoke(suite.user, "sessionId")
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *SessionsTestSuite) TestRevokeDeleteFamilyAnError() {
+	suite.sessionRepository.On("Delete", "sessionId").Return(nil)
+	suite.tokenRepository.On("DeleteFamily", "sessionId").Return(assert.AnError)
+
+	err := suite.sessions.Revoke(suite.user, "sessionId")
+	suite.EqualError(err, assert.AnError.Error())
+}
+
+func (suite *SessionsTestSuite) TestRevokeOK() {
+	suite.sessionRepository.On("Delete", "sessionId").Return(nil)
+	suite.tokenRepository.On("DeleteFamily", "sessionId").Return(nil)
+
+	var published entity.Event
+	suite.pubsubClient.On("PublishEvent", mock.MatchedBy(func(event entity.Event) bool {
+		published = event
+		return true
+	})).Return(nil)
+
+	suite.Require().NoError(suite.sessions.Revoke(suite.user, "sessionId"))
+
+	suite.Equal(SessionRevokedEvent, published.Type)
+	suite.Equal([]string{"a@b.com"}, published.To)
+	suite.JSONEq(`{"id": "sessionId"}`, string(published.Data))
+}
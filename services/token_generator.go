@@ -3,6 +3,7 @@ package services
 import (
 	"crypto/subtle"
 	"errors"
+	"fmt"
 	"github.com/asiragusa/wschat/entity"
 	"github.com/asiragusa/wschat/repository"
 	"github.com/dgrijalva/jwt-go"
@@ -10,6 +11,14 @@ import (
 	"time"
 )
 
+const (
+	// Minimum interval between two updates of the LastUsedAt of a session
+	SessionTouchInterval = time.Minute
+
+	// Audience of the tokens of the pending sessions, only exchanged for an access token with the second factor
+	MfaAudience = "mfa"
+)
+
 var (
 	// Error thrown when the token is invalid
 	InvalidTokenError = errors.New("Invalid Token")
@@ -17,8 +26,8 @@ var (
 
 // Interface used mainly for Unit testing
 type TokenGenerator interface {
-	GenerateToken(entity.User, time.Duration) (string, error)
-	ValidateToken(string) (*entity.User, error)
+	GenerateToken(entity.Session, time.Duration) (string, error)
+	ValidateToken(string) (*entity.User, *entity.Session, error)
 }
 
 // JWT Token generator and validator
@@ -29,68 +38,96 @@ type Generator struct {
 	// Injected via DI
 	UserRepository repository.UserRepository `inject:""`
 
-	signingKey []byte
-	issuer     string
-	audience   string
+	// Injected via DI
+	SessionRepository repository.SessionRepository `inject:""`
+
+	keys     *KeySet
+	issuer   string
+	audience string
 }
 
 // Creates a new JWT Token Generator
-// signingKey is the key used to sign the tokens
+// keys are the keys used to sign and validate the tokens
 // issuer of the token eg. http://localhost
 // audience for the token eg. access, ws etc
-func NewTokenGenerator(signingKey, issuer, audience string) *Generator {
+func NewTokenGenerator(keys *KeySet, issuer, audience string) *Generator {
 	return &Generator{
-		signingKey: []byte(signingKey),
-		issuer:     issuer,
-		audience:   audience,
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
 	}
 }
 
-// Generates a new token for the user with the duration duration
-func (g Generator) GenerateToken(user entity.User, duration time.Duration) (string, error) {
+// Generates a new token for the session with the duration duration
+func (g Generator) GenerateToken(session entity.Session, duration time.Duration) (string, error) {
 	expiresAt := g.Clock.Now().Add(duration)
 
 	claims := &jwt.StandardClaims{
-		Subject:   user.Id,
-		Id:        user.Secret,
+		Subject:   session.UserId,
+		Id:        session.Id,
 		Audience:  g.audience,
 		ExpiresAt: expiresAt.Unix(),
 		Issuer:    g.issuer,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(g.signingKey)
+	return g.keys.Sign(claims)
 }
 
-// Validates the given signed string. Returns the user fetched from the DB if the token is valid
-func (g Generator) ValidateToken(signed string) (*entity.User, error) {
+// Validates the given signed string. Returns the user and the session fetched from the DB if the token is valid
+func (g Generator) ValidateToken(signed string) (*entity.User, *entity.Session, error) {
 	// Parse the signed string
-	token, err := jwt.ParseWithClaims(signed, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return g.signingKey, nil
-	})
+	token, err := g.keys.Parse(signed, &jwt.StandardClaims{})
 	if err != nil {
-		return nil, InvalidTokenError
+		return nil, nil, InvalidTokenError
 	}
 
 	// Get the claims from the token
 	claims, ok := token.Claims.(*jwt.StandardClaims)
 	if !ok || claims.Audience != g.audience || claims.Issuer != g.issuer {
-		return nil, InvalidTokenError
+		return nil, nil, InvalidTokenError
+	}
+
+	// Get the session from the DB. A revoked session doesn't exist anymore
+	session, err := g.SessionRepository.GetById(claims.Id)
+	if err == repository.SessionNotFoundError {
+		return nil, nil, InvalidTokenError
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if session.UserId != claims.Subject {
+		return nil, nil, InvalidTokenError
+	}
+
+	// The tokens of a pending session are accepted only by the mfa audience, and only while the session is pending
+	if session.Pending != (g.audience == MfaAudience) {
+		return nil, nil, InvalidTokenError
 	}
 
 	// Get the user from the DB
 	user, err := g.UserRepository.GetUserById(claims.Subject)
 	if err == repository.UserNotFoundError {
-		return nil, InvalidTokenError
+		return nil, nil, InvalidTokenError
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// Rotating the user's secret invalidates all its sessions
+	if subtle.ConstantTimeCompare([]byte(user.Secret), []byte(session.Secret)) != 1 {
+		return nil, nil, InvalidTokenError
 	}
 
-	// Compare the user's secret with the given claim
-	if subtle.ConstantTimeCompare([]byte(user.Secret), []byte(claims.Id)) != 1 {
-		return nil, InvalidTokenError
+	// Record the usage of the session, at most every SessionTouchInterval
+	if g.Clock.Now().Sub(session.LastUsedAt) >= SessionTouchInterval {
+		if err := g.SessionRepository.Touch(session.Id); err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+		} else {
+			session.LastUsedAt = g.Clock.Now()
+		}
 	}
 
-	return user, nil
+	return user, session, nil
 }
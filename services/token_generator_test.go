@@ -14,9 +14,11 @@ import (
 
 type TokenGeneratorTestSuite struct {
 	suite.Suite
-	TokenGenerator *Generator
-	userRepository mocks.UserRepository
-	keyFunc        jwt.Keyfunc
+	TokenGenerator    *Generator
+	userRepository    mocks.UserRepository
+	sessionRepository mocks.SessionRepository
+	clock             clockwork.FakeClock
+	keyFunc           jwt.Keyfunc
 
 	signingKey string
 	issuer     string
@@ -28,29 +30,70 @@ func (suite *TokenGeneratorTestSuite) SetupSuite() {
 	suite.audience = "access"
 	suite.issuer = "http://localhost"
 
-	suite.TokenGenerator = NewTokenGenerator(suite.signingKey, suite.issuer, suite.audience)
+	keys, err := NewKeySet(NewHmacKey(suite.signingKey))
+	suite.Require().NoError(err)
+
+	suite.TokenGenerator = NewTokenGenerator(keys, suite.issuer, suite.audience)
 	suite.keyFunc = func(token *jwt.Token) (interface{}, error) {
 		return []byte("secret"), nil
 	}
 }
 
 func (suite *TokenGeneratorTestSuite) SetupTest() {
-	suite.TokenGenerator.Clock = clockwork.NewFakeClock()
+	suite.clock = clockwork.NewFakeClock()
+	suite.TokenGenerator.Clock = suite.clock
 
 	suite.userRepository = mocks.UserRepository{}
 	suite.TokenGenerator.UserRepository = &suite.userRepository
+
+	suite.sessionRepository = mocks.SessionRepository{}
+	suite.TokenGenerator.SessionRepository = &suite.sessionRepository
+}
+
+func (suite *TokenGeneratorTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+	suite.sessionRepository.AssertExpectations(suite.T())
+}
+
+// Signs a token with the given claims
+func (suite *TokenGeneratorTestSuite) sign(claims jwt.StandardClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(suite.signingKey))
+	suite.Require().NoError(err)
+
+	return signed
+}
+
+// Returns valid claims for the session "sessionId" of the user "userId"
+func (suite *TokenGeneratorTestSuite) validClaims() jwt.StandardClaims {
+	return jwt.StandardClaims{
+		Audience: suite.audience,
+		Issuer:   suite.issuer,
+		Subject:  "userId",
+		Id:       "sessionId",
+	}
+}
+
+// Returns the session of the valid claims, used just now
+func (suite *TokenGeneratorTestSuite) session() *entity.Session {
+	return &entity.Session{
+		Id:         "sessionId",
+		UserId:     "userId",
+		Secret:     "secret",
+		LastUsedAt: suite.clock.Now(),
+	}
 }
 
 func (suite *TokenGeneratorTestSuite) TestGenerator() {
-	user := entity.User{
-		Id:     "id",
-		Email:  "test@test.com",
+	session := entity.Session{
+		Id:     "sessionId",
+		UserId: "id",
 		Secret: "userSecret",
 	}
 
 	suite.TokenGenerator.Clock = clockwork.NewFakeClockAt(time.Now())
 
-	signed, err := suite.TokenGenerator.GenerateToken(user, time.Hour*24)
+	signed, err := suite.TokenGenerator.GenerateToken(session, time.Hour*24)
 	suite.Require().NoError(err)
 
 	token, err := jwt.ParseWithClaims(signed, &jwt.StandardClaims{}, suite.keyFunc)
@@ -61,8 +104,8 @@ func (suite *TokenGeneratorTestSuite) TestGenerator() {
 
 	suite.Equal(suite.audience, claims.Audience)
 	suite.Equal(suite.issuer, claims.Issuer)
-	suite.Equal(user.Id, claims.Subject)
-	suite.Equal(user.Secret, claims.Id)
+	suite.Equal(session.UserId, claims.Subject)
+	suite.Equal(session.Id, claims.Id)
 
 	now := suite.TokenGenerator.Clock.Now().Add(time.Hour * 24)
 	suite.Equal(now.Unix(), claims.ExpiresAt)
@@ -71,8 +114,9 @@ func (suite *TokenGeneratorTestSuite) TestGenerator() {
 func (suite *TokenGeneratorTestSuite) TestValidateTokenWithInvalidToken() {
 	token := "invalid"
 
-	user, err := suite.TokenGenerator.ValidateToken(token)
+	user, session, err := suite.TokenGenerator.ValidateToken(token)
 	suite.Nil(user)
+	suite.Nil(session)
 	suite.EqualError(err, InvalidTokenError.Error())
 }
 
@@ -92,93 +136,167 @@ func (suite *TokenGeneratorTestSuite) TestValidateTokenWithInvalidClaims() {
 		signed, err := token.SignedString([]byte(suite.signingKey))
 		suite.Require().NoError(err)
 
-		user, err := suite.TokenGenerator.ValidateToken(signed)
+		user, session, err := suite.TokenGenerator.ValidateToken(signed)
 		suite.Nil(user)
+		suite.Nil(session)
 		suite.EqualError(err, InvalidTokenError.Error())
 	}
 }
 
-func (suite *TokenGeneratorTestSuite) TestValidateTokenWithBadUserId() {
-	claims := &jwt.StandardClaims{
-		Audience: suite.audience,
-		Issuer:   suite.issuer,
-		Subject:  "invalid",
-	}
+func (suite *TokenGeneratorTestSuite) TestValidateTokenWithRevokedSession() {
+	signed := suite.sign(suite.validClaims())
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(suite.signingKey))
-	suite.Require().NoError(err)
+	suite.sessionRepository.On("GetById", "sessionId").Return(nil, repository.SessionNotFoundError)
+
+	user, session, err := suite.TokenGenerator.ValidateToken(signed)
+	suite.Nil(user)
+	suite.Nil(session)
+	suite.EqualError(err, InvalidTokenError.Error())
+}
+
+func (suite *TokenGeneratorTestSuite) TestValidateTokenWithSessionRepoError() {
+	signed := suite.sign(suite.validClaims())
+
+	suite.sessionRepository.On("GetById", "sessionId").Return(nil, assert.AnError)
+
+	user, session, err := suite.TokenGenerator.ValidateToken(signed)
+	suite.Nil(user)
+	suite.Nil(session)
+	suite.EqualError(err, assert.AnError.Error())
+}
 
-	suite.userRepository.On("GetUserById", claims.Subject).Return(nil, repository.UserNotFoundError)
+func (suite *TokenGeneratorTestSuite) TestValidateTokenWithSessionOfAnotherUser() {
+	claims := suite.validClaims()
+	claims.Subject = "otherId"
+	signed := suite.sign(claims)
 
-	user, err := suite.TokenGenerator.ValidateToken(signed)
+	suite.sessionRepository.On("GetById", "sessionId").Return(suite.session(), nil)
+
+	user, session, err := suite.TokenGenerator.ValidateToken(signed)
 	suite.Nil(user)
+	suite.Nil(session)
 	suite.EqualError(err, InvalidTokenError.Error())
 }
 
-func (suite *TokenGeneratorTestSuite) TestValidateTokenWithRepoError() {
-	claims := &jwt.StandardClaims{
-		Audience: suite.audience,
-		Issuer:   suite.issuer,
-		Subject:  "userId",
-	}
+func (suite *TokenGeneratorTestSuite) TestValidateTokenWithBadUserId() {
+	signed := suite.sign(suite.validClaims())
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(suite.signingKey))
-	suite.Require().NoError(err)
+	suite.sessionRepository.On("GetById", "sessionId").Return(suite.session(), nil)
+	suite.userRepository.On("GetUserById", "userId").Return(nil, repository.UserNotFoundError)
+
+	user, session, err := suite.TokenGenerator.ValidateToken(signed)
+	suite.Nil(user)
+	suite.Nil(session)
+	suite.EqualError(err, InvalidTokenError.Error())
+}
+
+func (suite *TokenGeneratorTestSuite) TestValidateTokenWithRepoError() {
+	signed := suite.sign(suite.validClaims())
 
-	suite.userRepository.On("GetUserById", claims.Subject).Return(nil, assert.AnError)
+	suite.sessionRepository.On("GetById", "sessionId").Return(suite.session(), nil)
+	suite.userRepository.On("GetUserById", "userId").Return(nil, assert.AnError)
 
-	user, err := suite.TokenGenerator.ValidateToken(signed)
+	user, session, err := suite.TokenGenerator.ValidateToken(signed)
 	suite.Nil(user)
+	suite.Nil(session)
 	suite.EqualError(err, assert.AnError.Error())
 }
 
 func (suite *TokenGeneratorTestSuite) TestValidateTokenWithBadSecret() {
-	claims := jwt.StandardClaims{
-		Audience: suite.audience,
-		Issuer:   suite.issuer,
-		Subject:  "userId",
-		Id:       "badSecret",
-	}
+	signed := suite.sign(suite.validClaims())
 	mockUser := entity.User{
-		Id:     claims.Subject,
-		Secret: "secret",
+		Id:     "userId",
+		Secret: "rotatedSecret",
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
-	signed, err := token.SignedString([]byte(suite.signingKey))
-	suite.Require().NoError(err)
-
+	suite.sessionRepository.On("GetById", "sessionId").Return(suite.session(), nil)
 	suite.userRepository.On("GetUserById", mockUser.Id).Return(&mockUser, nil)
 
-	user, err := suite.TokenGenerator.ValidateToken(signed)
+	user, session, err := suite.TokenGenerator.ValidateToken(signed)
 	suite.Nil(user)
+	suite.Nil(session)
 	suite.EqualError(err, InvalidTokenError.Error())
 }
 
 func (suite *TokenGeneratorTestSuite) TestValidateTokenOK() {
-	claims := jwt.StandardClaims{
-		Audience: suite.audience,
-		Issuer:   suite.issuer,
-		Subject:  "userId",
-		Id:       "secret",
+	signed := suite.sign(suite.validClaims())
+	mockUser := entity.User{
+		Id:     "userId",
+		Secret: "secret",
 	}
+
+	suite.sessionRepository.On("GetById", "sessionId").Return(suite.session(), nil)
+	suite.userRepository.On("GetUserById", mockUser.Id).Return(&mockUser, nil)
+
+	user, session, err := suite.TokenGenerator.ValidateToken(signed)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(user)
+	suite.Equal(user, &mockUser)
+	suite.Equal(suite.session(), session)
+}
+
+func (suite *TokenGeneratorTestSuite) TestValidateTokenTouchesSession() {
+	signed := suite.sign(suite.validClaims())
 	mockUser := entity.User{
-		Id:     claims.Subject,
-		Secret: claims.Id,
+		Id:     "userId",
+		Secret: "secret",
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(suite.signingKey))
+	suite.sessionRepository.On("GetById", "sessionId").Return(suite.session(), nil)
+	suite.userRepository.On("GetUserById", mockUser.Id).Return(&mockUser, nil)
+	suite.sessionRepository.On("Touch", "sessionId").Return(nil)
+
+	suite.clock.Advance(SessionTouchInterval)
+
+	_, session, err := suite.TokenGenerator.ValidateToken(signed)
 	suite.Require().NoError(err)
+	suite.Equal(suite.clock.Now(), session.LastUsedAt)
+}
+
+func (suite *TokenGeneratorTestSuite) TestValidateTokenWithPendingSession() {
+	signed := suite.sign(suite.validClaims())
+	pending := suite.session()
+	pending.Pending = true
+
+	suite.sessionRepository.On("GetById", "sessionId").Return(pending, nil)
+
+	user, session, err := suite.TokenGenerator.ValidateToken(signed)
+	suite.Nil(user)
+	suite.Nil(session)
+	suite.EqualError(err, InvalidTokenError.Error())
+}
+
+func (suite *TokenGeneratorTestSuite) TestValidateMfaToken() {
+	generator := NewTokenGenerator(suite.TokenGenerator.keys, suite.issuer, MfaAudience)
+	generator.Clock = suite.clock
+	generator.UserRepository = &suite.userRepository
+	generator.SessionRepository = &suite.sessionRepository
+
+	claims := suite.validClaims()
+	claims.Audience = MfaAudience
+	signed := suite.sign(claims)
+	mockUser := entity.User{
+		Id:     "userId",
+		Secret: "secret",
+	}
+	pending := suite.session()
+	pending.Pending = true
 
+	suite.sessionRepository.On("GetById", "sessionId").Return(pending, nil).Once()
 	suite.userRepository.On("GetUserById", mockUser.Id).Return(&mockUser, nil)
 
-	user, err := suite.TokenGenerator.ValidateToken(signed)
+	user, session, err := generator.ValidateToken(signed)
 	suite.Require().NoError(err)
-	suite.Require().NotNil(user)
-	suite.Equal(user, &mockUser)
+	suite.Equal(&mockUser, user)
+	suite.Equal(pending, session)
+
+	// Once activated, the session doesn't accept the mfa token anymore
+	suite.sessionRepository.On("GetById", "sessionId").Return(suite.session(), nil).Once()
+
+	user, session, err = generator.ValidateToken(signed)
+	suite.Nil(user)
+	suite.Nil(session)
+	suite.EqualError(err, InvalidTokenError.Error())
 }
 
 func TestConfirmTokenGenerator(t *testing.T) {
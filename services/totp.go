@@ -0,0 +1,192 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Validity of the mfa token returned by the login when the second factor is required
+	MfaTokenDuration = 5 * time.Minute
+
+	// Validity of a TOTP code
+	TotpPeriod = 30 * time.Second
+
+	// Number of digits of a TOTP code
+	TotpDigits = 6
+
+	// Number of periods before and after the current one accepted, to tolerate the clock drift of the device
+	TotpSkew = 1
+
+	// Number of recovery codes generated when the two factor authentication is enabled
+	RecoveryCodeCount = 10
+)
+
+// Base32 without padding, as expected by the authenticator apps
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Interface used mainly for Unit testing
+type TotpAuthenticator interface {
+	NewSecret() (string, error)
+	ProvisioningUri(string, string) string
+	ValidateCode(string, string) (int64, bool)
+	NewRecoveryCodes() ([]string, []string, error)
+	Verify(entity.User, string) (bool, error)
+}
+
+// Time based one time passwords (RFC 6238) used as second factor of the login, and the recovery codes replacing them
+type Totp struct {
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+
+	// Injected via DI
+	UserRepository repository.UserRepository `inject:""`
+
+	issuer string
+}
+
+// Creates a new TOTP authenticator. issuer is the name shown by the authenticator apps
+func NewTotpAuthenticator(issuer string) *Totp {
+	return &Totp{
+		issuer: issuer,
+	}
+}
+
+// Generates a new random TOTP secret, base32 encoded
+func (t Totp) NewSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// Returns the otpauth:// URI of the secret, usually shown as a QR code to the user
+func (t Totp) ProvisioningUri(secret, account string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", t.issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TotpDigits))
+	query.Set("period", fmt.Sprint(int(TotpPeriod.Seconds())))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + t.issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// Returns true if code is the TOTP code of the secret for the current period, or one of the adjacent ones, and the
+// time step of the matching period. The caller records it with UseTotpCounter, so that the code can't be used twice
+func (t Totp) ValidateCode(secret, code string) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != TotpDigits {
+		return 0, false
+	}
+
+	counter := t.Clock.Now().Unix() / int64(TotpPeriod.Seconds())
+	var matched int64
+	valid := false
+	for i := -TotpSkew; i <= TotpSkew; i++ {
+		// Every code is checked to spend the same time whatever the match
+		step := counter + int64(i)
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(step))), []byte(code)) == 1 {
+			matched = step
+			valid = true
+		}
+	}
+
+	return matched, valid
+}
+
+// Generates RecoveryCodeCount recovery codes. Returns the codes, to be shown once to the user, and their hashes
+func (t Totp) NewRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		encoded := strings.ToLower(totpEncoding.EncodeToString(b))
+		codes[i] = encoded[:4] + "-" + encoded[4:]
+		hashes[i] = hashToken(encoded)
+	}
+
+	return codes, hashes, nil
+}
+
+// Verifies the second factor of the user, either a TOTP code or a recovery code. Both can be used only once: the
+// time step of a TOTP code is recorded, refusing the codes of the same or of a previous step, and a recovery code is
+// consumed
+func (t Totp) Verify(user entity.User, code string) (bool, error) {
+	if !user.TotpEnabled() {
+		return false, nil
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == TotpDigits {
+		counter, ok := t.ValidateCode(user.TotpSecret, code)
+		if !ok {
+			return false, nil
+		}
+
+		err := t.UserRepository.UseTotpCounter(user.Id, counter)
+		if err == repository.UserTotpCodeUsedError {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	// Recovery codes are accepted with or without the dash, in any case
+	normalized := strings.ToLower(strings.Replace(code, "-", "", -1))
+	err := t.UserRepository.UseRecoveryCode(user.Id, hashToken(normalized))
+	if err == repository.UserRecoveryCodeNotFoundError {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Computes the HOTP code (RFC 4226) of the key for the counter
+func totpCode(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < TotpDigits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TotpDigits, value%modulo)
+}
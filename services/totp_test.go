@@ -0,0 +1,208 @@
+package services
+
+import (
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/mocks"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Base32 of the secret "12345678901234567890" of the RFC 6238 test vectors
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+type TotpTestSuite struct {
+	suite.Suite
+	authenticator  *Totp
+	clock          clockwork.FakeClock
+	userRepository *mocks.UserRepository
+	user           entity.User
+}
+
+func TestTotp(t *testing.T) {
+	suite.Run(t, new(TotpTestSuite))
+}
+
+func (suite *TotpTestSuite) SetupTest() {
+	suite.clock = clockwork.NewFakeClockAt(time.Unix(1111111109, 0))
+	suite.userRepository = &mocks.UserRepository{}
+
+	suite.authenticator = NewTotpAuthenticator("wschat")
+	suite.authenticator.Clock = suite.clock
+	suite.authenticator.UserRepository = suite.userRepository
+
+	suite.user = entity.User{
+		Id:         "userId",
+		Email:      "a@b.com",
+		TotpSecret: rfcSecret,
+	}
+}
+
+func (suite *TotpTestSuite) TearDownTest() {
+	suite.userRepository.AssertExpectations(suite.T())
+}
+
+func (suite *TotpTestSuite) TestTotpCode() {
+	key, err := totpEncoding.DecodeString(rfcSecret)
+	suite.Require().NoError(err)
+
+	// Last 6 digits of the SHA1 test vectors of RFC 6238
+	suite.Equal("287082", totpCode(key, 59/30))
+	suite.Equal("081804", totpCode(key, 1111111109/30))
+	suite.Equal("005924", totpCode(key, 1234567890/30))
+	suite.Equal("279037", totpCode(key, 2000000000/30))
+}
+
+// Asserts that code is valid for the time step
+func (suite *TotpTestSuite) assertValid(code string, step int64) {
+	counter, ok := suite.authenticator.ValidateCode(rfcSecret, code)
+	suite.True(ok)
+	suite.Equal(step, counter)
+}
+
+// Asserts that code is not valid
+func (suite *TotpTestSuite) assertNotValid(secret, code string) {
+	_, ok := suite.authenticator.ValidateCode(secret, code)
+	suite.False(ok)
+}
+
+func (suite *TotpTestSuite) TestValidateCode() {
+	step := int64(1111111109 / 30)
+
+	suite.assertValid("081804", step)
+	suite.assertNotValid(rfcSecret, "081805")
+	suite.assertNotValid(rfcSecret, "81804")
+	suite.assertNotValid("not base32!", "081804")
+
+	// The adjacent periods are accepted too, the step returned is the one of the code
+	suite.clock.Advance(TotpPeriod)
+	suite.assertValid("081804", step)
+
+	suite.clock.Advance(TotpPeriod)
+	suite.assertNotValid(rfcSecret, "081804")
+}
+
+func (suite *TotpTestSuite) TestNewSecret() {
+	secret, err := suite.authenticator.NewSecret()
+	suite.Require().NoError(err)
+
+	key, err := totpEncoding.DecodeString(secret)
+	suite.Require().NoError(err)
+	suite.Len(key, 20)
+
+	other, err := suite.authenticator.NewSecret()
+	suite.Require().NoError(err)
+	suite.NotEqual(secret, other)
+}
+
+func (suite *TotpTestSuite) TestProvisioningUri() {
+	uri, err := url.Parse(suite.authenticator.ProvisioningUri(rfcSecret, "a@b.com"))
+	suite.Require().NoError(err)
+
+	suite.Equal("otpauth", uri.Scheme)
+	suite.Equal("totp", uri.Host)
+	suite.Equal("/wschat:a@b.com", uri.Path)
+	suite.Equal(rfcSecret, uri.Query().Get("secret"))
+	suite.Equal("wschat", uri.Query().Get("issuer"))
+	suite.Equal("6", uri.Query().Get("digits"))
+	suite.Equal("30", uri.Query().Get("period"))
+}
+
+func (suite *TotpTestSuite) TestNewRecoveryCodes() {
+	codes, hashes, err := suite.authenticator.NewRecoveryCodes()
+	suite.Require().NoError(err)
+	suite.Require().Len(codes, RecoveryCodeCount)
+	suite.Require().Len(hashes, RecoveryCodeCount)
+
+	for i, code := range codes {
+		suite.Regexp("^[a-z2-7]{4}-[a-z2-7]{4}$", code)
+		suite.Equal(hashToken(code[:4]+code[5:]), hashes[i])
+	}
+}
+
+func (suite *TotpTestSuite) TestVerifyTotpCode() {
+	suite.userRepository.On("UseTotpCounter", suite.user.Id, int64(1111111109/30)).Return(nil)
+
+	ok, err := suite.authenticator.Verify(suite.user, "081804")
+	suite.NoError(err)
+	suite.True(ok)
+
+	ok, err = suite.authenticator.Verify(suite.user, "123456")
+	suite.NoError(err)
+	suite.False(ok)
+}
+
+func (suite *TotpTestSuite) TestVerifyTotpCodeReplayed() {
+	step := int64(1111111109 / 30)
+	suite.userRepository.On("UseTotpCounter", suite.user.Id, step).Once().Return(nil)
+
+	ok, err := suite.authenticator.Verify(suite.user, "081804")
+	suite.Require().NoError(err)
+	suite.Require().True(ok)
+
+	// The same code is refused while it's still within the accepted periods
+	suite.userRepository.On("UseTotpCounter", suite.user.Id, step).Return(repository.UserTotpCodeUsedError)
+
+	ok, err = suite.authenticator.Verify(suite.user, "081804")
+	suite.NoError(err)
+	suite.False(ok)
+
+	suite.clock.Advance(TotpPeriod)
+	ok, err = suite.authenticator.Verify(suite.user, "081804")
+	suite.NoError(err)
+	suite.False(ok)
+
+	// The code of the next period is accepted
+	key, err := totpEncoding.DecodeString(rfcSecret)
+	suite.Require().NoError(err)
+	suite.userRepository.On("UseTotpCounter", suite.user.Id, step+1).Return(nil)
+
+	ok, err = suite.authenticator.Verify(suite.user, totpCode(key, uint64(step+1)))
+	suite.NoError(err)
+	suite.True(ok)
+}
+
+func (suite *TotpTestSuite) TestVerifyTotpCodeAnyError() {
+	suite.userRepository.On("UseTotpCounter", suite.user.Id, int64(1111111109/30)).Return(assert.AnError)
+
+	ok, err := suite.authenticator.Verify(suite.user, "081804")
+	suite.Equal(assert.AnError, err)
+	suite.False(ok)
+}
+
+func (suite *TotpTestSuite) TestVerifyDisabled() {
+	suite.user.TotpSecret = ""
+
+	ok, err := suite.authenticator.Verify(suite.user, "081804")
+	suite.NoError(err)
+	suite.False(ok)
+}
+
+func (suite *TotpTestSuite) TestVerifyRecoveryCode() {
+	suite.userRepository.On("UseRecoveryCode", suite.user.Id, hashToken("abcdefgh")).Return(nil)
+
+	ok, err := suite.authenticator.Verify(suite.user, " ABCD-efgh ")
+	suite.NoError(err)
+	suite.True(ok)
+}
+
+func (suite *TotpTestSuite) TestVerifyRecoveryCodeNotFound() {
+	suite.userRepository.On("UseRecoveryCode", suite.user.Id, hashToken("abcdefgh")).
+		Return(repository.UserRecoveryCodeNotFoundError)
+
+	ok, err := suite.authenticator.Verify(suite.user, "abcd-efgh")
+	suite.NoError(err)
+	suite.False(ok)
+}
+
+func (suite *TotpTestSuite) TestVerifyRecoveryCodeAnyError() {
+	suite.userRepository.On("UseRecoveryCode", suite.user.Id, hashToken("abcdefgh")).Return(assert.AnError)
+
+	ok, err := suite.authenticator.Verify(suite.user, "abcd-efgh")
+	suite.Equal(assert.AnError, err)
+	suite.False(ok)
+}
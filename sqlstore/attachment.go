@@ -0,0 +1,115 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+)
+
+// SQL implementation of repository.AttachmentRepository
+type Attachment struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewAttachmentRepository() *Attachment {
+	return &Attachment{}
+}
+
+const attachmentColumns = `a.id, a.owner, a.message_id, a.name, a.mime_type, a.size, a.checksum, a.created_at`
+
+// Fetch an attachment by ID, using either the DB or a transaction
+func (r Attachment) get(db interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, id string) (*entity.Attachment, error) {
+	attachment := &entity.Attachment{}
+	var createdAt int64
+
+	err := db.QueryRow(`SELECT `+attachmentColumns+` FROM attachments a WHERE a.id = ?`, id).Scan(
+		&attachment.Id, &attachment.Owner, &attachment.MessageId, &attachment.Name, &attachment.MimeType,
+		&attachment.Size, &attachment.Checksum, &createdAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, repository.AttachmentNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attachment.CreatedAt = fromTimestamp(createdAt)
+	return attachment, nil
+}
+
+// Fetch an attachment by ID
+func (r Attachment) GetById(id string) (*entity.Attachment, error) {
+	return r.get(r.DB, id)
+}
+
+// Creates a new attachment, not attached to any message
+func (r Attachment) Create(owner, name, mimeType string, size int64, checksum string) (*entity.Attachment, error) {
+	attachment := &entity.Attachment{
+		Id:        uuid.NewV4().String(),
+		Owner:     owner,
+		Name:      name,
+		MimeType:  mimeType,
+		Size:      size,
+		Checksum:  checksum,
+		CreatedAt: r.Clock.Now(),
+	}
+
+	_, err := r.DB.Exec(
+		`INSERT INTO attachments (id, owner, message_id, name, mime_type, size, checksum, created_at)
+		VALUES (?, ?, '', ?, ?, ?, ?, ?)`,
+		attachment.Id, owner, name, mimeType, size, checksum, toTimestamp(attachment.CreatedAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// Attaches the attachment to the message. Attaching it again to the same message is a no-op
+func (r Attachment) Attach(id, messageId string) (*entity.Attachment, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := r.get(tx, id)
+	if err == nil && attachment.MessageId != "" && attachment.MessageId != messageId {
+		err = repository.AttachmentAttachedError
+	}
+	if err == nil {
+		attachment.MessageId = messageId
+		_, err = tx.Exec(`UPDATE attachments SET message_id = ? WHERE id = ?`, messageId, id)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// Detaches the attachment from the message, so that it can be sent again. It is a no-op if the attachment is not
+// attached to the message
+func (r Attachment) Detach(id, messageId string) error {
+	if _, err := r.GetById(id); err != nil {
+		return err
+	}
+
+	_, err := r.DB.Exec(`UPDATE attachments SET message_id = '' WHERE id = ? AND message_id = ?`, id, messageId)
+	return err
+}
+
+// Deletes the attachment. Deleting a missing attachment is a no-op
+func (r Attachment) Delete(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM attachments WHERE id = ?`, id)
+	return err
+}
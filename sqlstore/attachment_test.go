@@ -0,0 +1,20 @@
+package sqlstore
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/repository/repositorytest"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestAttachmentRepository(t *testing.T) {
+	suite.Run(t, &repositorytest.AttachmentSuite{
+		New: func(t *testing.T, clock clockwork.Clock) repository.AttachmentRepository {
+			r := NewAttachmentRepository()
+			r.DB = openTestDB(t)
+			r.Clock = clock
+			return r
+		},
+	})
+}
@@ -0,0 +1,213 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+)
+
+// SQL implementation of repository.ConversationRepository
+type Conversation struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewConversationRepository() *Conversation {
+	return &Conversation{}
+}
+
+const conversationColumns = `c.id, c.name, c.members, c.created_by, c.created_at`
+
+// Scans a row selected with conversationColumns
+func scanConversation(row interface {
+	Scan(...interface{}) error
+}) (*entity.Conversation, error) {
+	conversation := &entity.Conversation{}
+	var members string
+	var createdAt int64
+
+	err := row.Scan(&conversation.Id, &conversation.Name, &members, &conversation.CreatedBy, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation.Members, err = fromList(members); err != nil {
+		return nil, err
+	}
+	conversation.CreatedAt = fromTimestamp(createdAt)
+
+	return conversation, nil
+}
+
+// Fetch a conversation by ID
+func (r Conversation) GetById(id string) (*entity.Conversation, error) {
+	return r.get(r.DB, id)
+}
+
+// Fetch a conversation by ID, using either the DB or a transaction
+func (r Conversation) get(db interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, id string) (*entity.Conversation, error) {
+	row := db.QueryRow(`SELECT `+conversationColumns+` FROM conversations c WHERE c.id = ?`, id)
+
+	conversation, err := scanConversation(row)
+	if err == sql.ErrNoRows {
+		return nil, repository.ConversationNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return conversation, nil
+}
+
+// Fetch all the conversations the user is a member of, sorted by CreatedAt
+func (r Conversation) AllWithMember(email string) ([]entity.Conversation, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+conversationColumns+` FROM conversation_members cm JOIN conversations c ON c.id = cm.conversation_id
+		WHERE cm.email = ? ORDER BY c.created_at, c.id`,
+		email,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []entity.Conversation{}
+	for rows.Next() {
+		conversation, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, *conversation)
+	}
+
+	return conversations, rows.Err()
+}
+
+// Stores the members of the conversation, in the conversation and in the lookup table
+func (r Conversation) putMembers(tx *sql.Tx, conversation *entity.Conversation) error {
+	members, err := toList(conversation.Members)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE conversations SET members = ? WHERE id = ?`, members, conversation.Id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM conversation_members WHERE conversation_id = ?`, conversation.Id); err != nil {
+		return err
+	}
+
+	for _, member := range conversation.Members {
+		_, err := tx.Exec(
+			`INSERT INTO conversation_members (email, conversation_id) VALUES (?, ?)`,
+			member, conversation.Id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Creates a new conversation. The creator is always added to the members
+func (r Conversation) Create(name, createdBy string, members []string) (*entity.Conversation, error) {
+	conversation := &entity.Conversation{
+		Id:        uuid.NewV4().String(),
+		Name:      name,
+		Members:   uniqueMembers(append([]string{createdBy}, members...)),
+		CreatedBy: createdBy,
+		CreatedAt: r.Clock.Now(),
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO conversations (id, name, members, created_by, created_at) VALUES (?, ?, '[]', ?, ?)`,
+		conversation.Id, conversation.Name, conversation.CreatedBy, toTimestamp(conversation.CreatedAt),
+	)
+	if err == nil {
+		err = r.putMembers(tx, conversation)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+
+	return conversation, nil
+}
+
+// Adds a member to the conversation. Adding an existing member is a no-op
+func (r Conversation) AddMember(id, email string) (*entity.Conversation, error) {
+	return r.update(id, func(tx *sql.Tx, conversation *entity.Conversation) error {
+		conversation.Members = uniqueMembers(append(conversation.Members, email))
+		return r.putMembers(tx, conversation)
+	})
+}
+
+// Removes a member from the conversation. Removing a non member is a no-op
+func (r Conversation) RemoveMember(id, email string) (*entity.Conversation, error) {
+	return r.update(id, func(tx *sql.Tx, conversation *entity.Conversation) error {
+		members := []string{}
+		for _, member := range conversation.Members {
+			if member != email {
+				members = append(members, member)
+			}
+		}
+		conversation.Members = members
+		return r.putMembers(tx, conversation)
+	})
+}
+
+// Renames the conversation
+func (r Conversation) Rename(id, name string) (*entity.Conversation, error) {
+	return r.update(id, func(tx *sql.Tx, conversation *entity.Conversation) error {
+		conversation.Name = name
+		_, err := tx.Exec(`UPDATE conversations SET name = ? WHERE id = ?`, name, id)
+		return err
+	})
+}
+
+// Applies fn to the conversation in a transaction
+func (r Conversation) update(id string, fn func(*sql.Tx, *entity.Conversation) error) (*entity.Conversation, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	conversation, err := r.get(tx, id)
+	if err == nil {
+		err = fn(tx, conversation)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+
+	return conversation, nil
+}
+
+// Removes the duplicates from the members list, preserving the order
+func uniqueMembers(members []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, member := range members {
+		if seen[member] {
+			continue
+		}
+		seen[member] = true
+		unique = append(unique, member)
+	}
+	return unique
+}
@@ -0,0 +1,82 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/jonboulle/clockwork"
+)
+
+// SQL implementation of repository.DeliveryRepository
+type Delivery struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewDeliveryRepository() *Delivery {
+	return &Delivery{}
+}
+
+// Returns at most limit deliveries to the "to" user having a sequence number greater than seq, sorted by Seq
+func (r Delivery) AllAfter(to string, seq int64, limit int) ([]entity.Delivery, error) {
+	rows, err := r.DB.Query(
+		`SELECT to_user, seq, message_id, created_at FROM deliveries WHERE to_user = ? AND seq > ? ORDER BY seq LIMIT ?`,
+		to, seq, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []entity.Delivery{}
+	for rows.Next() {
+		var delivery entity.Delivery
+		var createdAt int64
+		if err := rows.Scan(&delivery.To, &delivery.Seq, &delivery.MessageId, &createdAt); err != nil {
+			return nil, err
+		}
+		delivery.Id = fmt.Sprintf("%s/%d", delivery.To, delivery.Seq)
+		delivery.CreatedAt = fromTimestamp(createdAt)
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// Records the delivery of a message to the "to" user, allocating the next sequence number of the user
+func (r Delivery) Create(to, messageId string) (*entity.Delivery, error) {
+	delivery := &entity.Delivery{
+		To:        to,
+		MessageId: messageId,
+		CreatedAt: r.Clock.Now(),
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO sequences (id, value) VALUES (?, 1) ON CONFLICT (id) DO UPDATE SET value = value + 1`,
+		to,
+	)
+	if err == nil {
+		err = tx.QueryRow(`SELECT value FROM sequences WHERE id = ?`, to).Scan(&delivery.Seq)
+	}
+	if err == nil {
+		_, err = tx.Exec(
+			`INSERT INTO deliveries (to_user, seq, message_id, created_at) VALUES (?, ?, ?, ?)`,
+			delivery.To, delivery.Seq, delivery.MessageId, toTimestamp(delivery.CreatedAt),
+		)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+	delivery.Id = fmt.Sprintf("%s/%d", delivery.To, delivery.Seq)
+
+	return delivery, nil
+}
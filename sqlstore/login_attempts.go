@@ -0,0 +1,80 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+// SQL implementation of repository.LoginAttemptRepository
+type LoginAttempts struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewLoginAttemptRepository() *LoginAttempts {
+	return &LoginAttempts{}
+}
+
+// Scans the failed login attempts of a key
+func scanLoginAttempts(row *sql.Row) (*entity.LoginAttempts, error) {
+	attempts := &entity.LoginAttempts{}
+	var lastFailureAt int64
+
+	err := row.Scan(&attempts.Key, &attempts.Failures, &lastFailureAt)
+	if err == sql.ErrNoRows {
+		return nil, repository.LoginAttemptsNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attempts.LastFailureAt = fromTimestamp(lastFailureAt)
+	return attempts, nil
+}
+
+// Fetches the failed login attempts of the key
+func (r LoginAttempts) Get(key string) (*entity.LoginAttempts, error) {
+	return scanLoginAttempts(r.DB.QueryRow(`SELECT id, failures, last_failure_at FROM login_attempts WHERE id = ?`, key))
+}
+
+// Counts a failed login attempt of the key. The count restarts if the last failure happened before since
+func (r LoginAttempts) RecordFailure(key string, since time.Time) (*entity.LoginAttempts, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := scanLoginAttempts(
+		tx.QueryRow(`SELECT id, failures, last_failure_at FROM login_attempts WHERE id = ?`, key),
+	)
+	if err == repository.LoginAttemptsNotFoundError || (err == nil && attempts.LastFailureAt.Before(since)) {
+		attempts, err = &entity.LoginAttempts{Key: key}, nil
+	}
+	if err == nil {
+		attempts.Failures++
+		attempts.LastFailureAt = r.Clock.Now()
+
+		_, err = tx.Exec(
+			`INSERT OR REPLACE INTO login_attempts (id, failures, last_failure_at) VALUES (?, ?, ?)`,
+			key, attempts.Failures, toTimestamp(attempts.LastFailureAt),
+		)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// Forgets the failed login attempts of the key
+func (r LoginAttempts) Reset(key string) error {
+	_, err := r.DB.Exec(`DELETE FROM login_attempts WHERE id = ?`, key)
+	return err
+}
@@ -0,0 +1,637 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+	"strconv"
+	"strings"
+)
+
+// SQL implementation of repository.MessageRepository
+type Message struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewMessageRepository() *Message {
+	return &Message{}
+}
+
+const messageColumns = `m.id, m.conversation_id, m.from_user, m.to_user, m.message, m.users, m.created_at, m.reads,
+	m.edited_at, m.revisions, m.deleted_at, m.reply_to, m.reply_from, m.reply_snippet,
+	m.reactions, m.attachments`
+
+// Scans a row selected with messageColumns
+func scanMessage(row interface {
+	Scan(...interface{}) error
+}) (*entity.Message, error) {
+	message := &entity.Message{}
+	var users, reads, revisions, reactions, attachments string
+	var createdAt, editedAt, deletedAt int64
+
+	err := row.Scan(
+		&message.Id, &message.ConversationId, &message.From, &message.To, &message.Message, &users, &createdAt,
+		&reads, &editedAt, &revisions, &deletedAt, &message.ReplyTo.MessageId, &message.ReplyTo.From,
+		&message.ReplyTo.Snippet, &reactions, &attachments,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.Users, err = fromList(users); err != nil {
+		return nil, err
+	}
+	message.CreatedAt = fromTimestamp(createdAt)
+
+	if err := json.Unmarshal([]byte(reads), &message.Reads); err != nil {
+		return nil, err
+	}
+	if len(message.Reads) == 0 {
+		message.Reads = nil
+	}
+	for i := range message.Reads {
+		message.Reads[i].ReadAt = message.Reads[i].ReadAt.UTC()
+	}
+
+	if editedAt != 0 {
+		message.EditedAt = fromTimestamp(editedAt)
+	}
+	if err := json.Unmarshal([]byte(revisions), &message.Revisions); err != nil {
+		return nil, err
+	}
+	if len(message.Revisions) == 0 {
+		message.Revisions = nil
+	}
+	for i := range message.Revisions {
+		message.Revisions[i].CreatedAt = message.Revisions[i].CreatedAt.UTC()
+	}
+
+	if deletedAt != 0 {
+		message.DeletedAt = fromTimestamp(deletedAt)
+	}
+
+	if err := json.Unmarshal([]byte(reactions), &message.Reactions); err != nil {
+		return nil, err
+	}
+	if len(message.Reactions) == 0 {
+		message.Reactions = nil
+	}
+
+	if err := json.Unmarshal([]byte(attachments), &message.Attachments); err != nil {
+		return nil, err
+	}
+	if len(message.Attachments) == 0 {
+		message.Attachments = nil
+	}
+	for i := range message.Attachments {
+		message.Attachments[i].CreatedAt = message.Attachments[i].CreatedAt.UTC()
+	}
+
+	// The terms are stored in message_terms, it's cheaper to compute them again
+	message.Index()
+
+	return message, nil
+}
+
+// Runs the query and scans the messages
+func (r Message) query(query string, args ...interface{}) ([]entity.Message, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []entity.Message{}
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *message)
+	}
+
+	return messages, rows.Err()
+}
+
+// Fetch a message by ID
+func (r Message) GetById(id string) (*entity.Message, error) {
+	row := r.DB.QueryRow(`SELECT `+messageColumns+` FROM messages m WHERE m.id = ?`, id)
+
+	message, err := scanMessage(row)
+	if err == sql.ErrNoRows {
+		return nil, repository.MessageNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// Fetch the messages by ID, in the same order. The messages not found are skipped
+func (r Message) GetByIds(ids []string) ([]entity.Message, error) {
+	if len(ids) == 0 {
+		return []entity.Message{}, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.Repeat(", ?", len(ids))[2:]
+
+	messages, err := r.query(`SELECT `+messageColumns+` FROM messages m WHERE m.id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]entity.Message{}
+	for _, message := range messages {
+		found[message.Id] = message
+	}
+
+	messages = []entity.Message{}
+	for _, id := range ids {
+		if message, ok := found[id]; ok {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+// Fetch all messages belonging to an user
+func (r Message) AllWithUser(email string) ([]entity.Message, error) {
+	return r.query(
+		`SELECT `+messageColumns+` FROM message_users u JOIN messages m ON m.id = u.message_id
+		WHERE u.email = ? ORDER BY u.created_at, u.message_id`,
+		email,
+	)
+}
+
+// Position in the messages sorted from the newest to the oldest, following the message having the given fields
+type cursor struct {
+	createdAt int64
+	id        string
+}
+
+func encodeCursor(message entity.Message) string {
+	encoded := fmt.Sprintf("%d/%s", toTimestamp(message.CreatedAt), message.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(encoded))
+}
+
+func decodeCursor(encoded string) (*cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, repository.InvalidCursorError
+	}
+
+	parts := strings.SplitN(string(decoded), "/", 2)
+	if len(parts) != 2 {
+		return nil, repository.InvalidCursorError
+	}
+
+	createdAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, repository.InvalidCursorError
+	}
+
+	return &cursor{createdAt, parts[1]}, nil
+}
+
+// Fetch a page of the messages belonging to an user, with the same semantics of repository.Message.PageWithUser
+func (r Message) PageWithUser(email string, options repository.MessagePageOptions) (*repository.MessagePage, error) {
+	query := `SELECT ` + messageColumns + ` FROM message_users u JOIN messages m ON m.id = u.message_id
+		WHERE u.email = ?`
+	args := []interface{}{email}
+
+	if options.With != "" {
+		query += ` AND m.conversation_id = '' AND EXISTS (
+			SELECT 1 FROM message_users w WHERE w.message_id = u.message_id AND w.email = ?
+		)`
+		args = append(args, options.With)
+	}
+	if options.ConversationId != "" {
+		query += ` AND m.conversation_id = ?`
+		args = append(args, options.ConversationId)
+	}
+
+	page := &repository.MessagePage{}
+
+	if options.After != "" {
+		after, err := decodeCursor(options.After)
+		if err != nil {
+			return nil, err
+		}
+
+		// The messages newer than the cursor, including the message the cursor follows, from the oldest
+		query += ` AND (u.created_at > ? OR (u.created_at = ? AND u.message_id >= ?))
+			ORDER BY u.created_at, u.message_id LIMIT ?`
+		args = append(args, after.createdAt, after.createdAt, after.id, options.Limit+1)
+
+		messages, err := r.query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		page.NextCursor = options.After
+		if len(messages) > options.Limit {
+			page.PrevCursor = encodeCursor(messages[options.Limit])
+			messages = messages[:options.Limit]
+		}
+		page.Messages = messages
+
+		return page, nil
+	}
+
+	if options.Before != "" {
+		before, err := decodeCursor(options.Before)
+		if err != nil {
+			return nil, err
+		}
+
+		query += ` AND (u.created_at < ? OR (u.created_at = ? AND u.message_id < ?))`
+		args = append(args, before.createdAt, before.createdAt, before.id)
+
+		page.PrevCursor = options.Before
+	}
+
+	// Fetch one more message to know if there is a next page
+	query += ` ORDER BY u.created_at DESC, u.message_id DESC LIMIT ?`
+	args = append(args, options.Limit+1)
+
+	messages, err := r.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(messages) > options.Limit {
+		messages = messages[:options.Limit]
+		page.NextCursor = encodeCursor(messages[len(messages)-1])
+	}
+
+	// The messages have been fetched from the newest, return them in chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	page.Messages = messages
+
+	return page, nil
+}
+
+// Search the messages belonging to an user containing all the terms, the most relevant first. Only the
+// repository.MaxSearchCandidates newest matches are ranked
+func (r Message) Search(email string, options repository.SearchOptions) ([]repository.SearchResult, error) {
+	if len(options.Terms) == 0 {
+		return []repository.SearchResult{}, nil
+	}
+
+	args := []interface{}{email}
+	for _, term := range options.Terms {
+		args = append(args, term)
+	}
+	args = append(args, len(options.Terms))
+	placeholders := strings.TrimPrefix(strings.Repeat(", ?", len(options.Terms)), ", ")
+
+	filters := ""
+	if options.With != "" {
+		filters += ` AND m.conversation_id = '' AND EXISTS (
+			SELECT 1 FROM message_users w WHERE w.message_id = m.id AND w.email = ?
+		)`
+		args = append(args, options.With)
+	}
+	if !options.Since.IsZero() {
+		filters += ` AND m.created_at >= ?`
+		args = append(args, toTimestamp(options.Since))
+	}
+	if !options.Until.IsZero() {
+		filters += ` AND m.created_at < ?`
+		args = append(args, toTimestamp(options.Until))
+	}
+	args = append(args, repository.MaxSearchCandidates)
+
+	messages, err := r.query(
+		`SELECT `+messageColumns+` FROM message_users u JOIN messages m ON m.id = u.message_id
+		WHERE u.email = ? AND m.id IN (
+			SELECT message_id FROM message_terms WHERE term IN (`+placeholders+`)
+			GROUP BY message_id HAVING COUNT(*) = ?
+		)`+filters+`
+		ORDER BY m.created_at DESC, m.id DESC LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.Rank(messages, options), nil
+}
+
+// Returns the emails of the users having exchanged direct messages with the user, sorted
+func (r Message) Contacts(email string) ([]string, error) {
+	rows, err := r.DB.Query(`SELECT contact FROM contacts WHERE owner = ? ORDER BY contact`, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []string{}
+	for rows.Next() {
+		var contact string
+		if err := rows.Scan(&contact); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	return contacts, rows.Err()
+}
+
+// Records the users of a direct message as contacts of each other
+func addContacts(tx *sql.Tx, message *entity.Message) error {
+	if message.ConversationId != "" || message.From == message.To {
+		return nil
+	}
+
+	_, err := tx.Exec(
+		`INSERT OR IGNORE INTO contacts (owner, contact) VALUES (?, ?), (?, ?)`,
+		message.From, message.To, message.To, message.From,
+	)
+	return err
+}
+
+// Replaces the terms of the message in the search index
+func indexMessage(tx *sql.Tx, message *entity.Message) error {
+	if _, err := tx.Exec(`DELETE FROM message_terms WHERE message_id = ?`, message.Id); err != nil {
+		return err
+	}
+
+	for _, term := range message.Terms {
+		if _, err := tx.Exec(`INSERT INTO message_terms (term, message_id) VALUES (?, ?)`, term, message.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Indexes the terms of all the messages
+func indexMessages(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, message FROM messages`)
+	if err != nil {
+		return err
+	}
+
+	// The rows are read before writing the index, the transaction holds a single connection
+	messages := []entity.Message{}
+	for rows.Next() {
+		var message entity.Message
+		if err := rows.Scan(&message.Id, &message.Message); err != nil {
+			rows.Close()
+			return err
+		}
+		message.Index()
+		messages = append(messages, message)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range messages {
+		if err := indexMessage(tx, &messages[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stores a new message and its users
+func (r Message) create(message *entity.Message) (*entity.Message, error) {
+	message.Id = uuid.NewV4().String()
+	message.CreatedAt = r.Clock.Now()
+	message.Index()
+
+	users, err := toList(message.Users)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO messages (id, conversation_id, from_user, to_user, message, users, created_at, reply_to,
+			reply_from, reply_snippet)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		message.Id, message.ConversationId, message.From, message.To, message.Message, users,
+		toTimestamp(message.CreatedAt), message.ReplyTo.MessageId, message.ReplyTo.From, message.ReplyTo.Snippet,
+	)
+	for _, user := range message.Users {
+		if err != nil {
+			break
+		}
+		_, err = tx.Exec(
+			`INSERT OR IGNORE INTO message_users (email, message_id, created_at) VALUES (?, ?, ?)`,
+			user, message.Id, toTimestamp(message.CreatedAt),
+		)
+	}
+	if err == nil {
+		err = indexMessage(tx, message)
+	}
+	if err == nil {
+		err = addContacts(tx, message)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// Creates a new message
+func (r Message) Create(from, to, message string) (*entity.Message, error) {
+	return r.create(&entity.Message{
+		From:    from,
+		To:      to,
+		Message: message,
+		Users:   []string{from, to},
+	})
+}
+
+// Creates a new message replying to another one
+func (r Message) CreateReply(from, to, message string, replyTo entity.Reply) (*entity.Message, error) {
+	return r.create(&entity.Message{
+		From:    from,
+		To:      to,
+		Message: message,
+		Users:   []string{from, to},
+		ReplyTo: replyTo,
+	})
+}
+
+// Creates a new message in a conversation. members are the conversation members at the time of sending
+func (r Message) CreateInConversation(from, conversationId string, members []string, message string) (*entity.Message, error) {
+	return r.create(&entity.Message{
+		ConversationId: conversationId,
+		From:           from,
+		Message:        message,
+		Users:          members,
+	})
+}
+
+// Marks the message as read by the user, at the current time. Marking an already read message is a no-op
+func (r Message) MarkRead(id, email string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		if message.ReadBy(email) == nil {
+			message.Reads = append(message.Reads, entity.Receipt{
+				User:   email,
+				ReadAt: r.Clock.Now(),
+			})
+		}
+		return nil
+	})
+}
+
+// Replaces the text of the message, keeping the prior one as a revision
+func (r Message) Edit(id, text string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.Edit(text, r.Clock.Now())
+		return nil
+	})
+}
+
+// Adds the attachments to the message
+func (r Message) Attach(id string, attachments []entity.Attachment) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.Attachments = append(message.Attachments, attachments...)
+		return nil
+	})
+}
+
+// Adds the reaction of the user with the emoji. Adding it twice is a no-op
+func (r Message) AddReaction(id, email, emoji string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.AddReaction(email, emoji)
+		return nil
+	})
+}
+
+// Removes the reaction of the user with the emoji, if any
+func (r Message) RemoveReaction(id, email, emoji string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.RemoveReaction(email, emoji)
+		return nil
+	})
+}
+
+// Removes the user from the message, hiding it from the user's history
+func (r Message) DeleteFor(id, email string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.DeleteFor(email)
+		return nil
+	})
+}
+
+// Deletes the message for everyone, leaving a tombstone in the history of its users
+func (r Message) DeleteForEveryone(id string) (*entity.Message, error) {
+	return r.update(id, func(message *entity.Message) error {
+		message.Delete(r.Clock.Now())
+		return nil
+	})
+}
+
+// Deletes the message, undoing its creation before it is published. Deleting a missing message is a no-op.
+// Its users and terms are deleted in cascade
+func (r Message) Delete(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM messages WHERE id = ?`, id)
+	return err
+}
+
+// Applies fn to the message in a transaction and stores its mutable fields. The users removed from the message lose
+// it from their history
+func (r Message) update(id string, fn func(*entity.Message) error) (*entity.Message, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := scanMessage(tx.QueryRow(`SELECT `+messageColumns+` FROM messages m WHERE m.id = ?`, id))
+	if err == sql.ErrNoRows {
+		err = repository.MessageNotFoundError
+	}
+
+	var text string
+	if err == nil {
+		text = message.Message
+		err = fn(message)
+	}
+
+	var users string
+	var reads, revisions, reactions, attachments []byte
+	if err == nil {
+		users, err = toList(message.Users)
+	}
+	if err == nil {
+		reads, err = json.Marshal(append([]entity.Receipt{}, message.Reads...))
+	}
+	if err == nil {
+		revisions, err = json.Marshal(append([]entity.Revision{}, message.Revisions...))
+	}
+	if err == nil {
+		reactions, err = json.Marshal(append([]entity.Reaction{}, message.Reactions...))
+	}
+	if err == nil {
+		attachments, err = json.Marshal(append([]entity.Attachment{}, message.Attachments...))
+	}
+	if err == nil {
+		var editedAt, deletedAt int64
+		if !message.EditedAt.IsZero() {
+			editedAt = toTimestamp(message.EditedAt)
+		}
+		if !message.DeletedAt.IsZero() {
+			deletedAt = toTimestamp(message.DeletedAt)
+		}
+
+		_, err = tx.Exec(
+			`UPDATE messages SET message = ?, users = ?, reads = ?, edited_at = ?, revisions = ?, deleted_at = ?,
+			reactions = ?, attachments = ? WHERE id = ?`,
+			message.Message, users, string(reads), editedAt, string(revisions), deletedAt, string(reactions),
+			string(attachments), id,
+		)
+	}
+	if err == nil {
+		args := []interface{}{id}
+		for _, user := range message.Users {
+			args = append(args, user)
+		}
+		placeholders := strings.TrimPrefix(strings.Repeat(", ?", len(message.Users)), ", ")
+
+		// SQLite accepts an empty list
+		_, err = tx.Exec(
+			`DELETE FROM message_users WHERE message_id = ? AND email NOT IN (`+placeholders+`)`,
+			args...,
+		)
+	}
+	if err == nil && message.Message != text {
+		err = indexMessage(tx, message)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
@@ -0,0 +1,294 @@
+package sqlstore
+
+import (
+	"database/sql"
+)
+
+// Schema migrations, applied in order. Never modify an existing migration, append a new one instead
+var migrations = []string{
+	// 1: users, messages and subscriptions
+	`
+	CREATE TABLE users (
+		id TEXT NOT NULL PRIMARY KEY,
+		email TEXT NOT NULL UNIQUE,
+		password TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE messages (
+		id TEXT NOT NULL PRIMARY KEY,
+		conversation_id TEXT NOT NULL DEFAULT '',
+		from_user TEXT NOT NULL,
+		to_user TEXT NOT NULL,
+		message TEXT NOT NULL,
+		users TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	-- One row per user belonging to a message, used by the per user queries
+	CREATE TABLE message_users (
+		email TEXT NOT NULL,
+		message_id TEXT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (email, message_id)
+	);
+	CREATE INDEX message_users_email_created_at ON message_users (email, created_at, message_id);
+	CREATE INDEX message_users_message_id ON message_users (message_id);
+
+	CREATE TABLE subscriptions (
+		id TEXT NOT NULL PRIMARY KEY,
+		to_user TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX subscriptions_to_user ON subscriptions (to_user);
+	`,
+
+	// 2: conversations and deliveries
+	`
+	CREATE TABLE conversations (
+		id TEXT NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		members TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	-- One row per conversation member, used to find the conversations of an user
+	CREATE TABLE conversation_members (
+		email TEXT NOT NULL,
+		conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+		PRIMARY KEY (email, conversation_id)
+	);
+
+	CREATE INDEX messages_conversation_id ON messages (conversation_id, created_at);
+
+	CREATE TABLE sequences (
+		id TEXT NOT NULL PRIMARY KEY,
+		value INTEGER NOT NULL
+	);
+
+	CREATE TABLE deliveries (
+		to_user TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		message_id TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (to_user, seq)
+	);
+	`,
+
+	// 3: subscriptions heartbeat
+	`
+	ALTER TABLE subscriptions ADD COLUMN last_seen_at INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX subscriptions_last_seen_at ON subscriptions (last_seen_at);
+	`,
+
+	// 4: read receipts
+	`
+	ALTER TABLE messages ADD COLUMN reads TEXT NOT NULL DEFAULT '[]';
+	`,
+
+	// 5: presence
+	`
+	ALTER TABLE users ADD COLUMN last_seen_at INTEGER NOT NULL DEFAULT 0;
+	`,
+
+	// 6: message edits
+	`
+	ALTER TABLE messages ADD COLUMN edited_at INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE messages ADD COLUMN revisions TEXT NOT NULL DEFAULT '[]';
+	`,
+
+	// 7: message deletion
+	`
+	ALTER TABLE messages ADD COLUMN deleted_at INTEGER NOT NULL DEFAULT 0;
+	`,
+
+	// 8: replies
+	`
+	ALTER TABLE messages ADD COLUMN reply_to TEXT NOT NULL DEFAULT '';
+	ALTER TABLE messages ADD COLUMN reply_from TEXT NOT NULL DEFAULT '';
+	ALTER TABLE messages ADD COLUMN reply_snippet TEXT NOT NULL DEFAULT '';
+	`,
+
+	// 9: reactions
+	`
+	ALTER TABLE messages ADD COLUMN reactions TEXT NOT NULL DEFAULT '[]';
+	`,
+
+	// 10: attachments
+	`
+	CREATE TABLE attachments (
+		id TEXT NOT NULL PRIMARY KEY,
+		owner TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		mime_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		checksum TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	ALTER TABLE messages ADD COLUMN attachments TEXT NOT NULL DEFAULT '[]';
+	`,
+
+	// 11: search index, one row per distinct term of the text of every message
+	`
+	CREATE TABLE message_terms (
+		term TEXT NOT NULL,
+		message_id TEXT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+		PRIMARY KEY (term, message_id)
+	);
+
+	CREATE INDEX message_terms_message_id ON message_terms (message_id);
+	`,
+
+	// 12: refresh tokens
+	`
+	CREATE TABLE refresh_tokens (
+		id TEXT NOT NULL PRIMARY KEY,
+		family TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		used_at INTEGER NOT NULL
+	);
+	CREATE INDEX refresh_tokens_family ON refresh_tokens (family);
+	`,
+
+	// 13: sessions
+	`
+	CREATE TABLE sessions (
+		id TEXT NOT NULL PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		device TEXT NOT NULL,
+		ip TEXT NOT NULL,
+		user_agent TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		last_used_at INTEGER NOT NULL
+	);
+	CREATE INDEX sessions_user_id ON sessions (user_id);
+	`,
+
+	// 14: password reset tokens
+	`
+	CREATE TABLE password_resets (
+		id TEXT NOT NULL PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL
+	);
+	`,
+
+	// 15: two factor authentication
+	`
+	ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+	ALTER TABLE users ADD COLUMN pending_totp_secret TEXT NOT NULL DEFAULT '';
+	ALTER TABLE users ADD COLUMN recovery_codes TEXT NOT NULL DEFAULT '[]';
+	ALTER TABLE sessions ADD COLUMN pending INTEGER NOT NULL DEFAULT 0;
+	`,
+
+	// 16: failed login attempts
+	`
+	CREATE TABLE login_attempts (
+		id TEXT NOT NULL PRIMARY KEY,
+		failures INTEGER NOT NULL,
+		last_failure_at INTEGER NOT NULL
+	);
+	`,
+
+	// 17: email verification. The users registered so far are considered verified
+	`
+	ALTER TABLE users ADD COLUMN email_verified INTEGER NOT NULL DEFAULT 0;
+	UPDATE users SET email_verified = 1;
+	`,
+
+	// 18: contacts, the users having exchanged direct messages. Filled from the messages sent so far
+	`
+	CREATE TABLE contacts (
+		owner TEXT NOT NULL,
+		contact TEXT NOT NULL,
+		PRIMARY KEY (owner, contact)
+	);
+
+	INSERT OR IGNORE INTO contacts (owner, contact)
+	SELECT o.email, c.email FROM messages m
+	JOIN message_users o ON o.message_id = m.id
+	JOIN message_users c ON c.message_id = m.id AND c.email != o.email
+	WHERE m.conversation_id = '';
+	`,
+
+	// 19: search index of the messages sent before it was introduced. The index is rebuilt by indexMessages
+	`
+	DELETE FROM message_terms;
+	`,
+
+	// 20: time step of the last TOTP code accepted, refusing the replayed codes
+	`
+	ALTER TABLE users ADD COLUMN totp_counter INTEGER NOT NULL DEFAULT 0;
+	`,
+
+	// 21: OpenID Connect flows, kept on the server until the callback
+	`
+	CREATE TABLE oidc_flows (
+		id TEXT NOT NULL PRIMARY KEY,
+		flow_token TEXT NOT NULL,
+		nonce TEXT NOT NULL,
+		verifier TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL
+	);
+	`,
+}
+
+// Steps run after the SQL of a migration, in the same transaction, for the changes SQL can't express.
+// Keyed by the version of the migration
+var backfills = map[int]func(*sql.Tx) error{
+	19: indexMessages,
+}
+
+// Applies the missing migrations. The current version is stored in the schema_version table
+func Migrate(db *sql.DB) error {
+	return migrateTo(db, len(migrations))
+}
+
+// Applies the missing migrations up to the target version
+func migrateTo(db *sql.DB, target int) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+
+	var version int
+	err := db.QueryRow(`SELECT version FROM schema_version`).Scan(&version)
+	if err == sql.ErrNoRows {
+		if _, err := db.Exec(`INSERT INTO schema_version (version) VALUES (0)`); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	for ; version < target; version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(migrations[version])
+		if backfill := backfills[version+1]; err == nil && backfill != nil {
+			err = backfill(tx)
+		}
+		if err == nil {
+			_, err = tx.Exec(`UPDATE schema_version SET version = ?`, version+1)
+		}
+
+		if err := endTx(tx, err); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+// SQL implementation of repository.OidcFlowRepository
+type OidcFlow struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewOidcFlowRepository() *OidcFlow {
+	return &OidcFlow{}
+}
+
+// Stores a new OpenID Connect flow
+func (r OidcFlow) Create(id, flowToken, nonce, verifier string, expiresAt time.Time) (*entity.OidcFlow, error) {
+	flow := &entity.OidcFlow{
+		Id:        id,
+		FlowToken: flowToken,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		CreatedAt: r.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	_, err := r.DB.Exec(
+		`INSERT INTO oidc_flows (id, flow_token, nonce, verifier, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		id, flowToken, nonce, verifier, toTimestamp(flow.CreatedAt), toTimestamp(expiresAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return flow, nil
+}
+
+// Deletes the OpenID Connect flow and returns it, so that it can be completed only once
+func (r OidcFlow) Consume(id string) (*entity.OidcFlow, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	flow := &entity.OidcFlow{}
+	var createdAt, expiresAt int64
+
+	err = tx.QueryRow(
+		`SELECT id, flow_token, nonce, verifier, created_at, expires_at FROM oidc_flows WHERE id = ?`, id,
+	).Scan(&flow.Id, &flow.FlowToken, &flow.Nonce, &flow.Verifier, &createdAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		err = repository.OidcFlowNotFoundError
+	}
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM oidc_flows WHERE id = ?`, id)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+
+	flow.CreatedAt = fromTimestamp(createdAt)
+	flow.ExpiresAt = fromTimestamp(expiresAt)
+	return flow, nil
+}
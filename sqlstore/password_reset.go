@@ -0,0 +1,72 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+// SQL implementation of repository.PasswordResetRepository
+type PasswordReset struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewPasswordResetRepository() *PasswordReset {
+	return &PasswordReset{}
+}
+
+// Stores a new password reset token of the user
+func (r PasswordReset) Create(id, userId, secret string, expiresAt time.Time) (*entity.PasswordReset, error) {
+	reset := &entity.PasswordReset{
+		Id:        id,
+		UserId:    userId,
+		Secret:    secret,
+		CreatedAt: r.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	_, err := r.DB.Exec(
+		`INSERT INTO password_resets (id, user_id, secret, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		id, userId, secret, toTimestamp(reset.CreatedAt), toTimestamp(expiresAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return reset, nil
+}
+
+// Deletes the password reset token and returns it, so that it can be used only once
+func (r PasswordReset) Consume(id string) (*entity.PasswordReset, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	reset := &entity.PasswordReset{}
+	var createdAt, expiresAt int64
+
+	err = tx.QueryRow(
+		`SELECT id, user_id, secret, created_at, expires_at FROM password_resets WHERE id = ?`, id,
+	).Scan(&reset.Id, &reset.UserId, &reset.Secret, &createdAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		err = repository.PasswordResetNotFoundError
+	}
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM password_resets WHERE id = ?`, id)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+
+	reset.CreatedAt = fromTimestamp(createdAt)
+	reset.ExpiresAt = fromTimestamp(expiresAt)
+	return reset, nil
+}
@@ -0,0 +1,20 @@
+package sqlstore
+
+import (
+	"github.com/asiragusa/wschat/repository"
+	"github.com/asiragusa/wschat/repository/repositorytest"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestPasswordResetRepository(t *testing.T) {
+	suite.Run(t, &repositorytest.PasswordResetSuite{
+		New: func(t *testing.T, clock clockwork.Clock) repository.PasswordResetRepository {
+			r := NewPasswordResetRepository()
+			r.DB = openTestDB(t)
+			r.Clock = clock
+			return r
+		},
+	})
+}
@@ -0,0 +1,110 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"time"
+)
+
+// SQL implementation of repository.RefreshTokenRepository
+type RefreshToken struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewRefreshTokenRepository() *RefreshToken {
+	return &RefreshToken{}
+}
+
+const refreshTokenColumns = `t.id, t.family, t.user_id, t.secret, t.created_at, t.expires_at, t.used_at`
+
+// Fetch a refresh token by ID, using either the DB or a transaction
+func (r RefreshToken) get(db interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, id string) (*entity.RefreshToken, error) {
+	token := &entity.RefreshToken{}
+	var createdAt, expiresAt, usedAt int64
+
+	err := db.QueryRow(`SELECT `+refreshTokenColumns+` FROM refresh_tokens t WHERE t.id = ?`, id).Scan(
+		&token.Id, &token.Family, &token.UserId, &token.Secret, &createdAt, &expiresAt, &usedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, repository.RefreshTokenNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token.CreatedAt = fromTimestamp(createdAt)
+	token.ExpiresAt = fromTimestamp(expiresAt)
+	if usedAt != 0 {
+		token.UsedAt = fromTimestamp(usedAt)
+	}
+	return token, nil
+}
+
+// Fetch a refresh token by ID
+func (r RefreshToken) GetById(id string) (*entity.RefreshToken, error) {
+	return r.get(r.DB, id)
+}
+
+// Stores a new refresh token of the user. The token starts a new family if family is empty
+func (r RefreshToken) Create(id, family, userId, secret string, expiresAt time.Time) (*entity.RefreshToken, error) {
+	if family == "" {
+		family = id
+	}
+
+	token := &entity.RefreshToken{
+		Id:        id,
+		Family:    family,
+		UserId:    userId,
+		Secret:    secret,
+		CreatedAt: r.Clock.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	_, err := r.DB.Exec(
+		`INSERT INTO refresh_tokens (id, family, user_id, secret, created_at, expires_at, used_at)
+		VALUES (?, ?, ?, ?, ?, ?, 0)`,
+		id, family, userId, secret, toTimestamp(token.CreatedAt), toTimestamp(expiresAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Marks the refresh token as used, at the current time. Returns RefreshTokenUsedError if it has already been used
+func (r RefreshToken) Use(id string) (*entity.RefreshToken, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := r.get(tx, id)
+	if err == nil && token.Used() {
+		err = repository.RefreshTokenUsedError
+	}
+	if err == nil {
+		token.UsedAt = r.Clock.Now()
+		_, err = tx.Exec(`UPDATE refresh_tokens SET used_at = ? WHERE id = ?`, toTimestamp(token.UsedAt), id)
+	}
+
+	if err := endTx(tx, err); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Deletes all the tokens of the family
+func (r RefreshToken) DeleteFamily(family string) error {
+	_, err := r.DB.Exec(`DELETE FROM refresh_tokens WHERE family = ?`, family)
+	return err
+}
@@ -0,0 +1,148 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/satori/go.uuid"
+)
+
+// SQL implementation of repository.SessionRepository
+type Session struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewSessionRepository() *Session {
+	return &Session{}
+}
+
+const sessionColumns = `id, user_id, secret, device, ip, user_agent, created_at, last_used_at, pending`
+
+// Scans a session from a row
+func scanSession(row interface {
+	Scan(...interface{}) error
+}) (*entity.Session, error) {
+	session := &entity.Session{}
+	var createdAt, lastUsedAt int64
+	err := row.Scan(
+		&session.Id, &session.UserId, &session.Secret, &session.Device, &session.Ip, &session.UserAgent,
+		&createdAt, &lastUsedAt, &session.Pending,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	session.CreatedAt = fromTimestamp(createdAt)
+	session.LastUsedAt = fromTimestamp(lastUsedAt)
+	return session, nil
+}
+
+// Fetch a session by ID
+func (r Session) GetById(id string) (*entity.Session, error) {
+	session, err := scanSession(r.DB.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.SessionNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Returns all the sessions of the user, the most recently used first
+func (r Session) AllOf(userId string) ([]entity.Session, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+sessionColumns+` FROM sessions WHERE user_id = ? ORDER BY last_used_at DESC, id`,
+		userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []entity.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Opens a new session of the user. A pending session waits for the second factor of the login
+func (r Session) Create(userId, secret, device, ip, userAgent string, pending bool) (*entity.Session, error) {
+	now := r.Clock.Now()
+	session := &entity.Session{
+		Id:         uuid.NewV4().String(),
+		UserId:     userId,
+		Secret:     secret,
+		Device:     device,
+		Ip:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		Pending:    pending,
+	}
+
+	_, err := r.DB.Exec(
+		`INSERT INTO sessions (`+sessionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.Id, session.UserId, session.Secret, session.Device, session.Ip, session.UserAgent,
+		toTimestamp(session.CreatedAt), toTimestamp(session.LastUsedAt), session.Pending,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Sets the LastUsedAt of the session to now
+func (r Session) Touch(id string) error {
+	result, err := r.DB.Exec(`UPDATE sessions SET last_used_at = ? WHERE id = ?`, toTimestamp(r.Clock.Now()), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.SessionNotFoundError
+	}
+
+	return nil
+}
+
+// Marks a pending session as active, once the second factor of the login has been verified
+func (r Session) Activate(id string) error {
+	result, err := r.DB.Exec(`UPDATE sessions SET pending = 0 WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.SessionNotFoundError
+	}
+
+	return nil
+}
+
+// Deletes a session, by ID
+func (r Session) Delete(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM sessions WHERE id = ?`, id)
+	return err
+}
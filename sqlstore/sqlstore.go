@@ -0,0 +1,63 @@
+// The package sqlstore contains a SQL implementation of the repositories, based on database/sql.
+//
+// SQLite is supported via github.com/mattn/go-sqlite3. The schema is created and updated by Migrate
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	_ "github.com/mattn/go-sqlite3"
+	"time"
+)
+
+// Opens the SQLite database at path and migrates it to the last schema version
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=1&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite serializes the writes, a single connection avoids "database is locked" errors.
+	// It also keeps the same database when path is :memory:
+	db.SetMaxOpenConns(1)
+
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Times are stored as unix nanoseconds, to keep the ordering exact
+func toTimestamp(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+func fromTimestamp(ts int64) time.Time {
+	return time.Unix(0, ts).UTC()
+}
+
+// String lists are stored as JSON arrays. The lookup tables are used for searching
+func toList(list []string) (string, error) {
+	if list == nil {
+		list = []string{}
+	}
+	encoded, err := json.Marshal(list)
+	return string(encoded), err
+}
+
+func fromList(encoded string) ([]string, error) {
+	var list []string
+	err := json.Unmarshal([]byte(encoded), &list)
+	return list, err
+}
+
+// Rollbacks tx if err is not nil, commits it otherwise
+func endTx(tx *sql.Tx, err error) error {
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
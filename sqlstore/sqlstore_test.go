@@ -0,0 +1,82 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// Opens a new in-memory database, migrated up to version
+func openTestDBAt(t *testing.T, version int) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:?_foreign_keys=1")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+
+	require.NoError(t, migrateTo(db, version))
+
+	return db
+}
+
+// Opens a new in-memory database
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := Open(":memory:")
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+
+	require.NoError(t, Migrate(db))
+
+	var version int
+	require.NoError(t, db.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version))
+	require.Equal(t, len(migrations), version)
+}
+
+func TestMigrateContacts(t *testing.T) {
+	db := openTestDBAt(t, 17)
+
+	_, err := db.Exec(`
+		INSERT INTO messages (id, conversation_id, from_user, to_user, message, users, created_at)
+		VALUES ('m1', '', 'a', 'b', 'txt', '["a","b"]', 1), ('m2', 'c1', 'a', '', 'txt', '["a","c"]', 2);
+		INSERT INTO message_users (email, message_id, created_at)
+		VALUES ('a', 'm1', 1), ('b', 'm1', 1), ('a', 'm2', 2), ('c', 'm2', 2);
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(db))
+
+	messages := NewMessageRepository()
+	messages.DB = db
+
+	for email, expected := range map[string][]string{"a": {"b"}, "b": {"a"}, "c": {}} {
+		contacts, err := messages.Contacts(email)
+		require.NoError(t, err)
+		require.Equal(t, expected, contacts, email)
+	}
+}
+
+func TestMigrateSearchIndex(t *testing.T) {
+	db := openTestDBAt(t, 18)
+
+	// A message sent before the search was introduced
+	_, err := db.Exec(`
+		INSERT INTO messages (id, conversation_id, from_user, to_user, message, users, created_at)
+		VALUES ('m1', '', 'a', 'b', 'Hello world', '["a","b"]', 1);
+		INSERT INTO message_users (email, message_id, created_at) VALUES ('a', 'm1', 1), ('b', 'm1', 1);
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(db))
+
+	messages := NewMessageRepository()
+	messages.DB = db
+
+	results, err := messages.Search("b", repository.SearchOptions{Terms: []string{"world"}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "m1", results[0].Message.Id)
+}
@@ -0,0 +1,123 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/mattn/go-sqlite3"
+	"time"
+)
+
+// SQL implementation of repository.SubscriptionRepository
+type Subscription struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewSubscriptionRepository() *Subscription {
+	return &Subscription{}
+}
+
+// Runs the query and scans the subscriptions
+func (r Subscription) query(query string, args ...interface{}) ([]entity.Subscription, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []entity.Subscription{}
+	for rows.Next() {
+		var subscription entity.Subscription
+		var createdAt, lastSeenAt int64
+		if err := rows.Scan(&subscription.Id, &subscription.To, &createdAt, &lastSeenAt); err != nil {
+			return nil, err
+		}
+		subscription.CreatedAt = fromTimestamp(createdAt)
+		subscription.LastSeenAt = fromTimestamp(lastSeenAt)
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// Returns all the subscriptions belonging the "to" user
+func (r Subscription) AllTo(to string) ([]entity.Subscription, error) {
+	return r.query(
+		`SELECT id, to_user, created_at, last_seen_at FROM subscriptions WHERE to_user = ? ORDER BY id`,
+		to,
+	)
+}
+
+// Returns all the subscriptions not seen since before, sorted by LastSeenAt
+func (r Subscription) AllStale(before time.Time) ([]entity.Subscription, error) {
+	return r.query(
+		`SELECT id, to_user, created_at, last_seen_at FROM subscriptions WHERE last_seen_at < ?
+		ORDER BY last_seen_at, id`,
+		toTimestamp(before),
+	)
+}
+
+// Returns all the subscriptions seen after since, sorted by Id
+func (r Subscription) AllLive(since time.Time) ([]entity.Subscription, error) {
+	return r.query(
+		`SELECT id, to_user, created_at, last_seen_at FROM subscriptions WHERE last_seen_at > ? ORDER BY id`,
+		toTimestamp(since),
+	)
+}
+
+// Creates a new subscription for the to user.
+// The caller is responsible for the uniqueness of the ID (eg. using an UUID generator)
+func (r Subscription) Create(id, to string) (*entity.Subscription, error) {
+	now := r.Clock.Now()
+	subscription := &entity.Subscription{
+		Id:         id,
+		To:         to,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	_, err := r.DB.Exec(
+		`INSERT INTO subscriptions (id, to_user, created_at, last_seen_at) VALUES (?, ?, ?, ?)`,
+		subscription.Id, subscription.To, toTimestamp(subscription.CreatedAt), toTimestamp(subscription.LastSeenAt),
+	)
+	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.Code == sqlite3.ErrConstraint {
+		return nil, repository.SubscriptionAlreadyExistsError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// Sets the LastSeenAt of the subscription to now
+func (r Subscription) Touch(id string) error {
+	result, err := r.DB.Exec(
+		`UPDATE subscriptions SET last_seen_at = ? WHERE id = ?`,
+		toTimestamp(r.Clock.Now()), id,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.SubscriptionNotFoundError
+	}
+
+	return nil
+}
+
+// Deletes a subscription, by ID
+func (r Subscription) Delete(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM subscriptions WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,316 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/repository"
+	"github.com/jonboulle/clockwork"
+	"github.com/mattn/go-sqlite3"
+	"github.com/satori/go.uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SQL implementation of repository.UserRepository
+type User struct {
+	// Injected via DI
+	DB *sql.DB `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+}
+
+func NewUserRepository() *User {
+	return &User{}
+}
+
+const userColumns = `id, email, password, secret, created_at, last_seen_at, totp_secret, pending_totp_secret,
+	recovery_codes, email_verified, totp_counter`
+
+// Scans a row selected with userColumns
+func scanUser(row interface {
+	Scan(...interface{}) error
+}) (*entity.User, error) {
+	user := &entity.User{}
+	var createdAt, lastSeenAt int64
+	var recoveryCodes string
+
+	err := row.Scan(
+		&user.Id, &user.Email, &user.Password, &user.Secret, &createdAt, &lastSeenAt,
+		&user.TotpSecret, &user.PendingTotpSecret, &recoveryCodes, &user.EmailVerified, &user.TotpCounter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if recoveryCodes != "[]" {
+		if user.RecoveryCodes, err = fromList(recoveryCodes); err != nil {
+			return nil, err
+		}
+	}
+	user.CreatedAt = fromTimestamp(createdAt)
+	if lastSeenAt != 0 {
+		user.LastSeenAt = fromTimestamp(lastSeenAt)
+	}
+
+	return user, nil
+}
+
+// Fetches a single user
+func (r User) get(where string, arg interface{}) (*entity.User, error) {
+	row := r.DB.QueryRow(`SELECT `+userColumns+` FROM users WHERE `+where, arg)
+
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return nil, repository.UserNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Fetches an user by ID
+func (r User) GetUserById(id string) (*entity.User, error) {
+	return r.get(`id = ?`, id)
+}
+
+// Fetches an user by Email
+func (r User) GetUserByEmail(email string) (*entity.User, error) {
+	return r.get(`email = ?`, email)
+}
+
+// Creates a new user, given its email and password.
+// The uniqueness of the email is enforced by the database
+func (r User) CreateUser(email string, password string) (*entity.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &entity.User{
+		Id:        uuid.NewV4().String(),
+		Email:     email,
+		Password:  string(hash),
+		Secret:    uuid.NewV4().String(),
+		CreatedAt: r.Clock.Now(),
+	}
+
+	_, err = r.DB.Exec(
+		`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, 0, '', '', '[]', 0, 0)`,
+		user.Id, user.Email, user.Password, user.Secret, toTimestamp(user.CreatedAt),
+	)
+	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return nil, repository.UserAlreadyExistsError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Creates a new user without password, whose email has been verified by an identity provider.
+// The user can't log in with a password until it resets it
+func (r User) CreateExternalUser(email string) (*entity.User, error) {
+	user := &entity.User{
+		Id:            uuid.NewV4().String(),
+		Email:         email,
+		Secret:        uuid.NewV4().String(),
+		CreatedAt:     r.Clock.Now(),
+		EmailVerified: true,
+	}
+
+	_, err := r.DB.Exec(
+		`INSERT INTO users (`+userColumns+`) VALUES (?, ?, '', ?, ?, 0, '', '', '[]', 1, 0)`,
+		user.Id, user.Email, user.Secret, toTimestamp(user.CreatedAt),
+	)
+	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return nil, repository.UserAlreadyExistsError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Logs in an user by email and password
+func (r User) Login(email, password string) (*entity.User, error) {
+	user, err := r.GetUserByEmail(email)
+	if err == repository.UserNotFoundError {
+		return nil, repository.UserBadUsernameOrPasswordError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if err != nil {
+		return nil, repository.UserBadUsernameOrPasswordError
+	}
+
+	return user, nil
+}
+
+// Fetches all the users, sorted by email
+func (r User) All() ([]entity.User, error) {
+	rows, err := r.DB.Query(`SELECT ` + userColumns + ` FROM users ORDER BY email`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []entity.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+// Sets the LastSeenAt of the user to now
+func (r User) Touch(id string) error {
+	result, err := r.DB.Exec(`UPDATE users SET last_seen_at = ? WHERE id = ?`, toTimestamp(r.Clock.Now()), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.UserNotFoundError
+	}
+
+	return nil
+}
+
+// Replaces the secret of the user with a new random one, invalidating all the tokens issued to the user
+func (r User) RotateSecret(id string) (*entity.User, error) {
+	return r.update(id, `secret = ?`, uuid.NewV4().String())
+}
+
+// Replaces the password of the user, hashing the new one with bcrypt
+func (r User) UpdatePassword(id string, password string) (*entity.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.update(id, `password = ?`, string(hash))
+}
+
+// Stores the TOTP secret being enrolled, until it's confirmed by EnableTotp
+func (r User) SetPendingTotpSecret(id string, secret string) (*entity.User, error) {
+	return r.update(id, `pending_totp_secret = ?`, secret)
+}
+
+// Enables the two factor authentication with the given TOTP secret and the hashes of the recovery codes
+func (r User) EnableTotp(id string, secret string, recoveryCodes []string) (*entity.User, error) {
+	codes, err := toList(recoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.update(id, `totp_secret = ?, pending_totp_secret = '', recovery_codes = ?`, secret, codes)
+}
+
+// Disables the two factor authentication, removing the TOTP secret, the recovery codes and the last time step used
+func (r User) DisableTotp(id string) (*entity.User, error) {
+	return r.update(id, `totp_secret = '', pending_totp_secret = '', recovery_codes = '[]', totp_counter = 0`)
+}
+
+// Records the time step of an accepted TOTP code. Returns UserTotpCodeUsedError if it isn't newer than the last one
+func (r User) UseTotpCounter(id string, counter int64) error {
+	result, err := r.DB.Exec(
+		`UPDATE users SET totp_counter = ? WHERE id = ? AND totp_counter < ?`, counter, id, counter,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	// Tell an unknown user from a replayed code
+	if _, err := r.GetUserById(id); err != nil {
+		return err
+	}
+	return repository.UserTotpCodeUsedError
+}
+
+// Removes the recovery code with the given hash, so it can't be used twice
+func (r User) UseRecoveryCode(id string, hash string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	return endTx(tx, r.removeRecoveryCode(tx, id, hash))
+}
+
+// Marks the email of the user as verified
+func (r User) SetEmailVerified(id string) (*entity.User, error) {
+	return r.update(id, `email_verified = 1`)
+}
+
+// Removes the recovery code from the list stored in the user's row
+func (r User) removeRecoveryCode(tx *sql.Tx, id string, hash string) error {
+	var encoded string
+	err := tx.QueryRow(`SELECT recovery_codes FROM users WHERE id = ?`, id).Scan(&encoded)
+	if err == sql.ErrNoRows {
+		return repository.UserNotFoundError
+	}
+	if err != nil {
+		return err
+	}
+
+	codes, err := fromList(encoded)
+	if err != nil {
+		return err
+	}
+
+	remaining := []string{}
+	for _, code := range codes {
+		if code != hash {
+			remaining = append(remaining, code)
+		}
+	}
+	if len(remaining) == len(codes) {
+		return repository.UserRecoveryCodeNotFoundError
+	}
+
+	if encoded, err = toList(remaining); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE users SET recovery_codes = ? WHERE id = ?`, encoded, id)
+	return err
+}
+
+// Sets the given columns of the user and returns the updated user
+func (r User) update(id string, set string, args ...interface{}) (*entity.User, error) {
+	result, err := r.DB.Exec(`UPDATE users SET `+set+` WHERE id = ?`, append(args, id)...)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, repository.UserNotFoundError
+	}
+
+	return r.GetUserById(id)
+}
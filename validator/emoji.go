@@ -0,0 +1,108 @@
+package validator
+
+const (
+	zwj                  = 0x200D
+	variationSelector    = 0xFE0F
+	combiningKeycap      = 0x20E3
+	blackFlag            = 0x1F3F4
+	cancelTag            = 0xE007F
+	regionalIndicatorMin = 0x1F1E6
+	regionalIndicatorMax = 0x1F1FF
+	skinToneMin          = 0x1F3FB
+	skinToneMax          = 0x1F3FF
+)
+
+// Ranges of the pictographic code points which can be presented as emoji
+var pictographs = [][2]rune{
+	{0x00A9, 0x00A9}, {0x00AE, 0x00AE}, {0x203C, 0x203C}, {0x2049, 0x2049}, {0x2122, 0x2122},
+	{0x2139, 0x2139}, {0x2194, 0x2199}, {0x21A9, 0x21AA}, {0x231A, 0x231B}, {0x2328, 0x2328},
+	{0x23CF, 0x23CF}, {0x23E9, 0x23F3}, {0x23F8, 0x23FA}, {0x24C2, 0x24C2}, {0x25AA, 0x25AB},
+	{0x25B6, 0x25B6}, {0x25C0, 0x25C0}, {0x25FB, 0x25FE}, {0x2600, 0x27BF}, {0x2934, 0x2935},
+	{0x2B05, 0x2B07}, {0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x3030, 0x3030},
+	{0x303D, 0x303D}, {0x3297, 0x3297}, {0x3299, 0x3299}, {0x1F000, 0x1F1E5}, {0x1F200, 0x1F3FA},
+	{0x1F400, 0x1FAFF},
+}
+
+func isPictograph(r rune) bool {
+	for _, p := range pictographs {
+		if r >= p[0] && r <= p[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if s is a single emoji: a pictograph with its optional presentation selector and skin tone, a flag,
+// a keycap, or a sequence of them joined with ZWJ
+func IsEmoji(s string) bool {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return false
+	}
+
+	for i := 0; ; i++ {
+		n := emojiElement(runes[i:])
+		if n == 0 {
+			return false
+		}
+		i += n
+		if i == len(runes) {
+			return true
+		}
+		if runes[i] != zwj {
+			return false
+		}
+	}
+}
+
+// Returns the length of the emoji starting the runes, 0 if they don't start with an emoji
+func emojiElement(runes []rune) int {
+	if len(runes) == 0 {
+		return 0
+	}
+	r := runes[0]
+
+	// Flags are pairs of regional indicators
+	if r >= regionalIndicatorMin && r <= regionalIndicatorMax {
+		if len(runes) >= 2 && runes[1] >= regionalIndicatorMin && runes[1] <= regionalIndicatorMax {
+			return 2
+		}
+		return 0
+	}
+
+	// Keycaps, eg. 1️⃣
+	if (r >= '0' && r <= '9') || r == '#' || r == '*' {
+		n := 1
+		if n < len(runes) && runes[n] == variationSelector {
+			n++
+		}
+		if n < len(runes) && runes[n] == combiningKeycap {
+			return n + 1
+		}
+		return 0
+	}
+
+	if !isPictograph(r) {
+		return 0
+	}
+	n := 1
+
+	// Subdivision flags, a black flag followed by tags
+	if r == blackFlag && n < len(runes) && runes[n] >= 0xE0020 && runes[n] <= 0xE007E {
+		for n < len(runes) && runes[n] >= 0xE0020 && runes[n] <= 0xE007E {
+			n++
+		}
+		if n < len(runes) && runes[n] == cancelTag {
+			return n + 1
+		}
+		return 0
+	}
+
+	if n < len(runes) && runes[n] == variationSelector {
+		n++
+	} else if n < len(runes) && runes[n] >= skinToneMin && runes[n] <= skinToneMax {
+		n++
+	}
+
+	return n
+}
@@ -9,6 +9,19 @@ import (
 	"strings"
 )
 
+// Maximum size in bytes of an attachment
+const MaxAttachmentSize = 10 << 20
+
+// MIME types accepted for the attachments
+var AttachmentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"text/plain",
+}
+
 // Interface used mainly for Unit testing
 type RequestValidator interface {
 	Struct(interface{}) error
@@ -32,6 +45,26 @@ func NewValidator() *Validator {
 		return jsonTag
 	})
 
+	// The attachments are limited in size and type
+	v.RegisterValidation("attachmentSize", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int() <= MaxAttachmentSize
+	})
+	v.RegisterValidation("attachmentType", func(fl validator.FieldLevel) bool {
+		// Ignore the parameters, eg. "text/plain; charset=utf-8"
+		mimeType := strings.TrimSpace(strings.Split(fl.Field().String(), ";")[0])
+		for _, t := range AttachmentTypes {
+			if mimeType == t {
+				return true
+			}
+		}
+		return false
+	})
+
+	// The reactions are single emojis
+	v.RegisterValidation("emoji", func(fl validator.FieldLevel) bool {
+		return IsEmoji(fl.Field().String())
+	})
+
 	return &Validator{
 		validator: v,
 	}
@@ -64,3 +64,59 @@ func (suite *ValidatorTestSuite) TestFormatError() {
 	res2 := suite.validator.FormatError(&validator.InvalidValidationError{})
 	suite.Equal(response.NewError(httptest.StatusInternalServerError), res2)
 }
+
+type AttachmentTest struct {
+	Size     int64  `json:"size" validate:"attachmentSize"`
+	MimeType string `json:"mimeType" validate:"attachmentType"`
+}
+
+func (suite *ValidatorTestSuite) TestAttachmentOK() {
+	err := suite.validator.Struct(&AttachmentTest{
+		Size:     MaxAttachmentSize,
+		MimeType: "text/plain; charset=utf-8",
+	})
+	suite.NoError(err)
+}
+
+func (suite *ValidatorTestSuite) TestAttachmentInvalid() {
+	err := suite.validator.Struct(&AttachmentTest{
+		Size:     MaxAttachmentSize + 1,
+		MimeType: "application/x-msdownload",
+	})
+	suite.Require().NotNil(err)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("size", "attachmentSize")
+	expected.AddDetail("mimeType", "attachmentType")
+
+	suite.Equal(expected, suite.validator.FormatError(err))
+}
+
+func (suite *ValidatorTestSuite) TestIsEmoji() {
+	for _, emoji := range []string{
+		"👍", "🎉", "❤", "❤️", "👍🏽", "🇮🇹", "1️⃣", "#⃣", "👩‍💻", "👨‍👩‍👧‍👦", "🏳️‍🌈", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", "©️",
+	} {
+		suite.True(IsEmoji(emoji), emoji)
+	}
+
+	for _, text := range []string{
+		"", "a", "ok", "1", "<b>", "👍👍", "👍 ", " 👍", "👍a", "\x00", "\u200d", "👍\u200d", "\u200d👍",
+		"🏽", "🇮", "🇮🇹🇫", "\ufe0f", "👍\ufe0f\ufe0f", "🏴󠁧󠁢", "\u202e👍",
+	} {
+		suite.False(IsEmoji(text), "%q", text)
+	}
+}
+
+type EmojiTest struct {
+	Emoji string `json:"emoji" validate:"emoji"`
+}
+
+func (suite *ValidatorTestSuite) TestEmojiInvalid() {
+	err := suite.validator.Struct(&EmojiTest{Emoji: "lol"})
+	suite.Require().NotNil(err)
+
+	expected := response.NewError(httptest.StatusUnprocessableEntity)
+	expected.AddDetail("emoji", "emoji")
+
+	suite.Equal(expected, suite.validator.FormatError(err))
+}
@@ -3,16 +3,31 @@ package ws
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/asiragusa/wschat/entity"
 	"github.com/asiragusa/wschat/interactor"
+	"github.com/asiragusa/wschat/repository"
 	"github.com/asiragusa/wschat/request"
 	"github.com/asiragusa/wschat/response"
 	"github.com/asiragusa/wschat/services"
 	"github.com/asiragusa/wschat/validator"
+	"github.com/jonboulle/clockwork"
 	"github.com/kataras/iris"
 	"github.com/kataras/iris/websocket"
+	"sync"
+	"time"
 )
 
+// Number of deliveries fetched at once while replaying the missed messages
+const replayBatchSize = 100
+
+// Minimum interval between two typing indicators forwarded to the same recipient by a connection.
+// It is shorter than interactor.TypingExpiry, so that the indicator doesn't expire while the user is typing
+const typingThrottle = 2 * time.Second
+
+// Minimum interval between two activity records of a connection
+const activityThrottle = time.Minute
+
 // Websocket response, used to wrap the response.Response.
 // If a requestId is given in the request it is returned to identify the response to the corresponding request
 type WsResponse struct {
@@ -28,15 +43,114 @@ type Handler struct {
 	// Injected via DI
 	PubsubClient services.PubsubClient `inject:""`
 
+	// Injected via DI
+	PresenceTracker services.PresenceTracker `inject:""`
+
 	// Injected via DI
 	CreateMessageInteractor interactor.CreateMessageInteractor `inject:""`
 
+	// Injected via DI
+	CreateConversationMessageInteractor interactor.CreateConversationMessageInteractor `inject:""`
+
+	// Injected via DI
+	ReadMessageInteractor interactor.ReadMessageInteractor `inject:""`
+
+	// Injected via DI
+	EditMessageInteractor interactor.EditMessageInteractor `inject:""`
+
+	// Injected via DI
+	DeleteMessageInteractor interactor.DeleteMessageInteractor `inject:""`
+
+	// Injected via DI
+	AddReactionInteractor interactor.AddReactionInteractor `inject:""`
+
+	// Injected via DI
+	RemoveReactionInteractor interactor.RemoveReactionInteractor `inject:""`
+
+	// Injected via DI
+	TypingInteractor interactor.TypingInteractor `inject:""`
+
+	// Injected via DI
+	DeliveryRepository repository.DeliveryRepository `inject:""`
+
+	// Injected via DI
+	MessageRepository repository.MessageRepository `inject:""`
+
 	// Injected via DI
 	Validator validator.RequestValidator `inject:""`
+
+	// Injected via DI
+	Clock clockwork.Clock `inject:""`
+
+	// If true, the users that haven't verified their email yet can't send messages
+	requireVerifiedEmail bool
+
+	// Live connections of this instance, by user email and connection ID
+	connections map[string]map[string]liveConnection
+	lock        sync.Mutex
+}
+
+// Connection tracked by the handler, with the session of the token used to open it
+type liveConnection struct {
+	conn      websocket.Connection
+	sessionId string
+}
+
+// Creates the handler. requireVerifiedEmail refuses the messages of the users that haven't verified their email yet
+func NewWsHandler(requireVerifiedEmail bool) *Handler {
+	return &Handler{
+		requireVerifiedEmail: requireVerifiedEmail,
+		connections:          map[string]map[string]liveConnection{},
+	}
+}
+
+// Tracks the connection of the user
+func (h *Handler) register(email string, sessionId string, c websocket.Connection) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.connections[email] == nil {
+		h.connections[email] = map[string]liveConnection{}
+	}
+	h.connections[email][c.ID()] = liveConnection{
+		conn:      c,
+		sessionId: sessionId,
+	}
 }
 
-func NewWsHandler() *Handler {
-	return &Handler{}
+// Stops tracking the connection of the user
+func (h *Handler) unregister(email string, c websocket.Connection) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	delete(h.connections[email], c.ID())
+	if len(h.connections[email]) == 0 {
+		delete(h.connections, email)
+	}
+}
+
+// Sends the event to the connections of the session on this instance, then closes them.
+// All the connections of the user are closed if sessionId is empty
+func (h *Handler) Disconnect(email string, sessionId string, event entity.Event) {
+	h.lock.Lock()
+	var connections []websocket.Connection
+	for id, c := range h.connections[email] {
+		if sessionId != "" && c.sessionId != sessionId {
+			continue
+		}
+		connections = append(connections, c.conn)
+		delete(h.connections[email], id)
+	}
+	if len(h.connections[email]) == 0 {
+		delete(h.connections, email)
+	}
+	h.lock.Unlock()
+
+	// The connections are closed without holding the lock, as the disconnection handlers unregister them
+	for _, c := range connections {
+		h.emitEvent(c, event)
+		c.Disconnect()
+	}
 }
 
 // Parse the received message
@@ -74,8 +188,9 @@ func (h *Handler) parseRequest(c websocket.Connection, msg interface{}, dst inte
 	return requestId, true
 }
 
-// Handle the `message` request
-func (h *Handler) handleMessage(c websocket.Connection, requestId string, req request.CreateMessage) {
+// Validates the request, runs call and emits the result.
+// The result is sent back with the `sent` event on success and with the `error` event otherwise
+func (h *Handler) handle(c websocket.Connection, requestId string, req interface{}, call func() response.Response) {
 	// Validate the request
 	if err := h.Validator.Struct(req); err != nil {
 		c.Emit("error", WsResponse{
@@ -85,16 +200,15 @@ func (h *Handler) handleMessage(c websocket.Connection, requestId string, req re
 		return
 	}
 
-	// Create the message
-	res := h.CreateMessageInteractor.Call(req)
+	res := call()
 
-	var successResponse response.CreateMessage
-	// If the response code is not the correct one return the error
-	if res.GetCode() != successResponse.GetCode() {
+	// If the interactor returned an error forward it to the client
+	if _, ok := res.(*response.Error); ok {
 		c.Emit("error", WsResponse{
 			RequestId: requestId,
 			Body:      res,
 		})
+		return
 	}
 
 	// Send a message to confirm that the message has been sent
@@ -104,22 +218,198 @@ func (h *Handler) handleMessage(c websocket.Connection, requestId string, req re
 	})
 }
 
-// Websocket connection handler
+// Emits a 403 error and returns false if the email verification is required and the user hasn't verified its email yet.
+// Mirrors middleware.Authenticated.Verified, which can't protect the websocket: the unverified users still receive
+// the messages, the receipts and the presence of the others
+func (h *Handler) verified(c websocket.Connection, requestId string, user entity.User) bool {
+	if h.requireVerifiedEmail && !user.EmailVerified {
+		error := response.NewError(iris.StatusForbidden)
+		error.AddDetail("email", "notVerified")
+		c.Emit("error", WsResponse{
+			RequestId: requestId,
+			Body:      error,
+		})
+		return false
+	}
+	return true
+}
+
+// Handle the `message` request
+func (h *Handler) handleMessage(c websocket.Connection, requestId string, req request.CreateMessage) {
+	if !h.verified(c, requestId, req.From) {
+		return
+	}
+	h.handle(c, requestId, req, func() response.Response {
+		return h.CreateMessageInteractor.Call(req)
+	})
+}
+
+// Handle the `conversationMessage` request
+func (h *Handler) handleConversationMessage(c websocket.Connection, requestId string, req request.CreateConversationMessage) {
+	if !h.verified(c, requestId, req.From) {
+		return
+	}
+	h.handle(c, requestId, req, func() response.Response {
+		return h.CreateConversationMessageInteractor.Call(req)
+	})
+}
+
+// Handle the `read` request
+func (h *Handler) handleRead(c websocket.Connection, requestId string, req request.ReadMessage) {
+	h.handle(c, requestId, req, func() response.Response {
+		return h.ReadMessageInteractor.Call(req)
+	})
+}
+
+// Handle the `edit` request
+func (h *Handler) handleEdit(c websocket.Connection, requestId string, req request.EditMessage) {
+	h.handle(c, requestId, req, func() response.Response {
+		return h.EditMessageInteractor.Call(req)
+	})
+}
+
+// Handle the `delete` request
+func (h *Handler) handleDelete(c websocket.Connection, requestId string, req request.DeleteMessage) {
+	h.handle(c, requestId, req, func() response.Response {
+		return h.DeleteMessageInteractor.Call(req)
+	})
+}
+
+// Handle the `react` request
+func (h *Handler) handleReact(c websocket.Connection, requestId string, req request.Reaction) {
+	h.handle(c, requestId, req, func() response.Response {
+		return h.AddReactionInteractor.Call(req)
+	})
+}
+
+// Handle the `unreact` request
+func (h *Handler) handleUnreact(c websocket.Connection, requestId string, req request.Reaction) {
+	h.handle(c, requestId, req, func() response.Response {
+		return h.RemoveReactionInteractor.Call(req)
+	})
+}
+
+// Handle the `typing` request
+func (h *Handler) handleTyping(c websocket.Connection, requestId string, req request.Typing) {
+	h.handle(c, requestId, req, func() response.Response {
+		return h.TypingInteractor.Call(req)
+	})
+}
+
+// Sends a received message to the client
+func (h *Handler) emitMessage(c websocket.Connection, message entity.Message) {
+	res := response.CreateMessage{
+		Id:             message.Id,
+		ConversationId: message.ConversationId,
+		From:           message.From,
+		To:             message.To,
+		Message:        message.Message,
+		CreatedAt:      message.CreatedAt,
+		Seq:            message.Seq,
+		ReplyTo:        interactor.NewQuoteResponse(message.ReplyTo),
+	}
+	if len(message.Attachments) > 0 {
+		res.Attachments = interactor.NewAttachmentsResponse(message.Attachments)
+	}
+
+	c.Emit("message", WsResponse{
+		Body: res,
+	})
+}
+
+// Sends a received event to the client
+func (h *Handler) emitEvent(c websocket.Connection, event entity.Event) {
+	c.Emit(event.Type, WsResponse{
+		Body: event.Data,
+	})
+}
+
+// Sends the messages delivered to the "to" user after lastSeq.
+// Returns the sequence number of the last delivery sent
+func (h *Handler) replay(c websocket.Connection, to string, lastSeq int64) (int64, error) {
+	for {
+		deliveries, err := h.DeliveryRepository.AllAfter(to, lastSeq, replayBatchSize)
+		if err != nil {
+			return lastSeq, err
+		}
+
+		var ids []string
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.MessageId)
+		}
+
+		messages, err := h.MessageRepository.GetByIds(ids)
+		if err != nil {
+			return lastSeq, err
+		}
+
+		found := map[string]entity.Message{}
+		for _, message := range messages {
+			found[message.Id] = message
+		}
+
+		for _, delivery := range deliveries {
+			lastSeq = delivery.Seq
+
+			// Skip the messages that don't exist anymore or have been deleted
+			message, ok := found[delivery.MessageId]
+			if !ok || message.Deleted() || !message.HasUser(to) {
+				continue
+			}
+
+			message.Seq = delivery.Seq
+			h.emitMessage(c, message)
+		}
+
+		if len(deliveries) < replayBatchSize {
+			return lastSeq, nil
+		}
+	}
+}
+
+// Websocket connection handler.
+//
+// If the lastSeq query param is given, the messages delivered after it are sent before the live ones
 func (h *Handler) HandleConnection(c websocket.Connection) {
 	// Fetch the user from the request
 	user := c.Context().Values().Get("user").(*entity.User)
+	session := c.Context().Values().Get("session").(*entity.Session)
+
+	// The messages received while replaying are buffered and sent after the replay
+	var lock sync.Mutex
+	replaying := c.Context().URLParamExists("lastSeq")
+	var buffered []entity.Message
 
-	// Subscribe to the messages for user.Email
+	// Subscribe to the messages and the events for user.Email
 	err, cancelFn := h.PubsubClient.Subscribe(user.Email, func(message entity.Message) {
-		c.Emit("message", WsResponse{
-			Body: response.CreateMessage{
-				Id:        message.Id,
-				From:      message.From,
-				To:        message.To,
-				Message:   message.Message,
-				CreatedAt: message.CreatedAt,
-			},
-		})
+		lock.Lock()
+		defer lock.Unlock()
+
+		if replaying {
+			buffered = append(buffered, message)
+			return
+		}
+
+		h.emitMessage(c, message)
+	}, func(event entity.Event) {
+		// The secret of the user has been rotated: the tokens used to open the connections are not valid anymore.
+		// Every connection receives the event, the first one closes all of them
+		if event.Type == interactor.SignedOutEvent {
+			h.Disconnect(user.Email, "", event)
+			return
+		}
+
+		// One of the sessions of the user has been revoked: its connections are closed, the other ones are notified
+		if event.Type == services.SessionRevokedEvent {
+			revoked := entity.SessionRevoked{}
+			if err := json.Unmarshal(event.Data, &revoked); err == nil && revoked.Id == session.Id {
+				h.Disconnect(user.Email, session.Id, event)
+				return
+			}
+		}
+
+		// Events are not replayed, there is no need to buffer them
+		h.emitEvent(c, event)
 	})
 
 	if err != nil {
@@ -128,8 +418,62 @@ func (h *Handler) HandleConnection(c websocket.Connection) {
 		return
 	}
 
+	h.register(user.Email, session.Id, c)
+
+	// Register the connection for the presence. The presence is not essential, the connection is kept on error
+	err, disconnectFn := h.PresenceTracker.Connect(*user)
+	if err != nil {
+		// TODO: properly log the error
+		fmt.Println(err.Error())
+	}
+
+	// Records the activity of the user, at most every activityThrottle
+	lastActivity := h.Clock.Now()
+	active := func() {
+		now := h.Clock.Now()
+		if now.Sub(lastActivity) < activityThrottle {
+			return
+		}
+		lastActivity = now
+
+		if err := h.PresenceTracker.Touch(*user); err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+		}
+	}
+
+	if replaying {
+		// The subscription is already active, so no message can be missed between the replay and the live ones
+		lastSeq, err := c.Context().URLParamInt64("lastSeq")
+		if err != nil {
+			c.Emit("error", WsResponse{
+				Body: response.NewError(iris.StatusBadRequest),
+			})
+		} else if lastSeq, err = h.replay(c, user.Email, lastSeq); err != nil {
+			// TODO: properly log the error
+			fmt.Println(err.Error())
+
+			c.Emit("error", WsResponse{
+				Body: response.NewError(iris.StatusInternalServerError),
+			})
+		}
+
+		lock.Lock()
+		for _, message := range buffered {
+			// Skip the messages already replayed
+			if message.Seq > lastSeq {
+				h.emitMessage(c, message)
+			}
+		}
+		buffered = nil
+		replaying = false
+		lock.Unlock()
+	}
+
 	// Handler for the message request
 	c.On("message", func(msg interface{}) {
+		active()
+
 		var req request.CreateMessage
 
 		// Parse the request
@@ -144,8 +488,144 @@ func (h *Handler) HandleConnection(c websocket.Connection) {
 		h.handleMessage(c, requestId, req)
 	})
 
-	// Handler for the disconnection. Calls the cancelFn of the subscription
+	// Handler for the conversationMessage request
+	c.On("conversationMessage", func(msg interface{}) {
+		active()
+
+		var req request.CreateConversationMessage
+
+		// Parse the request
+		requestId, ok := h.parseRequest(c, msg, &req)
+		if !ok {
+			return
+		}
+
+		req.From = *user
+
+		h.handleConversationMessage(c, requestId, req)
+	})
+
+	// Handler for the read request
+	c.On("read", func(msg interface{}) {
+		active()
+
+		var req request.ReadMessage
+
+		// Parse the request
+		requestId, ok := h.parseRequest(c, msg, &req)
+		if !ok {
+			return
+		}
+
+		req.User = *user
+
+		h.handleRead(c, requestId, req)
+	})
+
+	// Handler for the edit request
+	c.On("edit", func(msg interface{}) {
+		active()
+
+		var req request.EditMessage
+
+		// Parse the request
+		requestId, ok := h.parseRequest(c, msg, &req)
+		if !ok {
+			return
+		}
+
+		req.User = *user
+
+		h.handleEdit(c, requestId, req)
+	})
+
+	// Handler for the delete request
+	c.On("delete", func(msg interface{}) {
+		active()
+
+		var req request.DeleteMessage
+
+		// Parse the request
+		requestId, ok := h.parseRequest(c, msg, &req)
+		if !ok {
+			return
+		}
+
+		req.User = *user
+
+		h.handleDelete(c, requestId, req)
+	})
+
+	// Handler for the react request
+	c.On("react", func(msg interface{}) {
+		active()
+
+		var req request.Reaction
+
+		// Parse the request
+		requestId, ok := h.parseRequest(c, msg, &req)
+		if !ok {
+			return
+		}
+
+		req.User = *user
+
+		h.handleReact(c, requestId, req)
+	})
+
+	// Handler for the unreact request
+	c.On("unreact", func(msg interface{}) {
+		active()
+
+		var req request.Reaction
+
+		// Parse the request
+		requestId, ok := h.parseRequest(c, msg, &req)
+		if !ok {
+			return
+		}
+
+		req.User = *user
+
+		h.handleUnreact(c, requestId, req)
+	})
+
+	// Handler for the typing request. The indicators sent to the same user more often than typingThrottle are not
+	// forwarded, and answered with a 429 error
+	lastTyping := map[string]time.Time{}
+	c.On("typing", func(msg interface{}) {
+		active()
+
+		var req request.Typing
+
+		// Parse the request
+		requestId, ok := h.parseRequest(c, msg, &req)
+		if !ok {
+			return
+		}
+
+		now := h.Clock.Now()
+		if last, ok := lastTyping[req.To]; ok && now.Sub(last) < typingThrottle {
+			c.Emit("error", WsResponse{
+				RequestId: requestId,
+				Body:      response.NewTooManyRequestsError(typingThrottle - now.Sub(last)),
+			})
+			return
+		}
+		lastTyping[req.To] = now
+
+		req.From = *user
+
+		h.handleTyping(c, requestId, req)
+	})
+
+	// Handler for the disconnection. Calls the cancelFn of the subscription and unregisters the connection
 	c.OnDisconnect(func() {
+		h.unregister(user.Email, c)
 		cancelFn()
+
+		if disconnectFn != nil {
+			disconnectFn()
+		}
 	})
 }
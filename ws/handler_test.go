@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/asiragusa/wschat/entity"
+	"github.com/asiragusa/wschat/interactor"
 	"github.com/asiragusa/wschat/mocks"
 	"github.com/asiragusa/wschat/request"
 	"github.com/asiragusa/wschat/response"
+	"github.com/asiragusa/wschat/services"
+	"github.com/jonboulle/clockwork"
 	"github.com/kataras/iris"
 	"github.com/kataras/iris/context"
 	"github.com/kataras/iris/httptest"
@@ -31,13 +34,25 @@ func (m *MockCancel) Call() {
 
 type HandlerTestSuite struct {
 	suite.Suite
-	handler    *Handler
-	interactor *mocks.CreateMessageInteractor
-	pubsub     *mocks.PubsubClient
-	validator  *mocks.RequestValidator
-	user       *entity.User
-	cancel     *MockCancel
-	app        *iris.Application
+	handler                *Handler
+	interactor             *mocks.CreateMessageInteractor
+	conversationInteractor *mocks.CreateConversationMessageInteractor
+	readInteractor         *mocks.ReadMessageInteractor
+	typingInteractor       *mocks.TypingInteractor
+	editInteractor         *mocks.EditMessageInteractor
+	deleteInteractor       *mocks.DeleteMessageInteractor
+	addReaction            *mocks.AddReactionInteractor
+	removeReaction         *mocks.RemoveReactionInteractor
+	deliveryRepository     *mocks.DeliveryRepository
+	messageRepository      *mocks.MessageRepository
+	pubsub                 *mocks.PubsubClient
+	presence               *mocks.PresenceTracker
+	validator              *mocks.RequestValidator
+	user                   *entity.User
+	session                *entity.Session
+	cancel                 *MockCancel
+	clock                  clockwork.FakeClock
+	app                    *iris.Application
 }
 
 func TestListMessagesController(t *testing.T) {
@@ -45,16 +60,20 @@ func TestListMessagesController(t *testing.T) {
 }
 
 func (suite *HandlerTestSuite) SetupSuite() {
-	suite.handler = NewWsHandler()
+	suite.handler = NewWsHandler(false)
 
 	suite.user = &entity.User{
 		Email: "a@b.com",
 	}
+	suite.session = &entity.Session{
+		Id: "sessionId",
+	}
 
 	app := iris.New()
 	suite.app = app
 	app.Use(func(ctx context.Context) {
 		ctx.Values().Set("user", suite.user)
+		ctx.Values().Set("session", suite.session)
 		ctx.Next()
 	})
 
@@ -73,17 +92,51 @@ func (suite *HandlerTestSuite) SetupSuite() {
 
 func (suite *HandlerTestSuite) SetupTest() {
 	suite.interactor = &mocks.CreateMessageInteractor{}
+	suite.conversationInteractor = &mocks.CreateConversationMessageInteractor{}
+	suite.readInteractor = &mocks.ReadMessageInteractor{}
+	suite.typingInteractor = &mocks.TypingInteractor{}
+	suite.editInteractor = &mocks.EditMessageInteractor{}
+	suite.deleteInteractor = &mocks.DeleteMessageInteractor{}
+	suite.addReaction = &mocks.AddReactionInteractor{}
+	suite.removeReaction = &mocks.RemoveReactionInteractor{}
+	suite.deliveryRepository = &mocks.DeliveryRepository{}
+	suite.messageRepository = &mocks.MessageRepository{}
 	suite.pubsub = &mocks.PubsubClient{}
+	suite.presence = &mocks.PresenceTracker{}
 	suite.validator = &mocks.RequestValidator{}
 	suite.cancel = &MockCancel{}
+	suite.clock = clockwork.NewFakeClock()
+
+	// The presence is checked by TestPresence only
+	suite.presence.On("Connect", *suite.user).Return(nil, nil)
 
 	suite.handler.CreateMessageInteractor = suite.interactor
+	suite.handler.CreateConversationMessageInteractor = suite.conversationInteractor
+	suite.handler.ReadMessageInteractor = suite.readInteractor
+	suite.handler.TypingInteractor = suite.typingInteractor
+	suite.handler.EditMessageInteractor = suite.editInteractor
+	suite.handler.DeleteMessageInteractor = suite.deleteInteractor
+	suite.handler.AddReactionInteractor = suite.addReaction
+	suite.handler.RemoveReactionInteractor = suite.removeReaction
+	suite.handler.DeliveryRepository = suite.deliveryRepository
+	suite.handler.MessageRepository = suite.messageRepository
 	suite.handler.PubsubClient = suite.pubsub
+	suite.handler.PresenceTracker = suite.presence
 	suite.handler.Validator = suite.validator
+	suite.handler.Clock = suite.clock
 }
 
 func (suite *HandlerTestSuite) TearDownTest() {
 	suite.interactor.AssertExpectations(suite.T())
+	suite.conversationInteractor.AssertExpectations(suite.T())
+	suite.readInteractor.AssertExpectations(suite.T())
+	suite.typingInteractor.AssertExpectations(suite.T())
+	suite.editInteractor.AssertExpectations(suite.T())
+	suite.deleteInteractor.AssertExpectations(suite.T())
+	suite.addReaction.AssertExpectations(suite.T())
+	suite.removeReaction.AssertExpectations(suite.T())
+	suite.deliveryRepository.AssertExpectations(suite.T())
+	suite.messageRepository.AssertExpectations(suite.T())
 	suite.pubsub.AssertExpectations(suite.T())
 	suite.validator.AssertExpectations(suite.T())
 	suite.cancel.AssertExpectations(suite.T())
@@ -94,8 +147,12 @@ func (suite *HandlerTestSuite) TearDownSuite() {
 }
 
 func (suite *HandlerTestSuite) getWsConn() *websocket2.Conn {
+	return suite.getWsConnWithQuery("")
+}
+
+func (suite *HandlerTestSuite) getWsConnWithQuery(query string) *websocket2.Conn {
 	origin := "http://localhost:8081/"
-	url := "ws://localhost:8081/"
+	url := "ws://localhost:8081/" + query
 
 	var ws *websocket2.Conn
 	var err error
@@ -148,7 +205,7 @@ func (suite *HandlerTestSuite) sendMesasge(ws *websocket2.Conn, event string, v
 }
 
 func (suite *HandlerTestSuite) TestSubscriberAnError() {
-	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything).Return(assert.AnError, nil)
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(assert.AnError, nil)
 	conn := suite.getWsConn()
 
 	err := conn.Close()
@@ -156,7 +213,7 @@ func (suite *HandlerTestSuite) TestSubscriberAnError() {
 }
 
 func (suite *HandlerTestSuite) TestDisconnect() {
-	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
 	suite.cancel.On("Call")
 
 	conn := suite.getWsConn()
@@ -175,7 +232,7 @@ func (suite *HandlerTestSuite) TestReceiveMessage() {
 	suite.pubsub.On("Subscribe", suite.user.Email, mock.MatchedBy(func(fn func(entity.Message)) bool {
 		theFn = fn
 		return true
-	})).Return(nil, suite.cancel.Call)
+	}), mock.Anything).Return(nil, suite.cancel.Call)
 
 	conn := suite.getWsConn()
 
@@ -184,6 +241,20 @@ func (suite *HandlerTestSuite) TestReceiveMessage() {
 		From:    "from",
 		To:      "to",
 		Message: "message",
+		ReplyTo: entity.Reply{
+			MessageId: "repliedId",
+			From:      "to",
+			Snippet:   "replied",
+		},
+		Attachments: []entity.Attachment{
+			{
+				Id:       "attachmentId",
+				Name:     "file.png",
+				MimeType: "image/png",
+				Size:     42,
+				Checksum: "checksum",
+			},
+		},
 	}
 	suite.Require().NotNil(theFn)
 	theFn(message)
@@ -206,7 +277,7 @@ func (suite *HandlerTestSuite) TestReceiveMessage() {
 }
 
 func (suite *HandlerTestSuite) TestSendMessageInvalid() {
-	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
 	suite.cancel.On("Call")
 
 	conn := suite.getWsConn()
@@ -239,7 +310,7 @@ func (suite *HandlerTestSuite) TestSendMessageInvalid() {
 }
 
 func (suite *HandlerTestSuite) TestSendMessageInteractorError() {
-	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
 	suite.cancel.On("Call")
 
 	conn := suite.getWsConn()
@@ -267,7 +338,7 @@ func (suite *HandlerTestSuite) TestSendMessageInteractorError() {
 }
 
 func (suite *HandlerTestSuite) TestSendMessageOK() {
-	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
 	suite.cancel.On("Call")
 
 	conn := suite.getWsConn()
@@ -308,3 +379,522 @@ func (suite *HandlerTestSuite) TestSendMessageOK() {
 
 	time.Sleep(time.Millisecond * 100)
 }
+
+func (suite *HandlerTestSuite) TestSendConversationMessageOK() {
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.cancel.On("Call")
+
+	conn := suite.getWsConn()
+
+	req := request.CreateConversationMessage{
+		From:           *suite.user,
+		ConversationId: "conversationId",
+		Message:        "message",
+	}
+	createMessageResponse := response.CreateMessage{
+		Id:             "id",
+		ConversationId: "conversationId",
+		From:           "a@b.com",
+		Message:        "message",
+	}
+
+	suite.validator.On("Struct", req).Return(nil)
+	suite.conversationInteractor.On("Call", req).Return(createMessageResponse)
+	suite.sendMesasge(conn, "conversationMessage", map[string]interface{}{
+		"conversationId": "conversationId",
+		"message":        "message",
+	})
+
+	type Success struct {
+		RequestId string                 `json:"requestId"`
+		Body      response.CreateMessage `json:"body"`
+	}
+
+	var res Success
+	event := suite.readMessage(conn, &res)
+	suite.Require().Equal("sent", event)
+	suite.Equal("aRequestId", res.RequestId)
+	suite.Equal(createMessageResponse, res.Body)
+
+	err := conn.Close()
+	suite.Require().NoError(err)
+
+	time.Sleep(time.Millisecond * 100)
+}
+
+func (suite *HandlerTestSuite) TestSendNotVerified() {
+	suite.handler.requireVerifiedEmail = true
+	defer func() { suite.handler.requireVerifiedEmail = false }()
+
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.cancel.On("Call")
+
+	conn := suite.getWsConn()
+
+	// Neither the messages nor the conversation messages reach the interactors
+	for _, event := range []string{"message", "conversationMessage"} {
+		suite.sendMesasge(conn, event, map[string]interface{}{
+			"to":             "a@b.com",
+			"conversationId": "conversationId",
+			"message":        "message",
+		})
+
+		type Error struct {
+			RequestId string         `json:"requestId"`
+			Body      response.Error `json:"body"`
+		}
+
+		var res Error
+		suite.Require().Equal("error", suite.readMessage(conn, &res))
+		suite.Equal("aRequestId", res.RequestId)
+		suite.Equal(httptest.StatusForbidden, res.Body.Code)
+		suite.Equal([]string{"notVerified"}, res.Body.Details["email"])
+	}
+
+	err := conn.Close()
+	suite.Require().NoError(err)
+
+	time.Sleep(time.Millisecond * 100)
+}
+
+func (suite *HandlerTestSuite) TestReadOK() {
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.cancel.On("Call")
+
+	conn := suite.getWsConn()
+
+	req := request.ReadMessage{
+		User:      *suite.user,
+		MessageId: "messageId",
+	}
+	readMessageResponse := response.ReadMessage{
+		MessageId: "messageId",
+		Receipt: response.Receipt{
+			User:   "a@b.com",
+			ReadAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	suite.validator.On("Struct", req).Return(nil)
+	suite.readInteractor.On("Call", req).Return(readMessageResponse)
+	suite.sendMesasge(conn, "read", map[string]interface{}{
+		"messageId": "messageId",
+	})
+
+	type Success struct {
+		RequestId string               `json:"requestId"`
+		Body      response.ReadMessage `json:"body"`
+	}
+
+	var res Success
+	event := suite.readMessage(conn, &res)
+	suite.Require().Equal("sent", event)
+	suite.Equal("aRequestId", res.RequestId)
+	suite.Equal(readMessageResponse, res.Body)
+
+	err := conn.Close()
+	suite.Require().NoError(err)
+
+	time.Sleep(time.Millisecond * 100)
+}
+
+func (suite *HandlerTestSuite) TestTypingThrottle() {
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.cancel.On("Call")
+
+	conn := suite.getWsConn()
+
+	req := request.Typing{
+		From: *suite.user,
+		To:   "b@b.com",
+	}
+	typingResponse := response.Typing{
+		From:      "a@b.com",
+		To:        "b@b.com",
+		ExpiresAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	type Success struct {
+		RequestId string          `json:"requestId"`
+		Body      response.Typing `json:"body"`
+	}
+
+	suite.validator.On("Struct", req).Return(nil)
+	suite.typingInteractor.On("Call", req).Twice().Return(typingResponse)
+
+	// The second indicator isn't forwarded
+	for i := 0; i < 2; i++ {
+		suite.sendMesasge(conn, "typing", map[string]interface{}{
+			"to": "b@b.com",
+		})
+	}
+
+	var res Success
+	event := suite.readMessage(conn, &res)
+	suite.Require().Equal("sent", event)
+	suite.Equal("aRequestId", res.RequestId)
+	suite.Equal(typingResponse, res.Body)
+
+	// But it's answered, telling the client when the next one will be
+	var throttled struct {
+		RequestId string         `json:"requestId"`
+		Body      response.Error `json:"body"`
+	}
+	event = suite.readMessage(conn, &throttled)
+	suite.Require().Equal("error", event)
+	suite.Equal("aRequestId", throttled.RequestId)
+	suite.Equal(httptest.StatusTooManyRequests, throttled.Body.Code)
+	suite.Equal(int(typingThrottle.Seconds()), throttled.Body.RetryAfter)
+
+	// Once the throttle interval is elapsed the indicator is forwarded again
+	suite.clock.Advance(typingThrottle)
+	suite.sendMesasge(conn, "typing", map[string]interface{}{
+		"to": "b@b.com",
+	})
+
+	event = suite.readMessage(conn, &res)
+	suite.Require().Equal("sent", event)
+
+	err := conn.Close()
+	suite.Require().NoError(err)
+
+	time.Sleep(time.Millisecond * 100)
+}
+
+func (suite *HandlerTestSuite) TestEditOK() {
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.cancel.On("Call")
+
+	conn := suite.getWsConn()
+
+	req := request.EditMessage{
+		User:      *suite.user,
+		MessageId: "messageId",
+		Message:   "new",
+	}
+	editedAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	editMessageResponse := response.EditMessage{
+		Message: response.Message{
+			Id:       "messageId",
+			From:     "a@b.com",
+			Message:  "new",
+			EditedAt: &editedAt,
+		},
+	}
+
+	suite.validator.On("Struct", req).Return(nil)
+	suite.editInteractor.On("Call", req).Return(editMessageResponse)
+	suite.sendMesasge(conn, "edit", map[string]interface{}{
+		"messageId": "messageId",
+		"message":   "new",
+	})
+
+	type Success struct {
+		RequestId string               `json:"requestId"`
+		Body      response.EditMessage `json:"body"`
+	}
+
+	var res Success
+	event := suite.readMessage(conn, &res)
+	suite.Require().Equal("sent", event)
+	suite.Equal("aRequestId", res.RequestId)
+	suite.Equal(editMessageResponse, res.Body)
+
+	err := conn.Close()
+	suite.Require().NoError(err)
+
+	time.Sleep(time.Millisecond * 100)
+}
+
+func (suite *HandlerTestSuite) TestDeleteOK() {
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.cancel.On("Call")
+
+	conn := suite.getWsConn()
+
+	req := request.DeleteMessage{
+		User:      *suite.user,
+		MessageId: "messageId",
+		Scope:     "everyone",
+	}
+	deletedAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	deleteMessageResponse := response.DeleteMessage{
+		MessageId: "messageId",
+		Scope:     "everyone",
+		DeletedAt: &deletedAt,
+	}
+
+	suite.validator.On("Struct", req).Return(nil)
+	suite.deleteInteractor.On("Call", req).Return(deleteMessageResponse)
+	suite.sendMesasge(conn, "delete", map[string]interface{}{
+		"messageId": "messageId",
+		"scope":     "everyone",
+	})
+
+	type Success struct {
+		RequestId string                 `json:"requestId"`
+		Body      response.DeleteMessage `json:"body"`
+	}
+
+	var res Success
+	event := suite.readMessage(conn, &res)
+	suite.Require().Equal("sent", event)
+	suite.Equal("aRequestId", res.RequestId)
+	suite.Equal(deleteMessageResponse, res.Body)
+
+	err := conn.Close()
+	suite.Require().NoError(err)
+
+	time.Sleep(time.Millisecond * 100)
+}
+
+func (suite *HandlerTestSuite) TestReactions() {
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.cancel.On("Call")
+
+	conn := suite.getWsConn()
+
+	req := request.Reaction{
+		User:      *suite.user,
+		MessageId: "messageId",
+		Emoji:     "👍",
+	}
+	added := response.Reactions{
+		MessageId: "messageId",
+		Reactions: []response.Reaction{
+			{Emoji: "👍", Count: 1, Users: []string{suite.user.Email}},
+		},
+	}
+	removed := response.Reactions{
+		MessageId: "messageId",
+		Reactions: []response.Reaction{},
+	}
+
+	suite.validator.On("Struct", req).Return(nil)
+	suite.addReaction.On("Call", req).Return(added)
+	suite.removeReaction.On("Call", req).Return(removed)
+
+	type Success struct {
+		RequestId string             `json:"requestId"`
+		Body      response.Reactions `json:"body"`
+	}
+
+	for _, test := range []struct {
+		event    string
+		expected response.Reactions
+	}{
+		{"react", added},
+		{"unreact", removed},
+	} {
+		suite.sendMesasge(conn, test.event, map[string]interface{}{
+			"messageId": "messageId",
+			"emoji":     "👍",
+		})
+
+		var res Success
+		event := suite.readMessage(conn, &res)
+		suite.Require().Equal("sent", event)
+		suite.Equal(test.expected, res.Body)
+	}
+
+	err := conn.Close()
+	suite.Require().NoError(err)
+
+	time.Sleep(time.Millisecond * 100)
+}
+
+func (suite *HandlerTestSuite) TestReceiveEvent() {
+	suite.cancel.On("Call")
+
+	var theFn func(entity.Event)
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.MatchedBy(func(fn func(entity.Event)) bool {
+		theFn = fn
+		return true
+	})).Return(nil, suite.cancel.Call)
+
+	conn := suite.getWsConn()
+
+	event, err := entity.NewEvent("read", []string{suite.user.Email}, map[string]string{"messageId": "messageId"})
+	suite.Require().NoError(err)
+	theFn(event)
+
+	var res struct {
+		Body map[string]string `json:"body"`
+	}
+	name := suite.readMessage(conn, &res)
+	suite.Require().Equal("read", name)
+	suite.Equal(map[string]string{"messageId": "messageId"}, res.Body)
+
+	err = conn.Close()
+	suite.Require().NoError(err)
+
+	time.Sleep(time.Millisecond * 100)
+}
+
+func (suite *HandlerTestSuite) TestSignedOut() {
+	suite.cancel.On("Call")
+
+	var theFn func(entity.Event)
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.MatchedBy(func(fn func(entity.Event)) bool {
+		theFn = fn
+		return true
+	})).Return(nil, suite.cancel.Call)
+
+	conn := suite.getWsConn()
+
+	event, err := entity.NewEvent(interactor.SignedOutEvent, []string{suite.user.Email}, map[string]string{"email": suite.user.Email})
+	suite.Require().NoError(err)
+	theFn(event)
+
+	var res struct {
+		Body map[string]string `json:"body"`
+	}
+	name := suite.readMessage(conn, &res)
+	suite.Require().Equal(interactor.SignedOutEvent, name)
+	suite.Equal(map[string]string{"email": suite.user.Email}, res.Body)
+
+	// The connection has been closed by the server and is not tracked anymore
+	_, err = conn.Read(make([]byte, 1024))
+	suite.Error(err)
+
+	time.Sleep(time.Millisecond * 100)
+
+	suite.handler.lock.Lock()
+	suite.Empty(suite.handler.connections[suite.user.Email])
+	suite.handler.lock.Unlock()
+}
+
+func (suite *HandlerTestSuite) TestSessionRevoked() {
+	suite.cancel.On("Call")
+
+	var theFn func(entity.Event)
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.MatchedBy(func(fn func(entity.Event)) bool {
+		theFn = fn
+		return true
+	})).Return(nil, suite.cancel.Call)
+
+	conn := suite.getWsConn()
+
+	// Another session has been revoked: the connection is only notified
+	event, err := entity.NewEvent(services.SessionRevokedEvent, []string{suite.user.Email}, entity.SessionRevoked{Id: "otherId"})
+	suite.Require().NoError(err)
+	theFn(event)
+
+	var res struct {
+		Body entity.SessionRevoked `json:"body"`
+	}
+	name := suite.readMessage(conn, &res)
+	suite.Require().Equal(services.SessionRevokedEvent, name)
+	suite.Equal("otherId", res.Body.Id)
+
+	suite.handler.lock.Lock()
+	suite.Len(suite.handler.connections[suite.user.Email], 1)
+	suite.handler.lock.Unlock()
+
+	// The session of the connection has been revoked: the connection is closed
+	event, err = entity.NewEvent(services.SessionRevokedEvent, []string{suite.user.Email}, entity.SessionRevoked{Id: "sessionId"})
+	suite.Require().NoError(err)
+	theFn(event)
+
+	name = suite.readMessage(conn, &res)
+	suite.Require().Equal(services.SessionRevokedEvent, name)
+	suite.Equal("sessionId", res.Body.Id)
+
+	_, err = conn.Read(make([]byte, 1024))
+	suite.Error(err)
+
+	time.Sleep(time.Millisecond * 100)
+
+	suite.handler.lock.Lock()
+	suite.Empty(suite.handler.connections[suite.user.Email])
+	suite.handler.lock.Unlock()
+}
+
+func (suite *HandlerTestSuite) TestReplay() {
+	suite.cancel.On("Call")
+
+	var theFn func(entity.Message)
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.MatchedBy(func(fn func(entity.Message)) bool {
+		theFn = fn
+		return true
+	}), mock.Anything).Return(nil, suite.cancel.Call)
+
+	suite.deliveryRepository.On("AllAfter", suite.user.Email, int64(1), replayBatchSize).Run(func(mock.Arguments) {
+		// A message received while replaying is sent after the replayed ones, duplicates are skipped
+		theFn(entity.Message{Id: "id2", Seq: 2})
+		theFn(entity.Message{Id: "id6", Seq: 6})
+	}).Return([]entity.Delivery{
+		{Seq: 2, MessageId: "id2"},
+		{Seq: 3, MessageId: "id3"},
+		{Seq: 4, MessageId: "id4"},
+		{Seq: 5, MessageId: "id5"},
+	}, nil)
+
+	// The messages deleted for everyone or by the user are skipped
+	users := []string{suite.user.Email, "b@b.com"}
+	suite.messageRepository.On("GetByIds", []string{"id2", "id3", "id4", "id5"}).Return([]entity.Message{
+		{Id: "id2", Users: users},
+		{Id: "id3", Users: users},
+		{Id: "id4", Users: users, DeletedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Id: "id5", Users: []string{"b@b.com"}},
+	}, nil)
+
+	conn := suite.getWsConnWithQuery("?lastSeq=1")
+
+	type Res struct {
+		Body entity.Message `json:"body"`
+	}
+
+	for _, expected := range []entity.Message{{Id: "id2", Seq: 2}, {Id: "id3", Seq: 3}, {Id: "id6", Seq: 6}} {
+		var msg Res
+		event := suite.readMessage(conn, &msg)
+		suite.Require().Equal("message", event)
+		suite.Equal(expected.Id, msg.Body.Id)
+		suite.Equal(expected.Seq, msg.Body.Seq)
+	}
+
+	err := conn.Close()
+
+	time.Sleep(time.Millisecond * 100)
+
+	suite.Require().NoError(err)
+}
+
+func (suite *HandlerTestSuite) TestPresence() {
+	suite.pubsub.On("Subscribe", suite.user.Email, mock.Anything, mock.Anything).Return(nil, suite.cancel.Call)
+	suite.cancel.On("Call")
+
+	disconnect := &MockCancel{}
+	disconnect.On("Call")
+	suite.presence = &mocks.PresenceTracker{}
+	suite.presence.On("Connect", *suite.user).Return(nil, disconnect.Call)
+	suite.presence.On("Touch", *suite.user).Once().Return(nil)
+	suite.handler.PresenceTracker = suite.presence
+
+	conn := suite.getWsConn()
+
+	req := request.Typing{
+		From: *suite.user,
+		To:   "b@b.com",
+	}
+	suite.validator.On("Struct", req).Return(nil)
+	suite.typingInteractor.On("Call", req).Return(response.Typing{})
+
+	// The activity is recorded once the throttle interval is elapsed
+	suite.clock.Advance(activityThrottle)
+	suite.sendMesasge(conn, "typing", map[string]interface{}{
+		"to": "b@b.com",
+	})
+
+	var res interface{}
+	event := suite.readMessage(conn, &res)
+	suite.Require().Equal("sent", event)
+
+	err := conn.Close()
+	suite.Require().NoError(err)
+
+	time.Sleep(time.Millisecond * 100)
+
+	suite.presence.AssertExpectations(suite.T())
+	disconnect.AssertExpectations(suite.T())
+}